@@ -0,0 +1,140 @@
+package context
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/openapi/nrf/AccessToken"
+	"github.com/free5gc/udr/internal/logger"
+	udr_metrics "github.com/free5gc/udr/internal/metrics"
+)
+
+// accessTokenRefreshRatio is the share of its lifetime after which a token still in use is refreshed
+const accessTokenRefreshRatio = 0.8
+
+type accessTokenKey struct {
+	targetNF models.NrfNfManagementNfType
+	scope    string
+}
+
+func (k accessTokenKey) String() string {
+	return string(k.targetNF) + " " + k.scope
+}
+
+type cachedAccessToken struct {
+	token *oauth2.Token
+	// used tells whether the token was handed out since it was stored, an unused token is left to expire
+	used    atomic.Bool
+	refresh *time.Timer
+}
+
+// accessTokenFetcher requests an access token to the NRF
+type accessTokenFetcher func(key accessTokenKey) (*models.NrfAccessTokenAccessTokenRsp, error)
+
+// accessTokenCache keeps the NRF access tokens by target NF type and scope until they expire, refreshing
+// them in the background while they are in use. Concurrent requests of the same token wait for a single
+// access token request to the NRF.
+type accessTokenCache struct {
+	mu     sync.Mutex
+	tokens map[accessTokenKey]*cachedAccessToken
+	group  singleflight.Group
+	fetch  accessTokenFetcher
+}
+
+func newAccessTokenCache(fetch accessTokenFetcher) *accessTokenCache {
+	return &accessTokenCache{
+		tokens: make(map[accessTokenKey]*cachedAccessToken),
+		fetch:  fetch,
+	}
+}
+
+func (tc *accessTokenCache) get(key accessTokenKey) (*oauth2.Token, error) {
+	tc.mu.Lock()
+	cached, ok := tc.tokens[key]
+	tc.mu.Unlock()
+	if ok && time.Now().Before(cached.token.Expiry) {
+		cached.used.Store(true)
+		udr_metrics.IncrAccessTokenCacheCounter(true)
+		return cached.token, nil
+	}
+
+	udr_metrics.IncrAccessTokenCacheCounter(false)
+	return tc.refresh(key)
+}
+
+func (tc *accessTokenCache) refresh(key accessTokenKey) (*oauth2.Token, error) {
+	token, err, _ := tc.group.Do(key.String(), func() (interface{}, error) {
+		rsp, err := tc.fetch(key)
+		if err != nil {
+			return nil, err
+		}
+		lifetime := time.Duration(rsp.ExpiresIn) * time.Second
+		token := &oauth2.Token{
+			AccessToken: rsp.AccessToken,
+			TokenType:   rsp.TokenType,
+			Expiry:      time.Now().Add(lifetime),
+		}
+		if lifetime > 0 {
+			tc.store(key, token, lifetime)
+		}
+		return token, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return token.(*oauth2.Token), nil
+}
+
+func (tc *accessTokenCache) store(key accessTokenKey, token *oauth2.Token, lifetime time.Duration) {
+	cached := &cachedAccessToken{token: token}
+	cached.refresh = time.AfterFunc(time.Duration(float64(lifetime)*accessTokenRefreshRatio), func() {
+		if !cached.used.Load() {
+			return
+		}
+		if _, err := tc.refresh(key); err != nil {
+			logger.CtxLog.Warnf("Refresh access token for %s failed: %+v", key, err)
+		}
+	})
+
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if previous, ok := tc.tokens[key]; ok {
+		previous.refresh.Stop()
+	}
+	tc.tokens[key] = cached
+}
+
+func (tc *accessTokenCache) invalidate(key accessTokenKey) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if cached, ok := tc.tokens[key]; ok {
+		cached.refresh.Stop()
+		delete(tc.tokens, key)
+	}
+}
+
+// requestAccessToken sends the access token request of the UDR to the NRF
+func (c *UDRContext) requestAccessToken(key accessTokenKey) (*models.NrfAccessTokenAccessTokenRsp, error) {
+	configuration := AccessToken.NewConfiguration()
+	configuration.SetBasePath(c.NrfUri)
+	client := AccessToken.NewAPIClient(configuration)
+
+	req := &AccessToken.AccessTokenRequestRequest{}
+	req.SetGrantType("client_credentials")
+	req.SetNfInstanceId(c.NfId)
+	req.SetNfType(models.NrfNfManagementNfType_UDR)
+	req.SetTargetNfType(key.targetNF)
+	req.SetScope(key.scope)
+
+	rsp, err := client.AccessTokenRequestApi.AccessTokenRequest(context.Background(), req)
+	if err != nil {
+		return nil, err
+	}
+	return &rsp.NrfAccessTokenAccessTokenRsp, nil
+}
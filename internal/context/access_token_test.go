@@ -0,0 +1,87 @@
+package context
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/free5gc/openapi/models"
+)
+
+func TestAccessTokenCache(t *testing.T) {
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	tc := newAccessTokenCache(func(key accessTokenKey) (*models.NrfAccessTokenAccessTokenRsp, error) {
+		n := fetches.Add(1)
+		<-release
+		if key.scope == "nnrf-disc" {
+			return nil, errors.New("NRF unreachable")
+		}
+		return &models.NrfAccessTokenAccessTokenRsp{
+			AccessToken: key.scope + "-" + string(rune('0'+n)),
+			TokenType:   "Bearer",
+			ExpiresIn:   1,
+		}, nil
+	})
+	nfm := accessTokenKey{targetNF: models.NrfNfManagementNfType_NRF, scope: "nnrf-nfm"}
+
+	t.Run("Concurrent Misses Share One Request", func(t *testing.T) {
+		var wg sync.WaitGroup
+		tokens := make([]string, 10)
+		for i := range tokens {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				token, err := tc.get(nfm)
+				require.NoError(t, err)
+				tokens[i] = token.AccessToken
+			}()
+		}
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		require.Equal(t, int32(1), fetches.Load())
+		for _, token := range tokens {
+			require.Equal(t, "nnrf-nfm-1", token)
+		}
+	})
+
+	t.Run("Hit", func(t *testing.T) {
+		token, err := tc.get(nfm)
+		require.NoError(t, err)
+		require.Equal(t, "nnrf-nfm-1", token.AccessToken)
+		require.Equal(t, int32(1), fetches.Load())
+	})
+
+	t.Run("Refreshed Before Expiry", func(t *testing.T) {
+		require.Eventually(t, func() bool {
+			return fetches.Load() == 2
+		}, 2*time.Second, 50*time.Millisecond)
+		token, err := tc.get(nfm)
+		require.NoError(t, err)
+		require.Equal(t, "nnrf-nfm-2", token.AccessToken)
+	})
+
+	t.Run("Invalidated", func(t *testing.T) {
+		tc.invalidate(nfm)
+		token, err := tc.get(nfm)
+		require.NoError(t, err)
+		require.Equal(t, "nnrf-nfm-3", token.AccessToken)
+		tc.invalidate(nfm)
+	})
+
+	t.Run("Request Failure Not Cached", func(t *testing.T) {
+		disc := accessTokenKey{targetNF: models.NrfNfManagementNfType_NRF, scope: "nnrf-disc"}
+		before := fetches.Load()
+		_, err := tc.get(disc)
+		require.Error(t, err)
+		_, err = tc.get(disc)
+		require.Error(t, err)
+		require.Equal(t, before+2, fetches.Load())
+	})
+}
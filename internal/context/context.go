@@ -4,14 +4,18 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"net"
 	"os"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
+	"golang.org/x/oauth2"
 
+	"github.com/free5gc/openapi"
 	"github.com/free5gc/openapi/models"
 	"github.com/free5gc/openapi/oauth"
 	"github.com/free5gc/udr/internal/logger"
@@ -37,6 +41,7 @@ func Init() {
 	udrContext.SubscriptionDataSubscriptions = make(map[subsId]*models.SubscriptionDataSubscriptions)
 	udrContext.PolicyDataSubscriptions = make(map[subsId]*models.PolicyDataSubscription)
 	udrContext.InfluenceDataSubscriptionIDGenerator = rand.New(rand.NewSource(time.Now().UTC().UnixNano()))
+	udrContext.accessTokens = newAccessTokenCache(udrContext.requestAccessToken)
 
 	serviceName := []models.ServiceName{
 		models.ServiceName_NUDR_DR,
@@ -55,7 +60,10 @@ type UDRContext struct {
 	SBIPort                                 int
 	NfService                               map[models.ServiceName]models.NrfNfManagementNfService
 	RegisterIPv4                            string // IP register to NRF
+	RegisterIPv6                            string // IPv6 register to NRF
+	BindingIPv6                             string
 	HttpIPv6Address                         string
+	IpEndPoints                             []models.IpEndPoint // endpoints advertised to NRF
 	NfId                                    string
 	NrfUri                                  string
 	NrfCertPem                              string
@@ -67,27 +75,30 @@ type UDRContext struct {
 	UESubsCollection                        sync.Map // map[ueId]*UESubsData
 	UEGroupCollection                       sync.Map // map[ueGroupId]*UEGroupSubsData
 	SubscriptionDataSubscriptions           map[subsId]*models.SubscriptionDataSubscriptions
+	subscriptionDataSubscriptionsMtx        sync.RWMutex
 	PolicyDataSubscriptions                 map[subsId]*models.PolicyDataSubscription
 	InfluenceDataSubscriptions              sync.Map
 	appDataInfluDataSubscriptionIdGenerator uint64
 	mtx                                     sync.RWMutex
-	OAuth2Required                          bool
+	OAuth2SkipVerification                  bool
+	// oauth2Required and nrfHeartBeatTimer are written by the NRF registration while the requests read them
+	oauth2Required atomic.Bool
+	// nrfHeartBeatTimer is in seconds, given by NRF at registration
+	nrfHeartBeatTimer atomic.Int32
+	// accessTokens caches the access tokens of the outbound requests once OAuth2 is required
+	accessTokens *accessTokenCache
 }
 
+// UESubsData holds the SDM subscriptions of a UE, the embedded Mutex guards them
 type UESubsData struct {
-	EeSubscriptionCollection map[subsId]*EeSubscriptionCollection
-	SdmSubscriptions         map[subsId]*models.SdmSubscription
+	sync.Mutex
+	SdmSubscriptions map[subsId]*models.SdmSubscription
 }
 
 type UEGroupSubsData struct {
 	EeSubscriptions map[subsId]*models.EeSubscription
 }
 
-type EeSubscriptionCollection struct {
-	EeSubscriptions      *models.EeSubscription
-	AmfSubscriptionInfos []models.AmfSubscriptionInfo
-}
-
 type NFContext interface {
 	AuthorizationCheck(token string, serviceName models.ServiceName) error
 }
@@ -104,9 +115,11 @@ func (context *UDRContext) Reset() {
 		context.UEGroupCollection.Delete(key)
 		return true
 	})
+	context.subscriptionDataSubscriptionsMtx.Lock()
 	for key := range context.SubscriptionDataSubscriptions {
 		delete(context.SubscriptionDataSubscriptions, key)
 	}
+	context.subscriptionDataSubscriptionsMtx.Unlock()
 	for key := range context.PolicyDataSubscriptions {
 		delete(context.PolicyDataSubscriptions, key)
 	}
@@ -149,6 +162,27 @@ func initUdrContext() {
 				udrContext.BindingIPv4 = "0.0.0.0"
 			}
 		}
+
+		if sbi.BindingIPv6 != nil {
+			udrContext.BindingIPv6 = *sbi.BindingIPv6
+			if udrContext.BindingIPv6 == "" {
+				udrContext.BindingIPv6 = "::"
+			}
+		}
+		udrContext.RegisterIPv6 = sbi.RegisterIPv6
+		if udrContext.RegisterIPv6 == "" && udrContext.BindingIPv6 != "::" {
+			udrContext.RegisterIPv6 = udrContext.BindingIPv6
+		}
+
+		initAdvertisedEndPoints(sbi)
+
+		if sbi.OAuth != nil && sbi.OAuth.Enable {
+			udrContext.oauth2Required.Store(true)
+			udrContext.OAuth2SkipVerification = sbi.OAuth.SkipVerification
+			if udrContext.OAuth2SkipVerification {
+				logger.UtilLog.Warn("OAuth2 access token verification is skipped, do not use it in production")
+			}
+		}
 	}
 	if configuration.NrfUri != "" {
 		udrContext.NrfUri = configuration.NrfUri
@@ -159,6 +193,51 @@ func initUdrContext() {
 	udrContext.NrfCertPem = configuration.NrfCertPem
 }
 
+// initAdvertisedEndPoints collects the endpoints put in the NF profile, with a bindings list only the
+// advertised ones are kept and the first of them becomes the API prefix
+func initAdvertisedEndPoints(sbi *factory.Sbi) {
+	udrContext.IpEndPoints = nil
+
+	if len(sbi.Bindings) == 0 {
+		udrContext.IpEndPoints = append(udrContext.IpEndPoints, newIpEndPoint(udrContext.RegisterIPv4, udrContext.SBIPort))
+		if udrContext.RegisterIPv6 != "" {
+			udrContext.IpEndPoints = append(udrContext.IpEndPoints, newIpEndPoint(udrContext.RegisterIPv6, udrContext.SBIPort))
+		}
+		return
+	}
+
+	for _, binding := range sbi.Bindings {
+		if !binding.Advertised {
+			continue
+		}
+
+		registerIP := binding.GetRegisterIP()
+		if len(udrContext.IpEndPoints) == 0 {
+			udrContext.UriScheme = models.UriScheme(binding.Scheme)
+			udrContext.SBIPort = binding.Port
+			udrContext.RegisterIPv4 = registerIP
+		}
+		udrContext.IpEndPoints = append(udrContext.IpEndPoints, newIpEndPoint(registerIP, binding.Port))
+	}
+
+	if len(udrContext.IpEndPoints) == 0 {
+		logger.UtilLog.Warn("None of the SBI bindings is advertised, the NF profile will not contain any endpoint")
+	}
+}
+
+func newIpEndPoint(ip string, port int) models.IpEndPoint {
+	ipEndPoint := models.IpEndPoint{
+		Transport: models.NrfNfManagementTransportProtocol_TCP,
+		Port:      int32(port),
+	}
+	if parsed := net.ParseIP(ip); parsed != nil && parsed.To4() == nil {
+		ipEndPoint.Ipv6Address = ip
+	} else {
+		ipEndPoint.Ipv4Address = ip
+	}
+	return ipEndPoint
+}
+
 func initNfService(serviceName []models.ServiceName, version string) (
 	nfService map[models.ServiceName]models.NrfNfManagementNfService,
 ) {
@@ -174,16 +253,11 @@ func initNfService(serviceName []models.ServiceName, version string) (
 					ApiVersionInUri: versionUri,
 				},
 			},
-			Scheme:          udrContext.UriScheme,
-			NfServiceStatus: models.NfServiceStatus_REGISTERED,
-			ApiPrefix:       GetIPv4Uri(),
-			IpEndPoints: []models.IpEndPoint{
-				{
-					Ipv4Address: udrContext.RegisterIPv4,
-					Transport:   models.NrfNfManagementTransportProtocol_TCP,
-					Port:        int32(udrContext.SBIPort),
-				},
-			},
+			Scheme:            udrContext.UriScheme,
+			NfServiceStatus:   models.NfServiceStatus_REGISTERED,
+			ApiPrefix:         GetIPv4Uri(),
+			IpEndPoints:       udrContext.IpEndPoints,
+			SupportedFeatures: factory.UdrConfig.GetSupportedFeatures(string(name)),
 		}
 	}
 
@@ -191,7 +265,8 @@ func initNfService(serviceName []models.ServiceName, version string) (
 }
 
 func GetIPv4Uri() string {
-	return fmt.Sprintf("%s://%s:%d", udrContext.UriScheme, udrContext.RegisterIPv4, udrContext.SBIPort)
+	return fmt.Sprintf("%s://%s", udrContext.UriScheme,
+		net.JoinHostPort(udrContext.RegisterIPv4, strconv.Itoa(udrContext.SBIPort)))
 }
 
 func (context *UDRContext) GetIPv4GroupUri(udrServiceType UDRServiceType) string {
@@ -204,7 +279,8 @@ func (context *UDRContext) GetIPv4GroupUri(udrServiceType UDRServiceType) string
 		serviceUri = ""
 	}
 
-	return fmt.Sprintf("%s://%s:%d%s", context.UriScheme, context.RegisterIPv4, context.SBIPort, serviceUri)
+	return fmt.Sprintf("%s://%s%s", context.UriScheme,
+		net.JoinHostPort(context.RegisterIPv4, strconv.Itoa(context.SBIPort)), serviceUri)
 }
 
 // Create new UDR context
@@ -219,6 +295,91 @@ func (context *UDRContext) NewAppDataInfluDataSubscriptionID() uint64 {
 	return context.appDataInfluDataSubscriptionIdGenerator
 }
 
+// AddSubscriptionDataSubscription stores the subscription and returns its ID
+func (context *UDRContext) AddSubscriptionDataSubscription(subscription *models.SubscriptionDataSubscriptions) string {
+	context.subscriptionDataSubscriptionsMtx.Lock()
+	defer context.subscriptionDataSubscriptionsMtx.Unlock()
+	subscriptionId := strconv.Itoa(context.SubscriptionDataSubscriptionIDGenerator)
+	context.SubscriptionDataSubscriptions[subscriptionId] = subscription
+	context.SubscriptionDataSubscriptionIDGenerator++
+	return subscriptionId
+}
+
+// RemoveSubscriptionDataSubscription returns false when there is no subscription of the ID
+func (context *UDRContext) RemoveSubscriptionDataSubscription(subscriptionId string) bool {
+	context.subscriptionDataSubscriptionsMtx.Lock()
+	defer context.subscriptionDataSubscriptionsMtx.Unlock()
+	if _, ok := context.SubscriptionDataSubscriptions[subscriptionId]; !ok {
+		return false
+	}
+	delete(context.SubscriptionDataSubscriptions, subscriptionId)
+	return true
+}
+
+// CountSubscriptionDataSubscriptions returns the number of active subscriptions to data change notifications
+func (context *UDRContext) CountSubscriptionDataSubscriptions() int {
+	context.subscriptionDataSubscriptionsMtx.RLock()
+	defer context.subscriptionDataSubscriptionsMtx.RUnlock()
+	return len(context.SubscriptionDataSubscriptions)
+}
+
+// RangeSubscriptionDataSubscriptions calls f on a snapshot of the subscriptions until it returns false
+func (context *UDRContext) RangeSubscriptionDataSubscriptions(
+	f func(subscriptionId string, subscription *models.SubscriptionDataSubscriptions) bool,
+) {
+	context.subscriptionDataSubscriptionsMtx.RLock()
+	subscriptions := make(map[subsId]*models.SubscriptionDataSubscriptions, len(context.SubscriptionDataSubscriptions))
+	for subscriptionId, subscription := range context.SubscriptionDataSubscriptions {
+		subscriptions[subscriptionId] = subscription
+	}
+	context.subscriptionDataSubscriptionsMtx.RUnlock()
+
+	for subscriptionId, subscription := range subscriptions {
+		if !f(subscriptionId, subscription) {
+			return
+		}
+	}
+}
+
+// IsExpired reports whether the expiry of a subscription has passed, a subscription without one never expires
+func IsExpired(expiry *time.Time, now time.Time) bool {
+	return expiry != nil && !expiry.IsZero() && !expiry.After(now)
+}
+
+// RemoveExpiredSubscriptionDataSubscriptions removes the subscriptions to data change notifications whose expiry
+// has passed and returns how many there were
+func (context *UDRContext) RemoveExpiredSubscriptionDataSubscriptions(now time.Time) int {
+	context.subscriptionDataSubscriptionsMtx.Lock()
+	defer context.subscriptionDataSubscriptionsMtx.Unlock()
+	removed := 0
+	for subscriptionId, subscription := range context.SubscriptionDataSubscriptions {
+		if IsExpired(subscription.Expiry, now) {
+			delete(context.SubscriptionDataSubscriptions, subscriptionId)
+			removed++
+		}
+	}
+	return removed
+}
+
+// RemoveExpiredSdmSubscriptions removes the SDM subscriptions whose expiry has passed and returns how many there
+// were. The UE is left in the collection, its SDM subscriptions emptied.
+func (context *UDRContext) RemoveExpiredSdmSubscriptions(now time.Time) int {
+	removed := 0
+	context.UESubsCollection.Range(func(key, value interface{}) bool {
+		ueSubsData := value.(*UESubsData)
+		ueSubsData.Lock()
+		for subscriptionId, subscription := range ueSubsData.SdmSubscriptions {
+			if IsExpired(subscription.Expires, now) {
+				delete(ueSubsData.SdmSubscriptions, subscriptionId)
+				removed++
+			}
+		}
+		ueSubsData.Unlock()
+		return true
+	})
+	return removed
+}
+
 func NewInfluenceDataSubscriptionId() string {
 	if GetSelf().InfluenceDataSubscriptionIDGenerator == nil {
 		GetSelf().InfluenceDataSubscriptionIDGenerator = rand.New(rand.NewSource(time.Now().UTC().UnixNano()))
@@ -226,22 +387,66 @@ func NewInfluenceDataSubscriptionId() string {
 	return fmt.Sprintf("%08x", GetSelf().InfluenceDataSubscriptionIDGenerator.Uint32())
 }
 
+// OAuth2Required tells whether the requests to the UDR need an access token, when OAuth2 is enabled in the
+// config or by the NRF
+func (c *UDRContext) OAuth2Required() bool {
+	return c.oauth2Required.Load()
+}
+
+// SetOAuth2Required enables or disables OAuth2 whatever the config and the NRF tell
+func (c *UDRContext) SetOAuth2Required(required bool) {
+	c.oauth2Required.Store(required)
+}
+
+// SetNrfRegistration records the NRF registration: whether the NRF enables OAuth2, which stays enabled when the
+// config does, and the heartBeatTimer in seconds it gives
+func (c *UDRContext) SetNrfRegistration(oauth2 bool, heartBeatTimer int) {
+	if oauth2 {
+		c.oauth2Required.Store(true)
+	}
+	c.nrfHeartBeatTimer.Store(int32(heartBeatTimer))
+}
+
+// NrfHeartBeatTimer is the heartBeatTimer in seconds the NRF gave at registration, 0 before
+func (c *UDRContext) NrfHeartBeatTimer() int {
+	return int(c.nrfHeartBeatTimer.Load())
+}
+
 func (c *UDRContext) GetTokenCtx(serviceName models.ServiceName, targetNF models.NrfNfManagementNfType) (
 	context.Context, *models.ProblemDetails, error,
 ) {
-	if !c.OAuth2Required {
+	// The access tokens of the NRF are only fetched once it is known to require them
+	if !c.oauth2Required.Load() {
 		return context.TODO(), nil, nil
 	}
-	return oauth.GetTokenCtx(models.NrfNfManagementNfType_UDR, targetNF,
-		c.NfId, c.NrfUri, string(serviceName))
+	if c.accessTokens == nil {
+		return nil, nil, fmt.Errorf("UDR context not initialized")
+	}
+	token, err := c.accessTokens.get(accessTokenKey{targetNF: targetNF, scope: string(serviceName)})
+	if err != nil {
+		return nil, nil, err
+	}
+	return context.WithValue(context.Background(), openapi.ContextOAuth2, oauth2.StaticTokenSource(token)), nil, nil
+}
+
+// InvalidateToken drops the cached access token of the service, after the peer rejected it with 401
+func (c *UDRContext) InvalidateToken(serviceName models.ServiceName, targetNF models.NrfNfManagementNfType) {
+	if c.accessTokens != nil {
+		c.accessTokens.invalidate(accessTokenKey{targetNF: targetNF, scope: string(serviceName)})
+	}
 }
 
 func (c *UDRContext) AuthorizationCheck(token string, serviceName models.ServiceName) error {
-	if !c.OAuth2Required {
+	if !c.OAuth2Required() {
 		logger.UtilLog.Debugf("UDRContext::AuthorizationCheck: OAuth2 not required\n")
 		return nil
 	}
 
+	if c.OAuth2SkipVerification {
+		logger.UtilLog.Debugf("UDRContext::AuthorizationCheck: OAuth2 verification skipped\n")
+		return nil
+	}
+
 	logger.UtilLog.Debugf("UDRContext::AuthorizationCheck: token[%s] serviceName[%s]\n", token, serviceName)
 	return oauth.VerifyOAuth(token, string(serviceName), c.NrfCertPem)
 }
@@ -0,0 +1,59 @@
+package context
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/free5gc/udr/pkg/factory"
+)
+
+func TestUDRContext_OAuth2Required(t *testing.T) {
+	var c UDRContext
+	require.False(t, c.OAuth2Required(), "OAuth2 is only required by the config or the NRF")
+	require.Zero(t, c.NrfHeartBeatTimer())
+
+	// The registration is recorded while the requests are checked
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.SetNrfRegistration(true, 10)
+	}()
+	for i := 0; i < 100; i++ {
+		_ = c.OAuth2Required()
+	}
+	wg.Wait()
+	require.True(t, c.OAuth2Required())
+	require.Equal(t, 10, c.NrfHeartBeatTimer())
+
+	// OAuth2 enabled by the config or a former registration stays required whatever the NRF answers
+	c.SetNrfRegistration(false, 20)
+	require.True(t, c.OAuth2Required())
+	c.SetOAuth2Required(false)
+	require.False(t, c.OAuth2Required())
+}
+
+func TestInitUdrContext_OAuth2WithoutNrf(t *testing.T) {
+	config := factory.UdrConfig
+	t.Cleanup(func() {
+		factory.UdrConfig = config
+		udrContext.SetOAuth2Required(false)
+	})
+
+	// A UDR running without a reachable NRF serves the requests unless its config enables OAuth2
+	factory.UdrConfig = &factory.Config{
+		Info: &factory.Info{Version: "1.1.0"},
+		Configuration: &factory.Configuration{
+			Sbi:    &factory.Sbi{Scheme: "http"},
+			Probes: &factory.Probes{SkipNrfRegistrationCheck: true},
+		},
+	}
+	initUdrContext()
+	require.False(t, GetSelf().OAuth2Required())
+
+	factory.UdrConfig.Configuration.Sbi.OAuth = &factory.OAuth{Enable: true}
+	initUdrContext()
+	require.True(t, GetSelf().OAuth2Required())
+}
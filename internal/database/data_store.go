@@ -0,0 +1,43 @@
+package database
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/free5gc/udr/internal/database/memory"
+	"github.com/free5gc/udr/internal/database/mongodb"
+)
+
+// Query tells how the documents of a query are ordered and paged
+type Query = mongodb.Query
+
+// Slice is the part of the elements of an array field a query returns
+type Slice = mongodb.Slice
+
+// Update tells the fields a patch sets and removes
+type Update = mongodb.Update
+
+var (
+	_ DataStore = mongodb.MongoDbConnector{}
+	_ DataStore = (*memory.Store)(nil)
+)
+
+// DataStore is the storage of the documents of the collections, each with its ETag, util.DocumentETag. It is what a
+// backend implements at least, the DbConnector procedures being built on it.
+type DataStore interface {
+	// GetOne returns the document matching filter along with its ETag, ErrNoDocument when there is none
+	GetOne(ctx context.Context, collName string, filter bson.M) (map[string]interface{}, string, error)
+	// PutOne replaces the document matching filter with data, or inserts data when there is none, once ifMatch,
+	// an ETag or "*", accepts the current document, ErrVersionMismatch when not. It tells whether the document
+	// existed and returns its new ETag.
+	PutOne(ctx context.Context, collName string, filter bson.M, data map[string]interface{}, ifMatch string) (
+		bool, string, error)
+	// PatchOne sets and removes the fields of update in the document matching filter, ErrNoDocument when there is
+	// none and update is not an upsert. It returns the document after the update.
+	PatchOne(ctx context.Context, collName string, filter bson.M, update Update) (map[string]interface{}, error)
+	// DeleteOne removes the document matching filter and tells whether there was one
+	DeleteOne(ctx context.Context, collName string, filter bson.M) (bool, error)
+	// Query returns the documents matching filter, ordered and paged as told by query
+	Query(ctx context.Context, collName string, filter bson.M, query Query) ([]map[string]interface{}, error)
+}
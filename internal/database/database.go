@@ -1,34 +1,172 @@
 package database
 
 import (
+	"context"
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson"
 
 	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/udr/internal/database/memory"
 	"github.com/free5gc/udr/internal/database/mongodb"
 	"github.com/free5gc/udr/internal/logger"
 	"github.com/free5gc/udr/pkg/factory"
 )
 
 const (
-	APPDATA_INFLUDATA_DB_COLLECTION_NAME       = "applicationData.influenceData"
-	APPDATA_INFLUDATA_SUBSC_DB_COLLECTION_NAME = "applicationData.influenceData.subsToNotify"
-	APPDATA_PFD_DB_COLLECTION_NAME             = "applicationData.pfds"
+	APPDATA_INFLUDATA_DB_COLLECTION_NAME           = "applicationData.influenceData"
+	APPDATA_INFLUDATA_SUBSC_DB_COLLECTION_NAME     = "applicationData.influenceData.subsToNotify"
+	APPDATA_PFD_DB_COLLECTION_NAME                 = "applicationData.pfds"
+	SUBSCDATA_GROUP_MEMBERSHIP_DB_COLLECTION_NAME  = "subscriptionData.groupData.groupMembership"
+	SUBSCDATA_AUTH_SUBSC_DB_COLLECTION_NAME        = "subscriptionData.authenticationData.authenticationSubscription"
+	SUBSCDATA_AUTH_STATUS_DB_COLLECTION_NAME       = "subscriptionData.authenticationData.authenticationStatus"
+	SUBSCDATA_INDIV_AUTH_STATUS_DB_COLLECTION_NAME = "subscriptionData.authenticationData.individualAuthenticationStatus"
+	SUBSCDATA_SOR_DATA_DB_COLLECTION_NAME          = "subscriptionData.ueUpdateConfirmationData.sorData"
+	SUBSCDATA_UPU_DATA_DB_COLLECTION_NAME          = "subscriptionData.ueUpdateConfirmationData.upuData"
+	SUBSCDATA_AM_DATA_DB_COLLECTION_NAME           = "subscriptionData.provisionedData.amData"
+	SUBSCDATA_SM_DATA_DB_COLLECTION_NAME           = "subscriptionData.provisionedData.smData"
+	SUBSCDATA_SMF_SEL_DB_COLLECTION_NAME           = "subscriptionData.provisionedData.smfSelectionSubscriptionData"
+	SUBSCDATA_EE_SUBSC_DB_COLLECTION_NAME          = "subscriptionData.contextData.eeSubscriptions"
+	SUBSCDATA_EE_AMF_SUBSC_DB_COLLECTION_NAME      = "subscriptionData.contextData.eeSubscriptions.amfSubscriptions"
+	AUDIT_DB_COLLECTION_NAME                       = "udr.audit"
 
 	DBCONNECTOR_TYPE_MONGODB factory.DbType = "mongodb"
+	// DBCONNECTOR_TYPE_MEMORY keeps the documents in the memory of the UDR, they are lost when it stops
+	DBCONNECTOR_TYPE_MEMORY factory.DbType = "memory"
 )
 
+// ErrVersionMismatch is returned by the versioned writes when the If-Match precondition does not hold
+var ErrVersionMismatch = mongodb.ErrVersionMismatch
+
+// ErrNoDocument is returned by the versioned modifications when there is no document to modify
+var ErrNoDocument = mongodb.ErrNoDocument
+
+// IsUnavailable tells whether err is the datastore being unreachable, rather than the operation failing
+func IsUnavailable(err error) bool {
+	return mongodb.IsUnavailable(err)
+}
+
+// Upsert is one document of a bulk upsert
+type Upsert = mongodb.Upsert
+
+// Index is an index of a collection
+type Index = mongodb.Index
+
+// UdrIndexes cover the fields the subscribers are looked up by, the SUPI is unique among the
+// authentication subscriptions
+var UdrIndexes = []Index{
+	{Collection: SUBSCDATA_AUTH_SUBSC_DB_COLLECTION_NAME, Keys: []string{"ueId"}, Unique: true},
+	{Collection: SUBSCDATA_AUTH_STATUS_DB_COLLECTION_NAME, Keys: []string{"ueId"}},
+	{Collection: SUBSCDATA_INDIV_AUTH_STATUS_DB_COLLECTION_NAME, Keys: []string{"ueId", "servingNetworkName"}},
+	{Collection: SUBSCDATA_SOR_DATA_DB_COLLECTION_NAME, Keys: []string{"ueId"}, Unique: true},
+	{Collection: SUBSCDATA_UPU_DATA_DB_COLLECTION_NAME, Keys: []string{"ueId"}, Unique: true},
+	{Collection: SUBSCDATA_AM_DATA_DB_COLLECTION_NAME, Keys: []string{"ueId", "servingPlmnId"}},
+	// The listing of the AM data of a PLMN, by ueId
+	{Collection: SUBSCDATA_AM_DATA_DB_COLLECTION_NAME, Keys: []string{"servingPlmnId", "ueId"}},
+	{Collection: SUBSCDATA_SM_DATA_DB_COLLECTION_NAME, Keys: []string{"ueId", "servingPlmnId"}},
+	// The queries of the SM data of an S-NSSAI, case-insensitive as they are. The DNNs are keys of the
+	// documents and cannot be indexed, the UE has a document by S-NSSAI to look them up in.
+	{Collection: SUBSCDATA_SM_DATA_DB_COLLECTION_NAME,
+		Keys: []string{"ueId", "servingPlmnId", "singleNssai.sst", "singleNssai.sd"}, CaseInsensitive: true},
+	{Collection: SUBSCDATA_SMF_SEL_DB_COLLECTION_NAME, Keys: []string{"ueId", "servingPlmnId"}},
+	{Collection: "subscriptionData.provisionedData.smsData", Keys: []string{"ueId", "servingPlmnId"}},
+	{Collection: "subscriptionData.provisionedData.smsMngData", Keys: []string{"ueId", "servingPlmnId"}},
+	{Collection: "subscriptionData.provisionedData.traceData", Keys: []string{"ueId", "servingPlmnId"}},
+	{Collection: "subscriptionData.provisionedData.lcsPrivacyData", Keys: []string{"ueId"}},
+	{Collection: "subscriptionData.provisionedData.lcsMoData", Keys: []string{"ueId"}},
+	{Collection: "subscriptionData.provisionedData.v2xData", Keys: []string{"ueId"}},
+	{Collection: "subscriptionData.provisionedData.proseData", Keys: []string{"ueId"}},
+	{Collection: "subscriptionData.ppData", Keys: []string{"ueId"}, Unique: true},
+	{Collection: "subscriptionData.contextData.amf3gppAccess", Keys: []string{"ueId"}},
+	{Collection: "subscriptionData.contextData.amfNon3gppAccess", Keys: []string{"ueId"}},
+	{Collection: "subscriptionData.contextData.smfRegistrations", Keys: []string{"ueId", "pduSessionId"},
+		Unique: true},
+	{Collection: "subscriptionData.contextData.smsf3gppAccess", Keys: []string{"ueId"}},
+	{Collection: "subscriptionData.contextData.smsfNon3gppAccess", Keys: []string{"ueId"}},
+	{Collection: SUBSCDATA_EE_SUBSC_DB_COLLECTION_NAME, Keys: []string{"ueId", "subsId"}, Unique: true},
+	{Collection: SUBSCDATA_EE_AMF_SUBSC_DB_COLLECTION_NAME, Keys: []string{"ueId", "subsId"}, Unique: true},
+	{Collection: "subscriptionData.sharedData", Keys: []string{"sharedDataId"}},
+	{Collection: SUBSCDATA_GROUP_MEMBERSHIP_DB_COLLECTION_NAME, Keys: []string{"extGroupId"}, Unique: true},
+	{Collection: SUBSCDATA_GROUP_MEMBERSHIP_DB_COLLECTION_NAME, Keys: []string{"intGroupId"}, Unique: true},
+	{Collection: APPDATA_PFD_DB_COLLECTION_NAME, Keys: []string{"applicationId"}, Unique: true},
+	{Collection: "policyData.ues.amData", Keys: []string{"ueId"}},
+	{Collection: "policyData.ues.smData", Keys: []string{"ueId"}},
+	{Collection: "policyData.ues.smData.usageMonData", Keys: []string{"ueId", "limitId"}},
+	{Collection: "policyData.ues.smData.usageMonData", Keys: []string{"ueId", "usageMonId"}},
+}
+
+// StaleIndexes are the indexes of a former release on fields no longer queried, dropped on startup
+var StaleIndexes = []Index{
+	// The groupMembership documents were keyed by externalGroupId before they held the GroupIdentifiers
+	{Collection: SUBSCDATA_GROUP_MEMBERSHIP_DB_COLLECTION_NAME, Keys: []string{"externalGroupId"}},
+}
+
+// AuditIndexes cover the queries of the audit trail and expire its records after retention
+func AuditIndexes(retention time.Duration) []Index {
+	return []Index{
+		{Collection: AUDIT_DB_COLLECTION_NAME, Keys: []string{"ueId", "timestamp"}},
+		{Collection: AUDIT_DB_COLLECTION_NAME, Keys: []string{"timestamp"}, ExpireAfter: retention},
+	}
+}
+
 type DbConnector interface {
-	PatchDataToDBAndNotify(collName string, ueId string, patchItem []models.PatchItem, filter bson.M) (
-		map[string]interface{}, map[string]interface{}, error)
-	GetDataFromDB(collName string, filter bson.M) (map[string]interface{}, *models.ProblemDetails)
-	GetDataFromDBWithArg(collName string, filter bson.M, strength int) (map[string]interface{}, *models.ProblemDetails)
-	DeleteDataFromDB(collName string, filter bson.M)
+	DataStore
+	PatchDataToDBAndNotify(ctx context.Context, collName string, ueId string, patchItem []models.PatchItem,
+		filter bson.M) (map[string]interface{}, map[string]interface{}, error)
+	MergePatchDataToDBAndNotify(ctx context.Context, collName string, ueId string, mergePatch []byte,
+		filter bson.M) (map[string]interface{}, map[string]interface{}, error)
+	ModifyDataFieldToDB(ctx context.Context, collName string, filter bson.M, field string,
+		modify func(value []byte) ([]byte, bool, error)) (map[string]interface{}, map[string]interface{}, error)
+	MergePatchDataToDB(ctx context.Context, collName string, filter bson.M, patchData map[string]interface{}) error
+	PatchDataFieldToDB(ctx context.Context, collName string, filter bson.M, dataName string, patchJSON []byte) error
+	PutDataToDB(ctx context.Context, collName string, filter bson.M, putData map[string]interface{}) (bool, error)
+	ReplaceDataToDB(ctx context.Context, collName string, filter bson.M, data map[string]interface{}) (bool, error)
+	FindDataFromDB(ctx context.Context, collName string, filter bson.M) (map[string]interface{}, error)
+	GetManyDataFromDB(ctx context.Context, collName string, filter bson.M) ([]map[string]interface{}, error)
+	GetManyDataFromDBWithArg(ctx context.Context, collName string, filter bson.M, strength int) (
+		[]map[string]interface{}, error)
+	GetDataFromDB(ctx context.Context, collName string, filter bson.M) (map[string]interface{}, *models.ProblemDetails)
+	GetDataFromDBWithArg(ctx context.Context, collName string, filter bson.M, strength int) (
+		map[string]interface{}, *models.ProblemDetails)
+	GetVersionedDataFromDB(ctx context.Context, collName string, filter bson.M) (
+		map[string]interface{}, string, *models.ProblemDetails)
+	PutVersionedDataToDB(ctx context.Context, collName string, filter bson.M, putData map[string]interface{},
+		ifMatch string) (bool, string, error)
+	ReplaceVersionedDataToDB(ctx context.Context, collName string, filter bson.M, data map[string]interface{},
+		ifMatch string, validate func(current map[string]interface{}) error) (bool, string, error)
+	PatchVersionedDataToDB(ctx context.Context, collName string, filter bson.M, patchItem []models.PatchItem,
+		ifMatch string) (map[string]interface{}, map[string]interface{}, string, error)
+	MergePatchVersionedDataToDB(ctx context.Context, collName string, filter bson.M, mergePatch []byte,
+		ifMatch string, validate func(map[string]interface{}) error) (
+		map[string]interface{}, map[string]interface{}, string, error)
+	GetPageFromDB(ctx context.Context, collName string, filter bson.M, sortKeys []string, skip, limit int64) (
+		[]map[string]interface{}, *models.ProblemDetails)
+	BulkUpsertDataToDB(ctx context.Context, collName string, upserts []Upsert) []error
+	InsertDataToDB(ctx context.Context, collName string, data map[string]interface{}) error
+	EnsureIndexes(ctx context.Context, indexes []Index) error
+	DropIndexes(ctx context.Context, indexes []Index) error
+	DeleteDataFromDB(ctx context.Context, collName string, filter bson.M)
+	DeleteOneDataFromDB(ctx context.Context, collName string, filter bson.M) error
+	DeleteManyDataFromDB(ctx context.Context, collNames []string, filter bson.M) (map[string]int64, bool, error)
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) (bool, error)
+	DetectTransactions(ctx context.Context) (bool, error)
+	Ping(ctx context.Context) error
+	SessionsInProgress() int
+	Disconnect(ctx context.Context) error
 }
 
+var (
+	_ DbConnector = mongodb.MongoDbConnector{}
+	_ DbConnector = memory.Connector{}
+)
+
 func NewDbConnector(dbName factory.DbType) DbConnector {
-	if dbName == DBCONNECTOR_TYPE_MONGODB {
+	switch dbName {
+	case DBCONNECTOR_TYPE_MONGODB:
 		return mongodb.NewMongoDbConnector(factory.UdrConfig.Configuration.Mongodb)
-	} else {
+	case DBCONNECTOR_TYPE_MEMORY:
+		return memory.NewConnector()
+	default:
 		logger.DbLog.Fatalf("Unsupported database type: %s", dbName)
 		return nil
 	}
@@ -0,0 +1,435 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/udr/internal/database/mongodb"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/util/mongoapi"
+)
+
+// Connector is the database.DbConnector of a Store. Its procedures are built on the documents of the Store as
+// the ones of the MongoDB connector are, each of them atomic. The transactions are not isolated from the
+// operations running meanwhile, their writes are undone when they fail.
+type Connector struct {
+	*Store
+}
+
+func NewConnector() Connector {
+	return Connector{Store: NewStore()}
+}
+
+func (c Connector) PatchDataToDBAndNotify(ctx context.Context, collName string, ueId string,
+	patchItem []models.PatchItem, filter bson.M,
+) (map[string]interface{}, map[string]interface{}, error) {
+	patch, err := decodePatch(patchItem)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.modifyData(collName, filter, "json_patch", patch.Apply)
+}
+
+func (c Connector) MergePatchDataToDBAndNotify(ctx context.Context, collName string, ueId string,
+	mergePatch []byte, filter bson.M,
+) (map[string]interface{}, map[string]interface{}, error) {
+	return c.modifyData(collName, filter, "merge_patch", func(original []byte) ([]byte, error) {
+		return jsonpatch.MergePatch(original, mergePatch)
+	})
+}
+
+// modifyData sets the fields of the modified copy of the document matching filter, the fields the modification
+// removed are kept as with the $set of the MongoDB connector
+func (c Connector) modifyData(collName string, filter bson.M, op string,
+	modify func(original []byte) ([]byte, error),
+) (map[string]interface{}, map[string]interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	doc, err := c.findOne(collName, filter, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	if doc == nil {
+		return nil, nil, fmt.Errorf("%s: %w in %s", op, mongodb.ErrNoDocument, collName)
+	}
+	origValue, newValue, err := modified(doc.data, modify)
+	if err != nil {
+		return nil, nil, err
+	}
+	for field, value := range newValue {
+		doc.data[field] = cloneValue(value)
+	}
+	return origValue, newValue, nil
+}
+
+// modified is the copy of data before and after its modification
+func modified(data map[string]interface{}, modify func(original []byte) ([]byte, error)) (
+	map[string]interface{}, map[string]interface{}, error,
+) {
+	origValue := cloneDocument(data)
+	original, err := json.Marshal(origValue)
+	if err != nil {
+		return nil, nil, err
+	}
+	modifiedData, err := modify(original)
+	if err != nil {
+		return nil, nil, err
+	}
+	var newValue map[string]interface{}
+	if err = json.Unmarshal(modifiedData, &newValue); err != nil {
+		return nil, nil, fmt.Errorf("unmarshal document err: %+v", err)
+	}
+	return origValue, newValue, nil
+}
+
+func (c Connector) ModifyDataFieldToDB(ctx context.Context, collName string, filter bson.M, field string,
+	modify func(value []byte) ([]byte, bool, error),
+) (map[string]interface{}, map[string]interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	doc, err := c.findOne(collName, filter, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	if doc == nil {
+		return nil, nil, fmt.Errorf("ModifyDataFieldToDB: %w in %s", mongodb.ErrNoDocument, collName)
+	}
+	origValue := cloneDocument(doc.data)
+	value, err := json.Marshal(origValue[field])
+	if err != nil {
+		return nil, nil, fmt.Errorf("ModifyDataFieldToDB Marshal err: %+v", err)
+	}
+	modifiedValue, changed, err := modify(value)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !changed {
+		return origValue, origValue, nil
+	}
+	var fieldValue interface{}
+	if err = json.Unmarshal(modifiedValue, &fieldValue); err != nil {
+		return nil, nil, fmt.Errorf("ModifyDataFieldToDB Unmarshal err: %+v", err)
+	}
+	doc.data[field] = fieldValue
+	return origValue, cloneDocument(doc.data), nil
+}
+
+func (c Connector) MergePatchDataToDB(ctx context.Context, collName string, filter bson.M,
+	patchData map[string]interface{},
+) error {
+	mergePatch, err := json.Marshal(patchData)
+	if err != nil {
+		return fmt.Errorf("RestfulAPIMergePatch Marshal err: %+v", err)
+	}
+	if _, _, err = c.MergePatchDataToDBAndNotify(ctx, collName, "", mergePatch, filter); err != nil {
+		return fmt.Errorf("RestfulAPIMergePatch err: %w", err)
+	}
+	return nil
+}
+
+func (c Connector) PatchDataFieldToDB(ctx context.Context, collName string, filter bson.M, dataName string,
+	patchJSON []byte,
+) error {
+	patch, err := jsonpatch.DecodePatch(patchJSON)
+	if err != nil {
+		return fmt.Errorf("RestfulAPIJSONPatchExtend DecodePatch err: %+v", err)
+	}
+	_, _, err = c.modifyData(collName, filter, "json_patch", func(original []byte) ([]byte, error) {
+		var doc map[string]json.RawMessage
+		if err := json.Unmarshal(original, &doc); err != nil {
+			return nil, err
+		}
+		field, ok := doc[dataName]
+		if !ok {
+			field = json.RawMessage("null")
+		}
+		return patch.Apply(field)
+	})
+	if err != nil {
+		return fmt.Errorf("RestfulAPIJSONPatchExtend err: %w", err)
+	}
+	return nil
+}
+
+func (c Connector) PutDataToDB(ctx context.Context, collName string, filter bson.M,
+	putData map[string]interface{},
+) (bool, error) {
+	existed, _, err := c.PutVersionedDataToDB(ctx, collName, filter, putData, "")
+	return existed, err
+}
+
+func (c Connector) ReplaceDataToDB(ctx context.Context, collName string, filter bson.M,
+	data map[string]interface{},
+) (bool, error) {
+	existed, _, err := c.PutOne(ctx, collName, filter, data, "")
+	return existed, err
+}
+
+func (c Connector) FindDataFromDB(ctx context.Context, collName string, filter bson.M) (
+	map[string]interface{}, error,
+) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	doc, err := c.findOne(collName, filter, false)
+	if doc == nil || err != nil {
+		return nil, err
+	}
+	return cloneDocument(doc.data), nil
+}
+
+func (c Connector) GetManyDataFromDB(ctx context.Context, collName string, filter bson.M) (
+	[]map[string]interface{}, error,
+) {
+	return c.Query(ctx, collName, filter, mongodb.Query{})
+}
+
+func (c Connector) GetManyDataFromDBWithArg(ctx context.Context, collName string, filter bson.M, strength int) (
+	[]map[string]interface{}, error,
+) {
+	return c.Query(ctx, collName, filter, mongodb.Query{CaseInsensitive: caseInsensitive(strength)})
+}
+
+// caseInsensitive tells whether the collation strength ignores the case
+func caseInsensitive(strength int) bool {
+	return strength > 0 && strength < mongoapi.COLLATION_STRENGTH_DEFAULT
+}
+
+func (c Connector) GetDataFromDB(ctx context.Context, collName string, filter bson.M) (
+	map[string]interface{}, *models.ProblemDetails,
+) {
+	return c.GetDataFromDBWithArg(ctx, collName, filter, 0)
+}
+
+func (c Connector) GetDataFromDBWithArg(ctx context.Context, collName string, filter bson.M, strength int) (
+	map[string]interface{}, *models.ProblemDetails,
+) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	doc, err := c.findOne(collName, filter, caseInsensitive(strength))
+	if err != nil {
+		return nil, util.ProblemDetailsSystemFailure(err.Error())
+	}
+	if doc == nil {
+		return nil, util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+	return cloneDocument(doc.data), nil
+}
+
+func (c Connector) GetVersionedDataFromDB(ctx context.Context, collName string, filter bson.M) (
+	map[string]interface{}, string, *models.ProblemDetails,
+) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	doc, err := c.findOne(collName, filter, false)
+	if err != nil {
+		return nil, "", util.ProblemDetailsSystemFailure(err.Error())
+	}
+	if doc == nil {
+		return nil, "", util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
+	return cloneDocument(doc.data), util.DocumentETag(doc.data), nil
+}
+
+// PutVersionedDataToDB sets the fields of putData in the document matching filter, or inserts putData when
+// there is none, once ifMatch accepts the current document
+func (c Connector) PutVersionedDataToDB(ctx context.Context, collName string, filter bson.M,
+	putData map[string]interface{}, ifMatch string,
+) (bool, string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	doc, err := c.findOne(collName, filter, false)
+	if err != nil {
+		return false, "", err
+	}
+	if doc == nil {
+		if !util.IfMatch(ifMatch, "", false) {
+			return false, "", mongodb.ErrVersionMismatch
+		}
+		return false, c.insert(collName, putData), nil
+	}
+	if etag := util.DocumentETag(doc.data); !util.IfMatch(ifMatch, etag, true) {
+		return true, etag, mongodb.ErrVersionMismatch
+	}
+	for field, value := range putData {
+		doc.data[field] = cloneValue(value)
+	}
+	return true, util.DocumentETag(doc.data), nil
+}
+
+func (c Connector) ReplaceVersionedDataToDB(ctx context.Context, collName string, filter bson.M,
+	data map[string]interface{}, ifMatch string, validate func(current map[string]interface{}) error,
+) (bool, string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.replace(collName, filter, data, ifMatch, validate)
+}
+
+func (c Connector) PatchVersionedDataToDB(ctx context.Context, collName string, filter bson.M,
+	patchItem []models.PatchItem, ifMatch string,
+) (map[string]interface{}, map[string]interface{}, string, error) {
+	patch, err := decodePatch(patchItem)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return c.modifyVersionedData(collName, filter, ifMatch, "PatchVersionedDataToDB", patch.Apply, nil)
+}
+
+func (c Connector) MergePatchVersionedDataToDB(ctx context.Context, collName string, filter bson.M,
+	mergePatch []byte, ifMatch string, validate func(map[string]interface{}) error,
+) (map[string]interface{}, map[string]interface{}, string, error) {
+	return c.modifyVersionedData(collName, filter, ifMatch, "MergePatchVersionedDataToDB",
+		func(original []byte) ([]byte, error) {
+			return jsonpatch.MergePatch(original, mergePatch)
+		}, validate)
+}
+
+// modifyVersionedData replaces the document matching filter by its modified copy when ifMatch accepts it
+func (c Connector) modifyVersionedData(collName string, filter bson.M, ifMatch string, op string,
+	modify func(original []byte) ([]byte, error), validate func(map[string]interface{}) error,
+) (map[string]interface{}, map[string]interface{}, string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	doc, err := c.findOne(collName, filter, false)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	var current map[string]interface{}
+	if doc != nil {
+		current = doc.data
+	}
+	if etag := util.DocumentETag(current); !util.IfMatch(ifMatch, etag, doc != nil) {
+		return nil, nil, etag, mongodb.ErrVersionMismatch
+	}
+	if doc == nil {
+		return nil, nil, "", fmt.Errorf("%s: %w in %s", op, mongodb.ErrNoDocument, collName)
+	}
+	origValue, newValue, err := modified(doc.data, modify)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if validate != nil {
+		if err = validate(newValue); err != nil {
+			return nil, nil, "", err
+		}
+	}
+	doc.data = cloneDocument(newValue)
+	return origValue, newValue, util.DocumentETag(doc.data), nil
+}
+
+func (c Connector) GetPageFromDB(ctx context.Context, collName string, filter bson.M, sortKeys []string,
+	skip, limit int64,
+) ([]map[string]interface{}, *models.ProblemDetails) {
+	page, err := c.Query(ctx, collName, filter, mongodb.Query{SortKeys: sortKeys, Skip: skip, Limit: limit})
+	if err != nil {
+		return nil, util.ProblemDetailsSystemFailure(fmt.Sprintf("GetPageFromDB err: %+v", err))
+	}
+	return page, nil
+}
+
+func (c Connector) BulkUpsertDataToDB(ctx context.Context, collName string, upserts []mongodb.Upsert) []error {
+	errs := make([]error, len(upserts))
+	for i, upsert := range upserts {
+		_, _, errs[i] = c.PutOne(ctx, collName, upsert.Filter, upsert.Data, "")
+	}
+	return errs
+}
+
+func (c Connector) InsertDataToDB(ctx context.Context, collName string, data map[string]interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.insert(collName, data)
+	return nil
+}
+
+// EnsureIndexes has nothing to do, the documents are looked up one by one
+func (c Connector) EnsureIndexes(ctx context.Context, indexes []mongodb.Index) error {
+	return nil
+}
+
+// DropIndexes has nothing to do, there are no indexes
+func (c Connector) DropIndexes(ctx context.Context, indexes []mongodb.Index) error {
+	return nil
+}
+
+func (c Connector) DeleteDataFromDB(ctx context.Context, collName string, filter bson.M) {
+	_, _ = c.DeleteOne(ctx, collName, filter)
+}
+
+func (c Connector) DeleteOneDataFromDB(ctx context.Context, collName string, filter bson.M) error {
+	_, err := c.DeleteOne(ctx, collName, filter)
+	return err
+}
+
+// DeleteManyDataFromDB removes the documents matching filter from every collection of collNames at once
+func (c Connector) DeleteManyDataFromDB(ctx context.Context, collNames []string, filter bson.M) (
+	map[string]int64, bool, error,
+) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	kept := make(map[string][]*document, len(collNames))
+	deleted := make(map[string]int64, len(collNames))
+	for _, collName := range collNames {
+		for _, doc := range c.collections[collName] {
+			matched, err := matches(doc.data, filter, false)
+			if err != nil {
+				return nil, true, fmt.Errorf("DeleteManyDataFromDB %s err: %w", collName, err)
+			}
+			if matched {
+				deleted[collName]++
+			} else {
+				kept[collName] = append(kept[collName], doc)
+			}
+		}
+	}
+	for _, collName := range collNames {
+		c.collections[collName] = kept[collName]
+	}
+	return deleted, true, nil
+}
+
+// WithTransaction runs fn and restores the documents as they were before it when it fails
+func (c Connector) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) (bool, error) {
+	c.mu.Lock()
+	snapshot := c.snapshot()
+	c.mu.Unlock()
+	err := fn(ctx)
+	if err != nil {
+		c.mu.Lock()
+		c.collections = snapshot
+		c.mu.Unlock()
+	}
+	return true, err
+}
+
+func (c Connector) DetectTransactions(ctx context.Context) (bool, error) {
+	return true, nil
+}
+
+func (c Connector) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (c Connector) SessionsInProgress() int {
+	return 0
+}
+
+func (c Connector) Disconnect(ctx context.Context) error {
+	return nil
+}
+
+func decodePatch(patchItem []models.PatchItem) (jsonpatch.Patch, error) {
+	patchJSON, err := json.Marshal(patchItem)
+	if err != nil {
+		return nil, err
+	}
+	patch, err := jsonpatch.DecodePatch(patchJSON)
+	if err != nil {
+		return nil, fmt.Errorf("DecodePatch err: %+v", err)
+	}
+	return patch, nil
+}
@@ -0,0 +1,260 @@
+package memory
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// matches tells whether the document matches filter as a MongoDB query does. The fields may be the dotted
+// paths of nested ones, which go through the arrays, and a field holding an array matches by its elements.
+// The operators supported are $and, $or, $in, $exists, $gte and $lte.
+func matches(doc map[string]interface{}, filter bson.M, caseInsensitive bool) (bool, error) {
+	for field, condition := range filter {
+		var matched bool
+		var err error
+		switch field {
+		case "$and", "$or":
+			matched, err = matchesAll(doc, field, condition, caseInsensitive)
+		default:
+			matched, err = matchesField(valuesAt(doc, field), condition, caseInsensitive)
+		}
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// matchesAll tells whether the document matches all of the filters of an $and, or any of the ones of an $or
+func matchesAll(doc map[string]interface{}, operator string, condition interface{}, caseInsensitive bool) (
+	bool, error,
+) {
+	filters, err := filtersOf(condition)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", operator, err)
+	}
+	for _, filter := range filters {
+		matched, err := matches(doc, filter, caseInsensitive)
+		if err != nil {
+			return false, err
+		}
+		if matched == (operator == "$or") {
+			return matched, nil
+		}
+	}
+	return operator == "$and", nil
+}
+
+func filtersOf(condition interface{}) ([]bson.M, error) {
+	switch c := condition.(type) {
+	case []bson.M:
+		return c, nil
+	case []interface{}:
+		filters := make([]bson.M, 0, len(c))
+		for _, element := range c {
+			filter, ok := documentOf(element)
+			if !ok {
+				return nil, fmt.Errorf("%v is not a filter", element)
+			}
+			filters = append(filters, filter)
+		}
+		return filters, nil
+	case bson.A:
+		return filtersOf([]interface{}(c))
+	default:
+		return nil, fmt.Errorf("%v is not an array of filters", condition)
+	}
+}
+
+func documentOf(value interface{}) (bson.M, bool) {
+	switch v := value.(type) {
+	case bson.M:
+		return v, true
+	case map[string]interface{}:
+		return v, true
+	default:
+		return nil, false
+	}
+}
+
+// matchesField tells whether the values the field of the document has match condition, a value or a document
+// of operators
+func matchesField(values []interface{}, condition interface{}, caseInsensitive bool) (bool, error) {
+	operators, ok := documentOf(condition)
+	if !ok || !isOperators(operators) {
+		return anyEqual(values, condition, caseInsensitive), nil
+	}
+	for operator, operand := range operators {
+		var matched bool
+		switch operator {
+		case "$exists":
+			exists, isBool := operand.(bool)
+			if !isBool {
+				return false, fmt.Errorf("$exists: %v is not a boolean", operand)
+			}
+			matched = (len(values) > 0) == exists
+		case "$in":
+			elements, err := elementsOf(operand)
+			if err != nil {
+				return false, err
+			}
+			for _, element := range elements {
+				matched = matched || anyEqual(values, element, caseInsensitive)
+			}
+		case "$gte", "$lte":
+			for _, value := range values {
+				order, comparable := compare(value, operand, caseInsensitive)
+				matched = matched || comparable && (order == 0 || order > 0 == (operator == "$gte"))
+			}
+		default:
+			return false, fmt.Errorf("unsupported operator %s", operator)
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func isOperators(document bson.M) bool {
+	for key := range document {
+		if !strings.HasPrefix(key, "$") {
+			return false
+		}
+	}
+	return len(document) > 0
+}
+
+func elementsOf(operand interface{}) ([]interface{}, error) {
+	array := reflect.ValueOf(operand)
+	if array.Kind() != reflect.Slice && array.Kind() != reflect.Array {
+		return nil, fmt.Errorf("$in: %v is not an array", operand)
+	}
+	elements := make([]interface{}, array.Len())
+	for i := range elements {
+		elements[i] = array.Index(i).Interface()
+	}
+	return elements, nil
+}
+
+// valuesAt returns the values the document has at the dotted path, those of the elements when it goes
+// through an array, along with the elements of an array found at its end
+func valuesAt(doc map[string]interface{}, path string) []interface{} {
+	name, nested, isNested := strings.Cut(path, ".")
+	value, ok := doc[name]
+	if !ok {
+		return nil
+	}
+	if !isNested {
+		if array, isArray := value.([]interface{}); isArray {
+			return append([]interface{}{value}, array...)
+		}
+		return []interface{}{value}
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return valuesAt(v, nested)
+	case []interface{}:
+		var values []interface{}
+		for _, element := range v {
+			if elementDoc, isDoc := element.(map[string]interface{}); isDoc {
+				values = append(values, valuesAt(elementDoc, nested)...)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
+
+func anyEqual(values []interface{}, condition interface{}, caseInsensitive bool) bool {
+	condition = cloneValue(condition)
+	for _, value := range values {
+		if order, comparable := compare(value, condition, caseInsensitive); comparable && order == 0 {
+			return true
+		}
+		if !isScalar(value) && reflect.DeepEqual(value, condition) {
+			return true
+		}
+	}
+	return false
+}
+
+func isScalar(value interface{}) bool {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	default:
+		return true
+	}
+}
+
+// compare orders a before or after b, when they are both numbers, strings, booleans, times or nulls
+func compare(a, b interface{}, caseInsensitive bool) (int, bool) {
+	if a == nil || b == nil {
+		return 0, a == nil && b == nil
+	}
+	if x, isNumber := number(a); isNumber {
+		y, bIsNumber := number(b)
+		if !bIsNumber {
+			return 0, false
+		}
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		default:
+			return 0, true
+		}
+	}
+	switch x := a.(type) {
+	case string:
+		y, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+		if caseInsensitive {
+			x, y = strings.ToLower(x), strings.ToLower(y)
+		}
+		return strings.Compare(x, y), true
+	case bool:
+		y, ok := b.(bool)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x == y:
+			return 0, true
+		case y:
+			return -1, true
+		default:
+			return 1, true
+		}
+	case time.Time:
+		y, ok := b.(time.Time)
+		if !ok {
+			return 0, false
+		}
+		return x.Compare(y), true
+	default:
+		return 0, false
+	}
+}
+
+func number(value interface{}) (float64, bool) {
+	switch v := reflect.ValueOf(value); v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,355 @@
+// Package memory is a database.DbConnector keeping its documents in memory, selected by the memory
+// dbConnectorType, for the tests and the labs to run the UDR without MongoDB. It writes and reads the documents,
+// and tags them with their ETags, as the MongoDB connector does. The documents are lost when the UDR stops.
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/free5gc/udr/internal/database/mongodb"
+	"github.com/free5gc/udr/internal/util"
+)
+
+// document is a stored document
+type document struct {
+	data map[string]interface{}
+}
+
+// Store is a database.DataStore keeping the documents of each collection in memory, in their insertion order.
+// Its operations are atomic.
+type Store struct {
+	mu          sync.Mutex
+	collections map[string][]*document
+}
+
+func NewStore() *Store {
+	return &Store{collections: make(map[string][]*document)}
+}
+
+// GetOne returns a copy of the first document matching filter along with its ETag
+func (s *Store) GetOne(ctx context.Context, collName string, filter bson.M) (map[string]interface{}, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	doc, err := s.findOne(collName, filter, false)
+	if err != nil {
+		return nil, "", err
+	}
+	if doc == nil {
+		return nil, "", fmt.Errorf("GetOne: %w in %s", mongodb.ErrNoDocument, collName)
+	}
+	return cloneDocument(doc.data), util.DocumentETag(doc.data), nil
+}
+
+// PutOne replaces the document matching filter with a copy of data, or inserts it when there is none, once
+// ifMatch accepts the current document. As with MongoDB the fields of filter are not added to data.
+func (s *Store) PutOne(ctx context.Context, collName string, filter bson.M, data map[string]interface{},
+	ifMatch string,
+) (bool, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.replace(collName, filter, data, ifMatch, nil)
+}
+
+// PatchOne sets and unsets the dotted paths in the document matching filter. An upsert inserts the document of
+// the fields filter compares by equality when none matches.
+func (s *Store) PatchOne(ctx context.Context, collName string, filter bson.M, update mongodb.Update) (
+	map[string]interface{}, error,
+) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	doc, err := s.findOne(collName, filter, update.CaseInsensitive)
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		if !update.Upsert {
+			return nil, fmt.Errorf("PatchOne: %w in %s", mongodb.ErrNoDocument, collName)
+		}
+		doc = &document{data: equalityFields(filter)}
+		s.collections[collName] = append(s.collections[collName], doc)
+	}
+	for field, value := range update.Set {
+		setPath(doc.data, field, cloneValue(value))
+	}
+	for _, field := range update.Unset {
+		unsetPath(doc.data, field)
+	}
+	return cloneDocument(doc.data), nil
+}
+
+// DeleteOne removes the first document matching filter
+func (s *Store) DeleteOne(ctx context.Context, collName string, filter bson.M) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	docs := s.collections[collName]
+	for i, doc := range docs {
+		matched, err := matches(doc.data, filter, false)
+		if err != nil {
+			return false, fmt.Errorf("DeleteOne: %w", err)
+		}
+		if matched {
+			s.collections[collName] = append(docs[:i:i], docs[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Query returns copies of the documents matching filter, in their insertion order unless sorted. The documents
+// lacking a sort key come first, as MongoDB sorts the missing fields as nulls. The documents have no _id, sorting
+// by it keeps the insertion order as the ObjectIDs MongoDB generates do.
+func (s *Store) Query(ctx context.Context, collName string, filter bson.M, query mongodb.Query) (
+	[]map[string]interface{}, error,
+) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	docs, err := s.find(collName, filter, query.CaseInsensitive)
+	if err != nil {
+		return nil, err
+	}
+	if len(query.SortKeys) > 0 {
+		sort.SliceStable(docs, func(i, j int) bool {
+			for _, key := range query.SortKeys {
+				if lessAt(docs[i].data, docs[j].data, key) {
+					return true
+				}
+				if lessAt(docs[j].data, docs[i].data, key) {
+					return false
+				}
+			}
+			return false
+		})
+	}
+	docs = docs[min(int64(len(docs)), query.Skip):]
+	if query.Limit > 0 {
+		docs = docs[:min(int64(len(docs)), query.Limit)]
+	}
+	var results []map[string]interface{}
+	for _, doc := range docs {
+		result := projectFields(doc.data, query.Fields)
+		if query.Slice != nil {
+			sliceField(result, doc.data, *query.Slice)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// Documents returns copies of the documents of every collection holding any
+func (s *Store) Documents() map[string][]map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	collections := make(map[string][]map[string]interface{}, len(s.collections))
+	for collName, docs := range s.collections {
+		for _, doc := range docs {
+			collections[collName] = append(collections[collName], cloneDocument(doc.data))
+		}
+	}
+	return collections
+}
+
+func (s *Store) find(collName string, filter bson.M, caseInsensitive bool) ([]*document, error) {
+	var docs []*document
+	for _, doc := range s.collections[collName] {
+		matched, err := matches(doc.data, filter, caseInsensitive)
+		if err != nil {
+			return nil, fmt.Errorf("find in %s: %w", collName, err)
+		}
+		if matched {
+			docs = append(docs, doc)
+		}
+	}
+	return docs, nil
+}
+
+// findOne returns the first document matching filter, nil when there is none
+func (s *Store) findOne(collName string, filter bson.M, caseInsensitive bool) (*document, error) {
+	for _, doc := range s.collections[collName] {
+		matched, err := matches(doc.data, filter, caseInsensitive)
+		if err != nil {
+			return nil, fmt.Errorf("find in %s: %w", collName, err)
+		}
+		if matched {
+			return doc, nil
+		}
+	}
+	return nil, nil
+}
+
+// insert adds a copy of data and returns its ETag
+func (s *Store) insert(collName string, data map[string]interface{}) string {
+	doc := &document{data: cloneDocument(data)}
+	s.collections[collName] = append(s.collections[collName], doc)
+	return util.DocumentETag(doc.data)
+}
+
+// replace replaces the document matching filter with data, or inserts data, when ifMatch and validate accept the
+// current one
+func (s *Store) replace(collName string, filter bson.M, data map[string]interface{}, ifMatch string,
+	validate func(current map[string]interface{}) error,
+) (bool, string, error) {
+	doc, err := s.findOne(collName, filter, false)
+	if err != nil {
+		return false, "", err
+	}
+	var current map[string]interface{}
+	if doc != nil {
+		current = cloneDocument(doc.data)
+	}
+	etag := util.DocumentETag(current)
+	if !util.IfMatch(ifMatch, etag, doc != nil) {
+		return doc != nil, etag, mongodb.ErrVersionMismatch
+	}
+	if validate != nil {
+		if err = validate(current); err != nil {
+			return doc != nil, etag, err
+		}
+	}
+	if doc == nil {
+		return false, s.insert(collName, data), nil
+	}
+	doc.data = cloneDocument(data)
+	return true, util.DocumentETag(doc.data), nil
+}
+
+// snapshot is a copy of every collection
+func (s *Store) snapshot() map[string][]*document {
+	collections := make(map[string][]*document, len(s.collections))
+	for collName, docs := range s.collections {
+		for _, doc := range docs {
+			collections[collName] = append(collections[collName],
+				&document{data: cloneDocument(doc.data)})
+		}
+	}
+	return collections
+}
+
+// projectFields is a copy of the document with only the fields at the dotted paths, all of them when none
+func projectFields(data map[string]interface{}, fields []string) map[string]interface{} {
+	if len(fields) == 0 {
+		return cloneDocument(data)
+	}
+	projected := make(map[string]interface{})
+	for _, field := range fields {
+		if values := valuesAt(data, field); len(values) > 0 {
+			setPath(projected, field, cloneValue(values[0]))
+		}
+	}
+	return projected
+}
+
+// sliceField sets in result the part of the elements of the array field of data the slice tells, as $slice does
+func sliceField(result map[string]interface{}, data map[string]interface{}, slice mongodb.Slice) {
+	elements, ok := data[slice.Field].([]interface{})
+	if !ok {
+		return
+	}
+	start := min(int64(len(elements)), slice.Skip)
+	end := min(int64(len(elements)), start+slice.Limit)
+	result[slice.Field] = cloneValue(elements[start:end])
+}
+
+// lessAt tells whether the value of a at the dotted path sorts before the one of b
+func lessAt(a, b map[string]interface{}, path string) bool {
+	aValues, bValues := valuesAt(a, path), valuesAt(b, path)
+	if len(aValues) == 0 || len(bValues) == 0 {
+		return len(aValues) == 0 && len(bValues) > 0
+	}
+	order, comparable := compare(aValues[0], bValues[0], false)
+	if !comparable {
+		return fmt.Sprint(aValues[0]) < fmt.Sprint(bValues[0])
+	}
+	return order < 0
+}
+
+// equalityFields is the document of the fields filter compares by equality, as a MongoDB upsert inserts it
+func equalityFields(filter bson.M) map[string]interface{} {
+	doc := make(map[string]interface{})
+	for field, condition := range filter {
+		if operators, ok := documentOf(condition); strings.HasPrefix(field, "$") || ok && isOperators(operators) {
+			continue
+		}
+		setPath(doc, field, cloneValue(condition))
+	}
+	return doc
+}
+
+// setPath sets the field at the dotted path, creating the documents it goes through
+func setPath(doc map[string]interface{}, path string, value interface{}) {
+	name, nested, isNested := strings.Cut(path, ".")
+	if !isNested {
+		doc[name] = value
+		return
+	}
+	child, ok := doc[name].(map[string]interface{})
+	if !ok {
+		child = make(map[string]interface{})
+		doc[name] = child
+	}
+	setPath(child, nested, value)
+}
+
+// unsetPath removes the field at the dotted path, if any
+func unsetPath(doc map[string]interface{}, path string) {
+	name, nested, isNested := strings.Cut(path, ".")
+	if !isNested {
+		delete(doc, name)
+		return
+	}
+	if child, ok := doc[name].(map[string]interface{}); ok {
+		unsetPath(child, nested)
+	}
+}
+
+// cloneDocument is a deep copy of data whose documents are all map[string]interface{} and arrays []interface{},
+// as they are decoded from MongoDB or JSON
+func cloneDocument(data map[string]interface{}) map[string]interface{} {
+	if data == nil {
+		return nil
+	}
+	clone := make(map[string]interface{}, len(data))
+	for field, value := range data {
+		clone[field] = cloneValue(value)
+	}
+	return clone
+}
+
+// cloneValue is a deep copy of value, the values other than the documents, the arrays, the scalars and the times
+// being converted through their JSON
+func cloneValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, string, bool, time.Time,
+		int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return v
+	case map[string]interface{}:
+		return cloneDocument(v)
+	case bson.M:
+		return cloneDocument(v)
+	case []interface{}:
+		clone := make([]interface{}, len(v))
+		for i, element := range v {
+			clone[i] = cloneValue(element)
+		}
+		return clone
+	case bson.A:
+		return cloneValue([]interface{}(v))
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		var decoded interface{}
+		if err = json.Unmarshal(encoded, &decoded); err != nil {
+			return fmt.Sprint(v)
+		}
+		return decoded
+	}
+}
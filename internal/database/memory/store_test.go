@@ -0,0 +1,184 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/free5gc/udr/internal/database/mongodb"
+)
+
+func TestStore_ETags(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+	filter := bson.M{"ueId": "imsi-208930000000001"}
+
+	_, _, err := store.GetOne(ctx, "amData", filter)
+	require.True(t, errors.Is(err, mongodb.ErrNoDocument))
+	_, _, err = store.PutOne(ctx, "amData", filter, map[string]interface{}{"ueId": "imsi-208930000000001"}, "*")
+	require.True(t, errors.Is(err, mongodb.ErrVersionMismatch))
+
+	existed, inserted, err := store.PutOne(ctx, "amData", filter,
+		map[string]interface{}{"ueId": "imsi-208930000000001", "gpsis": []string{"msisdn-0900000000"}}, "")
+	require.NoError(t, err)
+	require.False(t, existed)
+	require.NotEmpty(t, inserted)
+
+	existed, replaced, err := store.PutOne(ctx, "amData", filter,
+		map[string]interface{}{"ueId": "imsi-208930000000001", "nssai": bson.M{"defaultSingleNssais": bson.A{}}},
+		inserted)
+	require.NoError(t, err)
+	require.True(t, existed)
+	require.NotEqual(t, inserted, replaced)
+	_, _, err = store.PutOne(ctx, "amData", filter, map[string]interface{}{}, inserted)
+	require.True(t, errors.Is(err, mongodb.ErrVersionMismatch))
+
+	doc, etag, err := store.GetOne(ctx, "amData", filter)
+	require.NoError(t, err)
+	require.Equal(t, replaced, etag)
+	require.Equal(t, map[string]interface{}{
+		"ueId":  "imsi-208930000000001",
+		"nssai": map[string]interface{}{"defaultSingleNssais": []interface{}{}},
+	}, doc)
+
+	// The documents returned are copies
+	doc["ueId"] = "imsi-208930000000002"
+	doc, err = store.PatchOne(ctx, "amData", filter, mongodb.Update{
+		Set:   bson.M{"nssai.singleNssais": bson.A{bson.M{"sst": 1}}},
+		Unset: []string{"nssai.defaultSingleNssais"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"ueId":  "imsi-208930000000001",
+		"nssai": map[string]interface{}{"singleNssais": []interface{}{map[string]interface{}{"sst": 1}}},
+	}, doc)
+	_, etag, err = store.GetOne(ctx, "amData", filter)
+	require.NoError(t, err)
+	require.NotEqual(t, replaced, etag)
+
+	_, err = store.PatchOne(ctx, "amData", bson.M{"ueId": "imsi-208930000000002"},
+		mongodb.Update{Set: bson.M{"gpsis": bson.A{}}})
+	require.True(t, errors.Is(err, mongodb.ErrNoDocument))
+	doc, err = store.PatchOne(ctx, "amData", bson.M{"ueId": "IMSI-208930000000001"},
+		mongodb.Update{Set: bson.M{"gpsis": bson.A{}}, CaseInsensitive: true})
+	require.NoError(t, err)
+	require.Equal(t, "imsi-208930000000001", doc["ueId"])
+	doc, err = store.PatchOne(ctx, "amData", bson.M{"ueId": "imsi-208930000000002", "version": bson.M{"$exists": false}},
+		mongodb.Update{Set: bson.M{"gpsis": bson.A{}}, Upsert: true})
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"ueId": "imsi-208930000000002", "gpsis": []interface{}{}}, doc)
+	deleted, err := store.DeleteOne(ctx, "amData", bson.M{"ueId": "imsi-208930000000002"})
+	require.NoError(t, err)
+	require.True(t, deleted)
+
+	deleted, err = store.DeleteOne(ctx, "amData", filter)
+	require.NoError(t, err)
+	require.True(t, deleted)
+	deleted, err = store.DeleteOne(ctx, "amData", filter)
+	require.NoError(t, err)
+	require.False(t, deleted)
+}
+
+func TestStore_Query(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, doc := range []map[string]interface{}{
+		{"ueId": "imsi-208930000000003", "gpsi": "msisdn-0900000003", "timestamp": start.Add(3 * time.Hour),
+			"singleNssai": map[string]interface{}{"sst": 1, "sd": "010203"}},
+		{"ueId": "imsi-208930000000001", "timestamp": start.Add(time.Hour),
+			"smPolicyDnnData": []interface{}{map[string]interface{}{"dnn": "internet"}}},
+		{"ueId": "IMSI-208930000000002", "gpsi": "msisdn-0900000002", "timestamp": start.Add(2 * time.Hour)},
+	} {
+		filter := bson.M{"ueId": doc["ueId"]}
+		_, _, err := store.PutOne(ctx, "coll", filter, doc, "")
+		require.NoError(t, err, i)
+	}
+
+	ueIdsOf := func(filter bson.M, query mongodb.Query) []interface{} {
+		docs, err := store.Query(ctx, "coll", filter, query)
+		require.NoError(t, err)
+		ueIds := []interface{}{}
+		for _, doc := range docs {
+			ueIds = append(ueIds, doc["ueId"])
+		}
+		return ueIds
+	}
+
+	require.Equal(t, []interface{}{"imsi-208930000000003", "imsi-208930000000001", "IMSI-208930000000002"},
+		ueIdsOf(bson.M{}, mongodb.Query{}))
+	require.Equal(t, []interface{}{"imsi-208930000000001", "IMSI-208930000000002"},
+		ueIdsOf(bson.M{}, mongodb.Query{SortKeys: []string{"timestamp"}, Limit: 2}))
+	require.Equal(t, []interface{}{"IMSI-208930000000002", "imsi-208930000000003"},
+		ueIdsOf(bson.M{}, mongodb.Query{SortKeys: []string{"gpsi"}, Skip: 1}))
+	require.Equal(t, []interface{}{},
+		ueIdsOf(bson.M{"ueId": "imsi-208930000000002"}, mongodb.Query{}))
+	require.Equal(t, []interface{}{"IMSI-208930000000002"},
+		ueIdsOf(bson.M{"ueId": "imsi-208930000000002"}, mongodb.Query{CaseInsensitive: true}))
+	require.Equal(t, []interface{}{"imsi-208930000000003"},
+		ueIdsOf(bson.M{"singleNssai.sst": 1, "singleNssai.sd": "010203"}, mongodb.Query{}))
+	require.Equal(t, []interface{}{"imsi-208930000000001"},
+		ueIdsOf(bson.M{"smPolicyDnnData.dnn": "internet"}, mongodb.Query{}))
+	require.Equal(t, []interface{}{"imsi-208930000000003", "imsi-208930000000001"},
+		ueIdsOf(bson.M{"ueId": bson.M{"$in": []string{"imsi-208930000000001", "imsi-208930000000003"}}},
+			mongodb.Query{}))
+	require.Equal(t, []interface{}{"imsi-208930000000001"},
+		ueIdsOf(bson.M{"gpsi": bson.M{"$exists": false}}, mongodb.Query{}))
+	require.Equal(t, []interface{}{"IMSI-208930000000002"},
+		ueIdsOf(bson.M{"timestamp": bson.M{"$gte": start.Add(90 * time.Minute), "$lte": start.Add(2 * time.Hour)}},
+			mongodb.Query{}))
+	require.Equal(t, []interface{}{"imsi-208930000000003", "IMSI-208930000000002"},
+		ueIdsOf(bson.M{"$or": []bson.M{{"gpsi": "msisdn-0900000002"}, {"ueId": "imsi-208930000000003"}}},
+			mongodb.Query{}))
+	require.Equal(t, []interface{}{"IMSI-208930000000002"},
+		ueIdsOf(bson.M{"$and": []bson.M{{"gpsi": bson.M{"$exists": true}}, {"ueId": "IMSI-208930000000002"}}},
+			mongodb.Query{}))
+
+	docs, err := store.Query(ctx, "coll", bson.M{"gpsi": "msisdn-0900000003"},
+		mongodb.Query{Fields: []string{"ueId", "singleNssai.sd", "smPolicyDnnData"}})
+	require.NoError(t, err)
+	require.Equal(t, []map[string]interface{}{
+		{"ueId": "imsi-208930000000003", "singleNssai": map[string]interface{}{"sd": "010203"}},
+	}, docs)
+
+	docs, err = store.Query(ctx, "coll", bson.M{"ueId": "imsi-208930000000001"},
+		mongodb.Query{Fields: []string{"ueId"}, Slice: &mongodb.Slice{Field: "smPolicyDnnData", Skip: 0, Limit: 1}})
+	require.NoError(t, err)
+	require.Equal(t, []map[string]interface{}{{
+		"ueId": "imsi-208930000000001", "smPolicyDnnData": []interface{}{map[string]interface{}{"dnn": "internet"}},
+	}}, docs)
+	docs, err = store.Query(ctx, "coll", bson.M{"ueId": "imsi-208930000000001"},
+		mongodb.Query{Fields: []string{"ueId"}, Slice: &mongodb.Slice{Field: "smPolicyDnnData", Skip: 1, Limit: 1}})
+	require.NoError(t, err)
+	require.Equal(t, []map[string]interface{}{{"ueId": "imsi-208930000000001", "smPolicyDnnData": []interface{}{}}},
+		docs)
+
+	_, err = store.Query(ctx, "coll", bson.M{"ueId": bson.M{"$regex": "^imsi"}}, mongodb.Query{})
+	require.Error(t, err)
+}
+
+func TestConnector_Transaction(t *testing.T) {
+	ctx := context.Background()
+	connector := NewConnector()
+	filter := bson.M{"ueId": "imsi-208930000000001"}
+	require.NoError(t, connector.InsertDataToDB(ctx, "coll", map[string]interface{}{"ueId": "imsi-208930000000001"}))
+
+	transactional, err := connector.WithTransaction(ctx, func(ctx context.Context) error {
+		if _, err := connector.DeleteOne(ctx, "coll", filter); err != nil {
+			return err
+		}
+		return errors.New("aborted")
+	})
+	require.True(t, transactional)
+	require.EqualError(t, err, "aborted")
+	_, _, err = connector.GetOne(ctx, "coll", filter)
+	require.NoError(t, err)
+
+	deleted, _, err := connector.DeleteManyDataFromDB(ctx, []string{"coll", "other"}, filter)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{"coll": 1}, deleted)
+}
@@ -0,0 +1,158 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	udr_metrics "github.com/free5gc/udr/internal/metrics"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/util/mongoapi"
+)
+
+// Query tells how the documents of a query are ordered and paged
+type Query struct {
+	// SortKeys order the documents by the ascending values of the fields, each one breaking the ties of the ones
+	// before it, they are in no particular order when empty
+	SortKeys []string
+	Skip     int64
+	// Limit bounds the count of documents, none when 0
+	Limit int64
+	// CaseInsensitive compares the strings regardless of their case, as COLLATION_STRENGTH_IGNORE_CASE does
+	CaseInsensitive bool
+	// Fields are the only fields of the documents returned, all of them when empty
+	Fields []string
+	// Slice returns only a part of the elements of an array field, all of them when nil
+	Slice *Slice
+}
+
+// Slice is the part of the elements of an array field a query returns, at most Limit of them after the first Skip
+type Slice struct {
+	Field string
+	Skip  int64
+	Limit int64
+}
+
+// Update tells the fields a patch sets and removes, by the dotted paths of the nested ones
+type Update struct {
+	Set   bson.M
+	Unset []string
+	// Upsert inserts the document of the equality fields of the filter along with Set when none matches
+	Upsert bool
+	// CaseInsensitive matches the filter regardless of the case of its strings, as COLLATION_STRENGTH_IGNORE_CASE
+	// does
+	CaseInsensitive bool
+}
+
+// GetOne returns the document matching filter along with its ETag, ErrNoDocument when there is none
+func (m MongoDbConnector) GetOne(ctx context.Context, collName string, filter bson.M) (
+	map[string]interface{}, string, error,
+) {
+	data, err := m.findOne(ctx, collName, filter, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	if data == nil {
+		return nil, "", fmt.Errorf("GetOne: %w in %s", ErrNoDocument, collName)
+	}
+	delete(data, util.DocumentVersionKey)
+	return data, util.DocumentETag(data), nil
+}
+
+// PutOne replaces the document matching filter with data, or inserts data when there is none, once ifMatch
+// accepts the current document. It tells whether the document existed and returns its new ETag.
+func (m MongoDbConnector) PutOne(ctx context.Context, collName string, filter bson.M,
+	data map[string]interface{}, ifMatch string,
+) (existed bool, etag string, err error) {
+	return m.ReplaceVersionedDataToDB(ctx, collName, filter, data, ifMatch, nil)
+}
+
+// PatchOne sets the fields of set and removes the ones of unset, dotted paths of nested fields, in the document
+// matching filter with a single findOneAndUpdate: the writes of other fields landing meanwhile are kept. It
+// returns the document after the update.
+func (m MongoDbConnector) PatchOne(ctx context.Context, collName string, filter bson.M, update Update) (
+	newValue map[string]interface{}, err error,
+) {
+	modification := bson.M{"$inc": bson.M{util.DocumentVersionKey: 1}}
+	if len(update.Set) > 0 {
+		modification["$set"] = update.Set
+	}
+	if len(update.Unset) > 0 {
+		unsetFields := make(bson.M, len(update.Unset))
+		for _, field := range update.Unset {
+			unsetFields[field] = ""
+		}
+		modification["$unset"] = unsetFields
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After).SetUpsert(update.Upsert)
+	if update.CaseInsensitive {
+		opts.SetCollation(&options.Collation{Locale: "en_US", Strength: mongoapi.COLLATION_STRENGTH_IGNORE_CASE})
+	}
+	err = mongoapi.Client.Database(m.Name).Collection(collName).FindOneAndUpdate(ctx, filter, modification, opts).
+		Decode(&newValue)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		udr_metrics.IncrMongoDbOpCounter("find_one_and_update", collName, nil)
+		return nil, fmt.Errorf("PatchOne: %w in %s", ErrNoDocument, collName)
+	}
+	udr_metrics.IncrMongoDbOpCounter("find_one_and_update", collName, err)
+	if err != nil {
+		return nil, fmt.Errorf("PatchOne FindOneAndUpdate err: %w", err)
+	}
+	delete(newValue, "_id")
+	delete(newValue, util.DocumentVersionKey)
+	return newValue, nil
+}
+
+// DeleteOne removes the document matching filter and tells whether there was one
+func (m MongoDbConnector) DeleteOne(ctx context.Context, collName string, filter bson.M) (bool, error) {
+	result, err := mongoapi.Client.Database(m.Name).Collection(collName).DeleteOne(ctx, filter)
+	udr_metrics.IncrMongoDbOpCounter("delete_one", collName, err)
+	if err != nil {
+		return false, fmt.Errorf("DeleteOne err: %w", err)
+	}
+	return result.DeletedCount > 0, nil
+}
+
+// Query returns the documents matching filter, ordered and paged as told by query
+func (m MongoDbConnector) Query(ctx context.Context, collName string, filter bson.M, query Query) (
+	[]map[string]interface{}, error,
+) {
+	opts := options.Find()
+	if len(query.SortKeys) > 0 {
+		sortKeys := make(bson.D, 0, len(query.SortKeys))
+		for _, key := range query.SortKeys {
+			sortKeys = append(sortKeys, bson.E{Key: key, Value: 1})
+		}
+		opts.SetSort(sortKeys)
+	}
+	if query.Skip > 0 {
+		opts.SetSkip(query.Skip)
+	}
+	if query.Limit > 0 {
+		opts.SetLimit(query.Limit)
+	}
+	if query.CaseInsensitive {
+		opts.SetCollation(&options.Collation{Locale: "en_US", Strength: mongoapi.COLLATION_STRENGTH_IGNORE_CASE})
+	}
+	if len(query.Fields) > 0 {
+		projection := bson.M{"_id": 0}
+		for _, field := range query.Fields {
+			projection[field] = 1
+		}
+		opts.SetProjection(projection)
+	}
+	if query.Slice != nil {
+		projection, ok := opts.Projection.(bson.M)
+		if !ok {
+			projection = bson.M{}
+		}
+		projection[query.Slice.Field] = bson.M{"$slice": bson.A{query.Slice.Skip, query.Slice.Limit}}
+		opts.SetProjection(projection)
+	}
+	return m.find(ctx, collName, filter, opts)
+}
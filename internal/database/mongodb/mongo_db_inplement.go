@@ -1,18 +1,45 @@
 package mongodb
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
 
 	"github.com/free5gc/openapi"
 	"github.com/free5gc/openapi/models"
 	"github.com/free5gc/udr/internal/logger"
+	udr_metrics "github.com/free5gc/udr/internal/metrics"
 	"github.com/free5gc/udr/internal/util"
 	"github.com/free5gc/udr/pkg/factory"
 	"github.com/free5gc/util/mongoapi"
 )
 
+// ErrVersionMismatch is returned by the versioned writes when the If-Match precondition does not hold
+var ErrVersionMismatch = errors.New("document version does not match If-Match")
+
+// ErrNoDocument is returned by the versioned modifications when there is no document to modify
+var ErrNoDocument = errors.New("no document")
+
+// IsUnavailable tells whether err is MongoDB being unreachable, rather than the operation failing
+func IsUnavailable(err error) bool {
+	return mongo.IsNetworkError(err) || errors.As(err, &topology.ServerSelectionError{})
+}
+
+// versionedWriteMaxAttempts bounds the retries of a versioned write racing with other writers
+const versionedWriteMaxAttempts = 3
+
 type MongoDbConnector struct {
 	*factory.Mongodb
 }
@@ -23,49 +50,351 @@ func NewMongoDbConnector(mongo *factory.Mongodb) MongoDbConnector {
 	}
 }
 
-func (m MongoDbConnector) PatchDataToDBAndNotify(
+func (m MongoDbConnector) PatchDataToDBAndNotify(ctx context.Context,
 	collName string, ueId string, patchItem []models.PatchItem, filter bson.M,
 ) (origValue, newValue map[string]interface{}, err error) {
-	origValue, err = mongoapi.RestfulAPIGetOne(collName, filter)
+	patchJSON, err := json.Marshal(patchItem)
 	if err != nil {
 		return
 	}
-
-	patchJSON, err := json.Marshal(patchItem)
+	patch, err := jsonpatch.DecodePatch(patchJSON)
 	if err != nil {
-		return
+		return nil, nil, fmt.Errorf("PatchDataToDBAndNotify DecodePatch err: %+v", err)
 	}
 
-	if err = mongoapi.RestfulAPIJSONPatch(collName, filter, patchJSON); err != nil {
-		return
-	}
+	return m.modifyData(ctx, collName, filter, "json_patch", func(original []byte) ([]byte, error) {
+		return patch.Apply(original)
+	})
+}
 
-	newValue, err = mongoapi.RestfulAPIGetOne(collName, filter)
-	if err != nil {
-		return
+// MergePatchDataToDBAndNotify applies the RFC 7386 merge patch, a JSON object, to the document matching filter
+func (m MongoDbConnector) MergePatchDataToDBAndNotify(ctx context.Context,
+	collName string, ueId string, mergePatch []byte, filter bson.M,
+) (origValue, newValue map[string]interface{}, err error) {
+	return m.modifyData(ctx, collName, filter, "merge_patch", func(original []byte) ([]byte, error) {
+		return jsonpatch.MergePatch(original, mergePatch)
+	})
+}
+
+// modifyData sets the fields of the modified copy of the document matching filter, as mongoapi does,
+// and returns the document before and after the modification. The update is guarded by the document version:
+// when another write lands in between, the latest document is modified again, until the write succeeds or ctx
+// is done.
+func (m MongoDbConnector) modifyData(ctx context.Context, collName string, filter bson.M, op string,
+	modify func(original []byte) ([]byte, error),
+) (origValue, newValue map[string]interface{}, err error) {
+	collection := mongoapi.Client.Database(m.Name).Collection(collName)
+	for ctx.Err() == nil {
+		if origValue, err = m.findOne(ctx, collName, filter, nil); err != nil {
+			return nil, nil, err
+		}
+		if origValue == nil {
+			return nil, nil, fmt.Errorf("%s: %w in %s", op, ErrNoDocument, collName)
+		}
+		version := util.DocumentVersion(origValue)
+		delete(origValue, util.DocumentVersionKey)
+		original, err := json.Marshal(origValue)
+		if err != nil {
+			return nil, nil, err
+		}
+		modified, err := modify(original)
+		if err != nil {
+			return nil, nil, err
+		}
+		if newValue, err = unmarshalDocument(modified); err != nil {
+			return nil, nil, err
+		}
+		delete(newValue, util.DocumentVersionKey)
+
+		result, err := collection.UpdateOne(ctx, versionFilter(filter, version), versionedUpdate(newValue))
+		udr_metrics.IncrMongoDbOpCounter(op, collName, err)
+		if err != nil {
+			return nil, nil, fmt.Errorf("UpdateOne err: %w", err)
+		}
+		if result.MatchedCount == 1 {
+			return origValue, newValue, nil
+		}
+		// The version moved on, modify the latest document
 	}
+	return nil, nil, fmt.Errorf("%s: %w", op, ctx.Err())
+}
+
+// ModifyDataFieldToDB sets the field of the document matching filter to its modified value with a single
+// findOneAndUpdate guarded by the document version. When another write lands in between, modify is given
+// the latest value again, until the write succeeds or ctx is done. Nothing is written when modify reports
+// no change. It returns the document before and after the modification.
+func (m MongoDbConnector) ModifyDataFieldToDB(ctx context.Context, collName string, filter bson.M, field string,
+	modify func(value []byte) (modified []byte, changed bool, err error),
+) (origValue, newValue map[string]interface{}, err error) {
+	collection := mongoapi.Client.Database(m.Name).Collection(collName)
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	for ctx.Err() == nil {
+		if origValue, err = m.findOne(ctx, collName, filter, nil); err != nil {
+			return nil, nil, err
+		}
+		if origValue == nil {
+			return nil, nil, fmt.Errorf("ModifyDataFieldToDB: %w in %s", ErrNoDocument, collName)
+		}
+		version := util.DocumentVersion(origValue)
+		delete(origValue, util.DocumentVersionKey)
+
+		value, err := json.Marshal(origValue[field])
+		if err != nil {
+			return nil, nil, fmt.Errorf("ModifyDataFieldToDB Marshal err: %+v", err)
+		}
+		modified, changed, err := modify(value)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !changed {
+			return origValue, origValue, nil
+		}
+		var modifiedValue interface{}
+		if err = json.Unmarshal(modified, &modifiedValue); err != nil {
+			return nil, nil, fmt.Errorf("ModifyDataFieldToDB Unmarshal err: %+v", err)
+		}
 
-	return
+		update := bson.M{"$set": bson.M{field: modifiedValue, util.DocumentVersionKey: version + 1}}
+		err = collection.FindOneAndUpdate(ctx, versionFilter(filter, version), update, opts).Decode(&newValue)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// The version moved on, modify the latest value
+			udr_metrics.IncrMongoDbOpCounter("find_one_and_update", collName, nil)
+			continue
+		}
+		udr_metrics.IncrMongoDbOpCounter("find_one_and_update", collName, err)
+		if err != nil {
+			return nil, nil, fmt.Errorf("ModifyDataFieldToDB FindOneAndUpdate err: %w", err)
+		}
+		delete(newValue, "_id")
+		delete(newValue, util.DocumentVersionKey)
+		return origValue, newValue, nil
+	}
+	return nil, nil, fmt.Errorf("ModifyDataFieldToDB: %w", ctx.Err())
 }
 
 func (m MongoDbConnector) GetDataFromDB(
-	collName string, filter bson.M) (
+	ctx context.Context, collName string, filter bson.M) (
 	map[string]interface{}, *models.ProblemDetails,
 ) {
-	data, err := mongoapi.RestfulAPIGetOne(collName, filter)
+	data, _, pd := m.GetVersionedDataFromDB(ctx, collName, filter)
+	return data, pd
+}
+
+// GetVersionedDataFromDB returns the document along with its ETag
+func (m MongoDbConnector) GetVersionedDataFromDB(ctx context.Context, collName string, filter bson.M) (
+	map[string]interface{}, string, *models.ProblemDetails,
+) {
+	data, etag, err := m.GetOne(ctx, collName, filter)
+	if errors.Is(err, ErrNoDocument) {
+		return nil, "", util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	}
 	if err != nil {
-		return nil, openapi.ProblemDetailsSystemFailure(err.Error())
+		return nil, "", openapi.ProblemDetailsSystemFailure(err.Error())
 	}
-	if data == nil {
-		return nil, util.ProblemDetailsNotFound("DATA_NOT_FOUND")
+	return data, etag, nil
+}
+
+// PutVersionedDataToDB stores putData when ifMatch accepts the current document and returns its new ETag.
+// Without ifMatch the write is retried when another one lands in between.
+func (m MongoDbConnector) PutVersionedDataToDB(ctx context.Context, collName string, filter bson.M,
+	putData map[string]interface{}, ifMatch string,
+) (existed bool, etag string, err error) {
+	collection := mongoapi.Client.Database(m.Name).Collection(collName)
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	for attempt := 0; attempt < versionedWriteMaxAttempts; attempt++ {
+		current, err := m.findOne(ctx, collName, filter, nil)
+		if err != nil {
+			return false, "", err
+		}
+		version := util.DocumentVersion(current)
+		if !util.IfMatch(ifMatch, util.DocumentETag(current), current != nil) {
+			return current != nil, util.DocumentETag(current), ErrVersionMismatch
+		}
+
+		versioned := versionedData(putData, version+1)
+		if current == nil {
+			if etag, err = storedETag(versioned); err != nil {
+				return false, "", err
+			}
+			_, err = collection.InsertOne(ctx, versioned)
+			udr_metrics.IncrMongoDbOpCounter("insert_one", collName, err)
+			if err != nil {
+				return false, "", fmt.Errorf("PutVersionedDataToDB InsertOne err: %+v", err)
+			}
+			return false, etag, nil
+		}
+
+		var stored map[string]interface{}
+		err = collection.FindOneAndUpdate(ctx, versionFilter(filter, version), bson.M{"$set": versioned}, opts).
+			Decode(&stored)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// The version moved on, check the latest document
+			udr_metrics.IncrMongoDbOpCounter("find_one_and_update", collName, nil)
+			continue
+		}
+		udr_metrics.IncrMongoDbOpCounter("find_one_and_update", collName, err)
+		if err != nil {
+			return true, "", fmt.Errorf("PutVersionedDataToDB FindOneAndUpdate err: %+v", err)
+		}
+		return true, util.DocumentETag(stored), nil
 	}
-	return data, nil
+	return true, "", fmt.Errorf("PutVersionedDataToDB: %s kept being modified concurrently", collName)
+}
+
+// ReplaceVersionedDataToDB replaces the document with data when ifMatch and validate accept the current one,
+// nil when there is none, and returns its new ETag. The write is retried when another one lands in between.
+func (m MongoDbConnector) ReplaceVersionedDataToDB(ctx context.Context, collName string, filter bson.M,
+	data map[string]interface{}, ifMatch string, validate func(current map[string]interface{}) error,
+) (existed bool, etag string, err error) {
+	collection := mongoapi.Client.Database(m.Name).Collection(collName)
+	if etag, err = storedETag(data); err != nil {
+		return false, "", err
+	}
+	for attempt := 0; attempt < versionedWriteMaxAttempts; attempt++ {
+		current, err := m.findOne(ctx, collName, filter, nil)
+		if err != nil {
+			return false, "", err
+		}
+		version := util.DocumentVersion(current)
+		if current != nil {
+			delete(current, util.DocumentVersionKey)
+		}
+		if !util.IfMatch(ifMatch, util.DocumentETag(current), current != nil) {
+			return current != nil, util.DocumentETag(current), ErrVersionMismatch
+		}
+		if validate != nil {
+			if err = validate(current); err != nil {
+				return current != nil, util.DocumentETag(current), err
+			}
+		}
+
+		versioned := versionedData(data, version+1)
+		if current == nil {
+			_, err = collection.InsertOne(ctx, versioned)
+			udr_metrics.IncrMongoDbOpCounter("insert_one", collName, err)
+			if err != nil {
+				return false, "", fmt.Errorf("ReplaceVersionedDataToDB InsertOne err: %+v", err)
+			}
+			return false, etag, nil
+		}
+
+		result, err := collection.ReplaceOne(ctx, versionFilter(filter, version), versioned)
+		udr_metrics.IncrMongoDbOpCounter("replace_one", collName, err)
+		if err != nil {
+			return true, "", fmt.Errorf("ReplaceVersionedDataToDB ReplaceOne err: %+v", err)
+		}
+		if result.MatchedCount == 1 {
+			return true, etag, nil
+		}
+	}
+	return true, "", fmt.Errorf("ReplaceVersionedDataToDB: %s kept being modified concurrently", collName)
 }
 
-func (m MongoDbConnector) GetDataFromDBWithArg(collName string, filter bson.M, strength int) (
+// PatchVersionedDataToDB applies the patch when ifMatch accepts the current document,
+// it returns the document before and after the patch and the new ETag
+func (m MongoDbConnector) PatchVersionedDataToDB(ctx context.Context, collName string, filter bson.M,
+	patchItem []models.PatchItem, ifMatch string,
+) (origValue, newValue map[string]interface{}, etag string, err error) {
+	patchJSON, err := json.Marshal(patchItem)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	patch, err := jsonpatch.DecodePatch(patchJSON)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("PatchVersionedDataToDB DecodePatch err: %+v", err)
+	}
+
+	return m.modifyVersionedData(ctx, collName, filter, ifMatch, "PatchVersionedDataToDB",
+		func(original []byte) (map[string]interface{}, error) {
+			modified, err := patch.Apply(original)
+			if err != nil {
+				return nil, fmt.Errorf("PatchVersionedDataToDB Apply err: %w", err)
+			}
+			return unmarshalDocument(modified)
+		})
+}
+
+// MergePatchVersionedDataToDB applies the RFC 7386 merge patch when ifMatch accepts the current document.
+// validate, when not nil, is given the patched document before it is written, its error is returned as is.
+func (m MongoDbConnector) MergePatchVersionedDataToDB(ctx context.Context, collName string, filter bson.M,
+	mergePatch []byte, ifMatch string, validate func(map[string]interface{}) error,
+) (origValue, newValue map[string]interface{}, etag string, err error) {
+	return m.modifyVersionedData(ctx, collName, filter, ifMatch, "MergePatchVersionedDataToDB",
+		func(original []byte) (map[string]interface{}, error) {
+			modified, err := jsonpatch.MergePatch(original, mergePatch)
+			if err != nil {
+				return nil, fmt.Errorf("MergePatchVersionedDataToDB MergePatch err: %w", err)
+			}
+			document, err := unmarshalDocument(modified)
+			if err != nil {
+				return nil, err
+			}
+			if validate == nil {
+				return document, nil
+			}
+			if err = validate(document); err != nil {
+				return nil, err
+			}
+			return document, nil
+		})
+}
+
+// modifyVersionedData replaces the current document by its modified copy when ifMatch accepts it,
+// the modification is done again on the latest document when another write lands in between
+func (m MongoDbConnector) modifyVersionedData(ctx context.Context, collName string, filter bson.M, ifMatch string,
+	op string, modify func(original []byte) (map[string]interface{}, error),
+) (origValue, newValue map[string]interface{}, etag string, err error) {
+	collection := mongoapi.Client.Database(m.Name).Collection(collName)
+	for attempt := 0; attempt < versionedWriteMaxAttempts; attempt++ {
+		origValue, err = m.findOne(ctx, collName, filter, nil)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		version := util.DocumentVersion(origValue)
+		if !util.IfMatch(ifMatch, util.DocumentETag(origValue), origValue != nil) {
+			return nil, nil, util.DocumentETag(origValue), ErrVersionMismatch
+		}
+		if origValue == nil {
+			return nil, nil, "", fmt.Errorf("%s: %w in %s", op, ErrNoDocument, collName)
+		}
+		delete(origValue, util.DocumentVersionKey)
+
+		original, err := json.Marshal(origValue)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("%s Marshal err: %+v", op, err)
+		}
+		if newValue, err = modify(original); err != nil {
+			return nil, nil, "", err
+		}
+		if etag, err = storedETag(newValue); err != nil {
+			return nil, nil, "", err
+		}
+
+		result, err := collection.ReplaceOne(ctx, versionFilter(filter, version), versionedData(newValue, version+1))
+		udr_metrics.IncrMongoDbOpCounter("replace_one", collName, err)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("%s ReplaceOne err: %+v", op, err)
+		}
+		if result.MatchedCount == 1 {
+			return origValue, newValue, etag, nil
+		}
+	}
+	return nil, nil, "", fmt.Errorf("%s: %s kept being modified concurrently", op, collName)
+}
+
+func unmarshalDocument(data []byte) (map[string]interface{}, error) {
+	var document map[string]interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("unmarshal document err: %+v", err)
+	}
+	return document, nil
+}
+
+func (m MongoDbConnector) GetDataFromDBWithArg(ctx context.Context, collName string, filter bson.M, strength int) (
 	map[string]interface{}, *models.ProblemDetails,
 ) {
-	data, err := mongoapi.RestfulAPIGetOne(collName, filter, strength)
+	// Strength 2: Case insensitive, 3: Case sensitive (default)
+	data, err := m.findOne(ctx, collName, filter, &options.Collation{Locale: "en_US", Strength: strength})
 	if err != nil {
 		return nil, openapi.ProblemDetailsSystemFailure(err.Error())
 	}
@@ -73,12 +402,493 @@ func (m MongoDbConnector) GetDataFromDBWithArg(collName string, filter bson.M, s
 		logger.ConsumerLog.Errorln("filter: ", filter)
 		return nil, util.ProblemDetailsNotFound("DATA_NOT_FOUND")
 	}
+	delete(data, util.DocumentVersionKey)
 
 	return data, nil
 }
 
-func (m MongoDbConnector) DeleteDataFromDB(collName string, filter bson.M) {
-	if err := mongoapi.RestfulAPIDeleteOne(collName, filter); err != nil {
+func (m MongoDbConnector) DeleteDataFromDB(ctx context.Context, collName string, filter bson.M) {
+	if err := m.DeleteOneDataFromDB(ctx, collName, filter); err != nil {
 		logger.DataRepoLog.Errorf("deleteDataFromDB: %+v", err)
 	}
 }
+
+// DeleteOneDataFromDB is DeleteDataFromDB returning its error to the caller
+func (m MongoDbConnector) DeleteOneDataFromDB(ctx context.Context, collName string, filter bson.M) error {
+	_, err := m.DeleteOne(ctx, collName, filter)
+	return err
+}
+
+// FindDataFromDB returns the document matching filter, nil when there is none
+func (m MongoDbConnector) FindDataFromDB(ctx context.Context, collName string, filter bson.M) (
+	map[string]interface{}, error,
+) {
+	data, err := m.findOne(ctx, collName, filter, nil)
+	delete(data, util.DocumentVersionKey)
+	return data, err
+}
+
+func (m MongoDbConnector) GetManyDataFromDB(ctx context.Context, collName string, filter bson.M) (
+	[]map[string]interface{}, error,
+) {
+	return m.Query(ctx, collName, filter, Query{})
+}
+
+func (m MongoDbConnector) GetManyDataFromDBWithArg(ctx context.Context, collName string, filter bson.M,
+	strength int,
+) ([]map[string]interface{}, error) {
+	// Strength 2: Case insensitive, 3: Case sensitive (default)
+	return m.find(ctx, collName, filter, options.Find().SetCollation(&options.Collation{
+		Locale:   "en_US",
+		Strength: strength,
+	}))
+}
+
+// PutDataToDB sets the fields of putData in the document matching filter, or inserts putData when there is
+// none, and tells whether the document existed. The version of the document is incremented.
+func (m MongoDbConnector) PutDataToDB(ctx context.Context, collName string, filter bson.M,
+	putData map[string]interface{},
+) (existed bool, err error) {
+	current, err := m.findOne(ctx, collName, filter, nil)
+	if err != nil {
+		return false, err
+	}
+
+	collection := mongoapi.Client.Database(m.Name).Collection(collName)
+	if current != nil {
+		_, err = collection.UpdateOne(ctx, filter, versionedUpdate(putData))
+		udr_metrics.IncrMongoDbOpCounter("update_one", collName, err)
+		if err != nil {
+			return true, fmt.Errorf("RestfulAPIPutOne UpdateOne err: %w", err)
+		}
+		return true, nil
+	}
+	_, err = collection.InsertOne(ctx, versionedData(putData, 1))
+	udr_metrics.IncrMongoDbOpCounter("insert_one", collName, err)
+	if err != nil {
+		return false, fmt.Errorf("RestfulAPIPutOne InsertOne err: %w", err)
+	}
+	return false, nil
+}
+
+// ReplaceDataToDB replaces the document matching filter with data, or inserts data when there is none,
+// and tells whether the document existed. The version of the document is incremented by the same update.
+func (m MongoDbConnector) ReplaceDataToDB(ctx context.Context, collName string, filter bson.M,
+	data map[string]interface{},
+) (existed bool, err error) {
+	result, err := mongoapi.Client.Database(m.Name).Collection(collName).UpdateOne(ctx, filter,
+		versionedReplacement(data), options.Update().SetUpsert(true))
+	udr_metrics.IncrMongoDbOpCounter("replace_one", collName, err)
+	if err != nil {
+		return false, fmt.Errorf("ReplaceDataToDB ReplaceOne err: %w", err)
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// MergePatchDataToDB sets the fields of the merge of patchData into the document matching filter
+func (m MongoDbConnector) MergePatchDataToDB(ctx context.Context, collName string, filter bson.M,
+	patchData map[string]interface{},
+) error {
+	mergePatch, err := json.Marshal(patchData)
+	if err != nil {
+		return fmt.Errorf("RestfulAPIMergePatch Marshal err: %+v", err)
+	}
+	_, _, err = m.MergePatchDataToDBAndNotify(ctx, collName, "", mergePatch, filter)
+	if err != nil {
+		return fmt.Errorf("RestfulAPIMergePatch err: %w", err)
+	}
+	return nil
+}
+
+// PatchDataFieldToDB applies the JSON Patch to the field dataName of the document matching filter
+func (m MongoDbConnector) PatchDataFieldToDB(ctx context.Context, collName string, filter bson.M,
+	dataName string, patchJSON []byte,
+) error {
+	patch, err := jsonpatch.DecodePatch(patchJSON)
+	if err != nil {
+		return fmt.Errorf("RestfulAPIJSONPatchExtend DecodePatch err: %+v", err)
+	}
+
+	_, _, err = m.modifyData(ctx, collName, filter, "json_patch", func(original []byte) ([]byte, error) {
+		var document map[string]json.RawMessage
+		if err := json.Unmarshal(original, &document); err != nil {
+			return nil, err
+		}
+		field, ok := document[dataName]
+		if !ok {
+			field = json.RawMessage("null")
+		}
+		// The fields of the patched data are set in the document, as mongoapi.RestfulAPIJSONPatchExtend does
+		return patch.Apply(field)
+	})
+	if err != nil {
+		return fmt.Errorf("RestfulAPIJSONPatchExtend err: %w", err)
+	}
+	return nil
+}
+
+// Ping checks that MongoDB answers within ctx
+func (m MongoDbConnector) Ping(ctx context.Context) error {
+	if mongoapi.Client == nil {
+		return errors.New("MongoDB is not connected")
+	}
+	return mongoapi.Client.Ping(ctx, readpref.Primary())
+}
+
+// SessionsInProgress returns the number of MongoDB sessions currently checked out by the client
+func (m MongoDbConnector) SessionsInProgress() int {
+	if mongoapi.Client == nil {
+		return 0
+	}
+	return mongoapi.Client.NumberSessionsInProgress()
+}
+
+func (m MongoDbConnector) Disconnect(ctx context.Context) error {
+	if mongoapi.Client == nil {
+		return nil
+	}
+	if err := mongoapi.Client.Disconnect(ctx); err != nil {
+		return err
+	}
+	mongoapi.Client = nil
+	return nil
+}
+
+// findOne is mongoapi.RestfulAPIGetOne bound to ctx, so that the query is abandoned with the request
+func (m MongoDbConnector) findOne(ctx context.Context, collName string, filter bson.M,
+	collation *options.Collation,
+) (map[string]interface{}, error) {
+	opts := options.FindOne()
+	if collation != nil {
+		opts.SetCollation(collation)
+	}
+
+	var result map[string]interface{}
+	err := mongoapi.Client.Database(m.Name).Collection(collName).FindOne(ctx, filter, opts).Decode(&result)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		udr_metrics.IncrMongoDbOpCounter("find_one", collName, nil)
+	} else {
+		udr_metrics.IncrMongoDbOpCounter("find_one", collName, err)
+	}
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("RestfulAPIGetOne err: %w", err)
+	}
+
+	// Delete "_id" entry which is auto-inserted by MongoDB
+	delete(result, "_id")
+	return result, nil
+}
+
+// find is mongoapi.RestfulAPIGetMany bound to ctx
+func (m MongoDbConnector) find(ctx context.Context, collName string, filter bson.M,
+	opts *options.FindOptions,
+) ([]map[string]interface{}, error) {
+	cursor, err := mongoapi.Client.Database(m.Name).Collection(collName).Find(ctx, filter, opts)
+	udr_metrics.IncrMongoDbOpCounter("find", collName, err)
+	if err != nil {
+		return nil, fmt.Errorf("RestfulAPIGetMany err: %w", err)
+	}
+
+	var results []map[string]interface{}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("RestfulAPIGetMany err: %w", err)
+	}
+	for _, result := range results {
+		// Delete "_id" entry which is auto-inserted by MongoDB
+		delete(result, "_id")
+		delete(result, util.DocumentVersionKey)
+	}
+	return results, nil
+}
+
+// InsertDataToDB adds data as a new document of the collection
+func (m MongoDbConnector) InsertDataToDB(ctx context.Context, collName string, data map[string]interface{}) error {
+	_, err := mongoapi.Client.Database(m.Name).Collection(collName).InsertOne(ctx, versionedData(data, 1))
+	udr_metrics.IncrMongoDbOpCounter("insert_one", collName, err)
+	if err != nil {
+		return fmt.Errorf("InsertDataToDB err: %w", err)
+	}
+	return nil
+}
+
+// Upsert replaces the document matching Filter by Data, Data holds the fields of Filter as well
+type Upsert struct {
+	Filter bson.M
+	Data   map[string]interface{}
+}
+
+// BulkUpsertDataToDB writes the upserts in a single unordered bulk write and returns the error of each of them
+func (m MongoDbConnector) BulkUpsertDataToDB(ctx context.Context, collName string, upserts []Upsert) []error {
+	errs := make([]error, len(upserts))
+	if len(upserts) == 0 {
+		return errs
+	}
+
+	writeModels := make([]mongo.WriteModel, 0, len(upserts))
+	for _, upsert := range upserts {
+		writeModels = append(writeModels,
+			mongo.NewUpdateOneModel().SetFilter(upsert.Filter).SetUpdate(versionedReplacement(upsert.Data)).SetUpsert(true))
+	}
+	_, err := mongoapi.Client.Database(m.Name).Collection(collName).
+		BulkWrite(ctx, writeModels, options.BulkWrite().SetOrdered(false))
+	udr_metrics.IncrMongoDbOpCounter("bulk_write", collName, err)
+	if err == nil {
+		return errs
+	}
+
+	var bulkErr mongo.BulkWriteException
+	if errors.As(err, &bulkErr) && bulkErr.WriteConcernError == nil {
+		for _, writeErr := range bulkErr.WriteErrors {
+			errs[writeErr.Index] = fmt.Errorf("BulkUpsertDataToDB err: %s", writeErr.Message)
+		}
+		return errs
+	}
+	for i := range errs {
+		errs[i] = fmt.Errorf("BulkUpsertDataToDB err: %+v", err)
+	}
+	return errs
+}
+
+// illegalOperationCode is the error code of the transactions on a standalone server, which only replica sets
+// and sharded clusters support
+const illegalOperationCode = 20
+
+// namespaceNotFoundCode and indexNotFoundCode are the error codes of dropping an index of a missing collection,
+// and a missing index
+const (
+	namespaceNotFoundCode = 26
+	indexNotFoundCode     = 27
+)
+
+const (
+	transactionsUndetected int32 = iota
+	transactionsSupported
+	transactionsUnsupported
+)
+
+// transactions tells whether the deployment supports the transactions, as detected at startup or learnt from
+// the first transaction refused. Until then a transaction is attempted.
+var transactions atomic.Int32
+
+// DetectTransactions asks the deployment whether it is a replica set or a sharded cluster, and so whether it
+// supports the transactions
+func (m MongoDbConnector) DetectTransactions(ctx context.Context) (bool, error) {
+	var hello bson.M
+	err := mongoapi.Client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+	if err != nil {
+		return false, fmt.Errorf("DetectTransactions err: %w", err)
+	}
+	_, replicaSet := hello["setName"]
+	supported := replicaSet || hello["msg"] == "isdbgrid"
+	if supported {
+		transactions.Store(transactionsSupported)
+	} else {
+		transactions.Store(transactionsUnsupported)
+	}
+	return supported, nil
+}
+
+// WithTransaction runs fn within a transaction when the deployment supports them, and otherwise runs it as is,
+// its writes applying one by one. It returns whether fn ran within a transaction. fn shall do its operations
+// with the context it is given and stop on the first failure, and it is run again on a transient error of the
+// transaction.
+func (m MongoDbConnector) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) (bool, error) {
+	if transactions.Load() == transactionsUnsupported {
+		return false, fn(ctx)
+	}
+
+	session, err := mongoapi.Client.StartSession()
+	if err != nil {
+		return false, fmt.Errorf("WithTransaction StartSession err: %w", err)
+	}
+	defer session.EndSession(ctx)
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessCtx)
+	})
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Code == illegalOperationCode {
+		// The first operation is refused, nothing is written yet
+		if transactions.Swap(transactionsUnsupported) != transactionsUnsupported {
+			logger.DbLog.Warnf("MongoDB does not support transactions, the multi-document writes are not atomic")
+		}
+		return false, fn(ctx)
+	}
+	if err == nil {
+		transactions.CompareAndSwap(transactionsUndetected, transactionsSupported)
+	}
+	return true, err
+}
+
+// DeleteManyDataFromDB removes the documents matching filter from every collection of collNames, within a single
+// transaction when the deployment supports them and one by one otherwise. It returns the count of the documents
+// removed by collection and whether they were removed within a transaction. When a transaction fails, nothing
+// is removed. Otherwise, on a failure the counts hold the collections removed from before it.
+func (m MongoDbConnector) DeleteManyDataFromDB(ctx context.Context, collNames []string, filter bson.M) (
+	deleted map[string]int64, transactional bool, err error,
+) {
+	database := mongoapi.Client.Database(m.Name)
+	transactional, err = m.WithTransaction(ctx, func(ctx context.Context) error {
+		deleted = make(map[string]int64, len(collNames))
+		for _, collName := range collNames {
+			result, err := database.Collection(collName).DeleteMany(ctx, filter)
+			udr_metrics.IncrMongoDbOpCounter("delete_many", collName, err)
+			if err != nil {
+				return fmt.Errorf("DeleteManyDataFromDB %s err: %w", collName, err)
+			}
+			deleted[collName] = result.DeletedCount
+		}
+		return nil
+	})
+	if err != nil && transactional {
+		return nil, true, err
+	}
+	return deleted, transactional, err
+}
+
+// Index is an index of a collection, on the fields of Keys in ascending order
+type Index struct {
+	Collection string
+	Keys       []string
+	Unique     bool
+	// ExpireAfter makes it a TTL index, its single key being a date, when positive
+	ExpireAfter time.Duration
+	// CaseInsensitive gives the index the collation of the queries by GetManyDataFromDBWithArg of
+	// COLLATION_STRENGTH_IGNORE_CASE, which cannot use the indexes of the default collation
+	CaseInsensitive bool
+}
+
+// EnsureIndexes creates the indexes missing from their collection, the existing ones are left as they are.
+// A collection failing, e.g. on an index of the same name created by the operator with other options,
+// does not prevent the indexes of the other collections from being created.
+func (m MongoDbConnector) EnsureIndexes(ctx context.Context, indexes []Index) error {
+	byCollection := make(map[string][]mongo.IndexModel)
+	var collections []string
+	for _, index := range indexes {
+		keys := make(bson.D, 0, len(index.Keys))
+		for _, key := range index.Keys {
+			keys = append(keys, bson.E{Key: key, Value: 1})
+		}
+		if _, ok := byCollection[index.Collection]; !ok {
+			collections = append(collections, index.Collection)
+		}
+		indexOptions := options.Index().SetUnique(index.Unique)
+		if index.ExpireAfter > 0 {
+			indexOptions.SetExpireAfterSeconds(int32(index.ExpireAfter / time.Second))
+		}
+		if index.CaseInsensitive {
+			indexOptions.SetCollation(&options.Collation{
+				Locale:   "en_US",
+				Strength: mongoapi.COLLATION_STRENGTH_IGNORE_CASE,
+			})
+		}
+		byCollection[index.Collection] = append(byCollection[index.Collection],
+			mongo.IndexModel{Keys: keys, Options: indexOptions})
+	}
+
+	var errs []error
+	for _, collName := range collections {
+		names, err := mongoapi.Client.Database(m.Name).Collection(collName).Indexes().
+			CreateMany(ctx, byCollection[collName])
+		udr_metrics.IncrMongoDbOpCounter("create_indexes", collName, err)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("EnsureIndexes of %s err: %w", collName, err))
+			continue
+		}
+		logger.DataRepoLog.Debugf("EnsureIndexes of %s: %v", collName, names)
+	}
+	return errors.Join(errs...)
+}
+
+// DropIndexes removes the indexes from their collection, by the name MongoDB gives to their keys. The ones
+// missing, or whose collection is, are skipped.
+func (m MongoDbConnector) DropIndexes(ctx context.Context, indexes []Index) error {
+	var errs []error
+	for _, index := range indexes {
+		keys := make([]string, 0, len(index.Keys))
+		for _, key := range index.Keys {
+			keys = append(keys, key+"_1")
+		}
+		name := strings.Join(keys, "_")
+		_, err := mongoapi.Client.Database(m.Name).Collection(index.Collection).Indexes().DropOne(ctx, name)
+		var commandErr mongo.CommandError
+		if errors.As(err, &commandErr) && (commandErr.Code == indexNotFoundCode || commandErr.Code == namespaceNotFoundCode) {
+			err = nil
+		}
+		udr_metrics.IncrMongoDbOpCounter("drop_index", index.Collection, err)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("DropIndexes %s of %s err: %w", name, index.Collection, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// GetPageFromDB returns at most limit documents matching filter in ascending sortKeys order,
+// skipping the first skip ones, so that a listing is never loaded at once. The sortKeys shall tell the
+// documents apart for the pages not to repeat nor skip any.
+func (m MongoDbConnector) GetPageFromDB(ctx context.Context, collName string, filter bson.M, sortKeys []string,
+	skip, limit int64,
+) ([]map[string]interface{}, *models.ProblemDetails) {
+	page, err := m.Query(ctx, collName, filter, Query{SortKeys: sortKeys, Skip: skip, Limit: limit})
+	if err != nil {
+		return nil, openapi.ProblemDetailsSystemFailure(fmt.Sprintf("GetPageFromDB err: %+v", err))
+	}
+	return page, nil
+}
+
+// storedETag is the ETag of data once stored, as it is read back from MongoDB
+func storedETag(data map[string]interface{}) (string, error) {
+	encoded, err := bson.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("storedETag Marshal err: %w", err)
+	}
+	var stored map[string]interface{}
+	if err = bson.Unmarshal(encoded, &stored); err != nil {
+		return "", fmt.Errorf("storedETag Unmarshal err: %w", err)
+	}
+	return util.DocumentETag(stored), nil
+}
+
+// versionedData is a copy of data at the given version
+func versionedData(data map[string]interface{}, version int64) bson.M {
+	versioned := make(bson.M, len(data)+1)
+	for key, value := range data {
+		versioned[key] = value
+	}
+	versioned[util.DocumentVersionKey] = version
+	return versioned
+}
+
+// versionedUpdate sets the fields of data and increments the version of the document, atomically as a single
+// update. The documents written before the versions existed start at version 1.
+func versionedUpdate(data map[string]interface{}) bson.M {
+	return bson.M{"$set": data, "$inc": bson.M{util.DocumentVersionKey: 1}}
+}
+
+// versionedReplacement is the update pipeline replacing the document with data at its next version, a single
+// update as ReplaceOne is. The _id is kept, data is taken literally so that its strings starting with $ are not
+// field paths.
+func versionedReplacement(data map[string]interface{}) mongo.Pipeline {
+	version := bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$" + util.DocumentVersionKey, 0}}, 1}}
+	return mongo.Pipeline{{{Key: "$replaceWith", Value: bson.M{"$mergeObjects": bson.A{
+		bson.M{"_id": "$_id"},
+		bson.M{"$literal": data},
+		bson.M{util.DocumentVersionKey: version},
+	}}}}}
+}
+
+// versionFilter narrows filter down to the document still at version
+func versionFilter(filter bson.M, version int64) bson.M {
+	versioned := make(bson.M, len(filter)+1)
+	for key, value := range filter {
+		versioned[key] = value
+	}
+	if version == 0 {
+		versioned[util.DocumentVersionKey] = bson.M{"$exists": false}
+	} else {
+		versioned[util.DocumentVersionKey] = version
+	}
+	return versioned
+}
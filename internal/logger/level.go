@@ -0,0 +1,90 @@
+package logger
+
+import (
+	"io"
+	"maps"
+	"os"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	logger_util "github.com/free5gc/util/logger"
+)
+
+const (
+	sbiCategory = "SBI"
+	ginCategory = "GIN"
+)
+
+// LevelCategories are the log categories whose level can differ from the level of Log
+var LevelCategories = []string{sbiCategory, ginCategory}
+
+var (
+	categoryMu sync.Mutex
+	// categoryLoggers log the entries of LevelCategories, with the output, the hooks and the formatter of Log
+	categoryLoggers = map[string]*logrus.Logger{}
+	// categoryLevels are the levels set apart, the level of Log applies to the other categories
+	categoryLevels = map[string]logrus.Level{}
+)
+
+func newCategoryEntry(category string) *logrus.Entry {
+	categoryLogger := &logrus.Logger{
+		Out:          Log.Out,
+		Hooks:        Log.Hooks,
+		Formatter:    Log.Formatter,
+		ReportCaller: Log.ReportCaller,
+		Level:        Log.GetLevel(),
+		ExitFunc:     os.Exit,
+	}
+	categoryLoggers[category] = categoryLogger
+	return logrus.NewEntry(categoryLogger).WithFields(NfLog.Data).WithField(logger_util.FieldCategory, category)
+}
+
+// SetOutput sets the output of Log and of the category loggers
+func SetOutput(out io.Writer) {
+	categoryMu.Lock()
+	defer categoryMu.Unlock()
+
+	Log.SetOutput(out)
+	for _, categoryLogger := range categoryLoggers {
+		categoryLogger.SetOutput(out)
+	}
+}
+
+func SetReportCaller(reportCaller bool) {
+	categoryMu.Lock()
+	defer categoryMu.Unlock()
+
+	Log.SetReportCaller(reportCaller)
+	for _, categoryLogger := range categoryLoggers {
+		categoryLogger.SetReportCaller(reportCaller)
+	}
+}
+
+// SetLevel sets the level of Log, and of the categories without a level of their own
+func SetLevel(level logrus.Level) {
+	categoryMu.Lock()
+	defer categoryMu.Unlock()
+
+	Log.SetLevel(level)
+	for category, categoryLogger := range categoryLoggers {
+		if _, ok := categoryLevels[category]; !ok {
+			categoryLogger.SetLevel(level)
+		}
+	}
+}
+
+// SetCategoryLevels sets the levels of the categories, the ones absent from levels take the level of Log again
+func SetCategoryLevels(levels map[string]logrus.Level) {
+	categoryMu.Lock()
+	defer categoryMu.Unlock()
+
+	categoryLevels = maps.Clone(levels)
+	for category, categoryLogger := range categoryLoggers {
+		level, ok := categoryLevels[category]
+		if !ok {
+			level = Log.GetLevel()
+		}
+		categoryLogger.SetLevel(level)
+	}
+}
@@ -0,0 +1,29 @@
+package logger
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetCategoryLevels(t *testing.T) {
+	defer SetCategoryLevels(nil)
+	defer SetLevel(Log.GetLevel())
+
+	SetLevel(logrus.InfoLevel)
+	SetCategoryLevels(map[string]logrus.Level{sbiCategory: logrus.DebugLevel})
+	require.True(t, SBILog.Logger.IsLevelEnabled(logrus.DebugLevel))
+	require.False(t, GinLog.Logger.IsLevelEnabled(logrus.DebugLevel))
+	require.False(t, MainLog.Logger.IsLevelEnabled(logrus.DebugLevel))
+
+	// The categories without a level of their own follow the level of Log
+	SetLevel(logrus.WarnLevel)
+	require.True(t, SBILog.Logger.IsLevelEnabled(logrus.DebugLevel))
+	require.False(t, GinLog.Logger.IsLevelEnabled(logrus.InfoLevel))
+
+	SetCategoryLevels(nil)
+	require.False(t, SBILog.Logger.IsLevelEnabled(logrus.InfoLevel))
+	require.Equal(t, "SBI", SBILog.Data["CAT"])
+	require.Equal(t, "UDR", SBILog.Data["NF"])
+}
@@ -1,6 +1,9 @@
 package logger
 
 import (
+	"sync/atomic"
+	"time"
+
 	"github.com/sirupsen/logrus"
 
 	logger_util "github.com/free5gc/util/logger"
@@ -21,8 +24,33 @@ var (
 	ProcLog     *logrus.Entry
 	SBILog      *logrus.Entry
 	DbLog       *logrus.Entry
+	AccessLog   *logrus.Entry
+	AuditLog    *logrus.Entry
 )
 
+const accessLogCategory = "Access"
+
+// accessLogJSON switches the access log entries to JSON, the other categories keep the NF format
+var accessLogJSON atomic.Bool
+
+// categoryFormatter formats the access log entries as JSON when asked to, so that log shippers can parse them
+type categoryFormatter struct {
+	logrus.Formatter
+	json logrus.Formatter
+}
+
+func (f *categoryFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if accessLogJSON.Load() && entry.Data[logger_util.FieldCategory] == accessLogCategory {
+		return f.json.Format(entry)
+	}
+	return f.Formatter.Format(entry)
+}
+
+// SetAccessLogJSON writes the access log as one JSON object per line instead of the NF text format
+func SetAccessLogJSON(enable bool) {
+	accessLogJSON.Store(enable)
+}
+
 func init() {
 	fieldsOrder := []string{
 		logger_util.FieldNF,
@@ -30,17 +58,23 @@ func init() {
 	}
 
 	Log = logger_util.New(fieldsOrder)
+	Log.Formatter = &categoryFormatter{
+		Formatter: Log.Formatter,
+		json:      &logrus.JSONFormatter{TimestampFormat: time.RFC3339Nano},
+	}
 	NfLog = Log.WithField(logger_util.FieldNF, "UDR")
 	MainLog = NfLog.WithField(logger_util.FieldCategory, "Main")
 	InitLog = NfLog.WithField(logger_util.FieldCategory, "Init")
 	CfgLog = NfLog.WithField(logger_util.FieldCategory, "CFG")
 	CtxLog = NfLog.WithField(logger_util.FieldCategory, "CTX")
-	GinLog = NfLog.WithField(logger_util.FieldCategory, "GIN")
+	GinLog = newCategoryEntry(ginCategory)
 	ConsumerLog = NfLog.WithField(logger_util.FieldCategory, "Consumer")
 	DataRepoLog = NfLog.WithField(logger_util.FieldCategory, "DataRepo")
 	ProcLog = NfLog.WithField(logger_util.FieldCategory, "Proc")
 	HttpLog = NfLog.WithField(logger_util.FieldCategory, "HTTP")
 	UtilLog = NfLog.WithField(logger_util.FieldCategory, "Util")
-	SBILog = NfLog.WithField(logger_util.FieldCategory, "SBI")
+	SBILog = newCategoryEntry(sbiCategory)
 	DbLog = NfLog.WithField(logger_util.FieldCategory, "DB")
+	AccessLog = NfLog.WithField(logger_util.FieldCategory, accessLogCategory)
+	AuditLog = NfLog.WithField(logger_util.FieldCategory, "Audit")
 }
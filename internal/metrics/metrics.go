@@ -0,0 +1,169 @@
+// Package metrics holds the UDR specific Prometheus metrics, served by the metrics server next to the
+// ones of free5gc/util.
+package metrics
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// GetUdrMetrics creates the UDR metrics, they are recorded once the collectors are registered
+// and EnableUdrMetrics is called
+func GetUdrMetrics(namespace string) []prometheus.Collector {
+	var metrics []prometheus.Collector
+
+	RouteReqCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: SUBSYSTEM_NAME,
+			Name:      ROUTE_REQ_COUNTER_NAME,
+			Help:      ROUTE_REQ_COUNTER_DESC,
+		},
+		[]string{METHOD_LABEL, ROUTE_LABEL, STATUS_CLASS_LABEL},
+	)
+	metrics = append(metrics, RouteReqCounter)
+
+	RouteRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: SUBSYSTEM_NAME,
+			Name:      ROUTE_REQ_HIST_NAME,
+			Help:      ROUTE_REQ_HIST_DESC,
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{METHOD_LABEL, ROUTE_LABEL, STATUS_CLASS_LABEL},
+	)
+	metrics = append(metrics, RouteRequestDuration)
+
+	MongoDbOpCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: SUBSYSTEM_NAME,
+			Name:      MONGODB_OP_COUNTER_NAME,
+			Help:      MONGODB_OP_COUNTER_DESC,
+		},
+		[]string{OPERATION_LABEL, COLLECTION_LABEL, RESULT_LABEL},
+	)
+	metrics = append(metrics, MongoDbOpCounter)
+
+	PanicCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: SUBSYSTEM_NAME,
+			Name:      PANIC_COUNTER_NAME,
+			Help:      PANIC_COUNTER_DESC,
+		},
+		[]string{SOURCE_LABEL},
+	)
+	metrics = append(metrics, PanicCounter)
+
+	AccessTokenCacheCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: SUBSYSTEM_NAME,
+			Name:      ACCESS_TOKEN_CACHE_COUNTER_NAME,
+			Help:      ACCESS_TOKEN_CACHE_COUNTER_DESC,
+		},
+		[]string{RESULT_LABEL},
+	)
+	metrics = append(metrics, AccessTokenCacheCounter)
+
+	AuthSubsCacheCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: SUBSYSTEM_NAME,
+			Name:      AUTH_SUBS_CACHE_COUNTER_NAME,
+			Help:      AUTH_SUBS_CACHE_COUNTER_DESC,
+		},
+		[]string{RESULT_LABEL},
+	)
+	metrics = append(metrics, AuthSubsCacheCounter)
+
+	AuditDroppedCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: SUBSYSTEM_NAME,
+			Name:      AUDIT_DROPPED_COUNTER_NAME,
+			Help:      AUDIT_DROPPED_COUNTER_DESC,
+		},
+	)
+	metrics = append(metrics, AuditDroppedCounter)
+
+	return metrics
+}
+
+func ObserveRouteRequest(method string, route string, statusCode int, duration float64) {
+	if !IsUdrMetricsEnabled() {
+		return
+	}
+	labels := prometheus.Labels{
+		METHOD_LABEL:       method,
+		ROUTE_LABEL:        route,
+		STATUS_CLASS_LABEL: statusClass(statusCode),
+	}
+	RouteReqCounter.With(labels).Inc()
+	RouteRequestDuration.With(labels).Observe(duration)
+}
+
+// IncrMongoDbOpCounter counts an operation of the data layer, a missing document is not an error
+func IncrMongoDbOpCounter(operation string, collection string, err error) {
+	if !IsUdrMetricsEnabled() {
+		return
+	}
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	MongoDbOpCounter.With(prometheus.Labels{
+		OPERATION_LABEL:  operation,
+		COLLECTION_LABEL: collection,
+		RESULT_LABEL:     result,
+	}).Inc()
+}
+
+func IncrPanicCounter(source string) {
+	if !IsUdrMetricsEnabled() {
+		return
+	}
+	PanicCounter.With(prometheus.Labels{SOURCE_LABEL: source}).Inc()
+}
+
+// IncrAccessTokenCacheCounter counts a lookup of the access token cache, a miss requests a token to the NRF
+func IncrAccessTokenCacheCounter(hit bool) {
+	if !IsUdrMetricsEnabled() {
+		return
+	}
+	result := ACCESS_TOKEN_CACHE_MISS
+	if hit {
+		result = ACCESS_TOKEN_CACHE_HIT
+	}
+	AccessTokenCacheCounter.With(prometheus.Labels{RESULT_LABEL: result}).Inc()
+}
+
+// IncrAuthSubsCacheCounter counts a lookup of the authentication subscription cache
+func IncrAuthSubsCacheCounter(hit bool) {
+	if !IsUdrMetricsEnabled() {
+		return
+	}
+	result := AUTH_SUBS_CACHE_MISS
+	if hit {
+		result = AUTH_SUBS_CACHE_HIT
+	}
+	AuthSubsCacheCounter.With(prometheus.Labels{RESULT_LABEL: result}).Inc()
+}
+
+func IncrAuditDroppedCounter() {
+	if !IsUdrMetricsEnabled() {
+		return
+	}
+	AuditDroppedCounter.Inc()
+}
+
+// statusClass keeps the cardinality of the status label down to 1xx..5xx
+func statusClass(statusCode int) string {
+	if statusCode < 100 || statusCode > 599 {
+		return "unknown"
+	}
+	return strconv.Itoa(statusCode/100) + "xx"
+}
@@ -0,0 +1,24 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RouteMetrics records the requests labeled by the gin route template, e.g.
+// /nudr-dr/v2/subscription-data/:ueId/:servingPlmnId/provisioned-data/am-data, rather than the raw path
+func RouteMetrics(c *gin.Context) {
+	if !IsUdrMetricsEnabled() {
+		return
+	}
+
+	start := time.Now()
+	c.Next()
+
+	route := c.FullPath()
+	if route == "" {
+		route = UNMATCHED_ROUTE
+	}
+	ObserveRouteRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start).Seconds())
+}
@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(GetUdrMetrics("free5gc")...)
+	EnableUdrMetrics()
+
+	router := gin.New()
+	router.Use(RouteMetrics)
+	router.GET("/subscription-data/:ueId/provisioned-data/am-data", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	for _, path := range []string{
+		"/subscription-data/imsi-208930000000001/provisioned-data/am-data",
+		"/subscription-data/imsi-208930000000002/provisioned-data/am-data",
+		"/subscription-data/imsi-208930000000003/unknown",
+	} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	require.Equal(t, float64(2), testutil.ToFloat64(RouteReqCounter.WithLabelValues(
+		http.MethodGet, "/subscription-data/:ueId/provisioned-data/am-data", "2xx")))
+	require.Equal(t, float64(1), testutil.ToFloat64(RouteReqCounter.WithLabelValues(
+		http.MethodGet, UNMATCHED_ROUTE, "4xx")))
+	require.Equal(t, 2, testutil.CollectAndCount(RouteReqCounter))
+	require.Equal(t, 2, testutil.CollectAndCount(RouteRequestDuration))
+}
@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	SUBSYSTEM_NAME = "udr"
+
+	ROUTE_REQ_COUNTER_NAME = "route_request_total"
+	ROUTE_REQ_COUNTER_DESC = "Total number of SBI requests handled by the UDR, per route template"
+
+	ROUTE_REQ_HIST_NAME = "route_request_duration_seconds"
+	ROUTE_REQ_HIST_DESC = "Histogram of the SBI request latencies, per route template"
+
+	MONGODB_OP_COUNTER_NAME = "mongodb_operation_total"
+	MONGODB_OP_COUNTER_DESC = "Total number of MongoDB operations issued by the data layer"
+
+	PANIC_COUNTER_NAME = "panic_total"
+	PANIC_COUNTER_DESC = "Total number of panics recovered, per source"
+
+	ACCESS_TOKEN_CACHE_COUNTER_NAME = "access_token_cache_total"
+	ACCESS_TOKEN_CACHE_COUNTER_DESC = "Total number of lookups of the NRF access token cache, per hit or miss"
+
+	AUTH_SUBS_CACHE_COUNTER_NAME = "auth_subs_cache_total"
+	AUTH_SUBS_CACHE_COUNTER_DESC = "Total number of lookups of the authentication subscription cache, per hit or miss"
+
+	AUDIT_DROPPED_COUNTER_NAME = "audit_dropped_records_total"
+	AUDIT_DROPPED_COUNTER_DESC = "Total number of audit records dropped, the audit queue being full"
+)
+
+// Labels names of the UDR metrics
+const (
+	METHOD_LABEL       = "method"
+	ROUTE_LABEL        = "route"
+	STATUS_CLASS_LABEL = "status_class"
+	OPERATION_LABEL    = "operation"
+	COLLECTION_LABEL   = "collection"
+	RESULT_LABEL       = "result"
+	SOURCE_LABEL       = "source"
+)
+
+// Results of the access token cache lookups
+const (
+	ACCESS_TOKEN_CACHE_HIT  = "hit"
+	ACCESS_TOKEN_CACHE_MISS = "miss"
+)
+
+// Results of the authentication subscription cache lookups, a miss reads MongoDB
+const (
+	AUTH_SUBS_CACHE_HIT  = "hit"
+	AUTH_SUBS_CACHE_MISS = "miss"
+)
+
+// Sources of the recovered panics
+const (
+	PANIC_SOURCE_SBI_HANDLER  = "sbi_handler"
+	PANIC_SOURCE_NOTIFICATION = "notification"
+)
+
+// UNMATCHED_ROUTE labels the requests that match no route, so that their raw path is never used as a label
+const UNMATCHED_ROUTE = "unmatched"
+
+var (
+	RouteReqCounter         *prometheus.CounterVec
+	RouteRequestDuration    *prometheus.HistogramVec
+	MongoDbOpCounter        *prometheus.CounterVec
+	PanicCounter            *prometheus.CounterVec
+	AccessTokenCacheCounter *prometheus.CounterVec
+	AuthSubsCacheCounter    *prometheus.CounterVec
+	AuditDroppedCounter     prometheus.Counter
+)
+
+var udrMetricsEnabled atomic.Bool
+
+func IsUdrMetricsEnabled() bool {
+	return udrMetricsEnabled.Load()
+}
+
+func EnableUdrMetrics() {
+	udrMetricsEnabled.Store(true)
+}
@@ -0,0 +1,115 @@
+package sbi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/udr/pkg/factory"
+	"github.com/free5gc/util/metrics/sbi"
+)
+
+// NfTypeResolver returns the NF type of the consumer NF instance, e.g. from its NF profile in the NRF
+type NfTypeResolver func(ctx context.Context, nfInstanceId string) (models.NrfNfManagementNfType, error)
+
+// WithNfTypeResolver sets how checkConsumerNfType finds the NF type of the consumers
+func WithNfTypeResolver(resolver NfTypeResolver) ServerOption {
+	return func(o *serverOptions) {
+		o.nfTypeResolver = resolver
+	}
+}
+
+// authenticationDataRoutes are the routes outside the authentication resources that write or remove the
+// authentication data, by method and route. They are of the authentication-data resource group as well.
+var authenticationDataRoutes = map[string][]string{
+	http.MethodPost:   {UdrBulkProvisioningPath},
+	http.MethodDelete: {"/subscription-data/:ueId"},
+}
+
+// resourceGroup returns the resource group of allowedNfTypes the data repository route of method belongs to,
+// empty for the routes outside a data set
+func resourceGroup(method string, route string) string {
+	route = strings.TrimPrefix(route, factory.UdrDrResUriPrefix)
+	if slices.Contains(authenticationDataRoutes[method], route) {
+		return factory.UdrResourceGroupAuthenticationData
+	}
+	dataSet, resource, _ := strings.Cut(strings.TrimPrefix(route, "/"), "/")
+	if dataSet == factory.UdrResourceGroupSubscriptionData &&
+		(strings.HasSuffix(resource, "/authentication-subscription") ||
+			strings.Contains(resource, "/authentication-subscription/") ||
+			strings.HasSuffix(resource, "/authentication-status") ||
+			strings.Contains(resource, "/authentication-status/")) {
+		return factory.UdrResourceGroupAuthenticationData
+	}
+	for _, dataSetScope := range dataSetScopes {
+		if dataSetScope.dataSet == dataSet {
+			return dataSet
+		}
+	}
+	return ""
+}
+
+// checkConsumerNfType answers 403 to the consumers whose NF type is not allowed on the resource group of
+// the route. The consumer is the subject of its verified access token, a consumer without one is refused.
+func (s *Server) checkConsumerNfType(c *gin.Context) {
+	group := resourceGroup(c.Request.Method, c.FullPath())
+	allowedNfTypes, ok := s.Config().GetAllowedNfTypes(group)
+	if !ok || s.isAuthorizationSkipped(c) {
+		return
+	}
+
+	if err := s.verifyConsumerNfType(c, allowedNfTypes); err != nil {
+		logger.SBILog.Warnf("Reject %s %s: %+v", c.Request.Method, c.Request.URL.Path, err)
+		problemDetails := &models.ProblemDetails{
+			Title:  "Forbidden",
+			Status: http.StatusForbidden,
+			Detail: fmt.Sprintf("%s is restricted to %s: %s", group, strings.Join(allowedNfTypes, ", "), err),
+			Cause:  "ACCESS_DENIED",
+		}
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
+		c.AbortWithStatusJSON(http.StatusForbidden, problemDetails)
+	}
+}
+
+func (s *Server) verifyConsumerNfType(c *gin.Context, allowedNfTypes []string) error {
+	requester := c.GetString(util.RequesterNfInstanceIdCtxKey)
+	if requester == "" {
+		return fmt.Errorf("the consumer NF instance is not identified by a verified access token")
+	}
+	if s.resolveNfType == nil {
+		return fmt.Errorf("the NF type of consumer %s can not be looked up", requester)
+	}
+
+	nfType, err := s.resolveNfType(c, requester)
+	if err != nil {
+		return fmt.Errorf("look up the NF type of consumer %s: %w", requester, err)
+	}
+	for _, allowedNfType := range allowedNfTypes {
+		if string(nfType) == allowedNfType {
+			return nil
+		}
+	}
+	return fmt.Errorf("consumer %s is a %s", requester, nfType)
+}
+
+// requireScope runs handler once the access token grants scope, answering 403 otherwise. Unlike the scopes of
+// the data sets, a token granting no additional scope does not grant it.
+func (s *Server) requireScope(scope string, handler gin.HandlerFunc) gin.HandlerFunc {
+	check := util.NewRouterAuthorizationCheck(models.ServiceName_NUDR_DR).WithRequiredScope(scope, true)
+	return func(c *gin.Context) {
+		if !s.isAuthorizationSkipped(c) {
+			check.Check(c, s.Context())
+			if c.IsAborted() {
+				return
+			}
+		}
+		handler(c)
+	}
+}
@@ -0,0 +1,109 @@
+package sbi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/udr/pkg/factory"
+)
+
+func TestServer_CheckConsumerNfType(t *testing.T) {
+	nfTypes := map[string]models.NrfNfManagementNfType{
+		"udm-instance": models.NrfNfManagementNfType_UDM,
+		"pcf-instance": models.NrfNfManagementNfType_PCF,
+	}
+	s := newTestServer(t, WithNfTypeResolver(func(ctx context.Context, nfInstanceId string) (
+		models.NrfNfManagementNfType, error,
+	) {
+		if nfType, ok := nfTypes[nfInstanceId]; ok {
+			return nfType, nil
+		}
+		return "", errors.New("NF instance not found")
+	}))
+
+	const (
+		authSubsRoute    = "/subscription-data/:ueId/:servingPlmnId/authentication-subscription"
+		amDataRoute      = "/subscription-data/:ueId/:servingPlmnId/provisioned-data/am-data"
+		amPolicyRoute    = "/policy-data/ues/:ueId/am-data"
+		subscriberRoute  = "/subscription-data/:ueId"
+		authSubsPath     = "/subscription-data/imsi-208930000000001/20893/authentication-subscription"
+		amDataPath       = "/subscription-data/imsi-208930000000001/20893/provisioned-data/am-data"
+		amPolicyDataPath = "/policy-data/ues/imsi-208930000000001/am-data"
+		subscriberPath   = "/subscription-data/imsi-208930000000001"
+	)
+	router := gin.New()
+	group := router.Group(factory.UdrDrResUriPrefix)
+	group.Use(func(c *gin.Context) {
+		if requester := c.GetHeader("X-Requester"); requester != "" {
+			c.Set(util.RequesterNfInstanceIdCtxKey, requester)
+		}
+	}, s.checkConsumerNfType)
+	ok := func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	}
+	for _, route := range []string{authSubsRoute, amDataRoute, amPolicyRoute, subscriberRoute} {
+		group.GET(route, ok)
+	}
+	group.DELETE(subscriberRoute, ok)
+	group.POST(UdrBulkProvisioningPath, ok)
+
+	testCases := []struct {
+		name           string
+		method         string
+		allowedNfTypes map[string][]string
+		requester      string
+		path           string
+		expectedCode   int
+	}{
+		{"No Restriction", http.MethodGet, nil, "", authSubsPath, http.StatusOK},
+		{"Allowed NF Type", http.MethodGet, map[string][]string{"policy-data": {"PCF"}},
+			"pcf-instance", amPolicyDataPath, http.StatusOK},
+		{"Other NF Type", http.MethodGet, map[string][]string{"policy-data": {"PCF"}},
+			"udm-instance", amPolicyDataPath, http.StatusForbidden},
+		{"Unidentified Consumer", http.MethodGet, map[string][]string{"policy-data": {"PCF"}},
+			"", amPolicyDataPath, http.StatusForbidden},
+		{"Unknown Consumer", http.MethodGet, map[string][]string{"policy-data": {"PCF"}},
+			"nef-instance", amPolicyDataPath, http.StatusForbidden},
+		{"Other Resource Group", http.MethodGet, map[string][]string{"policy-data": {"PCF"}},
+			"udm-instance", authSubsPath, http.StatusOK},
+		{"Authentication Data", http.MethodGet, map[string][]string{"authentication-data": {"UDM"}},
+			"pcf-instance", authSubsPath, http.StatusForbidden},
+		{"Subscription Data Outside Authentication Data", http.MethodGet, map[string][]string{"authentication-data": {"UDM"}},
+			"pcf-instance", amDataPath, http.StatusOK},
+		{"Authentication Data Falls Back On Subscription Data", http.MethodGet,
+			map[string][]string{"subscription-data": {"NEF"}},
+			"udm-instance", authSubsPath, http.StatusForbidden},
+		{"One Of The Allowed NF Types", http.MethodGet, map[string][]string{"subscription-data": {"NEF", "UDM"}},
+			"udm-instance", authSubsPath, http.StatusOK},
+		{"Subscriber Offboarding", http.MethodDelete, map[string][]string{"authentication-data": {"UDM"}},
+			"pcf-instance", subscriberPath, http.StatusForbidden},
+		{"Subscriber Data Sets", http.MethodGet, map[string][]string{"authentication-data": {"UDM"}},
+			"pcf-instance", subscriberPath, http.StatusOK},
+		{"Bulk Provisioning", http.MethodPost, map[string][]string{"authentication-data": {"UDM"}},
+			"pcf-instance", UdrBulkProvisioningPath, http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			factory.UdrConfig.Configuration.AllowedNfTypes = tc.allowedNfTypes
+			req := httptest.NewRequest(tc.method, factory.UdrDrResUriPrefix+tc.path, nil)
+			if tc.requester != "" {
+				req.Header.Set("X-Requester", tc.requester)
+			}
+			rsp := httptest.NewRecorder()
+			router.ServeHTTP(rsp, req)
+			require.Equal(t, tc.expectedCode, rsp.Code, rsp.Body.String())
+			if tc.expectedCode == http.StatusForbidden {
+				require.Contains(t, rsp.Body.String(), "ACCESS_DENIED")
+			}
+		})
+	}
+}
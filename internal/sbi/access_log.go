@@ -0,0 +1,80 @@
+package sbi
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/util"
+)
+
+// 3GPP TS 29.500 5.2.3.2
+const (
+	HeaderSbiCorrelationInfo = "3gpp-Sbi-Correlation-Info"
+	HeaderSbiMessagePriority = "3gpp-Sbi-Message-Priority"
+)
+
+var (
+	// The MCC and MNC of an IMSI and the realm of a NAI are kept, they tell where the subscriber comes from
+	imsiRegexp = regexp.MustCompile(`imsi-([0-9]{5})([0-9]+)`)
+	naiRegexp  = regexp.MustCompile(`nai-[^/@]+`)
+)
+
+// accessLog logs one entry per request once it is answered, and echoes the correlation info of the
+// request so that the consumer can match the response to its transaction
+func (s *Server) accessLog(c *gin.Context) {
+	start := time.Now()
+	correlationInfo := c.GetHeader(HeaderSbiCorrelationInfo)
+	if correlationInfo != "" {
+		c.Header(HeaderSbiCorrelationInfo, correlationInfo)
+	}
+
+	c.Next()
+
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	path := c.Request.URL.Path
+	if s.Config().IsLogAnonymized() {
+		path = maskSupis(path)
+	}
+
+	fields := logrus.Fields{
+		"method":    c.Request.Method,
+		"route":     route,
+		"path":      path,
+		"status":    c.Writer.Status(),
+		"latencyMs": float64(time.Since(start).Microseconds()) / 1000,
+		"clientIp":  c.ClientIP(),
+	}
+	if requestId := c.GetString(util.RequestIdCtxKey); requestId != "" {
+		fields["requestId"] = requestId
+	}
+	if requester := c.GetString(util.RequesterNfInstanceIdCtxKey); requester != "" {
+		fields["requesterNfInstanceId"] = requester
+	}
+	if correlationInfo != "" {
+		fields["correlationInfo"] = correlationInfo
+	}
+	if priority := c.GetHeader(HeaderSbiMessagePriority); priority != "" {
+		fields["messagePriority"] = priority
+	}
+	if errs := c.Errors.ByType(gin.ErrorTypePrivate).String(); errs != "" {
+		fields["errors"] = errs
+	}
+	logger.AccessLog.WithContext(c.Request.Context()).WithFields(fields).Info("SBI request")
+}
+
+// maskSupis hides the MSIN of the IMSIs and the user part of the NAIs found in path
+func maskSupis(path string) string {
+	path = imsiRegexp.ReplaceAllStringFunc(path, func(imsi string) string {
+		match := imsiRegexp.FindStringSubmatch(imsi)
+		return "imsi-" + match[1] + strings.Repeat("*", len(match[2]))
+	})
+	return naiRegexp.ReplaceAllString(path, "nai-***")
+}
@@ -0,0 +1,77 @@
+package sbi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/pkg/factory"
+)
+
+func TestServer_AccessLog(t *testing.T) {
+	s := newTestServer(t)
+	factory.UdrConfig.Logger = &factory.Logger{Level: "info", Anonymize: true}
+	s.router.GET("/ues/:ueId", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	hooks := logger.Log.ReplaceHooks(make(logrus.LevelHooks))
+	t.Cleanup(func() {
+		logger.Log.ReplaceHooks(hooks)
+	})
+	hook := test.NewLocal(logger.Log)
+
+	req := httptest.NewRequest(http.MethodGet, "/ues/imsi-208930000000001", nil)
+	req.Header.Set(HeaderSbiCorrelationInfo, "imsi-208930000000001")
+	req.Header.Set(HeaderSbiMessagePriority, "12")
+	rsp := httptest.NewRecorder()
+	s.router.ServeHTTP(rsp, req)
+
+	require.Equal(t, http.StatusNoContent, rsp.Code)
+	require.Equal(t, "imsi-208930000000001", rsp.Header().Get(HeaderSbiCorrelationInfo))
+
+	entry := hook.LastEntry()
+	require.NotNil(t, entry)
+	require.Equal(t, http.MethodGet, entry.Data["method"])
+	require.Equal(t, "/ues/:ueId", entry.Data["route"])
+	require.Equal(t, "/ues/imsi-20893**********", entry.Data["path"])
+	require.Equal(t, http.StatusNoContent, entry.Data["status"])
+	require.Equal(t, "imsi-208930000000001", entry.Data["correlationInfo"])
+	require.Equal(t, "12", entry.Data["messagePriority"])
+	require.Contains(t, entry.Data, "latencyMs")
+
+	logger.SetAccessLogJSON(true)
+	t.Cleanup(func() {
+		logger.SetAccessLogJSON(false)
+	})
+	line, err := entry.Bytes()
+	require.NoError(t, err)
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(bytes.TrimSpace(line), &decoded))
+	require.Equal(t, "/ues/:ueId", decoded["route"])
+	// The fields the log pipelines look for
+	require.Equal(t, http.MethodGet, decoded["method"])
+	require.Equal(t, "/ues/imsi-20893**********", decoded["path"])
+	require.Equal(t, float64(http.StatusNoContent), decoded["status"])
+	require.Equal(t, "192.0.2.1", decoded["clientIp"])
+	require.Equal(t, rsp.Header().Get(HeaderRequestId), decoded["requestId"])
+	require.Contains(t, decoded, "latencyMs")
+	require.Contains(t, decoded, "time")
+}
+
+func TestMaskSupis(t *testing.T) {
+	require.Equal(t, "/nudr-dr/v2/subscription-data/imsi-20893**********/authentication-data",
+		maskSupis("/nudr-dr/v2/subscription-data/imsi-208930000000001/authentication-data"))
+	require.Equal(t, "/nudr-dr/v2/subscription-data/nai-***@free5gc.org/context-data",
+		maskSupis("/nudr-dr/v2/subscription-data/nai-user@free5gc.org/context-data"))
+	require.Equal(t, "/nudr-dr/v2/subscription-data/msisdn-0900000000/context-data",
+		maskSupis("/nudr-dr/v2/subscription-data/msisdn-0900000000/context-data"))
+}
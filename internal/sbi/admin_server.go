@@ -0,0 +1,130 @@
+package sbi
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/util/metrics/sbi"
+)
+
+const UdrAdminAuditPath = "/admin/audit"
+
+// newAdminServer serves the operator endpoints on their own router, so that they can never be reached
+// on an SBI listener
+func newAdminServer(s *Server, bindAddr string) *http.Server {
+	router := gin.New()
+	router.Use(s.requestId, s.accessLog, s.recoverPanic)
+	if s.Config().IsAuditEnabled() {
+		router.GET(UdrAdminAuditPath, s.HandleQueryAudit)
+	}
+
+	readHeader, read, write, idle := s.Config().GetSbiTimeouts()
+	return &http.Server{
+		Addr:              bindAddr,
+		Handler:           router,
+		ReadHeaderTimeout: readHeader,
+		ReadTimeout:       read,
+		WriteTimeout:      write,
+		IdleTimeout:       idle,
+	}
+}
+
+// HandleQueryAudit - Retrieves one page of the audit trail, of one subscriber with ueId, between from and to
+func (s *Server) HandleQueryAudit(c *gin.Context) {
+	logger.SBILog.Tracef("Handle QueryAudit")
+
+	defaultPageSize, maxPageSize := s.Config().GetPageSizes()
+	pageSize, detail := positiveQueryInt(c, "page-size", defaultPageSize)
+	if detail == "" && pageSize > maxPageSize {
+		detail = fmt.Sprintf("page-size should not exceed %d", maxPageSize)
+	}
+	pageNumber, pageNumberDetail := positiveQueryInt(c, "page-number", 1)
+	if detail == "" {
+		detail = pageNumberDetail
+	}
+	from, fromDetail := timeQuery(c, "from")
+	if detail == "" {
+		detail = fromDetail
+	}
+	to, toDetail := timeQuery(c, "to")
+	if detail == "" {
+		detail = toDetail
+	}
+	if detail == "" && !from.IsZero() && !to.IsZero() && from.After(to) {
+		detail = "from should not be after to"
+	}
+	if detail != "" {
+		problemDetails := &models.ProblemDetails{
+			Title:  "Invalid parameter",
+			Status: http.StatusBadRequest,
+			Detail: detail,
+			Cause:  "INVALID_QUERY_PARAM",
+		}
+		logger.SBILog.Errorf("QueryAudit: %s", detail)
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
+		c.JSON(http.StatusBadRequest, problemDetails)
+		return
+	}
+
+	s.Processor().QueryAuditProcedure(c, c.Query("ueId"), from, to, pageSize, pageNumber)
+}
+
+// timeQuery returns the zero time when the query parameter is absent, and a detail when it is not RFC 3339
+func timeQuery(c *gin.Context, key string) (time.Time, string) {
+	param, ok := c.GetQuery(key)
+	if !ok {
+		return time.Time{}, ""
+	}
+	value, err := time.Parse(time.RFC3339, param)
+	if err != nil {
+		return time.Time{}, fmt.Sprintf("%s should be an RFC 3339 date-time", key)
+	}
+	return value, ""
+}
+
+// listenAdminServer opens the listener of the admin server, nil when no admin listener is configured
+func (s *Server) listenAdminServer() (net.Listener, error) {
+	if s.adminServer == nil {
+		return nil, nil
+	}
+
+	ln, err := net.Listen("tcp", s.adminServer.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("admin server failed to listen on %s: %w", s.adminServer.Addr, err)
+	}
+	logger.SBILog.Infof("Admin server listen on %s", s.adminServer.Addr)
+	return ln, nil
+}
+
+func (s *Server) serveAdminServer(wg *sync.WaitGroup, ln net.Listener) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		if err := s.adminServer.Serve(ln); err != http.ErrServerClosed {
+			logger.SBILog.Errorf("Admin server failed on %s: %+v", s.adminServer.Addr, err)
+			return
+		}
+		logger.SBILog.Infof("Admin server (listen on %s) stopped", s.adminServer.Addr)
+	}()
+}
+
+func (s *Server) shutdownAdminServer(ctx context.Context) {
+	if s.adminServer == nil {
+		return
+	}
+	if err := s.adminServer.Shutdown(ctx); err != nil {
+		logger.SBILog.Warnf("Admin server shutdown failed, closing it: %+v", err)
+		if err = s.adminServer.Close(); err != nil {
+			logger.SBILog.Errorf("Admin server close failed: %+v", err)
+		}
+	}
+}
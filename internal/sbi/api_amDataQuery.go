@@ -0,0 +1,95 @@
+package sbi
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/util/metrics/sbi"
+)
+
+// amDataFilters are the query parameters HandleQueryAmDataList filters on, by the field of the AM data
+// document they match
+var amDataFilters = map[string]string{
+	"plmn-id":    "servingPlmnId",
+	"gpsi":       "gpsis",
+	"ue-ambr-ul": "subscribedUeAmbr.uplink",
+	"ue-ambr-dl": "subscribedUeAmbr.downlink",
+}
+
+func (s *Server) getAmDataQueryRoutes() []Route {
+	return []Route{
+		{
+			Name:        "QueryAmDataList",
+			Method:      http.MethodGet,
+			Pattern:     "/subscription-data/am-data",
+			HandlerFunc: s.HandleQueryAmDataList,
+		},
+	}
+}
+
+// HandleQueryAmDataList - Retrieves one page of the AM data documents matching the filters of the query, each
+// with the ueId and servingPlmnId it is stored by. A gpsi matches the documents listing it among their gpsis.
+// A query parameter other than the filters and the pagination ones is answered 400.
+func (s *Server) HandleQueryAmDataList(c *gin.Context) {
+	logger.DataRepoLog.Tracef("Handle QueryAmDataList")
+
+	filter := bson.M{}
+	var invalidParams []models.InvalidParam
+	params := make([]string, 0, len(c.Request.URL.Query()))
+	for param := range c.Request.URL.Query() {
+		params = append(params, param)
+	}
+	sort.Strings(params)
+	for _, param := range params {
+		field, ok := amDataFilters[param]
+		value := c.Query(param)
+		switch {
+		case param == "page-size" || param == "page-number":
+		case !ok:
+			invalidParams = append(invalidParams, models.InvalidParam{Param: param, Reason: "is not a filter"})
+		case len(c.QueryArray(param)) > 1:
+			invalidParams = append(invalidParams, models.InvalidParam{Param: param, Reason: "shall be given once"})
+		case value == "":
+			invalidParams = append(invalidParams, models.InvalidParam{Param: param, Reason: "shall not be empty"})
+		case param == "plmn-id" && !util.IsValidServingPlmnId(value):
+			invalidParams = append(invalidParams, models.InvalidParam{Param: param, Reason: "shall be a PLMN ID"})
+		default:
+			filter[field] = value
+		}
+	}
+
+	defaultPageSize, maxPageSize := s.Config().GetPageSizes()
+	pageSize, detail := positiveQueryInt(c, "page-size", defaultPageSize)
+	if detail == "" && pageSize > maxPageSize {
+		detail = fmt.Sprintf("page-size should not exceed %d", maxPageSize)
+	}
+	pageNumber, pageNumberDetail := positiveQueryInt(c, "page-number", 1)
+	if detail == "" {
+		detail = pageNumberDetail
+	}
+	if len(invalidParams) > 0 || detail != "" {
+		problemDetails := &models.ProblemDetails{
+			Title:         "Invalid parameter",
+			Status:        http.StatusBadRequest,
+			Detail:        detail,
+			Cause:         "INVALID_QUERY_PARAM",
+			InvalidParams: invalidParams,
+		}
+		if detail == "" {
+			problemDetails.Detail = invalidParams[0].Param + " " + invalidParams[0].Reason
+		}
+		logger.DataRepoLog.Errorf("QueryAmDataList: %s", problemDetails.Detail)
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
+		c.JSON(http.StatusBadRequest, problemDetails)
+		return
+	}
+
+	s.Processor().QueryAmDataListProcedure(c, filter, pageSize, pageNumber)
+}
@@ -0,0 +1,100 @@
+package sbi
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/sbi/processor"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/util/metrics/sbi"
+)
+
+// UdrBatchReadPath reads a resource of many subscribers in a single query, in place of a read by subscriber
+const UdrBatchReadPath = "/subscription-data/batch-read"
+
+func (s *Server) getBatchReadRoutes() []Route {
+	return []Route{
+		{
+			Name:        "BatchReadSubscriptionData",
+			Method:      http.MethodPost,
+			Pattern:     UdrBatchReadPath,
+			HandlerFunc: s.HandleBatchReadSubscriptionData,
+		},
+	}
+}
+
+// HandleBatchReadSubscriptionData - Retrieves the resource of the request for every SUPI listed, answered by
+// SUPI. The SUPIs without the resource are left out of the response. More SUPIs than batchRead.maxSupis are
+// answered 400.
+func (s *Server) HandleBatchReadSubscriptionData(c *gin.Context) {
+	logger.DataRepoLog.Tracef("Handle BatchReadSubscriptionData")
+
+	requestBody, err := c.GetRawData()
+	if err != nil {
+		problemDetail := util.ProblemDetailsSystemFailure(err.Error())
+		logger.DataRepoLog.Errorf("Get Request Body error: %+v", err)
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetail.Cause)
+		c.JSON(http.StatusInternalServerError, problemDetail)
+		return
+	}
+
+	var request processor.BatchReadRequest
+	if err = openapi.Deserialize(&request, requestBody, "application/json"); err != nil {
+		problemDetail := util.ProblemDetailsMalformedReqSyntax("[Request Body] " + err.Error())
+		logger.DataRepoLog.Errorln(problemDetail.Detail)
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, http.StatusText(int(problemDetail.Status)))
+		c.JSON(http.StatusBadRequest, problemDetail)
+		return
+	}
+
+	var invalidParam *models.InvalidParam
+	maxSupis := s.Config().GetBatchReadMaxSupis()
+	switch {
+	case len(request.Supis) == 0:
+		invalidParam = &models.InvalidParam{Param: "supis", Reason: "at least one SUPI shall be provided"}
+	case len(request.Supis) > maxSupis:
+		invalidParam = &models.InvalidParam{
+			Param:  "supis",
+			Reason: fmt.Sprintf("%d SUPIs exceed the batch limit of %d", len(request.Supis), maxSupis),
+		}
+	case !slices.Contains(processor.BatchReadResources(), request.Resource):
+		invalidParam = &models.InvalidParam{
+			Param:  "resource",
+			Reason: "shall be one of " + strings.Join(processor.BatchReadResources(), ", "),
+		}
+	case processor.IsPerPlmnBatchReadResource(request.Resource) && !util.IsValidServingPlmnId(request.ServingPlmnId):
+		invalidParam = &models.InvalidParam{
+			Param:  "servingPlmnId",
+			Reason: "shall be the PLMN ID the " + request.Resource + " is stored by",
+		}
+	default:
+		for _, supi := range request.Supis {
+			if !util.IsValidSupi(supi) {
+				invalidParam = &models.InvalidParam{Param: "supis", Reason: supi + " is not a valid SUPI"}
+				break
+			}
+		}
+	}
+	if invalidParam != nil {
+		problemDetail := &models.ProblemDetails{
+			Title:         "Invalid parameter",
+			Status:        http.StatusBadRequest,
+			Detail:        invalidParam.Param + ": " + invalidParam.Reason,
+			Cause:         "INVALID_PARAMETER",
+			InvalidParams: []models.InvalidParam{*invalidParam},
+		}
+		logger.DataRepoLog.Errorf("BatchReadSubscriptionData: %s", problemDetail.Detail)
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetail.Cause)
+		c.JSON(http.StatusBadRequest, problemDetail)
+		return
+	}
+
+	s.Processor().BatchReadProcedure(c, request)
+}
@@ -0,0 +1,87 @@
+package sbi
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/sbi/processor"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/util/metrics/sbi"
+)
+
+// UdrBulkProvisioningPath is meant for onboarding many subscribers at once, its body size can be raised with
+// sbi.requestBodyLimits
+const UdrBulkProvisioningPath = "/subscription-data/bulk-provisioning"
+
+func (s *Server) getBulkProvisioningRoutes() []Route {
+	return []Route{
+		{
+			Name:        "BulkProvisionSubscriptionData",
+			Method:      http.MethodPost,
+			Pattern:     UdrBulkProvisioningPath,
+			HandlerFunc: s.HandleBulkProvisionSubscriptionData,
+		},
+	}
+}
+
+// HandleBulkProvisionSubscriptionData - Creates or replaces the subscription data of a batch of subscribers,
+// the response holds the result of every record in the order of the request. With If-None-Match: *, the records
+// only create subscribers and the ones of a SUPI already provisioned are answered 409.
+func (s *Server) HandleBulkProvisionSubscriptionData(c *gin.Context) {
+	logger.DataRepoLog.Tracef("Handle BulkProvisionSubscriptionData")
+
+	ifNoneMatch := c.GetHeader("If-None-Match")
+	if ifNoneMatch != "" && ifNoneMatch != "*" {
+		problemDetail := util.ProblemDetailsMalformedReqSyntax("If-None-Match shall be *")
+		logger.DataRepoLog.Errorf("BulkProvisionSubscriptionData: %s", problemDetail.Detail)
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetail.Cause)
+		c.JSON(int(problemDetail.Status), problemDetail)
+		return
+	}
+
+	requestBody, err := c.GetRawData()
+	if err != nil {
+		problemDetail := util.ProblemDetailsSystemFailure(err.Error())
+		logger.DataRepoLog.Errorf("Get Request Body error: %+v", err)
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetail.Cause)
+		c.JSON(http.StatusInternalServerError, problemDetail)
+		return
+	}
+
+	var records []processor.ProvisioningRecord
+	if err = openapi.Deserialize(&records, requestBody, "application/json"); err != nil {
+		problemDetail := util.ProblemDetailsMalformedReqSyntax("[Request Body] " + err.Error())
+		logger.DataRepoLog.Errorln(problemDetail.Detail)
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, http.StatusText(int(problemDetail.Status)))
+		c.JSON(http.StatusBadRequest, problemDetail)
+		return
+	}
+
+	if len(records) == 0 {
+		problemDetail := &models.ProblemDetails{
+			Title:  "Invalid parameter",
+			Status: http.StatusBadRequest,
+			Detail: "At least one record shall be provided",
+			Cause:  "INVALID_PARAMETER",
+		}
+		logger.DataRepoLog.Errorf("BulkProvisionSubscriptionData: %s", problemDetail.Detail)
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetail.Cause)
+		c.JSON(http.StatusBadRequest, problemDetail)
+		return
+	}
+	if maxBatchSize := s.Config().GetProvisioningMaxBatchSize(); len(records) > maxBatchSize {
+		problemDetail := util.ProblemDetailsPayloadTooLarge(
+			fmt.Sprintf("%d records exceed the batch limit of %d", len(records), maxBatchSize))
+		logger.DataRepoLog.Errorf("BulkProvisionSubscriptionData: %s", problemDetail.Detail)
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetail.Cause)
+		c.JSON(int(problemDetail.Status), problemDetail)
+		return
+	}
+
+	s.Processor().BulkProvisionSubscriptionData(c, records, ifNoneMatch == "*")
+}
@@ -11,9 +11,10 @@ package sbi
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"regexp"
-	"strconv"
+	"slices"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -23,6 +24,7 @@ import (
 	"github.com/free5gc/openapi/models"
 	udr_context "github.com/free5gc/udr/internal/context"
 	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/sbi/processor"
 	"github.com/free5gc/udr/internal/util"
 	"github.com/free5gc/util/metrics/sbi"
 )
@@ -85,6 +87,13 @@ func (s *Server) getDataRepositoryRoutes() []Route {
 			s.HandleQueryAmData,
 		},
 
+		{
+			"ModifyAmData",
+			strings.ToUpper("Patch"),
+			"/subscription-data/:ueId/:servingPlmnId/provisioned-data/am-data",
+			s.HandleModifyAmData,
+		},
+
 		{
 			"QueryAuthenticationStatus",
 			strings.ToUpper("Get"),
@@ -99,6 +108,34 @@ func (s *Server) getDataRepositoryRoutes() []Route {
 			s.HandleCreateAuthenticationStatus,
 		},
 
+		{
+			"DeleteAuthenticationStatus",
+			strings.ToUpper("Delete"),
+			"/subscription-data/:ueId/:servingPlmnId/authentication-status",
+			s.HandleDeleteAuthenticationStatus,
+		},
+
+		{
+			"QueryIndividualAuthenticationStatus",
+			strings.ToUpper("Get"),
+			"/subscription-data/:ueId/:servingPlmnId/authentication-status/:servingNetworkName",
+			s.HandleQueryIndividualAuthenticationStatus,
+		},
+
+		{
+			"CreateIndividualAuthenticationStatus",
+			strings.ToUpper("Put"),
+			"/subscription-data/:ueId/:servingPlmnId/authentication-status/:servingNetworkName",
+			s.HandleCreateIndividualAuthenticationStatus,
+		},
+
+		{
+			"DeleteIndividualAuthenticationStatus",
+			strings.ToUpper("Delete"),
+			"/subscription-data/:ueId/:servingPlmnId/authentication-status/:servingNetworkName",
+			s.HandleDeleteIndividualAuthenticationStatus,
+		},
+
 		{
 			"ModifyAuthentication",
 			strings.ToUpper("Patch"),
@@ -113,6 +150,13 @@ func (s *Server) getDataRepositoryRoutes() []Route {
 			s.HandleQueryAuthSubsData,
 		},
 
+		{
+			"IncrementSqn",
+			strings.ToUpper("Post"),
+			"/subscription-data/:ueId/:servingPlmnId/authentication-subscription/sqn-increment",
+			s.HandleIncrementSqn,
+		},
+
 		{
 			"CreateAuthenticationSoR",
 			strings.ToUpper("Put"),
@@ -127,6 +171,27 @@ func (s *Server) getDataRepositoryRoutes() []Route {
 			s.HandleQueryAuthSoR,
 		},
 
+		{
+			"CreateAuthenticationUPU",
+			strings.ToUpper("Put"),
+			"/subscription-data/:ueId/:servingPlmnId/upu-data",
+			s.HandleCreateAuthenticationUPU,
+		},
+
+		{
+			"QueryAuthUPU",
+			strings.ToUpper("Get"),
+			"/subscription-data/:ueId/:servingPlmnId/upu-data",
+			s.HandleQueryAuthUPU,
+		},
+
+		{
+			"QueryUeUpdateConfirmationData",
+			strings.ToUpper("Get"),
+			"/subscription-data/:ueId/ue-update-confirmation-data",
+			s.HandleQueryUeUpdateConfirmationData,
+		},
+
 		{
 			"ApplicationDataInfluenceDataGet",
 			strings.ToUpper("Get"),
@@ -193,153 +258,6 @@ func (s *Server) getDataRepositoryRoutes() []Route {
 			s.HandleApplicationDataPfdsGet,
 		},
 
-		{
-			"PolicyDataBdtDataBdtReferenceIdDelete",
-			strings.ToUpper("Delete"),
-			"/policy-data/bdt-data/:bdtReferenceId",
-			s.HandlePolicyDataBdtDataBdtReferenceIdDelete,
-		},
-
-		{
-			"PolicyDataBdtDataBdtReferenceIdGet",
-			strings.ToUpper("Get"),
-			"/policy-data/bdt-data/:bdtReferenceId",
-			s.HandlePolicyDataBdtDataBdtReferenceIdGet,
-		},
-
-		{
-			"PolicyDataBdtDataBdtReferenceIdPut",
-			strings.ToUpper("Put"),
-			"/policy-data/bdt-data/:bdtReferenceId",
-			s.HandlePolicyDataBdtDataBdtReferenceIdPut,
-		},
-
-		{
-			"PolicyDataBdtDataGet",
-			strings.ToUpper("Get"),
-			"/policy-data/bdt-data",
-			s.HandlePolicyDataBdtDataGet,
-		},
-
-		{
-			"PolicyDataPlmnsPlmnIdUePolicySetGet",
-			strings.ToUpper("Get"),
-			"/policy-data/plmns/:plmnId/ue-policy-set",
-			s.HandlePolicyDataPlmnsPlmnIdUePolicySetGet,
-		},
-
-		{
-			"PolicyDataSponsorConnectivityDataSponsorIdGet",
-			strings.ToUpper("Get"),
-			"/policy-data/sponsor-connectivity-data/:sponsorId",
-			s.HandlePolicyDataSponsorConnectivityDataSponsorIdGet,
-		},
-
-		{
-			"PolicyDataSubsToNotifyPost",
-			strings.ToUpper("Post"),
-			"/policy-data/subs-to-notify",
-			s.HandlePolicyDataSubsToNotifyPost,
-		},
-
-		{
-			"PolicyDataSubsToNotifySubsIdDelete",
-			strings.ToUpper("Delete"),
-			"/policy-data/subs-to-notify/:subsId",
-			s.HandlePolicyDataSubsToNotifySubsIdDelete,
-		},
-
-		{
-			"PolicyDataSubsToNotifySubsIdPut",
-			strings.ToUpper("Put"),
-			"/policy-data/subs-to-notify/:subsId",
-			s.HandlePolicyDataSubsToNotifySubsIdPut,
-		},
-
-		{
-			"PolicyDataUesUeIdAmDataGet",
-			strings.ToUpper("Get"),
-			"/policy-data/ues/:ueId/am-data",
-			s.HandlePolicyDataUesUeIdAmDataGet,
-		},
-
-		{
-			"PolicyDataUesUeIdOperatorSpecificDataGet",
-			strings.ToUpper("Get"),
-			"/policy-data/ues/:ueId/operator-specific-data",
-			s.HandlePolicyDataUesUeIdOperatorSpecificDataGet,
-		},
-
-		{
-			"PolicyDataUesUeIdOperatorSpecificDataPatch",
-			strings.ToUpper("Patch"),
-			"/policy-data/ues/:ueId/operator-specific-data",
-			s.HandlePolicyDataUesUeIdOperatorSpecificDataPatch,
-		},
-
-		{
-			"PolicyDataUesUeIdOperatorSpecificDataPut",
-			strings.ToUpper("Put"),
-			"/policy-data/ues/:ueId/operator-specific-data",
-			s.HandlePolicyDataUesUeIdOperatorSpecificDataPut,
-		},
-
-		{
-			"PolicyDataUesUeIdSmDataGet",
-			strings.ToUpper("Get"),
-			"/policy-data/ues/:ueId/sm-data",
-			s.HandlePolicyDataUesUeIdSmDataGet,
-		},
-
-		{
-			"PolicyDataUesUeIdSmDataPatch",
-			strings.ToUpper("Patch"),
-			"/policy-data/ues/:ueId/sm-data",
-			s.HandlePolicyDataUesUeIdSmDataPatch,
-		},
-
-		{
-			"PolicyDataUesUeIdSmDataUsageMonIdDelete",
-			strings.ToUpper("Delete"),
-			"/policy-data/ues/:ueId/sm-data/:usageMonId",
-			s.HandlePolicyDataUesUeIdSmDataUsageMonIdDelete,
-		},
-
-		{
-			"PolicyDataUesUeIdSmDataUsageMonIdGet",
-			strings.ToUpper("Get"),
-			"/policy-data/ues/:ueId/sm-data/:usageMonId",
-			s.HandlePolicyDataUesUeIdSmDataUsageMonIdGet,
-		},
-
-		{
-			"PolicyDataUesUeIdSmDataUsageMonIdPut",
-			strings.ToUpper("Put"),
-			"/policy-data/ues/:ueId/sm-data/:usageMonId",
-			s.HandlePolicyDataUesUeIdSmDataUsageMonIdPut,
-		},
-
-		{
-			"PolicyDataUesUeIdUePolicySetGet",
-			strings.ToUpper("Get"),
-			"/policy-data/ues/:ueId/ue-policy-set",
-			s.HandlePolicyDataUesUeIdUePolicySetGet,
-		},
-
-		{
-			"PolicyDataUesUeIdUePolicySetPatch",
-			strings.ToUpper("Patch"),
-			"/policy-data/ues/:ueId/ue-policy-set",
-			s.HandlePolicyDataUesUeIdUePolicySetPatch,
-		},
-
-		{
-			"PolicyDataUesUeIdUePolicySetPut",
-			strings.ToUpper("Put"),
-			"/policy-data/ues/:ueId/ue-policy-set",
-			s.HandlePolicyDataUesUeIdUePolicySetPut,
-		},
-
 		{
 			"QueryProvisionedData",
 			strings.ToUpper("Get"),
@@ -361,6 +279,13 @@ func (s *Server) getDataRepositoryRoutes() []Route {
 			s.HandleUpdatesdmsubscriptions,
 		},
 
+		{
+			"Modifysdmsubscription",
+			strings.ToUpper("Patch"),
+			"/subscription-data/:ueId/:servingPlmnId/sdm-subscriptions/:subsId",
+			s.HandleModifysdmsubscription,
+		},
+
 		{
 			"CreateSdmSubscriptions",
 			strings.ToUpper("Post"),
@@ -452,6 +377,34 @@ func (s *Server) getDataRepositoryRoutes() []Route {
 			s.HandleQuerySmsfContextNon3gpp,
 		},
 
+		{
+			"QueryLcsPrivacyData",
+			strings.ToUpper("Get"),
+			"/subscription-data/:ueId/lcs-privacy-data",
+			s.HandleQueryLcsPrivacyData,
+		},
+
+		{
+			"QueryLcsMoData",
+			strings.ToUpper("Get"),
+			"/subscription-data/:ueId/lcs-mo-data",
+			s.HandleQueryLcsMoData,
+		},
+
+		{
+			"QueryV2xData",
+			strings.ToUpper("Get"),
+			"/subscription-data/:ueId/v2x-data",
+			s.HandleQueryV2xData,
+		},
+
+		{
+			"QueryProseData",
+			strings.ToUpper("Get"),
+			"/subscription-data/:ueId/prose-data",
+			s.HandleQueryProseData,
+		},
+
 		{
 			"QuerySmsMngData",
 			strings.ToUpper("Get"),
@@ -459,6 +412,13 @@ func (s *Server) getDataRepositoryRoutes() []Route {
 			s.HandleQuerySmsMngData,
 		},
 
+		{
+			"CreateSmsMngData",
+			strings.ToUpper("Put"),
+			"/subscription-data/:ueId/:servingPlmnId/provisioned-data/sms-mng-data",
+			s.HandleCreateSmsMngData,
+		},
+
 		{
 			"QuerySmsData",
 			strings.ToUpper("Get"),
@@ -473,6 +433,13 @@ func (s *Server) getDataRepositoryRoutes() []Route {
 			s.HandleQuerySmData,
 		},
 
+		{
+			"ModifySmData",
+			strings.ToUpper("Patch"),
+			"/subscription-data/:ueId/:servingPlmnId/provisioned-data/sm-data",
+			s.HandleModifySmData,
+		},
+
 		{
 			"QueryTraceData",
 			strings.ToUpper("Get"),
@@ -483,28 +450,28 @@ func (s *Server) getDataRepositoryRoutes() []Route {
 		{
 			"CreateAMFSubscriptions",
 			strings.ToUpper("Put"),
-			"/subscription-data/:ueId/:servingPlmnId/ee-subscriptions/:subsId/amf-subscriptions",
+			"/subscription-data/:ueId/context-data/ee-subscriptions/:subsId/amf-subscriptions",
 			s.HandleCreateAMFSubscriptions,
 		},
 
 		{
 			"ModifyAmfSubscriptionInfo",
 			strings.ToUpper("Patch"),
-			"/subscription-data/:ueId/:servingPlmnId/ee-subscriptions/:subsId/amf-subscriptions",
+			"/subscription-data/:ueId/context-data/ee-subscriptions/:subsId/amf-subscriptions",
 			s.HandleModifyAmfSubscriptionInfo,
 		},
 
 		{
 			"RemoveAmfSubscriptionsInfo",
 			strings.ToUpper("Delete"),
-			"/subscription-data/:ueId/:servingPlmnId/ee-subscriptions/:subsId/amf-subscriptions",
+			"/subscription-data/:ueId/context-data/ee-subscriptions/:subsId/amf-subscriptions",
 			s.HandleRemoveAmfSubscriptionsInfo,
 		},
 
 		{
 			"GetAmfSubscriptionInfo",
 			strings.ToUpper("Get"),
-			"/subscription-data/:ueId/:servingPlmnId/ee-subscriptions/:subsId/amf-subscriptions",
+			"/subscription-data/:ueId/context-data/ee-subscriptions/:subsId/amf-subscriptions",
 			s.HandleGetAmfSubscriptionInfo,
 		},
 
@@ -516,6 +483,13 @@ func (s *Server) getDataRepositoryRoutes() []Route {
 			s.HandleGetSharedData,
 		},
 
+		{
+			"GetIndividualSharedData",
+			strings.ToUpper("Get"),
+			"/subscription-data/shared-data/:sharedDataId",
+			s.HandleGetIndividualSharedData,
+		},
+
 		{
 			"PostSubscriptionDataSubscriptions",
 			strings.ToUpper("Post"),
@@ -553,6 +527,20 @@ func (s *Server) getDataRepositoryRoutes() []Route {
 			s.HandleQueryOperSpecData,
 		},
 
+		{
+			"CreateOperSpecData",
+			strings.ToUpper("Put"),
+			"/subscription-data/:ueId/operator-specific-data",
+			s.HandleCreateOperSpecData,
+		},
+
+		{
+			"DeleteOperSpecData",
+			strings.ToUpper("Delete"),
+			"/subscription-data/:ueId/operator-specific-data",
+			s.HandleDeleteOperSpecData,
+		},
+
 		{
 			"GetppData",
 			strings.ToUpper("Get"),
@@ -618,6 +606,13 @@ func (s *Server) getDataRepositoryRoutes() []Route {
 			s.HandleRemoveeeSubscriptions,
 		},
 
+		{
+			"QueryeeSubscription",
+			strings.ToUpper("Get"),
+			"/subscription-data/:ueId/context-data/ee-subscriptions/:subsId",
+			s.HandleQueryeeSubscription,
+		},
+
 		{
 			"UpdateEesubscriptions",
 			strings.ToUpper("Put"),
@@ -755,45 +750,19 @@ func Index(c *gin.Context) {
 
 // HTTPAmfContext3gpp - To modify the AMF context data of a UE using 3gpp access in the UDR
 func (s *Server) HandleAmfContext3gpp(c *gin.Context) {
-	var patchItemArray []models.PatchItem
-
-	requestBody, err := c.GetRawData()
-	if err != nil {
-		problemDetail := models.ProblemDetails{
-			Title:  "System failure",
-			Status: http.StatusInternalServerError,
-			Detail: err.Error(),
-			Cause:  "SYSTEM_FAILURE",
-		}
-		logger.DataRepoLog.Errorf("Get Request Body error: %+v", err)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetail.Cause)
-		c.JSON(http.StatusInternalServerError, problemDetail)
-		return
-	}
-
-	err = openapi.Deserialize(&patchItemArray, requestBody, "application/json")
+	patch, err := getPatchFromRequestBody(c, MediaTypeJSONPatch, MediaTypeMergePatch)
 	if err != nil {
-		problemDetail := "[Request Body] " + err.Error()
-		rsp := models.ProblemDetails{
-			Title:  "Malformed request syntax",
-			Status: http.StatusBadRequest,
-			Detail: problemDetail,
-		}
-		logger.DataRepoLog.Errorln(problemDetail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, http.StatusText(int(rsp.Status)))
-		c.JSON(http.StatusBadRequest, rsp)
 		return
 	}
 
 	logger.DataRepoLog.Tracef("Handle AmfContext3gpp")
 	collName := "subscriptionData.contextData.amf3gppAccess"
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 
-	s.Processor().AmfContext3gppProcedure(c, collName, ueId, patchItemArray)
+	s.Processor().AmfContext3gppProcedure(c, collName, ueId, patch)
 }
 
 // HTTPCreateAmfContext3gpp - To store the AMF context data of a UE using 3gpp access in the UDR
@@ -832,8 +801,7 @@ func (s *Server) HandleCreateAmfContext3gpp(c *gin.Context) {
 	collName := "subscriptionData.contextData.amf3gppAccess"
 
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 	s.Processor().CreateAmfContext3gppProcedure(c, collName, ueId, amf3GppAccessRegistration)
@@ -846,13 +814,7 @@ func (s *Server) HandleQueryAmfContext3gpp(c *gin.Context) {
 	ueId := c.Params.ByName("ueId")
 	collName := "subscriptionData.contextData.amf3gppAccess"
 
-	if ueId == "" {
-		problemDetail := &models.ProblemDetails{
-			Title:  util.MALFORMED_REQUEST,
-			Status: http.StatusBadRequest,
-			Detail: "ueId is required",
-		}
-		util.GinProblemJson(c, problemDetail)
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 
@@ -861,46 +823,20 @@ func (s *Server) HandleQueryAmfContext3gpp(c *gin.Context) {
 
 // HTTPAmfContextNon3gpp - To modify the AMF context data of a UE using non 3gpp access in the UDR
 func (s *Server) HandleAmfContextNon3gpp(c *gin.Context) {
-	var patchItemArray []models.PatchItem
-
-	requestBody, err := c.GetRawData()
-	if err != nil {
-		problemDetail := models.ProblemDetails{
-			Title:  "System failure",
-			Status: http.StatusInternalServerError,
-			Detail: err.Error(),
-			Cause:  "SYSTEM_FAILURE",
-		}
-		logger.DataRepoLog.Errorf("Get Request Body error: %+v", err)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetail.Cause)
-		c.JSON(http.StatusInternalServerError, problemDetail)
-		return
-	}
-
-	err = openapi.Deserialize(&patchItemArray, requestBody, "application/json")
+	patch, err := getPatchFromRequestBody(c, MediaTypeJSONPatch, MediaTypeMergePatch)
 	if err != nil {
-		problemDetail := "[Request Body] " + err.Error()
-		rsp := models.ProblemDetails{
-			Title:  "Malformed request syntax",
-			Status: http.StatusBadRequest,
-			Detail: problemDetail,
-		}
-		logger.DataRepoLog.Errorln(problemDetail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, http.StatusText(int(rsp.Status)))
-		c.JSON(http.StatusBadRequest, rsp)
 		return
 	}
 
 	logger.DataRepoLog.Tracef("Handle AmfContextNon3gpp")
 
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 	filter := bson.M{"ueId": ueId}
 	s.Processor().AmfContextNon3gppProcedure(
-		c, ueId, "subscriptionData.contextData.amfNon3gppAccess", patchItemArray, filter)
+		c, ueId, "subscriptionData.contextData.amfNon3gppAccess", patch, filter)
 }
 
 // HTTPCreateAmfContextNon3gpp - To store the AMF context data of a UE using non-3gpp access in the UDR
@@ -937,8 +873,7 @@ func (s *Server) HandleCreateAmfContextNon3gpp(c *gin.Context) {
 
 	logger.DataRepoLog.Tracef("Handle CreateAmfContextNon3gpp")
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 
@@ -952,57 +887,66 @@ func (s *Server) HandleQueryAmfContextNon3gpp(c *gin.Context) {
 
 	collName := "subscriptionData.contextData.amfNon3gppAccess"
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 	s.Processor().QueryAmfContextNon3gppProcedure(c, collName, ueId)
 }
 
-// HTTPQueryAmData - Retrieves the access and mobility subscription data of a UE
+// HTTPQueryAmData - Retrieves the access and mobility subscription data of a UE, the members of fields only
+// when given, nested ones by their dotted path. With supported-features the attributes of the features the UDR
+// and the consumer do not both support are left out.
 func (s *Server) HandleQueryAmData(c *gin.Context) {
 	logger.DataRepoLog.Tracef("Handle QueryAmData")
 
 	collName := "subscriptionData.provisionedData.amData"
-	servingPlmnId := c.Params.ByName("servingPlmnId")
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
+		return
+	}
+	servingPlmnId := c.Params.ByName("servingPlmnId")
+	if !util.CheckServingPlmnIdParam(c, servingPlmnId) {
+		return
+	}
+
+	supportedFeatures, ok := util.SupportedFeaturesQuery(c)
+	if !ok {
+		return
+	}
+	fields, ok := util.FieldsQuery(c, models.AccessAndMobilitySubscriptionData{})
+	if !ok {
 		return
 	}
 
-	s.Processor().QueryAmDataProcedure(c, collName, ueId, servingPlmnId)
+	s.Processor().QueryAmDataProcedure(c, collName, ueId, servingPlmnId, supportedFeatures, fields)
 }
 
-// HTTPCreateAuthenticationStatus - To store the Authentication Status data of a UE
-func (s *Server) HandleCreateAuthenticationStatus(c *gin.Context) {
-	var authEvent models.AuthEvent
+// HTTPModifyAmData - Modifies the access and mobility subscription data of a UE with a JSON merge patch.
+func (s *Server) HandleModifyAmData(c *gin.Context) {
+	logger.DataRepoLog.Tracef("Handle ModifyAmData")
 
-	requestBody, err := c.GetRawData()
+	patch, err := getPatchFromRequestBody(c, MediaTypeMergePatch)
 	if err != nil {
-		problemDetail := models.ProblemDetails{
-			Title:  "System failure",
-			Status: http.StatusInternalServerError,
-			Detail: err.Error(),
-			Cause:  "SYSTEM_FAILURE",
-		}
-		logger.DataRepoLog.Errorf("Get Request Body error: %+v", err)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetail.Cause)
-		c.JSON(http.StatusInternalServerError, problemDetail)
 		return
 	}
 
-	err = openapi.Deserialize(&authEvent, requestBody, "application/json")
-	if err != nil {
-		problemDetail := "[Request Body] " + err.Error()
-		rsp := models.ProblemDetails{
-			Title:  "Malformed request syntax",
-			Status: http.StatusBadRequest,
-			Detail: problemDetail,
-		}
-		logger.DataRepoLog.Errorln(problemDetail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, http.StatusText(int(rsp.Status)))
-		c.JSON(http.StatusBadRequest, rsp)
+	collName := "subscriptionData.provisionedData.amData"
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckUeIdParam(c, ueId) {
+		return
+	}
+	servingPlmnId := c.Params.ByName("servingPlmnId")
+	if !util.CheckServingPlmnIdParam(c, servingPlmnId) {
+		return
+	}
+
+	s.Processor().ModifyAmDataProcedure(c, collName, ueId, servingPlmnId, patch.MergePatch)
+}
+
+// HTTPCreateAuthenticationStatus - To store the Authentication Status data of a UE
+func (s *Server) HandleCreateAuthenticationStatus(c *gin.Context) {
+	var authEvent models.AuthEvent
+	if err := getDataFromRequestBody(c, &authEvent); err != nil {
 		return
 	}
 
@@ -1010,8 +954,7 @@ func (s *Server) HandleCreateAuthenticationStatus(c *gin.Context) {
 
 	putData := util.ToBsonM(authEvent)
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 	collName := "subscriptionData.authenticationData.authenticationStatus"
@@ -1024,8 +967,7 @@ func (s *Server) HandleQueryAuthenticationStatus(c *gin.Context) {
 	logger.DataRepoLog.Tracef("Handle QueryAuthenticationStatus")
 
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 	collName := "subscriptionData.authenticationData.authenticationStatus"
@@ -1033,35 +975,79 @@ func (s *Server) HandleQueryAuthenticationStatus(c *gin.Context) {
 	s.Processor().QueryAuthenticationStatusProcedure(c, collName, ueId)
 }
 
-// HTTPModifyAuthentication - modify the authentication subscription data of a UE
-func (s *Server) HandleModifyAuthentication(c *gin.Context) {
-	var patchItemArray []models.PatchItem
+// HTTPDeleteAuthenticationStatus - To remove the Authentication Status of a UE
+func (s *Server) HandleDeleteAuthenticationStatus(c *gin.Context) {
+	logger.DataRepoLog.Tracef("Handle DeleteAuthenticationStatus")
 
-	requestBody, err := c.GetRawData()
-	if err != nil {
-		problemDetail := models.ProblemDetails{
-			Title:  "System failure",
-			Status: http.StatusInternalServerError,
-			Detail: err.Error(),
-			Cause:  "SYSTEM_FAILURE",
-		}
-		logger.DataRepoLog.Errorf("Get Request Body error: %+v", err)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetail.Cause)
-		c.JSON(http.StatusInternalServerError, problemDetail)
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckUeIdParam(c, ueId) {
+		return
+	}
+	collName := "subscriptionData.authenticationData.authenticationStatus"
+
+	s.Processor().DeleteAuthenticationStatusProcedure(c, collName, ueId)
+}
+
+// HTTPCreateIndividualAuthenticationStatus - To store the Authentication Status data of a UE in a serving network
+func (s *Server) HandleCreateIndividualAuthenticationStatus(c *gin.Context) {
+	var authEvent models.AuthEvent
+	if err := getDataFromRequestBody(c, &authEvent); err != nil {
+		return
+	}
+
+	logger.DataRepoLog.Tracef("Handle CreateIndividualAuthenticationStatus")
+
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckUeIdParam(c, ueId) {
+		return
+	}
+	servingNetworkName := c.Params.ByName("servingNetworkName")
+	if authEvent.ServingNetworkName != "" && authEvent.ServingNetworkName != servingNetworkName {
+		pd := util.ProblemDetailsMalformedReqSyntax(fmt.Sprintf(
+			"servingNetworkName %s differs from the one of the path %s", authEvent.ServingNetworkName,
+			servingNetworkName))
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+	putData := util.ToBsonM(authEvent)
+	collName := "subscriptionData.authenticationData.individualAuthenticationStatus"
+
+	s.Processor().CreateIndividualAuthenticationStatusProcedure(c, collName, ueId, servingNetworkName, putData)
+}
+
+// HTTPQueryIndividualAuthenticationStatus - Retrieves the Authentication Status of a UE in a serving network
+func (s *Server) HandleQueryIndividualAuthenticationStatus(c *gin.Context) {
+	logger.DataRepoLog.Tracef("Handle QueryIndividualAuthenticationStatus")
+
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
+	servingNetworkName := c.Params.ByName("servingNetworkName")
+	collName := "subscriptionData.authenticationData.individualAuthenticationStatus"
 
-	err = openapi.Deserialize(&patchItemArray, requestBody, "application/json")
+	s.Processor().QueryIndividualAuthenticationStatusProcedure(c, collName, ueId, servingNetworkName)
+}
+
+// HTTPDeleteIndividualAuthenticationStatus - To remove the Authentication Status of a UE in a serving network
+func (s *Server) HandleDeleteIndividualAuthenticationStatus(c *gin.Context) {
+	logger.DataRepoLog.Tracef("Handle DeleteIndividualAuthenticationStatus")
+
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckUeIdParam(c, ueId) {
+		return
+	}
+	servingNetworkName := c.Params.ByName("servingNetworkName")
+	collName := "subscriptionData.authenticationData.individualAuthenticationStatus"
+
+	s.Processor().DeleteIndividualAuthenticationStatusProcedure(c, collName, ueId, servingNetworkName)
+}
+
+// HTTPModifyAuthentication - modify the authentication subscription data of a UE
+func (s *Server) HandleModifyAuthentication(c *gin.Context) {
+	patch, err := getPatchFromRequestBody(c, MediaTypeJSONPatch, MediaTypeMergePatch)
 	if err != nil {
-		problemDetail := "[Request Body] " + err.Error()
-		rsp := models.ProblemDetails{
-			Title:  "Malformed request syntax",
-			Status: http.StatusBadRequest,
-			Detail: problemDetail,
-		}
-		logger.DataRepoLog.Errorln(problemDetail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, http.StatusText(int(rsp.Status)))
-		c.JSON(http.StatusBadRequest, rsp)
 		return
 	}
 
@@ -1069,12 +1055,11 @@ func (s *Server) HandleModifyAuthentication(c *gin.Context) {
 
 	collName := "subscriptionData.authenticationData.authenticationSubscription"
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 
-	s.Processor().ModifyAuthenticationProcedure(c, collName, ueId, patchItemArray)
+	s.Processor().ModifyAuthenticationProcedure(c, collName, ueId, patch)
 }
 
 // HTTPQueryAuthSubsData - Retrieves the authentication subscription data of a UE
@@ -1083,51 +1068,52 @@ func (s *Server) HandleQueryAuthSubsData(c *gin.Context) {
 
 	collName := "subscriptionData.authenticationData.authenticationSubscription"
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 
 	s.Processor().QueryAuthSubsDataProcedure(c, collName, ueId)
 }
 
-// HTTPCreateAuthenticationSoR - To store the SoR acknowledgement information of a UE
-func (s *Server) HandleCreateAuthenticationSoR(c *gin.Context) {
-	var sorData models.SorData
+// HTTPIncrementSqn - Increases the SQN of the authentication subscription of a UE
+func (s *Server) HandleIncrementSqn(c *gin.Context) {
+	var sqnIncrement processor.SqnIncrement
+	if err := getDataFromRequestBody(c, &sqnIncrement); err != nil {
+		return
+	}
+	if sqnIncrement.Increment == 0 || sqnIncrement.Increment > processor.SqnMax {
+		pd := util.ProblemDetailsMalformedReqSyntax(fmt.Sprintf("increment %d should be within [1, %d]",
+			sqnIncrement.Increment, uint64(processor.SqnMax)))
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
 
-	requestBody, err := c.GetRawData()
-	if err != nil {
-		problemDetail := models.ProblemDetails{
-			Title:  "System failure",
-			Status: http.StatusInternalServerError,
-			Detail: err.Error(),
-			Cause:  "SYSTEM_FAILURE",
-		}
-		logger.DataRepoLog.Errorf("Get Request Body error: %+v", err)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetail.Cause)
-		c.JSON(http.StatusInternalServerError, problemDetail)
+	logger.DataRepoLog.Tracef("Handle IncrementSqn")
+
+	collName := "subscriptionData.authenticationData.authenticationSubscription"
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 
-	err = openapi.Deserialize(&sorData, requestBody, "application/json")
-	if err != nil {
-		problemDetail := "[Request Body] " + err.Error()
-		rsp := models.ProblemDetails{
-			Title:  "Malformed request syntax",
-			Status: http.StatusBadRequest,
-			Detail: problemDetail,
-		}
-		logger.DataRepoLog.Errorln(problemDetail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, http.StatusText(int(rsp.Status)))
-		c.JSON(http.StatusBadRequest, rsp)
+	s.Processor().IncrementSqnProcedure(c, collName, ueId, sqnIncrement.Increment)
+}
+
+// HTTPCreateAuthenticationSoR - To store the SoR acknowledgement information of a UE
+func (s *Server) HandleCreateAuthenticationSoR(c *gin.Context) {
+	var sorData processor.SorData
+	if err := getDataFromRequestBody(c, &sorData); err != nil {
+		return
+	}
+	if !checkUeUpdateCounter(c, "counterSor", sorData.CounterSor) {
 		return
 	}
 
 	logger.DataRepoLog.Tracef("Handle CreateAuthenticationSoR")
 	putData := util.ToBsonM(sorData)
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 	collName := "subscriptionData.ueUpdateConfirmationData.sorData"
@@ -1140,8 +1126,7 @@ func (s *Server) HandleQueryAuthSoR(c *gin.Context) {
 	logger.DataRepoLog.Tracef("Handle QueryAuthSoR")
 
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 	collName := "subscriptionData.ueUpdateConfirmationData.sorData"
@@ -1149,27 +1134,80 @@ func (s *Server) HandleQueryAuthSoR(c *gin.Context) {
 	s.Processor().QueryAuthSoRProcedure(c, collName, ueId)
 }
 
-// HTTPApplicationDataInfluenceDataGet -
+// HTTPCreateAuthenticationUPU - To store the UPU acknowledgement information of a UE
+func (s *Server) HandleCreateAuthenticationUPU(c *gin.Context) {
+	var upuData processor.UpuData
+	if err := getDataFromRequestBody(c, &upuData); err != nil {
+		return
+	}
+	if !checkUeUpdateCounter(c, "counterUpu", upuData.CounterUpu) {
+		return
+	}
+
+	logger.DataRepoLog.Tracef("Handle CreateAuthenticationUPU")
+	putData := util.ToBsonM(upuData)
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckUeIdParam(c, ueId) {
+		return
+	}
+	collName := "subscriptionData.ueUpdateConfirmationData.upuData"
+
+	s.Processor().CreateAuthenticationUPUProcedure(c, collName, ueId, putData)
+}
+
+// HTTPQueryAuthUPU - Retrieves the UPU acknowledgement information of a UE
+func (s *Server) HandleQueryAuthUPU(c *gin.Context) {
+	logger.DataRepoLog.Tracef("Handle QueryAuthUPU")
+
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckUeIdParam(c, ueId) {
+		return
+	}
+	collName := "subscriptionData.ueUpdateConfirmationData.upuData"
+
+	s.Processor().QueryAuthUPUProcedure(c, collName, ueId)
+}
+
+// HTTPQueryUeUpdateConfirmationData - Retrieves the SoR and the UPU acknowledgement information of a UE
+func (s *Server) HandleQueryUeUpdateConfirmationData(c *gin.Context) {
+	logger.DataRepoLog.Tracef("Handle QueryUeUpdateConfirmationData")
+
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckUeIdParam(c, ueId) {
+		return
+	}
+
+	s.Processor().QueryUeUpdateConfirmationDataProcedure(c, ueId)
+}
+
+// checkUeUpdateCounter answers 400 when the SoR or UPU counter is out of its 16 bits, and tells whether the
+// handling goes on
+func checkUeUpdateCounter(c *gin.Context, field string, counter *int32) bool {
+	if counter == nil || (*counter >= 0 && *counter <= processor.UeUpdateCounterMax) {
+		return true
+	}
+	pd := util.ProblemDetailsMalformedReqSyntax(fmt.Sprintf("%s %d should be within [0, %d]",
+		field, *counter, processor.UeUpdateCounterMax))
+	c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+	c.JSON(int(pd.Status), pd)
+	return false
+}
+
+// HTTPApplicationDataInfluenceDataGet - Retrieves the traffic influence data matching all of the filters given,
+// all of it without a filter
 func (s *Server) HandleApplicationDataInfluenceDataGet(c *gin.Context) {
 	var filter []bson.M
 	logger.DataRepoLog.Tracef("Handle ApplicationDataInfluenceDataGet")
 	collName := "applicationData.influenceData"
 
-	influenceIdsParam := c.QueryArray("influence-Ids")
-	dnnsParam := c.QueryArray("dnns")
-	internalGroupIdsParam := c.QueryArray("internal-Group-Id")
-	supisParam := c.QueryArray("supis")
-	snssaisParam := c.QueryArray("snssais")
-	if len(influenceIdsParam) != 0 {
-		influenceIds := strings.Split(influenceIdsParam[0], ",")
+	if influenceIds := listQuery(c, "influence-Ids"); len(influenceIds) != 0 {
 		filter = append(filter, bson.M{"influenceId": bson.M{"$in": influenceIds}})
 	}
-	if len(dnnsParam) != 0 {
-		dnns := strings.Split(dnnsParam[0], ",")
+	if dnns := listQuery(c, "dnns"); len(dnns) != 0 {
 		filter = append(filter, bson.M{"dnn": bson.M{"$in": dnns}})
 	}
-	if len(internalGroupIdsParam) != 0 {
-		internalGroupIds := strings.Split(internalGroupIdsParam[0], ",")
+	// The data for any UE applies to every group and SUPI
+	if internalGroupIds := listQuery(c, "internal-Group-Id"); len(internalGroupIds) != 0 {
 		withAnyUeIndFilter := []bson.M{
 			{
 				"interGroupId": bson.M{"$in": internalGroupIds},
@@ -1179,8 +1217,7 @@ func (s *Server) HandleApplicationDataInfluenceDataGet(c *gin.Context) {
 			},
 		}
 		filter = append(filter, bson.M{"$or": withAnyUeIndFilter})
-	} else if len(supisParam) != 0 {
-		supis := strings.Split(supisParam[0], ",")
+	} else if supis := listQuery(c, "supis"); len(supis) != 0 {
 		withAnyUeIndFilter := []bson.M{
 			{
 				"supi": bson.M{"$in": supis},
@@ -1191,15 +1228,23 @@ func (s *Server) HandleApplicationDataInfluenceDataGet(c *gin.Context) {
 		}
 		filter = append(filter, bson.M{"$or": withAnyUeIndFilter})
 	}
-	if len(snssaisParam) != 0 {
-		snssais := s.Processor().ParseSnssaisFromQueryParam(snssaisParam[0])
+	// The S-NSSAIs are a JSON array, they are not split on the commas
+	if snssaisParam := c.Query("snssais"); snssaisParam != "" {
+		snssais, err := s.Processor().ParseSnssaisFromQueryParam(snssaisParam)
+		if err != nil {
+			pd := processor.ProblemDetailsOf(err)
+			c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+			c.JSON(int(pd.Status), pd)
+			return
+		}
 		// NOTE: The following code would have bugs with several tries that return null value from Mongo DB, while most of
 		//       tries would be correct. The errors seem to occur only when the receiving filters on Mongo DB have reverse
 		//       orders of snssai fields, i.e. first sd then sst, even though bson.M{} is used
 		// matchList := buildSnssaiMatchList(snssais)
 		// filter = append(filter, bson.M{"snssai": bson.M{"$in": matchList}})
-		matchList := s.Processor().BuildSnssaiMatchList(snssais)
-		filter = append(filter, bson.M{"$or": matchList})
+		if matchList := s.Processor().BuildSnssaiMatchList(snssais); len(matchList) != 0 {
+			filter = append(filter, bson.M{"$or": matchList})
+		}
 	}
 	s.Processor().ApplicationDataInfluenceDataGetProcedure(c, collName, filter)
 }
@@ -1211,6 +1256,7 @@ func (s *Server) HandleApplicationDataInfluenceDataSubsToNotifySubscriptionIdDel
 	influenceId := c.Param("influenceId")
 	if influenceId != "subs-to-notify" {
 		c.String(http.StatusNotFound, "404 page not found")
+		return
 	}
 
 	subscriptionId := c.Params.ByName("subscriptionId")
@@ -1225,6 +1271,7 @@ func (s *Server) HandleApplicationDataInfluenceDataSubsToNotifySubscriptionIdGet
 	influenceId := c.Param("influenceId")
 	if influenceId != "subs-to-notify" {
 		c.String(http.StatusNotFound, "404 page not found")
+		return
 	}
 
 	subscriptionId := c.Params.ByName("subscriptionId")
@@ -1237,6 +1284,7 @@ func (s *Server) HandleApplicationDataInfluenceDataSubsToNotifySubscriptionIdPut
 	influenceId := c.Param("influenceId")
 	if influenceId != "subs-to-notify" {
 		c.String(http.StatusNotFound, "404 page not found")
+		return
 	}
 
 	// Get HTTP request body
@@ -1297,6 +1345,52 @@ func getDataFromRequestBody(c *gin.Context, data interface{}) error {
 	return err
 }
 
+// getPatchFromRequestBody reads the body of a PATCH as the patch format told by its Content-Type among
+// mediaTypes, a body of another Content-Type is read as a JSON Patch if the resource supports it
+func getPatchFromRequestBody(c *gin.Context, mediaTypes ...string) (processor.PatchDocument, error) {
+	var patch processor.PatchDocument
+
+	contentType := c.ContentType()
+	mergePatch := strings.EqualFold(contentType, MediaTypeMergePatch)
+	if (mergePatch && !isSupportedMediaType(MediaTypeMergePatch, mediaTypes)) ||
+		(!mergePatch && !isSupportedMediaType(MediaTypeJSONPatch, mediaTypes)) {
+		rejectMediaType(c, contentType, mediaTypes)
+		return patch, fmt.Errorf("unsupported Content-Type %q", contentType)
+	}
+
+	reqBody, err := c.GetRawData()
+	if err != nil {
+		logger.DataRepoLog.Errorf("Get Request Body error: %+v", err)
+		pd := util.ProblemDetailsSystemFailure(err.Error())
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return patch, err
+	}
+
+	if !mergePatch {
+		err = openapi.Deserialize(&patch.PatchItems, reqBody, "application/json")
+		if err != nil {
+			logger.DataRepoLog.Errorf("Deserialize Request Body error: %+v", err)
+			pd := util.ProblemDetailsMalformedReqSyntax("[Request Body] " + err.Error())
+			c.Set(sbi.IN_PB_DETAILS_CTX_STR, http.StatusText(int(pd.Status)))
+			c.JSON(int(pd.Status), pd)
+		}
+		return patch, err
+	}
+
+	// A merge patch that is not an object replaces the whole document, which no route allows
+	var document map[string]interface{}
+	if err = json.Unmarshal(reqBody, &document); err != nil || document == nil {
+		pd := util.ProblemDetailsMalformedReqSyntax("[Request Body] merge patch should be a JSON object")
+		logger.DataRepoLog.Errorln(pd.Detail)
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, http.StatusText(int(pd.Status)))
+		c.JSON(int(pd.Status), pd)
+		return patch, fmt.Errorf("merge patch is not a JSON object")
+	}
+	patch.MergePatch = reqBody
+	return patch, nil
+}
+
 // HTTPApplicationDataPfdsAppIdDelete -
 func (s *Server) HandleApplicationDataPfdsAppIdDelete(c *gin.Context) {
 	appID := c.Params.ByName("appId")
@@ -1310,12 +1404,17 @@ func (s *Server) HandleApplicationDataPfdsAppIdGet(c *gin.Context) {
 	appID := c.Params.ByName("appId")
 	logger.DataRepoLog.Tracef("Handle ApplicationDataPfdsAppIdGet: appID=%q", appID)
 
-	s.Processor().GetApplicationDataIndividualPfdFromDBProcedure(c, appID)
+	supportedFeatures, ok := util.SupportedFeaturesQuery(c)
+	if !ok {
+		return
+	}
+
+	s.Processor().GetApplicationDataIndividualPfdFromDBProcedure(c, appID, supportedFeatures)
 }
 
 // HTTPApplicationDataPfdsAppIdPut -
 func (s *Server) HandleApplicationDataPfdsAppIdPut(c *gin.Context) {
-	var pfdDataforApp models.PfdDataForApp
+	var pfdDataforApp models.PfdDataForAppExt
 
 	if err := getDataFromRequestBody(c, &pfdDataforApp); err != nil {
 		return
@@ -1329,13 +1428,15 @@ func (s *Server) HandleApplicationDataPfdsAppIdPut(c *gin.Context) {
 
 // HTTPApplicationDataPfdsGet -
 func (s *Server) HandleApplicationDataPfdsGet(c *gin.Context) {
-	query := c.Request.URL.Query()
-	pfdsAppIDs := query["appId"]
+	pfdsAppIDs := listQuery(c, "appId")
 	logger.DataRepoLog.Tracef("Handle ApplicationDataPfdsGet: pfdsAppIDs=%#v", pfdsAppIDs)
 
-	// TODO: Parse appID with separator ','
-	// Ex: "app1,app2,..."
-	s.Processor().GetApplicationDataPfdsFromDBProcedure(c, pfdsAppIDs)
+	supportedFeatures, ok := util.SupportedFeaturesQuery(c)
+	if !ok {
+		return
+	}
+
+	s.Processor().GetApplicationDataPfdsFromDBProcedure(c, pfdsAppIDs, supportedFeatures)
 }
 
 // HTTPExposureDataSubsToNotifyPost -
@@ -1353,335 +1454,47 @@ func (s *Server) HandleExposureDataSubsToNotifySubIdPut(c *gin.Context) {
 	c.JSON(http.StatusNotImplemented, gin.H{})
 }
 
-// HTTPPolicyDataBdtDataBdtReferenceIdDelete -
-func (s *Server) HandlePolicyDataBdtDataBdtReferenceIdDelete(c *gin.Context) {
-	logger.DataRepoLog.Tracef("Handle PolicyDataBdtDataBdtReferenceIdDelete")
-
-	collName := "policyData.bdtData"
-	bdtReferenceId := c.Params.ByName("bdtReferenceId")
-
-	s.Processor().PolicyDataBdtDataBdtReferenceIdDeleteProcedure(c, collName, bdtReferenceId)
-}
-
-// HTTPPolicyDataBdtDataBdtReferenceIdGet -
-func (s *Server) HandlePolicyDataBdtDataBdtReferenceIdGet(c *gin.Context) {
-	logger.DataRepoLog.Tracef("Handle PolicyDataBdtDataBdtReferenceIdGet")
-
-	collName := "policyData.bdtData"
-	bdtReferenceId := c.Params.ByName("bdtReferenceId")
-
-	s.Processor().PolicyDataBdtDataBdtReferenceIdGetProcedure(c, collName, bdtReferenceId)
-}
-
-// HTTPPolicyDataBdtDataBdtReferenceIdPut -
-func (s *Server) HandlePolicyDataBdtDataBdtReferenceIdPut(c *gin.Context) {
-	var bdtData models.BdtData
-
-	if err := getDataFromRequestBody(c, &bdtData); err != nil {
-		return
-	}
-	logger.DataRepoLog.Tracef("Handle PolicyDataBdtDataBdtReferenceIdPut")
-
-	collName := "policyData.bdtData"
-	bdtReferenceId := c.Params.ByName("bdtReferenceId")
-
-	s.Processor().PolicyDataBdtDataBdtReferenceIdPutProcedure(c, collName, bdtReferenceId, bdtData)
-}
-
-// HTTPPolicyDataBdtDataGet -
-func (s *Server) HandlePolicyDataBdtDataGet(c *gin.Context) {
-	logger.DataRepoLog.Tracef("Handle PolicyDataBdtDataGet")
-
-	collName := "policyData.bdtData"
-
-	s.Processor().PolicyDataBdtDataGetProcedure(c, collName)
-}
-
-// HTTPPolicyDataPlmnsPlmnIdUePolicySetGet -
-func (s *Server) HandlePolicyDataPlmnsPlmnIdUePolicySetGet(c *gin.Context) {
-	logger.DataRepoLog.Tracef("Handle PolicyDataPlmnsPlmnIdUePolicySetGet")
-
-	collName := "policyData.plmns.uePolicySet"
-	plmnId := c.Params.ByName("plmnId")
-
-	s.Processor().PolicyDataPlmnsPlmnIdUePolicySetGetProcedure(c, collName, plmnId)
-}
-
-// HTTPPolicyDataSponsorConnectivityDataSponsorIdGet -
-func (s *Server) HandlePolicyDataSponsorConnectivityDataSponsorIdGet(c *gin.Context) {
-	logger.DataRepoLog.Tracef("Handle PolicyDataSponsorConnectivityDataSponsorIdGet")
-
-	collName := "policyData.sponsorConnectivityData"
-	sponsorId := c.Params.ByName("sponsorId")
-
-	s.Processor().PolicyDataSponsorConnectivityDataSponsorIdGetProcedure(c, collName, sponsorId)
-}
-
-// HTTPPolicyDataSubsToNotifyPost -
-func (s *Server) HandlePolicyDataSubsToNotifyPost(c *gin.Context) {
-	var policyDataSubscription models.PolicyDataSubscription
-
-	reqBody, err := c.GetRawData()
-	if err != nil {
-		logger.DataRepoLog.Errorf("Get Request Body error: %+v", err)
-		pd := openapi.ProblemDetailsSystemFailure(err.Error())
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(http.StatusInternalServerError, pd)
-	}
-
-	err = openapi.Deserialize(policyDataSubscription, reqBody, "application/json")
-	if err != nil {
-		logger.DataRepoLog.Errorf("Deserialize Request Body error: %+v", err)
-		pd := util.ProblemDetailsMalformedReqSyntax(err.Error())
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(http.StatusBadRequest, pd)
-	}
-
-	logger.DataRepoLog.Tracef("Handle PolicyDataSubsToNotifyPost")
-
-	s.Processor().PolicyDataSubsToNotifyPostProcedure(c, policyDataSubscription)
-}
-
-// HTTPPolicyDataSubsToNotifySubsIdDelete -
-func (s *Server) HandlePolicyDataSubsToNotifySubsIdDelete(c *gin.Context) {
-	subsId := c.Params.ByName("subsId")
-
-	s.Processor().PolicyDataSubsToNotifySubsIdDeleteProcedure(c, subsId)
-}
-
-// HTTPPolicyDataSubsToNotifySubsIdPut -
-func (s *Server) HandlePolicyDataSubsToNotifySubsIdPut(c *gin.Context) {
-	var policyDataSubscription models.PolicyDataSubscription
-
-	reqBody, err := c.GetRawData()
-	if err != nil {
-		logger.DataRepoLog.Errorf("Get Request Body error: %+v", err)
-		pd := openapi.ProblemDetailsSystemFailure(err.Error())
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(http.StatusInternalServerError, pd)
-	}
-
-	err = openapi.Deserialize(policyDataSubscription, reqBody, "application/json")
-	if err != nil {
-		logger.DataRepoLog.Errorf("Deserialize Request Body error: %+v", err)
-		pd := util.ProblemDetailsMalformedReqSyntax(err.Error())
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(http.StatusBadRequest, pd)
-	}
-
-	logger.DataRepoLog.Tracef("Handle PolicyDataSubsToNotifySubsIdPut")
-
-	subsId := c.Params.ByName("subsId")
-
-	s.Processor().PolicyDataSubsToNotifySubsIdPutProcedure(c, subsId, policyDataSubscription)
-}
-
-// HTTPPolicyDataUesUeIdAmDataGet -
-func (s *Server) HandlePolicyDataUesUeIdAmDataGet(c *gin.Context) {
-	logger.DataRepoLog.Tracef("Handle PolicyDataUesUeIdAmDataGet")
-
-	collName := "policyData.ues.amData"
-	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
-		return
-	}
-	s.Processor().PolicyDataUesUeIdAmDataGetProcedure(c, collName, ueId)
-}
-
-// HTTPPolicyDataUesUeIdOperatorSpecificDataGet -
-func (s *Server) HandlePolicyDataUesUeIdOperatorSpecificDataGet(c *gin.Context) {
-	collName := "policyData.ues.operatorSpecificData"
-	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
-		return
-	}
-	s.Processor().PolicyDataUesUeIdOperatorSpecificDataGetProcedure(c, collName, ueId)
-}
-
-// HTTPPolicyDataUesUeIdOperatorSpecificDataPatch - Need to be fixed
-func (s *Server) HandlePolicyDataUesUeIdOperatorSpecificDataPatch(c *gin.Context) {
-	var patchItemArray []models.PatchItem
-
-	if err := getDataFromRequestBody(c, &patchItemArray); err != nil {
-		return
-	}
-
-	collName := "policyData.ues.operatorSpecificData"
-	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
-		return
-	}
-
-	s.Processor().PolicyDataUesUeIdOperatorSpecificDataPatchProcedure(c, collName, ueId, patchItemArray)
-}
-
-// HTTPPolicyDataUesUeIdOperatorSpecificDataPut -
-func (s *Server) HandlePolicyDataUesUeIdOperatorSpecificDataPut(c *gin.Context) {
-	var operatorSpecificDataContainerMap map[string]models.OperatorSpecificDataContainer
-
-	if err := getDataFromRequestBody(c, &operatorSpecificDataContainerMap); err != nil {
-		return
-	}
-
-	collName := "policyData.ues.operatorSpecificData"
-	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
-		return
-	}
-
-	s.Processor().PolicyDataUesUeIdOperatorSpecificDataPutProcedure(c, collName, ueId, operatorSpecificDataContainerMap)
-}
-
-// HTTPPolicyDataUesUeIdSmDataGet -
-func (s *Server) HandlePolicyDataUesUeIdSmDataGet(c *gin.Context) {
-	logger.DataRepoLog.Tracef("Handle PolicyDataUesUeIdSmDataGet")
-
-	collName := "policyData.ues.smData"
-	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
-		return
-	}
-	sNssai := models.Snssai{}
-	sNssaiQuery := c.Request.URL.Query().Get("snssai")
-	dnn := c.Request.URL.Query().Get("dnn")
-
-	err := json.Unmarshal([]byte(sNssaiQuery), &sNssai)
-	if err != nil {
-		logger.DataRepoLog.Warnln(err)
-	}
-	s.Processor().PolicyDataUesUeIdSmDataGetProcedure(c, collName, ueId, sNssai, dnn)
-}
-
-// HTTPPolicyDataUesUeIdSmDataPatch - Need to be fixed
-func (s *Server) HandlePolicyDataUesUeIdSmDataPatch(c *gin.Context) {
-	var usageMonDataMap map[string]models.UsageMonData
-
-	if err := getDataFromRequestBody(c, &usageMonDataMap); err != nil {
-		return
-	}
-	logger.DataRepoLog.Tracef("Handle PolicyDataUesUeIdSmDataPatch")
-
-	collName := "policyData.ues.smData.usageMonData"
-	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
-		return
-	}
-
-	s.Processor().PolicyDataUesUeIdSmDataPatchProcedure(c, collName, ueId, usageMonDataMap)
-}
-
-// HTTPPolicyDataUesUeIdSmDataUsageMonIdDelete -
-func (s *Server) HandlePolicyDataUesUeIdSmDataUsageMonIdDelete(c *gin.Context) {
-	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
-		return
-	}
-	usageMonId := c.Params.ByName("usageMonId")
-	collName := "policyData.ues.smData.usageMonData"
-
-	s.Processor().PolicyDataUesUeIdSmDataUsageMonIdDeleteProcedure(c, collName, ueId, usageMonId)
-}
-
-// HTTPPolicyDataUesUeIdSmDataUsageMonIdGet -
-func (s *Server) HandlePolicyDataUesUeIdSmDataUsageMonIdGet(c *gin.Context) {
-	collName := "policyData.ues.smData.usageMonData"
-	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
-		return
-	}
-	usageMonId := c.Params.ByName("usageMonId")
-
-	s.Processor().PolicyDataUesUeIdSmDataUsageMonIdGetProcedure(c, collName, usageMonId, ueId)
-}
-
-// HTTPPolicyDataUesUeIdSmDataUsageMonIdPut -
-func (s *Server) HandlePolicyDataUesUeIdSmDataUsageMonIdPut(c *gin.Context) {
-	var usageMonData models.UsageMonData
-
-	if err := getDataFromRequestBody(c, &usageMonData); err != nil {
-		return
-	}
-	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
-		return
-	}
-	usageMonId := c.Params.ByName("usageMonId")
-	collName := "policyData.ues.smData.usageMonData"
-
-	s.Processor().PolicyDataUesUeIdSmDataUsageMonIdPutProcedure(c, collName, ueId, usageMonId, usageMonData)
-}
-
-// HTTPPolicyDataUesUeIdUePolicySetGet -
-func (s *Server) HandlePolicyDataUesUeIdUePolicySetGet(c *gin.Context) {
-	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
-		return
-	}
-	collName := "policyData.ues.uePolicySet"
-
-	s.Processor().PolicyDataUesUeIdUePolicySetGetProcedure(c, collName, ueId)
-}
-
-// HTTPPolicyDataUesUeIdUePolicySetPatch -
-func (s *Server) HandlePolicyDataUesUeIdUePolicySetPatch(c *gin.Context) {
-	var uePolicySet models.UePolicySet
-
-	if err := getDataFromRequestBody(c, &uePolicySet); err != nil {
-		return
-	}
-
-	collName := "policyData.ues.uePolicySet"
-	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
-		return
-	}
-
-	s.Processor().PolicyDataUesUeIdUePolicySetPatchProcedure(c, collName, ueId, uePolicySet)
-}
-
-// HTTPPolicyDataUesUeIdUePolicySetPut -
-func (s *Server) HandlePolicyDataUesUeIdUePolicySetPut(c *gin.Context) {
-	var uePolicySet models.UePolicySet
-
-	if err := getDataFromRequestBody(c, &uePolicySet); err != nil {
-		return
-	}
-
-	collName := "policyData.ues.uePolicySet"
-	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
-		return
-	}
-
-	s.Processor().PolicyDataUesUeIdUePolicySetPutProcedure(c, collName, ueId, uePolicySet)
-}
-
 // HTTPQueryProvisionedData - Retrieve multiple provisioned data sets of a UE
 func (s *Server) HandleQueryProvisionedData(c *gin.Context) {
 	logger.DataRepoLog.Tracef("Handle QueryProvisionedData")
 
 	var provisionedDataSets models.ProvisionedDataSets
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 
 	servingPlmnId := c.Params.ByName("servingPlmnId")
+	if !util.CheckServingPlmnIdParam(c, servingPlmnId) {
+		return
+	}
+	dataSetNames, ok := provisionedDataSetNamesQuery(c)
+	if !ok {
+		return
+	}
+
+	s.Processor().QueryProvisionedDataProcedure(c, ueId, servingPlmnId, provisionedDataSets, dataSetNames)
+}
 
-	s.Processor().QueryProvisionedDataProcedure(c, ueId, servingPlmnId, provisionedDataSets)
+// provisionedDataSetNamesQuery returns the data sets of the dataset-names query parameter, either repeated or
+// comma separated. It answers 400 for the names outside processor.ProvisionedDataSetNames, and tells whether
+// the handling goes on.
+func provisionedDataSetNamesQuery(c *gin.Context) ([]models.DataSetName, bool) {
+	var dataSetNames []models.DataSetName
+	for _, param := range c.QueryArray("dataset-names") {
+		for _, name := range strings.Split(param, ",") {
+			dataSetName := models.DataSetName(name)
+			if !slices.Contains(processor.ProvisionedDataSetNames, dataSetName) {
+				pd := util.ProblemDetailsMalformedReqSyntax(
+					fmt.Sprintf("dataset-names: %q is not a data set of the provisioned data", name))
+				c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+				c.JSON(int(pd.Status), pd)
+				return nil, false
+			}
+			dataSetNames = append(dataSetNames, dataSetName)
+		}
+	}
+	return dataSetNames, true
 }
 
 // HTTPRemovesdmSubscriptions - Deletes a sdmsubscriptions
@@ -1689,8 +1502,7 @@ func (s *Server) HandleRemovesdmSubscriptions(c *gin.Context) {
 	logger.DataRepoLog.Tracef("Handle RemovesdmSubscriptions")
 
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 	subsId := c.Params.ByName("subsId")
@@ -1733,8 +1545,7 @@ func (s *Server) HandleUpdatesdmsubscriptions(c *gin.Context) {
 	logger.DataRepoLog.Tracef("Handle Updatesdmsubscriptions")
 
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 	subsId := c.Params.ByName("subsId")
@@ -1742,6 +1553,24 @@ func (s *Server) HandleUpdatesdmsubscriptions(c *gin.Context) {
 	s.Processor().UpdatesdmsubscriptionsProcedure(c, ueId, subsId, sdmSubscription)
 }
 
+// HTTPModifysdmsubscription - Modifies an individual sdm subscription of a UE, such as its expiry
+func (s *Server) HandleModifysdmsubscription(c *gin.Context) {
+	patch, err := getPatchFromRequestBody(c, MediaTypeJSONPatch, MediaTypeMergePatch)
+	if err != nil {
+		return
+	}
+
+	logger.DataRepoLog.Tracef("Handle Modifysdmsubscription")
+
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckUeIdParam(c, ueId) {
+		return
+	}
+	subsId := c.Params.ByName("subsId")
+
+	s.Processor().ModifysdmsubscriptionProcedure(c, ueId, subsId, patch)
+}
+
 // HTTPCreateSdmSubscriptions - Create individual sdm subscription
 func (s *Server) HandleCreateSdmSubscriptions(c *gin.Context) {
 	var sdmSubscription models.SdmSubscription
@@ -1778,8 +1607,7 @@ func (s *Server) HandleCreateSdmSubscriptions(c *gin.Context) {
 
 	collName := "subscriptionData.contextData.amfNon3gppAccess"
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 
@@ -1791,8 +1619,7 @@ func (s *Server) HandleQuerysdmsubscriptions(c *gin.Context) {
 	logger.DataRepoLog.Tracef("Handle Querysdmsubscriptions")
 
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 
@@ -1835,13 +1662,19 @@ func (s *Server) HandleCreateSmfContextNon3gpp(c *gin.Context) {
 
 	collName := "subscriptionData.contextData.smfRegistrations"
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
-	pduSessionId, err := strconv.ParseInt(c.Param("pduSessionId"), 10, 64)
-	if err != nil {
-		logger.DataRepoLog.Warnln(err)
+	pduSessionId, ok := util.CheckPduSessionIdParam(c, c.Params.ByName("pduSessionId"))
+	if !ok {
+		return
+	}
+	if smfRegistration.PduSessionId != pduSessionId {
+		pd := util.ProblemDetailsMalformedReqSyntax(fmt.Sprintf(
+			"pduSessionId %d of the body differs from the pduSessionId of the URI", smfRegistration.PduSessionId))
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
 	}
 
 	s.Processor().CreateSmfContextNon3gppProcedure(c, smfRegistration, collName, ueId, pduSessionId)
@@ -1853,11 +1686,13 @@ func (s *Server) HandleDeleteSmfContext(c *gin.Context) {
 
 	collName := "subscriptionData.contextData.smfRegistrations"
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
+		return
+	}
+	pduSessionId, ok := util.CheckPduSessionIdParam(c, c.Params.ByName("pduSessionId"))
+	if !ok {
 		return
 	}
-	pduSessionId := c.Params.ByName("pduSessionId")
 
 	s.Processor().DeleteSmfContextProcedure(c, collName, ueId, pduSessionId)
 }
@@ -1867,11 +1702,13 @@ func (s *Server) HandleQuerySmfRegistration(c *gin.Context) {
 	logger.DataRepoLog.Tracef("Handle QuerySmfRegistration")
 
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
+		return
+	}
+	pduSessionId, ok := util.CheckPduSessionIdParam(c, c.Params.ByName("pduSessionId"))
+	if !ok {
 		return
 	}
-	pduSessionId := c.Params.ByName("pduSessionId")
 	collName := "subscriptionData.contextData.smfRegistrations"
 
 	s.Processor().QuerySmfRegistrationProcedure(c, collName, ueId, pduSessionId)
@@ -1883,25 +1720,31 @@ func (s *Server) HandleQuerySmfRegList(c *gin.Context) {
 
 	collName := "subscriptionData.contextData.smfRegistrations"
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 	s.Processor().QuerySmfRegListProcedure(c, collName, ueId)
 }
 
-// HTTPQuerySmfSelectData - Retrieves the SMF selection subscription data of a UE
+// HTTPQuerySmfSelectData - Retrieves the SMF selection subscription data of a UE, the members of fields only
+// when given
 func (s *Server) HandleQuerySmfSelectData(c *gin.Context) {
 	logger.DataRepoLog.Tracef("Handle QuerySmfSelectData")
 
 	collName := "subscriptionData.provisionedData.smfSelectionSubscriptionData"
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 	servingPlmnId := c.Params.ByName("servingPlmnId")
-	s.Processor().QuerySmfSelectDataProcedure(c, collName, ueId, servingPlmnId)
+	if !util.CheckServingPlmnIdParam(c, servingPlmnId) {
+		return
+	}
+	fields, ok := util.FieldsQuery(c, models.SmfSelectionSubscriptionData{})
+	if !ok {
+		return
+	}
+	s.Processor().QuerySmfSelectDataProcedure(c, collName, ueId, servingPlmnId, fields)
 }
 
 // HTTPCreateSmsfContext3gpp - Create the SMSF context data of a UE via 3GPP access
@@ -1940,8 +1783,7 @@ func (s *Server) HandleCreateSmsfContext3gpp(c *gin.Context) {
 
 	collName := "subscriptionData.contextData.smsf3gppAccess"
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 
@@ -1954,8 +1796,7 @@ func (s *Server) HandleDeleteSmsfContext3gpp(c *gin.Context) {
 
 	collName := "subscriptionData.contextData.smsf3gppAccess"
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 
@@ -1968,8 +1809,7 @@ func (s *Server) HandleQuerySmsfContext3gpp(c *gin.Context) {
 
 	collName := "subscriptionData.contextData.smsf3gppAccess"
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 
@@ -2012,8 +1852,7 @@ func (s *Server) HandleCreateSmsfContextNon3gpp(c *gin.Context) {
 
 	collName := "subscriptionData.contextData.smsfNon3gppAccess"
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 
@@ -2026,8 +1865,7 @@ func (s *Server) HandleDeleteSmsfContextNon3gpp(c *gin.Context) {
 
 	collName := "subscriptionData.contextData.smsfNon3gppAccess"
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 
@@ -2039,8 +1877,7 @@ func (s *Server) HandleQuerySmsfContextNon3gpp(c *gin.Context) {
 	logger.DataRepoLog.Tracef("Handle QuerySmsfContextNon3gpp")
 
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 	collName := "subscriptionData.contextData.smsfNon3gppAccess"
@@ -2054,12 +1891,109 @@ func (s *Server) HandleQuerySmsMngData(c *gin.Context) {
 
 	collName := "subscriptionData.provisionedData.smsMngData"
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
+		return
+	}
+	servingPlmnId := c.Params.ByName("servingPlmnId")
+	if !util.CheckServingPlmnIdParam(c, servingPlmnId) {
+		return
+	}
+	supportedFeatures, ok := util.SupportedFeaturesQuery(c)
+	if !ok {
+		return
+	}
+
+	s.Processor().QuerySmsMngDataProcedure(c, collName, ueId, servingPlmnId, supportedFeatures)
+}
+
+// HTTPCreateSmsMngData - To store the SMS management subscription data of a UE
+func (s *Server) HandleCreateSmsMngData(c *gin.Context) {
+	var smsMngData models.SmsManagementSubscriptionData
+	if err := getDataFromRequestBody(c, &smsMngData); err != nil {
+		return
+	}
+
+	logger.DataRepoLog.Tracef("Handle CreateSmsMngData")
+
+	collName := "subscriptionData.provisionedData.smsMngData"
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 	servingPlmnId := c.Params.ByName("servingPlmnId")
-	s.Processor().QuerySmsMngDataProcedure(c, collName, ueId, servingPlmnId)
+	if !util.CheckServingPlmnIdParam(c, servingPlmnId) {
+		return
+	}
+
+	s.Processor().CreateSmsMngDataProcedure(c, collName, ueId, servingPlmnId, smsMngData)
+}
+
+// HTTPQueryLcsPrivacyData - Retrieves the LCS privacy data of a UE
+func (s *Server) HandleQueryLcsPrivacyData(c *gin.Context) {
+	logger.DataRepoLog.Tracef("Handle QueryLcsPrivacyData")
+
+	collName := "subscriptionData.provisionedData.lcsPrivacyData"
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckUeIdParam(c, ueId) {
+		return
+	}
+
+	s.Processor().QueryLcsPrivacyDataProcedure(c, collName, ueId, listQuery(c, "fields"))
+}
+
+// HTTPQueryLcsMoData - Retrieves the LCS mobile originated data of a UE
+func (s *Server) HandleQueryLcsMoData(c *gin.Context) {
+	logger.DataRepoLog.Tracef("Handle QueryLcsMoData")
+
+	collName := "subscriptionData.provisionedData.lcsMoData"
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckUeIdParam(c, ueId) {
+		return
+	}
+
+	s.Processor().QueryLcsMoDataProcedure(c, collName, ueId, listQuery(c, "fields"))
+}
+
+// HTTPQueryV2xData - Retrieves the V2X subscription data of a UE
+func (s *Server) HandleQueryV2xData(c *gin.Context) {
+	logger.DataRepoLog.Tracef("Handle QueryV2xData")
+
+	collName := "subscriptionData.provisionedData.v2xData"
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckUeIdParam(c, ueId) {
+		return
+	}
+
+	s.Processor().QueryV2xDataProcedure(c, collName, ueId)
+}
+
+// HTTPQueryProseData - Retrieves the ProSe subscription data of a UE
+func (s *Server) HandleQueryProseData(c *gin.Context) {
+	logger.DataRepoLog.Tracef("Handle QueryProseData")
+
+	collName := "subscriptionData.provisionedData.proseData"
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckUeIdParam(c, ueId) {
+		return
+	}
+	if _, ok := util.SupportedFeaturesQuery(c); !ok {
+		return
+	}
+
+	s.Processor().QueryProseDataProcedure(c, collName, ueId)
+}
+
+// listQuery returns the values of the array query parameter name, either repeated or comma separated
+func listQuery(c *gin.Context, name string) []string {
+	var values []string
+	for _, param := range c.QueryArray(name) {
+		for _, value := range strings.Split(param, ",") {
+			if value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
 }
 
 // HTTPQuerySmsData - Retrieves the SMS subscription data of a UE
@@ -2067,36 +2001,76 @@ func (s *Server) HandleQuerySmsData(c *gin.Context) {
 	logger.DataRepoLog.Tracef("Handle QuerySmsData")
 
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 	servingPlmnId := c.Params.ByName("servingPlmnId")
+	if !util.CheckServingPlmnIdParam(c, servingPlmnId) {
+		return
+	}
 	collName := "subscriptionData.provisionedData.smsData"
 
-	s.Processor().QuerySmsDataProcedure(c, collName, ueId, servingPlmnId)
+	supportedFeatures, ok := util.SupportedFeaturesQuery(c)
+	if !ok {
+		return
+	}
+
+	s.Processor().QuerySmsDataProcedure(c, collName, ueId, servingPlmnId, supportedFeatures)
 }
 
-// HTTPQuerySmData - Retrieves the Session Management subscription data of a UE
+// HTTPQuerySmData - Retrieves the Session Management subscription data of a UE, of the S-NSSAI of single-nssai
+// and the DNN of dnn only when given, with fields the members of fields only along with the singleNssai of each,
+// negotiating the features of supported-features as am-data
 func (s *Server) HandleQuerySmData(c *gin.Context) {
 	logger.DataRepoLog.Tracef("Handle QuerySmData")
 
 	collName := "subscriptionData.provisionedData.smData"
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 	servingPlmnId := c.Params.ByName("servingPlmnId")
-	singleNssai := models.Snssai{}
-	singleNssaiQuery := c.Query("single-nssai")
-	err := json.Unmarshal([]byte(singleNssaiQuery), &singleNssai)
-	if err != nil {
-		logger.DataRepoLog.Warnln(err)
+	if !util.CheckServingPlmnIdParam(c, servingPlmnId) {
+		return
+	}
+	singleNssai, ok := util.SingleNssaiQuery(c)
+	if !ok {
+		return
 	}
 
 	dnn := c.Query("dnn")
-	s.Processor().QuerySmDataProcedure(c, collName, ueId, servingPlmnId, singleNssai, dnn)
+	supportedFeatures, ok := util.SupportedFeaturesQuery(c)
+	if !ok {
+		return
+	}
+	fields, ok := util.FieldsQuery(c, models.SessionManagementSubscriptionData{})
+	if !ok {
+		return
+	}
+	s.Processor().QuerySmDataProcedure(c, collName, ueId, servingPlmnId, singleNssai, dnn, supportedFeatures, fields)
+}
+
+// HTTPModifySmData - Modifies the DNN configurations of the Session Management subscription data of a UE by a
+// JSON Patch, each operation addressing the configuration of a DNN of an S-NSSAI
+func (s *Server) HandleModifySmData(c *gin.Context) {
+	patch, err := getPatchFromRequestBody(c, MediaTypeJSONPatch)
+	if err != nil {
+		return
+	}
+
+	logger.DataRepoLog.Tracef("Handle ModifySmData")
+
+	collName := "subscriptionData.provisionedData.smData"
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckUeIdParam(c, ueId) {
+		return
+	}
+	servingPlmnId := c.Params.ByName("servingPlmnId")
+	if !util.CheckServingPlmnIdParam(c, servingPlmnId) {
+		return
+	}
+
+	s.Processor().ModifySmDataProcedure(c, collName, ueId, servingPlmnId, patch.PatchItems)
 }
 
 // HTTPQueryTraceData - Retrieves the trace configuration data of a UE
@@ -2105,11 +2079,13 @@ func (s *Server) HandleQueryTraceData(c *gin.Context) {
 
 	collName := "subscriptionData.provisionedData.traceData"
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 	servingPlmnId := c.Params.ByName("servingPlmnId")
+	if !util.CheckServingPlmnIdParam(c, servingPlmnId) {
+		return
+	}
 
 	s.Processor().QueryTraceDataProcedure(c, collName, ueId, servingPlmnId)
 }
@@ -2149,8 +2125,7 @@ func (s *Server) HandleCreateAMFSubscriptions(c *gin.Context) {
 	logger.DataRepoLog.Tracef("Handle CreateAMFSubscriptions")
 
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckEeUeIdParam(c, ueId) {
 		return
 	}
 	subsId := c.Params.ByName("subsId")
@@ -2163,8 +2138,7 @@ func (s *Server) HandleRemoveAmfSubscriptionsInfo(c *gin.Context) {
 	logger.DataRepoLog.Tracef("Handle RemoveAmfSubscriptionsInfo")
 
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckEeUeIdParam(c, ueId) {
 		return
 	}
 	subsId := c.Params.ByName("subsId")
@@ -2174,46 +2148,20 @@ func (s *Server) HandleRemoveAmfSubscriptionsInfo(c *gin.Context) {
 
 // HTTPModifyAmfSubscriptionInfo - modify the AMF Subscription Info
 func (s *Server) HandleModifyAmfSubscriptionInfo(c *gin.Context) {
-	var patchItemArray []models.PatchItem
-
-	requestBody, err := c.GetRawData()
-	if err != nil {
-		problemDetail := models.ProblemDetails{
-			Title:  "System failure",
-			Status: http.StatusInternalServerError,
-			Detail: err.Error(),
-			Cause:  "SYSTEM_FAILURE",
-		}
-		logger.DataRepoLog.Errorf("Get Request Body error: %+v", err)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetail.Cause)
-		c.JSON(http.StatusInternalServerError, problemDetail)
-		return
-	}
-
-	err = openapi.Deserialize(&patchItemArray, requestBody, "application/json")
+	patch, err := getPatchFromRequestBody(c, MediaTypeJSONPatch)
 	if err != nil {
-		problemDetail := "[Request Body] " + err.Error()
-		rsp := models.ProblemDetails{
-			Title:  "Malformed request syntax",
-			Status: http.StatusBadRequest,
-			Detail: problemDetail,
-		}
-		logger.DataRepoLog.Errorln(problemDetail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, http.StatusText(int(rsp.Status)))
-		c.JSON(http.StatusBadRequest, rsp)
 		return
 	}
 
 	logger.DataRepoLog.Tracef("Handle ModifyAmfSubscriptionInfo")
 
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckEeUeIdParam(c, ueId) {
 		return
 	}
 	subsId := c.Params.ByName("subsId")
 
-	s.Processor().ModifyAmfSubscriptionInfoProcedure(c, ueId, subsId, patchItemArray)
+	s.Processor().ModifyAmfSubscriptionInfoProcedure(c, ueId, subsId, patch.PatchItems)
 }
 
 // HTTPGetAmfSubscriptionInfo - Retrieve AMF subscription Info
@@ -2221,8 +2169,7 @@ func (s *Server) HandleGetAmfSubscriptionInfo(c *gin.Context) {
 	logger.DataRepoLog.Tracef("Handle GetAmfSubscriptionInfo")
 
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckEeUeIdParam(c, ueId) {
 		return
 	}
 	subsId := c.Params.ByName("subsId")
@@ -2230,23 +2177,38 @@ func (s *Server) HandleGetAmfSubscriptionInfo(c *gin.Context) {
 	s.Processor().GetAmfSubscriptionInfoProcedure(c, subsId, ueId)
 }
 
-// HandleGetSharedData - retrieve shared data
+// HandleGetSharedData - retrieve shared data. The shared data have no optional feature, the supported features
+// of the consumer are only checked.
 func (s *Server) HandleGetSharedData(c *gin.Context) {
-	sharedDataIdArray := c.QueryArray("shared-data-ids")
 	logger.DataRepoLog.Tracef("Handle GetSharedData")
 
-	var sharedDataIds []string
-	if len(sharedDataIdArray) != 0 {
-		sharedDataIds = sharedDataIdArray
-		if strings.Contains(sharedDataIds[0], ",") {
-			sharedDataIds = strings.Split(sharedDataIds[0], ",")
-		}
+	sharedDataIds, ok := util.SharedDataIdsQuery(c)
+	if !ok {
+		return
+	}
+	if _, ok = util.SupportedFeaturesQuery(c, "supportedFeatures"); !ok {
+		return
 	}
+
 	collName := "subscriptionData.sharedData"
 
 	s.Processor().GetSharedDataProcedure(c, collName, sharedDataIds)
 }
 
+// HandleGetIndividualSharedData - retrieve the individual shared data
+func (s *Server) HandleGetIndividualSharedData(c *gin.Context) {
+	logger.DataRepoLog.Tracef("Handle GetIndividualSharedData")
+
+	if _, ok := util.SupportedFeaturesQuery(c, "supportedFeatures"); !ok {
+		return
+	}
+
+	collName := "subscriptionData.sharedData"
+	sharedDataId := c.Params.ByName("sharedDataId")
+
+	s.Processor().GetIndividualSharedDataProcedure(c, collName, sharedDataId)
+}
+
 // HandlePostSubscriptionDataSubscriptions - Subscription data subscriptions
 func (s *Server) HandlePostSubscriptionDataSubscriptions(c *gin.Context) {
 	var subscriptionDataSubscriptions models.SubscriptionDataSubscriptions
@@ -2294,8 +2256,7 @@ func (s *Server) HandleQueryEEData(c *gin.Context) {
 	logger.DataRepoLog.Tracef("Handle QueryEEData")
 
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 	collName := "subscriptionData.eeProfileData"
@@ -2303,35 +2264,10 @@ func (s *Server) HandleQueryEEData(c *gin.Context) {
 	s.Processor().QueryEEDataProcedure(c, collName, ueId)
 }
 
-// HTTPAmfContext3gpp - To modify operator specific data of a UE
+// HTTPPatchOperSpecData - To modify operator specific data of a UE
 func (s *Server) HandlePatchOperSpecData(c *gin.Context) {
-	var patchItemArray []models.PatchItem
-
-	requestBody, err := c.GetRawData()
-	if err != nil {
-		problemDetail := models.ProblemDetails{
-			Title:  "System failure",
-			Status: http.StatusInternalServerError,
-			Detail: err.Error(),
-			Cause:  "SYSTEM_FAILURE",
-		}
-		logger.DataRepoLog.Errorf("Get Request Body error: %+v", err)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetail.Cause)
-		c.JSON(http.StatusInternalServerError, problemDetail)
-		return
-	}
-
-	err = openapi.Deserialize(&patchItemArray, requestBody, "application/json")
+	patch, err := getPatchFromRequestBody(c, MediaTypeJSONPatch, MediaTypeMergePatch)
 	if err != nil {
-		problemDetail := "[Request Body] " + err.Error()
-		rsp := models.ProblemDetails{
-			Title:  "Malformed request syntax",
-			Status: http.StatusBadRequest,
-			Detail: problemDetail,
-		}
-		logger.DataRepoLog.Errorln(problemDetail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, http.StatusText(int(rsp.Status)))
-		c.JSON(http.StatusBadRequest, rsp)
 		return
 	}
 
@@ -2339,12 +2275,11 @@ func (s *Server) HandlePatchOperSpecData(c *gin.Context) {
 
 	collName := "subscriptionData.operatorSpecificData"
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 
-	s.Processor().PatchOperSpecDataProcedure(c, collName, ueId, patchItemArray)
+	s.Processor().PatchOperSpecDataProcedure(c, collName, ueId, patch)
 }
 
 // HTTPQueryOperSpecData - Retrieves the operator specific data of a UE
@@ -2352,13 +2287,46 @@ func (s *Server) HandleQueryOperSpecData(c *gin.Context) {
 	logger.DataRepoLog.Tracef("Handle QueryOperSpecData")
 
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
+		return
+	}
+	if _, ok := util.SupportedFeaturesQuery(c); !ok {
+		return
+	}
+	collName := "subscriptionData.operatorSpecificData"
+
+	s.Processor().QueryOperSpecDataProcedure(c, collName, ueId, listQuery(c, "fields"))
+}
+
+// HTTPCreateOperSpecData - To store the operator specific data of a UE, a map of containers by data name
+func (s *Server) HandleCreateOperSpecData(c *gin.Context) {
+	var operSpecData map[string]models.OperatorSpecificDataContainer
+	if err := getDataFromRequestBody(c, &operSpecData); err != nil {
+		return
+	}
+
+	logger.DataRepoLog.Tracef("Handle CreateOperSpecData")
+
+	collName := "subscriptionData.operatorSpecificData"
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
+
+	s.Processor().CreateOperSpecDataProcedure(c, collName, ueId, operSpecData)
+}
+
+// HTTPDeleteOperSpecData - To remove the operator specific data of a UE
+func (s *Server) HandleDeleteOperSpecData(c *gin.Context) {
+	logger.DataRepoLog.Tracef("Handle DeleteOperSpecData")
+
 	collName := "subscriptionData.operatorSpecificData"
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckUeIdParam(c, ueId) {
+		return
+	}
 
-	s.Processor().QueryOperSpecDataProcedure(c, collName, ueId)
+	s.Processor().DeleteOperSpecDataProcedure(c, collName, ueId)
 }
 
 // HTTPGetppData - Read the profile of a given UE
@@ -2367,8 +2335,7 @@ func (s *Server) HandleGetppData(c *gin.Context) {
 
 	collName := "subscriptionData.ppData"
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 
@@ -2377,43 +2344,17 @@ func (s *Server) HandleGetppData(c *gin.Context) {
 
 // HTTPModifyPpData - modify the provisioned parameter data
 func (s *Server) HandleModifyPpData(c *gin.Context) {
-	var patchItemArray []models.PatchItem
-
-	requestBody, err := c.GetRawData()
-	if err != nil {
-		problemDetail := models.ProblemDetails{
-			Title:  "System failure",
-			Status: http.StatusInternalServerError,
-			Detail: err.Error(),
-			Cause:  "SYSTEM_FAILURE",
-		}
-		logger.DataRepoLog.Errorf("Get Request Body error: %+v", err)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetail.Cause)
-		c.JSON(http.StatusInternalServerError, problemDetail)
-		return
-	}
-
-	err = openapi.Deserialize(&patchItemArray, requestBody, "application/json")
+	patch, err := getPatchFromRequestBody(c, MediaTypeJSONPatch, MediaTypeMergePatch)
 	if err != nil {
-		problemDetail := "[Request Body] " + err.Error()
-		rsp := models.ProblemDetails{
-			Title:  "Malformed request syntax",
-			Status: http.StatusBadRequest,
-			Detail: problemDetail,
-		}
-		logger.DataRepoLog.Errorln(problemDetail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, http.StatusText(int(rsp.Status)))
-		c.JSON(http.StatusBadRequest, rsp)
 		return
 	}
 	collName := "subscriptionData.ppData"
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 
-	s.Processor().ModifyPpDataProcedure(c, collName, ueId, patchItemArray)
+	s.Processor().ModifyPpDataProcedure(c, collName, ueId, patch)
 }
 
 // HTTPGetIdentityData - Retrieve identity data by SUPI or GPSI
@@ -2421,8 +2362,7 @@ func (s *Server) HandleGetIdentityData(c *gin.Context) {
 	logger.DataRepoLog.Tracef("Handle GetIdentityData")
 
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 	collName := "subscriptionData.identityData"
@@ -2435,8 +2375,7 @@ func (s *Server) HandleGetOdbData(c *gin.Context) {
 	logger.DataRepoLog.Tracef("Handle GetOdbData")
 
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckUeIdParam(c, ueId) {
 		return
 	}
 	collName := "subscriptionData.operatorDeterminedBarringData"
@@ -2527,7 +2466,7 @@ func (s *Server) HandleQueryEeGroupSubscriptions(c *gin.Context) {
 
 // HTTPCreateEeSubscriptions - Create individual EE subscription
 func (s *Server) HandleCreateEeSubscriptions(c *gin.Context) {
-	var eeSubscription models.EeSubscription
+	var eeSubscription models.UdmEeEeSubscription
 
 	requestBody, err := c.GetRawData()
 	if err != nil {
@@ -2559,30 +2498,10 @@ func (s *Server) HandleCreateEeSubscriptions(c *gin.Context) {
 
 	logger.DataRepoLog.Tracef("Handle CreateEeSubscriptions")
 
-	// String represents the SUPI or GPSI.
-	// Pattern: "^(imsi-[0-9]{5,15}|nai-.+|msisdn-[0-9]{5,15}|extid-[^@]+@[^@]+|gci-.+|gli-.+|.+)$".
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
-		return
-	}
-	match, err := regexp.MatchString(
-		"^(imsi-[0-9]{5,15}|nai-.+|msisdn-[0-9]{5,15}|extid-[^@]+@[^@]+|gci-.+|gli-.+|.+)$", ueId)
-	if !match {
-		problemDetail := models.ProblemDetails{
-			Title:  "Invalid parameter",
-			Status: http.StatusBadRequest,
-			Detail: "Invalid ueId",
-			Cause:  "INVALID_PARAMETER",
-		}
-		logger.DataRepoLog.Errorf("Invalid ueId: %s", ueId)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetail.Cause)
-		c.JSON(http.StatusBadRequest, problemDetail)
+	if !util.CheckEeUeIdParam(c, ueId) {
 		return
 	}
-	if err != nil {
-		logger.DataRepoLog.Errorf("Invalid regular expression: %s", err)
-	}
 
 	s.Processor().CreateEeSubscriptionsProcedure(c, ueId, eeSubscription)
 }
@@ -2591,30 +2510,10 @@ func (s *Server) HandleCreateEeSubscriptions(c *gin.Context) {
 func (s *Server) HandleQueryeesubscriptions(c *gin.Context) {
 	logger.DataRepoLog.Tracef("Handle Queryeesubscriptions")
 
-	// String represents the SUPI or GPSI.
-	// Pattern: "^(imsi-[0-9]{5,15}|nai-.+|msisdn-[0-9]{5,15}|extid-[^@]+@[^@]+|gci-.+|gli-.+|.+)$".
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
-		return
-	}
-	match, err := regexp.MatchString(
-		"^(imsi-[0-9]{5,15}|nai-.+|msisdn-[0-9]{5,15}|extid-[^@]+@[^@]+|gci-.+|gli-.+|.+)$", ueId)
-	if !match {
-		problemDetail := models.ProblemDetails{
-			Title:  "Invalid parameter",
-			Status: http.StatusBadRequest,
-			Detail: "Invalid ueId",
-			Cause:  "INVALID_PARAMETER",
-		}
-		logger.DataRepoLog.Errorf("Invalid ueId: %s", ueId)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetail.Cause)
-		c.JSON(http.StatusBadRequest, problemDetail)
+	if !util.CheckEeUeIdParam(c, ueId) {
 		return
 	}
-	if err != nil {
-		logger.DataRepoLog.Errorf("Invalid regular expression: %s", err)
-	}
 
 	s.Processor().QueryeesubscriptionsProcedure(c, ueId)
 }
@@ -2624,8 +2523,7 @@ func (s *Server) HandleRemoveeeSubscriptions(c *gin.Context) {
 	logger.DataRepoLog.Tracef("Handle RemoveeeSubscriptions")
 
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckEeUeIdParam(c, ueId) {
 		return
 	}
 
@@ -2634,9 +2532,22 @@ func (s *Server) HandleRemoveeeSubscriptions(c *gin.Context) {
 	s.Processor().RemoveeeSubscriptionsProcedure(c, ueId, subsId)
 }
 
+// HTTPQueryeeSubscription - Retrieves an individual ee subscription of a UE
+func (s *Server) HandleQueryeeSubscription(c *gin.Context) {
+	logger.DataRepoLog.Tracef("Handle QueryeeSubscription")
+
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckEeUeIdParam(c, ueId) {
+		return
+	}
+	subsId := c.Params.ByName("subsId")
+
+	s.Processor().QueryeeSubscriptionProcedure(c, ueId, subsId)
+}
+
 // HTTPUpdateEesubscriptions - Stores an individual ee subscriptions of a UE
 func (s *Server) HandleUpdateEesubscriptions(c *gin.Context) {
-	var eeSubscription models.EeSubscription
+	var eeSubscription models.UdmEeEeSubscription
 
 	requestBody, err := c.GetRawData()
 	if err != nil {
@@ -2668,8 +2579,7 @@ func (s *Server) HandleUpdateEesubscriptions(c *gin.Context) {
 	logger.DataRepoLog.Tracef("Handle UpdateEesubscriptions")
 
 	ueId := c.Params.ByName("ueId")
-	if ueId == "" {
-		util.EmptyUeIdProblemJson(c)
+	if !util.CheckEeUeIdParam(c, ueId) {
 		return
 	}
 	subsId := c.Params.ByName("subsId")
@@ -2778,6 +2688,7 @@ func (s *Server) HandleApplicationDataInfluenceDataSubsToNotifyGet(c *gin.Contex
 			}
 			c.Set(sbi.IN_PB_DETAILS_CTX_STR, http.StatusText(int(problemDetails.Status)))
 			c.JSON(http.StatusBadRequest, problemDetails)
+			return
 		}
 	}
 
@@ -2788,6 +2699,7 @@ func (s *Server) HandleApplicationDataInfluenceDataSubsToNotifyGet(c *gin.Contex
 		}
 		c.Set(sbi.IN_PB_DETAILS_CTX_STR, http.StatusText(int(problemDetails.Status)))
 		c.JSON(http.StatusBadRequest, problemDetails)
+		return
 	}
 
 	s.Processor().ApplicationDataInfluenceDataSubsToNotifyGetProcedure(c, dnn, snssai, internalGroupId, supi)
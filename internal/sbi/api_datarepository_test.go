@@ -0,0 +1,2962 @@
+package sbi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+
+	"github.com/free5gc/openapi/models"
+	udr_context "github.com/free5gc/udr/internal/context"
+	"github.com/free5gc/udr/internal/database"
+	"github.com/free5gc/udr/internal/database/memory"
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/sbi/processor"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/udr/pkg/factory"
+)
+
+// testDb is the in-memory datastore of the tests
+type testDb struct {
+	memory.Connector
+	// failedUpserts is the collection BulkUpsertDataToDB fails to write to
+	failedUpserts string
+	// noTransactions runs the transactions as a datastore without them does, fn as is
+	noTransactions bool
+}
+
+func newTestDb() *testDb {
+	return &testDb{Connector: memory.NewConnector()}
+}
+
+func (db *testDb) BulkUpsertDataToDB(ctx context.Context, collName string, upserts []database.Upsert) []error {
+	if collName != db.failedUpserts {
+		return db.Connector.BulkUpsertDataToDB(ctx, collName, upserts)
+	}
+	errs := make([]error, len(upserts))
+	for i := range upserts {
+		errs[i] = fmt.Errorf("BulkUpsertDataToDB err: %s is unavailable", collName)
+	}
+	return errs
+}
+
+func (db *testDb) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) (bool, error) {
+	if db.noTransactions {
+		return false, fn(ctx)
+	}
+	return db.Connector.WithTransaction(ctx, fn)
+}
+
+// seed stores data as the document matching filter, along with the fields of filter as the UDR writes them
+func (db *testDb) seed(t *testing.T, collName string, filter bson.M, data map[string]interface{}) {
+	t.Helper()
+	document := maps.Clone(data)
+	for field, value := range filter {
+		document[field] = value
+	}
+	_, _, err := db.PutOne(context.Background(), collName, filter, document, "")
+	require.NoError(t, err)
+}
+
+// document returns the document matching filter, nil when there is none
+func (db *testDb) document(t *testing.T, collName string, filter bson.M) map[string]interface{} {
+	t.Helper()
+	document, _, err := db.GetOne(context.Background(), collName, filter)
+	if errors.Is(err, database.ErrNoDocument) {
+		return nil
+	}
+	require.NoError(t, err)
+	return document
+}
+
+// fieldOf returns the field of the document by its dotted path
+func fieldOf(doc map[string]interface{}, field string) interface{} {
+	name, nested, ok := strings.Cut(field, ".")
+	if !ok {
+		return doc[name]
+	}
+	if value, isDoc := doc[name].(map[string]interface{}); isDoc {
+		return fieldOf(value, nested)
+	}
+	return nil
+}
+
+// serveRequest serves the request to the router of s and returns the response. The Content-Type and the other
+// headers, given by name then value, are only set when not empty.
+func serveRequest(s *Server, method, uri, contentType, body string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, uri, strings.NewReader(body))
+	headers = append([]string{"Content-Type", contentType}, headers...)
+	for i := 0; i+1 < len(headers); i += 2 {
+		if headers[i+1] != "" {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+	}
+	rsp := httptest.NewRecorder()
+	s.router.ServeHTTP(rsp, req)
+	return rsp
+}
+
+// newCallbackServer serves the notifications of the NFs by the handler over h2c, as the UDR sends them, and
+// returns its URI
+func newCallbackServer(t *testing.T, handler http.HandlerFunc) string {
+	server := httptest.NewServer(h2c.NewHandler(handler, &http2.Server{}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestServer_AuthenticationStatus(t *testing.T) {
+	db := newTestDb()
+	s := newTestServerWithDb(t, db)
+
+	const ueAuthStatus = factory.UdrDrResUriPrefix +
+		"/subscription-data/imsi-208930000000001/authentication-data/authentication-status"
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		return serveRequest(s, method, path, "application/json", body)
+	}
+	authEvent := func(timeStamp string, authRemovalInd bool) string {
+		return fmt.Sprintf(`{"nfInstanceId":"5b9a6f1c-2c6e-4b8e-9f1e-3c1a2b3c4d5e","success":true,`+
+			`"timeStamp":%q,"authType":"5G_AKA","servingNetworkName":"5G:mnc093.mcc208.3gppnetwork.org",`+
+			`"authRemovalInd":%t}`, timeStamp, authRemovalInd)
+	}
+	query := func(path string) models.AuthEvent {
+		rsp := serve(http.MethodGet, path, "")
+		require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+		require.NotContains(t, rsp.Body.String(), "ueId")
+		var event models.AuthEvent
+		require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &event))
+		return event
+	}
+
+	for _, path := range []string{ueAuthStatus, ueAuthStatus + "/5G:mnc093.mcc208.3gppnetwork.org"} {
+		t.Run(path, func(t *testing.T) {
+			rsp := serve(http.MethodGet, path, "")
+			require.Equal(t, http.StatusNotFound, rsp.Code)
+
+			rsp = serve(http.MethodPut, path, authEvent("2024-05-06T07:08:09.123456789+02:00", true))
+			require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+			event := query(path)
+			require.True(t, event.AuthRemovalInd)
+			require.Equal(t, "2024-05-06T07:08:09.123456789+02:00", event.TimeStamp.Format(time.RFC3339Nano))
+
+			// The overwrite replaces the previous record, its authRemovalInd included
+			rsp = serve(http.MethodPut, path, authEvent("2024-05-06T05:10:00.5Z", false))
+			require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+			event = query(path)
+			require.False(t, event.AuthRemovalInd)
+			require.Equal(t, "2024-05-06T05:10:00.5Z", event.TimeStamp.Format(time.RFC3339Nano))
+			require.Equal(t, models.UdmUeauAuthType__5_G_AKA, event.AuthType)
+
+			rsp = serve(http.MethodDelete, path, "")
+			require.Equal(t, http.StatusNoContent, rsp.Code)
+			rsp = serve(http.MethodGet, path, "")
+			require.Equal(t, http.StatusNotFound, rsp.Code)
+		})
+	}
+
+	t.Run("Serving Network Name Differing From The Path", func(t *testing.T) {
+		rsp := serve(http.MethodPut, ueAuthStatus+"/5G:mnc001.mcc001.3gppnetwork.org",
+			authEvent("2024-05-06T05:10:00Z", false))
+		require.Equal(t, http.StatusBadRequest, rsp.Code)
+		require.Empty(t, db.Documents())
+	})
+}
+
+func TestServer_SqnPatchBehind(t *testing.T) {
+	db := newTestDb()
+	s := newTestServerWithDb(t, db)
+
+	const ueId = "imsi-208930000000001"
+	filter := bson.M{"ueId": ueId}
+	db.seed(t, database.SUBSCDATA_AUTH_SUBSC_DB_COLLECTION_NAME, filter, map[string]interface{}{
+		"encPermanentKey": "8baf473f2f8fd09487cccbd7097c6862",
+		"sequenceNumber":  map[string]interface{}{"sqn": "ffffffffffe0", "sqnScheme": "NON_TIME_BASED"},
+	})
+	patchSqn := func(body string) *httptest.ResponseRecorder {
+		return serveRequest(s, http.MethodPatch,
+			factory.UdrDrResUriPrefix+"/subscription-data/"+ueId+"/authentication-data/authentication-subscription",
+			MediaTypeJSONPatch, body)
+	}
+	storedSqn := func() interface{} {
+		return fieldOf(db.document(t, database.SUBSCDATA_AUTH_SUBSC_DB_COLLECTION_NAME, filter), "sequenceNumber.sqn")
+	}
+
+	// The UDM resynchronising the SQN takes it back
+	rsp := patchSqn(`[{"op":"replace","path":"/sequenceNumber/sqn","value":"ffffffffffd0"}]`)
+	require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+	require.Equal(t, "ffffffffffd0", storedSqn())
+
+	// An update guarded by the SQN it read fails once the SQN changed
+	rsp = patchSqn(`[{"op":"test","path":"/sequenceNumber/sqn","value":"ffffffffffe0"},` +
+		`{"op":"replace","path":"/sequenceNumber/sqn","value":"fffffffffff0"}]`)
+	require.Equal(t, http.StatusConflict, rsp.Code, rsp.Body.String())
+	require.Equal(t, "ffffffffffd0", storedSqn())
+	rsp = patchSqn(`[{"op":"test","path":"/sequenceNumber/sqn","value":"ffffffffffd0"},` +
+		`{"op":"replace","path":"/sequenceNumber/sqn","value":"00000000001f"}]`)
+	require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+	require.Equal(t, "00000000001f", storedSqn())
+}
+
+func TestServer_SorData(t *testing.T) {
+	db := newTestDb()
+	s := newTestServerWithDb(t, db)
+
+	const sorDataPath = factory.UdrDrResUriPrefix +
+		"/subscription-data/imsi-208930000000001/ue-update-confirmation-data/sor-data"
+	put := func(body string) *httptest.ResponseRecorder {
+		return serveRequest(s, http.MethodPut, sorDataPath, "application/json", body)
+	}
+	sorData := func(status string, counter int) string {
+		return fmt.Sprintf(`{"provisioningTime":"2024-05-06T07:08:09.5Z","ueUpdateStatus":%q,`+
+			`"sorMacIue":"d8a3b1c2e4f5a6b7c8d9e0f1a2b3c4d5","counterSor":%d}`, status, counter)
+	}
+	get := func() *httptest.ResponseRecorder {
+		return serveRequest(s, http.MethodGet, sorDataPath, "", "")
+	}
+
+	rsp := get()
+	require.Equal(t, http.StatusNotFound, rsp.Code)
+	require.Contains(t, rsp.Body.String(), "DATA_NOT_FOUND")
+
+	rsp = put(sorData("NOT_SENT", 7))
+	require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+	etag := rsp.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	// The acknowledgement of the UE keeps the counter
+	rsp = put(sorData("ACK_RECEIVED", 7))
+	require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+	acknowledged := rsp.Header().Get("ETag")
+	require.NotEqual(t, etag, acknowledged)
+
+	rsp = put(sorData("NOT_SENT", 6))
+	require.Equal(t, http.StatusConflict, rsp.Code)
+	require.Contains(t, rsp.Body.String(), "counterSor 6 is lower than the stored one 7")
+
+	rsp = put(sorData("NOT_SENT", 0x10000))
+	require.Equal(t, http.StatusBadRequest, rsp.Code)
+
+	rsp = get()
+	require.Equal(t, http.StatusOK, rsp.Code)
+	require.Equal(t, acknowledged, rsp.Header().Get("ETag"))
+	require.NotContains(t, rsp.Body.String(), "ueId")
+	var stored struct {
+		models.SorData
+		CounterSor int32 `json:"counterSor"`
+	}
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &stored))
+	require.Equal(t, models.UeUpdateStatus_ACK_RECEIVED, stored.UeUpdateStatus)
+	require.Equal(t, "d8a3b1c2e4f5a6b7c8d9e0f1a2b3c4d5", stored.SorMacIue)
+	require.Equal(t, int32(7), stored.CounterSor)
+	require.Equal(t, "2024-05-06T07:08:09.5Z", stored.ProvisioningTime.Format(time.RFC3339Nano))
+}
+
+func TestServer_UpuData(t *testing.T) {
+	db := newTestDb()
+	s := newTestServerWithDb(t, db)
+
+	const confirmationDataPath = factory.UdrDrResUriPrefix +
+		"/subscription-data/imsi-208930000000001/ue-update-confirmation-data"
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		return serveRequest(s, method, confirmationDataPath+path, "application/json", body)
+	}
+	upuData := func(status string, counter int) string {
+		return fmt.Sprintf(`{"provisioningTime":"2024-05-06T07:08:09.5Z","ueUpdateStatus":%q,`+
+			`"upuMacIue":"0a1b2c3d4e5f60718293a4b5c6d7e8f9","counterUpu":%d}`, status, counter)
+	}
+
+	rsp := serve(http.MethodGet, "/upu-data", "")
+	require.Equal(t, http.StatusNotFound, rsp.Code)
+	rsp = serve(http.MethodGet, "", "")
+	require.Equal(t, http.StatusNotFound, rsp.Code)
+
+	rsp = serve(http.MethodPut, "/upu-data", upuData("NOT_SENT", 3))
+	require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+	etag := rsp.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	rsp = serve(http.MethodPut, "/upu-data", upuData("ACK_RECEIVED", 3))
+	require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+	acknowledged := rsp.Header().Get("ETag")
+	require.NotEqual(t, etag, acknowledged)
+
+	rsp = serve(http.MethodPut, "/upu-data", upuData("NOT_SENT", 2))
+	require.Equal(t, http.StatusConflict, rsp.Code)
+	require.Contains(t, rsp.Body.String(), "counterUpu 2 is lower than the stored one 3")
+
+	rsp = serve(http.MethodPut, "/upu-data", upuData("NOT_SENT", -1))
+	require.Equal(t, http.StatusBadRequest, rsp.Code)
+
+	rsp = serve(http.MethodGet, "/upu-data", "")
+	require.Equal(t, http.StatusOK, rsp.Code)
+	require.Equal(t, acknowledged, rsp.Header().Get("ETag"))
+	var stored struct {
+		models.UpuData
+		CounterUpu int32 `json:"counterUpu"`
+	}
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &stored))
+	require.Equal(t, models.UeUpdateStatus_ACK_RECEIVED, stored.UeUpdateStatus)
+	require.Equal(t, "0a1b2c3d4e5f60718293a4b5c6d7e8f9", stored.UpuMacIue)
+	require.Equal(t, int32(3), stored.CounterUpu)
+
+	// The SoR and UPU data are inspected at once
+	rsp = serve(http.MethodPut, "/sor-data",
+		`{"provisioningTime":"2024-05-06T07:08:09.5Z","ueUpdateStatus":"NOT_SENT","counterSor":1}`)
+	require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+	rsp = serve(http.MethodGet, "", "")
+	require.Equal(t, http.StatusOK, rsp.Code)
+	var confirmationData map[string]map[string]interface{}
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &confirmationData))
+	require.EqualValues(t, 1, confirmationData["sorData"]["counterSor"])
+	require.EqualValues(t, 3, confirmationData["upuData"]["counterUpu"])
+	require.NotContains(t, confirmationData["upuData"], "ueId")
+}
+
+func TestServer_TraceData(t *testing.T) {
+	db := newTestDb()
+	s := newTestServerWithDb(t, db)
+
+	const (
+		ueId          = "imsi-208930000000001"
+		servingPlmnId = "20893"
+	)
+	get := func(path string) *httptest.ResponseRecorder {
+		return serveRequest(s, http.MethodGet, factory.UdrDrResUriPrefix+
+			"/subscription-data/"+ueId+"/"+servingPlmnId+"/provisioned-data"+path, "", "")
+	}
+
+	rsp := get("/trace-data")
+	require.Equal(t, http.StatusNotFound, rsp.Code)
+	require.Contains(t, rsp.Body.String(), "USER_NOT_FOUND")
+	rsp = get("?dataset-names=TRACE")
+	require.Equal(t, http.StatusNotFound, rsp.Code)
+	require.Contains(t, rsp.Body.String(), "USER_NOT_FOUND")
+
+	// The UE is provisioned, without trace data
+	db.seed(t, database.SUBSCDATA_AUTH_SUBSC_DB_COLLECTION_NAME, bson.M{"ueId": ueId},
+		map[string]interface{}{"authenticationMethod": "5G_AKA"})
+	rsp = get("/trace-data")
+	require.Equal(t, http.StatusNotFound, rsp.Code)
+	require.Contains(t, rsp.Body.String(), "DATA_NOT_FOUND")
+	rsp = get("?dataset-names=TRACE")
+	require.Equal(t, http.StatusNotFound, rsp.Code)
+	require.Contains(t, rsp.Body.String(), "DATA_NOT_FOUND")
+
+	db.seed(t, "subscriptionData.provisionedData.traceData", bson.M{"ueId": ueId, "servingPlmnId": servingPlmnId},
+		map[string]interface{}{
+			"traceRef": "20893-4d7f3a", "traceDepth": "MAXIMUM", "neTypeList": "0f", "eventList": "ff",
+			"collectionEntityIpv4Addr": "192.0.2.10",
+		})
+	rsp = get("/trace-data")
+	require.Equal(t, http.StatusOK, rsp.Code)
+	var traceData models.TraceData
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &traceData))
+	require.Equal(t, "20893-4d7f3a", traceData.TraceRef)
+	require.Equal(t, models.TraceDepth_MAXIMUM, traceData.TraceDepth)
+
+	// Only the data sets named are looked up, the other ones are absent
+	rsp = get("?dataset-names=TRACE")
+	require.Equal(t, http.StatusOK, rsp.Code)
+	var provisionedDataSets models.ProvisionedDataSets
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &provisionedDataSets))
+	require.NotNil(t, provisionedDataSets.TraceData)
+	require.Equal(t, "20893-4d7f3a", provisionedDataSets.TraceData.TraceRef)
+	require.Nil(t, provisionedDataSets.AmData)
+
+	// Another serving PLMN has its own trace data
+	rsp = serveRequest(s, http.MethodGet, factory.UdrDrResUriPrefix+
+		"/subscription-data/"+ueId+"/20801/provisioned-data/trace-data", "", "")
+	require.Equal(t, http.StatusNotFound, rsp.Code)
+	require.Contains(t, rsp.Body.String(), "DATA_NOT_FOUND")
+
+	rsp = get("?dataset-names=TRACE,ODB")
+	require.Equal(t, http.StatusBadRequest, rsp.Code)
+	require.Contains(t, rsp.Body.String(), "ODB")
+}
+
+func TestServer_SmsData(t *testing.T) {
+	db := newTestDb()
+	s := newTestServerWithDb(t, db)
+
+	const (
+		ueId          = "imsi-208930000000001"
+		servingPlmnId = "20893"
+	)
+	get := func(path string) *httptest.ResponseRecorder {
+		return serveRequest(s, http.MethodGet, factory.UdrDrResUriPrefix+
+			"/subscription-data/"+ueId+"/"+servingPlmnId+"/provisioned-data"+path, "", "")
+	}
+
+	for _, path := range []string{"/sms-data", "/sms-mng-data"} {
+		rsp := get(path)
+		require.Equal(t, http.StatusNotFound, rsp.Code, path)
+		require.Contains(t, rsp.Body.String(), "USER_NOT_FOUND", path)
+	}
+	db.seed(t, database.SUBSCDATA_AUTH_SUBSC_DB_COLLECTION_NAME, bson.M{"ueId": ueId},
+		map[string]interface{}{"authenticationMethod": "5G_AKA"})
+	for _, path := range []string{"/sms-data", "/sms-mng-data"} {
+		rsp := get(path)
+		require.Equal(t, http.StatusNotFound, rsp.Code, path)
+		require.Contains(t, rsp.Body.String(), "DATA_NOT_FOUND", path)
+	}
+
+	plmnFilter := bson.M{"ueId": ueId, "servingPlmnId": servingPlmnId}
+	db.seed(t, "subscriptionData.provisionedData.smsData", plmnFilter, map[string]interface{}{
+		"ueId": ueId, "servingPlmnId": servingPlmnId, "smsSubscribed": true,
+	})
+	db.seed(t, "subscriptionData.provisionedData.smsMngData", plmnFilter, map[string]interface{}{
+		"ueId": ueId, "servingPlmnId": servingPlmnId, "mtSmsSubscribed": true, "moSmsBarringRoaming": true,
+	})
+
+	// The features of the consumer are negotiated down to the ones of the UDR, the mandatory others logged at Warn
+	hooks := logger.Log.ReplaceHooks(make(logrus.LevelHooks))
+	t.Cleanup(func() {
+		logger.Log.ReplaceHooks(hooks)
+	})
+	hook := test.NewLocal(logger.Log)
+	rsp := get("/sms-data?supported-features=1b")
+	require.Equal(t, http.StatusOK, rsp.Code)
+	var smsData models.SmsSubscriptionData
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &smsData))
+	require.True(t, smsData.SmsSubscribed)
+	require.Equal(t, "1", smsData.SupportedFeatures)
+	warnings := func() []string {
+		var messages []string
+		for _, entry := range hook.AllEntries() {
+			if entry.Level == logrus.WarnLevel {
+				messages = append(messages, entry.Message)
+			}
+		}
+		hook.Reset()
+		return messages
+	}
+	require.Empty(t, warnings(), "the features not required are optional")
+
+	rsp = get("/sms-data?supported-features=1b&required-features=11")
+	require.Equal(t, http.StatusOK, rsp.Code)
+	messages := warnings()
+	require.Len(t, messages, 1)
+	require.Contains(t, messages[0], "requires features 10 not supported")
+
+	_ = get("/sms-data?supported-features=1&required-features=1")
+	require.Empty(t, warnings())
+
+	rsp = get("/sms-data?supported-features=2&required-features=2")
+	require.Equal(t, http.StatusOK, rsp.Code)
+	require.NotContains(t, rsp.Body.String(), "supportedFeatures")
+	messages = warnings()
+	require.Len(t, messages, 1)
+	require.Contains(t, messages[0], "requires features 2 not supported")
+
+	rsp = get("/sms-mng-data")
+	require.Equal(t, http.StatusOK, rsp.Code)
+	var smsMngData models.SmsManagementSubscriptionData
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &smsMngData))
+	require.True(t, smsMngData.MtSmsSubscribed)
+	require.True(t, smsMngData.MoSmsBarringRoaming)
+	require.Empty(t, smsMngData.SupportedFeatures)
+
+	rsp = get("/sms-mng-data?supported-features=xyz")
+	require.Equal(t, http.StatusBadRequest, rsp.Code)
+	require.Contains(t, rsp.Body.String(), "supported-features")
+
+	rsp = get("?dataset-names=SMS_SUB,SMS_MNG")
+	require.Equal(t, http.StatusOK, rsp.Code)
+	var provisionedDataSets models.ProvisionedDataSets
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &provisionedDataSets))
+	require.NotNil(t, provisionedDataSets.SmsSubsData)
+	require.True(t, provisionedDataSets.SmsSubsData.SmsSubscribed)
+	require.NotNil(t, provisionedDataSets.SmsMngData)
+	require.True(t, provisionedDataSets.SmsMngData.MtSmsSubscribed)
+	require.Nil(t, provisionedDataSets.TraceData)
+}
+
+func TestServer_CreateSmsMngData(t *testing.T) {
+	db := newTestDb()
+	s := newTestServerWithDb(t, db)
+
+	const smsMngDataPath = factory.UdrDrResUriPrefix +
+		"/subscription-data/imsi-208930000000001/20893/provisioned-data/sms-mng-data"
+	serve := func(method, body string) *httptest.ResponseRecorder {
+		return serveRequest(s, method, smsMngDataPath, "application/json", body)
+	}
+
+	rsp := serve(http.MethodPut, `{"mtSmsSubscribed":true,"moSmsSubscribed":true,"traceData":`+
+		`{"traceRef":"20893-4d7f3a","traceDepth":"MINIMUM","neTypeList":"0f","eventList":"ff"}}`)
+	require.Equal(t, http.StatusCreated, rsp.Code, rsp.Body.String())
+
+	// The PUT replaces, the fields it omits are removed
+	rsp = serve(http.MethodPut, `{"mtSmsSubscribed":true,"mtSmsBarringRoaming":true}`)
+	require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+
+	rsp = serve(http.MethodGet, "")
+	require.Equal(t, http.StatusOK, rsp.Code)
+	var smsMngData models.SmsManagementSubscriptionData
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &smsMngData))
+	require.True(t, smsMngData.MtSmsSubscribed)
+	require.True(t, smsMngData.MtSmsBarringRoaming)
+	require.False(t, smsMngData.MoSmsSubscribed)
+	require.Nil(t, smsMngData.TraceData)
+
+	for body, detail := range map[string]string{
+		`{"mtSmsSubscribed":"yes"}`:                                                          "",
+		`{"traceData":{"traceRef":"4d7f3a","eventList":"ff"}}`:                               "traceRef",
+		`{"traceData":{"traceRef":"20893-4d7f3a","traceDepth":"MINIMUM","neTypeList":"0f"}}`: "eventList",
+	} {
+		rsp = serve(http.MethodPut, body)
+		require.Equal(t, http.StatusBadRequest, rsp.Code, body)
+		require.Contains(t, rsp.Body.String(), detail, body)
+	}
+}
+
+// lcsPrivacyDataFixture is an LcsPrivacyData with every class of privacy, the external clients being
+// exceptions to the default class
+const lcsPrivacyDataFixture = `{
+	"lpi": {
+		"locationPrivacyInd": "LOCATION_ALLOWED",
+		"validTimePeriod": {"startTime": "2024-05-06T00:00:00Z", "endTime": "2025-05-06T00:00:00Z"}
+	},
+	"unrelatedClass": {
+		"defaultUnrelatedClass": {
+			"allowedGeographicArea": [{"shape": "POINT", "point": {"lon": 2.2945, "lat": 48.8584}}],
+			"privacyCheckRelatedAction": "LOCATION_ALLOWED_WITH_NOTIFICATION",
+			"codeWordInd": "CODEWORD_CHECK_IN_UE",
+			"codeWordList": ["b7e2a1"]
+		},
+		"externalUnrelatedClass": {
+			"lcsClientExternals": [
+				{"privacyCheckRelatedAction": "LOCATION_ALLOWED_WITHOUT_NOTIFICATION"},
+				{"privacyCheckRelatedAction": "LOCATION_RESTRICTED_WITHOUT_RESPONSE",
+					"validTimePeriod": {"startTime": "2024-05-06T00:00:00Z", "endTime": "2024-06-06T00:00:00Z"}}
+			],
+			"afExternals": [{"afId": "af-gmlc-1",
+				"privacyCheckRelatedAction": "LOCATION_ALLOWED_WITHOUT_RESPONSE"}],
+			"lcsClientGroupExternals": [{"lcsClientGroupId": "emergency-services",
+				"privacyCheckRelatedAction": "LOCATION_ALLOWED_WITHOUT_NOTIFICATION"}]
+		},
+		"serviceTypeUnrelatedClasses": [{"serviceType": 0,
+			"privacyCheckRelatedAction": "LOCATION_ALLOWED_WITH_NOTIFICATION"}]
+	},
+	"plmnOperatorClasses": [
+		{"lcsClientClass": "BROADCAST_SERVICE", "lcsClientIds": ["client-1"]},
+		{"lcsClientClass": "OM_IN_HPLMN", "lcsClientIds": ["client-2", "client-3"]}
+	]
+}`
+
+func TestServer_LcsData(t *testing.T) {
+	db := newTestDb()
+	s := newTestServerWithDb(t, db)
+
+	const ueId = "imsi-208930000000001"
+	get := func(path string) *httptest.ResponseRecorder {
+		return serveRequest(s, http.MethodGet, factory.UdrDrResUriPrefix+"/subscription-data/"+ueId+path, "", "")
+	}
+
+	rsp := get("/lcs-privacy-data")
+	require.Equal(t, http.StatusNotFound, rsp.Code)
+	require.Contains(t, rsp.Body.String(), "USER_NOT_FOUND")
+	db.seed(t, database.SUBSCDATA_AUTH_SUBSC_DB_COLLECTION_NAME, bson.M{"ueId": ueId},
+		map[string]interface{}{"authenticationMethod": "5G_AKA"})
+	rsp = get("/lcs-mo-data")
+	require.Equal(t, http.StatusNotFound, rsp.Code)
+	require.Contains(t, rsp.Body.String(), "DATA_NOT_FOUND")
+
+	lcsPrivacyData := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal([]byte(lcsPrivacyDataFixture), &lcsPrivacyData))
+	lcsPrivacyData["ueId"] = ueId
+	db.seed(t, "subscriptionData.provisionedData.lcsPrivacyData", bson.M{"ueId": ueId}, lcsPrivacyData)
+	db.seed(t, "subscriptionData.provisionedData.lcsMoData", bson.M{"ueId": ueId}, map[string]interface{}{
+		"ueId":                  ueId,
+		"allowedServiceClasses": []interface{}{"BASIC_SELF_LOCATION", "TRANSFER_TO_THIRD_PARTY"},
+	})
+
+	rsp = get("/lcs-privacy-data")
+	require.Equal(t, http.StatusOK, rsp.Code)
+	require.JSONEq(t, lcsPrivacyDataFixture, rsp.Body.String())
+	var privacyData models.LcsPrivacyData
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &privacyData))
+	require.Equal(t, models.PrivacyCheckRelatedAction_RESTRICTED_WITHOUT_RESPONSE,
+		privacyData.UnrelatedClass.ExternalUnrelatedClass.LcsClientExternals[1].PrivacyCheckRelatedAction)
+	require.Len(t, privacyData.PlmnOperatorClasses, 2)
+
+	// fields projects the members named
+	rsp = get("/lcs-privacy-data?fields=lpi,plmnOperatorClasses")
+	require.Equal(t, http.StatusOK, rsp.Code)
+	var projected map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &projected))
+	require.Len(t, projected, 2)
+	require.Contains(t, projected, "lpi")
+	require.Contains(t, projected, "plmnOperatorClasses")
+
+	rsp = get("/lcs-mo-data?fields=allowedServiceClasses")
+	require.Equal(t, http.StatusOK, rsp.Code)
+	require.JSONEq(t, `{"allowedServiceClasses":["BASIC_SELF_LOCATION","TRANSFER_TO_THIRD_PARTY"]}`,
+		rsp.Body.String())
+
+	rsp = get("/20893/provisioned-data?dataset-names=LCS_PRIVACY,LCS_MO")
+	require.Equal(t, http.StatusOK, rsp.Code)
+	var provisionedDataSets models.ProvisionedDataSets
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &provisionedDataSets))
+	require.NotNil(t, provisionedDataSets.LcsPrivacyData)
+	require.Equal(t, models.LocationPrivacyInd_ALLOWED, provisionedDataSets.LcsPrivacyData.Lpi.LocationPrivacyInd)
+	require.NotNil(t, provisionedDataSets.LcsMoData)
+	require.Equal(t, []models.LcsMoServiceClass{models.LcsMoServiceClass_BASIC_SELF_LOCATION,
+		models.LcsMoServiceClass_TRANSFER_TO_THIRD_PARTY}, provisionedDataSets.LcsMoData.AllowedServiceClasses)
+}
+
+func TestServer_PfdData(t *testing.T) {
+	db := newTestDb()
+	s := newTestServerWithDb(t, db)
+
+	const pfdsPath = factory.UdrDrResUriPrefix + "/application-data/pfds"
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		return serveRequest(s, method, pfdsPath+path, "application/json", body)
+	}
+	pfdData := func(pfdId, flow string) string {
+		return fmt.Sprintf(`{"pfds":[{"pfdId":%q,"flowDescriptions":[%q]}],"resetIds":["pfd0"]}`, pfdId, flow)
+	}
+
+	rsp := serve(http.MethodGet, "/app1", "")
+	require.Equal(t, http.StatusNotFound, rsp.Code)
+	require.Contains(t, rsp.Body.String(), "DATA_NOT_FOUND")
+
+	rsp = serve(http.MethodPut, "/app1", pfdData("pfd1", "permit out ip from 192.0.2.1 to assigned"))
+	require.Equal(t, http.StatusCreated, rsp.Code, rsp.Body.String())
+	require.True(t, strings.HasSuffix(rsp.Header().Get("Location"), "/application-data/pfds/app1"))
+
+	// The PUT replaces, the reset IDs it omits are removed
+	rsp = serve(http.MethodPut, "/app1",
+		`{"applicationId":"app1","pfds":[{"pfdId":"pfd2","urls":["^http://example.com/"]}]}`)
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+
+	// The supported features of the consumer are negotiated
+	rsp = serve(http.MethodGet, "/app1?supported-features=1", "")
+	require.Equal(t, http.StatusOK, rsp.Code)
+	var pfdDataForApp models.PfdDataForAppExt
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &pfdDataForApp))
+	require.Equal(t, "app1", pfdDataForApp.ApplicationId)
+	require.Len(t, pfdDataForApp.Pfds, 1)
+	require.Equal(t, "pfd2", pfdDataForApp.Pfds[0].PfdId)
+	require.Empty(t, pfdDataForApp.ResetIds)
+	require.Equal(t, "1", pfdDataForApp.SuppFeat)
+
+	rsp = serve(http.MethodPut, "/app2", pfdData("pfd3", "permit out ip from 192.0.2.2 to assigned"))
+	require.Equal(t, http.StatusCreated, rsp.Code, rsp.Body.String())
+
+	// The appIds come comma separated or repeated, the unknown ones are left out
+	for _, query := range []string{"?appId=app1,app2,app3", "?appId=app1&appId=app2&appId=app3"} {
+		rsp = serve(http.MethodGet, query, "")
+		require.Equal(t, http.StatusOK, rsp.Code, query)
+		var pfdDataForApps []models.PfdDataForAppExt
+		require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &pfdDataForApps))
+		require.Len(t, pfdDataForApps, 2, query)
+	}
+	rsp = serve(http.MethodGet, "?appId=app3", "")
+	require.Equal(t, http.StatusNotFound, rsp.Code)
+
+	rsp = serve(http.MethodDelete, "/app1", "")
+	require.Equal(t, http.StatusNoContent, rsp.Code)
+	rsp = serve(http.MethodDelete, "/app1", "")
+	require.Equal(t, http.StatusNotFound, rsp.Code)
+
+	for body, detail := range map[string]string{
+		`{"applicationId":"app2","pfds":[{"pfdId":"pfd1"}]}`: "applicationId",
+		`{"pfds":[]}`: "pfds",
+		`{"pfds":[{"flowDescriptions":["permit out ip"]}]}`: "pfdId",
+		`{"pfds":[{"pfdId":"pfd1"}],"suppFeat":"xyz"}`:      "suppFeat",
+	} {
+		rsp = serve(http.MethodPut, "/app1", body)
+		require.Equal(t, http.StatusBadRequest, rsp.Code, body)
+		require.Contains(t, rsp.Body.String(), detail, body)
+	}
+	rsp = serve(http.MethodGet, "/app2?supported-features=xyz", "")
+	require.Equal(t, http.StatusBadRequest, rsp.Code)
+}
+
+func TestServer_V2xData(t *testing.T) {
+	db := newTestDb()
+	s := newTestServerWithDb(t, db)
+
+	const ueId = "imsi-208930000000001"
+	get := func(path string) *httptest.ResponseRecorder {
+		return serveRequest(s, http.MethodGet, factory.UdrDrResUriPrefix+"/subscription-data/"+ueId+path, "", "")
+	}
+
+	rsp := get("/v2x-data")
+	require.Equal(t, http.StatusNotFound, rsp.Code)
+	require.Contains(t, rsp.Body.String(), "USER_NOT_FOUND")
+	db.seed(t, database.SUBSCDATA_AUTH_SUBSC_DB_COLLECTION_NAME, bson.M{"ueId": ueId},
+		map[string]interface{}{"authenticationMethod": "5G_AKA"})
+	rsp = get("/v2x-data")
+	require.Equal(t, http.StatusNotFound, rsp.Code)
+	require.Contains(t, rsp.Body.String(), "DATA_NOT_FOUND")
+
+	// Provisioned without the LTE members, and with a member null
+	db.seed(t, "subscriptionData.provisionedData.v2xData", bson.M{"ueId": ueId}, map[string]interface{}{
+		"ueId":              ueId,
+		"nrV2xServicesAuth": map[string]interface{}{"vehicleUeAuth": "AUTHORIZED"},
+		"nrUePc5Ambr":       "100 Mbps",
+		"ltePc5Ambr":        nil,
+	})
+	rsp = get("/v2x-data")
+	require.Equal(t, http.StatusOK, rsp.Code)
+	require.JSONEq(t, `{"nrV2xServicesAuth":{"vehicleUeAuth":"AUTHORIZED"},"nrUePc5Ambr":"100 Mbps"}`,
+		rsp.Body.String())
+
+	rsp = get("/20893/provisioned-data?dataset-names=V2X")
+	require.Equal(t, http.StatusOK, rsp.Code)
+	var provisionedDataSets models.ProvisionedDataSets
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &provisionedDataSets))
+	require.NotNil(t, provisionedDataSets.V2xData)
+	require.Equal(t, models.UeAuth_AUTHORIZED, provisionedDataSets.V2xData.NrV2xServicesAuth.VehicleUeAuth)
+	require.Equal(t, "100 Mbps", provisionedDataSets.V2xData.NrUePc5Ambr)
+	require.Nil(t, provisionedDataSets.V2xData.LteV2xServicesAuth)
+}
+
+func TestServer_InfluenceDataBadFilters(t *testing.T) {
+	s := newTestServerWithDb(t, newTestDb())
+
+	// Answered before any lookup, the fake DbConnector has none
+	for _, path := range []string{
+		"/application-data/influenceData?snssais=sst1",
+		"/application-data/influenceData/subs-to-notify",
+		`/application-data/influenceData/subs-to-notify?snssai={"sst":"one"}`,
+	} {
+		rsp := serveRequest(s, http.MethodGet, factory.UdrDrResUriPrefix+path, "", "")
+		require.Equal(t, http.StatusBadRequest, rsp.Code, path)
+		var pd models.ProblemDetails
+		require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &pd), path)
+	}
+}
+
+func TestServer_ProseData(t *testing.T) {
+	db := newTestDb()
+	s := newTestServerWithDb(t, db)
+
+	const ueId = "imsi-208930000000001"
+	get := func(path string) *httptest.ResponseRecorder {
+		return serveRequest(s, http.MethodGet, factory.UdrDrResUriPrefix+"/subscription-data/"+ueId+path, "", "")
+	}
+	proseDataFilter := bson.M{"ueId": ueId}
+
+	rsp := get("/prose-data")
+	require.Equal(t, http.StatusNotFound, rsp.Code)
+	require.Contains(t, rsp.Body.String(), "USER_NOT_FOUND")
+	db.seed(t, database.SUBSCDATA_AUTH_SUBSC_DB_COLLECTION_NAME, bson.M{"ueId": ueId},
+		map[string]interface{}{"authenticationMethod": "5G_AKA"})
+	// No ProSe document is 404, like the AM data
+	rsp = get("/prose-data")
+	require.Equal(t, http.StatusNotFound, rsp.Code)
+	require.Contains(t, rsp.Body.String(), "DATA_NOT_FOUND")
+
+	// A ProSe document without any member is 200 with an empty object, like the AM data
+	db.seed(t, "subscriptionData.provisionedData.proseData", proseDataFilter, map[string]interface{}{})
+	rsp = get("/prose-data")
+	require.Equal(t, http.StatusOK, rsp.Code)
+	require.JSONEq(t, `{}`, rsp.Body.String())
+
+	db.seed(t, "subscriptionData.provisionedData.proseData", proseDataFilter, map[string]interface{}{
+		"proseServiceAuth": map[string]interface{}{
+			"proseDirectDiscoveryAuth":     "AUTHORIZED",
+			"proseDirectCommunicationAuth": "NOT_AUTHORIZED",
+		},
+		"nrUePc5Ambr": "50 Mbps",
+		"proseAllowedPlmn": []interface{}{map[string]interface{}{
+			"visitedPlmn":        map[string]interface{}{"mcc": "208", "mnc": "93"},
+			"proseDirectAllowed": []interface{}{"ANNOUNCE", "MONITOR"},
+		}},
+	})
+	rsp = get("/prose-data?supported-features=1")
+	require.Equal(t, http.StatusOK, rsp.Code)
+	var proseData models.ProseSubscriptionData
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &proseData))
+	require.Equal(t, models.UeAuth_AUTHORIZED, proseData.ProseServiceAuth.ProseDirectDiscoveryAuth)
+	require.Equal(t, models.UeAuth_NOT_AUTHORIZED, proseData.ProseServiceAuth.ProseDirectCommunicationAuth)
+	require.Equal(t, "50 Mbps", proseData.NrUePc5Ambr)
+	require.Len(t, proseData.ProseAllowedPlmn, 1)
+	require.NotContains(t, rsp.Body.String(), "ueId")
+
+	rsp = get("/prose-data?supported-features=xyz")
+	require.Equal(t, http.StatusBadRequest, rsp.Code)
+
+	rsp = get("/20893/provisioned-data?dataset-names=PROSE")
+	require.Equal(t, http.StatusOK, rsp.Code)
+	var provisionedDataSets models.ProvisionedDataSets
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &provisionedDataSets))
+	require.NotNil(t, provisionedDataSets.ProseData)
+	require.Equal(t, "50 Mbps", provisionedDataSets.ProseData.NrUePc5Ambr)
+	require.Nil(t, provisionedDataSets.V2xData)
+}
+
+func TestServer_SmfRegistrations(t *testing.T) {
+	db := newTestDb()
+	s := newTestServerWithDb(t, db)
+
+	const smfRegistrationsPath = factory.UdrDrResUriPrefix +
+		"/subscription-data/imsi-208930000000001/context-data/smf-registrations"
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		return serveRequest(s, method, smfRegistrationsPath+path, "application/json", body)
+	}
+	smfRegistration := func(pduSessionId int) string {
+		return fmt.Sprintf(`{"smfInstanceId":"5b9a6f1c-2c6e-4b8e-9f1e-3c1a2b3c4d5e","pduSessionId":%d,`+
+			`"singleNssai":{"sst":1,"sd":"010203"},"dnn":"internet","plmnId":{"mcc":"208","mnc":"93"}}`, pduSessionId)
+	}
+	list := func() []models.SmfRegistration {
+		rsp := serve(http.MethodGet, "", "")
+		require.Equal(t, http.StatusOK, rsp.Code)
+		var smfRegList []models.SmfRegistration
+		require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &smfRegList))
+		return smfRegList
+	}
+
+	// The list of a UE without PDU session is empty, not null
+	rsp := serve(http.MethodGet, "", "")
+	require.Equal(t, http.StatusOK, rsp.Code)
+	require.Equal(t, "[]", rsp.Body.String())
+
+	rsp = serve(http.MethodPut, "/1", smfRegistration(1))
+	require.Equal(t, http.StatusCreated, rsp.Code, rsp.Body.String())
+	rsp = serve(http.MethodPut, "/255", smfRegistration(255))
+	require.Equal(t, http.StatusCreated, rsp.Code, rsp.Body.String())
+	rsp = serve(http.MethodPut, "/1", smfRegistration(1))
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+	require.Len(t, list(), 2)
+
+	rsp = serve(http.MethodGet, "/255", "")
+	require.Equal(t, http.StatusOK, rsp.Code)
+	var registration models.SmfRegistration
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &registration))
+	require.Equal(t, int32(255), registration.PduSessionId)
+
+	for path, body := range map[string]string{
+		"/2":   smfRegistration(3),
+		"/256": smfRegistration(256),
+		"/-1":  smfRegistration(-1),
+		"/one": smfRegistration(1),
+	} {
+		rsp = serve(http.MethodPut, path, body)
+		require.Equal(t, http.StatusBadRequest, rsp.Code, path)
+		require.Contains(t, rsp.Body.String(), "pduSessionId", path)
+	}
+	rsp = serve(http.MethodGet, "/256", "")
+	require.Equal(t, http.StatusBadRequest, rsp.Code)
+
+	// The DELETE is idempotent, 204 as well when the registration is already gone
+	for range 2 {
+		rsp = serve(http.MethodDelete, "/1", "")
+		require.Equal(t, http.StatusNoContent, rsp.Code)
+	}
+	rsp = serve(http.MethodGet, "/1", "")
+	require.Equal(t, http.StatusNotFound, rsp.Code)
+	smfRegList := list()
+	require.Len(t, smfRegList, 1)
+	require.Equal(t, int32(255), smfRegList[0].PduSessionId)
+}
+
+func TestServer_PatchFormats(t *testing.T) {
+	db := newTestDb()
+	s := newTestServerWithDb(t, db)
+
+	const (
+		ueId      = "imsi-208930000000001"
+		ppDataUri = factory.UdrDrResUriPrefix + "/subscription-data/" + ueId + "/pp-data"
+	)
+	patch := func(contentType, body string) *httptest.ResponseRecorder {
+		return serveRequest(s, http.MethodPatch, ppDataUri, contentType, body)
+	}
+	get := func() string {
+		rsp := serveRequest(s, http.MethodGet, ppDataUri, "", "")
+		require.Equal(t, http.StatusOK, rsp.Code)
+		return rsp.Body.String()
+	}
+	db.seed(t, "subscriptionData.ppData", bson.M{"ueId": ueId}, map[string]interface{}{
+		"ueId":              ueId,
+		"supportedFeatures": "1",
+		"communicationCharacteristics": map[string]interface{}{
+			"ppSubsRegTimer": map[string]interface{}{"subsRegTimer": 3600, "afInstanceId": "af1", "referenceId": 1},
+		},
+	})
+
+	// The same change, as a merge patch then as a JSON Patch, gives the same document
+	rsp := patch(MediaTypeMergePatch, `{"supportedFeatures":"3","communicationCharacteristics":null}`)
+	require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+	mergePatched := get()
+	require.JSONEq(t, `{"supportedFeatures":"3"}`, mergePatched)
+
+	db.seed(t, "subscriptionData.ppData", bson.M{"ueId": ueId}, map[string]interface{}{
+		"ueId":              ueId,
+		"supportedFeatures": "1",
+		"communicationCharacteristics": map[string]interface{}{
+			"ppSubsRegTimer": map[string]interface{}{"subsRegTimer": 3600, "afInstanceId": "af1", "referenceId": 1},
+		},
+	})
+	rsp = patch(MediaTypeJSONPatch, `[{"op":"test","path":"/supportedFeatures","value":"1"},`+
+		`{"op":"replace","path":"/supportedFeatures","value":"3"},`+
+		`{"op":"remove","path":"/communicationCharacteristics"}]`)
+	require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+	require.JSONEq(t, mergePatched, get())
+
+	// A JSON Patch not applying to the document changes none of it
+	for body, status := range map[string]int{
+		`[{"op":"replace","path":"/supportedFeatures","value":"7"},` +
+			`{"op":"test","path":"/supportedFeatures","value":"1"}]`: http.StatusConflict,
+		`[{"op":"remove","path":"/communicationCharacteristics"}]`: http.StatusUnprocessableEntity,
+		`[{"op":"move","from":"/ppDlPacketCount","path":"/x"}]`:    http.StatusUnprocessableEntity,
+	} {
+		rsp = patch(MediaTypeJSONPatch, body)
+		require.Equal(t, status, rsp.Code, body)
+		var pd models.ProblemDetails
+		require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &pd), body)
+		require.NotEmpty(t, pd.Detail, body)
+		require.JSONEq(t, mergePatched, get())
+	}
+}
+
+func TestServer_AmfContextNon3gpp(t *testing.T) {
+	db := newTestDb()
+	s := newTestServerWithDb(t, db)
+
+	const (
+		ueId        = "imsi-208930000000001"
+		non3gppUri  = factory.UdrDrResUriPrefix + "/subscription-data/" + ueId + "/context-data/amf-non-3gpp-access"
+		deregCbUri  = "http://127.0.0.18:8000/nudm-uecm/v1/imsi-208930000000001/deregistration"
+		amfInstance = "25cf1f7a-6ab4-4c14-b3c9-6f2ce9e642b1"
+	)
+	serve := func(method, contentType, body string) *httptest.ResponseRecorder {
+		return serveRequest(s, method, non3gppUri, contentType, body)
+	}
+	get := func() models.AmfNon3GppAccessRegistration {
+		rsp := serve(http.MethodGet, "", "")
+		require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+		var registration models.AmfNon3GppAccessRegistration
+		require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &registration))
+		return registration
+	}
+
+	notified := make(chan models.DataChangeNotify, 4)
+	callbackUri := newCallbackServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var notify models.DataChangeNotify
+		if err := json.NewDecoder(r.Body).Decode(&notify); err == nil {
+			notified <- notify
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	udrSelf := udr_context.GetSelf()
+	udrSelf.SubscriptionDataSubscriptions = make(map[string]*models.SubscriptionDataSubscriptions)
+	subscriptionId := udrSelf.AddSubscriptionDataSubscription(&models.SubscriptionDataSubscriptions{
+		UeId:                  ueId,
+		CallbackReference:     callbackUri,
+		MonitoredResourceUris: []string{non3gppUri},
+	})
+	t.Cleanup(func() { udrSelf.RemoveSubscriptionDataSubscription(subscriptionId) })
+	requireNotified := func() {
+		select {
+		case notify := <-notified:
+			require.Equal(t, ueId, notify.UeId)
+			require.Len(t, notify.NotifyItems, 1)
+			require.True(t, strings.HasSuffix(notify.NotifyItems[0].ResourceId, "/context-data/amf-non-3gpp-access"))
+		case <-time.After(2 * time.Second):
+			require.Fail(t, "no data change notification")
+		}
+	}
+
+	// No registration to modify
+	rsp := serve(http.MethodPatch, MediaTypeJSONPatch, `[{"op":"replace","path":"/purgeFlag","value":true}]`)
+	require.Equal(t, http.StatusNotFound, rsp.Code, rsp.Body.String())
+
+	rsp = serve(http.MethodPut, "application/json", `{"amfInstanceId":"`+amfInstance+`","supi":"`+ueId+`",`+
+		`"deregCallbackUri":"`+deregCbUri+`","purgeFlag":true,"imsVoPs":"HOMOGENEOUS_SUPPORT",`+
+		`"guami":{"plmnId":{"mcc":"208","mnc":"93"},"amfId":"cafe00"},"ratType":"WLAN"}`)
+	require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+	requireNotified()
+	registration := get()
+	require.Equal(t, deregCbUri, registration.DeregCallbackUri)
+	require.True(t, registration.PurgeFlag)
+	require.Equal(t, models.RatType_WLAN, registration.RatType)
+
+	rsp = serve(http.MethodPatch, MediaTypeJSONPatch, `[{"op":"replace","path":"/purgeFlag","value":false},`+
+		`{"op":"add","path":"/pei","value":"imeisv-4370816125816151"}]`)
+	require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+	requireNotified()
+	registration = get()
+	require.False(t, registration.PurgeFlag)
+	require.Equal(t, "imeisv-4370816125816151", registration.Pei)
+	require.Equal(t, deregCbUri, registration.DeregCallbackUri)
+
+	// The fields of the registration itself are not modifiable
+	for contentType, body := range map[string]string{
+		MediaTypeJSONPatch:  `[{"op":"replace","path":"/deregCallbackUri","value":"http://127.0.0.1/dereg"}]`,
+		MediaTypeMergePatch: `{"pei":"imeisv-4370816125816152","amfInstanceId":"other"}`,
+	} {
+		rsp = serve(http.MethodPatch, contentType, body)
+		require.Equal(t, http.StatusForbidden, rsp.Code, body)
+	}
+	registration = get()
+	require.Equal(t, amfInstance, registration.AmfInstanceId)
+	require.Equal(t, deregCbUri, registration.DeregCallbackUri)
+	require.Equal(t, "imeisv-4370816125816151", registration.Pei)
+	select {
+	case <-notified:
+		require.Fail(t, "data change notified of a rejected PATCH")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestServer_DryRun(t *testing.T) {
+	db := newTestDb()
+	s := newTestServerWithDb(t, db)
+
+	const (
+		ueId       = "imsi-208930000000001"
+		ppDataUri  = factory.UdrDrResUriPrefix + "/subscription-data/" + ueId + "/pp-data"
+		non3gppUri = factory.UdrDrResUriPrefix + "/subscription-data/" + ueId + "/context-data/amf-non-3gpp-access"
+	)
+	db.seed(t, "subscriptionData.ppData", bson.M{"ueId": ueId}, map[string]interface{}{
+		"ueId":              ueId,
+		"supportedFeatures": "1",
+	})
+	stored, err := json.Marshal(db.Documents())
+	require.NoError(t, err)
+	requireUntouched := func() {
+		current, err := json.Marshal(db.Documents())
+		require.NoError(t, err)
+		require.JSONEq(t, string(stored), string(current))
+	}
+
+	rsp := serveRequest(s, http.MethodPatch, ppDataUri+"?dry-run=true", MediaTypeMergePatch, `{"supportedFeatures":"3"}`)
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+	require.JSONEq(t, `{"ueId":"`+ueId+`","supportedFeatures":"3"}`, rsp.Body.String())
+	requireUntouched()
+
+	rsp = serveRequest(s, http.MethodPatch, ppDataUri+"?dry-run=true", MediaTypeJSONPatch,
+		`[{"op":"test","path":"/supportedFeatures","value":"3"}]`)
+	require.Equal(t, http.StatusConflict, rsp.Code, rsp.Body.String())
+	requireUntouched()
+
+	rsp = serveRequest(s, http.MethodPut, non3gppUri+"?dry-run=true", "application/json",
+		`{"amfInstanceId":"25cf1f7a-6ab4-4c14-b3c9-6f2ce9e642b1","ratType":"WLAN",`+
+			`"guami":{"plmnId":{"mcc":"208","mnc":"93"},"amfId":"cafe00"}}`)
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+	require.Empty(t, rsp.Header().Get("ETag"))
+	var registration models.AmfNon3GppAccessRegistration
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &registration))
+	require.Equal(t, "25cf1f7a-6ab4-4c14-b3c9-6f2ce9e642b1", registration.AmfInstanceId)
+	requireUntouched()
+	require.Equal(t, http.StatusNotFound, serveRequest(s, http.MethodGet, non3gppUri, "", "").Code)
+
+	// The validation applies as without dry run
+	rsp = serveRequest(s, http.MethodPut, non3gppUri+"?dry-run=true", "application/json", `{"ratType":1}`)
+	require.Equal(t, http.StatusBadRequest, rsp.Code, rsp.Body.String())
+	requireUntouched()
+
+	// A dry run asked of another method, or not told, is rejected rather than applied
+	for method, uri := range map[string]string{
+		http.MethodDelete: factory.UdrDrResUriPrefix + "/subscription-data/" + ueId +
+			"/context-data/smsf-3gpp-access?dry-run=true",
+		http.MethodPatch: ppDataUri + "?dry-run=maybe",
+	} {
+		rsp = serveRequest(s, method, uri, MediaTypeMergePatch, `{"supportedFeatures":"7"}`)
+		require.Equal(t, http.StatusBadRequest, rsp.Code, uri)
+		requireUntouched()
+	}
+
+	rsp = serveRequest(s, http.MethodPatch, ppDataUri+"?dry-run=false", MediaTypeMergePatch, `{"supportedFeatures":"3"}`)
+	require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+	require.Equal(t, "3", db.document(t, "subscriptionData.ppData", bson.M{"ueId": ueId})["supportedFeatures"])
+}
+
+func TestServer_DryRunSubscriptions(t *testing.T) {
+	s := newTestServerWithDb(t, newTestDb())
+	udrSelf := udr_context.GetSelf()
+
+	const (
+		ueId         = "imsi-208930000000032"
+		sdmSubsUri   = factory.UdrDrResUriPrefix + "/subscription-data/" + ueId + "/context-data/sdm-subscriptions"
+		nfInstanceId = "8a2c9a4e-4a3c-4d2b-9d3f-7d8b33d5c003"
+	)
+	rsp := serveRequest(s, http.MethodPost, sdmSubsUri, "application/json", fmt.Sprintf(
+		`{"nfInstanceId":%q,"callbackReference":"http://udm.free5gc.org/sdm-callback",`+
+			`"monitoredResourceUris":["http://udr.free5gc.org/am-data"]}`, nfInstanceId))
+	require.Equal(t, http.StatusCreated, rsp.Code, rsp.Body.String())
+	var subscription models.SdmSubscription
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &subscription))
+	subsUri := sdmSubsUri + "/" + subscription.SubscriptionId
+	value, ok := udrSelf.UESubsCollection.Load(ueId)
+	require.True(t, ok)
+	stored := value.(*udr_context.UESubsData).SdmSubscriptions[subscription.SubscriptionId]
+
+	rsp = serveRequest(s, http.MethodPut, subsUri+"?dry-run=true", "application/json", fmt.Sprintf(
+		`{"nfInstanceId":%q,"callbackReference":"http://udm.free5gc.org/sdm-callback",`+
+			`"monitoredResourceUris":["http://udr.free5gc.org/sm-data"]}`, nfInstanceId))
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+	require.Contains(t, rsp.Body.String(), "http://udr.free5gc.org/sm-data")
+	rsp = serveRequest(s, http.MethodPatch, subsUri+"?dry-run=true", MediaTypeMergePatch,
+		`{"monitoredResourceUris":["http://udr.free5gc.org/sms-data"]}`)
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+	require.Contains(t, rsp.Body.String(), "http://udr.free5gc.org/sms-data")
+	require.Same(t, stored, value.(*udr_context.UESubsData).SdmSubscriptions[subscription.SubscriptionId])
+	require.Equal(t, []string{"http://udr.free5gc.org/am-data"}, stored.MonitoredResourceUris)
+
+	const influenceSubsId = "dry-run"
+	influenceUri := factory.UdrDrResUriPrefix + "/application-data/influenceData/subs-to-notify/" + influenceSubsId
+	rsp = serveRequest(s, http.MethodPut, influenceUri+"?dry-run=true", "application/json",
+		`{"dnns":["internet"],"notificationUri":"http://nef.free5gc.org/notify"}`)
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+	_, ok = udrSelf.InfluenceDataSubscriptions.Load(influenceSubsId)
+	require.False(t, ok)
+}
+
+func TestServer_ContextDataIfMatch(t *testing.T) {
+	db := newTestDb()
+	s := newTestServerWithDb(t, db)
+
+	const (
+		ueId           = "imsi-208930000000001"
+		contextDataUri = factory.UdrDrResUriPrefix + "/subscription-data/" + ueId + "/context-data"
+	)
+	serve := func(method, uri, ifMatch, contentType, body string) *httptest.ResponseRecorder {
+		return serveRequest(s, method, contextDataUri+uri, contentType, body, "If-Match", ifMatch)
+	}
+	registration := func(amfInstanceId string) string {
+		return `{"amfInstanceId":"` + amfInstanceId + `","deregCallbackUri":"http://127.0.0.18:8000/dereg",` +
+			`"guami":{"plmnId":{"mcc":"208","mnc":"93"},"amfId":"cafe00"},"ratType":"NR"}`
+	}
+
+	t.Run("Stale If-Match", func(t *testing.T) {
+		testCases := []struct {
+			name        string
+			method      string
+			uri         string
+			contentType string
+			body        string
+			rewritten   string // the body of a write changing the stored document
+			created     int
+		}{
+			{"AMF 3GPP PUT", http.MethodPut, "/amf-3gpp-access", "application/json", registration("amf-1"),
+				registration("amf-2"), http.StatusNoContent},
+			{"AMF 3GPP PATCH", http.MethodPatch, "/amf-3gpp-access", MediaTypeMergePatch,
+				`{"pei":"imei-4901542032375181"}`, `{"pei":"imei-4901542032375182"}`, http.StatusNoContent},
+			{"AMF Non-3GPP PUT", http.MethodPut, "/amf-non-3gpp-access", "application/json",
+				`{"amfInstanceId":"amf-1","imsVoPs":"HOMOGENEOUS_SUPPORT",` +
+					`"deregCallbackUri":"http://127.0.0.18:8000/dereg",` +
+					`"guami":{"plmnId":{"mcc":"208","mnc":"93"},"amfId":"cafe00"},"ratType":"NR"}`,
+				`{"amfInstanceId":"amf-2","imsVoPs":"HOMOGENEOUS_SUPPORT",` +
+					`"deregCallbackUri":"http://127.0.0.18:8000/dereg",` +
+					`"guami":{"plmnId":{"mcc":"208","mnc":"93"},"amfId":"cafe00"},"ratType":"NR"}`,
+				http.StatusNoContent},
+			{"AMF Non-3GPP PATCH", http.MethodPatch, "/amf-non-3gpp-access", MediaTypeMergePatch,
+				`{"pei":"imei-4901542032375181"}`, `{"pei":"imei-4901542032375182"}`, http.StatusNoContent},
+			{"SMF Registration PUT", http.MethodPut, "/smf-registrations/1", "application/json",
+				`{"smfInstanceId":"5b9a6f1c-2c6e-4b8e-9f1e-3c1a2b3c4d5e","pduSessionId":1,` +
+					`"singleNssai":{"sst":1},"dnn":"internet","plmnId":{"mcc":"208","mnc":"93"}}`,
+				`{"smfInstanceId":"5b9a6f1c-2c6e-4b8e-9f1e-3c1a2b3c4d5e","pduSessionId":1,` +
+					`"singleNssai":{"sst":1},"dnn":"ims","plmnId":{"mcc":"208","mnc":"93"}}`,
+				http.StatusOK},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				// Without If-Match the last writer wins
+				rsp := serve(tc.method, tc.uri, "", tc.contentType, tc.body)
+				require.Contains(t, []int{tc.created, http.StatusCreated}, rsp.Code, rsp.Body.String())
+				etag := rsp.Header().Get("ETag")
+				require.NotEmpty(t, etag)
+				// The same document keeps its ETag
+				rsp = serve(tc.method, tc.uri, "", tc.contentType, tc.body)
+				require.Equal(t, tc.created, rsp.Code, rsp.Body.String())
+				require.Equal(t, etag, rsp.Header().Get("ETag"))
+				rsp = serve(tc.method, tc.uri, "", tc.contentType, tc.rewritten)
+				require.Equal(t, tc.created, rsp.Code, rsp.Body.String())
+				require.NotEqual(t, etag, rsp.Header().Get("ETag"))
+
+				rsp = serve(tc.method, tc.uri, etag, tc.contentType, tc.body)
+				require.Equal(t, http.StatusPreconditionFailed, rsp.Code, rsp.Body.String())
+				var problemDetails models.ProblemDetails
+				require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &problemDetails))
+				require.Equal(t, int32(http.StatusPreconditionFailed), problemDetails.Status)
+
+				// If-Match uses the strong comparison
+				current := serve(http.MethodGet, tc.uri, "", "", "").Header().Get("ETag")
+				rsp = serve(tc.method, tc.uri, "W/"+current, tc.contentType, tc.body)
+				require.Equal(t, http.StatusPreconditionFailed, rsp.Code, rsp.Body.String())
+				rsp = serve(tc.method, tc.uri, current, tc.contentType, tc.body)
+				require.Equal(t, tc.created, rsp.Code, rsp.Body.String())
+			})
+		}
+	})
+
+	t.Run("If-None-Match Any", func(t *testing.T) {
+		// A PUT is an upsert, with If-None-Match: * it only creates
+		createOnly := func(uri, body string) *httptest.ResponseRecorder {
+			return serveRequest(s, http.MethodPut, factory.UdrDrResUriPrefix+uri, "application/json", body,
+				"If-None-Match", "*")
+		}
+		testCases := []struct {
+			name    string
+			uri     string
+			body    string
+			created int
+		}{
+			{"SMF Registration", "/subscription-data/" + ueId + "/context-data/smf-registrations/2",
+				`{"smfInstanceId":"5b9a6f1c-2c6e-4b8e-9f1e-3c1a2b3c4d5e","pduSessionId":2,` +
+					`"singleNssai":{"sst":1},"dnn":"internet","plmnId":{"mcc":"208","mnc":"93"}}`,
+				http.StatusCreated},
+			{"SMS Management Data", "/subscription-data/" + ueId + "/20893/provisioned-data/sms-mng-data",
+				`{"mtSmsSubscribed":true}`, http.StatusCreated},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				rsp := createOnly(tc.uri, tc.body)
+				require.Equal(t, tc.created, rsp.Code, rsp.Body.String())
+				rsp = createOnly(tc.uri, tc.body)
+				require.Equal(t, http.StatusPreconditionFailed, rsp.Code, rsp.Body.String())
+			})
+		}
+
+		// The registration of the AMF exists since the racing AMFs
+		rsp := createOnly("/subscription-data/"+ueId+"/context-data/amf-3gpp-access", registration("amf-3"))
+		require.Equal(t, http.StatusPreconditionFailed, rsp.Code, rsp.Body.String())
+	})
+}
+
+func TestServer_SmsfRegistrations(t *testing.T) {
+	db := newTestDb()
+	s := newTestServerWithDb(t, db)
+
+	const (
+		ueId       = "imsi-208930000000001"
+		contextUri = factory.UdrDrResUriPrefix + "/subscription-data/" + ueId + "/context-data"
+	)
+	serve := func(method, uri, body string) *httptest.ResponseRecorder {
+		return serveRequest(s, method, uri, "application/json", body)
+	}
+	registration := func(smsfInstanceId string) string {
+		return `{"smsfInstanceId":"` + smsfInstanceId + `","plmnId":{"mcc":"208","mnc":"93"},` +
+			`"smsfSetId":"set1.smsfset.5gc.mnc093.mcc208"}`
+	}
+	getSmsfInstanceId := func(access string) string {
+		rsp := serve(http.MethodGet, contextUri+"/"+access, "")
+		require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+		var smsfRegistration models.SmsfRegistration
+		require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &smsfRegistration))
+		return smsfRegistration.SmsfInstanceId
+	}
+
+	for access, smsfInstanceId := range map[string]string{
+		"smsf-3gpp-access":     "8a2c9a4e-4a3c-4d2b-9d3f-7d8b33d5c001",
+		"smsf-non-3gpp-access": "8a2c9a4e-4a3c-4d2b-9d3f-7d8b33d5c002",
+	} {
+		require.Equal(t, http.StatusNotFound, serve(http.MethodGet, contextUri+"/"+access, "").Code)
+		rsp := serve(http.MethodPut, contextUri+"/"+access, registration(smsfInstanceId))
+		require.Equal(t, http.StatusCreated, rsp.Code, rsp.Body.String())
+		require.True(t, strings.HasSuffix(rsp.Header().Get("Location"), "/subscription-data/"+ueId+
+			"/context-data/"+access), rsp.Header().Get("Location"))
+		rsp = serve(http.MethodPut, contextUri+"/"+access, registration(smsfInstanceId))
+		require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+	}
+	require.Equal(t, "8a2c9a4e-4a3c-4d2b-9d3f-7d8b33d5c001", getSmsfInstanceId("smsf-3gpp-access"))
+	require.Equal(t, "8a2c9a4e-4a3c-4d2b-9d3f-7d8b33d5c002", getSmsfInstanceId("smsf-non-3gpp-access"))
+
+	// The registration of one access changes or removes none of the other one
+	rsp := serve(http.MethodPut, contextUri+"/smsf-3gpp-access", registration("8a2c9a4e-4a3c-4d2b-9d3f-7d8b33d5c003"))
+	require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+	require.Equal(t, "8a2c9a4e-4a3c-4d2b-9d3f-7d8b33d5c002", getSmsfInstanceId("smsf-non-3gpp-access"))
+	for i := 0; i < 2; i++ {
+		rsp = serve(http.MethodDelete, contextUri+"/smsf-3gpp-access", "")
+		require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+	}
+	require.Equal(t, http.StatusNotFound, serve(http.MethodGet, contextUri+"/smsf-3gpp-access", "").Code)
+	require.Equal(t, "8a2c9a4e-4a3c-4d2b-9d3f-7d8b33d5c002", getSmsfInstanceId("smsf-non-3gpp-access"))
+
+	for _, body := range []string{
+		`{"plmnId":{"mcc":"208","mnc":"93"}}`,
+		`{"smsfInstanceId":"8a2c9a4e-4a3c-4d2b-9d3f-7d8b33d5c004"}`,
+	} {
+		rsp = serve(http.MethodPut, contextUri+"/smsf-non-3gpp-access", body)
+		require.Equal(t, http.StatusBadRequest, rsp.Code, body)
+	}
+	require.Equal(t, "8a2c9a4e-4a3c-4d2b-9d3f-7d8b33d5c002", getSmsfInstanceId("smsf-non-3gpp-access"))
+}
+
+func TestServer_BulkProvisioningCreateOnly(t *testing.T) {
+	db := newTestDb()
+	s := newTestServerWithDb(t, db)
+
+	provision := func(ifNoneMatch, body string) (int, []processor.ProvisioningResult) {
+		rsp := serveRequest(s, http.MethodPost, factory.UdrDrResUriPrefix+UdrBulkProvisioningPath, "application/json",
+			body, "If-None-Match", ifNoneMatch)
+		var results []processor.ProvisioningResult
+		if rsp.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &results))
+		}
+		return rsp.Code, results
+	}
+	record := func(supi, sequenceNumber string) string {
+		return `{"supi":"` + supi + `","authenticationSubscription":{"authenticationMethod":"5G_AKA",` +
+			`"sequenceNumber":{"sqn":"` + sequenceNumber + `"}}}`
+	}
+	sequenceNumber := func(supi string) interface{} {
+		data, pd := db.GetDataFromDB(context.Background(), database.SUBSCDATA_AUTH_SUBSC_DB_COLLECTION_NAME,
+			bson.M{"ueId": supi})
+		require.Nil(t, pd)
+		return data["sequenceNumber"].(map[string]interface{})["sqn"]
+	}
+
+	code, results := provision("*", `[`+record("imsi-208930000000001", "000000000001")+`]`)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, http.StatusOK, results[0].Status)
+
+	// Only the record of the SUPI already provisioned is refused, and it leaves the stored data as is
+	code, results = provision("*",
+		`[`+record("imsi-208930000000001", "000000000002")+`,`+record("imsi-208930000000002", "000000000002")+`]`)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, http.StatusConflict, results[0].Status)
+	require.NotNil(t, results[0].Error)
+	require.Equal(t, "CONFLICT", results[0].Error.Cause)
+	require.Equal(t, http.StatusOK, results[1].Status)
+	require.Equal(t, "000000000001", sequenceNumber("imsi-208930000000001"))
+
+	// Without If-None-Match the record replaces the stored dataset
+	code, results = provision("", `[`+record("imsi-208930000000001", "000000000003")+`]`)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, http.StatusOK, results[0].Status)
+	require.Equal(t, "000000000003", sequenceNumber("imsi-208930000000001"))
+
+	code, _ = provision(`"1"`, `[`+record("imsi-208930000000003", "000000000001")+`]`)
+	require.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestServer_BulkProvisioningTransaction(t *testing.T) {
+	db := newTestDb()
+	db.failedUpserts = database.SUBSCDATA_AM_DATA_DB_COLLECTION_NAME
+	s := newTestServerWithDb(t, db)
+
+	rsp := serveRequest(s, http.MethodPost, factory.UdrDrResUriPrefix+UdrBulkProvisioningPath, "application/json",
+		`[{"supi":"imsi-208930000000001","authenticationSubscription":`+
+			`{"authenticationMethod":"5G_AKA"}},{"supi":"imsi-208930000000002","servingPlmnId":"20893",`+
+			`"amData":{"gpsis":["msisdn-0900000000"]}},{"supi":"bad"}]`)
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+
+	// The failure of the amData rolls the authentication subscription back as well
+	var results []processor.ProvisioningResult
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &results))
+	require.Len(t, results, 3)
+	require.Equal(t, http.StatusInternalServerError, results[0].Status)
+	require.Equal(t, http.StatusInternalServerError, results[1].Status)
+	require.Contains(t, results[1].Error.Detail, "amData")
+	require.Equal(t, http.StatusBadRequest, results[2].Status)
+	_, pd := db.GetDataFromDB(context.Background(), database.SUBSCDATA_AUTH_SUBSC_DB_COLLECTION_NAME,
+		bson.M{"ueId": "imsi-208930000000001"})
+	require.NotNil(t, pd)
+	require.Equal(t, http.StatusNotFound, int(pd.Status))
+}
+
+func TestServer_BulkProvisioningWithoutTransaction(t *testing.T) {
+	db := newTestDb()
+	db.failedUpserts = database.SUBSCDATA_AM_DATA_DB_COLLECTION_NAME
+	db.noTransactions = true
+	s := newTestServerWithDb(t, db)
+
+	rsp := serveRequest(s, http.MethodPost, factory.UdrDrResUriPrefix+UdrBulkProvisioningPath, "application/json",
+		`[{"supi":"imsi-208930000000001","authenticationSubscription":`+
+			`{"authenticationMethod":"5G_AKA"}},{"supi":"imsi-208930000000002","servingPlmnId":"20893",`+
+			`"amData":{"gpsis":["msisdn-0900000000"]}},{"supi":"imsi-208930000000003","servingPlmnId":"20893",`+
+			`"smfSelectionData":{"supportedFeatures":"1"}}]`)
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+
+	// The authentication subscription is written before the amData fails, the SMF selection data after it is not
+	var results []processor.ProvisioningResult
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &results))
+	require.Len(t, results, 3)
+	require.Equal(t, http.StatusOK, results[0].Status)
+	require.NotNil(t, db.document(t, database.SUBSCDATA_AUTH_SUBSC_DB_COLLECTION_NAME,
+		bson.M{"ueId": "imsi-208930000000001"}))
+	require.Equal(t, http.StatusInternalServerError, results[1].Status)
+	require.Contains(t, results[1].Error.Detail, "amData")
+	require.Equal(t, http.StatusInternalServerError, results[2].Status)
+	require.Contains(t, results[2].Error.Detail, "smfSelectionData: not written")
+	require.Nil(t, db.document(t, database.SUBSCDATA_SMF_SEL_DB_COLLECTION_NAME,
+		bson.M{"ueId": "imsi-208930000000003"}))
+}
+
+func TestServer_EeSubscriptions(t *testing.T) {
+	db := newTestDb()
+	s := newTestServerWithDb(t, db)
+
+	const (
+		ueId         = "extgroupid-group1@free5gc.org"
+		eeSubsUri    = factory.UdrDrResUriPrefix + "/subscription-data/" + ueId + "/context-data/ee-subscriptions"
+		subscription = `{"callbackReference":"http://udm.free5gc.org/ee-callback","monitoringConfigurations":{}}`
+		amfSubsInfos = `[{"amfInstanceId":"8a2c9a4e-4a3c-4d2b-9d3f-7d8b33d5c001","subscriptionId":"1"}]`
+	)
+	create := func() string {
+		rsp := serveRequest(s, http.MethodPost, eeSubsUri, "application/json", subscription)
+		require.Equal(t, http.StatusCreated, rsp.Code, rsp.Body.String())
+		location := rsp.Header().Get("Location")
+		require.True(t, strings.HasPrefix(location, "http://example.com"+eeSubsUri+"/"), location)
+		return strings.TrimPrefix(location, "http://example.com"+eeSubsUri+"/")
+	}
+
+	subsId := create()
+	require.NotEqual(t, subsId, create())
+	rsp := serveRequest(s, http.MethodGet, eeSubsUri, "", "")
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+	var eeSubscriptions []map[string]interface{}
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &eeSubscriptions))
+	require.Len(t, eeSubscriptions, 2)
+	require.NotContains(t, eeSubscriptions[0], "subsId")
+
+	rsp = serveRequest(s, http.MethodPut, eeSubsUri+"/"+subsId, "application/json",
+		`{"callbackReference":"http://udm.free5gc.org/ee-callback2","monitoringConfigurations":{}}`)
+	require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+	rsp = serveRequest(s, http.MethodGet, eeSubsUri+"/"+subsId, "", "")
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+	require.Contains(t, rsp.Body.String(), "ee-callback2")
+	rsp = serveRequest(s, http.MethodPut, eeSubsUri+"/unknown", "application/json", subscription)
+	require.Equal(t, http.StatusNotFound, rsp.Code, rsp.Body.String())
+
+	amfSubsUri := eeSubsUri + "/" + subsId + "/amf-subscriptions"
+	rsp = serveRequest(s, http.MethodGet, amfSubsUri, "", "")
+	require.Equal(t, http.StatusNotFound, rsp.Code)
+	require.Contains(t, rsp.Body.String(), "AMFSUBSCRIPTION_NOT_FOUND")
+	rsp = serveRequest(s, http.MethodPut, amfSubsUri, "application/json", amfSubsInfos)
+	require.Equal(t, http.StatusCreated, rsp.Code, rsp.Body.String())
+	rsp = serveRequest(s, http.MethodPut, amfSubsUri, "application/json", amfSubsInfos)
+	require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+	rsp = serveRequest(s, http.MethodPatch, amfSubsUri, "application/json-patch+json",
+		`[{"op":"replace","path":"/0/subscriptionId","value":"2"}]`)
+	require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+	rsp = serveRequest(s, http.MethodGet, amfSubsUri, "", "")
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+	require.JSONEq(t, `[{"amfInstanceId":"8a2c9a4e-4a3c-4d2b-9d3f-7d8b33d5c001","subscriptionId":"2"}]`,
+		rsp.Body.String())
+
+	// The AMF subscriptions go along with the EE subscription
+	rsp = serveRequest(s, http.MethodDelete, eeSubsUri+"/"+subsId, "", "")
+	require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+	require.Equal(t, http.StatusNotFound, serveRequest(s, http.MethodGet, eeSubsUri+"/"+subsId, "", "").Code)
+	require.Nil(t, db.document(t, database.SUBSCDATA_EE_AMF_SUBSC_DB_COLLECTION_NAME,
+		bson.M{"ueId": ueId, "subsId": subsId}))
+	rsp = serveRequest(s, http.MethodPut, amfSubsUri, "application/json", amfSubsInfos)
+	require.Equal(t, http.StatusNotFound, rsp.Code)
+	require.Contains(t, rsp.Body.String(), "SUBSCRIPTION_NOT_FOUND")
+	require.Equal(t, http.StatusNotFound, serveRequest(s, http.MethodDelete, eeSubsUri+"/"+subsId, "", "").Code)
+
+	rsp = serveRequest(s, http.MethodGet,
+		factory.UdrDrResUriPrefix+"/subscription-data/group1/context-data/ee-subscriptions", "", "")
+	require.Equal(t, http.StatusBadRequest, rsp.Code)
+}
+
+func TestServer_DeleteSubscriber(t *testing.T) {
+	db := newTestDb()
+	s := newTestServerWithDb(t, db)
+
+	const (
+		ueId      = "imsi-208930000000001"
+		otherUeId = "imsi-208930000000002"
+	)
+	for _, supi := range []string{ueId, otherUeId} {
+		db.seed(t, database.SUBSCDATA_AUTH_SUBSC_DB_COLLECTION_NAME, bson.M{"ueId": supi},
+			map[string]interface{}{"authenticationMethod": "5G_AKA"})
+		for _, servingPlmnId := range []string{"20893", "20894"} {
+			filter := bson.M{"ueId": supi, "servingPlmnId": servingPlmnId}
+			db.seed(t, database.SUBSCDATA_AM_DATA_DB_COLLECTION_NAME, filter, map[string]interface{}{})
+		}
+	}
+	db.seed(t, "policyData.ues.amData", bson.M{"ueId": ueId}, map[string]interface{}{"ueId": ueId})
+	db.seed(t, "subscriptionData.ppData", bson.M{"ueId": ueId}, map[string]interface{}{"ueId": ueId})
+
+	serve := func(ueId string) *httptest.ResponseRecorder {
+		return serveRequest(s, http.MethodDelete, factory.UdrDrResUriPrefix+"/subscription-data/"+ueId, "", "")
+	}
+
+	rsp := serve(ueId)
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+	var removed processor.SubscriberDeletion
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &removed))
+	require.Equal(t, processor.SubscriberDeletion{
+		UeId:          ueId,
+		Transactional: true,
+		Deleted: map[string]int64{
+			database.SUBSCDATA_AUTH_SUBSC_DB_COLLECTION_NAME: 1,
+			database.SUBSCDATA_AM_DATA_DB_COLLECTION_NAME:    2,
+			"policyData.ues.amData":                          1,
+			"subscriptionData.ppData":                        1,
+		},
+	}, removed)
+	remaining := db.Documents()
+	require.Len(t, remaining[database.SUBSCDATA_AUTH_SUBSC_DB_COLLECTION_NAME], 1)
+	require.Len(t, remaining[database.SUBSCDATA_AM_DATA_DB_COLLECTION_NAME], 2)
+	require.Len(t, remaining, 2)
+	for _, docs := range remaining {
+		for _, doc := range docs {
+			require.Equal(t, otherUeId, doc["ueId"])
+		}
+	}
+
+	rsp = serve(ueId)
+	require.Equal(t, http.StatusNotFound, rsp.Code, rsp.Body.String())
+	require.Equal(t, http.StatusBadRequest, serve("msisdn-0900000000").Code)
+}
+
+func TestServer_ModifySdmSubscription(t *testing.T) {
+	s := newTestServerWithDb(t, newTestDb())
+	udrSelf := udr_context.GetSelf()
+
+	const (
+		ueId         = "imsi-208930000000031"
+		sdmSubsUri   = factory.UdrDrResUriPrefix + "/subscription-data/" + ueId + "/context-data/sdm-subscriptions"
+		nfInstanceId = "8a2c9a4e-4a3c-4d2b-9d3f-7d8b33d5c002"
+	)
+	create := func(expires time.Time) string {
+		rsp := serveRequest(s, http.MethodPost, sdmSubsUri, "application/json", fmt.Sprintf(
+			`{"nfInstanceId":%q,"callbackReference":"http://udm.free5gc.org/sdm-callback",`+
+				`"monitoredResourceUris":["http://udr.free5gc.org/am-data"],"expires":%q}`,
+			nfInstanceId, expires.Format(time.RFC3339)))
+		require.Equal(t, http.StatusCreated, rsp.Code, rsp.Body.String())
+		var subscription models.SdmSubscription
+		require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &subscription))
+		return subscription.SubscriptionId
+	}
+
+	subsId := create(time.Now().Add(time.Hour))
+	expires := time.Now().Add(2 * time.Hour).UTC().Truncate(time.Second)
+	rsp := serveRequest(s, http.MethodPatch, sdmSubsUri+"/"+subsId, MediaTypeMergePatch,
+		fmt.Sprintf(`{"expires":%q,"monitoredResourceUris":["http://udr.free5gc.org/sm-data"]}`,
+			expires.Format(time.RFC3339)))
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+	var subscription models.SdmSubscription
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &subscription))
+	require.Equal(t, subsId, subscription.SubscriptionId)
+	require.Equal(t, nfInstanceId, subscription.NfInstanceId)
+	require.True(t, expires.Equal(*subscription.Expires))
+	require.Equal(t, []string{"http://udr.free5gc.org/sm-data"}, subscription.MonitoredResourceUris)
+
+	rsp = serveRequest(s, http.MethodPatch, sdmSubsUri+"/"+subsId, MediaTypeJSONPatch,
+		`[{"op":"replace","path":"/nfInstanceId","value":"other"}]`)
+	require.Equal(t, http.StatusForbidden, rsp.Code, rsp.Body.String())
+	rsp = serveRequest(s, http.MethodPut, sdmSubsUri+"/"+subsId, "application/json",
+		`{"nfInstanceId":"other","callbackReference":"http://udm.free5gc.org/sdm-callback"}`)
+	require.Equal(t, http.StatusForbidden, rsp.Code, rsp.Body.String())
+
+	// An expired subscription is gone before the sweeper removes it
+	expiredId := create(time.Now().Add(time.Second))
+	rsp = serveRequest(s, http.MethodGet, sdmSubsUri, "", "")
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+	require.Contains(t, rsp.Body.String(), `"subscriptionId":"`+expiredId+`"`)
+	value, ok := udrSelf.UESubsCollection.Load(ueId)
+	require.True(t, ok)
+	past := time.Now().Add(-time.Minute)
+	value.(*udr_context.UESubsData).SdmSubscriptions[expiredId].Expires = &past
+	rsp = serveRequest(s, http.MethodPatch, sdmSubsUri+"/"+expiredId, MediaTypeMergePatch, `{"expires":null}`)
+	require.Equal(t, http.StatusNotFound, rsp.Code, rsp.Body.String())
+	rsp = serveRequest(s, http.MethodGet, sdmSubsUri, "", "")
+	require.NotContains(t, rsp.Body.String(), `"subscriptionId":"`+expiredId+`"`)
+
+	s.Processor().SweepExpiredSubscriptions(time.Now())
+	require.NotContains(t, value.(*udr_context.UESubsData).SdmSubscriptions, expiredId)
+	require.Contains(t, value.(*udr_context.UESubsData).SdmSubscriptions, subsId)
+	rsp = serveRequest(s, http.MethodPatch, sdmSubsUri+"/"+expiredId, MediaTypeMergePatch, `{"expires":null}`)
+	require.Equal(t, http.StatusNotFound, rsp.Code, rsp.Body.String())
+	require.Contains(t, rsp.Body.String(), "SUBSCRIPTION_NOT_FOUND")
+}
+
+func TestServer_GetSharedData(t *testing.T) {
+	db := newTestDb()
+	s := newTestServerWithDb(t, db)
+
+	const sharedDataUri = factory.UdrDrResUriPrefix + "/subscription-data/shared-data"
+	for _, sharedDataId := range []string{"20893-1", "20893-2"} {
+		_, err := db.ReplaceDataToDB(context.Background(), "subscriptionData.sharedData",
+			bson.M{"sharedDataId": sharedDataId},
+			map[string]interface{}{"sharedDataId": sharedDataId, "sharedAmData": map[string]interface{}{}})
+		require.NoError(t, err)
+	}
+	get := func(uri string) *httptest.ResponseRecorder {
+		return serveRequest(s, http.MethodGet, uri, "", "")
+	}
+	sharedDataIdsOf := func(rsp *httptest.ResponseRecorder) []string {
+		var sharedData []models.UdmSdmSharedData
+		require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &sharedData))
+		var sharedDataIds []string
+		for _, data := range sharedData {
+			sharedDataIds = append(sharedDataIds, data.SharedDataId)
+		}
+		return sharedDataIds
+	}
+
+	rsp := get(sharedDataUri + "?shared-data-ids=20893-2,20893-3,20893-1&supported-features=1")
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+	require.Equal(t, []string{"20893-2", "20893-1"}, sharedDataIdsOf(rsp))
+	rsp = get(sharedDataUri + "?shared-data-ids=20893-1&shared-data-ids=20893-1&supportedFeatures=1")
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+	require.Equal(t, []string{"20893-1"}, sharedDataIdsOf(rsp))
+	rsp = get(sharedDataUri + "?shared-data-ids=20893-3")
+	require.Equal(t, http.StatusNotFound, rsp.Code, rsp.Body.String())
+
+	for _, query := range []string{"", "?shared-data-ids=", "?shared-data-ids=20893-1,",
+		"?shared-data-ids=20893-1&supportedFeatures=xyz"} {
+		rsp = get(sharedDataUri + query)
+		require.Equal(t, http.StatusBadRequest, rsp.Code, query)
+	}
+
+	rsp = get(sharedDataUri + "/20893-2?supported-features=1")
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+	require.JSONEq(t, `{"sharedDataId":"20893-2","sharedAmData":{}}`, rsp.Body.String())
+	rsp = get(sharedDataUri + "/20893-3")
+	require.Equal(t, http.StatusNotFound, rsp.Code, rsp.Body.String())
+}
+
+func TestServer_GroupIdentifiers(t *testing.T) {
+	s := newTestServerWithDb(t, newTestDb())
+	factory.UdrConfig.Configuration.Pagination = &factory.Pagination{DefaultPageSize: 2, MaxPageSize: 2}
+
+	const groupIdentifiersUri = factory.UdrDrResUriPrefix + UdrGroupIdentifiersPath
+	serve := func(method, uri, body string) *httptest.ResponseRecorder {
+		return serveRequest(s, method, uri, "application/json", body)
+	}
+	const group = `{"extGroupId":"extgroupid-group1@example.com","intGroupId":"20893001-001-01-01",` +
+		`"ueIdList":[{"supi":"imsi-208930000000001"},{"supi":"imsi-208930000000002"},` +
+		`{"supi":"imsi-208930000000003"}]}`
+
+	rsp := serve(http.MethodPut, groupIdentifiersUri, group)
+	require.Equal(t, http.StatusCreated, rsp.Code, rsp.Body.String())
+	require.Contains(t, rsp.Header().Get("Location"), "group-identifiers?int-group-id=20893001-001-01-01")
+	rsp = serve(http.MethodPut, groupIdentifiersUri, group)
+	require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+	rsp = serve(http.MethodPut, groupIdentifiersUri,
+		`{"extGroupId":"extgroupid-group1@example.com","intGroupId":"20893001-001-01-02"}`)
+	require.Equal(t, http.StatusConflict, rsp.Code, rsp.Body.String())
+	for _, body := range []string{`{"extGroupId":"group1","intGroupId":"20893001-001-01-02"}`,
+		`{"extGroupId":"extgroupid-group2@example.com","intGroupId":"20893001-001-01-02",` +
+			`"ueIdList":[{"supi":"imsi-208930000000001"},{"supi":"imsi-208930000000001"}]}`} {
+		rsp = serve(http.MethodPut, groupIdentifiersUri, body)
+		require.Equal(t, http.StatusBadRequest, rsp.Code, body)
+	}
+
+	rsp = serve(http.MethodGet, groupIdentifiersUri+"?ext-group-id=extgroupid-group1@example.com", "")
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+	require.JSONEq(t, `{"extGroupId":"extgroupid-group1@example.com","intGroupId":"20893001-001-01-01"}`,
+		rsp.Body.String())
+
+	rsp = serve(http.MethodGet, groupIdentifiersUri+"?int-group-id=20893001-001-01-01&ue-id-ind=true", "")
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+	var groupIdentifiers models.GroupIdentifiers
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &groupIdentifiers))
+	require.Len(t, groupIdentifiers.UeIdList, 2)
+	require.Contains(t, rsp.Header().Get("Link"), "page-number=2")
+	rsp = serve(http.MethodGet, groupIdentifiersUri+
+		"?internal-group-id=20893001-001-01-01&supported-features=1&page-number=2", "")
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+	groupIdentifiers = models.GroupIdentifiers{}
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &groupIdentifiers))
+	require.Equal(t, []models.UdmSdmUeId{{Supi: "imsi-208930000000003"}}, groupIdentifiers.UeIdList)
+	require.Empty(t, rsp.Header().Get("Link"))
+
+	rsp = serve(http.MethodGet, groupIdentifiersUri+"?int-group-id=20893001-001-01-02", "")
+	require.Equal(t, http.StatusNotFound, rsp.Code, rsp.Body.String())
+	for _, query := range []string{"", "?ext-group-id=extgroupid-group1@example.com&int-group-id=20893001-001-01-01",
+		"?int-group-id=20893001-001-01-01&page-size=3", "?int-group-id=20893001-001-01-01&ue-id-ind=yes"} {
+		rsp = serve(http.MethodGet, groupIdentifiersUri+query, "")
+		require.Equal(t, http.StatusBadRequest, rsp.Code, query)
+		require.Contains(t, rsp.Body.String(), "INVALID_QUERY_PARAM", query)
+	}
+	rsp = serve(http.MethodGet, groupIdentifiersUri+"?int-group-id=20893001-001-01-01&ext-group-id=x", "")
+	var problemDetails models.ProblemDetails
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &problemDetails))
+	require.Len(t, problemDetails.InvalidParams, 1)
+}
+
+func TestServer_OperSpecData(t *testing.T) {
+	s := newTestServerWithDb(t, newTestDb())
+
+	const operSpecDataUri = factory.UdrDrResUriPrefix + "/subscription-data/imsi-208930000000001/operator-specific-data"
+
+	rsp := serveRequest(s, http.MethodGet, operSpecDataUri, "", "")
+	require.Equal(t, http.StatusNotFound, rsp.Code, rsp.Body.String())
+
+	const operSpecData = `{"tariff":{"dataType":"string","value":"gold"},` +
+		`"quota":{"dataType":"integer","value":10},"roaming":{"dataType":"boolean","value":true}}`
+	rsp = serveRequest(s, http.MethodPut, operSpecDataUri, "application/json", operSpecData)
+	require.Equal(t, http.StatusCreated, rsp.Code, rsp.Body.String())
+	rsp = serveRequest(s, http.MethodPut, operSpecDataUri, "application/json", operSpecData)
+	require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+	for _, body := range []string{`{"tariff":{"dataType":"number","value":"gold"}}`,
+		`{"quota":{"dataType":"integer","value":1.5}}`, `{"a.b":{"dataType":"boolean","value":true}}`} {
+		rsp = serveRequest(s, http.MethodPut, operSpecDataUri, "application/json", body)
+		require.Equal(t, http.StatusBadRequest, rsp.Code, body)
+	}
+
+	rsp = serveRequest(s, http.MethodGet, operSpecDataUri+"?fields=tariff,quota", "", "")
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+	require.JSONEq(t, `{"tariff":{"dataType":"string","value":"gold"},"quota":{"dataType":"integer","value":10}}`,
+		rsp.Body.String())
+
+	rsp = serveRequest(s, http.MethodPatch, operSpecDataUri, MediaTypeMergePatch,
+		`{"roaming":null,"profile":{"dataType":"object","value":{"tier":2}}}`)
+	require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+	rsp = serveRequest(s, http.MethodPatch, operSpecDataUri, MediaTypeJSONPatch,
+		`[{"op":"replace","path":"/tariff/value","value":"silver"}]`)
+	require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+	rsp = serveRequest(s, http.MethodPatch, operSpecDataUri, MediaTypeJSONPatch,
+		`[{"op":"replace","path":"/quota/value","value":"ten"}]`)
+	require.Equal(t, http.StatusBadRequest, rsp.Code, rsp.Body.String())
+	rsp = serveRequest(s, http.MethodGet, operSpecDataUri, "", "")
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+	require.JSONEq(t, `{"tariff":{"dataType":"string","value":"silver"},"quota":{"dataType":"integer","value":10},`+
+		`"profile":{"dataType":"object","value":{"tier":2}}}`, rsp.Body.String())
+
+	rsp = serveRequest(s, http.MethodDelete, operSpecDataUri, "", "")
+	require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+	rsp = serveRequest(s, http.MethodGet, operSpecDataUri, "", "")
+	require.Equal(t, http.StatusNotFound, rsp.Code, rsp.Body.String())
+	rsp = serveRequest(s, http.MethodPatch, operSpecDataUri, MediaTypeMergePatch, `{"roaming":null}`)
+	require.Equal(t, http.StatusNotFound, rsp.Code, rsp.Body.String())
+}
+
+func TestServer_QueryAmDataIfNoneMatch(t *testing.T) {
+	db := newTestDb()
+	s := newTestServerWithDb(t, db)
+
+	const amDataUri = factory.UdrDrResUriPrefix + "/subscription-data/imsi-208930000000001/20893/provisioned-data/am-data"
+	_, err := db.ReplaceDataToDB(context.Background(), "subscriptionData.provisionedData.amData",
+		bson.M{"ueId": "imsi-208930000000001", "servingPlmnId": "20893"},
+		map[string]interface{}{"ueId": "imsi-208930000000001", "servingPlmnId": "20893", "gpsis": []string{}})
+	require.NoError(t, err)
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		return serveRequest(s, http.MethodGet, amDataUri, "", "", "If-None-Match", ifNoneMatch)
+	}
+
+	rsp := get("")
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+	etag := rsp.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	rsp = get(etag)
+	require.Equal(t, http.StatusNotModified, rsp.Code)
+	require.Empty(t, rsp.Body.String())
+	require.Equal(t, etag, rsp.Header().Get("ETag"))
+
+	rsp = serveRequest(s, http.MethodPatch, amDataUri, MediaTypeMergePatch, `{"gpsis":["msisdn-0900000001"]}`)
+	require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+	require.NotEqual(t, etag, rsp.Header().Get("ETag"))
+
+	// The document changed since the client read it
+	rsp = get(etag)
+	require.Equal(t, http.StatusOK, rsp.Code)
+	require.Contains(t, rsp.Body.String(), "msisdn-0900000001")
+	rsp = get(`W/` + rsp.Header().Get("ETag"))
+	require.Equal(t, http.StatusNotModified, rsp.Code)
+	etag = rsp.Header().Get("ETag")
+
+	// A projection is answered with the ETag of its own content
+	getProjected := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		return serveRequest(s, http.MethodGet, amDataUri+"?fields=gpsis", "", "", "If-None-Match", ifNoneMatch)
+	}
+	rsp = getProjected(etag)
+	require.Equal(t, http.StatusOK, rsp.Code)
+	require.JSONEq(t, `{"gpsis":["msisdn-0900000001"]}`, rsp.Body.String())
+	projected := rsp.Header().Get("ETag")
+	require.NotEqual(t, etag, projected)
+	rsp = getProjected(projected)
+	require.Equal(t, http.StatusNotModified, rsp.Code)
+}
+
+func TestServer_ConditionalGet(t *testing.T) {
+	db := newTestDb()
+	s := newTestServerWithDb(t, db)
+
+	const ueId = "imsi-208930000000001"
+	testCases := []struct {
+		name     string
+		uri      string
+		collName string
+		filter   bson.M
+		data     map[string]interface{}
+		modified map[string]interface{}
+	}{
+		{
+			name:     "Trace Data",
+			uri:      "/subscription-data/" + ueId + "/20893/provisioned-data/trace-data",
+			collName: "subscriptionData.provisionedData.traceData",
+			filter:   bson.M{"ueId": ueId, "servingPlmnId": "20893"},
+			data:     map[string]interface{}{"traceRef": "208930-000001", "traceDepth": "MINIMUM"},
+			modified: map[string]interface{}{"traceRef": "208930-000001", "traceDepth": "MAXIMUM"},
+		},
+		{
+			name:     "SMF Selection Data",
+			uri:      "/subscription-data/" + ueId + "/20893/provisioned-data/smf-selection-subscription-data",
+			collName: "subscriptionData.provisionedData.smfSelectionSubscriptionData",
+			filter:   bson.M{"ueId": ueId, "servingPlmnId": "20893"},
+			data:     map[string]interface{}{"supportedFeatures": "1"},
+			modified: map[string]interface{}{"supportedFeatures": "3"},
+		},
+		{
+			name:     "SM Data Of Several Documents",
+			uri:      "/subscription-data/" + ueId + "/20893/provisioned-data/sm-data",
+			collName: "subscriptionData.provisionedData.smData",
+			filter:   bson.M{"ueId": ueId, "servingPlmnId": "20893", "singleNssai.sst": 1},
+			data:     map[string]interface{}{"singleNssai": map[string]interface{}{"sst": 1}},
+			modified: map[string]interface{}{"singleNssai": map[string]interface{}{"sst": 1, "sd": "010203"}},
+		},
+		{
+			name:     "Policy AM Data",
+			uri:      "/policy-data/ues/" + ueId + "/am-data",
+			collName: "policyData.ues.amData",
+			filter:   bson.M{"ueId": ueId},
+			data:     map[string]interface{}{"subscCats": []interface{}{"free5gc"}},
+			modified: map[string]interface{}{"subscCats": []interface{}{"free5gc", "gold"}},
+		},
+		{
+			name:     "Policy UE Policy Set",
+			uri:      "/policy-data/ues/" + ueId + "/ue-policy-set",
+			collName: "policyData.ues.uePolicySet",
+			filter:   bson.M{"ueId": ueId},
+			data:     map[string]interface{}{"subscCats": []interface{}{"free5gc"}},
+			modified: map[string]interface{}{"subscCats": []interface{}{"gold"}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			write := func(data map[string]interface{}) {
+				doc := maps.Clone(data)
+				for field, value := range tc.filter {
+					if !strings.Contains(field, ".") {
+						doc[field] = value
+					}
+				}
+				_, err := db.ReplaceDataToDB(context.Background(), tc.collName, tc.filter, doc)
+				require.NoError(t, err)
+			}
+			get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+				return serveRequest(s, http.MethodGet, factory.UdrDrResUriPrefix+tc.uri, "", "", "If-None-Match", ifNoneMatch)
+			}
+
+			write(tc.data)
+			rsp := get("")
+			require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+			etag := rsp.Header().Get("ETag")
+			require.NotEmpty(t, etag)
+
+			// Match
+			rsp = get(etag)
+			require.Equal(t, http.StatusNotModified, rsp.Code)
+			require.Empty(t, rsp.Body.String())
+			require.Equal(t, etag, rsp.Header().Get("ETag"))
+
+			// Mismatch
+			rsp = get(`"stale"`)
+			require.Equal(t, http.StatusOK, rsp.Code)
+			require.NotEmpty(t, rsp.Body.String())
+			require.Equal(t, etag, rsp.Header().Get("ETag"))
+
+			// Wildcard
+			rsp = get("*")
+			require.Equal(t, http.StatusNotModified, rsp.Code)
+			require.Empty(t, rsp.Body.String())
+
+			// The write moves the ETag on
+			write(tc.modified)
+			rsp = get(etag)
+			require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+			require.NotEqual(t, etag, rsp.Header().Get("ETag"))
+			rsp = get(rsp.Header().Get("ETag"))
+			require.Equal(t, http.StatusNotModified, rsp.Code)
+		})
+	}
+}
+
+func TestServer_PpData(t *testing.T) {
+	s := newTestServerWithDb(t, newTestDb())
+
+	const (
+		ueId      = "imsi-208930000000001"
+		ppDataUri = factory.UdrDrResUriPrefix + "/subscription-data/" + ueId + "/pp-data"
+	)
+	serve := func(method, contentType, body string) *httptest.ResponseRecorder {
+		return serveRequest(s, method, ppDataUri, contentType, body)
+	}
+
+	notified := make(chan models.DataChangeNotify, 4)
+	callbackUri := newCallbackServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var notify models.DataChangeNotify
+		if err := json.NewDecoder(r.Body).Decode(&notify); err == nil {
+			notified <- notify
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	udrSelf := udr_context.GetSelf()
+	udrSelf.SubscriptionDataSubscriptions = make(map[string]*models.SubscriptionDataSubscriptions)
+	subscriptionId := udrSelf.AddSubscriptionDataSubscription(&models.SubscriptionDataSubscriptions{
+		UeId:                  ueId,
+		CallbackReference:     callbackUri,
+		MonitoredResourceUris: []string{ppDataUri},
+	})
+	t.Cleanup(func() { udrSelf.RemoveSubscriptionDataSubscription(subscriptionId) })
+	requireNotified := func() {
+		select {
+		case notify := <-notified:
+			require.Equal(t, ueId, notify.UeId)
+			require.True(t, strings.HasSuffix(notify.NotifyItems[0].ResourceId, "/pp-data"))
+		case <-time.After(2 * time.Second):
+			require.Fail(t, "no data change notification")
+		}
+	}
+
+	rsp := serve(http.MethodGet, "", "")
+	require.Equal(t, http.StatusNotFound, rsp.Code, rsp.Body.String())
+
+	// The first PATCH creates the PpData
+	rsp = serve(http.MethodPatch, MediaTypeMergePatch, `{"communicationCharacteristics":{"ppMaximumLatency":`+
+		`{"maximumLatency":30,"afInstanceId":"af1","referenceId":1}},"ecRestriction":{"afInstanceId":"af1",`+
+		`"referenceId":1,"plmnEcInfos":[{"plmnId":{"mcc":"208","mnc":"93"},"ecRestrictionDataNb":true}]}}`)
+	require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+	requireNotified()
+
+	rsp = serve(http.MethodPatch, MediaTypeJSONPatch, `[{"op":"add","path":"/expectedUeBehaviourParameters",`+
+		`"value":{"afInstanceId":"af1","referenceId":2,"periodicTime":3600}}]`)
+	require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+	requireNotified()
+
+	rsp = serve(http.MethodGet, "", "")
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+	require.NotContains(t, rsp.Body.String(), "ueId")
+	var ppData models.PpData
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &ppData))
+	require.Equal(t, int32(30), ppData.CommunicationCharacteristics.PpMaximumLatency.MaximumLatency)
+	require.Equal(t, int32(3600), ppData.ExpectedUeBehaviourParameters.PeriodicTime)
+	require.True(t, ppData.EcRestriction.PlmnEcInfos[0].EcRestrictionDataNb)
+
+	// A patch leaving an invalid PpData changes none of it
+	for contentType, body := range map[string]string{
+		MediaTypeMergePatch: `{"communicationCharacteristics":{"ppMaximumLatency":{"maximumLatency":-1}}}`,
+		MediaTypeJSONPatch:  `[{"op":"add","path":"/unknown","value":1}]`,
+		"":                  `[{"op":"replace","path":"/ueId","value":"imsi-208930000000002"}]`,
+	} {
+		if contentType == "" {
+			contentType = MediaTypeJSONPatch
+		}
+		rsp = serve(http.MethodPatch, contentType, body)
+		require.Equal(t, http.StatusUnprocessableEntity, rsp.Code, body)
+	}
+	rsp = serve(http.MethodGet, "", "")
+	require.Contains(t, rsp.Body.String(), `"maximumLatency":30`)
+	select {
+	case <-notified:
+		require.Fail(t, "data change notified of a rejected PATCH")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestServer_ModifySmData(t *testing.T) {
+	db := newTestDb()
+	s := newTestServerWithDb(t, db)
+
+	const (
+		ueId      = "imsi-208930000000001"
+		smDataUri = factory.UdrDrResUriPrefix + "/subscription-data/" + ueId + "/20893/provisioned-data/sm-data"
+		collName  = "subscriptionData.provisionedData.smData"
+	)
+	dnnConfiguration := func(fiveQi int) map[string]interface{} {
+		return map[string]interface{}{
+			"pduSessionTypes": map[string]interface{}{"defaultSessionType": "IPV4"},
+			"sscModes":        map[string]interface{}{"defaultSscMode": "SSC_MODE_1"},
+			"5gQosProfile": map[string]interface{}{
+				"5qi": fiveQi, "priorityLevel": 8,
+				"arp": map[string]interface{}{"priorityLevel": 15, "preemptCap": "NOT_PREEMPT",
+					"preemptVuln": "NOT_PREEMPTABLE"},
+			},
+		}
+	}
+	smData := map[string]map[string]interface{}{
+		"010203": {"internet": dnnConfiguration(9), "ims": dnnConfiguration(5)},
+		"":       {"internet": dnnConfiguration(9)},
+	}
+	for sd, dnnConfigurations := range smData {
+		filter := bson.M{"ueId": ueId, "servingPlmnId": "20893", "singleNssai.sst": 1}
+		singleNssai := map[string]interface{}{"sst": 1}
+		if sd != "" {
+			filter["singleNssai.sd"], singleNssai["sd"] = sd, sd
+		} else {
+			filter["singleNssai.sd"] = bson.M{"$exists": false}
+		}
+		_, err := db.ReplaceDataToDB(context.Background(), collName, filter, map[string]interface{}{
+			"ueId": ueId, "servingPlmnId": "20893", "singleNssai": singleNssai,
+			"dnnConfigurations": dnnConfigurations,
+		})
+		require.NoError(t, err)
+	}
+	patch := func(body string) *httptest.ResponseRecorder {
+		return serveRequest(s, http.MethodPatch, smDataUri, MediaTypeJSONPatch, body)
+	}
+	fiveQiOf := func(sd string, dnn string) interface{} {
+		filter := bson.M{"ueId": ueId, "servingPlmnId": "20893", "singleNssai.sst": 1, "singleNssai.sd": sd}
+		data, pd := db.GetDataFromDB(context.Background(), collName, filter)
+		require.Nil(t, pd)
+		return fieldOf(data, "dnnConfigurations."+dnn+".5gQosProfile.5qi")
+	}
+
+	notified := make(chan models.DataChangeNotify, 4)
+	callbackUri := newCallbackServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var notify models.DataChangeNotify
+		if err := json.NewDecoder(r.Body).Decode(&notify); err == nil {
+			notified <- notify
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	udrSelf := udr_context.GetSelf()
+	udrSelf.SubscriptionDataSubscriptions = make(map[string]*models.SubscriptionDataSubscriptions)
+	// The subscription follows the internet DNN of the S-NSSAI 01010203 only
+	subscriptionId := udrSelf.AddSubscriptionDataSubscription(&models.SubscriptionDataSubscriptions{
+		UeId:                  ueId,
+		CallbackReference:     callbackUri,
+		MonitoredResourceUris: []string{smDataUri + "/01010203/dnnConfigurations/internet"},
+	})
+	t.Cleanup(func() { udrSelf.RemoveSubscriptionDataSubscription(subscriptionId) })
+	requireNotNotified := func() {
+		select {
+		case <-notified:
+			require.Fail(t, "data change notified of another DNN")
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	rsp := patch(`[{"op":"test","path":"/01010203/dnnConfigurations/internet/5gQosProfile/5qi","value":9},` +
+		`{"op":"replace","path":"/01010203/dnnConfigurations/internet/5gQosProfile/5qi","value":6}]`)
+	require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+	require.EqualValues(t, 6, fiveQiOf("010203", "internet"))
+	// Only the DNN patched changes, the one of the same name under the other S-NSSAI included
+	require.EqualValues(t, 5, fiveQiOf("010203", "ims"))
+	data, pd := db.GetDataFromDB(context.Background(), collName, bson.M{"ueId": ueId, "servingPlmnId": "20893",
+		"singleNssai.sst": 1, "singleNssai.sd": bson.M{"$exists": false}})
+	require.Nil(t, pd)
+	require.EqualValues(t, 9, fieldOf(data, "dnnConfigurations.internet.5gQosProfile.5qi"))
+	select {
+	case notify := <-notified:
+		require.Len(t, notify.NotifyItems, 1)
+		require.True(t, strings.HasSuffix(notify.NotifyItems[0].ResourceId,
+			"/sm-data/01010203/dnnConfigurations/internet"), notify.NotifyItems[0].ResourceId)
+		require.Len(t, notify.NotifyItems[0].Changes, 1)
+		change := notify.NotifyItems[0].Changes[0]
+		require.Equal(t, "/5gQosProfile/5qi", change.Path)
+		require.EqualValues(t, 9, fieldOf(change.OrigValue, "5gQosProfile.5qi"))
+		require.EqualValues(t, 6, fieldOf(change.NewValue, "5gQosProfile.5qi"))
+	case <-time.After(2 * time.Second):
+		require.Fail(t, "no data change notification")
+	}
+
+	rsp = patch(`[{"op":"replace","path":"/01010203/dnnConfigurations/ims/5gQosProfile/5qi","value":7}]`)
+	require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+	require.EqualValues(t, 7, fiveQiOf("010203", "ims"))
+	requireNotNotified()
+
+	testCases := []struct {
+		name   string
+		body   string
+		status int
+		param  string
+	}{
+		{"Unknown DNN", `[{"op":"replace","path":"/01010203/dnnConfigurations/ims/5gQosProfile/5qi","value":8},` +
+			`{"op":"replace","path":"/01010203/dnnConfigurations/iot/5gQosProfile/5qi","value":8}]`,
+			http.StatusNotFound, "/1"},
+		{"Unknown S-NSSAI", `[{"op":"remove","path":"/02/dnnConfigurations/internet"}]`,
+			http.StatusNotFound, "/0"},
+		{"Outside A DNN", `[{"op":"replace","path":"/01010203/singleNssai/sst","value":2}]`,
+			http.StatusBadRequest, "/0/path"},
+		{"Move Across DNNs", `[{"op":"move","from":"/01010203/dnnConfigurations/ims/sessionAmbr",` +
+			`"path":"/01010203/dnnConfigurations/internet/sessionAmbr"}]`, http.StatusBadRequest, "/0/from"},
+		{"Failed Test", `[{"op":"test","path":"/01010203/dnnConfigurations/ims/5gQosProfile/5qi","value":5}]`,
+			http.StatusConflict, "/0"},
+		{"Invalid DNN Configuration", `[{"op":"replace","path":"/01010203/dnnConfigurations/ims/sscModes",` +
+			`"value":"SSC_MODE_1"}]`, http.StatusUnprocessableEntity, "/0"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rsp := patch(tc.body)
+			require.Equal(t, tc.status, rsp.Code, rsp.Body.String())
+			var problemDetails models.ProblemDetails
+			require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &problemDetails))
+			require.Len(t, problemDetails.InvalidParams, 1)
+			require.Equal(t, tc.param, problemDetails.InvalidParams[0].Param)
+			require.EqualValues(t, 7, fiveQiOf("010203", "ims"), "a failed patch changes nothing")
+		})
+	}
+
+	rsp = patch(`[{"op":"remove","path":"/01010203/dnnConfigurations/ims"}]`)
+	require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+	require.Nil(t, fiveQiOf("010203", "ims"))
+	require.EqualValues(t, 6, fiveQiOf("010203", "internet"))
+	requireNotNotified()
+
+	// The patch honors the ETag of the whole SM data and answers the new one
+	rsp = serveRequest(s, http.MethodGet, smDataUri, "", "")
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+	etag := rsp.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	const replaceInternet = `[{"op":"replace","path":"/01/dnnConfigurations/internet/5gQosProfile/5qi","value":%d}]`
+	patchIfMatch := func(ifMatch string, fiveQi int) *httptest.ResponseRecorder {
+		return serveRequest(s, http.MethodPatch, smDataUri, MediaTypeJSONPatch, fmt.Sprintf(replaceInternet, fiveQi),
+			"If-Match", ifMatch)
+	}
+	rsp = patchIfMatch(`"stale"`, 8)
+	require.Equal(t, http.StatusPreconditionFailed, rsp.Code, rsp.Body.String())
+	rsp = patchIfMatch(etag, 8)
+	require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+	newEtag := rsp.Header().Get("ETag")
+	require.NotEqual(t, etag, newEtag)
+	rsp = patchIfMatch(etag, 4)
+	require.Equal(t, http.StatusPreconditionFailed, rsp.Code, rsp.Body.String())
+	data, pd = db.GetDataFromDB(context.Background(), collName, bson.M{"ueId": ueId, "servingPlmnId": "20893",
+		"singleNssai.sst": 1, "singleNssai.sd": bson.M{"$exists": false}})
+	require.Nil(t, pd)
+	require.EqualValues(t, 8, fieldOf(data, "dnnConfigurations.internet.5gQosProfile.5qi"))
+
+	rsp = serveRequest(s, http.MethodGet, smDataUri, "", "")
+	require.Equal(t, newEtag, rsp.Header().Get("ETag"))
+}
+
+func TestServer_QuerySmDataFilters(t *testing.T) {
+	db := newTestDb()
+	s := newTestServerWithDb(t, db)
+
+	const (
+		ueId      = "imsi-208930000000001"
+		smDataUri = factory.UdrDrResUriPrefix + "/subscription-data/" + ueId + "/20893/provisioned-data/sm-data"
+	)
+	_, err := db.ReplaceDataToDB(context.Background(), "subscriptionData.authenticationData.authenticationSubscription",
+		bson.M{"ueId": ueId}, map[string]interface{}{"ueId": ueId})
+	require.NoError(t, err)
+	for sd, dnns := range map[string][]string{"": {"internet"}, "010203": {"internet", "ims.mnc093"}} {
+		singleNssai := map[string]interface{}{"sst": 1}
+		if sd != "" {
+			singleNssai["sd"] = sd
+		}
+		dnnConfigurations := make(map[string]interface{})
+		for _, dnn := range dnns {
+			dnnConfigurations[util.EscapeDnn(dnn)] = map[string]interface{}{
+				"pduSessionTypes": map[string]interface{}{"defaultSessionType": "IPV4"},
+				"sscModes":        map[string]interface{}{"defaultSscMode": "SSC_MODE_1"},
+			}
+		}
+		_, err = db.ReplaceDataToDB(context.Background(), "subscriptionData.provisionedData.smData",
+			bson.M{"ueId": ueId, "servingPlmnId": "20893", "singleNssai.sst": 1, "singleNssai.sd": sd},
+			map[string]interface{}{
+				"ueId": ueId, "servingPlmnId": "20893", "singleNssai": singleNssai,
+				"dnnConfigurations": dnnConfigurations,
+			})
+		require.NoError(t, err)
+	}
+
+	testCases := []struct {
+		name         string
+		uri          string
+		status       int
+		singleNssais []string
+		cause        string
+	}{
+		{"Unfiltered", smDataUri, http.StatusOK, []string{"01", "01010203"}, ""},
+		{"S-NSSAI Without Sd", smDataUri + `?single-nssai={"sst":1}`, http.StatusOK, []string{"01"}, ""},
+		{"S-NSSAI With Sd", smDataUri + `?single-nssai={"sst":1,"sd":"010203"}`, http.StatusOK,
+			[]string{"01010203"}, ""},
+		{"DNN", smDataUri + "?dnn=ims.mnc093", http.StatusOK, []string{"01010203"}, ""},
+		{"S-NSSAI And DNN", smDataUri + `?single-nssai={"sst":1,"sd":"010203"}&dnn=internet`, http.StatusOK,
+			[]string{"01010203"}, ""},
+		// No SM data matching is not answered as an empty SmSubsData
+		{"None Matching", smDataUri + `?single-nssai={"sst":1}&dnn=ims.mnc093`, http.StatusNotFound, nil,
+			"DATA_NOT_FOUND"},
+		{"Unknown UE", strings.Replace(smDataUri, ueId, "imsi-208930000000002", 1), http.StatusNotFound, nil,
+			"USER_NOT_FOUND"},
+		{"Malformed S-NSSAI", smDataUri + `?single-nssai={"sst":1,`, http.StatusBadRequest, nil,
+			"MANDATORY_IE_INCORRECT"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rsp := serveRequest(s, http.MethodGet, tc.uri, "", "")
+			require.Equal(t, tc.status, rsp.Code, rsp.Body.String())
+			if tc.status != http.StatusOK {
+				var problemDetails models.ProblemDetails
+				require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &problemDetails))
+				require.Equal(t, tc.cause, problemDetails.Cause)
+				if tc.status == http.StatusBadRequest {
+					require.Equal(t, "single-nssai", problemDetails.InvalidParams[0].Param)
+				}
+				return
+			}
+			var smSubsData models.SmSubsData
+			require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &smSubsData))
+			singleNssais := make([]string, 0, len(smSubsData.IndividualSmSubsData))
+			for _, smData := range smSubsData.IndividualSmSubsData {
+				singleNssais = append(singleNssais, util.SnssaiModelsToHex(*smData.SingleNssai))
+				// The DNN configurations are keyed by DNN, not by their escaped key
+				for dnn := range smData.DnnConfigurations {
+					require.Contains(t, []string{"internet", "ims.mnc093"}, dnn)
+				}
+			}
+			require.ElementsMatch(t, tc.singleNssais, singleNssais)
+		})
+	}
+}
+
+func TestServer_QueryFields(t *testing.T) {
+	db := newTestDb()
+	s := newTestServerWithDb(t, db)
+
+	const (
+		ueId      = "imsi-208930000000001"
+		ueDataUri = factory.UdrDrResUriPrefix + "/subscription-data/" + ueId + "/20893/provisioned-data"
+	)
+	filter := bson.M{"ueId": ueId, "servingPlmnId": "20893"}
+	for collName, data := range map[string]map[string]interface{}{
+		"subscriptionData.provisionedData.amData": {
+			"gpsis":            []string{"msisdn-0900000000"},
+			"subscribedUeAmbr": map[string]interface{}{"uplink": "1 Gbps", "downlink": "2 Gbps"},
+			"nssai": map[string]interface{}{
+				"defaultSingleNssais": []interface{}{map[string]interface{}{"sst": 1, "sd": "010203"}},
+				"singleNssais":        []interface{}{map[string]interface{}{"sst": 2}},
+			},
+		},
+		"subscriptionData.provisionedData.smfSelectionSubscriptionData": {
+			"subscribedSnssaiInfos": map[string]interface{}{
+				"01010203": map[string]interface{}{"dnnInfos": []interface{}{
+					map[string]interface{}{"dnn": "internet", "defaultDnnIndicator": true},
+				}},
+			},
+		},
+		"subscriptionData.provisionedData.smData": {
+			"singleNssai": map[string]interface{}{"sst": 1, "sd": "010203"},
+			"dnnConfigurations": map[string]interface{}{
+				"internet": map[string]interface{}{"pduSessionTypes": map[string]interface{}{"defaultSessionType": "IPV4"}},
+			},
+			"internalGroupIds": []string{"12345678-001-01-01"},
+		},
+	} {
+		data["ueId"], data["servingPlmnId"] = ueId, "20893"
+		_, err := db.ReplaceDataToDB(context.Background(), collName, filter, data)
+		require.NoError(t, err)
+	}
+
+	testCases := []struct {
+		name   string
+		uri    string
+		status int
+		body   string
+	}{
+		{
+			name:   "Nested AM Data",
+			uri:    ueDataUri + "/am-data?fields=nssai.defaultSingleNssais,subscribedUeAmbr",
+			status: http.StatusOK,
+			body: `{"nssai":{"defaultSingleNssais":[{"sst":1,"sd":"010203"}]},` +
+				`"subscribedUeAmbr":{"uplink":"1 Gbps","downlink":"2 Gbps"}}`,
+		},
+		{
+			name:   "Within An Array",
+			uri:    ueDataUri + "/am-data?fields=nssai.singleNssais.sst&fields=nssai.defaultSingleNssais.sd",
+			status: http.StatusOK,
+			body:   `{"nssai":{"defaultSingleNssais":[{"sd":"010203"}],"singleNssais":[{"sst":2}]}}`,
+		},
+		{
+			name:   "Whole Member",
+			uri:    ueDataUri + "/am-data?fields=nssai.singleNssais,nssai",
+			status: http.StatusOK,
+			body: `{"nssai":{"defaultSingleNssais":[{"sst":1,"sd":"010203"}],` +
+				`"singleNssais":[{"sst":2}]}}`,
+		},
+		{
+			name:   "Unknown AM Data Path",
+			uri:    ueDataUri + "/am-data?fields=nssai.defaultSingleNssai,gpsis",
+			status: http.StatusBadRequest,
+			body: `{"title":"Invalid parameter","status":400,"detail":"Invalid fields","cause":"INVALID_QUERY_PARAM",` +
+				`"invalidParams":[{"param":"fields","reason":"nssai.defaultSingleNssai is not an attribute"}]}`,
+		},
+		{
+			name:   "Within A Map",
+			uri:    ueDataUri + "/smf-selection-subscription-data?fields=subscribedSnssaiInfos.01010203.dnnInfos.dnn",
+			status: http.StatusOK,
+			body:   `{"subscribedSnssaiInfos":{"01010203":{"dnnInfos":[{"dnn":"internet"}]}}}`,
+		},
+		{
+			name:   "Unknown SMF Selection Path",
+			uri:    ueDataUri + "/smf-selection-subscription-data?fields=subscribedSnssaiInfos.01010203.dnn",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "SM Data Keeps The S-NSSAI",
+			uri:    ueDataUri + "/sm-data?fields=internalGroupIds",
+			status: http.StatusOK,
+			body: `{"sharedSmSubsDataIds":null,"individualSmSubsData":[{"singleNssai":{"sst":1,"sd":"010203"},` +
+				`"internalGroupIds":["12345678-001-01-01"]}]}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rsp := serveRequest(s, http.MethodGet, tc.uri, "", "")
+			require.Equal(t, tc.status, rsp.Code, rsp.Body.String())
+			if tc.body != "" {
+				require.JSONEq(t, tc.body, rsp.Body.String())
+			}
+		})
+	}
+}
+
+func TestServer_QueryAmDataList(t *testing.T) {
+	db := newTestDb()
+	s := newTestServerWithDb(t, db)
+
+	for _, amData := range []struct{ ueId, servingPlmnId, uplink string }{
+		{"imsi-208930000000002", "20893", "2 Gbps"},
+		{"imsi-208930000000001", "20893", "1 Gbps"},
+		{"imsi-208940000000001", "20894", "1 Gbps"},
+	} {
+		_, err := db.ReplaceDataToDB(context.Background(), "subscriptionData.provisionedData.amData",
+			bson.M{"ueId": amData.ueId, "servingPlmnId": amData.servingPlmnId},
+			map[string]interface{}{
+				"ueId":             amData.ueId,
+				"servingPlmnId":    amData.servingPlmnId,
+				"subscribedUeAmbr": map[string]interface{}{"uplink": amData.uplink, "downlink": "2 Gbps"},
+			})
+		require.NoError(t, err)
+	}
+
+	testCases := []struct {
+		name   string
+		query  string
+		status int
+		ueIds  []string
+		link   string
+	}{
+		{
+			name:   "By PLMN",
+			query:  "plmn-id=20893",
+			status: http.StatusOK,
+			ueIds:  []string{"imsi-208930000000001", "imsi-208930000000002"},
+		},
+		{
+			name:   "By PLMN And AMBR",
+			query:  "plmn-id=20893&ue-ambr-ul=1+Gbps",
+			status: http.StatusOK,
+			ueIds:  []string{"imsi-208930000000001"},
+		},
+		{
+			name:   "First Page",
+			query:  "ue-ambr-ul=1+Gbps&page-size=1",
+			status: http.StatusOK,
+			ueIds:  []string{"imsi-208930000000001"},
+			link: `</nudr-dr/v2/subscription-data/am-data?page-number=2&page-size=1&ue-ambr-ul=1+Gbps>; ` +
+				`rel="next"`,
+		},
+		{
+			name:   "Last Page",
+			query:  "ue-ambr-ul=1+Gbps&page-size=1&page-number=2",
+			status: http.StatusOK,
+			ueIds:  []string{"imsi-208940000000001"},
+		},
+		{
+			name:   "No Match",
+			query:  "plmn-id=20895",
+			status: http.StatusOK,
+			ueIds:  []string{},
+		},
+		{
+			name:   "Unsupported Filter",
+			query:  "plmn-id=20893&rat-restrictions=NR",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Invalid PLMN",
+			query:  "plmn-id=208",
+			status: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rsp := serveRequest(s, http.MethodGet,
+				factory.UdrDrResUriPrefix+"/subscription-data/am-data?"+tc.query, "", "")
+			require.Equal(t, tc.status, rsp.Code, rsp.Body.String())
+			require.Equal(t, tc.link, rsp.Header().Get("Link"))
+			if tc.status != http.StatusOK {
+				return
+			}
+			var page []map[string]interface{}
+			require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &page))
+			ueIds := []string{}
+			for _, amData := range page {
+				ueIds = append(ueIds, amData["ueId"].(string))
+			}
+			require.Equal(t, tc.ueIds, ueIds)
+		})
+	}
+
+	rsp := serveRequest(s, http.MethodGet,
+		factory.UdrDrResUriPrefix+"/subscription-data/am-data?rat-restrictions=NR", "", "")
+	require.JSONEq(t, `{"title":"Invalid parameter","status":400,"detail":"rat-restrictions is not a filter",`+
+		`"cause":"INVALID_QUERY_PARAM","invalidParams":[{"param":"rat-restrictions","reason":"is not a filter"}]}`,
+		rsp.Body.String())
+}
+
+func TestServer_QueryAmDataListPlmnPages(t *testing.T) {
+	db := newTestDb()
+	s := newTestServerWithDb(t, db)
+
+	// The PLMNs of one UE are stored out of order, the pages still split them at a stable place
+	const ueId = "imsi-208930000000001"
+	for _, servingPlmnId := range []string{"20895", "20893", "20896", "20894"} {
+		_, err := db.ReplaceDataToDB(context.Background(), "subscriptionData.provisionedData.amData",
+			bson.M{"ueId": ueId, "servingPlmnId": servingPlmnId},
+			map[string]interface{}{"ueId": ueId, "servingPlmnId": servingPlmnId})
+		require.NoError(t, err)
+	}
+
+	servingPlmnIds := []string{}
+	for pageNumber := 1; pageNumber <= 2; pageNumber++ {
+		rsp := serveRequest(s, http.MethodGet, fmt.Sprintf(
+			"%s/subscription-data/am-data?page-size=3&page-number=%d", factory.UdrDrResUriPrefix, pageNumber), "", "")
+		require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+		var page []map[string]interface{}
+		require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &page))
+		for _, amData := range page {
+			servingPlmnIds = append(servingPlmnIds, amData["servingPlmnId"].(string))
+		}
+	}
+	require.Equal(t, []string{"20893", "20894", "20895", "20896"}, servingPlmnIds)
+}
+func TestServer_SupportedFeatures(t *testing.T) {
+	db := newTestDb()
+	s := newTestServerWithDb(t, db)
+
+	const ueDataUri = factory.UdrDrResUriPrefix + "/subscription-data/imsi-208930000000001/20893/provisioned-data"
+	filter := bson.M{"ueId": "imsi-208930000000001", "servingPlmnId": "20893"}
+	_, err := db.ReplaceDataToDB(context.Background(), "subscriptionData.provisionedData.amData", filter,
+		map[string]interface{}{
+			"ueId": "imsi-208930000000001", "servingPlmnId": "20893",
+			"gpsis": []string{"msisdn-0900000000"}, "sharedAmDataIds": []string{"shared-am-1"},
+		})
+	require.NoError(t, err)
+	_, err = db.ReplaceDataToDB(context.Background(), "subscriptionData.provisionedData.smData", filter,
+		map[string]interface{}{
+			"ueId": "imsi-208930000000001", "servingPlmnId": "20893",
+			"singleNssai":               map[string]interface{}{"sst": 1},
+			"sharedDnnConfigurationsId": "shared-dnn-1",
+		})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name        string
+		udrFeatures map[string]string
+		uri         string
+		status      int
+		body        string
+	}{
+		{
+			name:   "AM Data Without Negotiation",
+			uri:    ueDataUri + "/am-data?fields=gpsis,sharedAmDataIds,supportedFeatures",
+			status: http.StatusOK,
+			body:   `{"gpsis":["msisdn-0900000000"],"sharedAmDataIds":["shared-am-1"]}`,
+		},
+		{
+			name:   "AM Data Shared Data Supported",
+			uri:    ueDataUri + "/am-data?supported-features=3",
+			status: http.StatusOK,
+			body: `{"ueId":"imsi-208930000000001","servingPlmnId":"20893","gpsis":["msisdn-0900000000"],` +
+				`"sharedAmDataIds":["shared-am-1"],"supportedFeatures":"1"}`,
+		},
+		{
+			name:   "AM Data Shared Data Not Supported By The Consumer",
+			uri:    ueDataUri + "/am-data?supported-features=2&fields=gpsis,sharedAmDataIds",
+			status: http.StatusOK,
+			body:   `{"gpsis":["msisdn-0900000000"]}`,
+		},
+		{
+			name:        "AM Data Shared Data Not Supported By The UDR",
+			udrFeatures: map[string]string{"nudr-dr": "0"},
+			uri:         ueDataUri + "/am-data?supported-features=1&fields=gpsis,sharedAmDataIds",
+			status:      http.StatusOK,
+			body:        `{"gpsis":["msisdn-0900000000"]}`,
+		},
+		{
+			name:   "SM Data Shared Data Supported",
+			uri:    ueDataUri + "/sm-data?supported-features=1",
+			status: http.StatusOK,
+			body: `{"sharedSmSubsDataIds":null,"individualSmSubsData":[{"singleNssai":{"sst":1},` +
+				`"sharedDnnConfigurationsId":"shared-dnn-1","supportedFeatures":"1"}]}`,
+		},
+		{
+			name:   "SM Data Shared Data Not Supported",
+			uri:    ueDataUri + "/sm-data?supported-features=2",
+			status: http.StatusOK,
+			body:   `{"sharedSmSubsDataIds":null,"individualSmSubsData":[{"singleNssai":{"sst":1}}]}`,
+		},
+		{
+			name:   "Invalid Supported Features",
+			uri:    ueDataUri + "/sm-data?supported-features=1G",
+			status: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			factory.UdrConfig.Configuration.SupportedFeatures = tc.udrFeatures
+			rsp := serveRequest(s, http.MethodGet, tc.uri, "", "")
+			require.Equal(t, tc.status, rsp.Code, rsp.Body.String())
+			if tc.body != "" {
+				require.JSONEq(t, tc.body, rsp.Body.String())
+			}
+		})
+	}
+}
+
+func TestServer_QuerySubscriptionDataSets(t *testing.T) {
+	db := newTestDb()
+	s := newTestServerWithDb(t, db)
+
+	const ueId = "imsi-208930000000001"
+	for collName, data := range map[string]map[string]interface{}{
+		"subscriptionData.provisionedData.amData": {
+			"ueId": ueId, "servingPlmnId": "20893", "gpsis": []interface{}{"msisdn-0900000000"},
+		},
+		"subscriptionData.provisionedData.smData": {
+			"ueId": ueId, "servingPlmnId": "20893", "singleNssai": map[string]interface{}{"sst": 1},
+		},
+		"subscriptionData.provisionedData.lcsPrivacyData": {
+			"ueId": ueId, "lpi": map[string]interface{}{"locationPrivacyInd": "LOCATION_DISALLOWED"},
+		},
+	} {
+		filter := bson.M{"ueId": ueId}
+		if servingPlmnId, ok := data["servingPlmnId"]; ok {
+			filter["servingPlmnId"] = servingPlmnId
+		}
+		_, err := db.ReplaceDataToDB(context.Background(), collName, filter, data)
+		require.NoError(t, err)
+	}
+
+	testCases := []struct {
+		name     string
+		query    string
+		ueId     string
+		status   int
+		dataSets []string
+	}{
+		{
+			name:     "Data Sets Of The PLMN",
+			query:    "dataset-names=AM,SM&dataset-names=LCS_PRIVACY&plmn-id=20893",
+			status:   http.StatusOK,
+			dataSets: []string{"amData", "lcsPrivacyData", "smData"},
+		},
+		{
+			name:     "Data Set Without Data Left Out",
+			query:    "dataset-names=AM,SMF_SEL&plmn-id=20893",
+			status:   http.StatusOK,
+			dataSets: []string{"amData"},
+		},
+		{
+			name:     "Other PLMN",
+			query:    "plmn-id=20894",
+			status:   http.StatusOK,
+			dataSets: []string{"lcsPrivacyData"},
+		},
+		{
+			name:     "Without PLMN",
+			status:   http.StatusOK,
+			dataSets: []string{"lcsPrivacyData"},
+		},
+		{
+			name:   "PLMN Data Set Without PLMN",
+			query:  "dataset-names=LCS_PRIVACY,AM",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Unknown Data Set",
+			query:  "dataset-names=AM,UEC_AMF&plmn-id=20893",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Invalid PLMN",
+			query:  "plmn-id=2089",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Unknown UE",
+			ueId:   "imsi-208930000000002",
+			query:  "plmn-id=20893",
+			status: http.StatusNotFound,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.ueId == "" {
+				tc.ueId = ueId
+			}
+			rsp := serveRequest(s, http.MethodGet,
+				factory.UdrDrResUriPrefix+"/subscription-data/"+tc.ueId+"?"+tc.query, "", "")
+			require.Equal(t, tc.status, rsp.Code, rsp.Body.String())
+			if tc.status != http.StatusOK {
+				return
+			}
+			var dataSets map[string]json.RawMessage
+			require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &dataSets))
+			require.ElementsMatch(t, tc.dataSets, slices.Collect(maps.Keys(dataSets)))
+		})
+	}
+}
+
+// slowDb is a testDb taking latency to answer each read, as a datastore over the network does, and counting
+// the reads
+type slowDb struct {
+	*testDb
+	latency time.Duration
+	reads   int
+}
+
+func (db *slowDb) read() {
+	db.reads++
+	time.Sleep(db.latency)
+}
+
+func (db *slowDb) GetDataFromDB(ctx context.Context, collName string, filter bson.M) (
+	map[string]interface{}, *models.ProblemDetails,
+) {
+	db.read()
+	return db.testDb.GetDataFromDB(ctx, collName, filter)
+}
+
+func (db *slowDb) GetVersionedDataFromDB(ctx context.Context, collName string, filter bson.M) (
+	map[string]interface{}, string, *models.ProblemDetails,
+) {
+	db.read()
+	return db.testDb.GetVersionedDataFromDB(ctx, collName, filter)
+}
+
+func (db *slowDb) GetManyDataFromDB(ctx context.Context, collName string, filter bson.M) (
+	[]map[string]interface{}, error,
+) {
+	db.read()
+	return db.testDb.GetManyDataFromDB(ctx, collName, filter)
+}
+
+func (db *slowDb) GetManyDataFromDBWithArg(ctx context.Context, collName string, filter bson.M, strength int) (
+	[]map[string]interface{}, error,
+) {
+	db.read()
+	return db.testDb.GetManyDataFromDBWithArg(ctx, collName, filter, strength)
+}
+
+func TestServer_BatchRead(t *testing.T) {
+	db := &slowDb{testDb: newTestDb(), latency: 2 * time.Millisecond}
+	s := newTestServerWithDb(t, db)
+	batchReadConfig := factory.UdrConfig.Configuration.BatchRead
+	factory.UdrConfig.Configuration.BatchRead = &factory.BatchRead{MaxSupis: 20}
+	t.Cleanup(func() { factory.UdrConfig.Configuration.BatchRead = batchReadConfig })
+
+	supis := make([]string, 20)
+	for i := range supis {
+		supis[i] = fmt.Sprintf("imsi-2089300000%05d", i)
+		// The last SUPI has no AM data
+		if i == len(supis)-1 {
+			continue
+		}
+		_, err := db.ReplaceDataToDB(context.Background(), "subscriptionData.provisionedData.amData",
+			bson.M{"ueId": supis[i], "servingPlmnId": "20893"},
+			map[string]interface{}{"ueId": supis[i], "servingPlmnId": "20893", "gpsis": []interface{}{"msisdn-" + supis[i][5:]}})
+		require.NoError(t, err)
+	}
+
+	batchRead := func(t *testing.T, request map[string]interface{}) *httptest.ResponseRecorder {
+		body, err := json.Marshal(request)
+		require.NoError(t, err)
+		return serveRequest(s, http.MethodPost, factory.UdrDrResUriPrefix+UdrBatchReadPath, "application/json",
+			string(body))
+	}
+
+	t.Run("Absent SUPI Left Out", func(t *testing.T) {
+		rsp := batchRead(t, map[string]interface{}{
+			"supis": supis[len(supis)-3:], "resource": "am-data", "servingPlmnId": "20893",
+		})
+		require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+		var bySupi map[string]map[string]interface{}
+		require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &bySupi))
+		require.ElementsMatch(t, supis[len(supis)-3:len(supis)-1], slices.Collect(maps.Keys(bySupi)))
+		require.Equal(t, map[string]interface{}{"gpsis": []interface{}{"msisdn-" + supis[len(supis)-2][5:]}},
+			bySupi[supis[len(supis)-2]])
+	})
+
+	t.Run("Single Read Faster Than Individual Reads", func(t *testing.T) {
+		db.reads = 0
+		start := time.Now()
+		for _, supi := range supis {
+			_ = serveRequest(s, http.MethodGet,
+				factory.UdrDrResUriPrefix+"/subscription-data/"+supi+"/20893/provisioned-data/am-data", "", "")
+		}
+		individual, individualReads := time.Since(start), db.reads
+
+		db.reads = 0
+		start = time.Now()
+		rsp := batchRead(t, map[string]interface{}{"supis": supis, "resource": "am-data", "servingPlmnId": "20893"})
+		batch := time.Since(start)
+		require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+		require.Equal(t, 1, db.reads)
+		require.GreaterOrEqual(t, individualReads, len(supis))
+		require.Less(t, batch, individual)
+		t.Logf("%d SUPIs: batch read %v, individual reads %v", len(supis), batch, individual)
+	})
+
+	testCases := []struct {
+		name    string
+		request map[string]interface{}
+	}{
+		{
+			name:    "More SUPIs Than The Limit",
+			request: map[string]interface{}{"supis": append(slices.Clone(supis), "imsi-208930000099999"), "resource": "pp-data"},
+		},
+		{
+			name:    "No SUPI",
+			request: map[string]interface{}{"supis": []string{}, "resource": "pp-data"},
+		},
+		{
+			name:    "Invalid SUPI",
+			request: map[string]interface{}{"supis": []string{"msisdn-0900000000"}, "resource": "pp-data"},
+		},
+		{
+			name:    "Unknown Resource",
+			request: map[string]interface{}{"supis": supis[:1], "resource": "ue-context-in-smf-data"},
+		},
+		{
+			name:    "Authentication Subscription",
+			request: map[string]interface{}{"supis": supis[:1], "resource": "authentication-subscription"},
+		},
+		{
+			name:    "PLMN Resource Without PLMN",
+			request: map[string]interface{}{"supis": supis[:1], "resource": "am-data"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db.reads = 0
+			rsp := batchRead(t, tc.request)
+			require.Equal(t, http.StatusBadRequest, rsp.Code, rsp.Body.String())
+			require.Zero(t, db.reads)
+		})
+	}
+}
+
+func TestServer_PolicyAmData(t *testing.T) {
+	db := newTestDb()
+	s := newTestServerWithDb(t, db)
+
+	const (
+		ueId      = "imsi-208930000000001"
+		amDataUri = factory.UdrDrResUriPrefix + "/policy-data/ues/" + ueId + "/am-data"
+	)
+	serve := func(method string, body string, ifMatch string) *httptest.ResponseRecorder {
+		return serveRequest(s, method, amDataUri, "application/json", body, "If-Match", ifMatch)
+	}
+
+	notified := make(chan models.PolicyDataChangeNotification, 4)
+	callbackUri := newCallbackServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var notifications []models.PolicyDataChangeNotification
+		if err := json.NewDecoder(r.Body).Decode(&notifications); err == nil {
+			for _, notification := range notifications {
+				notified <- notification
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	udrSelf := udr_context.GetSelf()
+	udrSelf.PolicyDataSubscriptions = map[string]*models.PolicyDataSubscription{
+		"1": {NotificationUri: callbackUri, MonitoredResourceUris: []string{amDataUri}},
+		"2": {
+			NotificationUri:       callbackUri + "/ue-policy-set",
+			MonitoredResourceUris: []string{factory.UdrDrResUriPrefix + "/policy-data/ues/" + ueId + "/ue-policy-set"},
+		},
+	}
+	t.Cleanup(func() { udrSelf.PolicyDataSubscriptions = map[string]*models.PolicyDataSubscription{} })
+	requireNotified := func() models.PolicyDataChangeNotification {
+		select {
+		case notification := <-notified:
+			return notification
+		case <-time.After(time.Second):
+			require.Fail(t, "policy data change not notified")
+		}
+		return models.PolicyDataChangeNotification{}
+	}
+
+	rsp := serve(http.MethodGet, "", "")
+	require.Equal(t, http.StatusNotFound, rsp.Code)
+	require.Contains(t, rsp.Body.String(), "USER_NOT_FOUND")
+
+	rsp = serve(http.MethodPut, `{"subscCats":["free5gc"]}`, "")
+	require.Equal(t, http.StatusCreated, rsp.Code, rsp.Body.String())
+	etag := rsp.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	notification := requireNotified()
+	require.Equal(t, ueId, notification.UeId)
+	require.Equal(t, []string{"free5gc"}, notification.AmPolicyData.SubscCats)
+
+	rsp = serve(http.MethodPut, `{"subscCats":["gold"]}`, `"0"`)
+	require.Equal(t, http.StatusPreconditionFailed, rsp.Code)
+	rsp = serve(http.MethodPut, `{"subscCats":["gold"]}`, etag)
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+	require.Equal(t, []string{"gold"}, requireNotified().AmPolicyData.SubscCats)
+
+	rsp = serve(http.MethodGet, "", "")
+	require.Equal(t, http.StatusOK, rsp.Code)
+	var amPolicyData models.AmPolicyData
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &amPolicyData))
+	require.Equal(t, []string{"gold"}, amPolicyData.SubscCats)
+
+	rsp = serve(http.MethodDelete, "", "")
+	require.Equal(t, http.StatusNoContent, rsp.Code)
+	notification = requireNotified()
+	require.Nil(t, notification.AmPolicyData)
+	require.Len(t, notification.DelResources, 1)
+	require.True(t, strings.HasSuffix(notification.DelResources[0], "/policy-data/ues/"+ueId+"/am-data"),
+		notification.DelResources[0])
+	rsp = serve(http.MethodGet, "", "")
+	require.Equal(t, http.StatusNotFound, rsp.Code)
+	require.Contains(t, rsp.Body.String(), "USER_NOT_FOUND")
+
+	// Deleting again changes nothing and is not notified, the subscription to the UE policy set never is
+	rsp = serve(http.MethodDelete, "", "")
+	require.Equal(t, http.StatusNoContent, rsp.Code)
+	select {
+	case notification = <-notified:
+		require.Fail(t, "policy data change notified", "%+v", notification)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestServer_PolicySmData(t *testing.T) {
+	db := newTestDb()
+	s := newTestServerWithDb(t, db)
+
+	const (
+		ueId      = "imsi-208930000000001"
+		smDataUri = factory.UdrDrResUriPrefix + "/policy-data/ues/" + ueId + "/sm-data"
+	)
+	patch := func(body string) *httptest.ResponseRecorder {
+		return serveRequest(s, http.MethodPatch, smDataUri, "application/merge-patch+json", body)
+	}
+	smPolicyDataOf := func(rsp *httptest.ResponseRecorder) models.SmPolicyData {
+		require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+		var smPolicyData models.SmPolicyData
+		require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &smPolicyData))
+		return smPolicyData
+	}
+
+	db.seed(t, "policyData.ues.smData", bson.M{"ueId": ueId}, map[string]interface{}{
+		"ueId": ueId,
+		"smPolicySnssaiData": map[string]interface{}{
+			"01010203": map[string]interface{}{
+				"snssai": map[string]interface{}{"sst": 1, "sd": "010203"},
+				"smPolicyDnnData": map[string]interface{}{
+					"internet":   map[string]interface{}{"dnn": "internet"},
+					"ims_mnc001": map[string]interface{}{"dnn": "ims.mnc001"},
+				},
+			},
+			"02": map[string]interface{}{
+				"snssai":          map[string]interface{}{"sst": 2},
+				"smPolicyDnnData": map[string]interface{}{"internet": map[string]interface{}{"dnn": "internet"}},
+			},
+		},
+	})
+
+	// The usage monitoring data is stored by its usageMonId, which is its limitId
+	rsp := serveRequest(s, http.MethodPut, smDataUri+"/limit1", "application/json",
+		`{"limitId":"limit1","allowedUsage":{"totalVolume":1000}}`)
+	require.Equal(t, http.StatusCreated, rsp.Code, rsp.Body.String())
+	require.NotEmpty(t, rsp.Header().Get("ETag"))
+	rsp = serveRequest(s, http.MethodPut, smDataUri+"/limit2", "application/json", `{"limitId":"limit3"}`)
+	require.Equal(t, http.StatusBadRequest, rsp.Code)
+	require.Contains(t, rsp.Body.String(), "/limitId")
+	rsp = serveRequest(s, http.MethodGet, smDataUri+"/limit1", "", "")
+	require.Equal(t, http.StatusOK, rsp.Code)
+	require.JSONEq(t, `{"limitId":"limit1","allowedUsage":{"totalVolume":1000}}`, rsp.Body.String())
+
+	smPolicyData := smPolicyDataOf(serveRequest(s, http.MethodGet, smDataUri, "", ""))
+	require.Len(t, smPolicyData.SmPolicySnssaiData, 2)
+	require.Equal(t, int64(1000), smPolicyData.UmData["limit1"].AllowedUsage.TotalVolume)
+
+	t.Run("Filter", func(t *testing.T) {
+		query := url.Values{"snssai": {`{"sst":1,"sd":"010203"}`}, "dnn": {"IMS.mnc001"}}
+		smPolicyData = smPolicyDataOf(serveRequest(s, http.MethodGet, smDataUri+"?"+query.Encode(), "", ""))
+		require.Len(t, smPolicyData.SmPolicySnssaiData, 1)
+		require.Len(t, smPolicyData.SmPolicySnssaiData["01010203"].SmPolicyDnnData, 1)
+		require.Contains(t, smPolicyData.SmPolicySnssaiData["01010203"].SmPolicyDnnData, "ims.mnc001")
+
+		smPolicyData = smPolicyDataOf(serveRequest(s, http.MethodGet, smDataUri+"?dnn=internet", "", ""))
+		require.Len(t, smPolicyData.SmPolicySnssaiData, 2)
+
+		query = url.Values{"snssai": {`{"sst":2}`}, "dnn": {"ims.mnc001"}}
+		rsp = serveRequest(s, http.MethodGet, smDataUri+"?"+query.Encode(), "", "")
+		require.Equal(t, http.StatusNotFound, rsp.Code)
+		rsp = serveRequest(s, http.MethodGet, smDataUri+"?snssai=1", "", "")
+		require.Equal(t, http.StatusBadRequest, rsp.Code)
+	})
+
+	t.Run("Patch", func(t *testing.T) {
+		smPolicyData = smPolicyDataOf(patch(`{"umData":{"limit1":{"allowedUsage":{"totalVolume":400}}},` +
+			`"umDataLimits":{"limit1":{"limitId":"limit1","usageLimit":{"totalVolume":5000}}}}`))
+		require.Equal(t, int64(400), smPolicyData.UmData["limit1"].AllowedUsage.TotalVolume)
+		require.Equal(t, int64(5000), smPolicyData.UmDataLimits["limit1"].UsageLimit.TotalVolume)
+
+		// Only the fields patched are written, the ones of the other patches are kept
+		smPolicyData = smPolicyDataOf(patch(`{"umData":{"limit1":{"resetIds":["r1"]}},` +
+			`"smPolicySnssaiData":{"02":{"smPolicyDnnData":{"ims.mnc001":{"dnn":"ims.mnc001"}}}}}`))
+		require.Equal(t, int64(400), smPolicyData.UmData["limit1"].AllowedUsage.TotalVolume)
+		require.Equal(t, []string{"r1"}, smPolicyData.UmData["limit1"].ResetIds)
+		require.Equal(t, int64(5000), smPolicyData.UmDataLimits["limit1"].UsageLimit.TotalVolume)
+		require.Len(t, smPolicyData.SmPolicySnssaiData["02"].SmPolicyDnnData, 2)
+		require.Contains(t, smPolicyData.SmPolicySnssaiData["02"].SmPolicyDnnData, "ims.mnc001")
+
+		// A umData entry not stored yet is inserted by its limitId
+		smPolicyData = smPolicyDataOf(patch(`{"umData":{"limit9":{"resetIds":["r2"]}}}`))
+		require.Equal(t, []string{"r2"}, smPolicyData.UmData["limit9"].ResetIds)
+		require.Equal(t, int64(400), smPolicyData.UmData["limit1"].AllowedUsage.TotalVolume)
+		rsp = serveRequest(s, http.MethodGet, smDataUri+"/limit9", "", "")
+		require.Equal(t, http.StatusOK, rsp.Code)
+		require.JSONEq(t, `{"limitId":"limit9","resetIds":["r2"]}`, rsp.Body.String())
+		rsp = serveRequest(s, http.MethodDelete, smDataUri+"/limit9", "", "")
+		require.Equal(t, http.StatusNoContent, rsp.Code)
+
+		rsp = patch(`{"umData":{"limit1":{"limitId":"limit2"}}}`)
+		require.Equal(t, http.StatusBadRequest, rsp.Code)
+		require.Contains(t, rsp.Body.String(), "/umData/limit1")
+		rsp = patch(`{"umData":{"limit1":{"allowedUsage":"all"}}}`)
+		require.Equal(t, http.StatusBadRequest, rsp.Code)
+		rsp = patch(`{"suppFeat":"1"}`)
+		require.Equal(t, http.StatusBadRequest, rsp.Code)
+		rsp = serveRequest(s, http.MethodPatch, smDataUri, "application/json-patch+json", `[]`)
+		require.Equal(t, http.StatusUnsupportedMediaType, rsp.Code)
+
+		rsp = serveRequest(s, http.MethodPatch, factory.UdrDrResUriPrefix+"/policy-data/ues/imsi-208930000000002/sm-data",
+			"application/merge-patch+json", `{"umDataLimits":{"limit1":null}}`)
+		require.Equal(t, http.StatusNotFound, rsp.Code)
+		require.Contains(t, rsp.Body.String(), "USER_NOT_FOUND")
+
+		// The document of the UE is matched regardless of case, as it is read
+		db.seed(t, "policyData.ues.smData", bson.M{"ueId": "IMSI-208930000000003"},
+			map[string]interface{}{"ueId": "IMSI-208930000000003"})
+		rsp = serveRequest(s, http.MethodPatch, factory.UdrDrResUriPrefix+"/policy-data/ues/imsi-208930000000003/sm-data",
+			"application/merge-patch+json", `{"umDataLimits":{"limit1":{"limitId":"limit1"}}}`)
+		require.Contains(t, smPolicyDataOf(rsp).UmDataLimits, "limit1")
+
+		// A null entry removes it
+		smPolicyData = smPolicyDataOf(patch(`{"umData":{"limit1":null},"umDataLimits":{"limit1":null}}`))
+		require.Empty(t, smPolicyData.UmData)
+		require.Empty(t, smPolicyData.UmDataLimits)
+	})
+
+	rsp = serveRequest(s, http.MethodDelete, smDataUri+"/limit1", "", "")
+	require.Equal(t, http.StatusNoContent, rsp.Code)
+	rsp = serveRequest(s, http.MethodGet, smDataUri+"/limit1", "", "")
+	require.Equal(t, http.StatusNotFound, rsp.Code)
+}
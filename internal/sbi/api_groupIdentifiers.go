@@ -0,0 +1,171 @@
+package sbi
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/util/metrics/sbi"
+)
+
+// UdrGroupIdentifiersPath resolves an external group identifier to the internal one and the other way round
+const UdrGroupIdentifiersPath = "/subscription-data/group-data/group-identifiers"
+
+// groupIdentifiersUeIdListFeature is the feature of supported-features asking for the members of the group
+const groupIdentifiersUeIdListFeature = 1
+
+func (s *Server) getGroupIdentifiersRoutes() []Route {
+	return []Route{
+		{
+			Name:        "GetGroupIdentifiers",
+			Method:      http.MethodGet,
+			Pattern:     UdrGroupIdentifiersPath,
+			HandlerFunc: s.HandleGetGroupIdentifiers,
+		},
+		{
+			Name:        "PutGroupIdentifiers",
+			Method:      http.MethodPut,
+			Pattern:     UdrGroupIdentifiersPath,
+			HandlerFunc: s.requireScope(util.ScopeNudrDrAdmin, s.HandlePutGroupIdentifiers),
+		},
+	}
+}
+
+// HandleGetGroupIdentifiers - Retrieves the group identifiers of an external or internal group identifier.
+// The members of the group are answered with ue-id-ind=true or feature 1 in supported-features, one page of
+// page-size of them at a time, bounded by pagination.maxPageSize; a Link header points to the next page.
+func (s *Server) HandleGetGroupIdentifiers(c *gin.Context) {
+	logger.DataRepoLog.Tracef("Handle GetGroupIdentifiers")
+
+	extGroupId := c.Query("ext-group-id")
+	intGroupId, intGroupIdParam := c.Query("int-group-id"), "int-group-id"
+	if intGroupId == "" {
+		// The name of the parameter before TS 29.505 settled on int-group-id
+		intGroupId, intGroupIdParam = c.Query("internal-group-id"), "internal-group-id"
+	}
+
+	var invalidParam *models.InvalidParam
+	switch {
+	case extGroupId == "" && intGroupId == "":
+		invalidParam = &models.InvalidParam{
+			Param:  "ext-group-id",
+			Reason: "one of ext-group-id or int-group-id is required",
+		}
+	case extGroupId != "" && intGroupId != "":
+		invalidParam = &models.InvalidParam{Param: intGroupIdParam, Reason: "is mutually exclusive with ext-group-id"}
+	case extGroupId != "" && !util.IsValidExtGroupId(extGroupId):
+		invalidParam = &models.InvalidParam{Param: "ext-group-id", Reason: "shall be an extgroupid- identifier"}
+	}
+
+	ueIdInd, detail := ueIdIndQuery(c)
+	supportedFeatures, ok := util.SupportedFeaturesQuery(c)
+	if !ok {
+		return
+	}
+	defaultPageSize, maxPageSize := s.Config().GetPageSizes()
+	pageSize, pageSizeDetail := positiveQueryInt(c, "page-size", defaultPageSize)
+	if pageSizeDetail == "" && pageSize > maxPageSize {
+		pageSizeDetail = fmt.Sprintf("page-size should not exceed %d", maxPageSize)
+	}
+	pageNumber, pageNumberDetail := positiveQueryInt(c, "page-number", 1)
+	for _, queryDetail := range []string{pageSizeDetail, pageNumberDetail} {
+		if detail == "" {
+			detail = queryDetail
+		}
+	}
+	if invalidParam != nil || detail != "" {
+		problemDetails := &models.ProblemDetails{
+			Title:  "Invalid parameter",
+			Status: http.StatusBadRequest,
+			Detail: detail,
+			Cause:  "INVALID_QUERY_PARAM",
+		}
+		if invalidParam != nil {
+			problemDetails.Detail = invalidParam.Param + " " + invalidParam.Reason
+			problemDetails.InvalidParams = []models.InvalidParam{*invalidParam}
+		}
+		logger.DataRepoLog.Errorf("GetGroupIdentifiers: %s", problemDetails.Detail)
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
+		c.JSON(http.StatusBadRequest, problemDetails)
+		return
+	}
+
+	ueIdList := ueIdInd || util.HasFeature(supportedFeatures, groupIdentifiersUeIdListFeature)
+	s.Processor().GetGroupIdentifiers(c, extGroupId, intGroupId, ueIdList, pageSize, pageNumber)
+}
+
+// ueIdIndQuery returns the ue-id-ind query parameter, false when absent, and a detail when it is invalid
+func ueIdIndQuery(c *gin.Context) (bool, string) {
+	switch c.Query("ue-id-ind") {
+	case "", "false":
+		return false, ""
+	case "true":
+		return true, ""
+	}
+	return false, "ue-id-ind should be true or false"
+}
+
+// HandlePutGroupIdentifiers - Provisions a group, the access token shall grant the admin scope. The group is
+// identified by its intGroupId, its extGroupId shall not be the one of another group.
+func (s *Server) HandlePutGroupIdentifiers(c *gin.Context) {
+	logger.DataRepoLog.Tracef("Handle PutGroupIdentifiers")
+
+	requestBody, err := c.GetRawData()
+	if err != nil {
+		problemDetail := util.ProblemDetailsSystemFailure(err.Error())
+		logger.DataRepoLog.Errorf("Get Request Body error: %+v", err)
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetail.Cause)
+		c.JSON(http.StatusInternalServerError, problemDetail)
+		return
+	}
+
+	var groupIdentifiers models.GroupIdentifiers
+	if err = openapi.Deserialize(&groupIdentifiers, requestBody, "application/json"); err != nil {
+		problemDetail := util.ProblemDetailsMalformedReqSyntax("[Request Body] " + err.Error())
+		logger.DataRepoLog.Errorln(problemDetail.Detail)
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, http.StatusText(int(problemDetail.Status)))
+		c.JSON(http.StatusBadRequest, problemDetail)
+		return
+	}
+
+	if detail := validateGroupIdentifiers(&groupIdentifiers); detail != "" {
+		problemDetail := &models.ProblemDetails{
+			Title:  "Invalid parameter",
+			Status: http.StatusBadRequest,
+			Detail: detail,
+			Cause:  "INVALID_PARAMETER",
+		}
+		logger.DataRepoLog.Errorf("PutGroupIdentifiers: %s", detail)
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetail.Cause)
+		c.JSON(http.StatusBadRequest, problemDetail)
+		return
+	}
+
+	s.Processor().PutGroupIdentifiers(c, groupIdentifiers)
+}
+
+// validateGroupIdentifiers returns the detail of the first problem found in the group
+func validateGroupIdentifiers(groupIdentifiers *models.GroupIdentifiers) string {
+	switch {
+	case !util.IsValidExtGroupId(groupIdentifiers.ExtGroupId):
+		return "extGroupId shall be an extgroupid- identifier"
+	case !util.IsValidIntGroupId(groupIdentifiers.IntGroupId):
+		return "intGroupId shall be a GroupId of TS 29.571"
+	}
+	supis := make(map[string]bool, len(groupIdentifiers.UeIdList))
+	for i, ueId := range groupIdentifiers.UeIdList {
+		if !util.IsValidSupi(ueId.Supi) {
+			return fmt.Sprintf("ueIdList[%d]: invalid supi", i)
+		}
+		if supis[ueId.Supi] {
+			return fmt.Sprintf("ueIdList[%d]: supi %s is given twice", i, ueId.Supi)
+		}
+		supis[ueId.Supi] = true
+	}
+	return ""
+}
@@ -0,0 +1,534 @@
+package sbi
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/util/metrics/sbi"
+)
+
+// getPolicyDataRoutes are the policy-data routes of the data repository, e.g. the AM, UE policy set and SM
+// policy data the PCF retrieves
+func (s *Server) getPolicyDataRoutes() []Route {
+	return []Route{
+		{
+			"PolicyDataBdtDataBdtReferenceIdDelete",
+			strings.ToUpper("Delete"),
+			"/policy-data/bdt-data/:bdtReferenceId",
+			s.HandlePolicyDataBdtDataBdtReferenceIdDelete,
+		},
+
+		{
+			"PolicyDataBdtDataBdtReferenceIdGet",
+			strings.ToUpper("Get"),
+			"/policy-data/bdt-data/:bdtReferenceId",
+			s.HandlePolicyDataBdtDataBdtReferenceIdGet,
+		},
+
+		{
+			"PolicyDataBdtDataBdtReferenceIdPut",
+			strings.ToUpper("Put"),
+			"/policy-data/bdt-data/:bdtReferenceId",
+			s.HandlePolicyDataBdtDataBdtReferenceIdPut,
+		},
+
+		{
+			"PolicyDataBdtDataGet",
+			strings.ToUpper("Get"),
+			"/policy-data/bdt-data",
+			s.HandlePolicyDataBdtDataGet,
+		},
+
+		{
+			"PolicyDataPlmnsPlmnIdUePolicySetGet",
+			strings.ToUpper("Get"),
+			"/policy-data/plmns/:plmnId/ue-policy-set",
+			s.HandlePolicyDataPlmnsPlmnIdUePolicySetGet,
+		},
+
+		{
+			"PolicyDataSponsorConnectivityDataSponsorIdGet",
+			strings.ToUpper("Get"),
+			"/policy-data/sponsor-connectivity-data/:sponsorId",
+			s.HandlePolicyDataSponsorConnectivityDataSponsorIdGet,
+		},
+
+		{
+			"PolicyDataSubsToNotifyPost",
+			strings.ToUpper("Post"),
+			"/policy-data/subs-to-notify",
+			s.HandlePolicyDataSubsToNotifyPost,
+		},
+
+		{
+			"PolicyDataSubsToNotifySubsIdDelete",
+			strings.ToUpper("Delete"),
+			"/policy-data/subs-to-notify/:subsId",
+			s.HandlePolicyDataSubsToNotifySubsIdDelete,
+		},
+
+		{
+			"PolicyDataSubsToNotifySubsIdPut",
+			strings.ToUpper("Put"),
+			"/policy-data/subs-to-notify/:subsId",
+			s.HandlePolicyDataSubsToNotifySubsIdPut,
+		},
+
+		{
+			"PolicyDataUesUeIdAmDataGet",
+			strings.ToUpper("Get"),
+			"/policy-data/ues/:ueId/am-data",
+			s.HandlePolicyDataUesUeIdAmDataGet,
+		},
+
+		{
+			"PolicyDataUesUeIdAmDataPatch",
+			strings.ToUpper("Patch"),
+			"/policy-data/ues/:ueId/am-data",
+			s.HandlePolicyDataUesUeIdAmDataPatch,
+		},
+
+		{
+			"PolicyDataUesUeIdAmDataPut",
+			strings.ToUpper("Put"),
+			"/policy-data/ues/:ueId/am-data",
+			s.HandlePolicyDataUesUeIdAmDataPut,
+		},
+
+		{
+			"PolicyDataUesUeIdAmDataDelete",
+			strings.ToUpper("Delete"),
+			"/policy-data/ues/:ueId/am-data",
+			s.HandlePolicyDataUesUeIdAmDataDelete,
+		},
+
+		{
+			"PolicyDataUesUeIdOperatorSpecificDataGet",
+			strings.ToUpper("Get"),
+			"/policy-data/ues/:ueId/operator-specific-data",
+			s.HandlePolicyDataUesUeIdOperatorSpecificDataGet,
+		},
+
+		{
+			"PolicyDataUesUeIdOperatorSpecificDataPatch",
+			strings.ToUpper("Patch"),
+			"/policy-data/ues/:ueId/operator-specific-data",
+			s.HandlePolicyDataUesUeIdOperatorSpecificDataPatch,
+		},
+
+		{
+			"PolicyDataUesUeIdOperatorSpecificDataPut",
+			strings.ToUpper("Put"),
+			"/policy-data/ues/:ueId/operator-specific-data",
+			s.HandlePolicyDataUesUeIdOperatorSpecificDataPut,
+		},
+
+		{
+			"PolicyDataUesUeIdSmDataGet",
+			strings.ToUpper("Get"),
+			"/policy-data/ues/:ueId/sm-data",
+			s.HandlePolicyDataUesUeIdSmDataGet,
+		},
+
+		{
+			"PolicyDataUesUeIdSmDataPatch",
+			strings.ToUpper("Patch"),
+			"/policy-data/ues/:ueId/sm-data",
+			s.HandlePolicyDataUesUeIdSmDataPatch,
+		},
+
+		{
+			"PolicyDataUesUeIdSmDataUsageMonIdDelete",
+			strings.ToUpper("Delete"),
+			"/policy-data/ues/:ueId/sm-data/:usageMonId",
+			s.HandlePolicyDataUesUeIdSmDataUsageMonIdDelete,
+		},
+
+		{
+			"PolicyDataUesUeIdSmDataUsageMonIdGet",
+			strings.ToUpper("Get"),
+			"/policy-data/ues/:ueId/sm-data/:usageMonId",
+			s.HandlePolicyDataUesUeIdSmDataUsageMonIdGet,
+		},
+
+		{
+			"PolicyDataUesUeIdSmDataUsageMonIdPut",
+			strings.ToUpper("Put"),
+			"/policy-data/ues/:ueId/sm-data/:usageMonId",
+			s.HandlePolicyDataUesUeIdSmDataUsageMonIdPut,
+		},
+
+		{
+			"PolicyDataUesUeIdUePolicySetGet",
+			strings.ToUpper("Get"),
+			"/policy-data/ues/:ueId/ue-policy-set",
+			s.HandlePolicyDataUesUeIdUePolicySetGet,
+		},
+
+		{
+			"PolicyDataUesUeIdUePolicySetPatch",
+			strings.ToUpper("Patch"),
+			"/policy-data/ues/:ueId/ue-policy-set",
+			s.HandlePolicyDataUesUeIdUePolicySetPatch,
+		},
+
+		{
+			"PolicyDataUesUeIdUePolicySetPut",
+			strings.ToUpper("Put"),
+			"/policy-data/ues/:ueId/ue-policy-set",
+			s.HandlePolicyDataUesUeIdUePolicySetPut,
+		},
+	}
+}
+
+// HTTPPolicyDataBdtDataBdtReferenceIdDelete -
+func (s *Server) HandlePolicyDataBdtDataBdtReferenceIdDelete(c *gin.Context) {
+	logger.DataRepoLog.Tracef("Handle PolicyDataBdtDataBdtReferenceIdDelete")
+
+	collName := "policyData.bdtData"
+	bdtReferenceId := c.Params.ByName("bdtReferenceId")
+
+	s.Processor().PolicyDataBdtDataBdtReferenceIdDeleteProcedure(c, collName, bdtReferenceId)
+}
+
+// HTTPPolicyDataBdtDataBdtReferenceIdGet -
+func (s *Server) HandlePolicyDataBdtDataBdtReferenceIdGet(c *gin.Context) {
+	logger.DataRepoLog.Tracef("Handle PolicyDataBdtDataBdtReferenceIdGet")
+
+	collName := "policyData.bdtData"
+	bdtReferenceId := c.Params.ByName("bdtReferenceId")
+
+	s.Processor().PolicyDataBdtDataBdtReferenceIdGetProcedure(c, collName, bdtReferenceId)
+}
+
+// HTTPPolicyDataBdtDataBdtReferenceIdPut -
+func (s *Server) HandlePolicyDataBdtDataBdtReferenceIdPut(c *gin.Context) {
+	var bdtData models.BdtData
+
+	if err := getDataFromRequestBody(c, &bdtData); err != nil {
+		return
+	}
+	logger.DataRepoLog.Tracef("Handle PolicyDataBdtDataBdtReferenceIdPut")
+
+	collName := "policyData.bdtData"
+	bdtReferenceId := c.Params.ByName("bdtReferenceId")
+
+	s.Processor().PolicyDataBdtDataBdtReferenceIdPutProcedure(c, collName, bdtReferenceId, bdtData)
+}
+
+// HTTPPolicyDataBdtDataGet -
+func (s *Server) HandlePolicyDataBdtDataGet(c *gin.Context) {
+	logger.DataRepoLog.Tracef("Handle PolicyDataBdtDataGet")
+
+	collName := "policyData.bdtData"
+
+	s.Processor().PolicyDataBdtDataGetProcedure(c, collName)
+}
+
+// HTTPPolicyDataPlmnsPlmnIdUePolicySetGet -
+func (s *Server) HandlePolicyDataPlmnsPlmnIdUePolicySetGet(c *gin.Context) {
+	logger.DataRepoLog.Tracef("Handle PolicyDataPlmnsPlmnIdUePolicySetGet")
+
+	collName := "policyData.plmns.uePolicySet"
+	plmnId := c.Params.ByName("plmnId")
+
+	s.Processor().PolicyDataPlmnsPlmnIdUePolicySetGetProcedure(c, collName, plmnId)
+}
+
+// HTTPPolicyDataSponsorConnectivityDataSponsorIdGet -
+func (s *Server) HandlePolicyDataSponsorConnectivityDataSponsorIdGet(c *gin.Context) {
+	logger.DataRepoLog.Tracef("Handle PolicyDataSponsorConnectivityDataSponsorIdGet")
+
+	collName := "policyData.sponsorConnectivityData"
+	sponsorId := c.Params.ByName("sponsorId")
+
+	s.Processor().PolicyDataSponsorConnectivityDataSponsorIdGetProcedure(c, collName, sponsorId)
+}
+
+// HTTPPolicyDataSubsToNotifyPost -
+func (s *Server) HandlePolicyDataSubsToNotifyPost(c *gin.Context) {
+	var policyDataSubscription models.PolicyDataSubscription
+
+	reqBody, err := c.GetRawData()
+	if err != nil {
+		logger.DataRepoLog.Errorf("Get Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsSystemFailure(err.Error())
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusInternalServerError, pd)
+	}
+
+	err = openapi.Deserialize(policyDataSubscription, reqBody, "application/json")
+	if err != nil {
+		logger.DataRepoLog.Errorf("Deserialize Request Body error: %+v", err)
+		pd := util.ProblemDetailsMalformedReqSyntax(err.Error())
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusBadRequest, pd)
+	}
+
+	logger.DataRepoLog.Tracef("Handle PolicyDataSubsToNotifyPost")
+
+	s.Processor().PolicyDataSubsToNotifyPostProcedure(c, policyDataSubscription)
+}
+
+// HTTPPolicyDataSubsToNotifySubsIdDelete -
+func (s *Server) HandlePolicyDataSubsToNotifySubsIdDelete(c *gin.Context) {
+	subsId := c.Params.ByName("subsId")
+
+	s.Processor().PolicyDataSubsToNotifySubsIdDeleteProcedure(c, subsId)
+}
+
+// HTTPPolicyDataSubsToNotifySubsIdPut -
+func (s *Server) HandlePolicyDataSubsToNotifySubsIdPut(c *gin.Context) {
+	var policyDataSubscription models.PolicyDataSubscription
+
+	reqBody, err := c.GetRawData()
+	if err != nil {
+		logger.DataRepoLog.Errorf("Get Request Body error: %+v", err)
+		pd := openapi.ProblemDetailsSystemFailure(err.Error())
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusInternalServerError, pd)
+	}
+
+	err = openapi.Deserialize(policyDataSubscription, reqBody, "application/json")
+	if err != nil {
+		logger.DataRepoLog.Errorf("Deserialize Request Body error: %+v", err)
+		pd := util.ProblemDetailsMalformedReqSyntax(err.Error())
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(http.StatusBadRequest, pd)
+	}
+
+	logger.DataRepoLog.Tracef("Handle PolicyDataSubsToNotifySubsIdPut")
+
+	subsId := c.Params.ByName("subsId")
+
+	s.Processor().PolicyDataSubsToNotifySubsIdPutProcedure(c, subsId, policyDataSubscription)
+}
+
+// HTTPPolicyDataUesUeIdAmDataGet -
+func (s *Server) HandlePolicyDataUesUeIdAmDataGet(c *gin.Context) {
+	logger.DataRepoLog.Tracef("Handle PolicyDataUesUeIdAmDataGet")
+
+	collName := "policyData.ues.amData"
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckUeIdParam(c, ueId) {
+		return
+	}
+	s.Processor().PolicyDataUesUeIdAmDataGetProcedure(c, collName, ueId)
+}
+
+// HTTPPolicyDataUesUeIdAmDataPatch - Modifies the access and mobility policy data of a UE, with a JSON Patch
+// or a JSON merge patch
+func (s *Server) HandlePolicyDataUesUeIdAmDataPatch(c *gin.Context) {
+	logger.DataRepoLog.Tracef("Handle PolicyDataUesUeIdAmDataPatch")
+
+	patch, err := getPatchFromRequestBody(c, MediaTypeJSONPatch, MediaTypeMergePatch)
+	if err != nil {
+		return
+	}
+
+	collName := "policyData.ues.amData"
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckUeIdParam(c, ueId) {
+		return
+	}
+	s.Processor().PolicyDataUesUeIdAmDataPatchProcedure(c, collName, ueId, patch)
+}
+
+// HTTPPolicyDataUesUeIdAmDataPut - Provisions the access and mobility policy data of a UE
+func (s *Server) HandlePolicyDataUesUeIdAmDataPut(c *gin.Context) {
+	var amPolicyData models.AmPolicyData
+
+	if err := getDataFromRequestBody(c, &amPolicyData); err != nil {
+		return
+	}
+	logger.DataRepoLog.Tracef("Handle PolicyDataUesUeIdAmDataPut")
+
+	collName := "policyData.ues.amData"
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckUeIdParam(c, ueId) {
+		return
+	}
+	s.Processor().PolicyDataUesUeIdAmDataPutProcedure(c, collName, ueId, amPolicyData)
+}
+
+// HTTPPolicyDataUesUeIdAmDataDelete - Removes the access and mobility policy data of a UE
+func (s *Server) HandlePolicyDataUesUeIdAmDataDelete(c *gin.Context) {
+	logger.DataRepoLog.Tracef("Handle PolicyDataUesUeIdAmDataDelete")
+
+	collName := "policyData.ues.amData"
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckUeIdParam(c, ueId) {
+		return
+	}
+	s.Processor().PolicyDataUesUeIdAmDataDeleteProcedure(c, collName, ueId)
+}
+
+// HTTPPolicyDataUesUeIdOperatorSpecificDataGet -
+func (s *Server) HandlePolicyDataUesUeIdOperatorSpecificDataGet(c *gin.Context) {
+	collName := "policyData.ues.operatorSpecificData"
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckUeIdParam(c, ueId) {
+		return
+	}
+	s.Processor().PolicyDataUesUeIdOperatorSpecificDataGetProcedure(c, collName, ueId)
+}
+
+// HTTPPolicyDataUesUeIdOperatorSpecificDataPatch - Need to be fixed
+func (s *Server) HandlePolicyDataUesUeIdOperatorSpecificDataPatch(c *gin.Context) {
+	patch, err := getPatchFromRequestBody(c, MediaTypeJSONPatch)
+	if err != nil {
+		return
+	}
+
+	collName := "policyData.ues.operatorSpecificData"
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckUeIdParam(c, ueId) {
+		return
+	}
+
+	s.Processor().PolicyDataUesUeIdOperatorSpecificDataPatchProcedure(c, collName, ueId, patch.PatchItems)
+}
+
+// HTTPPolicyDataUesUeIdOperatorSpecificDataPut -
+func (s *Server) HandlePolicyDataUesUeIdOperatorSpecificDataPut(c *gin.Context) {
+	var operatorSpecificDataContainerMap map[string]models.OperatorSpecificDataContainer
+
+	if err := getDataFromRequestBody(c, &operatorSpecificDataContainerMap); err != nil {
+		return
+	}
+
+	collName := "policyData.ues.operatorSpecificData"
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckUeIdParam(c, ueId) {
+		return
+	}
+
+	s.Processor().PolicyDataUesUeIdOperatorSpecificDataPutProcedure(c, collName, ueId, operatorSpecificDataContainerMap)
+}
+
+// HTTPPolicyDataUesUeIdSmDataGet -
+func (s *Server) HandlePolicyDataUesUeIdSmDataGet(c *gin.Context) {
+	logger.DataRepoLog.Tracef("Handle PolicyDataUesUeIdSmDataGet")
+
+	collName := "policyData.ues.smData"
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckUeIdParam(c, ueId) {
+		return
+	}
+	snssai, ok := util.SnssaiQuery(c, "snssai")
+	if !ok {
+		return
+	}
+	dnn := c.Query("dnn")
+
+	s.Processor().PolicyDataUesUeIdSmDataGetProcedure(c, collName, ueId, snssai, dnn)
+}
+
+// HTTPPolicyDataUesUeIdSmDataPatch -
+func (s *Server) HandlePolicyDataUesUeIdSmDataPatch(c *gin.Context) {
+	patch, err := getPatchFromRequestBody(c, MediaTypeMergePatch)
+	if err != nil {
+		return
+	}
+	logger.DataRepoLog.Tracef("Handle PolicyDataUesUeIdSmDataPatch")
+
+	collName := "policyData.ues.smData"
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckUeIdParam(c, ueId) {
+		return
+	}
+
+	s.Processor().PolicyDataUesUeIdSmDataPatchProcedure(c, collName, ueId, patch)
+}
+
+// HTTPPolicyDataUesUeIdSmDataUsageMonIdDelete -
+func (s *Server) HandlePolicyDataUesUeIdSmDataUsageMonIdDelete(c *gin.Context) {
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckUeIdParam(c, ueId) {
+		return
+	}
+	usageMonId := c.Params.ByName("usageMonId")
+	collName := "policyData.ues.smData.usageMonData"
+
+	s.Processor().PolicyDataUesUeIdSmDataUsageMonIdDeleteProcedure(c, collName, ueId, usageMonId)
+}
+
+// HTTPPolicyDataUesUeIdSmDataUsageMonIdGet -
+func (s *Server) HandlePolicyDataUesUeIdSmDataUsageMonIdGet(c *gin.Context) {
+	collName := "policyData.ues.smData.usageMonData"
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckUeIdParam(c, ueId) {
+		return
+	}
+	usageMonId := c.Params.ByName("usageMonId")
+
+	s.Processor().PolicyDataUesUeIdSmDataUsageMonIdGetProcedure(c, collName, usageMonId, ueId)
+}
+
+// HTTPPolicyDataUesUeIdSmDataUsageMonIdPut -
+func (s *Server) HandlePolicyDataUesUeIdSmDataUsageMonIdPut(c *gin.Context) {
+	var usageMonData models.UsageMonData
+
+	if err := getDataFromRequestBody(c, &usageMonData); err != nil {
+		return
+	}
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckUeIdParam(c, ueId) {
+		return
+	}
+	usageMonId := c.Params.ByName("usageMonId")
+	collName := "policyData.ues.smData.usageMonData"
+
+	s.Processor().PolicyDataUesUeIdSmDataUsageMonIdPutProcedure(c, collName, ueId, usageMonId, usageMonData)
+}
+
+// HTTPPolicyDataUesUeIdUePolicySetGet -
+func (s *Server) HandlePolicyDataUesUeIdUePolicySetGet(c *gin.Context) {
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckUeIdParam(c, ueId) {
+		return
+	}
+	collName := "policyData.ues.uePolicySet"
+
+	s.Processor().PolicyDataUesUeIdUePolicySetGetProcedure(c, collName, ueId)
+}
+
+// HTTPPolicyDataUesUeIdUePolicySetPatch -
+func (s *Server) HandlePolicyDataUesUeIdUePolicySetPatch(c *gin.Context) {
+	var uePolicySet models.UePolicySet
+
+	if err := getDataFromRequestBody(c, &uePolicySet); err != nil {
+		return
+	}
+
+	collName := "policyData.ues.uePolicySet"
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckUeIdParam(c, ueId) {
+		return
+	}
+
+	s.Processor().PolicyDataUesUeIdUePolicySetPatchProcedure(c, collName, ueId, uePolicySet)
+}
+
+// HTTPPolicyDataUesUeIdUePolicySetPut -
+func (s *Server) HandlePolicyDataUesUeIdUePolicySetPut(c *gin.Context) {
+	var uePolicySet models.UePolicySet
+
+	if err := getDataFromRequestBody(c, &uePolicySet); err != nil {
+		return
+	}
+
+	collName := "policyData.ues.uePolicySet"
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckUeIdParam(c, ueId) {
+		return
+	}
+
+	s.Processor().PolicyDataUesUeIdUePolicySetPutProcedure(c, collName, ueId, uePolicySet)
+}
@@ -0,0 +1,158 @@
+package sbi
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	UdrHealthPath       = "/healthz"
+	UdrHealthDetailPath = "/healthz/detail"
+	UdrLivenessPath     = "/livez"
+	UdrReadinessPath    = "/readyz"
+)
+
+const (
+	probeStatusReady    = "ready"
+	probeStatusNotReady = "not ready"
+	probeCheckOk        = "ok"
+)
+
+// readinessReport tells which of the dependencies of the UDR is failing
+type readinessReport struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// healthDetail reports the measures behind the readiness, so that a degrading dependency can be alerted
+// on before it fails
+type healthDetail struct {
+	Status  string        `json:"status"`
+	State   string        `json:"state"`
+	MongoDB mongoDBHealth `json:"mongodb"`
+	Nrf     nrfHealth     `json:"nrf"`
+}
+
+type mongoDBHealth struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type nrfHealth struct {
+	Registered bool `json:"registered"`
+	// Omitted until the NRF accepted a first heartbeat
+	LastHeartbeat *time.Time `json:"lastHeartbeat,omitempty"`
+}
+
+func (s *Server) getProbeRoutes() []Route {
+	return []Route{
+		{
+			"Health",
+			http.MethodGet,
+			UdrHealthPath,
+			s.HandleLiveness,
+		},
+
+		{
+			"HealthDetail",
+			http.MethodGet,
+			UdrHealthDetailPath,
+			s.HandleHealthDetail,
+		},
+
+		{
+			"Liveness",
+			http.MethodGet,
+			UdrLivenessPath,
+			s.HandleLiveness,
+		},
+
+		{
+			"Readiness",
+			http.MethodGet,
+			UdrReadinessPath,
+			s.HandleReadiness,
+		},
+	}
+}
+
+// HandleLiveness - The process is up and serving requests
+func (s *Server) HandleLiveness(c *gin.Context) {
+	c.String(http.StatusOK, "ok")
+}
+
+// HandleReadiness - The server is started, MongoDB answers and the UDR is registered to the NRF
+func (s *Server) HandleReadiness(c *gin.Context) {
+	report := readinessReport{
+		Status: probeStatusReady,
+		Checks: make(map[string]string),
+	}
+	fail := func(check, reason string) {
+		report.Status = probeStatusNotReady
+		report.Checks[check] = reason
+	}
+
+	switch {
+	case s.draining.Load():
+		fail("server", "draining")
+	case !s.ready.Load():
+		fail("server", "starting")
+	default:
+		report.Checks["server"] = probeCheckOk
+	}
+
+	ctx, cancel := context.WithTimeout(c, s.Config().GetProbeMongoPingTimeout())
+	defer cancel()
+	if err := s.pingDataStore(ctx); err != nil {
+		fail("mongodb", err.Error())
+	} else {
+		report.Checks["mongodb"] = probeCheckOk
+	}
+
+	if !s.Config().IsProbeNrfRegistrationCheckSkipped() {
+		if s.nrfRegistered.Load() {
+			report.Checks["nrf"] = probeCheckOk
+		} else {
+			fail("nrf", "not registered")
+		}
+	}
+
+	if report.Status != probeStatusReady {
+		c.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// HandleHealthDetail - The round-trip latency of a MongoDB ping and the last NRF heartbeat, answered
+// 503 when MongoDB does not answer within the configured timeout
+func (s *Server) HandleHealthDetail(c *gin.Context) {
+	report := healthDetail{
+		Status: probeCheckOk,
+		State:  s.State().String(),
+		Nrf:    nrfHealth{Registered: s.nrfRegistered.Load()},
+	}
+	if heartbeat := s.lastNrfHeartbeat.Load(); heartbeat != 0 {
+		lastHeartbeat := time.Unix(0, heartbeat).UTC()
+		report.Nrf.LastHeartbeat = &lastHeartbeat
+	}
+
+	ctx, cancel := context.WithTimeout(c, s.Config().GetProbeHealthDetailTimeout())
+	defer cancel()
+	start := time.Now()
+	err := s.pingDataStore(ctx)
+	report.MongoDB.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		report.Status = probeStatusNotReady
+		report.MongoDB.Status = "unavailable"
+		report.MongoDB.Error = err.Error()
+		c.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+	report.MongoDB.Status = probeCheckOk
+	c.JSON(http.StatusOK, report)
+}
@@ -4,16 +4,23 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 
 	"github.com/free5gc/openapi/models"
 	db "github.com/free5gc/udr/internal/database"
+	"github.com/free5gc/udr/internal/database/mongodb"
 	"github.com/free5gc/udr/internal/logger"
 	"github.com/free5gc/udr/internal/sbi/processor"
 	"github.com/free5gc/udr/pkg/factory"
@@ -26,7 +33,9 @@ type testdata struct {
 	supi    string
 }
 
-func setupHttpServer(t *testing.T) *gin.Engine {
+// setupHttpServer serves the data repository on a datastore of dbConnectorType, along with its connector. The
+// memory one starts empty, the MongoDB one is the test5gc database of setupMongoDB.
+func setupHttpServer(t *testing.T, dbConnectorType factory.DbType) (*gin.Engine, db.DbConnector) {
 	router := util_logger.NewGinWithLogrus(logger.GinLog)
 	dataRepositoryGroup := router.Group(factory.UdrDrResUriPrefix)
 	ctrl := gomock.NewController(t)
@@ -35,8 +44,8 @@ func setupHttpServer(t *testing.T) *gin.Engine {
 	udr := NewMockUDR(ctrl)
 	factory.UdrConfig = &factory.Config{
 		Configuration: &factory.Configuration{
-			DbConnectorType: "mongodb",
-			Mongodb:         &factory.Mongodb{},
+			DbConnectorType: dbConnectorType,
+			Mongodb:         &factory.Mongodb{Name: "test5gc"},
 			Sbi: &factory.Sbi{
 				BindingIPv4: "127.0.0.1",
 				Port:        8000,
@@ -51,10 +60,12 @@ func setupHttpServer(t *testing.T) *gin.Engine {
 	processor := processor.NewProcessor(udr)
 	udr.EXPECT().Processor().Return(processor).AnyTimes()
 
-	s := NewServer(udr, "")
+	s, err := NewServer(udr, "")
+	require.NoError(t, err)
 	dataRepositoryRoutes := s.getDataRepositoryRoutes()
-	AddService(dataRepositoryGroup, dataRepositoryRoutes)
-	return router
+	dataRepositoryRoutes = append(dataRepositoryRoutes, s.getPolicyDataRoutes()...)
+	AddService(dataRepositoryGroup, answerFailures(dataRepositoryRoutes))
+	return router, processor.DbConnector
 }
 
 func setupMongoDB(t *testing.T) {
@@ -68,8 +79,7 @@ func setupMongoDB(t *testing.T) {
 	require.Nil(t, err)
 }
 
-func getUri(t *testing.T, baseUri, extUri string) *httptest.ResponseRecorder {
-	server := setupHttpServer(t)
+func getUri(t *testing.T, server *gin.Engine, baseUri, extUri string) *httptest.ResponseRecorder {
 	reqUri := baseUri + extUri
 	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, reqUri, nil)
 	require.Nil(t, err)
@@ -105,10 +115,9 @@ func getInfluData(supi string) *models.TrafficInfluData {
 	}
 }
 
-func postPutInfluData(t *testing.T, method string, baseUri, extUri string, influData *models.TrafficInfluData) (
-	*httptest.ResponseRecorder, []byte,
-) {
-	server := setupHttpServer(t)
+func postPutInfluData(t *testing.T, server *gin.Engine, method string, baseUri, extUri string,
+	influData *models.TrafficInfluData,
+) (*httptest.ResponseRecorder, []byte) {
 	reqUri := baseUri + extUri
 	bjson, err := json.Marshal(influData)
 	require.Nil(t, err)
@@ -120,20 +129,19 @@ func postPutInfluData(t *testing.T, method string, baseUri, extUri string, influ
 	return rsp, bjson
 }
 
-func postInfluData(t *testing.T, baseUri, extUri string, influData *models.TrafficInfluData) (
+func postInfluData(t *testing.T, server *gin.Engine, baseUri, extUri string, influData *models.TrafficInfluData) (
 	*httptest.ResponseRecorder, []byte,
 ) {
-	return postPutInfluData(t, http.MethodPost, baseUri, extUri, influData)
+	return postPutInfluData(t, server, http.MethodPost, baseUri, extUri, influData)
 }
 
-func putInfluData(t *testing.T, baseUri, extUri string, influData *models.TrafficInfluData) (
+func putInfluData(t *testing.T, server *gin.Engine, baseUri, extUri string, influData *models.TrafficInfluData) (
 	*httptest.ResponseRecorder, []byte,
 ) {
-	return postPutInfluData(t, http.MethodPut, baseUri, extUri, influData)
+	return postPutInfluData(t, server, http.MethodPut, baseUri, extUri, influData)
 }
 
-func delUri(t *testing.T, baseUri, extUri string) *httptest.ResponseRecorder {
-	server := setupHttpServer(t)
+func delUri(t *testing.T, server *gin.Engine, baseUri, extUri string) *httptest.ResponseRecorder {
 	reqUri := baseUri + extUri
 	req, err := http.NewRequestWithContext(context.Background(), http.MethodDelete, reqUri, nil)
 	require.Nil(t, err)
@@ -143,7 +151,7 @@ func delUri(t *testing.T, baseUri, extUri string) *httptest.ResponseRecorder {
 }
 
 func TestUDR_Root(t *testing.T) {
-	server := setupHttpServer(t)
+	server, _ := setupHttpServer(t, db.DBCONNECTOR_TYPE_MEMORY)
 	reqUri := factory.UdrDrResUriPrefix + "/"
 
 	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, reqUri, nil)
@@ -158,11 +166,7 @@ func TestUDR_Root(t *testing.T) {
 }
 
 func TestUDR_GetSubs2Notify_GetBeforeCreateingOne(t *testing.T) {
-	if testing.Short() {
-		t.Skip("skipping testing in short mode")
-	}
-
-	server := setupHttpServer(t)
+	server, _ := setupHttpServer(t, db.DBCONNECTOR_TYPE_MEMORY)
 	reqUri := factory.UdrDrResUriPrefix + "/application-data/influenceData/subs-to-notify?dnn=internet"
 
 	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, reqUri, nil)
@@ -177,11 +181,7 @@ func TestUDR_GetSubs2Notify_GetBeforeCreateingOne(t *testing.T) {
 }
 
 func TestUDR_GetSubs2Notify_CreateThenGet(t *testing.T) {
-	if testing.Short() {
-		t.Skip("skipping testing in short mode")
-	}
-
-	server := setupHttpServer(t)
+	server, _ := setupHttpServer(t, db.DBCONNECTOR_TYPE_MEMORY)
 	baseUri := factory.UdrDrResUriPrefix + "/application-data/influenceData/subs-to-notify"
 	reqUri := baseUri
 
@@ -232,20 +232,20 @@ func TestUDR_GetSubs2Notify_CreateThenGet(t *testing.T) {
 	})
 
 	// Get success
-	rsp = getUri(t, baseUri, "?dnn=internet")
+	rsp = getUri(t, server, baseUri, "?dnn=internet")
 	t.Run("UDR subs-to-notify CreateThenGet - get", func(t *testing.T) {
 		require.Equal(t, http.StatusOK, rsp.Code)
 		require.Equal(t, "["+string(bjson)+"]", rsp.Body.String())
 	})
 
 	// Get without a filter
-	rsp = getUri(t, baseUri, "")
+	rsp = getUri(t, server, baseUri, "")
 	t.Run("UDR subs-to-notify CreateThenGet - get w/o a filter", func(t *testing.T) {
 		require.Equal(t, http.StatusBadRequest, rsp.Code)
 	})
 
 	// Get a non-exist DNN
-	rsp = getUri(t, baseUri, "?dnn=ThisIsABadDNN")
+	rsp = getUri(t, server, baseUri, "?dnn=ThisIsABadDNN")
 	t.Run("UDR subs-to-notify CreateThenGet - get bad DNN", func(t *testing.T) {
 		require.Equal(t, http.StatusOK, rsp.Code)
 		require.Equal(t, "null", rsp.Body.String())
@@ -253,11 +253,7 @@ func TestUDR_GetSubs2Notify_CreateThenGet(t *testing.T) {
 }
 
 func TestUDR_InfluData_GetBeforeCreateing(t *testing.T) {
-	if testing.Short() {
-		t.Skip("skipping testing in short mode")
-	}
-
-	server := setupHttpServer(t)
+	server, _ := setupHttpServer(t, db.DBCONNECTOR_TYPE_MEMORY)
 	reqUri := factory.UdrDrResUriPrefix + "/application-data/influenceData"
 
 	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, reqUri, nil)
@@ -273,20 +269,15 @@ func TestUDR_InfluData_GetBeforeCreateing(t *testing.T) {
 }
 
 func TestUDR_InfluData_CreateThenGet(t *testing.T) {
-	if testing.Short() {
-		t.Skip("skipping testing in short mode")
-	}
-
 	// PUT, PATCH, DELETE
-	setupMongoDB(t)
-	server := setupHttpServer(t)
+	server, _ := setupHttpServer(t, db.DBCONNECTOR_TYPE_MEMORY)
 	baseUri := factory.UdrDrResUriPrefix + "/application-data/influenceData"
 	td1 := testdata{"/influenceId0001", "imsi-208930000000001"}
 	td2 := testdata{"/influenceId0002", "imsi-208930000000002"}
 
 	// Create one - bad method (POST not allowed)
 	influData := getInfluData(td1.supi)
-	rsp, _ := postInfluData(t, baseUri, td1.influId, influData)
+	rsp, _ := postInfluData(t, server, baseUri, td1.influId, influData)
 	t.Run("UDR influ-data CreateThenGet - Create one - bad method",
 		func(t *testing.T) {
 			require.Equal(t, http.StatusMethodNotAllowed, rsp.Code)
@@ -294,7 +285,7 @@ func TestUDR_InfluData_CreateThenGet(t *testing.T) {
 
 	// Create one - normal
 	influData = getInfluData(td1.supi)
-	rsp, bjson := putInfluData(t, baseUri, td1.influId, influData)
+	rsp, bjson := putInfluData(t, server, baseUri, td1.influId, influData)
 	t.Run("UDR influ-data CreateThenGet - Create normal case",
 		func(t *testing.T) {
 			require.Equal(t, http.StatusCreated, rsp.Code)
@@ -303,7 +294,7 @@ func TestUDR_InfluData_CreateThenGet(t *testing.T) {
 
 	// Create one - update existing one with identical data
 	influData = getInfluData(td1.supi)
-	rsp, bjson = putInfluData(t, baseUri, td1.influId, influData)
+	rsp, bjson = putInfluData(t, server, baseUri, td1.influId, influData)
 	t.Run("UDR influ-data CreateThenGet - Create - update existing one-identical data",
 		func(t *testing.T) {
 			require.Equal(t, http.StatusOK, rsp.Code)
@@ -313,7 +304,7 @@ func TestUDR_InfluData_CreateThenGet(t *testing.T) {
 	// Create one - update existing one with some difference
 	influData = getInfluData(td1.supi)
 	influData.Snssai.Sst = 2
-	rsp, bjson = putInfluData(t, baseUri, td1.influId, influData)
+	rsp, bjson = putInfluData(t, server, baseUri, td1.influId, influData)
 	t.Run("UDR influ-data CreateThenGet - Create - update existing one-with some difference",
 		func(t *testing.T) {
 			require.Equal(t, http.StatusOK, rsp.Code)
@@ -340,7 +331,7 @@ func TestUDR_InfluData_CreateThenGet(t *testing.T) {
 	// 	})
 
 	// Get success
-	rsp = getUri(t, baseUri, "?dnns="+influData.Dnn)
+	rsp = getUri(t, server, baseUri, "?dnns="+influData.Dnn)
 	testRsp := []models.TrafficInfluData{}
 	err := json.Unmarshal(rsp.Body.Bytes(), &testRsp)
 	require.Nil(t, err)
@@ -354,7 +345,7 @@ func TestUDR_InfluData_CreateThenGet(t *testing.T) {
 
 	// Create with td2 - normal
 	influData = getInfluData(td2.supi)
-	rsp, bjson = putInfluData(t, baseUri, td2.influId, influData)
+	rsp, bjson = putInfluData(t, server, baseUri, td2.influId, influData)
 	t.Run("UDR influ-data CreateThenGet - Create normal case",
 		func(t *testing.T) {
 			require.Equal(t, http.StatusCreated, rsp.Code)
@@ -362,7 +353,7 @@ func TestUDR_InfluData_CreateThenGet(t *testing.T) {
 		})
 
 	// Get - 2 influencesIds
-	rsp = getUri(t, baseUri, "?dnns="+influData.Dnn)
+	rsp = getUri(t, server, baseUri, "?dnns="+influData.Dnn)
 	err = json.Unmarshal(rsp.Body.Bytes(), &testRsp)
 	t.Log(rsp.Body.String())
 	require.Nil(t, err)
@@ -373,16 +364,48 @@ func TestUDR_InfluData_CreateThenGet(t *testing.T) {
 		})
 
 	// Get a non-exist Supi
-	rsp = getUri(t, baseUri, "?Supi=BadSupi")
+	rsp = getUri(t, server, baseUri, "?supis=BadSupi")
 	err = json.Unmarshal(rsp.Body.Bytes(), &testRsp)
 	require.Nil(t, err)
-	t.Run("UDR influ-data CreateThenGet - Bad DNN",
+	t.Run("UDR influ-data CreateThenGet - Bad SUPI",
 		func(t *testing.T) {
 			require.Equal(t, http.StatusOK, rsp.Code)
 			// expect zero influData
 			require.Equal(t, 0, len(testRsp))
 		})
 
+	// Get - the filters are ANDed, the values of a filter ORed, comma separated or repeated
+	for _, query := range []string{
+		"?supis=BadSupi," + td1.supi + "&dnns=" + influData.Dnn,
+		"?supis=BadSupi&supis=" + td1.supi + "&snssais=" + url.QueryEscape(`[{"sst":2,"sd":"010203"}]`),
+	} {
+		rsp = getUri(t, server, baseUri, query)
+		err = json.Unmarshal(rsp.Body.Bytes(), &testRsp)
+		require.Nil(t, err)
+		t.Run("UDR influ-data CreateThenGet - get - filters "+query,
+			func(t *testing.T) {
+				require.Equal(t, http.StatusOK, rsp.Code)
+				require.Equal(t, 1, len(testRsp))
+				require.Equal(t, td1.supi, testRsp[0].Supi)
+			})
+	}
+
+	// Get without a filter - all the influData
+	rsp = getUri(t, server, baseUri, "")
+	err = json.Unmarshal(rsp.Body.Bytes(), &testRsp)
+	require.Nil(t, err)
+	t.Run("UDR influ-data CreateThenGet - get - no filter",
+		func(t *testing.T) {
+			require.Equal(t, http.StatusOK, rsp.Code)
+			require.Equal(t, 2, len(testRsp))
+		})
+
+	rsp = getUri(t, server, baseUri, "?snssais=sst1")
+	t.Run("UDR influ-data CreateThenGet - get - bad snssais",
+		func(t *testing.T) {
+			require.Equal(t, http.StatusBadRequest, rsp.Code)
+		})
+
 	// Delete td2
 	reqUri := baseUri + td2.influId
 	req, err := http.NewRequestWithContext(context.Background(), http.MethodDelete, reqUri, nil)
@@ -395,7 +418,7 @@ func TestUDR_InfluData_CreateThenGet(t *testing.T) {
 		})
 
 	// Get - 1 influenceId left
-	rsp = getUri(t, baseUri, "?dnns="+influData.Dnn)
+	rsp = getUri(t, server, baseUri, "?dnns="+influData.Dnn)
 	err = json.Unmarshal(rsp.Body.Bytes(), &testRsp)
 	t.Log(rsp.Body.String())
 	require.Nil(t, err)
@@ -406,14 +429,14 @@ func TestUDR_InfluData_CreateThenGet(t *testing.T) {
 		})
 
 	// Delete td1
-	rsp = delUri(t, baseUri, td1.influId)
+	rsp = delUri(t, server, baseUri, td1.influId)
 	t.Run("UDR influ-data CreateThenGet - Delete td1",
 		func(t *testing.T) {
 			require.Equal(t, http.StatusNoContent, rsp.Code)
 		})
 
 	// Get without a filter - 0 influenceId
-	rsp = getUri(t, baseUri, "?dnns="+influData.Dnn)
+	rsp = getUri(t, server, baseUri, "")
 	err = json.Unmarshal(rsp.Body.Bytes(), &testRsp)
 	t.Log(rsp.Body.String())
 	require.Nil(t, err)
@@ -423,3 +446,356 @@ func TestUDR_InfluData_CreateThenGet(t *testing.T) {
 			require.Equal(t, 0, len(testRsp))
 		})
 }
+
+func TestUDR_EnsureIndexes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping testing in short mode")
+	}
+
+	setupMongoDB(t)
+	err := mongoapi.Drop(db.SUBSCDATA_AUTH_SUBSC_DB_COLLECTION_NAME)
+	require.Nil(t, err)
+
+	connector := mongodb.NewMongoDbConnector(&factory.Mongodb{Name: "test5gc"})
+	// Creating them again on a restart must not fail
+	require.NoError(t, connector.EnsureIndexes(context.Background(), db.UdrIndexes))
+	require.NoError(t, connector.EnsureIndexes(context.Background(), db.UdrIndexes))
+
+	collection := mongoapi.Client.Database("test5gc").Collection(db.SUBSCDATA_AUTH_SUBSC_DB_COLLECTION_NAME)
+	_, err = collection.InsertOne(context.Background(), bson.M{"ueId": "imsi-208930000000001"})
+	require.Nil(t, err)
+	_, err = collection.InsertOne(context.Background(), bson.M{"ueId": "imsi-208930000000001"})
+	require.True(t, mongo.IsDuplicateKeyError(err), err)
+}
+
+func patchUri(t *testing.T, server *gin.Engine, baseUri, extUri, contentType, body string) *httptest.ResponseRecorder {
+	reqUri := baseUri + extUri
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPatch, reqUri, bytes.NewReader([]byte(body)))
+	require.Nil(t, err)
+	req.Header.Set("Content-Type", contentType)
+	rsp := httptest.NewRecorder()
+	server.ServeHTTP(rsp, req)
+	return rsp
+}
+
+func TestUDR_PatchFormats(t *testing.T) {
+	ueId := "imsi-208930000000001"
+	testCases := []struct {
+		name       string
+		collName   string
+		extUri     string
+		jsonPatch  string
+		mergePatch string
+		expected   bson.M
+	}{
+		{
+			name:       "Authentication Subscription",
+			collName:   db.SUBSCDATA_AUTH_SUBSC_DB_COLLECTION_NAME,
+			extUri:     "/subscription-data/" + ueId + "/20893/authentication-subscription",
+			jsonPatch:  `[{"op":"replace","path":"/sequenceNumber/sqn","value":"000000000021"}]`,
+			mergePatch: `{"authenticationManagementField":"8000"}`,
+			expected: bson.M{
+				"sequenceNumber":                bson.M{"sqn": "000000000021"},
+				"authenticationManagementField": "8000",
+			},
+		},
+		{
+			name:       "AM Policy Data",
+			collName:   "policyData.ues.amData",
+			extUri:     "/policy-data/ues/" + ueId + "/am-data",
+			jsonPatch:  `[{"op":"add","path":"/subscCats","value":["free5gc"]}]`,
+			mergePatch: `{"praInfos":{"1":{"praId":"1"}}}`,
+			expected: bson.M{
+				"subscCats": bson.A{"free5gc"},
+				"praInfos":  bson.M{"1": bson.M{"praId": "1"}},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server, connector := setupHttpServer(t, db.DBCONNECTOR_TYPE_MEMORY)
+			err := connector.InsertDataToDB(context.Background(), tc.collName, bson.M{
+				"ueId": ueId, "sequenceNumber": bson.M{"sqn": "000000000020"},
+			})
+			require.Nil(t, err)
+
+			rsp := patchUri(t, server, factory.UdrDrResUriPrefix, tc.extUri, "application/json-patch+json", tc.jsonPatch)
+			require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+			rsp = patchUri(t, server, factory.UdrDrResUriPrefix, tc.extUri, "application/merge-patch+json", tc.mergePatch)
+			require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+
+			document, _, err := connector.GetOne(context.Background(), tc.collName, bson.M{"ueId": ueId})
+			require.Nil(t, err)
+			for key, value := range tc.expected {
+				expected, err := json.Marshal(value)
+				require.Nil(t, err)
+				actual, err := json.Marshal(document[key])
+				require.Nil(t, err)
+				require.JSONEq(t, string(expected), string(actual), key)
+			}
+		})
+	}
+}
+
+func TestUDR_ConcurrentSqnPatches(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping testing in short mode")
+	}
+
+	setupMongoDB(t)
+	require.Nil(t, mongoapi.Drop(db.SUBSCDATA_AUTH_SUBSC_DB_COLLECTION_NAME))
+	ueId := "imsi-208930000000001"
+	collection := mongoapi.Client.Database("test5gc").Collection(db.SUBSCDATA_AUTH_SUBSC_DB_COLLECTION_NAME)
+	_, err := collection.InsertOne(context.Background(), bson.M{
+		"ueId": ueId, "encPermanentKey": "8baf473f2f8fd09487cccbd7097c6862",
+		"sequenceNumber": bson.M{"sqn": "000000000000", "sqnScheme": "NON_TIME_BASED"},
+	})
+	require.Nil(t, err)
+
+	server, _ := setupHttpServer(t, db.DBCONNECTOR_TYPE_MONGODB)
+	patch := func(body string) *httptest.ResponseRecorder {
+		req, reqErr := http.NewRequestWithContext(context.Background(), http.MethodPatch,
+			factory.UdrDrResUriPrefix+"/subscription-data/"+ueId+"/authentication-data/authentication-subscription",
+			bytes.NewReader([]byte(body)))
+		require.Nil(t, reqErr)
+		req.Header.Set("Content-Type", "application/json-patch+json")
+		rsp := httptest.NewRecorder()
+		server.ServeHTTP(rsp, req)
+		return rsp
+	}
+
+	// Each update is guarded by the SQN it follows and retried on conflict, none of them is lost
+	const patches = 100
+	var wg sync.WaitGroup
+	codes := make([]int, patches)
+	for i := 0; i < patches; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			body := fmt.Sprintf(`[{"op":"test","path":"/sequenceNumber/sqn","value":"%012x"},`+
+				`{"op":"replace","path":"/sequenceNumber/sqn","value":"%012x"}]`, i, i+1)
+			for codes[i] = patch(body).Code; codes[i] == http.StatusConflict; codes[i] = patch(body).Code {
+				time.Sleep(time.Millisecond)
+			}
+		}(i)
+	}
+	wg.Wait()
+	for _, code := range codes {
+		require.Equal(t, http.StatusNoContent, code)
+	}
+
+	var document struct {
+		EncPermanentKey string                `bson:"encPermanentKey"`
+		SequenceNumber  models.SequenceNumber `bson:"sequenceNumber"`
+	}
+	require.Nil(t, collection.FindOne(context.Background(), bson.M{"ueId": ueId}).Decode(&document))
+	require.Equal(t, fmt.Sprintf("%012x", patches), document.SequenceNumber.Sqn)
+	require.Equal(t, models.SqnScheme_NON_TIME_BASED, document.SequenceNumber.SqnScheme)
+
+	rsp := patch(`[{"op":"replace","path":"/encPermanentKey","value":"00000000000000000000000000000000"}]`)
+	require.Equal(t, http.StatusForbidden, rsp.Code)
+	require.Contains(t, rsp.Body.String(), "MODIFY_NOT_ALLOWED")
+	require.Nil(t, collection.FindOne(context.Background(), bson.M{"ueId": ueId}).Decode(&document))
+	require.Equal(t, "8baf473f2f8fd09487cccbd7097c6862", document.EncPermanentKey)
+
+	// The resynchronisation takes the SQN back
+	rsp = patch(`[{"op":"replace","path":"/sequenceNumber/sqn","value":"000000000001"}]`)
+	require.Equal(t, http.StatusNoContent, rsp.Code)
+	require.Nil(t, collection.FindOne(context.Background(), bson.M{"ueId": ueId}).Decode(&document))
+	require.Equal(t, "000000000001", document.SequenceNumber.Sqn)
+
+	ueId = "imsi-208930000000002"
+	rsp = patch(`[{"op":"replace","path":"/sequenceNumber/sqn","value":"000000000001"}]`)
+	require.Equal(t, http.StatusNotFound, rsp.Code)
+}
+
+func TestUDR_ConcurrentMergePatches(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping testing in short mode")
+	}
+
+	setupMongoDB(t)
+	require.Nil(t, mongoapi.Drop(db.SUBSCDATA_AUTH_SUBSC_DB_COLLECTION_NAME))
+	ueId := "imsi-208930000000001"
+	collection := mongoapi.Client.Database("test5gc").Collection(db.SUBSCDATA_AUTH_SUBSC_DB_COLLECTION_NAME)
+	_, err := collection.InsertOne(context.Background(), bson.M{
+		"ueId": ueId, "encPermanentKey": "8baf473f2f8fd09487cccbd7097c6862",
+	})
+	require.Nil(t, err)
+
+	server, _ := setupHttpServer(t, db.DBCONNECTOR_TYPE_MONGODB)
+	patch := func(body string) int {
+		req, reqErr := http.NewRequestWithContext(context.Background(), http.MethodPatch,
+			factory.UdrDrResUriPrefix+"/subscription-data/"+ueId+"/authentication-data/authentication-subscription",
+			bytes.NewReader([]byte(body)))
+		require.Nil(t, reqErr)
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		rsp := httptest.NewRecorder()
+		server.ServeHTTP(rsp, req)
+		return rsp.Code
+	}
+
+	// Each patch sets a field of its own, none of them is lost to another one written meanwhile
+	const patches = 50
+	var wg sync.WaitGroup
+	codes := make([]int, patches)
+	for i := 0; i < patches; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes[i] = patch(fmt.Sprintf(`{"vendorSpecific%d":"%d"}`, i, i))
+		}(i)
+	}
+	wg.Wait()
+
+	var document bson.M
+	require.Nil(t, collection.FindOne(context.Background(), bson.M{"ueId": ueId}).Decode(&document))
+	for i, code := range codes {
+		require.Equal(t, http.StatusNoContent, code)
+		require.Equal(t, fmt.Sprint(i), document[fmt.Sprintf("vendorSpecific%d", i)])
+	}
+	require.Equal(t, "8baf473f2f8fd09487cccbd7097c6862", document["encPermanentKey"])
+}
+
+func TestUDR_ConcurrentSqnIncrements(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping testing in short mode")
+	}
+
+	setupMongoDB(t)
+	require.Nil(t, mongoapi.Drop(db.SUBSCDATA_AUTH_SUBSC_DB_COLLECTION_NAME))
+	ueId := "imsi-208930000000001"
+	collection := mongoapi.Client.Database("test5gc").Collection(db.SUBSCDATA_AUTH_SUBSC_DB_COLLECTION_NAME)
+	_, err := collection.InsertOne(context.Background(), bson.M{
+		"ueId": ueId, "encPermanentKey": "8baf473f2f8fd09487cccbd7097c6862",
+		"sequenceNumber": bson.M{"sqn": "000000000000", "sqnScheme": "NON_TIME_BASED"},
+	})
+	require.Nil(t, err)
+
+	server, _ := setupHttpServer(t, db.DBCONNECTOR_TYPE_MONGODB)
+	increment := func(body string) *httptest.ResponseRecorder {
+		req, reqErr := http.NewRequestWithContext(context.Background(), http.MethodPost,
+			factory.UdrDrResUriPrefix+"/subscription-data/"+ueId+
+				"/authentication-data/authentication-subscription/sqn-increment",
+			bytes.NewReader([]byte(body)))
+		require.Nil(t, reqErr)
+		req.Header.Set("Content-Type", "application/json")
+		rsp := httptest.NewRecorder()
+		server.ServeHTTP(rsp, req)
+		return rsp
+	}
+
+	// No increment is lost, every SQN answered is distinct
+	const increments = 100
+	var wg sync.WaitGroup
+	rsps := make([]*httptest.ResponseRecorder, increments)
+	for i := 0; i < increments; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rsps[i] = increment(`{"increment":1}`)
+		}(i)
+	}
+	wg.Wait()
+	succeeded := 0
+	sqns := make(map[string]bool, increments)
+	for _, rsp := range rsps {
+		if rsp.Code != http.StatusOK {
+			continue
+		}
+		succeeded++
+		var sequenceNumber models.SequenceNumber
+		require.Nil(t, json.Unmarshal(rsp.Body.Bytes(), &sequenceNumber))
+		require.False(t, sqns[sequenceNumber.Sqn], sequenceNumber.Sqn)
+		sqns[sequenceNumber.Sqn] = true
+	}
+	require.Equal(t, increments, succeeded)
+
+	var document struct {
+		SequenceNumber models.SequenceNumber `bson:"sequenceNumber"`
+	}
+	require.Nil(t, collection.FindOne(context.Background(), bson.M{"ueId": ueId}).Decode(&document))
+	require.Equal(t, fmt.Sprintf("%012x", succeeded), document.SequenceNumber.Sqn)
+	require.Equal(t, models.SqnScheme_NON_TIME_BASED, document.SequenceNumber.SqnScheme)
+
+	// The SQN wraps at 48 bits
+	_, err = collection.UpdateOne(context.Background(), bson.M{"ueId": ueId},
+		bson.M{"$set": bson.M{"sequenceNumber.sqn": "ffffffffffff"}})
+	require.Nil(t, err)
+	rsp := increment(`{"increment":32}`)
+	require.Equal(t, http.StatusOK, rsp.Code)
+	require.Contains(t, rsp.Body.String(), `"sqn":"00000000001f"`)
+
+	require.Equal(t, http.StatusBadRequest, increment(`{"increment":0}`).Code)
+
+	ueId = "imsi-208930000000002"
+	require.Equal(t, http.StatusNotFound, increment(`{"increment":1}`).Code)
+}
+
+func TestUDR_ConcurrentIfMatchPuts(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping testing in short mode")
+	}
+
+	setupMongoDB(t)
+	const collName = "subscriptionData.contextData.amf3gppAccess"
+	require.Nil(t, mongoapi.Drop(collName))
+	server, _ := setupHttpServer(t, db.DBCONNECTOR_TYPE_MONGODB)
+	amf3gppUri := factory.UdrDrResUriPrefix + "/subscription-data/imsi-208930000000001/context-data/amf-3gpp-access"
+	put := func(amfInstanceId, ifMatch string) *httptest.ResponseRecorder {
+		req, reqErr := http.NewRequestWithContext(context.Background(), http.MethodPut, amf3gppUri,
+			bytes.NewReader([]byte(`{"amfInstanceId":"`+amfInstanceId+`",`+
+				`"deregCallbackUri":"http://127.0.0.18:8000/dereg",`+
+				`"guami":{"plmnId":{"mcc":"208","mnc":"93"},"amfId":"cafe00"},"ratType":"NR"}`)))
+		require.Nil(t, reqErr)
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		rsp := httptest.NewRecorder()
+		server.ServeHTTP(rsp, req)
+		return rsp
+	}
+
+	rsp := put("amf-source", "")
+	require.Equal(t, http.StatusNoContent, rsp.Code, rsp.Body.String())
+	etag := rsp.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	// The target AMFs read the registration of the source AMF, then replace it at once: MongoDB applies a
+	// single replacement of the version of the ETag
+	amfs := []string{"amf-target-1", "amf-target-2", "amf-target-3", "amf-target-4", "amf-target-5"}
+	codes := make([]int, len(amfs))
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i, amf := range amfs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			codes[i] = put(amf, etag).Code
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	var winner string
+	for i, code := range codes {
+		if code == http.StatusNoContent {
+			require.Empty(t, winner, "a single writer wins")
+			winner = amfs[i]
+			continue
+		}
+		require.Equal(t, http.StatusPreconditionFailed, code)
+	}
+	require.NotEmpty(t, winner)
+
+	var document struct {
+		AmfInstanceId string `bson:"amfInstanceId"`
+	}
+	collection := mongoapi.Client.Database("test5gc").Collection(collName)
+	require.Nil(t, collection.FindOne(context.Background(),
+		bson.M{"ueId": "imsi-208930000000001"}).Decode(&document))
+	require.Equal(t, winner, document.AmfInstanceId)
+}
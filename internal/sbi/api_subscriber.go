@@ -0,0 +1,82 @@
+package sbi
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/sbi/processor"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/util/metrics/sbi"
+)
+
+func (s *Server) getSubscriberRoutes() []Route {
+	return []Route{
+		{
+			Name:        "QuerySubscriptionDataSets",
+			Method:      http.MethodGet,
+			Pattern:     "/subscription-data/:ueId",
+			HandlerFunc: s.HandleQuerySubscriptionDataSets,
+		},
+		{
+			Name:        "DeleteSubscriber",
+			Method:      http.MethodDelete,
+			Pattern:     "/subscription-data/:ueId",
+			HandlerFunc: s.HandleDeleteSubscriber,
+		},
+	}
+}
+
+// HandleDeleteSubscriber - Offboards a subscriber, removing all of its data, and answers what was removed
+func (s *Server) HandleDeleteSubscriber(c *gin.Context) {
+	logger.DataRepoLog.Tracef("Handle DeleteSubscriber")
+
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckSupiParam(c, ueId) {
+		return
+	}
+
+	s.Processor().DeleteSubscriber(c, ueId)
+}
+
+// HandleQuerySubscriptionDataSets - Retrieves the provisioned data sets of dataset-names of a UE in one request,
+// all of them when absent. plmn-id is the serving PLMN of the data sets stored by serving PLMN, e.g. AM, which
+// cannot be asked for without it; the data sets without data are left out.
+func (s *Server) HandleQuerySubscriptionDataSets(c *gin.Context) {
+	logger.DataRepoLog.Tracef("Handle QuerySubscriptionDataSets")
+
+	ueId := c.Params.ByName("ueId")
+	if !util.CheckUeIdParam(c, ueId) {
+		return
+	}
+	dataSetNames, ok := provisionedDataSetNamesQuery(c)
+	if !ok {
+		return
+	}
+
+	servingPlmnId := c.Query("plmn-id")
+	var detail string
+	switch {
+	case servingPlmnId != "" && !util.IsValidServingPlmnId(servingPlmnId):
+		detail = "plmn-id: shall be the MCC and MNC of a PLMN"
+	case servingPlmnId == "":
+		for _, dataSetName := range dataSetNames {
+			if processor.IsPerPlmnDataSet(dataSetName) {
+				detail = fmt.Sprintf("plmn-id: is required for the data set %s", dataSetName)
+				break
+			}
+		}
+	}
+	if detail != "" {
+		pd := util.ProblemDetailsMalformedReqSyntax(detail)
+		logger.DataRepoLog.Errorf("QuerySubscriptionDataSets: %s", detail)
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+
+	s.Processor().QueryProvisionedDataProcedure(c, ueId, servingPlmnId, models.ProvisionedDataSets{}, dataSetNames)
+}
@@ -0,0 +1,66 @@
+package sbi
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/util/metrics/sbi"
+)
+
+func (s *Server) getSupiListRoutes() []Route {
+	return []Route{
+		{
+			Name:        "GetSupiList",
+			Method:      http.MethodGet,
+			Pattern:     "/subscription-data/supis",
+			HandlerFunc: s.HandleGetSupiList,
+		},
+	}
+}
+
+// HandleGetSupiList - Retrieves one page of the SUPIs of the subscribers
+func (s *Server) HandleGetSupiList(c *gin.Context) {
+	logger.DataRepoLog.Tracef("Handle GetSupiList")
+
+	defaultPageSize, maxPageSize := s.Config().GetPageSizes()
+	pageSize, detail := positiveQueryInt(c, "page-size", defaultPageSize)
+	if detail == "" && pageSize > maxPageSize {
+		detail = fmt.Sprintf("page-size should not exceed %d", maxPageSize)
+	}
+	pageNumber, pageNumberDetail := positiveQueryInt(c, "page-number", 1)
+	if detail == "" {
+		detail = pageNumberDetail
+	}
+	if detail != "" {
+		problemDetails := &models.ProblemDetails{
+			Title:  "Invalid parameter",
+			Status: http.StatusBadRequest,
+			Detail: detail,
+			Cause:  "INVALID_QUERY_PARAM",
+		}
+		logger.DataRepoLog.Errorf("GetSupiList: %s", detail)
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
+		c.JSON(http.StatusBadRequest, problemDetails)
+		return
+	}
+
+	s.Processor().GetSupiList(c, pageSize, pageNumber)
+}
+
+// positiveQueryInt returns defaultValue when the query parameter is absent, and a detail when it is invalid
+func positiveQueryInt(c *gin.Context, key string, defaultValue int) (int, string) {
+	param, ok := c.GetQuery(key)
+	if !ok {
+		return defaultValue, ""
+	}
+	value, err := strconv.Atoi(param)
+	if err != nil || value <= 0 {
+		return 0, fmt.Sprintf("%s should be a positive integer", key)
+	}
+	return value, ""
+}
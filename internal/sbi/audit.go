@@ -0,0 +1,131 @@
+package sbi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/udr/internal/sbi/processor"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/udr/pkg/factory"
+)
+
+// maxAuditBodyBytes bounds the copy of the request body kept to summarize the write
+const maxAuditBodyBytes = 64 << 10
+
+// auditedScopes are the additional scopes of the data sets whose writes are audited
+var auditedScopes = map[string]bool{
+	util.ScopeNudrDrSubscriptionData: true,
+	util.ScopeNudrDrPolicyData:       true,
+}
+
+// auditWrites records the successful PUT, PATCH and DELETE of the group in the audit trail, summarized
+// from a copy of the request body taken on its way to the handler
+func (s *Server) auditWrites(c *gin.Context) {
+	method := c.Request.Method
+	if method != http.MethodPut && method != http.MethodPatch && method != http.MethodDelete {
+		return
+	}
+	// A dry run writes nothing
+	if processor.IsDryRun(c) {
+		return
+	}
+
+	body := &cappedBuffer{max: maxAuditBodyBytes}
+	if c.Request.Body != nil && c.Request.Body != http.NoBody {
+		c.Request.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.TeeReader(c.Request.Body, body), c.Request.Body}
+	}
+
+	c.Next()
+
+	if status := c.Writer.Status(); status < http.StatusOK || status >= http.StatusMultipleChoices {
+		return
+	}
+	ueId := c.Param("ueId")
+	if ueId == "" {
+		ueId = c.Param("ueGroupId")
+	}
+	s.Processor().RecordAudit(processor.AuditRecord{
+		Timestamp:             time.Now().UTC(),
+		UeId:                  ueId,
+		Resource:              strings.TrimPrefix(c.Request.URL.Path, factory.UdrDrResUriPrefix),
+		Operation:             method,
+		RequesterNfInstanceId: c.GetString(util.RequesterNfInstanceIdCtxKey),
+		Summary:               auditSummary(method, c.ContentType(), body),
+	})
+}
+
+// auditSummary names the members or the paths the write changed, leaving their values, e.g. the keys
+// of an authentication subscription, out of the trail
+func auditSummary(method, contentType string, body *cappedBuffer) string {
+	if method == http.MethodDelete {
+		return "deleted"
+	}
+	if body.truncated {
+		return fmt.Sprintf("body of more than %d bytes", body.max)
+	}
+
+	if strings.EqualFold(contentType, MediaTypeJSONPatch) {
+		var patchItems []models.PatchItem
+		if err := json.Unmarshal(body.Bytes(), &patchItems); err != nil {
+			return "unreadable JSON Patch"
+		}
+		operations := make([]string, 0, len(patchItems))
+		for _, item := range patchItems {
+			operations = append(operations, fmt.Sprintf("%s %s", item.Op, item.Path))
+		}
+		return strings.Join(operations, "; ")
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body.Bytes(), &members); err != nil {
+		return ""
+	}
+	var set, removed []string
+	for member, value := range members {
+		if string(value) == "null" && method == http.MethodPatch {
+			removed = append(removed, member)
+		} else {
+			set = append(set, member)
+		}
+	}
+	sort.Strings(set)
+	sort.Strings(removed)
+
+	verb := "put"
+	if method == http.MethodPatch {
+		verb = "merge"
+	}
+	summary := verb + " " + strings.Join(set, ", ")
+	if len(removed) > 0 {
+		summary += "; remove " + strings.Join(removed, ", ")
+	}
+	return summary
+}
+
+// cappedBuffer keeps the first max bytes written to it
+type cappedBuffer struct {
+	bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.Len(); len(p) > room {
+		b.truncated = true
+		b.Buffer.Write(p[:room])
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
@@ -0,0 +1,41 @@
+package sbi
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditSummary(t *testing.T) {
+	testCases := []struct {
+		name        string
+		method      string
+		contentType string
+		body        string
+		expected    string
+	}{
+		{"Delete", http.MethodDelete, "", "", "deleted"},
+		{"Put", http.MethodPut, MediaTypeJSON, `{"subscCats":["x"],"gpsis":["msisdn-0900000000"]}`, "put gpsis, subscCats"},
+		{"JSON Patch", http.MethodPatch, MediaTypeJSONPatch,
+			`[{"op":"replace","path":"/sequenceNumber","value":"16f3b3f70fc2"},{"op":"remove","path":"/x"}]`,
+			"replace /sequenceNumber; remove /x"},
+		{"Merge Patch", http.MethodPatch, MediaTypeMergePatch,
+			`{"encPermanentKey":"8baf473f2f8fd09487cccbd7097c6862","opc":null}`, "merge encPermanentKey; remove opc"},
+		{"Unreadable JSON Patch", http.MethodPatch, MediaTypeJSONPatch, `{`, "unreadable JSON Patch"},
+		{"Truncated", http.MethodPut, MediaTypeJSON, `{"a":"` + strings.Repeat("a", maxAuditBodyBytes) + `"}`,
+			"body of more than 65536 bytes"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			body := &cappedBuffer{max: maxAuditBodyBytes}
+			_, err := body.Write([]byte(tc.body))
+			require.NoError(t, err)
+			summary := auditSummary(tc.method, tc.contentType, body)
+			require.Equal(t, tc.expected, summary)
+			require.NotContains(t, summary, "8baf473f2f8fd09487cccbd7097c6862")
+		})
+	}
+}
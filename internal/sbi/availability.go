@@ -0,0 +1,151 @@
+package sbi
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/util/metrics/sbi"
+)
+
+// ServerState is the availability of the UDR to its consumers
+type ServerState int32
+
+const (
+	ServerStateStarting ServerState = iota
+	ServerStateReady
+	ServerStateOverloaded
+	ServerStateShuttingDown
+)
+
+func (st ServerState) String() string {
+	switch st {
+	case ServerStateStarting:
+		return "starting"
+	case ServerStateReady:
+		return "ready"
+	case ServerStateOverloaded:
+		return "overloaded"
+	case ServerStateShuttingDown:
+		return "shutting-down"
+	default:
+		return "unknown"
+	}
+}
+
+// State returns the availability of the UDR, only a ready server can be overloaded
+func (s *Server) State() ServerState {
+	state := ServerState(s.state.Load())
+	if state == ServerStateReady && s.isOverloaded() {
+		return ServerStateOverloaded
+	}
+	return state
+}
+
+func (s *Server) isOverloaded() bool {
+	maxInflight := s.Config().GetMaxInflightRequests()
+	return maxInflight > 0 && s.ActiveRequests() > int64(maxInflight)
+}
+
+// updateState derives the state from the startup sequence, the datastore and the NRF registration,
+// it is called whenever one of them changes
+func (s *Server) updateState() {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	state := ServerStateReady
+	switch {
+	case s.draining.Load():
+		state = ServerStateShuttingDown
+	case !s.ready.Load() || !s.dataStoreAvailable.Load():
+		state = ServerStateStarting
+	case !s.nrfRegistered.Load() && !s.Config().IsProbeNrfRegistrationCheckSkipped():
+		state = ServerStateStarting
+	}
+
+	if previous := ServerState(s.state.Swap(int32(state))); previous != state {
+		logger.SBILog.Infof("Server state changed from %s to %s", previous, state)
+	}
+}
+
+// unavailableReason tells the consumer why a starting server rejects its request
+func (s *Server) unavailableReason() string {
+	switch {
+	case !s.ready.Load():
+		return "UDR is starting"
+	case !s.dataStoreAvailable.Load():
+		return "UDR datastore is unavailable"
+	default:
+		return "UDR is not registered to the NRF"
+	}
+}
+
+// rejectUnavailable answers 503 with a Retry-After while the UDR is not ready to serve the request.
+// The metrics stay reachable so that an unavailable UDR can still be observed.
+func (s *Server) rejectUnavailable(c *gin.Context) {
+	if s.rejectWhileDraining(c); c.IsAborted() {
+		return
+	}
+	if c.FullPath() == UdrSbiMetricsPath {
+		return
+	}
+
+	state := s.State()
+	if state == ServerStateReady {
+		return
+	}
+
+	pd := util.ProblemDetailsServiceUnavailable(s.unavailableReason())
+	if state == ServerStateOverloaded {
+		pd = util.ProblemDetailsNfCongestion(fmt.Sprintf("UDR has more than %d requests in flight",
+			s.Config().GetMaxInflightRequests()))
+	}
+	logger.SBILog.Debugf("Reject %s %s while %s: %s", c.Request.Method, c.Request.URL.Path, state, pd.Detail)
+	c.Header("Retry-After", strconv.Itoa(int(s.Config().GetAvailabilityRetryAfter()/time.Second)))
+	c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+	c.AbortWithStatusJSON(int(pd.Status), pd)
+}
+
+// watchDataStore checks the datastore every interval until ctx is done, so that the requests are
+// rejected while the datastore is unreachable and served again once it recovers
+func (s *Server) watchDataStore(ctx context.Context, wg *sync.WaitGroup) {
+	s.checkDataStore(ctx)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(s.Config().GetDataStoreCheckInterval())
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.checkDataStore(ctx)
+			}
+		}
+	}()
+}
+
+func (s *Server) checkDataStore(ctx context.Context) {
+	pingCtx, cancel := context.WithTimeout(ctx, s.Config().GetProbeMongoPingTimeout())
+	defer cancel()
+
+	err := s.pingDataStore(pingCtx)
+	available := err == nil
+	if s.dataStoreAvailable.Swap(available) != available {
+		if available {
+			logger.SBILog.Infof("Datastore is available")
+		} else {
+			logger.SBILog.Warnf("Datastore is unavailable: %+v", err)
+		}
+	}
+	s.updateState()
+}
@@ -0,0 +1,115 @@
+package sbi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/udr/pkg/factory"
+)
+
+func TestServer_AvailabilityStates(t *testing.T) {
+	s := newTestServer(t)
+	s.SetReady(false)
+	s.SetNrfRegistered(false)
+	factory.UdrConfig.Configuration.Availability = &factory.Availability{RetryAfter: 3}
+
+	var pingErr error
+	s.pingDataStore = func(ctx context.Context) error {
+		return pingErr
+	}
+	s.router.GET("/ping", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	request := func(path string) *httptest.ResponseRecorder {
+		rsp := httptest.NewRecorder()
+		s.router.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, path, nil))
+		return rsp
+	}
+	requireUnavailable := func(cause, detail string) {
+		rsp := request("/ping")
+		require.Equal(t, http.StatusServiceUnavailable, rsp.Code)
+		require.Equal(t, "3", rsp.Header().Get("Retry-After"))
+		var pd models.ProblemDetails
+		require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &pd))
+		require.Equal(t, cause, pd.Cause)
+		require.Equal(t, detail, pd.Detail)
+	}
+
+	require.Equal(t, ServerStateStarting, s.State())
+	requireUnavailable("SYSTEM_FAILURE", "UDR is starting")
+	require.Equal(t, http.StatusOK, request(UdrSbiMetricsPath).Code)
+
+	s.SetReady(true)
+	require.Equal(t, ServerStateStarting, s.State())
+	requireUnavailable("SYSTEM_FAILURE", "UDR is not registered to the NRF")
+
+	s.SetNrfRegistered(true)
+	require.Equal(t, ServerStateReady, s.State())
+	require.Equal(t, http.StatusNoContent, request("/ping").Code)
+
+	pingErr = errors.New("server selection timeout")
+	s.checkDataStore(context.Background())
+	require.Equal(t, ServerStateStarting, s.State())
+	requireUnavailable("SYSTEM_FAILURE", "UDR datastore is unavailable")
+
+	pingErr = nil
+	s.checkDataStore(context.Background())
+	require.Equal(t, ServerStateReady, s.State())
+	require.Equal(t, http.StatusNoContent, request("/ping").Code)
+
+	s.Shutdown(context.Background())
+	require.Equal(t, ServerStateShuttingDown, s.State())
+	rsp := request("/ping")
+	require.Equal(t, http.StatusServiceUnavailable, rsp.Code)
+	require.Contains(t, rsp.Body.String(), "NF_SERVICE_FAILOVER")
+
+	// The datastore watch must not bring a shutting down server back
+	s.checkDataStore(context.Background())
+	require.Equal(t, ServerStateShuttingDown, s.State())
+}
+
+func TestServer_AvailabilityOverload(t *testing.T) {
+	s := newTestServer(t)
+	factory.UdrConfig.Configuration.Availability = &factory.Availability{MaxInflightRequests: 1}
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	s.router.GET("/slow", func(c *gin.Context) {
+		close(entered)
+		<-release
+		c.Status(http.StatusNoContent)
+	})
+	s.router.GET("/ping", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	slowDone := make(chan struct{})
+	go func() {
+		defer close(slowDone)
+		s.router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+	}()
+	<-entered
+
+	// The request being checked is in flight as well
+	rsp := httptest.NewRecorder()
+	s.router.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, "/ping", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rsp.Code)
+	require.Equal(t, "5", rsp.Header().Get("Retry-After"))
+	require.Contains(t, rsp.Body.String(), "NF_CONGESTION")
+
+	close(release)
+	<-slowDone
+	require.Equal(t, ServerStateReady, s.State())
+	rsp = httptest.NewRecorder()
+	s.router.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, "/ping", nil))
+	require.Equal(t, http.StatusNoContent, rsp.Code)
+}
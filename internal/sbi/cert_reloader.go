@@ -0,0 +1,131 @@
+package sbi
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/free5gc/udr/internal/logger"
+)
+
+// certReloadInterval is how often the certificate files are checked for changes
+const certReloadInterval = 10 * time.Second
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// certReloader serves the TLS certificate from an atomically swapped holder and reloads it when
+// its files change on disk or on SIGHUP, so that rotated certificates are used without restarting the server
+type certReloader struct {
+	pemPath string
+	keyPath string
+
+	cert     atomic.Pointer[tls.Certificate]
+	pemStamp fileStamp
+	keyStamp fileStamp
+
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newCertReloader(pemPath, keyPath string) (*certReloader, error) {
+	r := &certReloader{
+		pemPath: pemPath,
+		keyPath: keyPath,
+		done:    make(chan struct{}),
+	}
+	if _, err := r.reloadIfChanged(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.cert.Load(), nil
+}
+
+// reloadIfChanged loads the key pair when one of its files changed since the last successful load,
+// the current certificate is kept when the new pair cannot be loaded
+func (r *certReloader) reloadIfChanged() (bool, error) {
+	return r.reload(false)
+}
+
+// reload loads the key pair, unless its files are unchanged and force is not set
+func (r *certReloader) reload(force bool) (bool, error) {
+	pemStamp, err := statFile(r.pemPath)
+	if err != nil {
+		return false, err
+	}
+	keyStamp, err := statFile(r.keyPath)
+	if err != nil {
+		return false, err
+	}
+	if !force && r.cert.Load() != nil && pemStamp == r.pemStamp && keyStamp == r.keyStamp {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.pemPath, r.keyPath)
+	if err != nil {
+		return false, fmt.Errorf("load certificate [%s] and key [%s] fail: %w", r.pemPath, r.keyPath, err)
+	}
+
+	r.cert.Store(&cert)
+	r.pemStamp, r.keyStamp = pemStamp, keyStamp
+	return true, nil
+}
+
+// start watches the files every interval and reloads them unconditionally on SIGHUP, for the rotations
+// keeping the modification time of the files
+func (r *certReloader) start(interval time.Duration) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	go r.watch(interval, hangup)
+}
+
+func (r *certReloader) watch(interval time.Duration, hangup chan os.Signal) {
+	defer signal.Stop(hangup)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		force := false
+		select {
+		case <-r.done:
+			return
+		case <-ticker.C:
+		case <-hangup:
+			force = true
+		}
+
+		reloaded, err := r.reload(force)
+		if err != nil {
+			logger.SBILog.Errorf("TLS certificate reload failed, keep serving the previous one: %+v", err)
+		} else if reloaded {
+			logger.SBILog.Infof("TLS certificate reloaded from %s", r.pemPath)
+		}
+	}
+}
+
+func (r *certReloader) stop() {
+	r.stopOnce.Do(func() {
+		close(r.done)
+	})
+}
+
+func statFile(path string) (fileStamp, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}, err
+	}
+	return fileStamp{
+		modTime: info.ModTime(),
+		size:    info.Size(),
+	}, nil
+}
@@ -0,0 +1,156 @@
+package sbi
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestCert(t *testing.T, pemPath, keyPath, commonName string, modTime time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(pemPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600))
+	require.NoError(t, os.Chtimes(pemPath, modTime, modTime))
+	require.NoError(t, os.Chtimes(keyPath, modTime, modTime))
+}
+
+func TestCertReloader_NewHandshakesUseReloadedCert(t *testing.T) {
+	dir := t.TempDir()
+	pemPath := filepath.Join(dir, "udr.pem")
+	keyPath := filepath.Join(dir, "udr.key")
+	now := time.Now()
+	writeTestCert(t, pemPath, keyPath, "udr-first", now.Add(-time.Minute))
+
+	reloader, err := newCertReloader(pemPath, keyPath)
+	require.NoError(t, err)
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.getCertificate,
+	})
+	require.NoError(t, err)
+	defer ln.Close()
+	go func() {
+		for {
+			conn, acceptErr := ln.Accept()
+			if acceptErr != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				buf := make([]byte, 1)
+				for {
+					if _, readErr := conn.Read(buf); readErr != nil {
+						return
+					}
+					if _, writeErr := conn.Write(buf); writeErr != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+
+	dial := func() *tls.Conn {
+		conn, dialErr := tls.Dial("tcp", ln.Addr().String(), &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: true, // #nosec G402 -- self-signed test certificates
+		})
+		require.NoError(t, dialErr)
+		return conn
+	}
+	serverName := func(conn *tls.Conn) string {
+		return conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+	}
+	echo := func(conn *tls.Conn) {
+		_, writeErr := conn.Write([]byte{'x'})
+		require.NoError(t, writeErr)
+		buf := make([]byte, 1)
+		_, readErr := conn.Read(buf)
+		require.NoError(t, readErr)
+	}
+
+	oldConn := dial()
+	defer oldConn.Close()
+	require.Equal(t, "udr-first", serverName(oldConn))
+
+	// Rotate the certificate while the first connection is still open
+	writeTestCert(t, pemPath, keyPath, "udr-second", now)
+	reloaded, err := reloader.reloadIfChanged()
+	require.NoError(t, err)
+	require.True(t, reloaded)
+
+	newConn := dial()
+	defer newConn.Close()
+	require.Equal(t, "udr-second", serverName(newConn))
+	echo(oldConn)
+	require.Equal(t, "udr-first", serverName(oldConn))
+
+	// A broken key pair keeps the previous certificate in use
+	require.NoError(t, os.WriteFile(keyPath, []byte("not a key"), 0o600))
+	require.NoError(t, os.Chtimes(keyPath, now.Add(time.Minute), now.Add(time.Minute)))
+	_, err = reloader.reloadIfChanged()
+	require.Error(t, err)
+
+	lastConn := dial()
+	defer lastConn.Close()
+	require.Equal(t, "udr-second", serverName(lastConn))
+}
+
+func TestCertReloader_ReloadOnSighup(t *testing.T) {
+	dir := t.TempDir()
+	pemPath := filepath.Join(dir, "udr.pem")
+	keyPath := filepath.Join(dir, "udr.key")
+	modTime := time.Now().Add(-time.Minute)
+	writeTestCert(t, pemPath, keyPath, "udr-first", modTime)
+
+	reloader, err := newCertReloader(pemPath, keyPath)
+	require.NoError(t, err)
+	reloader.start(time.Hour)
+	defer reloader.stop()
+
+	commonName := func() string {
+		cert, certErr := reloader.getCertificate(nil)
+		require.NoError(t, certErr)
+		leaf, parseErr := x509.ParseCertificate(cert.Certificate[0])
+		require.NoError(t, parseErr)
+		return leaf.Subject.CommonName
+	}
+	require.Equal(t, "udr-first", commonName())
+
+	// The rotated files keep the modification time, only SIGHUP has them reloaded
+	writeTestCert(t, pemPath, keyPath, "udr-second", modTime)
+	process, err := os.FindProcess(os.Getpid())
+	require.NoError(t, err)
+	require.NoError(t, process.Signal(syscall.SIGHUP))
+	require.Eventually(t, func() bool {
+		return commonName() == "udr-second"
+	}, 5*time.Second, 10*time.Millisecond)
+}
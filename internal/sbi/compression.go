@@ -0,0 +1,183 @@
+package sbi
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/util/metrics/sbi"
+)
+
+const encodingGzip = "gzip"
+
+// decompressRequest replaces a gzip request body by its decompressed content, so that limitRequestBody
+// bounds the decompressed size and the handlers read plain JSON
+func (s *Server) decompressRequest(c *gin.Context) {
+	if enabled, _ := s.Config().GetSbiCompression(); !enabled {
+		return
+	}
+	if !strings.EqualFold(c.GetHeader("Content-Encoding"), encodingGzip) ||
+		c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return
+	}
+
+	reader, err := gzip.NewReader(c.Request.Body)
+	if err != nil {
+		pd := util.ProblemDetailsMalformedReqSyntax("invalid gzip request body: " + err.Error())
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, http.StatusText(int(pd.Status)))
+		c.AbortWithStatusJSON(int(pd.Status), pd)
+		return
+	}
+	c.Request.Body = &gzipRequestBody{Reader: reader, body: c.Request.Body}
+	c.Request.Header.Del("Content-Encoding")
+	c.Request.Header.Del("Content-Length")
+	c.Request.ContentLength = -1
+}
+
+type gzipRequestBody struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (b *gzipRequestBody) Close() error {
+	if err := b.Reader.Close(); err != nil {
+		_ = b.body.Close()
+		return err
+	}
+	return b.body.Close()
+}
+
+// compressResponse gzips the successful responses reaching the configured size for the consumers accepting it.
+// The error responses and the bodies already encoded by their handler are sent as they are.
+func (s *Server) compressResponse(c *gin.Context) {
+	enabled, minSize := s.Config().GetSbiCompression()
+	if !enabled || !acceptsGzip(c.GetHeader("Accept-Encoding")) {
+		return
+	}
+
+	writer := &gzipResponseWriter{ResponseWriter: c.Writer, minSize: minSize}
+	c.Writer = writer
+	// On a panic the buffered body is dropped, recoverPanic answers on the original writer
+	defer func() {
+		c.Writer = writer.ResponseWriter
+	}()
+
+	c.Next()
+
+	if err := writer.finish(); err != nil {
+		logger.SBILog.Warnf("Compress response of %s %s failed: %+v", c.Request.Method, c.Request.URL.Path, err)
+	}
+}
+
+// acceptsGzip reports whether the Accept-Encoding lists gzip, or *, with a non-zero quality
+func acceptsGzip(acceptEncoding string) bool {
+	for _, coding := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(coding, ";")
+		name = strings.TrimSpace(name)
+		if !strings.EqualFold(name, encodingGzip) && name != "*" {
+			continue
+		}
+		qvalue, found := strings.CutPrefix(strings.TrimSpace(params), "q=")
+		if !found {
+			return true
+		}
+		if q, err := strconv.ParseFloat(qvalue, 64); err == nil && q > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// gzipResponseWriter holds the body back until it reaches minSize, the headers are sent then with the
+// encoding decided. The Content-Length of a compressed body is unknown and left to the transport,
+// chunked on HTTP/1.1 and framed by DATA frames on HTTP/2.
+type gzipResponseWriter struct {
+	gin.ResponseWriter
+	minSize int
+
+	buf         []byte
+	gz          *gzip.Writer
+	passthrough bool
+}
+
+func (w *gzipResponseWriter) Write(data []byte) (int, error) {
+	switch {
+	case w.gz != nil:
+		return w.gz.Write(data)
+	case w.passthrough:
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.buf = append(w.buf, data...)
+	if len(w.buf) >= w.minSize {
+		if err := w.flushBuffer(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *gzipResponseWriter) WriteString(data string) (int, error) {
+	return w.Write([]byte(data))
+}
+
+// Written is true once the handler wrote a body, even if it is still buffered
+func (w *gzipResponseWriter) Written() bool {
+	return len(w.buf) > 0 || w.ResponseWriter.Written()
+}
+
+func (w *gzipResponseWriter) Flush() {
+	if w.gz == nil && !w.passthrough {
+		if err := w.flushBuffer(len(w.buf) >= w.minSize); err != nil {
+			return
+		}
+	}
+	if w.gz != nil {
+		if err := w.gz.Flush(); err != nil {
+			return
+		}
+	}
+	w.ResponseWriter.Flush()
+}
+
+// flushBuffer decides the encoding of the response and writes the buffered body with it
+func (w *gzipResponseWriter) flushBuffer(compress bool) error {
+	header := w.Header()
+	if !compress || w.Status() >= http.StatusBadRequest || header.Get("Content-Encoding") != "" {
+		w.passthrough = true
+	} else {
+		header.Set("Content-Encoding", encodingGzip)
+		header.Add("Vary", "Accept-Encoding")
+		header.Del("Content-Length")
+		w.gz = gzip.NewWriter(w.ResponseWriter)
+	}
+
+	buf := w.buf
+	w.buf = nil
+	if w.gz != nil {
+		_, err := w.gz.Write(buf)
+		return err
+	}
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+// finish sends the body still buffered, uncompressed as it is smaller than minSize, or ends the gzip stream
+func (w *gzipResponseWriter) finish() error {
+	if w.gz != nil {
+		return w.gz.Close()
+	}
+	if w.passthrough || len(w.buf) == 0 {
+		return nil
+	}
+	return w.flushBuffer(false)
+}
@@ -0,0 +1,126 @@
+package sbi
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	"github.com/free5gc/udr/pkg/factory"
+)
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func TestServer_CompressResponse(t *testing.T) {
+	s := newTestServer(t)
+	factory.UdrConfig.Configuration.Sbi.Compression = &factory.Compression{Enable: true, MinSize: 64}
+
+	large := map[string]string{"data": strings.Repeat("influenceData", 16)}
+	s.router.GET("/large", func(c *gin.Context) {
+		c.JSON(http.StatusOK, large)
+	})
+	s.router.GET("/small", func(c *gin.Context) {
+		c.JSON(http.StatusOK, map[string]string{"data": "pfd"})
+	})
+	s.router.GET("/error", func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, large)
+	})
+	s.router.GET("/encoded", func(c *gin.Context) {
+		c.Header("Content-Encoding", "gzip")
+		c.Data(http.StatusOK, "application/json", gzipBytes(t, []byte(large["data"])))
+	})
+
+	get := func(path, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		rsp := httptest.NewRecorder()
+		s.router.ServeHTTP(rsp, req)
+		return rsp
+	}
+
+	rsp := get("/large", "br, gzip;q=0.5")
+	require.Equal(t, http.StatusOK, rsp.Code)
+	require.Equal(t, "gzip", rsp.Header().Get("Content-Encoding"))
+	require.Equal(t, "Accept-Encoding", rsp.Header().Get("Vary"))
+	require.Empty(t, rsp.Header().Get("Content-Length"))
+	reader, err := gzip.NewReader(rsp.Body)
+	require.NoError(t, err)
+	body, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.Contains(t, string(body), large["data"])
+
+	testCases := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+	}{
+		{"Below Min Size", "/small", "gzip"},
+		{"Not Accepted", "/large", ""},
+		{"Zero Quality", "/large", "gzip;q=0"},
+		{"Error Response", "/error", "gzip"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rsp := get(tc.path, tc.acceptEncoding)
+			require.Empty(t, rsp.Header().Get("Content-Encoding"))
+			require.Contains(t, rsp.Body.String(), `"data":`)
+		})
+	}
+
+	rsp = get("/encoded", "gzip")
+	require.Equal(t, "gzip", rsp.Header().Get("Content-Encoding"))
+	reader, err = gzip.NewReader(rsp.Body)
+	require.NoError(t, err)
+	body, err = io.ReadAll(reader)
+	require.NoError(t, err)
+	require.Equal(t, large["data"], string(body))
+}
+
+func TestServer_DecompressRequest(t *testing.T) {
+	s := newTestServer(t)
+	s.router.PUT("/echo", func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		require.NoError(t, err)
+		c.Data(http.StatusOK, "application/json", body)
+	})
+
+	put := func(body []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/echo", bytes.NewReader(body))
+		req.Header.Set("Content-Encoding", "gzip")
+		rsp := httptest.NewRecorder()
+		s.router.ServeHTTP(rsp, req)
+		return rsp
+	}
+
+	compressed := gzipBytes(t, []byte(`{"supi":"imsi-208930000000001"}`))
+	rsp := put(compressed)
+	require.Equal(t, http.StatusOK, rsp.Code)
+	require.Equal(t, compressed, rsp.Body.Bytes(), "compression disabled, the body is left as it is")
+
+	factory.UdrConfig.Configuration.Sbi.Compression = &factory.Compression{Enable: true}
+	rsp = put(compressed)
+	require.Equal(t, http.StatusOK, rsp.Code)
+	require.Equal(t, `{"supi":"imsi-208930000000001"}`, rsp.Body.String())
+
+	rsp = put([]byte(`{"supi":"imsi-208930000000001"}`))
+	require.Equal(t, http.StatusBadRequest, rsp.Code)
+
+	factory.UdrConfig.Configuration.Sbi.MaxRequestBodyBytes = 16
+	rsp = put(compressed)
+	require.Equal(t, http.StatusRequestEntityTooLarge, rsp.Code, "the limit applies to the decompressed body")
+}
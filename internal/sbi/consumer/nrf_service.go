@@ -2,16 +2,20 @@ package consumer
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 	"sync"
-	"time"
 
+	"github.com/free5gc/openapi"
 	"github.com/free5gc/openapi/models"
 	"github.com/free5gc/openapi/nrf/NFDiscovery"
 	"github.com/free5gc/openapi/nrf/NFManagement"
 	udr_context "github.com/free5gc/udr/internal/context"
 	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/udr/pkg/factory"
 	sbi_metrics "github.com/free5gc/util/metrics/sbi"
 )
 
@@ -19,6 +23,10 @@ type NrfService struct {
 	nfMngmntMu sync.RWMutex
 
 	nfMngmntClients map[string]*NFManagement.APIClient
+
+	// nfTypes caches the NF type of the consumer NF instances by their instance ID,
+	// the type of an instance does not change
+	nfTypes sync.Map
 }
 
 func (ns *NrfService) getNFManagementClient(uri string) *NFManagement.APIClient {
@@ -35,6 +43,7 @@ func (ns *NrfService) getNFManagementClient(uri string) *NFManagement.APIClient
 	configuration := NFManagement.NewConfiguration()
 	configuration.SetBasePath(uri)
 	configuration.SetMetrics(sbi_metrics.SbiMetricHook)
+	configuration.SetHTTPClient(util.SbiClient())
 	client = NFManagement.NewAPIClient(configuration)
 
 	ns.nfMngmntMu.RUnlock()
@@ -45,13 +54,12 @@ func (ns *NrfService) getNFManagementClient(uri string) *NFManagement.APIClient
 }
 
 func (ns *NrfService) buildNFProfile(context *udr_context.UDRContext) (models.NrfNfManagementNfProfile, error) {
-	// config := factory.UdrConfig
+	nfProfile := factory.UdrConfig.GetNfProfile()
 
 	profile := models.NrfNfManagementNfProfile{
-		NfInstanceId:  context.NfId,
-		NfType:        models.NrfNfManagementNfType_UDR,
-		NfStatus:      models.NrfNfManagementNfStatus_REGISTERED,
-		Ipv4Addresses: []string{context.RegisterIPv4},
+		NfInstanceId: context.NfId,
+		NfType:       models.NrfNfManagementNfType_UDR,
+		NfStatus:     models.NrfNfManagementNfStatus_REGISTERED,
 		UdrInfo: &models.UdrInfo{
 			SupportedDataSets: []models.DataSetId{
 				// models.DataSetId_APPLICATION,
@@ -62,6 +70,22 @@ func (ns *NrfService) buildNFProfile(context *udr_context.UDRContext) (models.Nr
 		},
 	}
 
+	for _, plmnId := range nfProfile.PlmnList {
+		profile.PlmnList = append(profile.PlmnList, models.PlmnId{Mcc: plmnId.Mcc, Mnc: plmnId.Mnc})
+	}
+	profile.Priority = int32(nfProfile.Priority)
+	profile.Capacity = int32(nfProfile.Capacity)
+	profile.Locality = nfProfile.Locality
+
+	for _, ipEndPoint := range context.IpEndPoints {
+		if ipEndPoint.Ipv4Address != "" {
+			profile.Ipv4Addresses = append(profile.Ipv4Addresses, ipEndPoint.Ipv4Address)
+		}
+		if ipEndPoint.Ipv6Address != "" {
+			profile.Ipv6Addresses = append(profile.Ipv6Addresses, ipEndPoint.Ipv6Address)
+		}
+	}
+
 	var services []models.NrfNfManagementNfService
 	for _, nfService := range context.NfService {
 		services = append(services, nfService)
@@ -87,72 +111,154 @@ func (ns *NrfService) SendRegisterNFInstance(ctx context.Context, nrfUri string)
 	configuration.SetMetrics(sbi_metrics.SbiMetricHook)
 	client := ns.getNFManagementClient(nrfUri)
 
-	finish := false
-
-	for !finish {
-		select {
-		case <-ctx.Done():
-			return "", "", fmt.Errorf("context done")
-		default:
-			registerReq := &NFManagement.RegisterNFInstanceRequest{
-				NfInstanceID:             &profile.NfInstanceId,
-				NrfNfManagementNfProfile: &profile,
-			}
-			rsp, registerErr := client.NFInstanceIDDocumentApi.RegisterNFInstance(ctx, registerReq)
-			if registerErr != nil || rsp == nil {
-				// TODO : add log
-				logger.ConsumerLog.Errorf("UDR register to NRF Error[%s]", registerErr.Error())
-				time.Sleep(2 * time.Second)
-				continue
-			}
-
-			resourceUri := rsp.Location
-			resourceNrfUri, _, _ = strings.Cut(resourceUri, "/nnrf-nfm/")
-			retrieveNfInstanceId = resourceUri[strings.LastIndex(resourceUri, "/")+1:]
-
-			oauth2 := false
-
-			if rsp.NrfNfManagementNfProfile.CustomInfo != nil {
-				v, ok := rsp.NrfNfManagementNfProfile.CustomInfo["oauth2"].(bool)
-				if ok {
-					oauth2 = v
-					logger.MainLog.Infoln("OAuth2 setting receive from NRF:", oauth2)
-				}
-			}
-			udr_context.GetSelf().OAuth2Required = oauth2
-			if oauth2 && udr_context.GetSelf().NrfCertPem == "" {
-				logger.CfgLog.Error("OAuth2 enable but no nrfCertPem provided in config.")
-			}
-			finish = true
+	registerReq := &NFManagement.RegisterNFInstanceRequest{
+		NfInstanceID:             &profile.NfInstanceId,
+		NrfNfManagementNfProfile: &profile,
+	}
+	rsp, err := client.NFInstanceIDDocumentApi.RegisterNFInstance(ctx, registerReq)
+	if err != nil {
+		return "", "", registerNFInstanceError(err)
+	}
+	if rsp == nil {
+		return "", "", fmt.Errorf("NRF answered no NF profile")
+	}
+
+	resourceUri := rsp.Location
+	resourceNrfUri, _, _ = strings.Cut(resourceUri, "/nnrf-nfm/")
+	retrieveNfInstanceId = resourceUri[strings.LastIndex(resourceUri, "/")+1:]
+
+	oauth2 := false
+
+	if rsp.NrfNfManagementNfProfile.CustomInfo != nil {
+		v, ok := rsp.NrfNfManagementNfProfile.CustomInfo["oauth2"].(bool)
+		if ok {
+			oauth2 = v
+			logger.MainLog.Infoln("OAuth2 setting receive from NRF:", oauth2)
 		}
 	}
+	if oauth2 && udr_context.GetSelf().NrfCertPem == "" {
+		logger.CfgLog.Error("OAuth2 enable but no nrfCertPem provided in config.")
+	}
+	udr_context.GetSelf().SetNrfRegistration(oauth2, int(rsp.NrfNfManagementNfProfile.HeartBeatTimer))
+	logger.ConsumerLog.Infof("NRF registered NF instance %s at %s, status %s, heartBeatTimer %ds",
+		retrieveNfInstanceId, resourceUri, rsp.NrfNfManagementNfProfile.NfStatus,
+		rsp.NrfNfManagementNfProfile.HeartBeatTimer)
 	return resourceNrfUri, retrieveNfInstanceId, nil
 }
 
-func (ns *NrfService) SendDeregisterNFInstance() (err error) {
-	logger.ConsumerLog.Infof("Send Deregister NFInstance")
-
-	ctx, pd, err := udr_context.GetSelf().GetTokenCtx(models.ServiceName_NNRF_NFM, models.NrfNfManagementNfType_NRF)
-	if err != nil {
-		logger.ConsumerLog.Errorf("Get token context failed: problem details: %+v", pd)
+// registerNFInstanceError adds to err the problem or the redirection the NRF answered, err stays unwrappable
+func registerNFInstanceError(err error) error {
+	var apiErr openapi.GenericOpenAPIError
+	if !errors.As(err, &apiErr) {
 		return err
 	}
+	errModel, ok := apiErr.Model().(NFManagement.RegisterNFInstanceError)
+	switch {
+	case !ok:
+		return fmt.Errorf("NRF answered %w", err)
+	case errModel.Location != "":
+		return fmt.Errorf("NRF answered %w: redirect to %s", err, errModel.Location)
+	default:
+		pd := errModel.ProblemDetails
+		return fmt.Errorf("NRF answered %w: cause %s, %s", err, pd.Cause, pd.Detail)
+	}
+}
+
+func (ns *NrfService) SendDeregisterNFInstance(ctx context.Context) (err error) {
+	logger.ConsumerLog.Infof("Send Deregister NFInstance")
 
 	udrSelf := udr_context.GetSelf()
-	// Set client and set url
-	configuration := NFManagement.NewConfiguration()
-	configuration.SetBasePath(udrSelf.NrfUri)
-	configuration.SetMetrics(sbi_metrics.SbiMetricHook)
 	client := ns.getNFManagementClient(udrSelf.NrfUri)
 
 	deregisterReq := &NFManagement.DeregisterNFInstanceRequest{
 		NfInstanceID: &udrSelf.NfId,
 	}
-	_, deregisterErr := client.NFInstanceIDDocumentApi.DeregisterNFInstance(ctx, deregisterReq)
-	if deregisterErr != nil {
+	return sendWithAccessToken(ctx, models.ServiceName_NNRF_NFM, func(tokenCtx context.Context) error {
+		_, deregisterErr := client.NFInstanceIDDocumentApi.DeregisterNFInstance(tokenCtx, deregisterReq)
 		return deregisterErr
+	})
+}
+
+// sendWithAccessToken sends a request to the NRF with the access token of the service, and once more
+// with a new token when the NRF rejects the cached one with 401
+func sendWithAccessToken(ctx context.Context, serviceName models.ServiceName,
+	send func(tokenCtx context.Context) error,
+) error {
+	udrSelf := udr_context.GetSelf()
+	for attempt := 1; ; attempt++ {
+		tokenCtx, pd, err := udrSelf.GetTokenCtx(serviceName, models.NrfNfManagementNfType_NRF)
+		if err != nil {
+			logger.ConsumerLog.Errorf("Get token context failed: problem details: %+v", pd)
+			return err
+		}
+		tokenCtx, cancel := withTokenCtx(ctx, tokenCtx)
+		err = send(tokenCtx)
+		cancel()
+
+		var apiErr openapi.GenericOpenAPIError
+		if attempt > 1 || !udrSelf.OAuth2Required() || !errors.As(err, &apiErr) ||
+			apiErr.ErrorStatus != http.StatusUnauthorized {
+			return err
+		}
+		logger.ConsumerLog.Warnf("NRF rejected the access token of %s, retry with a new one", serviceName)
+		udrSelf.InvalidateToken(serviceName, models.NrfNfManagementNfType_NRF)
+	}
+}
+
+// withTokenCtx bounds the token context, which carries the OAuth2 token source, by the deadline of ctx
+func withTokenCtx(ctx, tokenCtx context.Context) (context.Context, context.CancelFunc) {
+	if deadline, ok := ctx.Deadline(); ok {
+		return context.WithDeadline(tokenCtx, deadline)
+	}
+	return tokenCtx, func() {}
+}
+
+// SendHeartbeat refreshes the NF profile in the NRF, a 404 GenericOpenAPIError means the profile is gone
+func (ns *NrfService) SendHeartbeat(ctx context.Context) error {
+	udrSelf := udr_context.GetSelf()
+	client := ns.getNFManagementClient(udrSelf.NrfUri)
+
+	updateReq := &NFManagement.UpdateNFInstanceRequest{
+		NfInstanceID: &udrSelf.NfId,
+		PatchItem: []models.PatchItem{
+			{
+				Op:    models.PatchOperation_REPLACE,
+				Path:  "/nfStatus",
+				Value: models.NrfNfManagementNfStatus_REGISTERED,
+			},
+		},
+	}
+	return sendWithAccessToken(ctx, models.ServiceName_NNRF_NFM, func(tokenCtx context.Context) error {
+		_, err := client.NFInstanceIDDocumentApi.UpdateNFInstance(tokenCtx, updateReq)
+		return err
+	})
+}
+
+// GetNfType returns the NF type of the NF instance, as registered in its NF profile in the NRF
+func (ns *NrfService) GetNfType(ctx context.Context, nfInstanceId string) (models.NrfNfManagementNfType, error) {
+	if nfType, ok := ns.nfTypes.Load(nfInstanceId); ok {
+		return nfType.(models.NrfNfManagementNfType), nil
+	}
+
+	client := ns.getNFManagementClient(udr_context.GetSelf().NrfUri)
+	getReq := &NFManagement.GetNFInstanceRequest{
+		NfInstanceID: &nfInstanceId,
 	}
-	return nil
+	var rsp *NFManagement.GetNFInstanceResponse
+	err := sendWithAccessToken(ctx, models.ServiceName_NNRF_NFM, func(tokenCtx context.Context) error {
+		var getErr error
+		rsp, getErr = client.NFInstanceIDDocumentApi.GetNFInstance(tokenCtx, getReq)
+		return getErr
+	})
+	if err != nil {
+		return "", err
+	}
+	nfType := rsp.NrfNfManagementNfProfile.NfType
+	if nfType == "" {
+		return "", fmt.Errorf("NF profile of %s has no NF type", nfInstanceId)
+	}
+	ns.nfTypes.Store(nfInstanceId, nfType)
+	return nfType, nil
 }
 
 func (ns *NrfService) SendSearchNFInstances(nrfUri string,
@@ -162,14 +268,14 @@ func (ns *NrfService) SendSearchNFInstances(nrfUri string,
 	configuration := NFDiscovery.NewConfiguration()
 	configuration.SetBasePath(nrfUri)
 	configuration.SetMetrics(sbi_metrics.SbiMetricHook)
+	configuration.SetHTTPClient(util.SbiClient())
 	client := NFDiscovery.NewAPIClient(configuration)
 
-	ctx, _, err := udr_context.GetSelf().GetTokenCtx(models.ServiceName_NNRF_DISC, models.NrfNfManagementNfType_NRF)
-	if err != nil {
-		return nil, err
-	}
-
-	result, err := client.NFInstancesStoreApi.SearchNFInstances(ctx, &param)
-
+	var result *NFDiscovery.SearchNFInstancesResponse
+	err := sendWithAccessToken(context.Background(), models.ServiceName_NNRF_DISC, func(tokenCtx context.Context) error {
+		var searchErr error
+		result, searchErr = client.NFInstancesStoreApi.SearchNFInstances(tokenCtx, &param)
+		return searchErr
+	})
 	return result, err
 }
@@ -0,0 +1,60 @@
+package sbi
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/util/metrics/sbi"
+)
+
+const (
+	MediaTypeJSON       = "application/json"
+	MediaTypeJSONPatch  = "application/json-patch+json"
+	MediaTypeMergePatch = "application/merge-patch+json"
+)
+
+// supportedMediaTypes are the Content-Type accepted for the request body of each method of the data repository
+var supportedMediaTypes = map[string][]string{
+	http.MethodPut:   {MediaTypeJSON},
+	http.MethodPost:  {MediaTypeJSON},
+	http.MethodPatch: {MediaTypeJSONPatch, MediaTypeMergePatch},
+}
+
+// checkContentType answers 415 to a request whose body is not of a media type of its method,
+// before a handler tries to decode it
+func (s *Server) checkContentType(c *gin.Context) {
+	mediaTypes, ok := supportedMediaTypes[c.Request.Method]
+	if !ok || c.Request.Body == nil || c.Request.Body == http.NoBody || c.Request.ContentLength == 0 {
+		return
+	}
+	if contentType := c.ContentType(); !isSupportedMediaType(contentType, mediaTypes) {
+		rejectMediaType(c, contentType, mediaTypes)
+	}
+}
+
+func isSupportedMediaType(contentType string, mediaTypes []string) bool {
+	for _, mediaType := range mediaTypes {
+		if strings.EqualFold(contentType, mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+// rejectMediaType answers 415 with the supported media types, also given in Accept-Patch for a PATCH (RFC 5789)
+func rejectMediaType(c *gin.Context, contentType string, mediaTypes []string) {
+	supported := strings.Join(mediaTypes, ", ")
+	if c.Request.Method == http.MethodPatch {
+		c.Header("Accept-Patch", supported)
+	}
+	pd := util.ProblemDetailsUnsupportedMediaType(fmt.Sprintf("Content-Type %q is not supported on %s %s, supported: %s",
+		contentType, c.Request.Method, c.FullPath(), supported))
+	logger.SBILog.Warnf("Reject %s %s: %s", c.Request.Method, c.Request.URL.Path, pd.Detail)
+	c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+	c.AbortWithStatusJSON(int(pd.Status), pd)
+}
@@ -0,0 +1,65 @@
+package sbi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/free5gc/udr/pkg/factory"
+)
+
+func TestServer_CheckContentType(t *testing.T) {
+	s := newTestServer(t)
+
+	authSubsPath := "/subscription-data/imsi-208930000000001/20893/authentication-subscription"
+	amPolicyDataPath := "/policy-data/ues/imsi-208930000000001/am-data"
+
+	testCases := []struct {
+		name          string
+		method        string
+		path          string
+		contentType   string
+		body          string
+		expectedCode  int
+		expectedPatch string
+	}{
+		{"PUT Text", http.MethodPut, "/subscription-data/imsi-208930000000001/context-data/amf-3gpp-access",
+			"text/plain", `{}`, http.StatusUnsupportedMediaType, ""},
+		{"POST JSON Patch", http.MethodPost, UdrBulkProvisioningPath,
+			"application/json-patch+json", `[]`, http.StatusUnsupportedMediaType, ""},
+		{"PATCH JSON", http.MethodPatch, authSubsPath,
+			"application/json", `[]`, http.StatusUnsupportedMediaType,
+			"application/json-patch+json, application/merge-patch+json"},
+		{"PATCH Without Content-Type", http.MethodPatch, amPolicyDataPath,
+			"", `[]`, http.StatusUnsupportedMediaType,
+			"application/json-patch+json, application/merge-patch+json"},
+		{"Merge Patch Not An Object", http.MethodPatch, authSubsPath,
+			"application/merge-patch+json", `["sequenceNumber"]`, http.StatusBadRequest, ""},
+		{"Merge Patch Null", http.MethodPatch, amPolicyDataPath,
+			"application/merge-patch+json", `null`, http.StatusBadRequest, ""},
+		{"Malformed JSON Patch", http.MethodPatch, amPolicyDataPath,
+			"application/json-patch+json; charset=utf-8", `{`, http.StatusBadRequest, ""},
+		{"Merge Patch On JSON Patch Only", http.MethodPatch,
+			"/policy-data/ues/imsi-208930000000001/operator-specific-data",
+			"application/merge-patch+json", `{}`, http.StatusUnsupportedMediaType, "application/json-patch+json"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, factory.UdrDrResUriPrefix+tc.path, strings.NewReader(tc.body))
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			rsp := httptest.NewRecorder()
+			s.router.ServeHTTP(rsp, req)
+			require.Equal(t, tc.expectedCode, rsp.Code)
+			require.Equal(t, tc.expectedPatch, rsp.Header().Get("Accept-Patch"))
+			if tc.expectedCode == http.StatusUnsupportedMediaType {
+				require.Contains(t, rsp.Body.String(), "UNSUPPORTED_MEDIA_TYPE")
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package sbi
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/free5gc/udr/pkg/factory"
+)
+
+// corsExposedHeaders are the response headers the pages may read, beyond the CORS-safelisted ones
+const corsExposedHeaders = "ETag, Location, Retry-After"
+
+// newCorsHandler answers the CORS headers of the requests from the allowed origins. A preflight is answered
+// 204 right away, or 403 for the other origins, so that it reaches neither the authorization check nor the
+// handlers. The other requests go on with their CORS headers, whatever their origin.
+func newCorsHandler(cors *factory.Cors) gin.HandlerFunc {
+	allowMethods := strings.Join(cors.AllowedMethods, ", ")
+	allowHeaders := strings.Join(cors.AllowedHeaders, ", ")
+	anyOrigin := slices.Contains(cors.AllowedOrigins, "*")
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			return
+		}
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+
+		c.Writer.Header().Add("Vary", "Origin")
+		if !anyOrigin && !slices.ContainsFunc(cors.AllowedOrigins, func(allowed string) bool {
+			return strings.EqualFold(allowed, origin)
+		}) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+			}
+			return
+		}
+
+		header := c.Writer.Header()
+		if anyOrigin {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+		if !preflight {
+			header.Set("Access-Control-Expose-Headers", corsExposedHeaders)
+			return
+		}
+
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+		header.Set("Access-Control-Allow-Methods", allowMethods)
+		header.Set("Access-Control-Allow-Headers", allowHeaders)
+		if cors.MaxAge > 0 {
+			header.Set("Access-Control-Max-Age", strconv.Itoa(cors.MaxAge))
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,81 @@
+package sbi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/free5gc/udr/pkg/factory"
+)
+
+func TestServer_Cors(t *testing.T) {
+	const (
+		webUi = "https://webui.example.org"
+		path  = factory.UdrDrResUriPrefix + "/subscription-data/supis?page-size=ten"
+	)
+	s := newStrictScopesTestServer(t)
+	factory.UdrConfig.Configuration.Sbi.Cors = &factory.Cors{
+		Enable:         true,
+		AllowedOrigins: []string{webUi},
+		MaxAge:         600,
+	}
+	s.router = newRouter(s)
+
+	serve := func(method, origin, authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if method == http.MethodOptions {
+			req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+			req.Header.Set("Access-Control-Request-Headers", "authorization")
+		}
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rsp := httptest.NewRecorder()
+		s.router.ServeHTTP(rsp, req)
+		return rsp
+	}
+
+	// The preflight carries no access token, it is answered ahead of the authorization check
+	rsp := serve(http.MethodOptions, webUi, "")
+	require.Equal(t, http.StatusNoContent, rsp.Code)
+	require.Equal(t, webUi, rsp.Header().Get("Access-Control-Allow-Origin"))
+	require.Equal(t, "GET, PUT, POST, PATCH, DELETE", rsp.Header().Get("Access-Control-Allow-Methods"))
+	require.Contains(t, rsp.Header().Get("Access-Control-Allow-Headers"), "Authorization")
+	require.Equal(t, "600", rsp.Header().Get("Access-Control-Max-Age"))
+	require.Empty(t, rsp.Body.String())
+
+	rsp = serve(http.MethodOptions, "https://evil.example.org", "")
+	require.Equal(t, http.StatusForbidden, rsp.Code)
+	require.Empty(t, rsp.Header().Get("Access-Control-Allow-Origin"))
+
+	// The actual requests are still authorized, and answered with their CORS headers
+	rsp = serve(http.MethodGet, webUi, mintToken(t, "nudr-dr nudr-dr:policy-data"))
+	require.Equal(t, http.StatusForbidden, rsp.Code)
+	require.Equal(t, webUi, rsp.Header().Get("Access-Control-Allow-Origin"))
+	rsp = serve(http.MethodGet, webUi, mintToken(t, "nudr-dr nudr-dr:subscription-data"))
+	require.Equal(t, http.StatusBadRequest, rsp.Code)
+	require.Equal(t, webUi, rsp.Header().Get("Access-Control-Allow-Origin"))
+	require.Contains(t, rsp.Header().Get("Access-Control-Expose-Headers"), "ETag")
+	require.Contains(t, rsp.Header().Values("Vary"), "Origin")
+
+	rsp = serve(http.MethodGet, "https://evil.example.org", mintToken(t, "nudr-dr nudr-dr:subscription-data"))
+	require.Equal(t, http.StatusBadRequest, rsp.Code)
+	require.Empty(t, rsp.Header().Get("Access-Control-Allow-Origin"))
+
+	t.Run("Disabled", func(t *testing.T) {
+		factory.UdrConfig.Configuration.Sbi.Cors.Enable = false
+		s.router = newRouter(s)
+
+		rsp := serve(http.MethodOptions, webUi, "")
+		require.NotEqual(t, http.StatusNoContent, rsp.Code)
+		require.Empty(t, rsp.Header().Get("Access-Control-Allow-Origin"))
+		rsp = serve(http.MethodGet, webUi, mintToken(t, "nudr-dr nudr-dr:subscription-data"))
+		require.Equal(t, http.StatusBadRequest, rsp.Code)
+		require.Empty(t, rsp.Header().Get("Access-Control-Allow-Origin"))
+	})
+}
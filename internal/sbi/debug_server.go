@@ -0,0 +1,107 @@
+package sbi
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"sync"
+	"sync/atomic"
+
+	"github.com/free5gc/udr/internal/logger"
+)
+
+const (
+	UdrDebugPprofPath = "/debug/pprof/"
+	UdrDebugVarsPath  = "/debug/vars"
+	udrDebugVarsName  = "udr"
+)
+
+var (
+	// expvar.Publish panics on a name published twice, the variable reads the counters of the latest Server
+	publishDebugVarsOnce sync.Once
+	debugVarsServer      atomic.Pointer[Server]
+)
+
+// newDebugServer serves pprof and expvar on their own mux, so that they can never be reached on an SBI listener
+func newDebugServer(s *Server, bindAddr string) *http.Server {
+	debugVarsServer.Store(s)
+	publishDebugVarsOnce.Do(func() {
+		expvar.Publish(udrDebugVarsName, expvar.Func(func() any {
+			if server := debugVarsServer.Load(); server != nil {
+				return server.debugVars()
+			}
+			return nil
+		}))
+	})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(UdrDebugPprofPath, pprof.Index)
+	mux.HandleFunc(UdrDebugPprofPath+"cmdline", pprof.Cmdline)
+	mux.HandleFunc(UdrDebugPprofPath+"profile", pprof.Profile)
+	mux.HandleFunc(UdrDebugPprofPath+"symbol", pprof.Symbol)
+	mux.HandleFunc(UdrDebugPprofPath+"trace", pprof.Trace)
+	mux.Handle(UdrDebugVarsPath, expvar.Handler())
+
+	// No write timeout, the CPU profile and the trace stream for as long as the client asks
+	readHeader, _, _, idle := s.Config().GetSbiTimeouts()
+	return &http.Server{
+		Addr:              bindAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: readHeader,
+		IdleTimeout:       idle,
+	}
+}
+
+func (s *Server) debugVars() map[string]any {
+	vars := map[string]any{
+		"inflightRequests":    s.ActiveRequests(),
+		"activeSubscriptions": s.Context().CountSubscriptionDataSubscriptions(),
+	}
+	if s.Processor() != nil {
+		vars["mongoSessionsInProgress"] = s.Processor().SessionsInProgress()
+	}
+	return vars
+}
+
+// listenDebugServer opens the listener of the debug server, nil when debug.pprof is off
+func (s *Server) listenDebugServer() (net.Listener, error) {
+	if s.debugServer == nil {
+		return nil, nil
+	}
+
+	ln, err := net.Listen("tcp", s.debugServer.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("debug server failed to listen on %s: %w", s.debugServer.Addr, err)
+	}
+	logger.SBILog.Warnf("Debug server (pprof, expvar) listen on %s, do not expose it outside the node",
+		s.debugServer.Addr)
+	return ln, nil
+}
+
+func (s *Server) serveDebugServer(wg *sync.WaitGroup, ln net.Listener) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		if err := s.debugServer.Serve(ln); err != http.ErrServerClosed {
+			logger.SBILog.Errorf("Debug server failed on %s: %+v", s.debugServer.Addr, err)
+			return
+		}
+		logger.SBILog.Infof("Debug server (listen on %s) stopped", s.debugServer.Addr)
+	}()
+}
+
+func (s *Server) shutdownDebugServer(ctx context.Context) {
+	if s.debugServer == nil {
+		return
+	}
+	if err := s.debugServer.Shutdown(ctx); err != nil {
+		logger.SBILog.Warnf("Debug server shutdown failed, closing it: %+v", err)
+		if err = s.debugServer.Close(); err != nil {
+			logger.SBILog.Errorf("Debug server close failed: %+v", err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package sbi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/free5gc/udr/pkg/factory"
+)
+
+func TestServer_DebugEndpoints(t *testing.T) {
+	s := newTestServer(t)
+	require.Nil(t, s.debugServer)
+
+	factory.UdrConfig.Configuration.Debug = &factory.Debug{Pprof: true, BindAddr: "127.0.0.1:0"}
+	s, err := NewServer(s.UDR, "")
+	require.NoError(t, err)
+	s = setServerReady(s)
+	require.NotNil(t, s.debugServer)
+
+	serve := func(handler http.Handler, path string) *httptest.ResponseRecorder {
+		rsp := httptest.NewRecorder()
+		handler.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, path, nil))
+		return rsp
+	}
+
+	require.Equal(t, http.StatusOK, serve(s.debugServer.Handler, UdrDebugPprofPath+"goroutine?debug=1").Code)
+	require.Equal(t, http.StatusNotFound, serve(s.router, UdrDebugPprofPath).Code)
+	require.Equal(t, http.StatusNotFound, serve(s.router, UdrDebugVarsPath).Code)
+
+	rsp := serve(s.debugServer.Handler, UdrDebugVarsPath)
+	require.Equal(t, http.StatusOK, rsp.Code)
+	var vars struct {
+		Udr map[string]int `json:"udr"`
+	}
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &vars))
+	require.Equal(t, map[string]int{
+		"inflightRequests":        0,
+		"activeSubscriptions":     0,
+		"mongoSessionsInProgress": 0,
+	}, vars.Udr)
+}
+
+func TestConfig_DebugBindAddrConflictsWithSbi(t *testing.T) {
+	testCases := []struct {
+		name     string
+		bindAddr string
+		valid    bool
+	}{
+		{"Distinct Port", "127.0.0.1:8001", true},
+		{"Same Address", "127.0.0.1:8000", false},
+		{"Unspecified Host", "0.0.0.0:8000", false},
+		{"Missing Port", "127.0.0.1", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &factory.Config{
+				Info: &factory.Info{Version: "1.1.0"},
+				Configuration: &factory.Configuration{
+					Sbi: &factory.Sbi{
+						Scheme:      "http",
+						BindingIPv4: "127.0.0.1",
+						Port:        8000,
+					},
+					DbConnectorType: "mongodb",
+					Mongodb:         &factory.Mongodb{Name: "free5gc", Url: "mongodb://127.0.0.1:27017"},
+					NrfUri:          "http://127.0.0.10:8000",
+					Debug:           &factory.Debug{Pprof: true, BindAddr: tc.bindAddr},
+				},
+				Logger: &factory.Logger{Level: "info"},
+			}
+			valid, err := cfg.Validate()
+			require.Equal(t, tc.valid, valid, err)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package sbi
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/free5gc/udr/internal/sbi/processor"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/util/metrics/sbi"
+)
+
+// dryRunQueryParam asks a PUT or PATCH to be validated and its result computed without storing anything
+const dryRunQueryParam = "dry-run"
+
+var errDryRunMethod = errors.New("only applies to PUT and PATCH")
+
+// dryRunWrites answers the PUT and PATCH asking for a dry run with 200 and the document the write would
+// have stored, the datastore and the subscriptions are left untouched and the subscribers are not notified.
+// The failures of the handler are answered as they are. The other methods asking for it are rejected,
+// rather than applied.
+func (s *Server) dryRunWrites(c *gin.Context) {
+	value, ok := c.GetQuery(dryRunQueryParam)
+	if !ok {
+		return
+	}
+	dryRun, err := strconv.ParseBool(value)
+	if err == nil && dryRun && c.Request.Method != http.MethodPut && c.Request.Method != http.MethodPatch {
+		err = errDryRunMethod
+	}
+	if err != nil {
+		pd := util.ProblemDetailsMalformedReqSyntax(dryRunQueryParam + ": " + err.Error())
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.AbortWithStatusJSON(int(pd.Status), pd)
+		return
+	}
+	if !dryRun {
+		return
+	}
+
+	ctx, result := processor.WithDryRun(c.Request.Context())
+	c.Request = c.Request.WithContext(ctx)
+	writer := &bufferedResponseWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+	c.Writer = writer
+	// On a panic the buffered response is dropped, recoverPanic answers on the original writer
+	defer func() {
+		c.Writer = writer.ResponseWriter
+	}()
+
+	c.Next()
+
+	c.Writer = writer.ResponseWriter
+	if writer.status < http.StatusOK || writer.status >= http.StatusMultipleChoices {
+		c.Writer.WriteHeader(writer.status)
+		_, _ = c.Writer.Write(writer.body.Bytes())
+		return
+	}
+	// Nothing was stored, there is no version nor created resource to refer to
+	c.Header("ETag", "")
+	c.Header("Location", "")
+	if document, ok := result.Document(); ok {
+		c.JSON(http.StatusOK, document)
+		return
+	}
+	c.Data(http.StatusOK, c.Writer.Header().Get("Content-Type"), writer.body.Bytes())
+}
+
+// bufferedResponseWriter holds the response of the handler back, for dryRunWrites to answer in its place
+type bufferedResponseWriter struct {
+	gin.ResponseWriter
+	status  int
+	written bool
+	body    bytes.Buffer
+}
+
+func (w *bufferedResponseWriter) WriteHeader(code int) {
+	if !w.written {
+		w.status = code
+	}
+}
+
+func (w *bufferedResponseWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+func (w *bufferedResponseWriter) Write(data []byte) (int, error) {
+	w.written = true
+	return w.body.Write(data)
+}
+
+func (w *bufferedResponseWriter) WriteString(data string) (int, error) {
+	w.written = true
+	return w.body.WriteString(data)
+}
+
+func (w *bufferedResponseWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedResponseWriter) Size() int {
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *bufferedResponseWriter) Written() bool {
+	return w.written
+}
+
+func (w *bufferedResponseWriter) Flush() {}
@@ -0,0 +1,94 @@
+package sbi
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/pkg/factory"
+)
+
+type serverTimeouts struct {
+	readHeader time.Duration
+	read       time.Duration
+	write      time.Duration
+	idle       time.Duration
+}
+
+func newServerTimeouts(cfg *factory.Config) serverTimeouts {
+	readHeader, read, write, idle := cfg.GetSbiTimeouts()
+	return serverTimeouts{
+		readHeader: readHeader,
+		read:       read,
+		write:      write,
+		idle:       idle,
+	}
+}
+
+// newTLSConfig builds the TLS settings of an https listener from its tls section, the certificate
+// being provided later by the reloader
+func newTLSConfig(tlsCfg *factory.Tls) *tls.Config {
+	config := &tls.Config{
+		MinVersion:       tls.VersionTLS12,
+		VerifyConnection: logNegotiatedVersion,
+	}
+	if tlsCfg != nil {
+		config.MinVersion = tlsCfg.GetMinVersion()
+		config.CipherSuites = tlsCfg.GetCipherSuites()
+	}
+	return config
+}
+
+func logNegotiatedVersion(state tls.ConnectionState) error {
+	logger.SBILog.Debugf("TLS connection negotiated %s with %s, server name %q",
+		tls.VersionName(state.Version), tls.CipherSuiteName(state.CipherSuite), state.ServerName)
+	return nil
+}
+
+// newHttp2Server mirrors httpwrapper.NewHttp2Server, with the timeouts applied to the http.Server
+// and the idle one to HTTP/2 connections too, both cleartext and over TLS. tlsConfig is nil for
+// a cleartext server.
+func newHttp2Server(bindAddr string, preMasterSecretLogPath string, handler http.Handler,
+	timeouts serverTimeouts, tlsConfig *tls.Config,
+) (*http.Server, error) {
+	if handler == nil {
+		return nil, errors.New("server needs handler to handle request")
+	}
+
+	h2Server := &http2.Server{
+		IdleTimeout: timeouts.idle,
+	}
+	server := &http.Server{
+		Addr:              bindAddr,
+		Handler:           h2c.NewHandler(handler, h2Server),
+		ReadHeaderTimeout: timeouts.readHeader,
+		ReadTimeout:       timeouts.read,
+		WriteTimeout:      timeouts.write,
+		IdleTimeout:       timeouts.idle,
+		TLSConfig:         tlsConfig,
+	}
+
+	if preMasterSecretLogPath != "" {
+		preMasterSecretFile, err := os.OpenFile(preMasterSecretLogPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("create pre-master-secret log [%s] fail: %s", preMasterSecretLogPath, err)
+		}
+		if server.TLSConfig == nil {
+			server.TLSConfig = &tls.Config{}
+		}
+		server.TLSConfig.KeyLogWriter = preMasterSecretFile
+	}
+
+	if err := http2.ConfigureServer(server, h2Server); err != nil {
+		return nil, fmt.Errorf("configure HTTP/2 server fail: %w", err)
+	}
+
+	return server, nil
+}
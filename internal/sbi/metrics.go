@@ -0,0 +1,82 @@
+package sbi
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	UdrSbiMetricsPath      = "/metrics"
+	drMetricsSubsystem     = "udr_dr"
+	drMetricsOperationName = "operation"
+	drMetricsStatusName    = "status"
+)
+
+// handlerMetrics counts and times the data repository handlers, labeled by their route name
+type handlerMetrics struct {
+	registry *prometheus.Registry
+
+	requests *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+}
+
+func newHandlerMetrics(registry *prometheus.Registry, namespace string) *handlerMetrics {
+	m := &handlerMetrics{
+		registry: registry,
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: drMetricsSubsystem,
+			Name:      "requests_total",
+			Help:      "Number of requests handled by the data repository, per operation",
+		}, []string{drMetricsOperationName}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: drMetricsSubsystem,
+			Name:      "request_errors_total",
+			Help:      "Number of data repository requests answered with an error status, per operation and status",
+		}, []string{drMetricsOperationName, drMetricsStatusName}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: drMetricsSubsystem,
+			Name:      "request_duration_seconds",
+			Help:      "Latency of the data repository requests, per operation",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{drMetricsOperationName}),
+	}
+
+	registry.MustRegister(m.requests, m.errors, m.latency)
+	return m
+}
+
+// instrumentRoutes wraps the handler of every route with the metrics of its operation
+func (m *handlerMetrics) instrumentRoutes(routes []Route) []Route {
+	instrumented := make([]Route, 0, len(routes))
+	for _, route := range routes {
+		route.HandlerFunc = m.instrument(route.Name, route.HandlerFunc)
+		instrumented = append(instrumented, route)
+	}
+	return instrumented
+}
+
+func (m *handlerMetrics) instrument(operation string, handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		handler(c)
+
+		m.latency.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+		m.requests.WithLabelValues(operation).Inc()
+		if status := c.Writer.Status(); status >= http.StatusBadRequest {
+			m.errors.WithLabelValues(operation, strconv.Itoa(status)).Inc()
+		}
+	}
+}
+
+func (m *handlerMetrics) handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry}))
+}
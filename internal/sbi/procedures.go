@@ -0,0 +1,170 @@
+package sbi
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/udr/internal/sbi/processor"
+)
+
+// Procedures are the processor procedures the handlers of the Server call, for the tests to serve them by a fake
+type Procedures interface {
+	AmfContext3gppProcedure(c *gin.Context, collName string, ueId string, patch processor.PatchDocument)
+	AmfContextNon3gppProcedure(c *gin.Context, ueId string, collName string, patch processor.PatchDocument, filter bson.M)
+	ApplicationDataInfluenceDataGetProcedure(c *gin.Context, collName string, filter []bson.M)
+	ApplicationDataInfluenceDataInfluenceIdDeleteProcedure(c *gin.Context, collName string, influenceId string)
+	ApplicationDataInfluenceDataInfluenceIdPostProcedure(c *gin.Context)
+	ApplicationDataInfluenceDataInfluenceIdPutProcedure(c *gin.Context, collName string, influenceId string,
+		request *models.TrafficInfluData)
+	ApplicationDataInfluenceDataSubsToNotifyGetProcedure(c *gin.Context, dnn string, snssai *models.Snssai,
+		internalGroupId string, supi string)
+	ApplicationDataInfluenceDataSubsToNotifySubscriptionIdDeleteProcedure(c *gin.Context, subscriptionId string)
+	ApplicationDataInfluenceDataSubsToNotifySubscriptionIdGetProcedure(c *gin.Context, subscriptionID string)
+	ApplicationDataInfluenceDataSubsToNotifySubscriptionIdPostProcedure(c *gin.Context, subscriptionId string,
+		request *models.TrafficInfluSub)
+	ApplicationDataInfluenceDataSubsToNotifySubscriptionIdPutProcedure(c *gin.Context, subscriptionId string,
+		request *models.TrafficInfluSub)
+	BatchReadProcedure(c *gin.Context, request processor.BatchReadRequest)
+	BuildSnssaiMatchList(snssais []models.Snssai) (matchList []bson.M)
+	BulkProvisionSubscriptionData(c *gin.Context, records []processor.ProvisioningRecord, createOnly bool)
+	CreateAMFSubscriptionsProcedure(c *gin.Context, subsId string, ueId string,
+		AmfSubscriptionInfo []models.AmfSubscriptionInfo)
+	CreateAmfContext3gppProcedure(c *gin.Context, collName string, ueId string,
+		Amf3GppAccessRegistration models.Amf3GppAccessRegistration)
+	CreateAmfContextNon3gppProcedure(c *gin.Context, AmfNon3GppAccessRegistration models.AmfNon3GppAccessRegistration,
+		collName string, ueId string)
+	CreateAuthenticationSoRProcedure(c *gin.Context, collName string, ueId string, putData bson.M)
+	CreateAuthenticationStatusProcedure(c *gin.Context, collName string, ueId string, putData bson.M)
+	CreateAuthenticationUPUProcedure(c *gin.Context, collName string, ueId string, putData bson.M)
+	CreateEeGroupSubscriptionsProcedure(c *gin.Context, ueGroupId string, EeSubscription models.EeSubscription)
+	CreateEeSubscriptionsProcedure(c *gin.Context, ueId string, EeSubscription models.UdmEeEeSubscription)
+	CreateIndividualAuthenticationStatusProcedure(c *gin.Context, collName string, ueId string,
+		servingNetworkName string, putData bson.M)
+	CreateOperSpecDataProcedure(c *gin.Context, collName string, ueId string,
+		operSpecData map[string]models.OperatorSpecificDataContainer)
+	CreateSdmSubscriptionsProcedure(c *gin.Context, SdmSubscription models.SdmSubscription, collName string, ueId string)
+	CreateSmfContextNon3gppProcedure(c *gin.Context, SmfRegistration models.SmfRegistration, collName string,
+		ueId string, pduSessionId int32)
+	CreateSmsMngDataProcedure(c *gin.Context, collName string, ueId string, servingPlmnId string,
+		smsMngData models.SmsManagementSubscriptionData)
+	CreateSmsfContext3gppProcedure(c *gin.Context, collName string, ueId string, SmsfRegistration models.SmsfRegistration)
+	CreateSmsfContextNon3gppProcedure(c *gin.Context, SmsfRegistration models.SmsfRegistration, collName string,
+		ueId string)
+	DeleteApplicationDataIndividualPfdFromDBProcedure(c *gin.Context, appID string)
+	DeleteAuthenticationStatusProcedure(c *gin.Context, collName string, ueId string)
+	DeleteIndividualAuthenticationStatusProcedure(c *gin.Context, collName string, ueId string, servingNetworkName string)
+	DeleteOperSpecDataProcedure(c *gin.Context, collName string, ueId string)
+	DeleteSmfContextProcedure(c *gin.Context, collName string, ueId string, pduSessionId int32)
+	DeleteSmsfContext3gppProcedure(c *gin.Context, collName string, ueId string)
+	DeleteSmsfContextNon3gppProcedure(c *gin.Context, collName string, ueId string)
+	DeleteSubscriber(c *gin.Context, supi string)
+	Disconnect(ctx context.Context) error
+	GetAmfSubscriptionInfoProcedure(c *gin.Context, subsId string, ueId string)
+	GetApplicationDataIndividualPfdFromDBProcedure(c *gin.Context, appID string, supportedFeatures string)
+	GetApplicationDataPfdsFromDBProcedure(c *gin.Context, pfdsAppIDs []string, supportedFeatures string)
+	GetGroupIdentifiers(c *gin.Context, extGroupId string, intGroupId string, ueIdList bool, pageSize int, pageNumber int)
+	GetIdentityDataProcedure(c *gin.Context, collName string, ueId string)
+	GetIndividualSharedDataProcedure(c *gin.Context, collName string, sharedDataId string)
+	GetOdbDataProcedure(c *gin.Context, collName string, ueId string)
+	GetSharedDataProcedure(c *gin.Context, collName string, sharedDataIds []string)
+	GetSupiList(c *gin.Context, pageSize int, pageNumber int)
+	GetppDataProcedure(c *gin.Context, collName string, ueId string)
+	IncrementSqnProcedure(c *gin.Context, collName string, ueId string, increment uint64)
+	ModifyAmDataProcedure(c *gin.Context, collName string, ueId string, servingPlmnId string, mergePatch []byte)
+	ModifyAmfSubscriptionInfoProcedure(c *gin.Context, ueId string, subsId string, patchItem []models.PatchItem)
+	ModifyAuthenticationProcedure(c *gin.Context, collName string, ueId string, patch processor.PatchDocument)
+	ModifyPpDataProcedure(c *gin.Context, collName string, ueId string, patch processor.PatchDocument)
+	ModifySmDataProcedure(c *gin.Context, collName string, ueId string, servingPlmnId string,
+		patchItems []models.PatchItem)
+	ModifysdmsubscriptionProcedure(c *gin.Context, ueId string, subsId string, patch processor.PatchDocument)
+	ParseSnssaisFromQueryParam(snssaiStr string) ([]models.Snssai, error)
+	PatchOperSpecDataProcedure(c *gin.Context, collName string, ueId string, patch processor.PatchDocument)
+	Ping(ctx context.Context) error
+	PolicyDataBdtDataBdtReferenceIdDeleteProcedure(c *gin.Context, collName string, bdtReferenceId string)
+	PolicyDataBdtDataBdtReferenceIdGetProcedure(c *gin.Context, collName string, bdtReferenceId string)
+	PolicyDataBdtDataBdtReferenceIdPutProcedure(c *gin.Context, collName string, bdtReferenceId string,
+		bdtData models.BdtData)
+	PolicyDataBdtDataGetProcedure(c *gin.Context, collName string)
+	PolicyDataPlmnsPlmnIdUePolicySetGetProcedure(c *gin.Context, collName string, plmnId string)
+	PolicyDataSponsorConnectivityDataSponsorIdGetProcedure(c *gin.Context, collName string, sponsorId string)
+	PolicyDataSubsToNotifyPostProcedure(c *gin.Context, PolicyDataSubscription models.PolicyDataSubscription)
+	PolicyDataSubsToNotifySubsIdDeleteProcedure(c *gin.Context, subsId string)
+	PolicyDataSubsToNotifySubsIdPutProcedure(c *gin.Context, subsId string,
+		policyDataSubscription models.PolicyDataSubscription)
+	PolicyDataUesUeIdAmDataDeleteProcedure(c *gin.Context, collName string, ueId string)
+	PolicyDataUesUeIdAmDataGetProcedure(c *gin.Context, collName string, ueId string)
+	PolicyDataUesUeIdAmDataPatchProcedure(c *gin.Context, collName string, ueId string, patch processor.PatchDocument)
+	PolicyDataUesUeIdAmDataPutProcedure(c *gin.Context, collName string, ueId string, amPolicyData models.AmPolicyData)
+	PolicyDataUesUeIdOperatorSpecificDataGetProcedure(c *gin.Context, collName string, ueId string)
+	PolicyDataUesUeIdOperatorSpecificDataPatchProcedure(c *gin.Context, collName string, ueId string,
+		patchItem []models.PatchItem)
+	PolicyDataUesUeIdOperatorSpecificDataPutProcedure(c *gin.Context, collName string, ueId string,
+		OperatorSpecificDataContainer map[string]models.OperatorSpecificDataContainer)
+	PolicyDataUesUeIdSmDataGetProcedure(c *gin.Context, collName string, ueId string, snssai *models.Snssai, dnn string)
+	PolicyDataUesUeIdSmDataPatchProcedure(c *gin.Context, collName string, ueId string, patch processor.PatchDocument)
+	PolicyDataUesUeIdSmDataUsageMonIdDeleteProcedure(c *gin.Context, collName string, ueId string, usageMonId string)
+	PolicyDataUesUeIdSmDataUsageMonIdGetProcedure(c *gin.Context, collName string, usageMonId string, ueId string)
+	PolicyDataUesUeIdSmDataUsageMonIdPutProcedure(c *gin.Context, collName string, ueId string, usageMonId string,
+		usageMonData models.UsageMonData)
+	PolicyDataUesUeIdUePolicySetGetProcedure(c *gin.Context, collName string, ueId string)
+	PolicyDataUesUeIdUePolicySetPatchProcedure(c *gin.Context, collName string, ueId string,
+		UePolicySet models.UePolicySet)
+	PolicyDataUesUeIdUePolicySetPutProcedure(c *gin.Context, collName string, ueId string, UePolicySet models.UePolicySet)
+	PostSubscriptionDataSubscriptionsProcedure(c *gin.Context,
+		SubscriptionDataSubscriptions models.SubscriptionDataSubscriptions)
+	PutApplicationDataIndividualPfdToDBProcedure(c *gin.Context, appID string, pfdDataForApp *models.PfdDataForAppExt)
+	PutGroupIdentifiers(c *gin.Context, groupIdentifiers models.GroupIdentifiers)
+	QueryAmDataListProcedure(c *gin.Context, filter bson.M, pageSize int, pageNumber int)
+	QueryAmDataProcedure(c *gin.Context, collName string, ueId string, servingPlmnId string, supportedFeatures string,
+		fields []string)
+	QueryAmfContext3gppProcedure(c *gin.Context, collName string, ueId string)
+	QueryAmfContextNon3gppProcedure(c *gin.Context, collName string, ueId string)
+	QueryAuditProcedure(c *gin.Context, ueId string, from time.Time, to time.Time, pageSize int, pageNumber int)
+	QueryAuthSoRProcedure(c *gin.Context, collName string, ueId string)
+	QueryAuthSubsDataProcedure(c *gin.Context, collName string, ueId string)
+	QueryAuthUPUProcedure(c *gin.Context, collName string, ueId string)
+	QueryAuthenticationStatusProcedure(c *gin.Context, collName string, ueId string)
+	QueryEEDataProcedure(c *gin.Context, collName string, ueId string)
+	QueryEeGroupSubscriptionsProcedure(c *gin.Context, ueGroupId string)
+	QueryIndividualAuthenticationStatusProcedure(c *gin.Context, collName string, ueId string, servingNetworkName string)
+	QueryLcsMoDataProcedure(c *gin.Context, collName string, ueId string, fields []string)
+	QueryLcsPrivacyDataProcedure(c *gin.Context, collName string, ueId string, fields []string)
+	QueryOperSpecDataProcedure(c *gin.Context, collName string, ueId string, fields []string)
+	QueryProseDataProcedure(c *gin.Context, collName string, ueId string)
+	QueryProvisionedDataProcedure(c *gin.Context, ueId string, servingPlmnId string,
+		provisionedDataSets models.ProvisionedDataSets, dataSetNames []models.DataSetName)
+	QuerySmDataProcedure(c *gin.Context, collName string, ueId string, servingPlmnId string, singleNssai *models.Snssai,
+		dnn string, supportedFeatures string, fields []string)
+	QuerySmfRegListProcedure(c *gin.Context, collName string, ueId string)
+	QuerySmfRegistrationProcedure(c *gin.Context, collName string, ueId string, pduSessionId int32)
+	QuerySmfSelectDataProcedure(c *gin.Context, collName string, ueId string, servingPlmnId string, fields []string)
+	QuerySmsDataProcedure(c *gin.Context, collName string, ueId string, servingPlmnId string, supportedFeatures string)
+	QuerySmsMngDataProcedure(c *gin.Context, collName string, ueId string, servingPlmnId string, supportedFeatures string)
+	QuerySmsfContext3gppProcedure(c *gin.Context, collName string, ueId string)
+	QuerySmsfContextNon3gppProcedure(c *gin.Context, collName string, ueId string)
+	QueryTraceDataProcedure(c *gin.Context, collName string, ueId string, servingPlmnId string)
+	QueryUeUpdateConfirmationDataProcedure(c *gin.Context, ueId string)
+	QueryV2xDataProcedure(c *gin.Context, collName string, ueId string)
+	QueryeeSubscriptionProcedure(c *gin.Context, ueId string, subsId string)
+	QueryeesubscriptionsProcedure(c *gin.Context, ueId string)
+	QuerysdmsubscriptionsProcedure(c *gin.Context, ueId string)
+	RecordAudit(record processor.AuditRecord)
+	RemoveAmfSubscriptionsInfoProcedure(c *gin.Context, subsId string, ueId string)
+	RemoveEeGroupSubscriptionsProcedure(c *gin.Context, ueGroupId string, subsId string)
+	RemoveeeSubscriptionsProcedure(c *gin.Context, ueId string, subsId string)
+	RemovesdmSubscriptionsProcedure(c *gin.Context, ueId string, subsId string)
+	RemovesubscriptionDataSubscriptionsProcedure(c *gin.Context, subsId string)
+	SessionsInProgress() int
+	StopAudit(ctx context.Context)
+	SweepExpiredSubscriptions(now time.Time)
+	UpdateEeGroupSubscriptionsProcedure(c *gin.Context, ueGroupId string, subsId string,
+		EeSubscription models.EeSubscription)
+	UpdateEesubscriptionsProcedure(c *gin.Context, ueId string, subsId string, EeSubscription models.UdmEeEeSubscription)
+	UpdatesdmsubscriptionsProcedure(c *gin.Context, ueId string, subsId string, SdmSubscription models.SdmSubscription)
+}
+
+var _ Procedures = (*processor.Processor)(nil)
@@ -10,25 +10,137 @@
 package processor
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 
-	"github.com/free5gc/udr/internal/logger"
-	"github.com/free5gc/util/metrics/sbi"
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/udr/internal/database"
+	"github.com/free5gc/udr/internal/util"
 )
 
-func (p *Processor) QueryAmDataProcedure(c *gin.Context, collName string, ueId string, servingPlmnId string) {
-	logger.DataRepoLog.Infof("QueryAmDataProcedure: ueId: %s, servingPlmnId: %s", ueId, servingPlmnId)
+// invalidDocumentError tells that the modification would store a document not matching its OpenAPI model
+type invalidDocumentError struct {
+	err error
+}
+
+func (e *invalidDocumentError) Error() string {
+	return e.err.Error()
+}
+
+// QueryAmDataProcedure answers the AM data of the UE in the serving PLMN with its ETag, which consumers
+// polling it send back in If-None-Match to be answered 304 as long as it is unchanged. With fields only those
+// members are answered, with supportedFeatures the attributes of the features not negotiated are left out and
+// the negotiated ones answered: the ETag is then the one of the answered content, not of the whole data.
+func (p *Processor) QueryAmDataProcedure(c *gin.Context, collName string, ueId string, servingPlmnId string,
+	supportedFeatures string, fields []string,
+) {
+	dataRepoLog(c).Infof("QueryAmDataProcedure: ueId: %s, servingPlmnId: %s", ueId, servingPlmnId)
 
 	filter := bson.M{"ueId": ueId, "servingPlmnId": servingPlmnId}
-	data, pd := p.GetDataFromDB(collName, filter)
+	data, etag, pd := p.GetVersionedDataFromDB(c, collName, filter)
+	if pd != nil {
+		fail(c, problemOf(pd))
+		return
+	}
+
+	negotiated, negotiating := p.negotiateFeatures(c, supportedFeatures)
+	if negotiating {
+		stripFeatures(data, amDataFeatures, negotiated)
+	}
+	projected, err := projectDocument(data, fields)
+	if err != nil {
+		dataRepoLog(c).Errorf("QueryAmDataProcedure err: %+v", err)
+		fail(c, err)
+		return
+	}
+	if negotiating {
+		setSupportedFeatures(projected, negotiated)
+	}
+	if negotiating || len(fields) > 0 {
+		respondHashed(c, projected)
+		return
+	}
+	respondVersioned(c, etag, projected)
+}
+
+// QueryAmDataListProcedure answers the page of the AM data documents matching filter, ordered by ueId and
+// servingPlmnId, along with the ueId and servingPlmnId of each. pageNumber starts at 1, the Link header points
+// to the next page when there is one.
+func (p *Processor) QueryAmDataListProcedure(c *gin.Context, filter bson.M, pageSize, pageNumber int) {
+	// One more document than the page tells whether a next page exists
+	skip := int64(pageSize) * int64(pageNumber-1)
+	page, pd := p.GetPageFromDB(c, database.SUBSCDATA_AM_DATA_DB_COLLECTION_NAME, filter,
+		[]string{"ueId", "servingPlmnId"}, skip, int64(pageSize)+1)
 	if pd != nil {
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		dataRepoLog(c).Errorf("QueryAmDataListProcedure err: %s", pd.Detail)
+		fail(c, problemOf(pd))
 		return
 	}
 
-	c.JSON(http.StatusOK, data)
+	if len(page) > pageSize {
+		page = page[:pageSize]
+		c.Header("Link", nextPageLink(c.Request.URL, pageSize, pageNumber))
+	}
+	if page == nil {
+		page = []map[string]interface{}{}
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// ModifyAmDataProcedure applies the RFC 7386 merge patch to the stored AM data, the patched document is
+// written only when it is still a valid AccessAndMobilitySubscriptionData
+func (p *Processor) ModifyAmDataProcedure(c *gin.Context, collName string, ueId string, servingPlmnId string,
+	mergePatch []byte,
+) {
+	filter := bson.M{"ueId": ueId, "servingPlmnId": servingPlmnId}
+	validate := func(document map[string]interface{}) error {
+		return validateAmData(document, ueId, servingPlmnId)
+	}
+	_, newValue, etag, err := p.MergePatchVersionedDataToDB(c, collName, filter, mergePatch, ifMatchOf(c),
+		validate)
+	if err != nil {
+		dataRepoLog(c).Errorf("ModifyAmDataProcedure err: %+v", err)
+		if abortVersionedWrite(c, err) {
+			return
+		}
+		var invalidErr *invalidDocumentError
+		switch {
+		case errors.Is(err, database.ErrNoDocument):
+			err = &Error{Category: ErrorNotFound}
+		case errors.As(err, &invalidErr):
+			err = &Error{Category: ErrorUnprocessable, Err: invalidErr}
+		}
+		fail(c, err)
+		return
+	}
+
+	p.NotifySubscribers(c, ueId, c.Request.URL.Path, documentChanges(newValue))
+	setETag(c, etag)
+	c.Status(http.StatusNoContent)
+}
+
+// validateAmData strictly decodes the document, without its ueId and servingPlmnId, into the OpenAPI model
+func validateAmData(document map[string]interface{}, ueId string, servingPlmnId string) error {
+	if document["ueId"] != ueId || document["servingPlmnId"] != servingPlmnId {
+		return &invalidDocumentError{errors.New("ueId and servingPlmnId cannot be modified")}
+	}
+
+	amData := make(map[string]interface{}, len(document))
+	for key, value := range document {
+		if key != "ueId" && key != "servingPlmnId" {
+			amData[key] = value
+		}
+	}
+	decoder := json.NewDecoder(bytes.NewReader(util.MapToByte(amData)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&models.AccessAndMobilitySubscriptionData{}); err != nil {
+		return &invalidDocumentError{fmt.Errorf("invalid AccessAndMobilitySubscriptionData: %w", err)}
+	}
+	return nil
 }
@@ -0,0 +1,65 @@
+package processor
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateAmData(t *testing.T) {
+	const (
+		ueId          = "imsi-208930000000001"
+		servingPlmnId = "20893"
+	)
+
+	testCases := []struct {
+		name     string
+		document map[string]interface{}
+		valid    bool
+	}{
+		{
+			name: "Valid",
+			document: map[string]interface{}{
+				"ueId": ueId, "servingPlmnId": servingPlmnId,
+				"gpsis":            []interface{}{"msisdn-0900000000"},
+				"subscribedUeAmbr": map[string]interface{}{"uplink": "1 Gbps", "downlink": "2 Gbps"},
+			},
+			valid: true,
+		},
+		{
+			name: "Unknown Field",
+			document: map[string]interface{}{
+				"ueId": ueId, "servingPlmnId": servingPlmnId,
+				"gpsi": "msisdn-0900000000",
+			},
+		},
+		{
+			name: "Wrong Type",
+			document: map[string]interface{}{
+				"ueId": ueId, "servingPlmnId": servingPlmnId,
+				"gpsis": "msisdn-0900000000",
+			},
+		},
+		{
+			name:     "Modified Key",
+			document: map[string]interface{}{"ueId": "imsi-208930000000002", "servingPlmnId": servingPlmnId},
+		},
+		{
+			name:     "Removed Key",
+			document: map[string]interface{}{"ueId": ueId},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateAmData(tc.document, ueId, servingPlmnId)
+			if tc.valid {
+				require.NoError(t, err)
+				return
+			}
+			var invalidErr *invalidDocumentError
+			require.True(t, errors.As(err, &invalidErr), err)
+		})
+	}
+}
@@ -16,26 +16,25 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 
 	"github.com/free5gc/openapi/models"
-	"github.com/free5gc/udr/internal/logger"
 	"github.com/free5gc/udr/internal/util"
-	"github.com/free5gc/util/metrics/sbi"
-	"github.com/free5gc/util/mongoapi"
 )
 
 func (p *Processor) AmfContext3gppProcedure(
-	c *gin.Context, collName string, ueId string, patchItem []models.PatchItem,
+	c *gin.Context, collName string, ueId string, patch PatchDocument,
 ) {
-	var origValue, newValue map[string]interface{}
-	var err error
 	filter := bson.M{"ueId": ueId}
-	if origValue, newValue, err = p.PatchDataToDBAndNotify(collName, ueId, patchItem, filter); err != nil {
-		logger.DataRepoLog.Errorf("AmfContext3gppProcedure err: %+v", err)
-		problemDetails := util.ProblemDetailsModifyNotAllowed("")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
-		c.JSON(int(problemDetails.Status), problemDetails)
+	origValue, newValue, etag, err := p.patchVersionedDataToDB(c, collName, filter, patch, ifMatchOf(c))
+	if err != nil {
+		dataRepoLog(c).Errorf("AmfContext3gppProcedure err: %+v", err)
+		if abortVersionedWrite(c, err) {
+			return
+		}
+		fail(c, patchFailure(err, &Error{Category: ErrorNotAllowed}))
+		return
 	}
 
-	PreHandleOnDataChangeNotify(ueId, CurrentResourceUri, patchItem, origValue, newValue)
+	p.NotifySubscribers(c, ueId, c.Request.URL.Path, patch.changes(origValue, newValue))
+	setETag(c, etag)
 	c.Status(http.StatusNoContent)
 }
 
@@ -46,19 +45,26 @@ func (p *Processor) CreateAmfContext3gppProcedure(c *gin.Context, collName strin
 	putData := util.ToBsonM(Amf3GppAccessRegistration)
 	putData["ueId"] = ueId
 
-	if _, err := mongoapi.RestfulAPIPutOne(collName, filter, putData); err != nil {
-		logger.DataRepoLog.Errorf("CreateAmfContext3gppProcedure err: %+v", err)
+	_, etag, err := p.PutVersionedDataToDB(c, collName, filter, putData, ifMatchOf(c))
+	if err != nil {
+		dataRepoLog(c).Errorf("CreateAmfContext3gppProcedure err: %+v", err)
+		if !abortVersionedWrite(c, err) {
+			fail(c, err)
+		}
+		return
 	}
+	p.NotifySubscribers(c, ueId, c.Request.URL.Path, documentChanges(putData))
+	setETag(c, etag)
 	c.Status(http.StatusNoContent)
 }
 
 func (p *Processor) QueryAmfContext3gppProcedure(c *gin.Context, collName string, ueId string) {
 	filter := bson.M{"ueId": ueId}
-	data, pd := p.GetDataFromDB(collName, filter)
+	data, etag, pd := p.GetVersionedDataFromDB(c, collName, filter)
 	if pd != nil {
-		logger.DataRepoLog.Errorf("QueryAmfContext3gppProcedure err: %s", pd.Detail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		dataRepoLog(c).Errorf("QueryAmfContext3gppProcedure err: %s", pd.Detail)
+		fail(c, problemOf(pd))
+		return
 	}
-	c.JSON(http.StatusOK, data)
+	respondVersioned(c, etag, data)
 }
@@ -10,31 +10,46 @@
 package processor
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 
 	"github.com/free5gc/openapi/models"
-	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/database"
 	"github.com/free5gc/udr/internal/util"
-	"github.com/free5gc/util/metrics/sbi"
-	"github.com/free5gc/util/mongoapi"
 )
 
+// amfNon3gppModifiableFields are the fields of AmfNon3GppAccessRegistrationModification (TS 29.505), the
+// other ones are those of the registration and only change with a new registration
+var amfNon3gppModifiableFields = []string{"guami", "purgeFlag", "pei", "imsVoPs", "backupAmfInfo"}
+
 func (p *Processor) AmfContextNon3gppProcedure(
-	c *gin.Context, ueId string, collName string, patchItem []models.PatchItem,
+	c *gin.Context, ueId string, collName string, patch PatchDocument,
 	filter bson.M,
 ) {
-	var err error
-	var origValue, newValue map[string]interface{}
-	if origValue, newValue, err = p.PatchDataToDBAndNotify(collName, ueId, patchItem, filter); err != nil {
-		logger.DataRepoLog.Errorf("AmfContextNon3gppProcedure err: %+v", err)
-		pd := util.ProblemDetailsSystemFailure(err.Error())
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+	if field, ok := unmodifiableField(patch, amfNon3gppModifiableFields); ok {
+		err := &Error{Category: ErrorNotAllowed, Message: field + " cannot be modified"}
+		dataRepoLog(c).Warnf("AmfContextNon3gppProcedure of %s: %+v", ueId, err)
+		fail(c, err)
+		return
+	}
+
+	origValue, newValue, etag, err := p.patchVersionedDataToDB(c, collName, filter, patch, ifMatchOf(c))
+	if err != nil {
+		dataRepoLog(c).Errorf("AmfContextNon3gppProcedure err: %+v", err)
+		if abortVersionedWrite(c, err) {
+			return
+		}
+		if errors.Is(err, database.ErrNoDocument) {
+			err = &Error{Category: ErrorNotFound}
+		}
+		fail(c, patchFailure(err, err))
+		return
 	}
-	PreHandleOnDataChangeNotify(ueId, CurrentResourceUri, patchItem, origValue, newValue)
+	p.NotifySubscribers(c, ueId, c.Request.URL.Path, patch.changes(origValue, newValue))
+	setETag(c, etag)
 	c.Status(http.StatusNoContent)
 }
 
@@ -46,21 +61,27 @@ func (p *Processor) CreateAmfContextNon3gppProcedure(
 	putData["ueId"] = ueId
 	filter := bson.M{"ueId": ueId}
 
-	if _, err := mongoapi.RestfulAPIPutOne(collName, filter, putData); err != nil {
-		logger.DataRepoLog.Errorf("CreateAmfContextNon3gppProcedure err: %+v", err)
+	_, etag, err := p.PutVersionedDataToDB(c, collName, filter, putData, ifMatchOf(c))
+	if err != nil {
+		dataRepoLog(c).Errorf("CreateAmfContextNon3gppProcedure err: %+v", err)
+		if !abortVersionedWrite(c, err) {
+			fail(c, err)
+		}
+		return
 	}
+	p.NotifySubscribers(c, ueId, c.Request.URL.Path, documentChanges(putData))
+	setETag(c, etag)
 
 	c.Data(http.StatusNoContent, "application/json", nil)
 }
 
 func (p *Processor) QueryAmfContextNon3gppProcedure(c *gin.Context, collName string, ueId string) {
 	filter := bson.M{"ueId": ueId}
-	data, pd := p.GetDataFromDB(collName, filter)
+	data, etag, pd := p.GetVersionedDataFromDB(c, collName, filter)
 	if pd != nil {
-		logger.DataRepoLog.Errorf("QueryAmfContextNon3gppProcedure err: %s", pd.Detail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		dataRepoLog(c).Errorf("QueryAmfContextNon3gppProcedure err: %s", pd.Detail)
+		fail(c, problemOf(pd))
 		return
 	}
-	c.JSON(http.StatusOK, data)
+	respondVersioned(c, etag, data)
 }
@@ -11,80 +11,63 @@ package processor
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/gin-gonic/gin"
 
 	"github.com/free5gc/openapi/models"
-	udr_context "github.com/free5gc/udr/internal/context"
-	"github.com/free5gc/udr/internal/logger"
-	"github.com/free5gc/udr/internal/util"
-	"github.com/free5gc/util/metrics/sbi"
+	db "github.com/free5gc/udr/internal/database"
 )
 
 func (p *Processor) ModifyAmfSubscriptionInfoProcedure(c *gin.Context, ueId string, subsId string,
 	patchItem []models.PatchItem,
 ) {
-	udrSelf := udr_context.GetSelf()
-	value, ok := udrSelf.UESubsCollection.Load(ueId)
-	if !ok {
-		pd := util.ProblemDetailsNotFound("USER_NOT_FOUND")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+	if !p.checkEeSubscription(c, "ModifyAmfSubscriptionInfoProcedure", ueId, subsId) {
 		return
 	}
-	UESubsData := value.(*udr_context.UESubsData)
 
-	_, ok = UESubsData.EeSubscriptionCollection[subsId]
-
-	if !ok {
-		pd := util.ProblemDetailsNotFound("SUBSCRIPTION_NOT_FOUND")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
-		return
-	}
-
-	if UESubsData.EeSubscriptionCollection[subsId].AmfSubscriptionInfos == nil {
-		pd := util.ProblemDetailsNotFound("AMFSUBSCRIPTION_NOT_FOUND")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
-		return
-	}
-	var patchJSON []byte
-	if patchJSONtemp, err := json.Marshal(patchItem); err != nil {
-		logger.DataRepoLog.Errorln(err)
-	} else {
-		patchJSON = patchJSONtemp
-	}
 	var patch jsonpatch.Patch
-	if patchtemp, err := jsonpatch.DecodePatch(patchJSON); err != nil {
-		logger.DataRepoLog.Errorln(err)
-		pd := util.ProblemDetailsModifyNotAllowed("PatchItem attributes are invalid")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
-		return
-	} else {
-		patch = patchtemp
+	patchJSON, err := json.Marshal(patchItem)
+	if err == nil {
+		patch, err = jsonpatch.DecodePatch(patchJSON)
 	}
-	original, err := json.Marshal((UESubsData.EeSubscriptionCollection[subsId]).AmfSubscriptionInfos)
 	if err != nil {
-		logger.DataRepoLog.Warnln(err)
+		dataRepoLog(c).Errorln(err)
+		fail(c, &Error{Category: ErrorNotAllowed, Message: "PatchItem attributes are invalid"})
+		return
 	}
 
-	modified, err := patch.Apply(original)
-	if err != nil {
-		pd := util.ProblemDetailsModifyNotAllowed("Occur error when applying PatchItem")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+	// patchErr is the failure of the patch itself, rather than of the datastore
+	var patchErr error
+	_, _, err = p.ModifyDataFieldToDB(c, db.SUBSCDATA_EE_AMF_SUBSC_DB_COLLECTION_NAME,
+		eeSubscriptionFilter(ueId, subsId), amfSubscriptionInfoListField,
+		func(value []byte) ([]byte, bool, error) {
+			modified, applyErr := patch.Apply(value)
+			if applyErr == nil {
+				// The AMF subscriptions stay a list of AmfSubscriptionInfo
+				var amfSubscriptionInfos []models.AmfSubscriptionInfo
+				applyErr = json.Unmarshal(modified, &amfSubscriptionInfos)
+			}
+			if applyErr != nil {
+				patchErr = applyErr
+				return nil, false, applyErr
+			}
+			return modified, true, nil
+		})
+	switch {
+	case patchErr != nil:
+		fail(c, patchFailure(patchErr,
+			&Error{Category: ErrorNotAllowed, Message: "Occur error when applying PatchItem"}))
+		return
+	case errors.Is(err, db.ErrNoDocument):
+		fail(c, &Error{Category: ErrorNotFound, Cause: "AMFSUBSCRIPTION_NOT_FOUND"})
+		return
+	case err != nil:
+		dataRepoLog(c).Errorf("ModifyAmfSubscriptionInfoProcedure err: %+v", err)
+		fail(c, err)
 		return
 	}
-	var modifiedData []models.AmfSubscriptionInfo
-	err = json.Unmarshal(modified, &modifiedData)
-	if err != nil {
-		logger.DataRepoLog.Error(err)
-	}
-
-	UESubsData.EeSubscriptionCollection[subsId].AmfSubscriptionInfos = modifiedData
 	c.Status(http.StatusNoContent)
 }
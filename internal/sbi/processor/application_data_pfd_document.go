@@ -0,0 +1,152 @@
+/*
+ * Nudr_DataRepository API OpenAPI file
+ *
+ * Unified Data Repository Service
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package processor
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/free5gc/openapi/models"
+	udr_context "github.com/free5gc/udr/internal/context"
+	db "github.com/free5gc/udr/internal/database"
+	"github.com/free5gc/udr/internal/util"
+)
+
+func (p *Processor) DeleteApplicationDataIndividualPfdFromDBProcedure(c *gin.Context, appID string) {
+	filter := bson.M{"applicationId": appID}
+	_, pd := p.GetDataFromDB(c, db.APPDATA_PFD_DB_COLLECTION_NAME, filter)
+	var err error
+	if pd != nil {
+		err = problemOf(pd)
+	} else {
+		err = p.DeleteOneDataFromDB(c, db.APPDATA_PFD_DB_COLLECTION_NAME, filter)
+	}
+	if err != nil {
+		dataRepoLog(c).Errorf("deleteApplicationDataIndividualPfdFromDB of %s err: %+v", appID, err)
+		fail(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetApplicationDataIndividualPfdFromDBProcedure answers the PFDs of the application, with the features of
+// supportedFeatures negotiated as suppFeat. The PFDs have no optional attribute of a feature.
+func (p *Processor) GetApplicationDataIndividualPfdFromDBProcedure(c *gin.Context, appID string,
+	supportedFeatures string,
+) {
+	filter := bson.M{"applicationId": appID}
+	data, pd := p.GetDataFromDB(c, db.APPDATA_PFD_DB_COLLECTION_NAME, filter)
+	if pd != nil {
+		dataRepoLog(c).Errorf("getApplicationDataIndividualPfdFromDB of %s err: %s", appID, pd.Detail)
+		fail(c, problemOf(pd))
+		return
+	}
+	if negotiated, negotiating := p.negotiateFeatures(c, supportedFeatures); negotiating {
+		setSuppFeat(data, negotiated)
+	}
+	c.JSON(http.StatusOK, data)
+}
+
+// PutApplicationDataIndividualPfdToDBProcedure replaces the PFDs of the application, so that the PFDs and the
+// reset IDs of the previous ones are not kept along
+func (p *Processor) PutApplicationDataIndividualPfdToDBProcedure(
+	c *gin.Context, appID string, pfdDataForApp *models.PfdDataForAppExt,
+) {
+	if pfdDataForApp.ApplicationId == "" {
+		pfdDataForApp.ApplicationId = appID
+	}
+	if detail := validatePfdDataForApp(appID, pfdDataForApp); detail != "" {
+		dataRepoLog(c).Warnf("putApplicationDataIndividualPfdToDB of %s: %s", appID, detail)
+		fail(c, &Error{Category: ErrorValidation, Message: detail})
+		return
+	}
+
+	filter := bson.M{"applicationId": appID}
+	existed, _, err := p.ReplaceVersionedDataToDB(c, db.APPDATA_PFD_DB_COLLECTION_NAME, filter,
+		util.ToBsonM(*pfdDataForApp), ifMatchOf(c), nil)
+	if err != nil {
+		dataRepoLog(c).Errorf("putApplicationDataIndividualPfdToDB err: %+v", err)
+		if !abortVersionedWrite(c, err) {
+			fail(c, err)
+		}
+		return
+	}
+
+	if existed {
+		c.JSON(http.StatusOK, pfdDataForApp)
+		return
+	}
+	groupUri := udr_context.GetSelf().GetIPv4GroupUri(udr_context.NUDR_DR)
+	c.Header("Location", fmt.Sprintf("%s/application-data/pfds/%s", groupUri, appID))
+	c.JSON(http.StatusCreated, pfdDataForApp)
+}
+
+// validatePfdDataForApp returns the detail of the first problem found, the types are checked by the decoding
+func validatePfdDataForApp(appID string, pfdDataForApp *models.PfdDataForAppExt) string {
+	if pfdDataForApp.ApplicationId != appID {
+		return fmt.Sprintf("applicationId %q differs from the appId of the URI", pfdDataForApp.ApplicationId)
+	}
+	if len(pfdDataForApp.Pfds) == 0 {
+		return "pfds shall hold at least one PFD"
+	}
+	for i, pfd := range pfdDataForApp.Pfds {
+		if pfd.PfdId == "" {
+			return fmt.Sprintf("pfds[%d]: pfdId is required", i)
+		}
+	}
+	if !util.SupportedFeaturesRegexp.MatchString(pfdDataForApp.SuppFeat) {
+		return "suppFeat shall be hexadecimal"
+	}
+	return ""
+}
+
+// GetApplicationDataPfdsFromDBProcedure answers the PFDs of the applications of pfdsAppIDs, of all of them when
+// pfdsAppIDs is empty. The unknown applications are left out, none known answers 404.
+func (p *Processor) GetApplicationDataPfdsFromDBProcedure(c *gin.Context, pfdsAppIDs []string,
+	supportedFeatures string,
+) {
+	matchedPfds := []map[string]interface{}{}
+	if len(pfdsAppIDs) == 0 {
+		pfds, err := p.GetManyDataFromDB(c, db.APPDATA_PFD_DB_COLLECTION_NAME, bson.M{})
+		if err != nil {
+			dataRepoLog(c).Errorf("getApplicationDataPfdsFromDB err: %+v", err)
+			fail(c, err)
+			return
+		}
+		matchedPfds = append(matchedPfds, pfds...)
+	} else {
+		for _, appID := range pfdsAppIDs {
+			filter := bson.M{"applicationId": appID}
+			data, pd := p.GetDataFromDB(c, db.APPDATA_PFD_DB_COLLECTION_NAME, filter)
+			if pd != nil && pd.Status != http.StatusNotFound {
+				dataRepoLog(c).Errorf("getApplicationDataPfdsFromDB of %s err: %s", appID, pd.Detail)
+				fail(c, problemOf(pd))
+				return
+			}
+			if pd == nil {
+				matchedPfds = append(matchedPfds, data)
+			}
+		}
+		if len(matchedPfds) == 0 {
+			fail(c, &Error{Category: ErrorNotFound})
+			return
+		}
+	}
+
+	if negotiated, negotiating := p.negotiateFeatures(c, supportedFeatures); negotiating {
+		for _, pfdDataForApp := range matchedPfds {
+			setSuppFeat(pfdDataForApp, negotiated)
+		}
+	}
+	c.JSON(http.StatusOK, matchedPfds)
+}
@@ -0,0 +1,192 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+
+	db "github.com/free5gc/udr/internal/database"
+	"github.com/free5gc/udr/internal/logger"
+	udr_metrics "github.com/free5gc/udr/internal/metrics"
+)
+
+// auditWriteTimeout bounds the write of one audit record, the queue filling up meanwhile
+const auditWriteTimeout = 5 * time.Second
+
+// AuditRecord is one successful provisioning write of the audit trail
+type AuditRecord struct {
+	Timestamp time.Time `json:"timestamp" bson:"timestamp"`
+	UeId      string    `json:"ueId,omitempty" bson:"ueId,omitempty"`
+	// Resource is the URI of the resource relative to the data repository root
+	Resource              string `json:"resource" bson:"resource"`
+	Operation             string `json:"operation" bson:"operation"`
+	RequesterNfInstanceId string `json:"requesterNfInstanceId,omitempty" bson:"requesterNfInstanceId,omitempty"`
+	// Summary names what the write changed, never the values
+	Summary string `json:"summary,omitempty" bson:"summary,omitempty"`
+}
+
+// auditTrail writes the audit records in the background, so that the provisioning writes never wait for
+// it. The records arriving while its queue is full are dropped.
+type auditTrail struct {
+	mu     sync.RWMutex
+	closed bool
+	queue  chan AuditRecord
+	done   chan struct{}
+}
+
+func newAuditTrail(queueSize int, write func(record AuditRecord) error) *auditTrail {
+	a := &auditTrail{
+		queue: make(chan AuditRecord, queueSize),
+		done:  make(chan struct{}),
+	}
+	go func() {
+		defer close(a.done)
+		for record := range a.queue {
+			if err := write(record); err != nil {
+				logger.AuditLog.Errorf("Write audit record of %s %s failed: %+v", record.Operation, record.Resource, err)
+				continue
+			}
+			logger.AuditLog.WithFields(logrus.Fields{
+				"timestamp":             record.Timestamp,
+				"ueId":                  record.UeId,
+				"resource":              record.Resource,
+				"operation":             record.Operation,
+				"requesterNfInstanceId": record.RequesterNfInstanceId,
+				"summary":               record.Summary,
+			}).Info("Provisioning write")
+		}
+	}()
+	return a
+}
+
+func (a *auditTrail) record(record AuditRecord) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if !a.closed {
+		select {
+		case a.queue <- record:
+			return
+		default:
+		}
+	}
+	udr_metrics.IncrAuditDroppedCounter()
+	logger.AuditLog.Warnf("Audit queue full, drop the record of %s %s", record.Operation, record.Resource)
+}
+
+// stop writes the queued records, until ctx is done
+func (a *auditTrail) stop(ctx context.Context) {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.queue)
+	}
+	a.mu.Unlock()
+
+	select {
+	case <-a.done:
+	case <-ctx.Done():
+		logger.AuditLog.Warnf("Audit records left unwritten: %d", len(a.queue))
+	}
+}
+
+// RecordAudit queues the record of a write for the audit trail, it does nothing when the audit is disabled
+func (p *Processor) RecordAudit(record AuditRecord) {
+	if p.audit != nil {
+		p.audit.record(record)
+	}
+}
+
+// StopAudit writes the queued audit records, before the datastore is disconnected
+func (p *Processor) StopAudit(ctx context.Context) {
+	if p.audit != nil {
+		p.audit.stop(ctx)
+	}
+}
+
+func (p *Processor) writeAuditRecord(record AuditRecord) error {
+	data := map[string]interface{}{
+		"timestamp": record.Timestamp,
+		"resource":  record.Resource,
+		"operation": record.Operation,
+	}
+	if record.UeId != "" {
+		data["ueId"] = record.UeId
+	}
+	if record.RequesterNfInstanceId != "" {
+		data["requesterNfInstanceId"] = record.RequesterNfInstanceId
+	}
+	if record.Summary != "" {
+		data["summary"] = record.Summary
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), auditWriteTimeout)
+	defer cancel()
+	return p.InsertDataToDB(ctx, db.AUDIT_DB_COLLECTION_NAME, data)
+}
+
+// QueryAuditProcedure answers the page of the audit records of ueId, all of them when empty, written
+// between from and to, ordered by time. pageNumber starts at 1, the Link header points to the next page
+// when there is one.
+func (p *Processor) QueryAuditProcedure(c *gin.Context, ueId string, from, to time.Time,
+	pageSize, pageNumber int,
+) {
+	filter := bson.M{}
+	if ueId != "" {
+		filter["ueId"] = ueId
+	}
+	timestamp := bson.M{}
+	if !from.IsZero() {
+		timestamp["$gte"] = from
+	}
+	if !to.IsZero() {
+		timestamp["$lte"] = to
+	}
+	if len(timestamp) > 0 {
+		filter["timestamp"] = timestamp
+	}
+
+	// One more document than the page tells whether a next page exists. The records written at the same time
+	// are ordered by _id for the pages not to repeat nor skip any.
+	skip := int64(pageSize) * int64(pageNumber-1)
+	page, pd := p.GetPageFromDB(c, db.AUDIT_DB_COLLECTION_NAME, filter, []string{"timestamp", "_id"}, skip,
+		int64(pageSize)+1)
+	if pd != nil {
+		dataRepoLog(c).Errorf("QueryAudit err: %s", pd.Detail)
+		fail(c, problemOf(pd))
+		return
+	}
+
+	if len(page) > pageSize {
+		page = page[:pageSize]
+		c.Header("Link", nextPageLink(c.Request.URL, pageSize, pageNumber))
+	}
+
+	records := make([]AuditRecord, 0, len(page))
+	for _, data := range page {
+		record, err := decodeAuditRecord(data)
+		if err != nil {
+			dataRepoLog(c).Warnf("QueryAudit: skip undecodable record: %+v", err)
+			continue
+		}
+		records = append(records, record)
+	}
+	c.JSON(http.StatusOK, records)
+}
+
+func decodeAuditRecord(data map[string]interface{}) (AuditRecord, error) {
+	var record AuditRecord
+	raw, err := bson.Marshal(data)
+	if err != nil {
+		return record, fmt.Errorf("marshal audit record: %w", err)
+	}
+	if err = bson.Unmarshal(raw, &record); err != nil {
+		return record, fmt.Errorf("unmarshal audit record: %w", err)
+	}
+	return record, nil
+}
@@ -0,0 +1,42 @@
+package processor
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditTrail(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var written []string
+	a := newAuditTrail(2, func(record AuditRecord) error {
+		<-release
+		mu.Lock()
+		defer mu.Unlock()
+		written = append(written, record.Resource)
+		return nil
+	})
+
+	// The first record is taken by the writer, the next two fill the queue and the last one is dropped
+	a.record(AuditRecord{Resource: "/first"})
+	require.Eventually(t, func() bool {
+		return len(a.queue) == 0
+	}, time.Second, 10*time.Millisecond)
+	for _, resource := range []string{"/second", "/third", "/dropped"} {
+		a.record(AuditRecord{Resource: resource})
+	}
+
+	close(release)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	a.stop(ctx)
+	require.Equal(t, []string{"/first", "/second", "/third"}, written)
+
+	// Records arriving after stop are dropped rather than written
+	a.record(AuditRecord{Resource: "/late"})
+	require.Len(t, written, 3)
+}
@@ -0,0 +1,99 @@
+package processor
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// authSubsCache is the LRU cache of the authentication subscriptions read, by SUPI. The cached documents
+// are shared by the readers and never modified.
+type authSubsCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	lru     *list.List // of *authSubsEntry, the most recently read first
+	entries map[string]*list.Element
+	// generation changes on every invalidation, so that a read racing a write does not cache what it read
+	// before the write
+	generation uint64
+
+	now func() time.Time
+}
+
+type authSubsEntry struct {
+	ueId    string
+	data    map[string]interface{}
+	expires time.Time
+}
+
+func newAuthSubsCache(size int, ttl time.Duration) *authSubsCache {
+	return &authSubsCache{
+		size:    size,
+		ttl:     ttl,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element, size),
+		now:     time.Now,
+	}
+}
+
+// get returns the cached subscription of ueId, or the generation to put the one read with
+func (a *authSubsCache) get(ueId string) (map[string]interface{}, uint64, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	element, ok := a.entries[ueId]
+	if !ok {
+		return nil, a.generation, false
+	}
+	entry := element.Value.(*authSubsEntry)
+	if !a.now().Before(entry.expires) {
+		a.lru.Remove(element)
+		delete(a.entries, ueId)
+		return nil, a.generation, false
+	}
+	a.lru.MoveToFront(element)
+	return entry.data, a.generation, true
+}
+
+// put caches the subscription read, unless an invalidation happened since the generation was got
+func (a *authSubsCache) put(ueId string, data map[string]interface{}, generation uint64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if generation != a.generation {
+		return
+	}
+	entry := &authSubsEntry{ueId: ueId, data: data, expires: a.now().Add(a.ttl)}
+	if element, ok := a.entries[ueId]; ok {
+		element.Value = entry
+		a.lru.MoveToFront(element)
+		return
+	}
+	a.entries[ueId] = a.lru.PushFront(entry)
+	for a.lru.Len() > a.size {
+		oldest := a.lru.Back()
+		a.lru.Remove(oldest)
+		delete(a.entries, oldest.Value.(*authSubsEntry).ueId)
+	}
+}
+
+// invalidate drops the subscription of ueId, after a write to it
+func (a *authSubsCache) invalidate(ueId string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.generation++
+	if element, ok := a.entries[ueId]; ok {
+		a.lru.Remove(element)
+		delete(a.entries, ueId)
+	}
+}
+
+// invalidateAuthSubs drops the cached authentication subscription of ueId, it does nothing when the cache is
+// disabled
+func (p *Processor) invalidateAuthSubs(ueId string) {
+	if p.authSubsCache != nil {
+		p.authSubsCache.invalidate(ueId)
+	}
+}
@@ -0,0 +1,46 @@
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthSubsCache(t *testing.T) {
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	a := newAuthSubsCache(2, time.Minute)
+	a.now = func() time.Time { return now }
+	subscription := func(sqn string) map[string]interface{} {
+		return map[string]interface{}{"sequenceNumber": map[string]interface{}{"sqn": sqn}}
+	}
+	cached := func(ueId string) map[string]interface{} {
+		data, _, hit := a.get(ueId)
+		if !hit {
+			return nil
+		}
+		return data
+	}
+
+	_, generation, hit := a.get("imsi-208930000000001")
+	require.False(t, hit)
+	a.put("imsi-208930000000001", subscription("000000000001"), generation)
+	a.put("imsi-208930000000002", subscription("000000000002"), generation)
+	require.Equal(t, subscription("000000000001"), cached("imsi-208930000000001"))
+
+	// The least recently read subscription is evicted
+	a.put("imsi-208930000000003", subscription("000000000003"), generation)
+	require.Nil(t, cached("imsi-208930000000002"))
+	require.NotNil(t, cached("imsi-208930000000001"))
+	require.NotNil(t, cached("imsi-208930000000003"))
+
+	// A write drops the subscription, and the read which started before it does not cache what it read
+	_, generation, _ = a.get("imsi-208930000000004")
+	a.invalidate("imsi-208930000000001")
+	require.Nil(t, cached("imsi-208930000000001"))
+	a.put("imsi-208930000000004", subscription("000000000004"), generation)
+	require.Nil(t, cached("imsi-208930000000004"))
+
+	now = now.Add(time.Minute)
+	require.Nil(t, cached("imsi-208930000000003"))
+}
@@ -10,48 +10,231 @@
 package processor
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
+	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 
 	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/udr/internal/database"
 	"github.com/free5gc/udr/internal/logger"
+	udr_metrics "github.com/free5gc/udr/internal/metrics"
 	"github.com/free5gc/udr/internal/util"
-	"github.com/free5gc/util/metrics/sbi"
 )
 
+const sequenceNumberField = "sequenceNumber"
+
+// SqnMax is the largest SQN, a 48 bit counter of 3GPP TS 33.102 Annex C
+const SqnMax = 1<<48 - 1
+
+// SqnIncrement is the amount the SQN of the UE is increased by
+type SqnIncrement struct {
+	Increment uint64 `json:"increment"`
+}
+
+// permanentKeyFields hold the permanent key K of the subscriber, which the UDM never modifies
+var permanentKeyFields = []string{"encPermanentKey", "permanentKey"}
+
 func (p *Processor) ModifyAuthenticationProcedure(
-	c *gin.Context, collName string, ueId string, patchItem []models.PatchItem,
+	c *gin.Context, collName string, ueId string, patch PatchDocument,
 ) {
-	logger.ProcLog.Debugf("ModifyAuthenticationProcedure: %s %v", ueId, patchItem)
+	util.RequestLog(c, logger.ProcLog).Debugf("ModifyAuthenticationProcedure: %s %s", ueId, patch)
+
+	if modifiesPermanentKey(patch) {
+		err := &Error{Category: ErrorNotAllowed, Message: "the permanent key cannot be modified"}
+		dataRepoLog(c).Warnf("ModifyAuthenticationProcedure of %s: %+v", ueId, err)
+		fail(c, err)
+		return
+	}
 
 	var err error
 	var origValue, newValue map[string]interface{}
 	filter := bson.M{"ueId": ueId}
-	if origValue, newValue, err = p.PatchDataToDBAndNotify(collName, ueId, patchItem, filter); err != nil {
-		logger.DataRepoLog.Errorf("ModifyAuthenticationProcedure err: %+v", err)
-		problemDetails := util.ProblemDetailsModifyNotAllowed("")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
-		c.JSON(http.StatusInternalServerError, problemDetails)
+	if patchesSequenceNumberOnly(patch) {
+		origValue, newValue, err = p.patchSequenceNumber(c, collName, patch.PatchItems, filter)
+	} else {
+		origValue, newValue, err = p.patchDataToDBAndNotify(c, collName, ueId, patch, filter)
+	}
+	// Even a failed write may have been applied
+	p.invalidateAuthSubs(ueId)
+	if err != nil {
+		dataRepoLog(c).Errorf("ModifyAuthenticationProcedure err: %+v", err)
+		if errors.Is(err, database.ErrNoDocument) {
+			err = &Error{Category: ErrorNotFound, Cause: "USER_NOT_FOUND"}
+		}
+		fail(c, patchFailure(err, err))
 		return
 	}
-	PreHandleOnDataChangeNotify(ueId, CurrentResourceUri, patchItem, origValue, newValue)
+	p.NotifySubscribers(c, ueId, c.Request.URL.Path, patch.changes(origValue, newValue))
 	c.Status(http.StatusNoContent)
 }
 
+// patchSequenceNumber applies the JSON Patch to the sequence number alone, atomically, so that the updates
+// the UDM sends after the authentications of the UE do not overwrite each other. The SQN may be taken back,
+// as by the resynchronisation of the UDM, an update guarded by a test operation of the SQN it read fails
+// with a conflict once another one changed it.
+func (p *Processor) patchSequenceNumber(c *gin.Context, collName string, patchItems []models.PatchItem,
+	filter bson.M,
+) (origValue, newValue map[string]interface{}, err error) {
+	patchJSON, err := json.Marshal(patchItems)
+	if err != nil {
+		return nil, nil, err
+	}
+	patch, err := jsonpatch.DecodePatch(patchJSON)
+	if err != nil {
+		return nil, nil, fmt.Errorf("patchSequenceNumber DecodePatch err: %+v", err)
+	}
+
+	origValue, newValue, err = p.ModifyDataFieldToDB(c, collName, filter, sequenceNumberField,
+		func(value []byte) ([]byte, bool, error) {
+			// The patch paths are relative to the document, patch a document holding the sequence number only
+			document, err := json.Marshal(map[string]json.RawMessage{sequenceNumberField: value})
+			if err != nil {
+				return nil, false, err
+			}
+			patched, err := patch.Apply(document)
+			if err != nil {
+				return nil, false, fmt.Errorf("patchSequenceNumber Apply err: %w", err)
+			}
+			var patchedDocument map[string]json.RawMessage
+			if err = json.Unmarshal(patched, &patchedDocument); err != nil {
+				return nil, false, err
+			}
+			return patchedDocument[sequenceNumberField], true, nil
+		})
+	if err != nil {
+		return nil, nil, err
+	}
+	return origValue, newValue, nil
+}
+
+// IncrementSqnProcedure increases the SQN of the UE in one atomic read-modify-write, so that the concurrent
+// authentications never lose an increment, and answers the new sequence number. The SQN wraps at SqnMax.
+func (p *Processor) IncrementSqnProcedure(c *gin.Context, collName string, ueId string, increment uint64) {
+	var sequenceNumber map[string]interface{}
+	filter := bson.M{"ueId": ueId}
+	// The last modification run is the one written
+	_, _, err := p.ModifyDataFieldToDB(c, collName, filter, sequenceNumberField,
+		func(value []byte) ([]byte, bool, error) {
+			sequenceNumber = make(map[string]interface{})
+			if string(value) != "null" {
+				if err := json.Unmarshal(value, &sequenceNumber); err != nil {
+					return nil, false, fmt.Errorf("IncrementSqnProcedure Unmarshal err: %+v", err)
+				}
+			}
+			sequenceNumber["sqn"] = fmt.Sprintf("%012x", (sqnOf(value)+increment)&SqnMax)
+			modified, err := json.Marshal(sequenceNumber)
+			return modified, true, err
+		})
+	p.invalidateAuthSubs(ueId)
+	if err != nil {
+		dataRepoLog(c).Errorf("IncrementSqnProcedure err: %+v", err)
+		if errors.Is(err, database.ErrNoDocument) {
+			fail(c, &Error{Category: ErrorNotFound, Cause: "USER_NOT_FOUND"})
+			return
+		}
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, sequenceNumber)
+}
+
+// sqnOf returns the SQN of the sequence number, 0 when it has none
+func sqnOf(sequenceNumber []byte) uint64 {
+	var value models.SequenceNumber
+	if err := json.Unmarshal(sequenceNumber, &value); err != nil {
+		return 0
+	}
+	sqn, err := strconv.ParseUint(value.Sqn, 16, 64)
+	if err != nil {
+		return 0
+	}
+	return sqn
+}
+
+// patchesSequenceNumberOnly reports whether the patch is a JSON Patch of the sequence number alone
+func patchesSequenceNumberOnly(patch PatchDocument) bool {
+	if patch.IsMergePatch() || len(patch.PatchItems) == 0 {
+		return false
+	}
+	for _, item := range patch.PatchItems {
+		if !isPatchPathUnder(item.Path, sequenceNumberField) ||
+			(item.From != "" && !isPatchPathUnder(item.From, sequenceNumberField)) {
+			return false
+		}
+	}
+	return true
+}
+
+func modifiesPermanentKey(patch PatchDocument) bool {
+	if patch.IsMergePatch() {
+		var document map[string]json.RawMessage
+		if err := json.Unmarshal(patch.MergePatch, &document); err != nil {
+			return false
+		}
+		for _, field := range permanentKeyFields {
+			if _, ok := document[field]; ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, item := range patch.PatchItems {
+		// The whole document is replaced by an empty path
+		if item.Path == "" {
+			return true
+		}
+		for _, field := range permanentKeyFields {
+			if isPatchPathUnder(item.Path, field) {
+				return true
+			}
+			// Moving the key away removes it
+			if item.Op == models.PatchOperation_MOVE && isPatchPathUnder(item.From, field) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isPatchPathUnder reports whether the JSON Pointer designates the field of the document or one of its members
+func isPatchPathUnder(path string, field string) bool {
+	rest, found := strings.CutPrefix(path, "/"+field)
+	return found && (rest == "" || strings.HasPrefix(rest, "/"))
+}
+
 func (p *Processor) QueryAuthSubsDataProcedure(c *gin.Context, collName string, ueId string) {
+	var generation uint64
+	if p.authSubsCache != nil {
+		data, gen, hit := p.authSubsCache.get(ueId)
+		udr_metrics.IncrAuthSubsCacheCounter(hit)
+		if hit {
+			c.JSON(http.StatusOK, data)
+			return
+		}
+		generation = gen
+	}
+
 	filter := bson.M{"ueId": ueId}
-	data, pd := p.GetDataFromDB(collName, filter)
+	data, pd := p.GetDataFromDB(c, collName, filter)
 	if pd != nil {
 		if pd.Status == http.StatusNotFound {
-			logger.DataRepoLog.Warnf("QueryAuthSubsDataProcedure err: %s", pd.Title)
+			dataRepoLog(c).Warnf("QueryAuthSubsDataProcedure err: %s", pd.Title)
 		} else {
-			logger.DataRepoLog.Errorf("QueryAuthSubsDataProcedure err: %s", pd.Detail)
+			dataRepoLog(c).Errorf("QueryAuthSubsDataProcedure err: %s", pd.Detail)
 		}
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		fail(c, problemOf(pd))
 		return
 	}
+	if p.authSubsCache != nil {
+		p.authSubsCache.put(ueId, data, generation)
+	}
 	c.JSON(http.StatusOK, data)
 }
@@ -0,0 +1,59 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/free5gc/openapi/models"
+)
+
+func TestAuthenticationSubscriptionPatch(t *testing.T) {
+	jsonPatch := func(items ...models.PatchItem) PatchDocument {
+		return PatchDocument{PatchItems: items}
+	}
+	replace := func(path string) models.PatchItem {
+		return models.PatchItem{Op: models.PatchOperation_REPLACE, Path: path, Value: "000000000021"}
+	}
+
+	testCases := []struct {
+		name                 string
+		patch                PatchDocument
+		modifiesPermanentKey bool
+		sequenceNumberOnly   bool
+	}{
+		{"SQN", jsonPatch(replace("/sequenceNumber/sqn")), false, true},
+		{"Sequence Number", jsonPatch(replace("/sequenceNumber"), replace("/sequenceNumber/lastIndexes/ausf")),
+			false, true},
+		{"SQN And AMF", jsonPatch(replace("/sequenceNumber/sqn"), replace("/authenticationManagementField")),
+			false, false},
+		{"Field Sharing The Prefix", jsonPatch(replace("/sequenceNumberScheme")), false, false},
+		{"Copy From Another Field", jsonPatch(models.PatchItem{
+			Op: models.PatchOperation_COPY, Path: "/sequenceNumber/sqn", From: "/encOpcKey",
+		}), false, false},
+		{"Encrypted Permanent Key", jsonPatch(replace("/encPermanentKey")), true, false},
+		{"Permanent Key Value", jsonPatch(replace("/permanentKey/permanentKeyValue")), true, false},
+		{"Move Permanent Key", jsonPatch(models.PatchItem{
+			Op: models.PatchOperation_MOVE, Path: "/encOpcKey", From: "/encPermanentKey",
+		}), true, false},
+		{"Whole Document", jsonPatch(replace("")), true, false},
+		{"Merge Patch Of Permanent Key", PatchDocument{MergePatch: []byte(`{"encPermanentKey":"8baf473f"}`)},
+			true, false},
+		{"Merge Patch Of SQN", PatchDocument{MergePatch: []byte(`{"sequenceNumber":{"sqn":"000000000021"}}`)},
+			false, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.modifiesPermanentKey, modifiesPermanentKey(tc.patch))
+			require.Equal(t, tc.sequenceNumberOnly, patchesSequenceNumberOnly(tc.patch))
+		})
+	}
+}
+
+func TestSqnOf(t *testing.T) {
+	require.Equal(t, uint64(0x21), sqnOf([]byte(`{"sqn":"000000000021","sqnScheme":"NON_TIME_BASED"}`)))
+	require.Equal(t, uint64(0xff9bb4d0b607), sqnOf([]byte(`{"sqn":"ff9bb4d0b607"}`)))
+	require.Equal(t, uint64(0), sqnOf([]byte(`null`)))
+	require.Equal(t, uint64(0), sqnOf([]byte(`{"sqn":"not hex"}`)))
+}
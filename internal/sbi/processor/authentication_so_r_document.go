@@ -10,35 +10,25 @@
 package processor
 
 import (
-	"net/http"
-
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 
-	"github.com/free5gc/udr/internal/logger"
-	"github.com/free5gc/util/metrics/sbi"
-	"github.com/free5gc/util/mongoapi"
+	"github.com/free5gc/openapi/models"
 )
 
-func (p *Processor) CreateAuthenticationSoRProcedure(c *gin.Context, collName string, ueId string, putData bson.M) {
-	filter := bson.M{"ueId": ueId}
-	putData["ueId"] = ueId
-
-	if _, err := mongoapi.RestfulAPIPutOne(collName, filter, putData); err != nil {
-		logger.DataRepoLog.Errorf("CreateAuthenticationSoRProcedure err: %+v", err)
-	}
+// SorData is the SorData with the CounterSoR the UDM protected the steering information with,
+// which the OpenAPI model lacks
+type SorData struct {
+	models.SorData
+	CounterSor *int32 `json:"counterSor,omitempty"`
+}
 
-	c.Status(http.StatusNoContent)
+// CreateAuthenticationSoRProcedure stores the SorData in place of the previous one, unless its counterSor is
+// lower than the stored one
+func (p *Processor) CreateAuthenticationSoRProcedure(c *gin.Context, collName string, ueId string, putData bson.M) {
+	p.putUeUpdateConfirmation(c, collName, ueId, putData, "counterSor")
 }
 
 func (p *Processor) QueryAuthSoRProcedure(c *gin.Context, collName string, ueId string) {
-	filter := bson.M{"ueId": ueId}
-	data, pd := p.GetDataFromDB(collName, filter)
-	if pd != nil {
-		logger.DataRepoLog.Errorf("QueryAuthSoRProcedure err: %s", pd.Detail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
-		return
-	}
-	c.JSON(http.StatusOK, data)
+	p.queryUeUpdateConfirmation(c, collName, ueId)
 }
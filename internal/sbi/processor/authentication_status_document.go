@@ -14,32 +14,88 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
-
-	"github.com/free5gc/udr/internal/logger"
-	"github.com/free5gc/util/metrics/sbi"
-	"github.com/free5gc/util/mongoapi"
 )
 
+// CreateAuthenticationStatusProcedure stores the AuthEvent in place of the previous one. Its timeStamp is kept
+// as the RFC 3339 string of the request, which a BSON date would truncate to the millisecond.
 func (p *Processor) CreateAuthenticationStatusProcedure(c *gin.Context, collName string, ueId string, putData bson.M) {
 	filter := bson.M{"ueId": ueId}
 	putData["ueId"] = ueId
 
-	if _, err := mongoapi.RestfulAPIPutOne(collName, filter, putData); err != nil {
-		logger.DataRepoLog.Errorf("CreateAuthenticationStatusProcedure err: %+v", err)
-	}
-
-	c.Status(http.StatusNoContent)
+	p.putAuthenticationStatus(c, collName, filter, putData)
 }
 
 func (p *Processor) QueryAuthenticationStatusProcedure(c *gin.Context, collName string, ueId string) {
 	filter := bson.M{"ueId": ueId}
-	data, pd := p.GetDataFromDB(collName, filter)
 
+	p.queryAuthenticationStatus(c, collName, filter)
+}
+
+func (p *Processor) DeleteAuthenticationStatusProcedure(c *gin.Context, collName string, ueId string) {
+	filter := bson.M{"ueId": ueId}
+
+	p.deleteAuthenticationStatus(c, collName, filter)
+}
+
+// CreateIndividualAuthenticationStatusProcedure stores the AuthEvent of the UE in the serving network,
+// see CreateAuthenticationStatusProcedure
+func (p *Processor) CreateIndividualAuthenticationStatusProcedure(c *gin.Context, collName string, ueId string,
+	servingNetworkName string, putData bson.M,
+) {
+	filter := bson.M{"ueId": ueId, "servingNetworkName": servingNetworkName}
+	putData["ueId"] = ueId
+	putData["servingNetworkName"] = servingNetworkName
+
+	p.putAuthenticationStatus(c, collName, filter, putData)
+}
+
+func (p *Processor) QueryIndividualAuthenticationStatusProcedure(c *gin.Context, collName string, ueId string,
+	servingNetworkName string,
+) {
+	filter := bson.M{"ueId": ueId, "servingNetworkName": servingNetworkName}
+
+	p.queryAuthenticationStatus(c, collName, filter)
+}
+
+func (p *Processor) DeleteIndividualAuthenticationStatusProcedure(c *gin.Context, collName string, ueId string,
+	servingNetworkName string,
+) {
+	filter := bson.M{"ueId": ueId, "servingNetworkName": servingNetworkName}
+
+	p.deleteAuthenticationStatus(c, collName, filter)
+}
+
+func (p *Processor) putAuthenticationStatus(c *gin.Context, collName string, filter bson.M, putData bson.M) {
+	if _, _, err := p.ReplaceVersionedDataToDB(c, collName, filter, putData, ifMatchOf(c), nil); err != nil {
+		dataRepoLog(c).Errorf("CreateAuthenticationStatusProcedure err: %+v", err)
+		if !abortVersionedWrite(c, err) {
+			fail(c, err)
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (p *Processor) queryAuthenticationStatus(c *gin.Context, collName string, filter bson.M) {
+	data, pd := p.GetDataFromDB(c, collName, filter)
 	if pd != nil {
-		logger.DataRepoLog.Errorf("QueryAuthenticationStatusProcedure err: %s", pd.Detail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		dataRepoLog(c).Errorf("QueryAuthenticationStatusProcedure err: %s", pd.Detail)
+		fail(c, problemOf(pd))
 		return
 	}
+
+	// ueId is the key of the document, not a field of the AuthEvent
+	delete(data, "ueId")
 	c.JSON(http.StatusOK, data)
 }
+
+func (p *Processor) deleteAuthenticationStatus(c *gin.Context, collName string, filter bson.M) {
+	if err := p.DeleteOneDataFromDB(c, collName, filter); err != nil {
+		dataRepoLog(c).Errorf("DeleteAuthenticationStatusProcedure err: %+v", err)
+		fail(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,34 @@
+/*
+ * Nudr_DataRepository API OpenAPI file
+ *
+ * Unified Data Repository Service
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package processor
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/free5gc/openapi/models"
+)
+
+// UpuData is the UpuData with the CounterUPU the UDM protected the UE parameters update with,
+// which the OpenAPI model lacks
+type UpuData struct {
+	models.UpuData
+	CounterUpu *int32 `json:"counterUpu,omitempty"`
+}
+
+// CreateAuthenticationUPUProcedure stores the UpuData in place of the previous one, unless its counterUpu is
+// lower than the stored one
+func (p *Processor) CreateAuthenticationUPUProcedure(c *gin.Context, collName string, ueId string, putData bson.M) {
+	p.putUeUpdateConfirmation(c, collName, ueId, putData, "counterUpu")
+}
+
+func (p *Processor) QueryAuthUPUProcedure(c *gin.Context, collName string, ueId string) {
+	p.queryUeUpdateConfirmation(c, collName, ueId)
+}
@@ -0,0 +1,92 @@
+package processor
+
+import (
+	"maps"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+
+	db "github.com/free5gc/udr/internal/database"
+	"github.com/free5gc/util/metrics/sbi"
+)
+
+// BatchReadRequest asks for the data of the resource of every SUPI
+type BatchReadRequest struct {
+	Supis []string `json:"supis"`
+	// Resource is one of BatchReadResources
+	Resource string `json:"resource"`
+	// Required along with the resources stored by serving PLMN
+	ServingPlmnId string `json:"servingPlmnId,omitempty"`
+}
+
+// batchReadResource is the collection of a resource of the subscription data
+type batchReadResource struct {
+	collName string
+	// perPlmn tells the resource is stored by serving PLMN
+	perPlmn bool
+}
+
+// batchReadResources are the resources of a batch read, by the last segment of their path. The SM data stored
+// by S-NSSAI are left out, a subscriber has a single document of these. So is the authentication subscription:
+// the batch read is of the subscription-data resource group, its allowedNfTypes do not restrict the
+// authentication data.
+var batchReadResources = map[string]batchReadResource{
+	"am-data":                         {db.SUBSCDATA_AM_DATA_DB_COLLECTION_NAME, true},
+	"smf-selection-subscription-data": {db.SUBSCDATA_SMF_SEL_DB_COLLECTION_NAME, true},
+	"sms-data":                        {"subscriptionData.provisionedData.smsData", true},
+	"sms-mng-data":                    {"subscriptionData.provisionedData.smsMngData", true},
+	"trace-data":                      {"subscriptionData.provisionedData.traceData", true},
+	"lcs-privacy-data":                {"subscriptionData.provisionedData.lcsPrivacyData", false},
+	"lcs-mo-data":                     {"subscriptionData.provisionedData.lcsMoData", false},
+	"v2x-data":                        {"subscriptionData.provisionedData.v2xData", false},
+	"prose-data":                      {"subscriptionData.provisionedData.proseData", false},
+	"pp-data":                         {"subscriptionData.ppData", false},
+	"operator-specific-data":          {"subscriptionData.operatorSpecificData", false},
+}
+
+// BatchReadResources are the resources a batch read accepts
+func BatchReadResources() []string {
+	resources := make([]string, 0, len(batchReadResources))
+	for resource := range batchReadResources {
+		resources = append(resources, resource)
+	}
+	slices.Sort(resources)
+	return resources
+}
+
+// IsPerPlmnBatchReadResource tells whether the resource of a batch read is stored by serving PLMN
+func IsPerPlmnBatchReadResource(resource string) bool {
+	return batchReadResources[resource].perPlmn
+}
+
+// BatchReadProcedure answers the documents of the resource by SUPI, in a single query of all the SUPIs. The
+// SUPIs without a document are left out, the ueId and servingPlmnId of the documents as well.
+func (p *Processor) BatchReadProcedure(c *gin.Context, request BatchReadRequest) {
+	resource := batchReadResources[request.Resource]
+	filter := bson.M{"ueId": bson.M{"$in": request.Supis}}
+	if resource.perPlmn {
+		filter["servingPlmnId"] = request.ServingPlmnId
+	}
+	docs, err := p.GetManyDataFromDB(c, resource.collName, filter)
+	if err != nil {
+		dataRepoLog(c).Errorf("BatchReadProcedure of %s err: %+v", request.Resource, err)
+		fail(c, err)
+		return
+	}
+
+	bySupi := make(map[string]map[string]interface{}, len(docs))
+	for _, doc := range docs {
+		supi, ok := doc["ueId"].(string)
+		if !ok {
+			continue
+		}
+		data := maps.Clone(doc)
+		delete(data, "ueId")
+		delete(data, "servingPlmnId")
+		bySupi[supi] = data
+	}
+	c.Set(sbi.IN_PB_DETAILS_CTX_STR, "")
+	c.JSON(http.StatusOK, bySupi)
+}
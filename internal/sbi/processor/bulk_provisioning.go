@@ -0,0 +1,264 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/free5gc/openapi/models"
+	db "github.com/free5gc/udr/internal/database"
+	"github.com/free5gc/udr/internal/util"
+)
+
+// ProvisioningRecord is the subscription data of one subscriber in a bulk provisioning request,
+// every dataset given replaces the stored one
+type ProvisioningRecord struct {
+	Supi string `json:"supi"`
+	// Required along with amData, smData or smfSelectionData
+	ServingPlmnId              string                                     `json:"servingPlmnId,omitempty"`
+	AuthenticationSubscription *models.AuthenticationSubscription         `json:"authenticationSubscription,omitempty"`
+	AmData                     *models.AccessAndMobilitySubscriptionData  `json:"amData,omitempty"`
+	SmData                     []models.SessionManagementSubscriptionData `json:"smData,omitempty"`
+	SmfSelectionData           *models.SmfSelectionSubscriptionData       `json:"smfSelectionData,omitempty"`
+}
+
+// ProvisioningResult is the outcome of the record at the same index of the request
+type ProvisioningResult struct {
+	Supi   string                 `json:"supi"`
+	Status int                    `json:"status"`
+	Error  *models.ProblemDetails `json:"error,omitempty"`
+}
+
+// bulkUpserts gathers the upserts of one collection and the record each of them comes from
+type bulkUpserts struct {
+	dataset string
+	upserts []db.Upsert
+	records []int
+}
+
+// add stores the identity fields, ueId and servingPlmnId, along with the data
+func (b *bulkUpserts) add(record int, identity, filter bson.M, data map[string]interface{}) {
+	for key, value := range identity {
+		data[key] = value
+	}
+	b.upserts = append(b.upserts, db.Upsert{Filter: filter, Data: data})
+	b.records = append(b.records, record)
+}
+
+// bulkProvisioningOrder is the order the collections of a bulk provisioning are written in
+var bulkProvisioningOrder = []string{
+	db.SUBSCDATA_AUTH_SUBSC_DB_COLLECTION_NAME,
+	db.SUBSCDATA_AM_DATA_DB_COLLECTION_NAME,
+	db.SUBSCDATA_SM_DATA_DB_COLLECTION_NAME,
+	db.SUBSCDATA_SMF_SEL_DB_COLLECTION_NAME,
+}
+
+// BulkProvisionSubscriptionData writes the valid records with one bulk upsert per collection. Unless createOnly,
+// a record creates the subscriber or overwrites the datasets it gives of the stored one, the datasets it does not
+// give are kept. With createOnly, a record of a SUPI already having subscription data is answered 409 and nothing
+// of it is written, the check is not atomic with the writes of a concurrent request though.
+// The upserts are written within a transaction when the datastore supports them, a failure then fails all the
+// records written. Otherwise the collections written before the one failing stay written, so a record failing on
+// one of its datasets may have its other datasets written, and the records of the collections left unwritten
+// after it are failed as well. Sending a failed record again is harmless.
+func (p *Processor) BulkProvisionSubscriptionData(c *gin.Context, records []ProvisioningRecord, createOnly bool) {
+	results := make([]ProvisioningResult, len(records))
+	collections := map[string]*bulkUpserts{
+		db.SUBSCDATA_AUTH_SUBSC_DB_COLLECTION_NAME: {dataset: "authenticationSubscription"},
+		db.SUBSCDATA_AM_DATA_DB_COLLECTION_NAME:    {dataset: "amData"},
+		db.SUBSCDATA_SM_DATA_DB_COLLECTION_NAME:    {dataset: "smData"},
+		db.SUBSCDATA_SMF_SEL_DB_COLLECTION_NAME:    {dataset: "smfSelectionData"},
+	}
+
+	var provisioned map[string]bool
+	if createOnly {
+		var err error
+		if provisioned, err = p.provisionedSupis(c, records, collections); err != nil {
+			dataRepoLog(c).Errorf("BulkProvisionSubscriptionData err: %+v", err)
+			fail(c, err)
+			return
+		}
+	}
+
+	seen := make(map[string]int, len(records))
+	for i, record := range records {
+		results[i] = ProvisioningResult{Supi: record.Supi, Status: http.StatusOK}
+		detail := validateProvisioningRecord(&record)
+		if first, ok := seen[record.Supi]; ok && detail == "" {
+			detail = fmt.Sprintf("supi is already provisioned by the record %d of the request", first)
+		}
+		if detail != "" {
+			results[i].Status = http.StatusBadRequest
+			results[i].Error = (&Error{Category: ErrorValidation, Cause: "INVALID_PARAMETER", Message: detail}).
+				ProblemDetails()
+			continue
+		}
+		seen[record.Supi] = i
+		if provisioned[record.Supi] {
+			results[i].Status = http.StatusConflict
+			results[i].Error = (&Error{Category: ErrorConflict, Message: "supi is already provisioned"}).
+				ProblemDetails()
+			continue
+		}
+
+		ueFilter := bson.M{"ueId": record.Supi}
+		plmnFilter := bson.M{"ueId": record.Supi, "servingPlmnId": record.ServingPlmnId}
+		if record.AuthenticationSubscription != nil {
+			collections[db.SUBSCDATA_AUTH_SUBSC_DB_COLLECTION_NAME].add(i, ueFilter, ueFilter,
+				util.ToBsonM(record.AuthenticationSubscription))
+		}
+		if record.AmData != nil {
+			collections[db.SUBSCDATA_AM_DATA_DB_COLLECTION_NAME].add(i, plmnFilter, plmnFilter,
+				util.ToBsonM(record.AmData))
+		}
+		for _, smData := range record.SmData {
+			collections[db.SUBSCDATA_SM_DATA_DB_COLLECTION_NAME].add(i, plmnFilter,
+				smDataFilter(record, smData.SingleNssai), util.ToBsonM(escapeDnnConfigurations(smData)))
+		}
+		if record.SmfSelectionData != nil {
+			collections[db.SUBSCDATA_SMF_SEL_DB_COLLECTION_NAME].add(i, plmnFilter, plmnFilter,
+				util.ToBsonM(record.SmfSelectionData))
+		}
+	}
+
+	// Within a transaction, a failure of one upsert writes none of the records
+	var upsertErrs map[string][]error
+	transactional, err := p.WithTransaction(c, func(ctx context.Context) error {
+		upsertErrs = make(map[string][]error, len(collections))
+		for _, collName := range bulkProvisioningOrder {
+			collection := collections[collName]
+			upsertErrs[collName] = p.BulkUpsertDataToDB(ctx, collName, collection.upserts)
+			if err := errors.Join(upsertErrs[collName]...); err != nil {
+				return fmt.Errorf("%s: %w", collection.dataset, err)
+			}
+		}
+		return nil
+	})
+	// Nothing is written when the transaction fails or fn did not run
+	failedAll := err != nil && (transactional || upsertErrs == nil)
+	for collName, collection := range collections {
+		if collName == db.SUBSCDATA_AUTH_SUBSC_DB_COLLECTION_NAME {
+			for _, record := range collection.records {
+				p.invalidateAuthSubs(records[record].Supi)
+			}
+		}
+		_, attempted := upsertErrs[collName]
+		for j, record := range collection.records {
+			result := &results[record]
+			var pd *models.ProblemDetails
+			switch {
+			case result.Error != nil:
+				continue
+			case failedAll:
+				pd = ProblemDetailsOf(fmt.Errorf("no record is written: %w", err))
+			case err != nil && !attempted:
+				pd = ProblemDetailsOf(
+					fmt.Errorf("%s: not written, the bulk write stopped on %w", collection.dataset, err))
+			case j < len(upsertErrs[collName]) && upsertErrs[collName][j] != nil:
+				dataRepoLog(c).Errorf("BulkProvisionSubscriptionData %s of %s err: %+v",
+					collection.dataset, result.Supi, upsertErrs[collName][j])
+				pd = ProblemDetailsOf(fmt.Errorf("%s: %w", collection.dataset, upsertErrs[collName][j]))
+			default:
+				continue
+			}
+			result.Status = int(pd.Status)
+			result.Error = pd
+		}
+	}
+	if failedAll {
+		dataRepoLog(c).Errorf("BulkProvisionSubscriptionData err: %+v", err)
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// provisionedSupis returns the SUPIs of the records having data in one of the collections provisioned, reading
+// only the ueId of the documents
+func (p *Processor) provisionedSupis(c *gin.Context, records []ProvisioningRecord,
+	collections map[string]*bulkUpserts,
+) (map[string]bool, error) {
+	supis := make([]string, 0, len(records))
+	for _, record := range records {
+		if util.IsValidSupi(record.Supi) {
+			supis = append(supis, record.Supi)
+		}
+	}
+	provisioned := make(map[string]bool)
+	if len(supis) == 0 {
+		return provisioned, nil
+	}
+	for collName, collection := range collections {
+		data, err := p.Query(c, collName, bson.M{"ueId": bson.M{"$in": supis}},
+			db.Query{Fields: []string{"ueId"}})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", collection.dataset, err)
+		}
+		for _, doc := range data {
+			if ueId, ok := doc["ueId"].(string); ok {
+				provisioned[ueId] = true
+			}
+		}
+	}
+	return provisioned, nil
+}
+
+// validateProvisioningRecord returns the detail of the first problem found in the record
+func validateProvisioningRecord(record *ProvisioningRecord) string {
+	switch {
+	case !util.IsValidSupi(record.Supi):
+		return "Invalid supi"
+	case record.AuthenticationSubscription == nil && record.AmData == nil && len(record.SmData) == 0 &&
+		record.SmfSelectionData == nil:
+		return "At least one of authenticationSubscription, amData, smData or smfSelectionData shall be provided"
+	case (record.AmData != nil || len(record.SmData) != 0 || record.SmfSelectionData != nil) &&
+		!util.IsValidServingPlmnId(record.ServingPlmnId):
+		return "Invalid servingPlmnId, required along with amData, smData or smfSelectionData"
+	case record.AuthenticationSubscription != nil && record.AuthenticationSubscription.AuthenticationMethod == "":
+		return "authenticationSubscription: authenticationMethod is required"
+	}
+
+	snssais := make(map[string]bool, len(record.SmData))
+	for _, smData := range record.SmData {
+		if smData.SingleNssai == nil {
+			return "smData: singleNssai is required"
+		}
+		key := util.SnssaiModelsToHex(*smData.SingleNssai)
+		if snssais[key] {
+			return fmt.Sprintf("smData: singleNssai %s is given twice", key)
+		}
+		snssais[key] = true
+	}
+	return ""
+}
+
+// smDataFilter matches the session management data of a slice, stored one document per slice
+func smDataFilter(record ProvisioningRecord, singleNssai *models.Snssai) bson.M {
+	filter := bson.M{
+		"ueId":            record.Supi,
+		"servingPlmnId":   record.ServingPlmnId,
+		"singleNssai.sst": singleNssai.Sst,
+	}
+	if singleNssai.Sd != "" {
+		filter["singleNssai.sd"] = singleNssai.Sd
+	} else {
+		filter["singleNssai.sd"] = bson.M{"$exists": false}
+	}
+	return filter
+}
+
+// escapeDnnConfigurations stores the DNNs as QuerySmDataProcedure looks them up
+func escapeDnnConfigurations(smData models.SessionManagementSubscriptionData) models.SessionManagementSubscriptionData {
+	if smData.DnnConfigurations == nil {
+		return smData
+	}
+	dnnConfigurations := make(map[string]models.DnnConfiguration, len(smData.DnnConfigurations))
+	for dnn, dnnConfiguration := range smData.DnnConfigurations {
+		dnnConfigurations[util.EscapeDnn(dnn)] = dnnConfiguration
+	}
+	smData.DnnConfigurations = dnnConfigurations
+	return smData
+}
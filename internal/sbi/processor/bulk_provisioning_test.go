@@ -0,0 +1,97 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/free5gc/openapi/models"
+)
+
+func TestValidateProvisioningRecord(t *testing.T) {
+	authSubs := &models.AuthenticationSubscription{AuthenticationMethod: models.AuthMethod__5_G_AKA}
+	amData := &models.AccessAndMobilitySubscriptionData{}
+
+	testCases := []struct {
+		name           string
+		record         ProvisioningRecord
+		expectedDetail string
+	}{
+		{
+			name:   "Valid",
+			record: ProvisioningRecord{Supi: "imsi-208930000000001", ServingPlmnId: "20893", AmData: amData},
+		},
+		{
+			name:           "Invalid Supi",
+			record:         ProvisioningRecord{Supi: "208930000000001", AuthenticationSubscription: authSubs},
+			expectedDetail: "Invalid supi",
+		},
+		{
+			name:           "No Dataset",
+			record:         ProvisioningRecord{Supi: "imsi-208930000000001"},
+			expectedDetail: "At least one of",
+		},
+		{
+			name:           "Missing Serving PLMN",
+			record:         ProvisioningRecord{Supi: "imsi-208930000000001", AmData: amData},
+			expectedDetail: "Invalid servingPlmnId",
+		},
+		{
+			name: "Missing Authentication Method",
+			record: ProvisioningRecord{
+				Supi:                       "imsi-208930000000001",
+				AuthenticationSubscription: &models.AuthenticationSubscription{},
+			},
+			expectedDetail: "authenticationMethod is required",
+		},
+		{
+			name: "Duplicated Slice",
+			record: ProvisioningRecord{
+				Supi:          "imsi-208930000000001",
+				ServingPlmnId: "20893",
+				SmData: []models.SessionManagementSubscriptionData{
+					{SingleNssai: &models.Snssai{Sst: 1, Sd: "010203"}},
+					{SingleNssai: &models.Snssai{Sst: 1, Sd: "010203"}},
+				},
+			},
+			expectedDetail: "singleNssai 01010203 is given twice",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			detail := validateProvisioningRecord(&tc.record)
+			if tc.expectedDetail == "" {
+				require.Empty(t, detail)
+			} else {
+				require.Contains(t, detail, tc.expectedDetail)
+			}
+		})
+	}
+}
+
+func TestBulkUpsertsAdd(t *testing.T) {
+	record := ProvisioningRecord{Supi: "imsi-208930000000001", ServingPlmnId: "20893"}
+	identity := bson.M{"ueId": record.Supi, "servingPlmnId": record.ServingPlmnId}
+	smData := models.SessionManagementSubscriptionData{
+		SingleNssai:       &models.Snssai{Sst: 1},
+		DnnConfigurations: map[string]models.DnnConfiguration{"internet.mnc093": {}},
+	}
+
+	upserts := &bulkUpserts{}
+	upserts.add(3, identity, smDataFilter(record, smData.SingleNssai), map[string]interface{}{
+		"dnnConfigurations": escapeDnnConfigurations(smData).DnnConfigurations,
+	})
+
+	require.Equal(t, []int{3}, upserts.records)
+	require.Equal(t, bson.M{
+		"ueId":            record.Supi,
+		"servingPlmnId":   record.ServingPlmnId,
+		"singleNssai.sst": int32(1),
+		"singleNssai.sd":  bson.M{"$exists": false},
+	}, upserts.upserts[0].Filter)
+	require.Equal(t, record.Supi, upserts.upserts[0].Data["ueId"])
+	require.Contains(t, upserts.upserts[0].Data["dnnConfigurations"], "internet_mnc093")
+	require.NotContains(t, upserts.upserts[0].Data, "singleNssai.sst")
+}
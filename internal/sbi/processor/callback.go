@@ -3,23 +3,32 @@ package processor
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
 
 	"github.com/free5gc/openapi/models"
 	"github.com/free5gc/openapi/udr/DataRepository"
 	udr_context "github.com/free5gc/udr/internal/context"
 	"github.com/free5gc/udr/internal/logger"
+	udr_metrics "github.com/free5gc/udr/internal/metrics"
 	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/udr/pkg/factory"
 )
 
-var CurrentResourceUri string
+const dataChangeNotifyMaxAttempts = 3
+
+// dataChangeNotifyRetryInterval is doubled after each failed attempt
+var dataChangeNotifyRetryInterval = time.Second
 
-func PreHandleOnDataChangeNotify(ueId string, resourceId string, patchItems []models.PatchItem,
+// patchChanges describes the changes made by the patch items
+func patchChanges(patchItems []models.PatchItem,
 	origValue map[string]interface{}, newValue map[string]interface{},
-) {
-	notifyItems := []models.NotifyItem{}
+) []models.ChangeItem {
 	changes := []models.ChangeItem{}
-
 	for _, patchItem := range patchItems {
 		change := models.ChangeItem{
 			Op:        models.ChangeType(patchItem.Op),
@@ -30,102 +39,227 @@ func PreHandleOnDataChangeNotify(ueId string, resourceId string, patchItems []mo
 		}
 		changes = append(changes, change)
 	}
+	return changes
+}
 
-	notifyItem := models.NotifyItem{
-		ResourceId: resourceId,
-		Changes:    changes,
+// documentChanges describes the replacement or the removal, when newValue is nil, of the whole document
+func documentChanges(newValue map[string]interface{}) []models.ChangeItem {
+	change := models.ChangeItem{
+		Op:       models.ChangeType_REPLACE,
+		Path:     "",
+		NewValue: newValue,
+	}
+	if newValue == nil {
+		change.Op = models.ChangeType_REMOVE
 	}
+	return []models.ChangeItem{change}
+}
 
-	notifyItems = append(notifyItems, notifyItem)
+// NotifySubscribers sends the changes of the resource at resourcePath to the subscriptions of the UE monitoring it.
+// It is called once the write succeeded, the notifications are delivered in the background. A dry run
+// changes nothing and notifies nothing.
+func (p *Processor) NotifySubscribers(ctx context.Context, ueId string, resourcePath string,
+	changes []models.ChangeItem,
+) {
+	if IsDryRun(ctx) {
+		return
+	}
+	udrSelf := udr_context.GetSelf()
+	resourcePath = strings.TrimPrefix(resourcePath, factory.UdrDrResUriPrefix)
+	notifyItems := []models.NotifyItem{
+		{
+			ResourceId: udrSelf.GetIPv4GroupUri(udr_context.NUDR_DR) + resourcePath,
+			Changes:    changes,
+		},
+	}
 
-	go SendOnDataChangeNotify(ueId, notifyItems)
+	// The expired subscriptions are skipped until the sweeper removes them
+	now := time.Now()
+	udrSelf.RangeSubscriptionDataSubscriptions(
+		func(subscriptionId string, subscription *models.SubscriptionDataSubscriptions) bool {
+			if !udr_context.IsExpired(subscription.Expiry, now) &&
+				(subscription.UeId == "" || subscription.UeId == ueId) &&
+				isResourceMonitored(subscription.MonitoredResourceUris, resourcePath) {
+				go SendOnDataChangeNotify(subscriptionId, subscription, ueId, notifyItems)
+			}
+			return true
+		})
 }
 
-func PreHandlePolicyDataChangeNotification(ueId string, dataId string, value interface{}) {
+// isResourceMonitored reports whether the resource is, or is under, one of the monitored URIs. They are
+// compared by their path from the segment naming the data set, whatever the apiRoot and the API version.
+// A subscription without monitored URIs follows all the resources of its UE.
+func isResourceMonitored(monitoredResourceUris []string, resourcePath string) bool {
+	if len(monitoredResourceUris) == 0 {
+		return true
+	}
+	resourcePath = dataSetPath(resourcePath)
+	for _, monitoredResourceUri := range monitoredResourceUris {
+		monitoredUrl, err := url.Parse(monitoredResourceUri)
+		if err != nil {
+			continue
+		}
+		monitoredPath := strings.TrimSuffix(dataSetPath(monitoredUrl.Path), "/")
+		if resourcePath == monitoredPath || strings.HasPrefix(resourcePath, monitoredPath+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// dataSets are the first segments of the paths of the Nudr_DataRepository resources
+var dataSets = []string{"/subscription-data/", "/policy-data/", "/exposure-data/", "/application-data/"}
+
+// dataSetPath is the path from its segment naming the data set, e.g. /policy-data/ues/{ueId}/am-data of
+// /nudr-dr/v1/policy-data/ues/{ueId}/am-data, the path without the Nudr_DataRepository prefix when it names none
+func dataSetPath(path string) string {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	for _, dataSet := range dataSets {
+		if i := strings.Index(path+"/", dataSet); i >= 0 {
+			return path[i:]
+		}
+	}
+	return strings.TrimPrefix(path, factory.UdrDrResUriPrefix)
+}
+
+func PreHandlePolicyDataChangeNotification(ctx context.Context, ueId string, dataId string, value interface{}) {
+	if IsDryRun(ctx) {
+		return
+	}
 	policyDataChangeNotification := models.PolicyDataChangeNotification{}
 
 	if ueId != "" {
 		policyDataChangeNotification.UeId = ueId
 	}
 
+	var resourcePath string
 	switch v := value.(type) {
 	case models.AmPolicyData:
 		policyDataChangeNotification.AmPolicyData = &v
+		resourcePath = "/policy-data/ues/" + ueId + "/am-data"
 	case models.UePolicySet:
 		policyDataChangeNotification.UePolicySet = &v
+		resourcePath = "/policy-data/ues/" + ueId + "/ue-policy-set"
 	case models.SmPolicyData:
 		policyDataChangeNotification.SmPolicyData = &v
+		resourcePath = "/policy-data/ues/" + ueId + "/sm-data"
 	case models.UsageMonData:
 		policyDataChangeNotification.UsageMonId = dataId
 		policyDataChangeNotification.UsageMonData = &v
+		resourcePath = "/policy-data/ues/" + ueId + "/sm-data/" + dataId
 	case models.SponsorConnectivityData:
 		policyDataChangeNotification.SponsorId = dataId
 		policyDataChangeNotification.SponsorConnectivityData = &v
+		resourcePath = "/policy-data/sponsor-connectivity-data/" + dataId
 	case models.BdtData:
 		policyDataChangeNotification.BdtRefId = dataId
 		policyDataChangeNotification.BdtData = &v
+		resourcePath = "/policy-data/bdt-data/" + dataId
 	default:
 		return
 	}
 
-	go SendPolicyDataChangeNotification(policyDataChangeNotification)
+	go SendPolicyDataChangeNotification(policyDataChangeNotification, resourcePath)
 }
 
-func PreHandleInfluenceDataUpdateNotification(influenceId string, original, modified *models.TrafficInfluData) {
+// PreHandlePolicyDataDeletionNotification notifies the removal of the policy data at resourcePath, in
+// delResources
+func PreHandlePolicyDataDeletionNotification(ctx context.Context, ueId string, resourcePath string) {
+	if IsDryRun(ctx) {
+		return
+	}
+	policyDataChangeNotification := models.PolicyDataChangeNotification{
+		UeId:         ueId,
+		DelResources: []string{udr_context.GetSelf().GetIPv4GroupUri(udr_context.NUDR_DR) + resourcePath},
+	}
+	go SendPolicyDataChangeNotification(policyDataChangeNotification, resourcePath)
+}
+
+func PreHandleInfluenceDataUpdateNotification(ctx context.Context, influenceId string,
+	original, modified *models.TrafficInfluData,
+) {
+	if IsDryRun(ctx) {
+		return
+	}
 	resUri := fmt.Sprintf("%s/application-data/influenceData/%s",
 		udr_context.GetSelf().GetIPv4GroupUri(udr_context.NUDR_DR), influenceId)
 
 	go SendInfluenceDataUpdateNotification(resUri, original, modified)
 }
 
-func SendOnDataChangeNotify(ueId string, notifyItems []models.NotifyItem) {
-	defer func() {
-		if p := recover(); p != nil {
-			// Print stack for panic to log. Fatalf() will let program exit.
-			logger.HttpLog.Fatalf("panic: %v\n%s", p, string(debug.Stack()))
-		}
-	}()
+// recoverNotificationPanic is deferred by the notification senders, which run in their own goroutine,
+// so that a bad subscription cannot crash the UDR
+func recoverNotificationPanic(notification string) {
+	if p := recover(); p != nil {
+		incidentId := uuid.New().String()
+		logger.SBILog.Errorf("panic [incident %s] sending %s: %v\n%s", incidentId, notification, p,
+			string(debug.Stack()))
+		udr_metrics.IncrPanicCounter(udr_metrics.PANIC_SOURCE_NOTIFICATION)
+	}
+}
+
+// SendOnDataChangeNotify retries the delivery a few times before dropping the subscription,
+// whose callback is then considered gone
+func SendOnDataChangeNotify(subscriptionId string, subscription *models.SubscriptionDataSubscriptions,
+	ueId string, notifyItems []models.NotifyItem,
+) {
+	defer recoverNotificationPanic("data change notification")
 
-	udrSelf := udr_context.GetSelf()
 	configuration := DataRepository.NewConfiguration()
+	configuration.SetHTTPClient(util.SbiClient())
 	client := DataRepository.NewAPIClient(configuration)
 
-	for _, subscriptionDataSubscription := range udrSelf.SubscriptionDataSubscriptions {
-		if ueId == subscriptionDataSubscription.UeId {
-			onDataChangeNotifyUrl := subscriptionDataSubscription.CallbackReference
-
-			dataChangeReq := DataRepository.SubscriptionDataSubscriptionsOnDataChangePostRequest{}
-			dataChangeReq.DataChangeNotify.UeId = ueId
-			dataChangeReq.DataChangeNotify.OriginalCallbackReference = []string{
-				subscriptionDataSubscription.OriginalCallbackReference,
-			}
-			dataChangeReq.DataChangeNotify.NotifyItems = notifyItems
-			rsp, err := client.SubsToNotifyCollectionApi.SubscriptionDataSubscriptionsOnDataChangePost(
-				context.TODO(), onDataChangeNotifyUrl, &dataChangeReq)
+	dataChangeNotify := models.DataChangeNotify{
+		UeId:        ueId,
+		NotifyItems: notifyItems,
+	}
+	if subscription.OriginalCallbackReference != "" {
+		dataChangeNotify.OriginalCallbackReference = []string{subscription.OriginalCallbackReference}
+	}
+	dataChangeReq := DataRepository.SubscriptionDataSubscriptionsOnDataChangePostRequest{
+		DataChangeNotify: &dataChangeNotify,
+	}
 
-			if err != nil {
-				logger.SBILog.Errorln(err.Error())
-			} else if rsp == nil {
-				logger.SBILog.Errorln("Empty SubscriptionDataSubscriptionsOnDataChangePost response")
-			}
+	var err error
+	retryInterval := dataChangeNotifyRetryInterval
+	for attempt := 1; attempt <= dataChangeNotifyMaxAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(retryInterval)
+			retryInterval *= 2
 		}
+		_, err = client.SubsToNotifyCollectionApi.SubscriptionDataSubscriptionsOnDataChangePost(
+			context.TODO(), subscription.CallbackReference, &dataChangeReq)
+		if err == nil {
+			return
+		}
+		logger.SBILog.Warnf("SubscriptionDataSubscriptionsOnDataChangePost to %s attempt %d/%d failed: %+v",
+			subscription.CallbackReference, attempt, dataChangeNotifyMaxAttempts, err)
+	}
+
+	if udr_context.GetSelf().RemoveSubscriptionDataSubscription(subscriptionId) {
+		logger.SBILog.Errorf("Remove subscription %s, its callback %s did not accept the notification: %+v",
+			subscriptionId, subscription.CallbackReference, err)
 	}
 }
 
-func SendPolicyDataChangeNotification(policyDataChangeNotification models.PolicyDataChangeNotification) {
-	defer func() {
-		if p := recover(); p != nil {
-			// Print stack for panic to log. Fatalf() will let program exit.
-			logger.HttpLog.Fatalf("panic: %v\n%s", p, string(debug.Stack()))
-		}
-	}()
+// SendPolicyDataChangeNotification notifies the subscriptions monitoring the policy data at resourcePath
+func SendPolicyDataChangeNotification(policyDataChangeNotification models.PolicyDataChangeNotification,
+	resourcePath string,
+) {
+	defer recoverNotificationPanic("policy data change notification")
 
 	udrSelf := udr_context.GetSelf()
 
 	for _, policyDataSubscription := range udrSelf.PolicyDataSubscriptions {
+		if !isResourceMonitored(policyDataSubscription.MonitoredResourceUris, resourcePath) {
+			continue
+		}
 		policyDataChangeNotificationUrl := policyDataSubscription.NotificationUri
 
 		configuration := DataRepository.NewConfiguration()
+		configuration.SetHTTPClient(util.SbiClient())
 		client := DataRepository.NewAPIClient(configuration)
 
 		req := DataRepository.CreateIndividualPolicyDataSubscriptionPolicyDataChangeNotificationPostRequest{
@@ -147,9 +281,12 @@ func SendPolicyDataChangeNotification(policyDataChangeNotification models.Policy
 }
 
 func SendInfluenceDataUpdateNotification(resUri string, original, modified *models.TrafficInfluData) {
+	defer recoverNotificationPanic("influence data update notification")
+
 	udrSelf := udr_context.GetSelf()
 
 	configuration := DataRepository.NewConfiguration()
+	configuration.SetHTTPClient(util.SbiClient())
 	client := DataRepository.NewAPIClient(configuration)
 
 	var trafficInfluDataNotif models.TrafficInfluDataNotif
@@ -0,0 +1,134 @@
+package processor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+
+	"github.com/free5gc/openapi/models"
+	udr_context "github.com/free5gc/udr/internal/context"
+	"github.com/free5gc/udr/pkg/factory"
+)
+
+func newCallbackServer(t *testing.T, handler http.HandlerFunc) string {
+	server := httptest.NewServer(h2c.NewHandler(handler, &http2.Server{}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestNotifySubscribers(t *testing.T) {
+	dataChangeNotifyRetryInterval = 10 * time.Millisecond
+	udrSelf := udr_context.GetSelf()
+	udrSelf.SubscriptionDataSubscriptions = make(map[string]*models.SubscriptionDataSubscriptions)
+	p := &Processor{}
+
+	var flakyCalls, goneCalls, otherCalls atomic.Int32
+	flakyUri := newCallbackServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if flakyCalls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	goneUri := newCallbackServer(t, func(w http.ResponseWriter, r *http.Request) {
+		goneCalls.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	})
+	otherUri := newCallbackServer(t, func(w http.ResponseWriter, r *http.Request) {
+		otherCalls.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	ueId := "imsi-208930000000001"
+	contextDataUri := "http://127.0.0.4:8000" + factory.UdrDrResUriPrefix + "/subscription-data/" + ueId + "/context-data"
+	flakyId := udrSelf.AddSubscriptionDataSubscription(&models.SubscriptionDataSubscriptions{
+		UeId:                  ueId,
+		CallbackReference:     flakyUri,
+		MonitoredResourceUris: []string{contextDataUri},
+	})
+	goneId := udrSelf.AddSubscriptionDataSubscription(&models.SubscriptionDataSubscriptions{
+		UeId:              ueId,
+		CallbackReference: goneUri,
+	})
+	otherId := udrSelf.AddSubscriptionDataSubscription(&models.SubscriptionDataSubscriptions{
+		UeId:                  ueId,
+		CallbackReference:     otherUri,
+		MonitoredResourceUris: []string{contextDataUri + "/smsf-3gpp-access"},
+	})
+	expiry := time.Now().Add(-time.Minute)
+	udrSelf.AddSubscriptionDataSubscription(&models.SubscriptionDataSubscriptions{
+		UeId:              ueId,
+		CallbackReference: otherUri,
+		Expiry:            &expiry,
+	})
+
+	p.NotifySubscribers(context.Background(), ueId,
+		factory.UdrDrResUriPrefix+"/subscription-data/"+ueId+"/context-data/amf-3gpp-access",
+		documentChanges(map[string]interface{}{"amfInstanceId": "amf"}))
+
+	require.Eventually(t, func() bool {
+		return flakyCalls.Load() == 2 && goneCalls.Load() == dataChangeNotifyMaxAttempts
+	}, 2*time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool {
+		dropped := true
+		udrSelf.RangeSubscriptionDataSubscriptions(
+			func(subscriptionId string, _ *models.SubscriptionDataSubscriptions) bool {
+				dropped = subscriptionId != goneId
+				return dropped
+			})
+		return dropped
+	}, time.Second, 10*time.Millisecond)
+	require.True(t, udrSelf.RemoveSubscriptionDataSubscription(flakyId))
+	require.True(t, udrSelf.RemoveSubscriptionDataSubscription(otherId))
+	require.Equal(t, int32(0), otherCalls.Load())
+	require.Equal(t, 1, udrSelf.RemoveExpiredSubscriptionDataSubscriptions(time.Now()))
+}
+
+func TestIsResourceMonitored(t *testing.T) {
+	ueId := "imsi-208930000000001"
+	amDataPath := factory.UdrDrResUriPrefix + "/policy-data/ues/" + ueId + "/am-data"
+	testCases := []struct {
+		name         string
+		monitored    []string
+		resourcePath string
+		want         bool
+	}{
+		{"No Monitored URI", nil, amDataPath, true},
+		{"Absolute URI", []string{"http://127.0.0.4:8000" + amDataPath}, amDataPath, true},
+		{"Prefixed Path", []string{amDataPath}, amDataPath, true},
+		{"Other API Version", []string{"http://udr:8000/nudr-dr/v1/policy-data/ues/" + ueId + "/am-data"},
+			amDataPath, true},
+		{"Data Set Path", []string{"/policy-data/ues/" + ueId}, amDataPath, true},
+		{"Relative Path", []string{"policy-data/ues/" + ueId + "/am-data/"}, amDataPath, true},
+		{"Unprefixed Resource", []string{"http://127.0.0.4:8000" + amDataPath},
+			"/policy-data/ues/" + ueId + "/am-data", true},
+		{"Under The Resource", []string{"http://127.0.0.4:8000" + amDataPath}, amDataPath + "/limit1", true},
+		{"Other Resource", []string{"http://127.0.0.4:8000" + amDataPath},
+			factory.UdrDrResUriPrefix + "/policy-data/ues/" + ueId + "/sm-data", false},
+		{"Same Prefix", []string{amDataPath}, amDataPath + "s", false},
+		{"Other UE", []string{amDataPath}, factory.UdrDrResUriPrefix + "/policy-data/ues/imsi-208930000000002/am-data",
+			false},
+		{"No Data Set", []string{"http://udr.free5gc.org/am-data"}, amDataPath, false},
+		{"Subscription Data", []string{"http://127.0.0.4:8000/nudr-dr/v1/subscription-data/" + ueId + "/context-data"},
+			factory.UdrDrResUriPrefix + "/subscription-data/" + ueId + "/context-data/amf-3gpp-access", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, isResourceMonitored(tc.monitored, tc.resourcePath))
+		})
+	}
+}
+
+func TestSendOnDataChangeNotifyRecoversPanic(t *testing.T) {
+	require.NotPanics(t, func() {
+		SendOnDataChangeNotify("1", nil, "imsi-208930000000001", nil)
+	})
+}
@@ -10,93 +10,28 @@
 package processor
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
 	"net/http"
-	"reflect"
+	"slices"
 	"strconv"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 
-	"github.com/free5gc/openapi"
 	"github.com/free5gc/openapi/models"
 	udr_context "github.com/free5gc/udr/internal/context"
-	db "github.com/free5gc/udr/internal/database"
-	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/database"
 	"github.com/free5gc/udr/internal/util"
-	"github.com/free5gc/util/metrics/sbi"
-	"github.com/free5gc/util/mongoapi"
 )
 
-func (p *Processor) DeleteApplicationDataIndividualPfdFromDBProcedure(c *gin.Context, appID string) {
-	filter := bson.M{"applicationId": appID}
-	p.DeleteDataFromDB(db.APPDATA_PFD_DB_COLLECTION_NAME, filter)
-	c.Status(http.StatusNoContent)
-}
-
-func (p *Processor) GetApplicationDataIndividualPfdFromDBProcedure(c *gin.Context, appID string) {
-	filter := bson.M{"applicationId": appID}
-	data, pd := p.GetDataFromDB(db.APPDATA_PFD_DB_COLLECTION_NAME, filter)
-	if pd != nil {
-		logger.DataRepoLog.Errorf("getApplicationDataIndividualPfdFromDB err: %s", pd.Detail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
-	}
-	c.JSON(http.StatusOK, data)
-}
-
-func (p *Processor) PutApplicationDataIndividualPfdToDBProcedure(
-	c *gin.Context, appID string, pfdDataForApp *models.PfdDataForApp,
-) {
-	filter := bson.M{"applicationId": appID}
-	data := util.ToBsonM(*pfdDataForApp)
-
-	existed, err := mongoapi.RestfulAPIPutOne(db.APPDATA_PFD_DB_COLLECTION_NAME, filter, data)
-	if err != nil {
-		logger.DataRepoLog.Errorf("putApplicationDataIndividualPfdToDB err: %+v", err)
-		statusCode := http.StatusInternalServerError
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, http.StatusText(statusCode))
-		c.JSON(statusCode, nil)
-		return
-	}
-
-	if existed {
-		c.JSON(http.StatusOK, data)
-	}
-	c.JSON(http.StatusCreated, data)
-}
-
-func (p *Processor) GetApplicationDataPfdsFromDBProcedure(c *gin.Context, pfdsAppIDs []string) {
-	filter := bson.M{}
-
-	var matchedPfds []map[string]interface{}
-	if len(pfdsAppIDs) == 0 {
-		var err error
-		matchedPfds, err = mongoapi.RestfulAPIGetMany(db.APPDATA_PFD_DB_COLLECTION_NAME, filter)
-		if err != nil {
-			logger.DataRepoLog.Errorf("getApplicationDataPfdsFromDB err: %+v", err)
-			c.JSON(http.StatusOK, nil)
-			return
-		}
-	} else {
-		for _, v := range pfdsAppIDs {
-			filter := bson.M{"applicationId": v}
-			data, pd := p.GetDataFromDB(db.APPDATA_PFD_DB_COLLECTION_NAME, filter)
-			if pd == nil {
-				matchedPfds = append(matchedPfds, data)
-			}
-		}
-	}
-	c.JSON(http.StatusOK, matchedPfds)
-}
-
 func (p *Processor) PolicyDataBdtDataBdtReferenceIdDeleteProcedure(
 	c *gin.Context, collName string, bdtReferenceId string,
 ) {
 	filter := bson.M{"bdtReferenceId": bdtReferenceId}
-	p.DeleteDataFromDB(collName, filter)
+	p.DeleteDataFromDB(c, collName, filter)
 	c.Status(http.StatusNoContent)
 }
 
@@ -104,14 +39,13 @@ func (p *Processor) PolicyDataBdtDataBdtReferenceIdGetProcedure(
 	c *gin.Context, collName string, bdtReferenceId string,
 ) {
 	filter := bson.M{"bdtReferenceId": bdtReferenceId}
-	data, pd := p.GetDataFromDB(collName, filter)
+	data, etag, pd := p.GetVersionedDataFromDB(c, collName, filter)
 	if pd != nil {
-		logger.DataRepoLog.Errorf("PolicyDataBdtDataBdtReferenceIdGetProcedure err: %s", pd.Detail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		dataRepoLog(c).Errorf("PolicyDataBdtDataBdtReferenceIdGetProcedure err: %s", pd.Detail)
+		fail(c, problemOf(pd))
 		return
 	}
-	c.JSON(http.StatusOK, data)
+	respondVersioned(c, etag, data)
 }
 
 func (p *Processor) PolicyDataBdtDataBdtReferenceIdPutProcedure(
@@ -121,26 +55,26 @@ func (p *Processor) PolicyDataBdtDataBdtReferenceIdPutProcedure(
 	putData["bdtReferenceId"] = bdtReferenceId
 	filter := bson.M{"bdtReferenceId": bdtReferenceId}
 
-	existed, err := mongoapi.RestfulAPIPutOne(collName, filter, putData)
+	existed, _, err := p.PutVersionedDataToDB(c, collName, filter, putData, ifMatchOf(c))
 	if err != nil {
-		logger.DataRepoLog.Errorf("putApplicationDataIndividualPfdToDB err: %+v", err)
-		pd := util.ProblemDetailsUpspecified(err.Error())
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		dataRepoLog(c).Errorf("putApplicationDataIndividualPfdToDB err: %+v", err)
+		if !abortVersionedWrite(c, err) {
+			fail(c, &Error{Category: ErrorNotAllowed, Cause: "UNSPECIFIED", Err: err})
+		}
 		return
 	}
 
 	if existed {
-		PreHandlePolicyDataChangeNotification("", bdtReferenceId, bdtData)
+		PreHandlePolicyDataChangeNotification(c, "", bdtReferenceId, bdtData)
 	}
 	c.JSON(http.StatusCreated, putData)
 }
 
 func (p *Processor) PolicyDataBdtDataGetProcedure(c *gin.Context, collName string) {
 	filter := bson.M{}
-	bdtDataArray, err := mongoapi.RestfulAPIGetMany(collName, filter)
+	bdtDataArray, err := p.GetManyDataFromDB(c, collName, filter)
 	if err != nil {
-		logger.DataRepoLog.Errorf("PolicyDataBdtDataGetProcedure err: %+v", err)
+		dataRepoLog(c).Errorf("PolicyDataBdtDataGetProcedure err: %+v", err)
 		c.JSON(http.StatusOK, nil)
 	}
 	c.JSON(http.StatusOK, bdtDataArray)
@@ -148,28 +82,26 @@ func (p *Processor) PolicyDataBdtDataGetProcedure(c *gin.Context, collName strin
 
 func (p *Processor) PolicyDataPlmnsPlmnIdUePolicySetGetProcedure(c *gin.Context, collName string, plmnId string) {
 	filter := bson.M{"plmnId": plmnId}
-	data, pd := p.GetDataFromDB(collName, filter)
+	data, etag, pd := p.GetVersionedDataFromDB(c, collName, filter)
 	if pd != nil {
-		logger.DataRepoLog.Errorf("PolicyDataPlmnsPlmnIdUePolicySetGetProcedure err: %s", pd.Detail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		dataRepoLog(c).Errorf("PolicyDataPlmnsPlmnIdUePolicySetGetProcedure err: %s", pd.Detail)
+		fail(c, problemOf(pd))
 		return
 	}
-	c.JSON(http.StatusOK, data)
+	respondVersioned(c, etag, data)
 }
 
 func (p *Processor) PolicyDataSponsorConnectivityDataSponsorIdGetProcedure(c *gin.Context, collName string,
 	sponsorId string,
 ) {
 	filter := bson.M{"sponsorId": sponsorId}
-	data, pd := p.GetDataFromDB(collName, filter)
+	data, etag, pd := p.GetVersionedDataFromDB(c, collName, filter)
 	if pd != nil {
-		logger.DataRepoLog.Errorf("PolicyDataSponsorConnectivityDataSponsorIdGetProcedure err: %s", pd.Detail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		dataRepoLog(c).Errorf("PolicyDataSponsorConnectivityDataSponsorIdGetProcedure err: %s", pd.Detail)
+		fail(c, problemOf(pd))
 		return
 	}
-	c.JSON(http.StatusOK, data)
+	respondVersioned(c, etag, data)
 }
 
 func (p *Processor) PolicyDataSubsToNotifyPostProcedure(
@@ -194,9 +126,8 @@ func (p *Processor) PolicyDataSubsToNotifySubsIdDeleteProcedure(c *gin.Context,
 	udrSelf := udr_context.GetSelf()
 	_, ok := udrSelf.PolicyDataSubscriptions[subsId]
 	if !ok {
-		pd := util.ProblemDetailsNotFound("SUBSCRIPTION_NOT_FOUND")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		fail(c, &Error{Category: ErrorNotFound, Cause: "SUBSCRIPTION_NOT_FOUND"})
+		return
 	}
 	delete(udrSelf.PolicyDataSubscriptions, subsId)
 	c.Status(http.StatusNoContent)
@@ -208,12 +139,13 @@ func (p *Processor) PolicyDataSubsToNotifySubsIdPutProcedure(c *gin.Context, sub
 	udrSelf := udr_context.GetSelf()
 	_, ok := udrSelf.PolicyDataSubscriptions[subsId]
 	if !ok {
-		pd := util.ProblemDetailsNotFound("SUBSCRIPTION_NOT_FOUND")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		fail(c, &Error{Category: ErrorNotFound, Cause: "SUBSCRIPTION_NOT_FOUND"})
+		return
 	}
 
-	udrSelf.PolicyDataSubscriptions[subsId] = &policyDataSubscription
+	if !dryRunSubscription(c, &policyDataSubscription) {
+		udrSelf.PolicyDataSubscriptions[subsId] = &policyDataSubscription
+	}
 	c.JSON(http.StatusOK, policyDataSubscription)
 }
 
@@ -221,27 +153,90 @@ func (p *Processor) PolicyDataUesUeIdAmDataGetProcedure(c *gin.Context, collName
 	ueId string,
 ) {
 	filter := bson.M{"ueId": ueId}
-	data, pd := p.GetDataFromDB(collName, filter)
+	data, etag, pd := p.GetVersionedDataFromDB(c, collName, filter)
 	if pd != nil {
-		logger.DataRepoLog.Errorf("PolicyDataUesUeIdAmDataGetProcedure err: %s", pd.Detail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		dataRepoLog(c).Errorf("PolicyDataUesUeIdAmDataGetProcedure err: %s", pd.Detail)
+		fail(c, problemOf(pd).notFoundCause("USER_NOT_FOUND"))
+		return
+	}
+	respondVersioned(c, etag, data)
+}
+
+// PolicyDataUesUeIdAmDataPutProcedure stores the access and mobility policy data of the UE in place of the
+// previous one, If-Match is honored
+func (p *Processor) PolicyDataUesUeIdAmDataPutProcedure(c *gin.Context, collName string, ueId string,
+	amPolicyData models.AmPolicyData,
+) {
+	putData := util.ToBsonM(amPolicyData)
+	putData["ueId"] = ueId
+	filter := bson.M{"ueId": ueId}
+
+	existed, etag, err := p.ReplaceVersionedDataToDB(c, collName, filter, putData, ifMatchOf(c), nil)
+	if err != nil {
+		dataRepoLog(c).Errorf("PolicyDataUesUeIdAmDataPutProcedure err: %+v", err)
+		if abortVersionedWrite(c, err) {
+			return
+		}
+		fail(c, err)
+		return
 	}
-	c.JSON(http.StatusOK, data)
+	PreHandlePolicyDataChangeNotification(c, ueId, "", amPolicyData)
+	setETag(c, etag)
+
+	if existed {
+		c.JSON(http.StatusOK, amPolicyData)
+		return
+	}
+	c.JSON(http.StatusCreated, amPolicyData)
+}
+
+// PolicyDataUesUeIdAmDataDeleteProcedure removes the access and mobility policy data of the UE. The deletion
+// is idempotent, 204 is answered as well when there is none, only its actual removal is notified.
+func (p *Processor) PolicyDataUesUeIdAmDataDeleteProcedure(c *gin.Context, collName string, ueId string) {
+	filter := bson.M{"ueId": ueId}
+	deleted, err := p.DeleteOne(c, collName, filter)
+	if err != nil {
+		dataRepoLog(c).Errorf("PolicyDataUesUeIdAmDataDeleteProcedure err: %+v", err)
+		fail(c, err)
+		return
+	}
+	if deleted {
+		PreHandlePolicyDataDeletionNotification(c, ueId, "/policy-data/ues/"+ueId+"/am-data")
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (p *Processor) PolicyDataUesUeIdAmDataPatchProcedure(c *gin.Context, collName string,
+	ueId string, patch PatchDocument,
+) {
+	filter := bson.M{"ueId": ueId}
+	_, newValue, err := p.patchDataToDBAndNotify(c, collName, ueId, patch, filter)
+	if err != nil {
+		dataRepoLog(c).Errorf("PolicyDataUesUeIdAmDataPatchProcedure err: %+v", err)
+		fail(c, patchFailure(err, &Error{Category: ErrorNotAllowed}))
+		return
+	}
+
+	var amPolicyData models.AmPolicyData
+	if err := json.Unmarshal(util.MapToByte(newValue), &amPolicyData); err != nil {
+		dataRepoLog(c).Warnln(err)
+	}
+	PreHandlePolicyDataChangeNotification(c, ueId, "", amPolicyData)
+	c.Status(http.StatusNoContent)
 }
 
 func (p *Processor) PolicyDataUesUeIdOperatorSpecificDataGetProcedure(c *gin.Context, collName string,
 	ueId string,
 ) {
 	filter := bson.M{"ueId": ueId}
-	data, pd := p.GetDataFromDB(collName, filter)
+	data, etag, pd := p.GetVersionedDataFromDB(c, collName, filter)
 	if pd != nil {
-		logger.DataRepoLog.Errorf("PolicyDataUesUeIdOperatorSpecificDataGetProcedure err: %s", pd.Detail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		dataRepoLog(c).Errorf("PolicyDataUesUeIdOperatorSpecificDataGetProcedure err: %s", pd.Detail)
+		fail(c, problemOf(pd))
+		return
 	}
 	operatorSpecificDataContainerMap := data["operatorSpecificDataContainerMap"]
-	c.JSON(http.StatusOK, operatorSpecificDataContainerMap)
+	respondVersioned(c, etag, operatorSpecificDataContainerMap)
 }
 
 func (p *Processor) PolicyDataUesUeIdOperatorSpecificDataPatchProcedure(c *gin.Context, collName string, ueId string,
@@ -251,18 +246,16 @@ func (p *Processor) PolicyDataUesUeIdOperatorSpecificDataPatchProcedure(c *gin.C
 
 	patchJSON, err := json.Marshal(patchItem)
 	if err != nil {
-		logger.DataRepoLog.Errorf("PolicyDataUesUeIdOperatorSpecificDataPatchProcedure err: %+v", err)
-		pd := util.ProblemDetailsModifyNotAllowed("")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		dataRepoLog(c).Errorf("PolicyDataUesUeIdOperatorSpecificDataPatchProcedure err: %+v", err)
+		fail(c, &Error{Category: ErrorNotAllowed})
+		return
 	}
 
-	if err := mongoapi.RestfulAPIJSONPatchExtend(collName, filter, patchJSON,
-		"operatorSpecificDataContainerMap"); err != nil {
-		logger.DataRepoLog.Errorf("PolicyDataUesUeIdOperatorSpecificDataPatchProcedure err: %+v", err)
-		pd := util.ProblemDetailsModifyNotAllowed("")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+	if err := p.PatchDataFieldToDB(c, collName, filter,
+		"operatorSpecificDataContainerMap", patchJSON); err != nil {
+		dataRepoLog(c).Errorf("PolicyDataUesUeIdOperatorSpecificDataPatchProcedure err: %+v", err)
+		fail(c, patchFailure(err, &Error{Category: ErrorNotAllowed}))
+		return
 	}
 	c.Status(http.StatusNoContent)
 }
@@ -275,172 +268,111 @@ func (p *Processor) PolicyDataUesUeIdOperatorSpecificDataPutProcedure(c *gin.Con
 	putData := map[string]interface{}{"operatorSpecificDataContainerMap": OperatorSpecificDataContainer}
 	putData["ueId"] = ueId
 
-	_, err := mongoapi.RestfulAPIPutOne(collName, filter, putData)
+	_, _, err := p.PutVersionedDataToDB(c, collName, filter, putData, ifMatchOf(c))
 	if err != nil {
-		logger.DataRepoLog.Errorf("PolicyDataUesUeIdOperatorSpecificDataPutProcedure err: %+v", err)
+		dataRepoLog(c).Errorf("PolicyDataUesUeIdOperatorSpecificDataPutProcedure err: %+v", err)
+		if !abortVersionedWrite(c, err) {
+			fail(c, err)
+		}
+		return
 	}
 	c.Status(http.StatusOK)
 }
 
+// PolicyDataUesUeIdSmDataGetProcedure answers the SM policy data of the UE, only the S-NSSAI and the DNN asked
+// for when snssai or dnn is given
 func (p *Processor) PolicyDataUesUeIdSmDataGetProcedure(
-	c *gin.Context, collName string, ueId string, snssai models.Snssai,
+	c *gin.Context, collName string, ueId string, snssai *models.Snssai,
 	dnn string,
 ) {
-	filter := bson.M{"ueId": ueId}
-
-	smPolicyData, pd := p.GetDataFromDBWithArg(collName, filter, mongoapi.COLLATION_STRENGTH_IGNORE_CASE)
-	if pd != nil {
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
-		return
+	smPolicyData, err := p.smPolicyDataOf(c, collName, ueId)
+	if err == nil && !filterSmPolicyData(smPolicyData, snssai, dnn) {
+		err = &Error{Category: ErrorNotFound}
 	}
-
-	hex_snssai := util.SnssaiModelsToHex(snssai)
-	found := false
-	smPolicySnssaiDatas, ok := smPolicyData["smPolicySnssaiData"].(map[string]interface{})
-	if !ok {
-		pd := util.ProblemDetailsNotFound("DATA_NOT_FOUND")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
-		return
-	}
-	for cmpSnssai, v := range smPolicySnssaiDatas {
-		if !strings.EqualFold(cmpSnssai, hex_snssai) {
-			continue
-		}
-		smPolicySnssaiData, ok := v.(map[string]interface{})
-		if !ok {
-			break
-		}
-		smPolicyDnnDatas, ok := smPolicySnssaiData["smPolicyDnnData"].(map[string]interface{})
-		if !ok {
-			break
-		}
-
-		for cmpDnn := range smPolicyDnnDatas {
-			if strings.EqualFold(cmpDnn, util.EscapeDnn(dnn)) {
-				found = true
-				break
-			}
-		}
-
-		if found {
-			break
-		}
-	}
-	if !found {
-		pd := util.ProblemDetailsNotFound("DATA_NOT_FOUND")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
-		return
-	}
-
-	var smPolicyDataResp models.SmPolicyData
-	err := json.Unmarshal(util.MapToByte(smPolicyData), &smPolicyDataResp)
-	if err != nil {
-		logger.DataRepoLog.Warnln(err)
-	}
-	tmpSmPolicySnssaiData := make(map[string]models.SmPolicySnssaiData)
-	for snssai, snssaiData := range smPolicyDataResp.SmPolicySnssaiData {
-		tmpSmPolicyDnnData := make(map[string]models.SmPolicyDnnData)
-		for escapedDnn, dnnData := range snssaiData.SmPolicyDnnData {
-			dnn := util.UnescapeDnn(escapedDnn)
-			tmpSmPolicyDnnData[dnn] = dnnData
-		}
-		snssaiData.SmPolicyDnnData = tmpSmPolicyDnnData
-		tmpSmPolicySnssaiData[snssai] = snssaiData
-	}
-	smPolicyDataResp.SmPolicySnssaiData = tmpSmPolicySnssaiData
-	filter = bson.M{"ueId": ueId}
-	usageMonDataMapArray, err := mongoapi.RestfulAPIGetMany("policyData.ues.smData.usageMonData", filter)
 	if err != nil {
-		logger.DataRepoLog.Errorf("PolicyDataUesUeIdSmDataGetProcedure err: %+v", err)
-	}
-
-	if !reflect.DeepEqual(usageMonDataMapArray, []map[string]interface{}{}) {
-		var usageMonDataArray []models.UsageMonData
-		if err := json.Unmarshal(util.MapArrayToByte(usageMonDataMapArray), &usageMonDataArray); err != nil {
-			logger.DataRepoLog.Warnln(err)
-		}
-		smPolicyDataResp.UmData = make(map[string]models.UsageMonData)
-		for _, element := range usageMonDataArray {
-			smPolicyDataResp.UmData[element.LimitId] = element
-		}
+		dataRepoLog(c).Errorf("PolicyDataUesUeIdSmDataGetProcedure err: %+v", err)
+		fail(c, err)
+		return
 	}
-
-	c.JSON(http.StatusOK, smPolicyDataResp)
+	respondHashed(c, smPolicyData)
 }
 
+// PolicyDataUesUeIdSmDataPatchProcedure applies the merge patch to the SM policy data of the UE. Its fields are
+// set and unset by their path with a findOneAndUpdate, one on the document of the UE and one per limitId of
+// umData on its usage monitoring data, so that the concurrent patches of the other fields are not lost. The
+// document of the UE is matched regardless of case as it is read, and a umData entry not stored yet is
+// inserted. The writes are a transaction, the SM policy data answered is the patched document along with the
+// usage monitoring data read within it.
 func (p *Processor) PolicyDataUesUeIdSmDataPatchProcedure(c *gin.Context, collName string, ueId string,
-	UsageMonData map[string]models.UsageMonData,
+	patch PatchDocument,
 ) {
-	filter := bson.M{"ueId": ueId}
-
-	successAll := true
-	for k, usageMonData := range UsageMonData {
-		limitId := k
-		filterTmp := bson.M{"ueId": ueId, "limitId": limitId}
-		if err := mongoapi.RestfulAPIMergePatch(collName, filterTmp, util.ToBsonM(usageMonData)); err != nil {
-			successAll = false
-		} else {
-			var usageMonData models.UsageMonData
-			usageMonDataBsonM, pd := p.GetDataFromDB(collName, filter)
-			if pd != nil && pd.Status == http.StatusInternalServerError {
-				logger.DataRepoLog.Errorf("PolicyDataUesUeIdSmDataPatchProcedure err: %s", pd.Detail)
-				c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-				c.JSON(int(pd.Status), pd)
-				return
-			}
-			if err := json.Unmarshal(util.MapToByte(usageMonDataBsonM), &usageMonData); err != nil {
-				logger.DataRepoLog.Warnln(err)
-			}
-			PreHandlePolicyDataChangeNotification(ueId, limitId, usageMonData)
-		}
+	update, err := decodeSmPolicyDataPatch(patch.MergePatch)
+	if err != nil {
+		dataRepoLog(c).Errorf("PolicyDataUesUeIdSmDataPatchProcedure err: %+v", err)
+		fail(c, err)
+		return
 	}
 
-	if successAll {
-		smPolicyDataBsonM, pd := p.GetDataFromDB(collName, filter)
-		if pd != nil {
-			logger.DataRepoLog.Errorf("PolicyDataUesUeIdSmDataPatchProcedure err: %s", pd.Detail)
-			c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-			c.JSON(int(pd.Status), pd)
-			return
+	limitIds := slices.Sorted(maps.Keys(update.umData))
+	var smPolicyData *models.SmPolicyData
+	_, err = p.WithTransaction(c, func(ctx context.Context) error {
+		data, updateErr := p.PatchOne(ctx, collName, bson.M{"ueId": ueId},
+			database.Update{Set: update.set, Unset: update.unset, CaseInsensitive: true})
+		if updateErr != nil {
+			return smPolicyDataNotFound(updateErr, "USER_NOT_FOUND", "")
 		}
-		var smPolicyData models.SmPolicyData
-		if err := json.Unmarshal(util.MapToByte(smPolicyDataBsonM), &smPolicyData); err != nil {
-			logger.DataRepoLog.Warnln(err)
+		for _, limitId := range limitIds {
+			filter := bson.M{"ueId": ueId, "limitId": limitId}
+			umDataUpdate := update.umData[limitId]
+			if umDataUpdate == nil {
+				updateErr = p.DeleteOneDataFromDB(ctx, usageMonDataCollName, filter)
+			} else {
+				// The usageMonId of the inserted ones is their limitId, as the PUT of the usage monitoring data stores
+				umDataUpdate.set["usageMonId"] = limitId
+				_, updateErr = p.PatchOne(ctx, usageMonDataCollName, filter,
+					database.Update{Set: umDataUpdate.set, Unset: umDataUpdate.unset, Upsert: true})
+			}
+			if updateErr != nil {
+				return smPolicyDataNotFound(updateErr, "", "/umData/"+limitId)
+			}
 		}
-
-		collName := "policyData.ues.smData.usageMonData"
-		filter := bson.M{"ueId": ueId}
-		usageMonDataMapArray, err := mongoapi.RestfulAPIGetMany(collName, filter)
-		if err != nil {
-			logger.DataRepoLog.Errorf("PolicyDataUesUeIdSmDataPatchProcedure err: %+v", err)
+		usageMonDataMapArray, readErr := p.GetManyDataFromDB(ctx, usageMonDataCollName, bson.M{"ueId": ueId})
+		if readErr != nil {
+			return readErr
 		}
+		smPolicyData, updateErr = decodeSmPolicyData(data, usageMonDataMapArray)
+		return updateErr
+	})
+	if err != nil {
+		dataRepoLog(c).Errorf("PolicyDataUesUeIdSmDataPatchProcedure err: %+v", err)
+		fail(c, err)
+		return
+	}
 
-		if !reflect.DeepEqual(usageMonDataMapArray, []map[string]interface{}{}) {
-			var usageMonDataArray []models.UsageMonData
-			if err := json.Unmarshal(util.MapArrayToByte(usageMonDataMapArray), &usageMonDataArray); err != nil {
-				logger.DataRepoLog.Warnln(err)
-			}
-			smPolicyData.UmData = make(map[string]models.UsageMonData)
-			for _, element := range usageMonDataArray {
-				smPolicyData.UmData[element.LimitId] = element
-			}
+	for _, limitId := range limitIds {
+		if usageMonData, ok := smPolicyData.UmData[limitId]; ok {
+			PreHandlePolicyDataChangeNotification(c, ueId, limitId, usageMonData)
 		}
-		PreHandlePolicyDataChangeNotification(ueId, "", smPolicyData)
-		c.Status(http.StatusNoContent)
 	}
-	pd := util.ProblemDetailsModifyNotAllowed("")
-	c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-	c.JSON(int(pd.Status), pd)
+	PreHandlePolicyDataChangeNotification(c, ueId, "", *smPolicyData)
+	c.JSON(http.StatusOK, smPolicyData)
 }
 
+// PolicyDataUesUeIdSmDataUsageMonIdDeleteProcedure removes the usage monitoring data of the UE. The deletion is
+// idempotent, 204 is answered as well when there is none, only its actual removal is notified.
 func (p *Processor) PolicyDataUesUeIdSmDataUsageMonIdDeleteProcedure(
 	c *gin.Context, collName string, ueId string, usageMonId string,
 ) {
 	filter := bson.M{"ueId": ueId, "usageMonId": usageMonId}
-	p.DeleteDataFromDB(collName, filter)
+	deleted, err := p.DeleteOne(c, collName, filter)
+	if err != nil {
+		dataRepoLog(c).Errorf("PolicyDataUesUeIdSmDataUsageMonIdDeleteProcedure err: %+v", err)
+		fail(c, err)
+		return
+	}
+	if deleted {
+		PreHandlePolicyDataDeletionNotification(c, ueId, "/policy-data/ues/"+ueId+"/sm-data/"+usageMonId)
+	}
 	c.Status(http.StatusNoContent)
 }
 
@@ -448,48 +380,69 @@ func (p *Processor) PolicyDataUesUeIdSmDataUsageMonIdGetProcedure(c *gin.Context
 	ueId string,
 ) {
 	filter := bson.M{"ueId": ueId, "usageMonId": usageMonId}
-	data, pd := p.GetDataFromDB(collName, filter)
+	data, etag, pd := p.GetVersionedDataFromDB(c, collName, filter)
 	if pd != nil {
-		logger.DataRepoLog.Errorf("PolicyDataUesUeIdSmDataUsageMonIdGetProcedure err: %s", pd.Detail)
-		pd := util.ProblemDetailsNotFound("DATA_NOT_FOUND")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		dataRepoLog(c).Errorf("PolicyDataUesUeIdSmDataUsageMonIdGetProcedure err: %s", pd.Detail)
+		fail(c, problemOf(pd))
 		return
 	}
-	c.JSON(http.StatusOK, data)
+	delete(data, "ueId")
+	delete(data, "usageMonId")
+	respondVersioned(c, etag, data)
 }
 
+// PolicyDataUesUeIdSmDataUsageMonIdPutProcedure stores the usage monitoring data of the UE in place of the
+// previous one, If-Match is honored. Its limitId is the usageMonId, the one the patches of umData refer to.
 func (p *Processor) PolicyDataUesUeIdSmDataUsageMonIdPutProcedure(
 	c *gin.Context, collName string, ueId string, usageMonId string,
 	usageMonData models.UsageMonData,
 ) {
+	if usageMonData.LimitId == "" {
+		usageMonData.LimitId = usageMonId
+	}
+	if usageMonData.LimitId != usageMonId {
+		err := &Error{
+			Category:      ErrorValidation,
+			Message:       fmt.Sprintf("limitId %s is not the usageMonId %s", usageMonData.LimitId, usageMonId),
+			InvalidParams: []models.InvalidParam{{Param: "/limitId", Reason: "differs from the usageMonId"}},
+		}
+		dataRepoLog(c).Errorf("PolicyDataUesUeIdSmDataUsageMonIdPutProcedure err: %+v", err)
+		fail(c, err)
+		return
+	}
 	putData := util.ToBsonM(usageMonData)
 	putData["ueId"] = ueId
 	putData["usageMonId"] = usageMonId
 	filter := bson.M{"ueId": ueId, "usageMonId": usageMonId}
 
-	_, err := mongoapi.RestfulAPIPutOne(collName, filter, putData)
+	existed, etag, err := p.ReplaceVersionedDataToDB(c, collName, filter, putData, ifMatchOf(c), nil)
 	if err != nil {
-		logger.DataRepoLog.Errorf("PolicyDataUesUeIdSmDataUsageMonIdPutProcedure err: %+v", err)
-		pd := util.ProblemDetailsUpspecified("")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		dataRepoLog(c).Errorf("PolicyDataUesUeIdSmDataUsageMonIdPutProcedure err: %+v", err)
+		if abortVersionedWrite(c, err) {
+			return
+		}
+		fail(c, err)
+		return
+	}
+	PreHandlePolicyDataChangeNotification(c, ueId, usageMonId, usageMonData)
+	setETag(c, etag)
+
+	if existed {
+		c.JSON(http.StatusOK, usageMonData)
 		return
 	}
-	c.JSON(http.StatusOK, putData)
+	c.JSON(http.StatusCreated, usageMonData)
 }
 
 func (p *Processor) PolicyDataUesUeIdUePolicySetGetProcedure(c *gin.Context, collName string, ueId string) {
 	filter := bson.M{"ueId": ueId}
-	data, pd := p.GetDataFromDB(collName, filter)
+	data, etag, pd := p.GetVersionedDataFromDB(c, collName, filter)
 	if pd != nil {
-		logger.DataRepoLog.Errorf("PolicyDataUesUeIdUePolicySetGetProcedure err: %s", pd.Detail)
-		pd := util.ProblemDetailsNotFound("DATA_NOT_FOUND")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		dataRepoLog(c).Errorf("PolicyDataUesUeIdUePolicySetGetProcedure err: %s", pd.Detail)
+		fail(c, &Error{Category: ErrorNotFound})
 		return
 	}
-	c.JSON(http.StatusOK, data)
+	respondVersioned(c, etag, data)
 }
 
 func (p *Processor) PolicyDataUesUeIdUePolicySetPatchProcedure(c *gin.Context, collName string, ueId string,
@@ -499,30 +452,25 @@ func (p *Processor) PolicyDataUesUeIdUePolicySetPatchProcedure(c *gin.Context, c
 	patchData["ueId"] = ueId
 	filter := bson.M{"ueId": ueId}
 
-	if err := mongoapi.RestfulAPIMergePatch(collName, filter, patchData); err != nil {
-		logger.DataRepoLog.Errorf("PolicyDataUesUeIdUePolicySetPatchProcedure err: %+v", err)
-		pd := util.ProblemDetailsModifyNotAllowed("")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+	if err := p.MergePatchDataToDB(c, collName, filter, patchData); err != nil {
+		dataRepoLog(c).Errorf("PolicyDataUesUeIdUePolicySetPatchProcedure err: %+v", err)
+		fail(c, &Error{Category: ErrorNotAllowed})
 		return
 	}
 
 	var uePolicySet models.UePolicySet
-	uePolicySetBsonM, pd := p.GetDataFromDB(collName, filter)
+	uePolicySetBsonM, pd := p.GetDataFromDB(c, collName, filter)
 	if pd != nil {
-		logger.DataRepoLog.Errorf("PolicyDataUesUeIdUePolicySetPatchProcedure err: %s", pd.Detail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		dataRepoLog(c).Errorf("PolicyDataUesUeIdUePolicySetPatchProcedure err: %s", pd.Detail)
+		fail(c, problemOf(pd))
 		return
 	}
 	if err := json.Unmarshal(util.MapToByte(uePolicySetBsonM), &uePolicySet); err != nil {
-		logger.DataRepoLog.Errorf("PolicyDataUesUeIdUePolicySetPatchProcedure err: %+v", err)
-		pd := openapi.ProblemDetailsSystemFailure(err.Error())
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		dataRepoLog(c).Errorf("PolicyDataUesUeIdUePolicySetPatchProcedure err: %+v", err)
+		fail(c, err)
 		return
 	}
-	PreHandlePolicyDataChangeNotification(ueId, "", uePolicySet)
+	PreHandlePolicyDataChangeNotification(c, ueId, "", uePolicySet)
 	c.Status(http.StatusNoContent)
 }
 
@@ -533,13 +481,17 @@ func (p *Processor) PolicyDataUesUeIdUePolicySetPutProcedure(c *gin.Context, col
 	putData["ueId"] = ueId
 	filter := bson.M{"ueId": ueId}
 
-	existed, err := mongoapi.RestfulAPIPutOne(collName, filter, putData)
+	existed, _, err := p.PutVersionedDataToDB(c, collName, filter, putData, ifMatchOf(c))
 	if err != nil {
-		logger.DataRepoLog.Errorf("PolicyDataUesUeIdUePolicySetPutProcedure err: %+v", err)
-		c.Status(http.StatusInternalServerError)
+		dataRepoLog(c).Errorf("PolicyDataUesUeIdUePolicySetPutProcedure err: %+v", err)
+		if !abortVersionedWrite(c, err) {
+			fail(c, err)
+		}
+		return
 	}
 	if existed {
 		c.Status(http.StatusNoContent)
+		return
 	}
 	c.JSON(http.StatusCreated, putData)
 }
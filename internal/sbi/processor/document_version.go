@@ -0,0 +1,73 @@
+package processor
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/free5gc/udr/internal/database"
+	"github.com/free5gc/udr/internal/util"
+)
+
+// The versioned documents are answered with an ETag, the hash of their content, their PUT and PATCH honor
+// If-Match and their GET If-None-Match. A 412 tells the client that the document changed since it read it:
+// GET it again and retry with the new ETag. A 304 tells it that the document it holds is still the current one.
+//
+// A PUT of a single resource is an upsert: it creates the document when there is none and replaces it
+// otherwise. With If-None-Match: * it only creates, 412 when the document exists; with If-Match it only replaces
+// the document of the tag.
+
+// ifMatchOf is the precondition of the write, NoneMatchAny for If-None-Match: * unless If-Match is given
+func ifMatchOf(c *gin.Context) string {
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" {
+		return ifMatch
+	}
+	if strings.TrimSpace(c.GetHeader("If-None-Match")) == "*" {
+		return util.NoneMatchAny
+	}
+	return ""
+}
+
+func setETag(c *gin.Context, etag string) {
+	c.Header("ETag", etag)
+}
+
+// respondVersioned answers the document with its etag, 304 without a body when If-None-Match matches it
+func respondVersioned(c *gin.Context, etag string, data interface{}) {
+	setETag(c, etag)
+	if util.IfNoneMatch(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.JSON(http.StatusOK, data)
+}
+
+// respondHashed answers data made of several documents, or of a part of one, with the ETag of its content,
+// 304 without a body when If-None-Match matches it
+func respondHashed(c *gin.Context, data interface{}) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	etag := util.ContentETag(body)
+	c.Header("ETag", etag)
+	if util.IfNoneMatch(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// abortVersionedWrite answers 412 when the versioned write failed on If-Match and returns true,
+// the other errors are left to the caller
+func abortVersionedWrite(c *gin.Context, err error) bool {
+	if !errors.Is(err, database.ErrVersionMismatch) {
+		return false
+	}
+	fail(c, &Error{Category: ErrorPreconditionFailed, Err: err})
+	return true
+}
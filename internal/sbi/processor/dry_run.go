@@ -0,0 +1,473 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/udr/internal/database"
+	"github.com/free5gc/udr/internal/util"
+)
+
+type dryRunKey struct{}
+
+// DryRun collects the document the writes of a dry-run request would store. The writes of the Processor
+// given a dry-run context are computed on the stored documents and not applied, the subscribers are not
+// notified of them.
+type DryRun struct {
+	mu       sync.Mutex
+	document map[string]interface{}
+}
+
+// WithDryRun returns the context of a dry-run request and the DryRun collecting its writes
+func WithDryRun(ctx context.Context) (context.Context, *DryRun) {
+	dryRun := &DryRun{}
+	return context.WithValue(ctx, dryRunKey{}, dryRun), dryRun
+}
+
+// IsDryRun reports whether ctx is the context of a dry-run request
+func IsDryRun(ctx context.Context) bool {
+	return dryRunOf(ctx) != nil
+}
+
+func dryRunOf(ctx context.Context) *DryRun {
+	dryRun, _ := ctx.Value(dryRunKey{}).(*DryRun)
+	return dryRun
+}
+
+// Document returns the document the last write of the request would have stored, false when there was no
+// write. The writes of a request do not see the ones before them.
+func (d *DryRun) Document() (map[string]interface{}, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.document, d.document != nil
+}
+
+func (d *DryRun) store(document map[string]interface{}) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.document = document
+}
+
+// dryRunSubscription records the subscription a dry-run request would keep in the UDR context in place of
+// the current one, false when the request is not a dry run and the subscription shall be kept
+func dryRunSubscription(ctx context.Context, subscription interface{}) bool {
+	dryRun := dryRunOf(ctx)
+	if dryRun == nil {
+		return false
+	}
+	dryRun.store(util.ToBsonM(subscription))
+	return true
+}
+
+// dryRunCurrent reads the document a dry-run write applies to, nil when there is none
+func (p *Processor) dryRunCurrent(ctx context.Context, collName string, filter bson.M) (
+	map[string]interface{}, string, error,
+) {
+	current, etag, pd := p.GetVersionedDataFromDB(ctx, collName, filter)
+	if pd != nil {
+		if pd.Status == http.StatusNotFound {
+			return nil, "", nil
+		}
+		return nil, "", errors.New(pd.Detail)
+	}
+	return current, etag, nil
+}
+
+// dryRunModify computes the modification of the document as modifyVersionedData of the DbConnector does,
+// an empty ifMatch accepts any document
+func (p *Processor) dryRunModify(ctx context.Context, dryRun *DryRun, collName string, filter bson.M,
+	ifMatch string, modify func(original []byte) ([]byte, error),
+) (origValue, newValue map[string]interface{}, etag string, err error) {
+	origValue, etag, err = p.dryRunCurrent(ctx, collName, filter)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if !util.IfMatch(ifMatch, etag, origValue != nil) {
+		return nil, nil, etag, database.ErrVersionMismatch
+	}
+	if origValue == nil {
+		return nil, nil, "", fmt.Errorf("dry run: %w in %s", database.ErrNoDocument, collName)
+	}
+	original, err := json.Marshal(origValue)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	modified, err := modify(original)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if err = json.Unmarshal(modified, &newValue); err != nil {
+		return nil, nil, "", err
+	}
+	dryRun.store(newValue)
+	return origValue, newValue, util.DocumentETag(newValue), nil
+}
+
+// setFields is the document once the fields are set in it, as by $set
+func setFields(document, fields map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(document)+len(fields))
+	for field, value := range document {
+		result[field] = value
+	}
+	for field, value := range fields {
+		result[field] = value
+	}
+	return result
+}
+
+func (p *Processor) PatchDataToDBAndNotify(ctx context.Context, collName string, ueId string,
+	patchItem []models.PatchItem, filter bson.M,
+) (map[string]interface{}, map[string]interface{}, error) {
+	dryRun := dryRunOf(ctx)
+	if dryRun == nil {
+		return p.DbConnector.PatchDataToDBAndNotify(ctx, collName, ueId, patchItem, filter)
+	}
+	patch, err := decodePatchItems(patchItem)
+	if err != nil {
+		return nil, nil, err
+	}
+	origValue, newValue, _, err := p.dryRunModify(ctx, dryRun, collName, filter, "", patch.Apply)
+	return origValue, newValue, err
+}
+
+func (p *Processor) MergePatchDataToDBAndNotify(ctx context.Context, collName string, ueId string,
+	mergePatch []byte, filter bson.M,
+) (map[string]interface{}, map[string]interface{}, error) {
+	dryRun := dryRunOf(ctx)
+	if dryRun == nil {
+		return p.DbConnector.MergePatchDataToDBAndNotify(ctx, collName, ueId, mergePatch, filter)
+	}
+	origValue, newValue, _, err := p.dryRunModify(ctx, dryRun, collName, filter, "",
+		func(original []byte) ([]byte, error) {
+			return jsonpatch.MergePatch(original, mergePatch)
+		})
+	return origValue, newValue, err
+}
+
+func (p *Processor) PatchOne(ctx context.Context, collName string, filter bson.M, update database.Update) (
+	map[string]interface{}, error,
+) {
+	dryRun := dryRunOf(ctx)
+	if dryRun == nil {
+		return p.DbConnector.PatchOne(ctx, collName, filter, update)
+	}
+	_, newValue, _, err := p.dryRunModify(ctx, dryRun, collName, filter, "",
+		func(original []byte) ([]byte, error) {
+			var document map[string]interface{}
+			if err := json.Unmarshal(original, &document); err != nil {
+				return nil, err
+			}
+			updateDottedFields(document, update.Set, update.Unset)
+			return json.Marshal(document)
+		})
+	if errors.Is(err, database.ErrNoDocument) && update.Upsert {
+		newValue = bson.M{}
+		for field, condition := range filter {
+			if _, isOperator := condition.(bson.M); !isOperator && !strings.HasPrefix(field, "$") {
+				newValue[field] = condition
+			}
+		}
+		updateDottedFields(newValue, update.Set, update.Unset)
+		dryRun.store(newValue)
+		return newValue, nil
+	}
+	return newValue, err
+}
+
+// updateDottedFields applies the $set and the $unset of the dotted paths to the document as MongoDB does
+func updateDottedFields(document map[string]interface{}, set bson.M, unset []string) {
+	parentOf := func(path string, create bool) (map[string]interface{}, string) {
+		names := strings.Split(path, ".")
+		parent := document
+		for _, name := range names[:len(names)-1] {
+			child, ok := parent[name].(map[string]interface{})
+			if !ok {
+				if !create {
+					return nil, ""
+				}
+				child = make(map[string]interface{})
+				parent[name] = child
+			}
+			parent = child
+		}
+		return parent, names[len(names)-1]
+	}
+	for path, value := range set {
+		parent, name := parentOf(path, true)
+		parent[name] = value
+	}
+	for _, path := range unset {
+		if parent, name := parentOf(path, false); parent != nil {
+			delete(parent, name)
+		}
+	}
+}
+
+func (p *Processor) ModifyDataFieldToDB(ctx context.Context, collName string, filter bson.M, field string,
+	modify func(value []byte) ([]byte, bool, error),
+) (map[string]interface{}, map[string]interface{}, error) {
+	dryRun := dryRunOf(ctx)
+	if dryRun == nil {
+		return p.DbConnector.ModifyDataFieldToDB(ctx, collName, filter, field, modify)
+	}
+	var unchanged bool
+	origValue, newValue, _, err := p.dryRunModify(ctx, dryRun, collName, filter, "",
+		func(original []byte) ([]byte, error) {
+			var document map[string]json.RawMessage
+			if err := json.Unmarshal(original, &document); err != nil {
+				return nil, err
+			}
+			value, ok := document[field]
+			if !ok {
+				value = json.RawMessage("null")
+			}
+			modified, changed, err := modify(value)
+			if err != nil {
+				return nil, err
+			}
+			unchanged = !changed
+			document[field] = modified
+			return json.Marshal(document)
+		})
+	if err == nil && unchanged {
+		return origValue, origValue, nil
+	}
+	return origValue, newValue, err
+}
+
+func (p *Processor) MergePatchDataToDB(ctx context.Context, collName string, filter bson.M,
+	patchData map[string]interface{},
+) error {
+	dryRun := dryRunOf(ctx)
+	if dryRun == nil {
+		return p.DbConnector.MergePatchDataToDB(ctx, collName, filter, patchData)
+	}
+	mergePatch, err := json.Marshal(patchData)
+	if err != nil {
+		return err
+	}
+	_, _, _, err = p.dryRunModify(ctx, dryRun, collName, filter, "", func(original []byte) ([]byte, error) {
+		return jsonpatch.MergePatch(original, mergePatch)
+	})
+	return err
+}
+
+func (p *Processor) PatchDataFieldToDB(ctx context.Context, collName string, filter bson.M,
+	dataName string, patchJSON []byte,
+) error {
+	dryRun := dryRunOf(ctx)
+	if dryRun == nil {
+		return p.DbConnector.PatchDataFieldToDB(ctx, collName, filter, dataName, patchJSON)
+	}
+	patch, err := jsonpatch.DecodePatch(patchJSON)
+	if err != nil {
+		return err
+	}
+	_, _, _, err = p.dryRunModify(ctx, dryRun, collName, filter, "", func(original []byte) ([]byte, error) {
+		var document map[string]interface{}
+		if err := json.Unmarshal(original, &document); err != nil {
+			return nil, err
+		}
+		field, err := json.Marshal(document[dataName])
+		if err != nil {
+			return nil, err
+		}
+		patched, err := patch.Apply(field)
+		if err != nil {
+			return nil, err
+		}
+		// The fields of the patched data are set in the document, as the DbConnector does
+		var fields map[string]interface{}
+		if err := json.Unmarshal(patched, &fields); err != nil {
+			return nil, err
+		}
+		return json.Marshal(setFields(document, fields))
+	})
+	return err
+}
+
+func (p *Processor) PutDataToDB(ctx context.Context, collName string, filter bson.M,
+	putData map[string]interface{},
+) (bool, error) {
+	dryRun := dryRunOf(ctx)
+	if dryRun == nil {
+		return p.DbConnector.PutDataToDB(ctx, collName, filter, putData)
+	}
+	current, _, err := p.dryRunCurrent(ctx, collName, filter)
+	if err != nil {
+		return false, err
+	}
+	dryRun.store(setFields(current, putData))
+	return current != nil, nil
+}
+
+func (p *Processor) ReplaceDataToDB(ctx context.Context, collName string, filter bson.M,
+	data map[string]interface{},
+) (bool, error) {
+	dryRun := dryRunOf(ctx)
+	if dryRun == nil {
+		return p.DbConnector.ReplaceDataToDB(ctx, collName, filter, data)
+	}
+	current, _, err := p.dryRunCurrent(ctx, collName, filter)
+	if err != nil {
+		return false, err
+	}
+	dryRun.store(data)
+	return current != nil, nil
+}
+
+func (p *Processor) PutVersionedDataToDB(ctx context.Context, collName string, filter bson.M,
+	putData map[string]interface{}, ifMatch string,
+) (bool, string, error) {
+	dryRun := dryRunOf(ctx)
+	if dryRun == nil {
+		return p.DbConnector.PutVersionedDataToDB(ctx, collName, filter, putData, ifMatch)
+	}
+	current, etag, err := p.dryRunCurrent(ctx, collName, filter)
+	if err != nil {
+		return false, "", err
+	}
+	if !util.IfMatch(ifMatch, etag, current != nil) {
+		return current != nil, etag, database.ErrVersionMismatch
+	}
+	stored := setFields(current, putData)
+	dryRun.store(stored)
+	return current != nil, util.DocumentETag(stored), nil
+}
+
+func (p *Processor) PutOne(ctx context.Context, collName string, filter bson.M, data map[string]interface{},
+	ifMatch string,
+) (bool, string, error) {
+	if dryRunOf(ctx) == nil {
+		return p.DbConnector.PutOne(ctx, collName, filter, data, ifMatch)
+	}
+	return p.ReplaceVersionedDataToDB(ctx, collName, filter, data, ifMatch, nil)
+}
+
+func (p *Processor) ReplaceVersionedDataToDB(ctx context.Context, collName string, filter bson.M,
+	data map[string]interface{}, ifMatch string, validate func(current map[string]interface{}) error,
+) (bool, string, error) {
+	dryRun := dryRunOf(ctx)
+	if dryRun == nil {
+		return p.DbConnector.ReplaceVersionedDataToDB(ctx, collName, filter, data, ifMatch, validate)
+	}
+	current, etag, err := p.dryRunCurrent(ctx, collName, filter)
+	if err != nil {
+		return false, "", err
+	}
+	if !util.IfMatch(ifMatch, etag, current != nil) {
+		return current != nil, etag, database.ErrVersionMismatch
+	}
+	if validate != nil {
+		if err = validate(current); err != nil {
+			return current != nil, etag, err
+		}
+	}
+	dryRun.store(data)
+	return current != nil, util.DocumentETag(data), nil
+}
+
+func (p *Processor) PatchVersionedDataToDB(ctx context.Context, collName string, filter bson.M,
+	patchItem []models.PatchItem, ifMatch string,
+) (map[string]interface{}, map[string]interface{}, string, error) {
+	dryRun := dryRunOf(ctx)
+	if dryRun == nil {
+		return p.DbConnector.PatchVersionedDataToDB(ctx, collName, filter, patchItem, ifMatch)
+	}
+	patch, err := decodePatchItems(patchItem)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return p.dryRunModify(ctx, dryRun, collName, filter, ifMatch, patch.Apply)
+}
+
+func (p *Processor) MergePatchVersionedDataToDB(ctx context.Context, collName string, filter bson.M,
+	mergePatch []byte, ifMatch string, validate func(map[string]interface{}) error,
+) (map[string]interface{}, map[string]interface{}, string, error) {
+	dryRun := dryRunOf(ctx)
+	if dryRun == nil {
+		return p.DbConnector.MergePatchVersionedDataToDB(ctx, collName, filter, mergePatch, ifMatch, validate)
+	}
+	return p.dryRunModify(ctx, dryRun, collName, filter, ifMatch, func(original []byte) ([]byte, error) {
+		modified, err := jsonpatch.MergePatch(original, mergePatch)
+		if err != nil || validate == nil {
+			return modified, err
+		}
+		var document map[string]interface{}
+		if err = json.Unmarshal(modified, &document); err != nil {
+			return nil, err
+		}
+		return modified, validate(document)
+	})
+}
+
+func (p *Processor) BulkUpsertDataToDB(ctx context.Context, collName string, upserts []database.Upsert) []error {
+	if dryRunOf(ctx) == nil {
+		return p.DbConnector.BulkUpsertDataToDB(ctx, collName, upserts)
+	}
+	return make([]error, len(upserts))
+}
+
+func (p *Processor) InsertDataToDB(ctx context.Context, collName string, data map[string]interface{}) error {
+	dryRun := dryRunOf(ctx)
+	if dryRun == nil {
+		return p.DbConnector.InsertDataToDB(ctx, collName, data)
+	}
+	dryRun.store(data)
+	return nil
+}
+
+func (p *Processor) DeleteDataFromDB(ctx context.Context, collName string, filter bson.M) {
+	if dryRunOf(ctx) == nil {
+		p.DbConnector.DeleteDataFromDB(ctx, collName, filter)
+	}
+}
+
+func (p *Processor) DeleteOneDataFromDB(ctx context.Context, collName string, filter bson.M) error {
+	if dryRunOf(ctx) == nil {
+		return p.DbConnector.DeleteOneDataFromDB(ctx, collName, filter)
+	}
+	return nil
+}
+
+func (p *Processor) DeleteOne(ctx context.Context, collName string, filter bson.M) (bool, error) {
+	if dryRunOf(ctx) == nil {
+		return p.DbConnector.DeleteOne(ctx, collName, filter)
+	}
+	current, _, err := p.dryRunCurrent(ctx, collName, filter)
+	return current != nil, err
+}
+
+func (p *Processor) DeleteManyDataFromDB(ctx context.Context, collNames []string, filter bson.M) (
+	map[string]int64, bool, error,
+) {
+	if dryRunOf(ctx) == nil {
+		return p.DbConnector.DeleteManyDataFromDB(ctx, collNames, filter)
+	}
+	return map[string]int64{}, false, nil
+}
+
+// WithTransaction runs fn as is in a dry run, its writes are recorded rather than applied
+func (p *Processor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) (bool, error) {
+	if dryRunOf(ctx) == nil {
+		return p.DbConnector.WithTransaction(ctx, fn)
+	}
+	return false, fn(ctx)
+}
+
+func decodePatchItems(patchItem []models.PatchItem) (jsonpatch.Patch, error) {
+	patchJSON, err := json.Marshal(patchItem)
+	if err != nil {
+		return nil, err
+	}
+	return jsonpatch.DecodePatch(patchJSON)
+}
@@ -0,0 +1,142 @@
+package processor
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/udr/internal/database"
+	"github.com/free5gc/udr/internal/util"
+)
+
+// ErrorCategory is the kind of a processor failure, it alone decides the HTTP status answered
+type ErrorCategory int
+
+const (
+	ErrorInternal ErrorCategory = iota
+	ErrorNotFound
+	ErrorConflict
+	ErrorValidation
+	ErrorUnavailable
+	ErrorNotAllowed
+	ErrorUnprocessable
+	ErrorPreconditionFailed
+)
+
+var errorCategories = map[ErrorCategory]struct {
+	status int
+	title  string
+	cause  string
+}{
+	ErrorInternal:           {http.StatusInternalServerError, "System failure", "SYSTEM_FAILURE"},
+	ErrorNotFound:           {http.StatusNotFound, "Data not found", "DATA_NOT_FOUND"},
+	ErrorConflict:           {http.StatusConflict, "Conflict", "CONFLICT"},
+	ErrorValidation:         {http.StatusBadRequest, "Invalid parameter", "MANDATORY_IE_INCORRECT"},
+	ErrorUnavailable:        {http.StatusServiceUnavailable, "Service unavailable", "SYSTEM_FAILURE"},
+	ErrorNotAllowed:         {http.StatusForbidden, "Modify not allowed", "MODIFY_NOT_ALLOWED"},
+	ErrorUnprocessable:      {http.StatusUnprocessableEntity, "Unprocessable entity", "UNPROCESSABLE_ENTITY"},
+	ErrorPreconditionFailed: {http.StatusPreconditionFailed, "Precondition failed", "PRECONDITION_FAILED"},
+}
+
+// causeTitles are the titles of the causes other than the one of their category
+var causeTitles = map[string]string{
+	"USER_NOT_FOUND":            "User not found",
+	"SUBSCRIPTION_NOT_FOUND":    "Subscription not found",
+	"AMFSUBSCRIPTION_NOT_FOUND": "AMF Subscription not found",
+	"UNSPECIFIED":               "Unspecified",
+}
+
+// Status returns the HTTP status of the category, 500 for an unknown one
+func (category ErrorCategory) Status() int {
+	if c, ok := errorCategories[category]; ok {
+		return c.status
+	}
+	return http.StatusInternalServerError
+}
+
+// Error is a processor failure the consumer is answered, by the ProblemDetails of its category
+type Error struct {
+	Category ErrorCategory
+	// Cause is the ProblemDetails cause, the one of the category when empty
+	Cause         string
+	Message       string
+	InvalidParams []models.InvalidParam
+	// Err is the failure behind, if any
+	Err error
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.Err == nil:
+		return e.Message
+	case e.Message == "":
+		return e.Err.Error()
+	}
+	return e.Message + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ProblemDetails returns the ProblemDetails answering the failure
+func (e *Error) ProblemDetails() *models.ProblemDetails {
+	category, ok := errorCategories[e.Category]
+	if !ok {
+		category = errorCategories[ErrorInternal]
+	}
+	pd := &models.ProblemDetails{
+		Title:         category.title,
+		Status:        int32(category.status),
+		Detail:        e.Error(),
+		Cause:         category.cause,
+		InvalidParams: e.InvalidParams,
+	}
+	if e.Cause != "" {
+		pd.Cause = e.Cause
+	}
+	if title, ok := causeTitles[pd.Cause]; ok {
+		pd.Title = title
+	}
+	return pd
+}
+
+// problemOf is the Error of the ProblemDetails a DbConnector answered, by the category of its status
+func problemOf(pd *models.ProblemDetails) *Error {
+	err := &Error{Category: ErrorInternal, Cause: pd.Cause, Message: pd.Detail}
+	for category, c := range errorCategories {
+		if int32(c.status) == pd.Status {
+			err.Category = category
+		}
+	}
+	return err
+}
+
+// notFoundCause has the not found failure answer cause, e.g. the one naming what is not found
+func (e *Error) notFoundCause(cause string) *Error {
+	if e.Category == ErrorNotFound {
+		e.Cause = cause
+	}
+	return e
+}
+
+// ProblemDetailsOf returns the ProblemDetails answering err: 503 when the datastore is unreachable, the one of
+// its category when it is an Error, and a system failure otherwise
+func ProblemDetailsOf(err error) *models.ProblemDetails {
+	if database.IsUnavailable(err) {
+		return (&Error{Category: ErrorUnavailable, Err: err}).ProblemDetails()
+	}
+	var processorErr *Error
+	if errors.As(err, &processorErr) {
+		return processorErr.ProblemDetails()
+	}
+	return util.ProblemDetailsSystemFailure(err.Error())
+}
+
+// fail records the failure of the request on the context, the SBI answers its ProblemDetails once the
+// procedure returns. The procedure answers nothing else afterwards.
+func fail(c *gin.Context, err error) {
+	_ = c.Error(err)
+}
@@ -0,0 +1,69 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
+
+	"github.com/free5gc/openapi/models"
+)
+
+func TestError_ProblemDetails(t *testing.T) {
+	errDecode := errors.New("invalid character 's'")
+	testCases := []struct {
+		name   string
+		err    error
+		status int32
+		cause  string
+		title  string
+		detail string
+	}{
+		{"Not Found", &Error{Category: ErrorNotFound, Message: "no AM data"},
+			http.StatusNotFound, "DATA_NOT_FOUND", "Data not found", "no AM data"},
+		{"Not Found Cause", &Error{Category: ErrorNotFound, Cause: "USER_NOT_FOUND", Message: "no such UE"},
+			http.StatusNotFound, "USER_NOT_FOUND", "User not found", "no such UE"},
+		{"Conflict", &Error{Category: ErrorConflict, Message: "counterSor 1 is lower than the stored one 2"},
+			http.StatusConflict, "CONFLICT", "Conflict", "counterSor 1 is lower than the stored one 2"},
+		{"Validation", &Error{Category: ErrorValidation, Message: "snssais", Err: errDecode},
+			http.StatusBadRequest, "MANDATORY_IE_INCORRECT", "Invalid parameter", "snssais: invalid character 's'"},
+		{"Unavailable", &Error{Category: ErrorUnavailable, Message: "MongoDB is not connected"},
+			http.StatusServiceUnavailable, "SYSTEM_FAILURE", "Service unavailable", "MongoDB is not connected"},
+		{"Internal", &Error{Err: errDecode},
+			http.StatusInternalServerError, "SYSTEM_FAILURE", "System failure", "invalid character 's'"},
+		{"Wrapped", fmt.Errorf("replace: %w", &Error{Category: ErrorConflict, Message: "stale"}),
+			http.StatusConflict, "CONFLICT", "Conflict", "stale"},
+		{"Datastore Unreachable", fmt.Errorf("find: %w", topology.ServerSelectionError{}),
+			http.StatusServiceUnavailable, "SYSTEM_FAILURE", "Service unavailable",
+			"find: server selection error: current topology: { Type: Unknown, Servers: [] }"},
+		{"Not Allowed", &Error{Category: ErrorNotAllowed, Cause: "UNSPECIFIED", Message: "notificationUri"},
+			http.StatusForbidden, "UNSPECIFIED", "Unspecified", "notificationUri"},
+		{"DB Problem", problemOf(&models.ProblemDetails{Status: http.StatusNotFound, Cause: "DATA_NOT_FOUND",
+			Detail: "no document"}).notFoundCause("USER_NOT_FOUND"),
+			http.StatusNotFound, "USER_NOT_FOUND", "User not found", "no document"},
+		{"Not An Error", errDecode,
+			http.StatusInternalServerError, "SYSTEM_FAILURE", "System failure", "invalid character 's'"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pd := ProblemDetailsOf(tc.err)
+			require.Equal(t, tc.status, pd.Status)
+			require.Equal(t, tc.cause, pd.Cause)
+			require.Equal(t, tc.title, pd.Title)
+			require.Equal(t, tc.detail, pd.Detail)
+		})
+	}
+
+	err := &Error{
+		Category:      ErrorValidation,
+		InvalidParams: []models.InvalidParam{{Param: "snssais", Reason: "shall be a JSON array of S-NSSAIs"}},
+		Err:           errDecode,
+	}
+	require.ErrorIs(t, err, errDecode)
+	require.Equal(t, err.InvalidParams, ProblemDetailsOf(err).InvalidParams)
+	require.Equal(t, http.StatusInternalServerError, ErrorCategory(42).Status())
+}
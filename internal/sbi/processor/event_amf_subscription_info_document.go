@@ -15,68 +15,74 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/free5gc/openapi/models"
-	udr_context "github.com/free5gc/udr/internal/context"
-	"github.com/free5gc/udr/internal/logger"
-	"github.com/free5gc/udr/internal/util"
-	"github.com/free5gc/util/metrics/sbi"
+	db "github.com/free5gc/udr/internal/database"
 )
 
+// amfSubscriptionInfoListField holds the AMF subscriptions in the document of the EE subscription they belong to
+const amfSubscriptionInfoListField = "amfSubscriptionInfoList"
+
+// CreateAMFSubscriptionsProcedure stores the AMF subscriptions of the EE subscription, 201 is answered with them
+// when there were none and 204 otherwise
 func (p *Processor) CreateAMFSubscriptionsProcedure(c *gin.Context, subsId string, ueId string,
 	AmfSubscriptionInfo []models.AmfSubscriptionInfo,
 ) {
-	udrSelf := udr_context.GetSelf()
-	value, ok := udrSelf.UESubsCollection.Load(ueId)
-	if !ok {
-		pd := util.ProblemDetailsNotFound("USER_NOT_FOUND")
-		logger.DataRepoLog.Errorf("CreateAMFSubscriptionsProcedure err: %s", pd.Detail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+	if !p.checkEeSubscription(c, "CreateAMFSubscriptionsProcedure", ueId, subsId) {
 		return
 	}
-	UESubsData := value.(*udr_context.UESubsData)
 
-	_, ok = UESubsData.EeSubscriptionCollection[subsId]
-	if !ok {
-		pd := util.ProblemDetailsNotFound("SUBSCRIPTION_NOT_FOUND")
-		logger.DataRepoLog.Errorf("CreateAMFSubscriptionsProcedure err: %s", pd.Detail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+	filter := eeSubscriptionFilter(ueId, subsId)
+	putData := map[string]interface{}{
+		"ueId":                       ueId,
+		"subsId":                     subsId,
+		amfSubscriptionInfoListField: AmfSubscriptionInfo,
+	}
+	existed, _, err := p.ReplaceVersionedDataToDB(c, db.SUBSCDATA_EE_AMF_SUBSC_DB_COLLECTION_NAME, filter, putData,
+		ifMatchOf(c), nil)
+	if err != nil {
+		dataRepoLog(c).Errorf("CreateAMFSubscriptionsProcedure err: %+v", err)
+		if !abortVersionedWrite(c, err) {
+			fail(c, err)
+		}
 		return
 	}
 
-	UESubsData.EeSubscriptionCollection[subsId].AmfSubscriptionInfos = AmfSubscriptionInfo
-	c.Status(http.StatusNoContent)
+	if existed {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.Header("Location", eeSubscriptionUri(c, ueId, subsId)+"/amf-subscriptions")
+	c.JSON(http.StatusCreated, AmfSubscriptionInfo)
 }
 
 func (p *Processor) RemoveAmfSubscriptionsInfoProcedure(c *gin.Context, subsId string, ueId string) {
-	udrSelf := udr_context.GetSelf()
-	value, ok := udrSelf.UESubsCollection.Load(ueId)
-	var pd *models.ProblemDetails = nil
-
-	if !ok {
-		pd = util.ProblemDetailsNotFound("USER_NOT_FOUND")
-		logger.DataRepoLog.Errorf("RemoveAmfSubscriptionsInfoProcedure err: %s", pd.Detail)
-	}
-
-	UESubsData := value.(*udr_context.UESubsData)
-	_, ok = UESubsData.EeSubscriptionCollection[subsId]
-
-	if !ok {
-		pd = util.ProblemDetailsNotFound("SUBSCRIPTION_NOT_FOUND")
-		logger.DataRepoLog.Errorf("RemoveAmfSubscriptionsInfoProcedure err: %s", pd.Detail)
-	}
-
-	if UESubsData.EeSubscriptionCollection[subsId].AmfSubscriptionInfos == nil {
-		pd = util.ProblemDetailsNotFound("AMFSUBSCRIPTION_NOT_FOUND")
+	if !p.checkEeSubscription(c, "RemoveAmfSubscriptionsInfoProcedure", ueId, subsId) {
+		return
 	}
 
+	filter := eeSubscriptionFilter(ueId, subsId)
+	_, pd := p.GetDataFromDB(c, db.SUBSCDATA_EE_AMF_SUBSC_DB_COLLECTION_NAME, filter)
+	var err error
 	if pd != nil {
-		logger.DataRepoLog.Errorf("RemoveAmfSubscriptionsInfoProcedure err: %s", pd.Detail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		err = problemOf(pd).notFoundCause("AMFSUBSCRIPTION_NOT_FOUND")
+	} else {
+		err = p.DeleteOneDataFromDB(c, db.SUBSCDATA_EE_AMF_SUBSC_DB_COLLECTION_NAME, filter)
+	}
+	if err != nil {
+		dataRepoLog(c).Errorf("RemoveAmfSubscriptionsInfoProcedure err: %+v", err)
+		fail(c, err)
 		return
 	}
-
-	UESubsData.EeSubscriptionCollection[subsId].AmfSubscriptionInfos = nil
 	c.Status(http.StatusNoContent)
 }
+
+// checkEeSubscription answers 404 when there is no EE subscription subsId of ueId, and tells whether the
+// handling goes on
+func (p *Processor) checkEeSubscription(c *gin.Context, procedure string, ueId string, subsId string) bool {
+	_, pd := p.GetDataFromDB(c, db.SUBSCDATA_EE_SUBSC_DB_COLLECTION_NAME, eeSubscriptionFilter(ueId, subsId))
+	if pd == nil {
+		return true
+	}
+	dataRepoLog(c).Errorf("%s of %s err: %s", procedure, subsId, pd.Detail)
+	fail(c, problemOf(pd).notFoundCause("SUBSCRIPTION_NOT_FOUND"))
+	return false
+}
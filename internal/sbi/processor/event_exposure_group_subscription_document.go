@@ -16,17 +16,13 @@ import (
 
 	"github.com/free5gc/openapi/models"
 	udr_context "github.com/free5gc/udr/internal/context"
-	"github.com/free5gc/udr/internal/util"
-	"github.com/free5gc/util/metrics/sbi"
 )
 
 func (p *Processor) RemoveEeGroupSubscriptionsProcedure(c *gin.Context, ueGroupId string, subsId string) {
 	udrSelf := udr_context.GetSelf()
 	value, ok := udrSelf.UEGroupCollection.Load(ueGroupId)
 	if !ok {
-		pd := util.ProblemDetailsNotFound("USER_NOT_FOUND")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		fail(c, &Error{Category: ErrorNotFound, Cause: "USER_NOT_FOUND"})
 		return
 	}
 
@@ -34,9 +30,7 @@ func (p *Processor) RemoveEeGroupSubscriptionsProcedure(c *gin.Context, ueGroupI
 	_, ok = UEGroupSubsData.EeSubscriptions[subsId]
 
 	if !ok {
-		pd := util.ProblemDetailsNotFound("SUBSCRIPTION_NOT_FOUND")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		fail(c, &Error{Category: ErrorNotFound, Cause: "SUBSCRIPTION_NOT_FOUND"})
 		return
 	}
 	delete(UEGroupSubsData.EeSubscriptions, subsId)
@@ -50,9 +44,7 @@ func (p *Processor) UpdateEeGroupSubscriptionsProcedure(c *gin.Context, ueGroupI
 	udrSelf := udr_context.GetSelf()
 	value, ok := udrSelf.UEGroupCollection.Load(ueGroupId)
 	if !ok {
-		pd := util.ProblemDetailsNotFound("USER_NOT_FOUND")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		fail(c, &Error{Category: ErrorNotFound, Cause: "USER_NOT_FOUND"})
 		return
 	}
 
@@ -60,12 +52,12 @@ func (p *Processor) UpdateEeGroupSubscriptionsProcedure(c *gin.Context, ueGroupI
 	_, ok = UEGroupSubsData.EeSubscriptions[subsId]
 
 	if !ok {
-		pd := util.ProblemDetailsNotFound("SUBSCRIPTION_NOT_FOUND")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		fail(c, &Error{Category: ErrorNotFound, Cause: "SUBSCRIPTION_NOT_FOUND"})
 		return
 	}
-	UEGroupSubsData.EeSubscriptions[subsId] = &EeSubscription
+	if !dryRunSubscription(c, &EeSubscription) {
+		UEGroupSubsData.EeSubscriptions[subsId] = &EeSubscription
+	}
 
 	c.Status(http.StatusNoContent)
 }
@@ -18,9 +18,7 @@ import (
 
 	"github.com/free5gc/openapi/models"
 	udr_context "github.com/free5gc/udr/internal/context"
-	"github.com/free5gc/udr/internal/util"
 	"github.com/free5gc/udr/pkg/factory"
-	"github.com/free5gc/util/metrics/sbi"
 )
 
 func (p *Processor) CreateEeGroupSubscriptionsProcedure(
@@ -56,9 +54,7 @@ func (p *Processor) QueryEeGroupSubscriptionsProcedure(c *gin.Context, ueGroupId
 
 	value, ok := udrSelf.UEGroupCollection.Load(ueGroupId)
 	if !ok {
-		pd := util.ProblemDetailsNotFound("USER_NOT_FOUND")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		fail(c, &Error{Category: ErrorNotFound, Cause: "USER_NOT_FOUND"})
 		return
 	}
 
@@ -70,9 +66,7 @@ func (p *Processor) QueryEeGroupSubscriptionsProcedure(c *gin.Context, ueGroupId
 	}
 
 	if len(eeSubscriptionSlice) == 0 {
-		pd := util.ProblemDetailsUpspecified("")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		fail(c, &Error{Category: ErrorNotAllowed, Cause: "UNSPECIFIED"})
 		return
 	}
 	c.JSON(http.StatusOK, eeSubscriptionSlice)
@@ -10,61 +10,76 @@
 package processor
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/free5gc/openapi/models"
-	udr_context "github.com/free5gc/udr/internal/context"
+	db "github.com/free5gc/udr/internal/database"
 	"github.com/free5gc/udr/internal/util"
-	"github.com/free5gc/util/metrics/sbi"
 )
 
+var errEeSubscriptionNotFound = &Error{
+	Category: ErrorNotFound, Cause: "SUBSCRIPTION_NOT_FOUND", Message: "no such EE subscription",
+}
+
+// RemoveeeSubscriptionsProcedure removes the EE subscription along with its AMF subscriptions, within a
+// transaction when the datastore supports them. Otherwise the AMF subscriptions are removed first, so that a
+// failure leaves no AMF subscription behind a removed subscription.
 func (p *Processor) RemoveeeSubscriptionsProcedure(c *gin.Context, ueId string, subsId string) {
-	udrSelf := udr_context.GetSelf()
-	value, ok := udrSelf.UESubsCollection.Load(ueId)
-	if !ok {
-		pd := util.ProblemDetailsNotFound("USER_NOT_FOUND")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
-		return
+	filter := eeSubscriptionFilter(ueId, subsId)
+	_, pd := p.GetDataFromDB(c, db.SUBSCDATA_EE_SUBSC_DB_COLLECTION_NAME, filter)
+	var err error
+	if pd != nil {
+		err = problemOf(pd).notFoundCause("SUBSCRIPTION_NOT_FOUND")
+	} else {
+		_, err = p.WithTransaction(c, func(ctx context.Context) error {
+			if err := p.DeleteOneDataFromDB(ctx, db.SUBSCDATA_EE_AMF_SUBSC_DB_COLLECTION_NAME, filter); err != nil {
+				return err
+			}
+			return p.DeleteOneDataFromDB(ctx, db.SUBSCDATA_EE_SUBSC_DB_COLLECTION_NAME, filter)
+		})
 	}
-
-	UESubsData := value.(*udr_context.UESubsData)
-	_, ok = UESubsData.EeSubscriptionCollection[subsId]
-
-	if !ok {
-		pd := util.ProblemDetailsNotFound("SUBSCRIPTION_NOT_FOUND")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+	if err != nil {
+		dataRepoLog(c).Errorf("RemoveeeSubscriptionsProcedure of %s err: %+v", subsId, err)
+		fail(c, err)
 		return
 	}
-	delete(UESubsData.EeSubscriptionCollection, subsId)
 	c.Status(http.StatusNoContent)
 }
 
-func (p *Processor) UpdateEesubscriptionsProcedure(c *gin.Context, ueId string, subsId string,
-	EeSubscription models.EeSubscription,
-) {
-	udrSelf := udr_context.GetSelf()
-	value, ok := udrSelf.UESubsCollection.Load(ueId)
-	if !ok {
-		pd := util.ProblemDetailsNotFound("USER_NOT_FOUND")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+// QueryeeSubscriptionProcedure answers the EE subscription subsId of ueId
+func (p *Processor) QueryeeSubscriptionProcedure(c *gin.Context, ueId string, subsId string) {
+	data, pd := p.GetDataFromDB(c, db.SUBSCDATA_EE_SUBSC_DB_COLLECTION_NAME, eeSubscriptionFilter(ueId, subsId))
+	if pd != nil {
+		dataRepoLog(c).Errorf("QueryeeSubscriptionProcedure of %s err: %s", subsId, pd.Detail)
+		fail(c, problemOf(pd).notFoundCause("SUBSCRIPTION_NOT_FOUND"))
 		return
 	}
+	c.JSON(http.StatusOK, eeSubscriptionOf(data))
+}
 
-	UESubsData := value.(*udr_context.UESubsData)
-	_, ok = UESubsData.EeSubscriptionCollection[subsId]
+// UpdateEesubscriptionsProcedure replaces the EE subscription, the subscription shall have been created before
+func (p *Processor) UpdateEesubscriptionsProcedure(c *gin.Context, ueId string, subsId string,
+	EeSubscription models.UdmEeEeSubscription,
+) {
+	filter := eeSubscriptionFilter(ueId, subsId)
+	putData := util.ToBsonM(EeSubscription)
+	putData["ueId"] = ueId
+	putData["subsId"] = subsId
 
-	if !ok {
-		pd := util.ProblemDetailsNotFound("SUBSCRIPTION_NOT_FOUND")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+	_, _, err := p.ReplaceVersionedDataToDB(c, db.SUBSCDATA_EE_SUBSC_DB_COLLECTION_NAME, filter, putData, "",
+		func(current map[string]interface{}) error {
+			if current == nil {
+				return errEeSubscriptionNotFound
+			}
+			return nil
+		})
+	if err != nil {
+		dataRepoLog(c).Errorf("UpdateEesubscriptionsProcedure of %s err: %+v", subsId, err)
+		fail(c, err)
 		return
 	}
-	UESubsData.EeSubscriptionCollection[subsId].EeSubscriptions = &EeSubscription
-
 	c.Status(http.StatusNoContent)
 }
@@ -12,66 +12,78 @@ package processor
 import (
 	"fmt"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
 
 	"github.com/free5gc/openapi/models"
 	udr_context "github.com/free5gc/udr/internal/context"
+	db "github.com/free5gc/udr/internal/database"
 	"github.com/free5gc/udr/internal/util"
-	"github.com/free5gc/util/metrics/sbi"
+	"github.com/free5gc/udr/pkg/factory"
 )
 
-func (p *Processor) CreateEeSubscriptionsProcedure(c *gin.Context, ueId string, EeSubscription models.EeSubscription) {
-	udrSelf := udr_context.GetSelf()
+// The EE subscriptions of a UE, a group of UEs or any UE are stored one document per subscription, along with
+// the ueId and the subsId they are looked up by. The subsId is a UUID assigned on creation, so that it stays
+// unique across the restarts and the instances of the UDR.
 
-	value, ok := udrSelf.UESubsCollection.Load(ueId)
-	if !ok {
-		udrSelf.UESubsCollection.Store(ueId, new(udr_context.UESubsData))
-		value, _ = udrSelf.UESubsCollection.Load(ueId)
-	}
-	UESubsData := value.(*udr_context.UESubsData)
-	if UESubsData.EeSubscriptionCollection == nil {
-		UESubsData.EeSubscriptionCollection = make(map[string]*udr_context.EeSubscriptionCollection)
+// CreateEeSubscriptionsProcedure stores the EE subscription under a new subsId, and answers 201 with its URI
+func (p *Processor) CreateEeSubscriptionsProcedure(c *gin.Context, ueId string,
+	EeSubscription models.UdmEeEeSubscription,
+) {
+	subsId := uuid.New().String()
+	putData := util.ToBsonM(EeSubscription)
+	putData["ueId"] = ueId
+	putData["subsId"] = subsId
+	if err := p.InsertDataToDB(c, db.SUBSCDATA_EE_SUBSC_DB_COLLECTION_NAME, putData); err != nil {
+		dataRepoLog(c).Errorf("CreateEeSubscriptionsProcedure err: %+v", err)
+		fail(c, err)
+		return
 	}
 
-	newSubscriptionID := strconv.Itoa(udrSelf.EeSubscriptionIDGenerator)
-	UESubsData.EeSubscriptionCollection[newSubscriptionID] = new(udr_context.EeSubscriptionCollection)
-	UESubsData.EeSubscriptionCollection[newSubscriptionID].EeSubscriptions = &EeSubscription
-	udrSelf.EeSubscriptionIDGenerator++
-
-	/* Contains the URI of the newly created resource, according
-	   to the structure: {apiRoot}/subscription-data/{ueId}/context-data/ee-subscriptions/{subsId} */
-	locationHeader := fmt.Sprintf("%s/subscription-data/%s/context-data/ee-subscriptions/%s",
-		udrSelf.GetIPv4GroupUri(udr_context.NUDR_DR), ueId, newSubscriptionID)
-
-	c.Header("Location", locationHeader)
+	c.Header("Location", eeSubscriptionUri(c, ueId, subsId))
 	c.JSON(http.StatusCreated, EeSubscription)
 }
 
+// QueryeesubscriptionsProcedure answers the EE subscriptions of ueId, an empty list when there is none
 func (p *Processor) QueryeesubscriptionsProcedure(c *gin.Context, ueId string) {
-	udrSelf := udr_context.GetSelf()
-
-	value, ok := udrSelf.UESubsCollection.Load(ueId)
-	if !ok {
-		pd := util.ProblemDetailsNotFound("USER_NOT_FOUND")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+	data, err := p.GetManyDataFromDB(c, db.SUBSCDATA_EE_SUBSC_DB_COLLECTION_NAME, bson.M{"ueId": ueId})
+	if err != nil {
+		dataRepoLog(c).Errorf("QueryeesubscriptionsProcedure err: %+v", err)
+		fail(c, err)
 		return
 	}
 
-	UESubsData := value.(*udr_context.UESubsData)
-	var eeSubscriptionSlice []models.EeSubscription
-
-	for _, v := range UESubsData.EeSubscriptionCollection {
-		eeSubscriptionSlice = append(eeSubscriptionSlice, *v.EeSubscriptions)
+	eeSubscriptions := make([]map[string]interface{}, 0, len(data))
+	for _, eeSubscription := range data {
+		eeSubscriptions = append(eeSubscriptions, eeSubscriptionOf(eeSubscription))
 	}
+	c.JSON(http.StatusOK, eeSubscriptions)
+}
 
-	if len(eeSubscriptionSlice) == 0 {
-		pd := util.ProblemDetailsUpspecified("")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
-		return
+// eeSubscriptionFilter matches the EE subscription subsId of ueId, and its AMF subscriptions
+func eeSubscriptionFilter(ueId, subsId string) bson.M {
+	return bson.M{"ueId": ueId, "subsId": subsId}
+}
+
+// eeSubscriptionOf strips the stored document down to the EE subscription
+func eeSubscriptionOf(data map[string]interface{}) map[string]interface{} {
+	delete(data, util.DocumentVersionKey)
+	delete(data, "ueId")
+	delete(data, "subsId")
+	return data
+}
+
+// eeSubscriptionUri is the URI of the EE subscription, on the scheme and the host the request was sent to
+func eeSubscriptionUri(c *gin.Context, ueId, subsId string) string {
+	apiRoot := udr_context.GetSelf().GetIPv4GroupUri(udr_context.NUDR_DR)
+	if c.Request.Host != "" {
+		scheme := "http"
+		if c.Request.TLS != nil {
+			scheme = "https"
+		}
+		apiRoot = fmt.Sprintf("%s://%s%s", scheme, c.Request.Host, factory.UdrDrResUriPrefix)
 	}
-	c.JSON(http.StatusOK, eeSubscriptionSlice)
+	return fmt.Sprintf("%s/subscription-data/%s/context-data/ee-subscriptions/%s", apiRoot, ueId, subsId)
 }
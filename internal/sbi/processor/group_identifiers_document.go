@@ -0,0 +1,162 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/free5gc/openapi/models"
+	udr_context "github.com/free5gc/udr/internal/context"
+	db "github.com/free5gc/udr/internal/database"
+	"github.com/free5gc/udr/internal/util"
+)
+
+// GetGroupIdentifiers resolves a group by its external or internal identifier, exactly one of them is set.
+// With ueIdList the page pageNumber of pageSize members is answered, otherwise none. Only the members of the page,
+// and one more telling whether there is a next page, are read from the datastore.
+func (p *Processor) GetGroupIdentifiers(c *gin.Context, extGroupId string, intGroupId string, ueIdList bool,
+	pageSize, pageNumber int,
+) {
+	filter := bson.M{"intGroupId": intGroupId}
+	if extGroupId != "" {
+		filter = bson.M{"extGroupId": extGroupId}
+	}
+	query := db.Query{Limit: 1, Fields: []string{"extGroupId", "intGroupId", "allowedAfIds"}}
+	if ueIdList {
+		query = db.Query{Limit: 1, Slice: &db.Slice{
+			Field: "ueIdList", Skip: int64(pageSize) * int64(pageNumber-1), Limit: int64(pageSize) + 1,
+		}}
+	}
+
+	docs, err := p.Query(c, db.SUBSCDATA_GROUP_MEMBERSHIP_DB_COLLECTION_NAME, filter, query)
+	if err == nil && len(docs) == 0 {
+		err = &Error{Category: ErrorNotFound, Message: "no such group"}
+	}
+	if err != nil {
+		dataRepoLog(c).Errorf("GetGroupIdentifiers err: %+v", err)
+		fail(c, err)
+		return
+	}
+
+	var groupIdentifiers models.GroupIdentifiers
+	if err = json.Unmarshal(util.MapToByte(docs[0]), &groupIdentifiers); err != nil {
+		dataRepoLog(c).Errorf("GetGroupIdentifiers decode err: %+v", err)
+		fail(c, err)
+		return
+	}
+
+	if ueIdList && len(groupIdentifiers.UeIdList) > pageSize {
+		groupIdentifiers.UeIdList = groupIdentifiers.UeIdList[:pageSize]
+		c.Header("Link", nextPageLink(c.Request.URL, pageSize, pageNumber))
+	}
+	c.JSON(http.StatusOK, groupIdentifiers)
+}
+
+// PutGroupIdentifiers stores the group by its intGroupId, 201 is answered when it is new and 204 otherwise.
+// An extGroupId of another group is answered 409, the check is not atomic with a concurrent request though.
+func (p *Processor) PutGroupIdentifiers(c *gin.Context, groupIdentifiers models.GroupIdentifiers) {
+	other, pd := p.GetDataFromDB(c, db.SUBSCDATA_GROUP_MEMBERSHIP_DB_COLLECTION_NAME,
+		bson.M{"extGroupId": groupIdentifiers.ExtGroupId})
+	var err error
+	switch {
+	case pd == nil && other["intGroupId"] != groupIdentifiers.IntGroupId:
+		err = &Error{Category: ErrorConflict, Message: fmt.Sprintf("extGroupId %s is the one of group %v",
+			groupIdentifiers.ExtGroupId, other["intGroupId"])}
+	case pd != nil && pd.Status != http.StatusNotFound:
+		err = problemOf(pd)
+	}
+	if err != nil {
+		dataRepoLog(c).Errorf("PutGroupIdentifiers err: %+v", err)
+		fail(c, err)
+		return
+	}
+
+	existed, _, err := p.ReplaceVersionedDataToDB(c, db.SUBSCDATA_GROUP_MEMBERSHIP_DB_COLLECTION_NAME,
+		bson.M{"intGroupId": groupIdentifiers.IntGroupId}, util.ToBsonM(groupIdentifiers), ifMatchOf(c), nil)
+	if err != nil {
+		dataRepoLog(c).Errorf("PutGroupIdentifiers err: %+v", err)
+		if !abortVersionedWrite(c, err) {
+			fail(c, err)
+		}
+		return
+	}
+
+	if existed {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.Header("Location", fmt.Sprintf("%s/subscription-data/group-data/group-identifiers?int-group-id=%s",
+		udr_context.GetSelf().GetIPv4GroupUri(udr_context.NUDR_DR), url.QueryEscape(groupIdentifiers.IntGroupId)))
+	c.JSON(http.StatusCreated, groupIdentifiers)
+}
+
+// MigrateGroupMembership rewrites the groupMembership documents of a former release, 5G VN group configurations
+// keyed by internalGroupIdentifier and externalGroupId with their members, as GroupIdentifiers. It is idempotent
+// and returns the count of documents rewritten.
+func (p *Processor) MigrateGroupMembership(ctx context.Context) (int, error) {
+	legacy := bson.M{"$or": bson.A{
+		bson.M{"internalGroupIdentifier": bson.M{"$exists": true}},
+		bson.M{"externalGroupId": bson.M{"$exists": true}},
+	}}
+	docs, err := p.Query(ctx, db.SUBSCDATA_GROUP_MEMBERSHIP_DB_COLLECTION_NAME, legacy, db.Query{})
+	if err != nil {
+		return 0, fmt.Errorf("MigrateGroupMembership query err: %w", err)
+	}
+
+	migrated := 0
+	for _, doc := range docs {
+		filter := bson.M{}
+		for _, key := range []string{"internalGroupIdentifier", "externalGroupId"} {
+			if value, ok := doc[key]; ok {
+				filter[key] = value
+			}
+		}
+		if _, _, err = p.PutOne(ctx, db.SUBSCDATA_GROUP_MEMBERSHIP_DB_COLLECTION_NAME, filter,
+			migratedGroupMembership(doc), ""); err != nil {
+			return migrated, fmt.Errorf("MigrateGroupMembership %v err: %w", filter, err)
+		}
+		migrated++
+	}
+	return migrated, nil
+}
+
+// migratedGroupMembership is the GroupIdentifiers document of a former 5G VN group configuration, its members
+// being SUPIs or GPSIs. Its other fields are kept.
+func migratedGroupMembership(doc map[string]interface{}) map[string]interface{} {
+	migrated := make(map[string]interface{}, len(doc))
+	for field, value := range doc {
+		switch field {
+		case "_id":
+		case "internalGroupIdentifier":
+			migrated["intGroupId"] = value
+		case "externalGroupId":
+			migrated["extGroupId"] = value
+		case "members":
+			var members []interface{}
+			switch v := value.(type) {
+			case []interface{}:
+				members = v
+			case bson.A:
+				members = v
+			}
+			ueIds := make([]interface{}, 0, len(members))
+			for _, member := range members {
+				id := fmt.Sprint(member)
+				if util.IsValidSupi(id) {
+					ueIds = append(ueIds, map[string]interface{}{"supi": id})
+				} else {
+					ueIds = append(ueIds, map[string]interface{}{"gpsiList": []interface{}{id}})
+				}
+			}
+			migrated["ueIdList"] = ueIds
+		default:
+			migrated[field] = value
+		}
+	}
+	return migrated
+}
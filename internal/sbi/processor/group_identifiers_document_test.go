@@ -0,0 +1,46 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+
+	db "github.com/free5gc/udr/internal/database"
+	"github.com/free5gc/udr/internal/database/memory"
+)
+
+func TestMigrateGroupMembership(t *testing.T) {
+	ctx := context.Background()
+	p := &Processor{DbConnector: memory.NewConnector()}
+	legacy := map[string]interface{}{
+		"internalGroupIdentifier": "20893001-001-01-01",
+		"externalGroupId":         "extgroupid-group1@example.com",
+		"members":                 []interface{}{"imsi-208930000000001", "msisdn-0900000001"},
+		"allowedAfIds":            []interface{}{"af1"},
+	}
+	_, _, err := p.PutOne(ctx, db.SUBSCDATA_GROUP_MEMBERSHIP_DB_COLLECTION_NAME,
+		bson.M{"internalGroupIdentifier": "20893001-001-01-01"}, legacy, "")
+	require.NoError(t, err)
+
+	migrated, err := p.MigrateGroupMembership(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, migrated)
+	migrated, err = p.MigrateGroupMembership(ctx)
+	require.NoError(t, err)
+	require.Zero(t, migrated)
+
+	docs, err := p.Query(ctx, db.SUBSCDATA_GROUP_MEMBERSHIP_DB_COLLECTION_NAME,
+		bson.M{"intGroupId": "20893001-001-01-01"}, db.Query{})
+	require.NoError(t, err)
+	require.Equal(t, []map[string]interface{}{{
+		"intGroupId": "20893001-001-01-01",
+		"extGroupId": "extgroupid-group1@example.com",
+		"ueIdList": []interface{}{
+			map[string]interface{}{"supi": "imsi-208930000000001"},
+			map[string]interface{}{"gpsiList": []interface{}{"msisdn-0900000001"}},
+		},
+		"allowedAfIds": []interface{}{"af1"},
+	}}, docs)
+}
@@ -20,10 +20,8 @@ import (
 
 	"github.com/free5gc/openapi/models"
 	udr_context "github.com/free5gc/udr/internal/context"
-	"github.com/free5gc/udr/internal/logger"
 	"github.com/free5gc/udr/internal/util"
 	"github.com/free5gc/util/metrics/sbi"
-	"github.com/free5gc/util/mongoapi"
 )
 
 func (p *Processor) ApplicationDataInfluenceDataInfluenceIdPutProcedure(
@@ -35,57 +33,39 @@ func (p *Processor) ApplicationDataInfluenceDataInfluenceIdPutProcedure(
 
 	var original *models.TrafficInfluData
 
-	if mapData, err := mongoapi.RestfulAPIGetOne(collName, filter); err != nil {
-		logger.DataRepoLog.Error(err.Error())
-		problemDetails := &models.ProblemDetails{
-			Status: http.StatusInternalServerError,
-			Detail: err.Error(),
-		}
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, http.StatusText(int(problemDetails.Status)))
-		c.JSON(int(problemDetails.Status), problemDetails)
+	if mapData, err := p.FindDataFromDB(c, collName, filter); err != nil {
+		dataRepoLog(c).Error(err.Error())
+		fail(c, err)
 		return
 	} else {
 		if len(mapData) != 0 {
 			original = new(models.TrafficInfluData)
 			byteData, err := json.Marshal(mapData)
 			if err != nil {
-				logger.DataRepoLog.Error(err.Error())
-				problemDetails := &models.ProblemDetails{
-					Status: http.StatusInternalServerError,
-					Detail: err.Error(),
-				}
-				c.Set(sbi.IN_PB_DETAILS_CTX_STR, http.StatusText(int(problemDetails.Status)))
-				c.JSON(int(problemDetails.Status), problemDetails)
+				dataRepoLog(c).Error(err.Error())
+				fail(c, err)
 				return
 			}
 			err = json.Unmarshal(byteData, &original)
 			if err != nil {
-				logger.DataRepoLog.Error(err.Error())
-				problemDetails := &models.ProblemDetails{
-					Status: http.StatusInternalServerError,
-					Detail: err.Error(),
-				}
-				c.Set(sbi.IN_PB_DETAILS_CTX_STR, http.StatusText(int(problemDetails.Status)))
-				c.JSON(int(problemDetails.Status), problemDetails)
+				dataRepoLog(c).Error(err.Error())
+				fail(c, err)
 				return
 			}
 		}
 	}
 
-	isExisted, err := mongoapi.RestfulAPIPutOne(collName, filter, putData)
+	isExisted, _, err := p.PutVersionedDataToDB(c, collName, filter, putData, ifMatchOf(c))
 	if err != nil {
-		logger.DataRepoLog.Errorf("ApplicationDataInfluenceDataInfluenceIdPutProcedure err: %+v", err)
-		problemDetails := &models.ProblemDetails{
-			Status: http.StatusInternalServerError,
-			Detail: err.Error(),
+		dataRepoLog(c).Errorf("ApplicationDataInfluenceDataInfluenceIdPutProcedure err: %+v", err)
+		if !abortVersionedWrite(c, err) {
+			fail(c, err)
 		}
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, http.StatusText(int(problemDetails.Status)))
-		c.JSON(int(problemDetails.Status), problemDetails)
 		return
 	}
 	if original == nil || !reflect.DeepEqual(*original, *request) {
 		// Notify the change of influence data
-		PreHandleInfluenceDataUpdateNotification(influenceId, original, request)
+		PreHandleInfluenceDataUpdateNotification(c, influenceId, original, request)
 	}
 
 	if isExisted {
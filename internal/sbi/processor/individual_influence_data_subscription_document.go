@@ -17,8 +17,6 @@ import (
 
 	"github.com/free5gc/openapi/models"
 	udr_context "github.com/free5gc/udr/internal/context"
-	"github.com/free5gc/udr/internal/util"
-	"github.com/free5gc/util/metrics/sbi"
 )
 
 func (p *Processor) ApplicationDataInfluenceDataSubsToNotifySubscriptionIdDeleteProcedure(
@@ -35,9 +33,7 @@ func (p *Processor) ApplicationDataInfluenceDataSubsToNotifySubscriptionIdGetPro
 	if subscription, ok := udrSelf.InfluenceDataSubscriptions.Load(subscriptionID); ok {
 		c.JSON(http.StatusOK, subscription)
 	} else {
-		pd := util.ProblemDetailsNotFound("USER_NOT_FOUND")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		fail(c, &Error{Category: ErrorNotFound, Cause: "USER_NOT_FOUND"})
 	}
 }
 
@@ -48,22 +44,15 @@ func (p *Processor) ApplicationDataInfluenceDataSubsToNotifySubscriptionIdPutPro
 		len(request.Snssais) == 0 &&
 		len(request.InternalGroupIds) == 0 &&
 		len(request.Supis) == 0 {
-		pd := &models.ProblemDetails{
-			Status: http.StatusBadRequest,
-			Detail: "At least one of DNNs, S-NSSAIs, Internal Group IDs or SUPIs shall be provided",
-		}
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, http.StatusText(int(pd.Status)))
-		c.JSON(int(pd.Status), pd)
+		fail(c, &Error{
+			Category: ErrorValidation,
+			Message:  "At least one of DNNs, S-NSSAIs, Internal Group IDs or SUPIs shall be provided",
+		})
 		return
 	}
 
 	if request.NotificationUri == "" {
-		pd := models.ProblemDetails{
-			Status: http.StatusBadRequest,
-			Detail: "Notification URI shall be provided",
-		}
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, http.StatusText(int(pd.Status)))
-		c.JSON(int(pd.Status), pd)
+		fail(c, &Error{Category: ErrorValidation, Message: "Notification URI shall be provided"})
 		return
 	}
 
@@ -71,7 +60,9 @@ func (p *Processor) ApplicationDataInfluenceDataSubsToNotifySubscriptionIdPutPro
 	if subs, ok := udrSelf.InfluenceDataSubscriptions.Load(subscriptionId); ok && reflect.DeepEqual(*request, subs) {
 		c.Status(http.StatusOK)
 	} else {
-		udrSelf.InfluenceDataSubscriptions.Store(subscriptionId, request)
+		if !dryRunSubscription(c, request) {
+			udrSelf.InfluenceDataSubscriptions.Store(subscriptionId, request)
+		}
 		c.JSON(http.StatusOK, request)
 	}
 }
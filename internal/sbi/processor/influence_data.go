@@ -19,44 +19,54 @@ import (
 
 	"github.com/free5gc/openapi/models"
 	udr_context "github.com/free5gc/udr/internal/context"
-	"github.com/free5gc/udr/internal/logger"
-	"github.com/free5gc/util/mongoapi"
 )
 
-func (p *Processor) ApplicationDataInfluenceDataGetProcedure(c *gin.Context, collName string, filter []bson.M) (
-	response *[]map[string]interface{},
-) {
-	influenceDataArray := make([]map[string]interface{}, 0)
+// ApplicationDataInfluenceDataGetProcedure answers the traffic influence data matching all of filter, all of it
+// when filter is empty
+func (p *Processor) ApplicationDataInfluenceDataGetProcedure(c *gin.Context, collName string, filter []bson.M) {
+	query := bson.M{}
 	if len(filter) != 0 {
-		var err error
-		influenceDataArray, err = mongoapi.RestfulAPIGetMany(collName, bson.M{"$and": filter})
-		if err != nil {
-			logger.DataRepoLog.Errorf("ApplicationDataInfluenceDataGetProcedure err: %+v", err)
-			return nil
-		}
+		query = bson.M{"$and": filter}
+	}
+	influenceDataArray, err := p.GetManyDataFromDB(c, collName, query)
+	if err != nil {
+		dataRepoLog(c).Errorf("ApplicationDataInfluenceDataGetProcedure err: %+v", err)
+		fail(c, err)
+		return
+	}
+	if influenceDataArray == nil {
+		influenceDataArray = make([]map[string]interface{}, 0)
 	}
 	for _, influenceData := range influenceDataArray {
 		groupUri := udr_context.GetSelf().GetIPv4GroupUri(udr_context.NUDR_DR)
 		influenceData["resUri"] = fmt.Sprintf("%s/application-data/influenceData/%s",
-			groupUri, influenceData["influenceId"].(string))
+			groupUri, influenceData["influenceId"])
 		delete(influenceData, "_id")
 		delete(influenceData, "influenceId")
 	}
 	c.JSON(http.StatusOK, influenceDataArray)
-	return
 }
 
-func (p *Processor) ParseSnssaisFromQueryParam(snssaiStr string) []models.Snssai {
+func (p *Processor) ParseSnssaisFromQueryParam(snssaiStr string) ([]models.Snssai, error) {
 	var snssais []models.Snssai
-	err := json.Unmarshal([]byte(snssaiStr), &snssais)
-	if err != nil {
-		logger.DataRepoLog.Warnln("Unmarshal Error in snssaiStruct", err)
+	if err := json.Unmarshal([]byte(snssaiStr), &snssais); err != nil {
+		return nil, &Error{
+			Category:      ErrorValidation,
+			Message:       "snssais shall be a JSON array of S-NSSAIs",
+			InvalidParams: []models.InvalidParam{{Param: "snssais", Reason: "shall be a JSON array of S-NSSAIs"}},
+			Err:           err,
+		}
 	}
-	return snssais
+	return snssais, nil
 }
 
+// BuildSnssaiMatchList matches the S-NSSAIs, the ones without an SD only the data without an SD either
 func (p *Processor) BuildSnssaiMatchList(snssais []models.Snssai) (matchList []bson.M) {
 	for _, v := range snssais {
+		if v.Sd == "" {
+			matchList = append(matchList, bson.M{"snssai.sst": v.Sst, "snssai.sd": bson.M{"$exists": false}})
+			continue
+		}
 		matchList = append(matchList, bson.M{"snssai.sst": v.Sst, "snssai.sd": v.Sd})
 	}
 	return
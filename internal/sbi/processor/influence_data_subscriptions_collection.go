@@ -20,10 +20,7 @@ import (
 
 	"github.com/free5gc/openapi/models"
 	udr_context "github.com/free5gc/udr/internal/context"
-	"github.com/free5gc/udr/internal/logger"
 	"github.com/free5gc/udr/internal/util"
-	"github.com/free5gc/util/metrics/sbi"
-	"github.com/free5gc/util/mongoapi"
 )
 
 func (p *Processor) ApplicationDataInfluenceDataSubsToNotifyGetProcedure(
@@ -35,7 +32,7 @@ func (p *Processor) ApplicationDataInfluenceDataSubsToNotifyGetProcedure(
 	udrSelf.InfluenceDataSubscriptions.Range(func(key, value interface{}) bool {
 		subs, ok := value.(*models.TrafficInfluSub)
 		if !ok {
-			logger.DataRepoLog.Errorf("Failed to load influence Data subscription ID [%+v]", key)
+			dataRepoLog(c).Errorf("Failed to load influence Data subscription ID [%+v]", key)
 			return true
 		} else if dnn != "" && !util.Contain(dnn, subs.Dnns) {
 			return true
@@ -61,33 +58,21 @@ func (p *Processor) ApplicationDataInfluenceDataSubsToNotifySubscriptionIdPostPr
 		len(request.Snssais) == 0 &&
 		len(request.InternalGroupIds) == 0 &&
 		len(request.Supis) == 0 {
-		pd := models.ProblemDetails{
-			Status: http.StatusBadRequest,
-			Detail: "At least one of DNNs, S-NSSAIs, Internal Group IDs or SUPIs shall be provided",
-		}
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, http.StatusText(int(pd.Status)))
-		c.JSON(int(pd.Status), pd)
+		fail(c, &Error{
+			Category: ErrorValidation,
+			Message:  "At least one of DNNs, S-NSSAIs, Internal Group IDs or SUPIs shall be provided",
+		})
 		return
 	}
 
 	if request.NotificationUri == "" {
-		pd := models.ProblemDetails{
-			Status: http.StatusBadRequest,
-			Detail: "Notification URI shall be provided",
-		}
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, http.StatusText(int(pd.Status)))
-		c.JSON(int(pd.Status), pd)
+		fail(c, &Error{Category: ErrorValidation, Message: "Notification URI shall be provided"})
 		return
 	}
 
 	udrSelf := udr_context.GetSelf()
 	if subs, ok := udrSelf.InfluenceDataSubscriptions.Load(subscriptionId); ok && reflect.DeepEqual(*request, subs) {
-		pd := &models.ProblemDetails{
-			Status: http.StatusForbidden,
-			Cause:  "UNSPECIFIED",
-		}
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		fail(c, &Error{Category: ErrorNotAllowed, Cause: "UNSPECIFIED"})
 	} else {
 		udrSelf.InfluenceDataSubscriptions.Store(subscriptionId, request)
 
@@ -104,12 +89,10 @@ func (p *Processor) ApplicationDataInfluenceDataInfluenceIdDeleteProcedure(
 ) {
 	filter := bson.M{"influenceId": influenceId}
 
-	mapData, err := mongoapi.RestfulAPIGetOne(collName, filter)
+	mapData, err := p.FindDataFromDB(c, collName, filter)
 	if err != nil {
-		logger.DataRepoLog.Errorf("ApplicationDataInfluenceDataInfluenceIdDeleteProcedure err: %+v", err)
-		pd := util.ProblemDetailsUpspecified("")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		dataRepoLog(c).Errorf("ApplicationDataInfluenceDataInfluenceIdDeleteProcedure err: %+v", err)
+		fail(c, &Error{Category: ErrorNotAllowed, Cause: "UNSPECIFIED"})
 		return
 	}
 	var original *models.TrafficInfluData
@@ -117,32 +100,26 @@ func (p *Processor) ApplicationDataInfluenceDataInfluenceIdDeleteProcedure(
 		original = new(models.TrafficInfluData)
 		byteData, err := json.Marshal(mapData)
 		if err != nil {
-			logger.DataRepoLog.Error(err.Error())
-			pd := util.ProblemDetailsUpspecified(err.Error())
-			c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-			c.JSON(int(pd.Status), pd)
+			dataRepoLog(c).Error(err.Error())
+			fail(c, &Error{Category: ErrorNotAllowed, Cause: "UNSPECIFIED", Err: err})
 			return
 		}
 		err = json.Unmarshal(byteData, &original)
 		if err != nil {
-			logger.DataRepoLog.Error(err.Error())
-			pd := util.ProblemDetailsUpspecified(err.Error())
-			c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-			c.JSON(int(pd.Status), pd)
+			dataRepoLog(c).Error(err.Error())
+			fail(c, &Error{Category: ErrorNotAllowed, Cause: "UNSPECIFIED", Err: err})
 			return
 		}
 	}
 
-	if err := mongoapi.RestfulAPIDeleteOne(collName, filter); err != nil {
-		logger.DataRepoLog.Errorf("InfluIdDelProcedure: %+v", err)
-		pd := util.ProblemDetailsUpspecified(err.Error())
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+	if err := p.DeleteOneDataFromDB(c, collName, filter); err != nil {
+		dataRepoLog(c).Errorf("InfluIdDelProcedure: %+v", err)
+		fail(c, &Error{Category: ErrorNotAllowed, Cause: "UNSPECIFIED", Err: err})
 		return
 	}
 
 	// Notify the change of influence data
-	PreHandleInfluenceDataUpdateNotification(influenceId, original, nil)
+	PreHandleInfluenceDataUpdateNotification(c, influenceId, original, nil)
 
 	c.Status(http.StatusNoContent)
 }
@@ -0,0 +1,100 @@
+package processor
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// QueryLcsPrivacyDataProcedure answers the LcsPrivacyData of the UE, its members of fields only when given
+func (p *Processor) QueryLcsPrivacyDataProcedure(c *gin.Context, collName string, ueId string, fields []string) {
+	p.queryLcsDataSet(c, collName, ueId, fields)
+}
+
+// QueryLcsMoDataProcedure answers the LcsMoData of the UE, its members of fields only when given
+func (p *Processor) QueryLcsMoDataProcedure(c *gin.Context, collName string, ueId string, fields []string) {
+	p.queryLcsDataSet(c, collName, ueId, fields)
+}
+
+// queryLcsDataSet answers the LCS data set of the UE, the same in any serving PLMN
+func (p *Processor) queryLcsDataSet(c *gin.Context, collName string, ueId string, fields []string) {
+	filter := bson.M{"ueId": ueId}
+	data, pd := p.GetDataFromDB(c, collName, filter)
+	if pd != nil {
+		dataRepoLog(c).Errorf("queryLcsDataSet %s err: %s", collName, pd.Detail)
+		fail(c, p.provisionedDataFailure(c, ueId, pd))
+		return
+	}
+
+	// ueId is the key of the document, not a member of the data set
+	delete(data, "ueId")
+	c.JSON(http.StatusOK, projectFields(data, fields))
+}
+
+// projectFields keeps the members of data named by fields, all of them when fields is empty. A field may be
+// the dotted path of a nested member, the elements of an array are projected each, and a member named as a
+// whole is kept whole whatever its paths besides. The nested values shall be of JSON, as decoded into interface{}.
+func projectFields(data map[string]interface{}, fields []string) map[string]interface{} {
+	if len(fields) == 0 {
+		return data
+	}
+	// nested holds the paths within each member, nil for the members kept whole
+	nested := make(map[string][]string, len(fields))
+	for _, field := range fields {
+		name, path, isNested := strings.Cut(field, ".")
+		paths, seen := nested[name]
+		switch {
+		case !isNested:
+			nested[name] = nil
+		case !seen || paths != nil:
+			nested[name] = append(paths, path)
+		}
+	}
+	projected := make(map[string]interface{}, len(nested))
+	for name, paths := range nested {
+		value, ok := data[name]
+		if !ok {
+			continue
+		}
+		if paths != nil {
+			if value = projectValue(value, paths); value == nil {
+				continue
+			}
+		}
+		projected[name] = value
+	}
+	return projected
+}
+
+// projectDocument projects the document read from the datastore to fields, through its JSON so that the
+// nested documents are projected as well
+func projectDocument(data map[string]interface{}, fields []string) (map[string]interface{}, error) {
+	if len(fields) == 0 {
+		return data, nil
+	}
+	var document map[string]interface{}
+	if err := decodeJSONDataSet(data, &document); err != nil {
+		return nil, err
+	}
+	return projectFields(document, fields), nil
+}
+
+// projectValue projects the object value, or each object of the array value, to the paths; nil tells that
+// the value has no such member
+func projectValue(value interface{}, paths []string) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		return projectFields(value, paths)
+	case []interface{}:
+		elements := make([]interface{}, 0, len(value))
+		for _, element := range value {
+			if element = projectValue(element, paths); element != nil {
+				elements = append(elements, element)
+			}
+		}
+		return elements
+	}
+	return nil
+}
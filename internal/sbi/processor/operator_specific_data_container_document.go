@@ -10,44 +10,181 @@
 package processor
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"sort"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 
 	"github.com/free5gc/openapi/models"
-	"github.com/free5gc/udr/internal/logger"
+	db "github.com/free5gc/udr/internal/database"
 	"github.com/free5gc/udr/internal/util"
-	"github.com/free5gc/util/metrics/sbi"
 )
 
+// operSpecDataField holds the operator specific data of the UE in its document, keyed by data name, as the
+// operator specific policy data is
+const operSpecDataField = "operatorSpecificDataContainerMap"
+
+// errInvalidOperSpecData is the failure of the operator specific data resulting from a patch
+var errInvalidOperSpecData = errors.New("invalid operator specific data")
+
+// PatchOperSpecDataProcedure modifies the operator specific data of the UE by a JSON Patch or a JSON Merge
+// Patch, relative to the map of the data names. The modified data shall still be valid.
 func (p *Processor) PatchOperSpecDataProcedure(
-	c *gin.Context, collName string, ueId string, patchItem []models.PatchItem,
+	c *gin.Context, collName string, ueId string, patch PatchDocument,
 ) {
-	var origValue, newValue map[string]interface{}
-	var err error
-
-	filter := bson.M{"ueId": ueId}
-	if origValue, newValue, err = p.PatchDataToDBAndNotify(collName, ueId, patchItem, filter); err != nil {
-		logger.DataRepoLog.Errorf("PatchOperSpecDataProcedure err: %+v", err)
-		pd := util.ProblemDetailsModifyNotAllowed("")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+	var origData, newData map[string]interface{}
+	// patchErr is the failure of the patch itself, rather than of the datastore
+	var patchErr error
+	_, _, err := p.ModifyDataFieldToDB(c, collName, bson.M{"ueId": ueId}, operSpecDataField,
+		func(value []byte) ([]byte, bool, error) {
+			if string(value) == "null" {
+				value = []byte("{}")
+			}
+			modified, applyErr := patch.apply(value)
+			if applyErr == nil {
+				applyErr = decodeOperSpecData(modified, &newData)
+			}
+			if applyErr == nil {
+				applyErr = json.Unmarshal(value, &origData)
+			}
+			if applyErr != nil {
+				patchErr = applyErr
+				return nil, false, applyErr
+			}
+			return modified, true, nil
+		})
+	switch {
+	case errors.Is(patchErr, errInvalidOperSpecData):
+		err = &Error{Category: ErrorValidation, Err: patchErr}
+	case patchErr != nil:
+		err = patchFailure(patchErr,
+			&Error{Category: ErrorNotAllowed, Message: "Occur error when applying the patch"})
+	case errors.Is(err, db.ErrNoDocument):
+		err = &Error{Category: ErrorNotFound}
+	}
+	if err != nil {
+		dataRepoLog(c).Errorf("PatchOperSpecDataProcedure of %s err: %+v", ueId, err)
+		fail(c, err)
 		return
 	}
-	PreHandleOnDataChangeNotify(ueId, CurrentResourceUri, patchItem, origValue, newValue)
+	p.NotifySubscribers(c, ueId, c.Request.URL.Path, patch.changes(origData, newData))
 	c.Status(http.StatusNoContent)
 }
 
-func (p *Processor) QueryOperSpecDataProcedure(c *gin.Context, collName string, ueId string) {
-	filter := bson.M{"ueId": ueId}
-	data, pd := p.GetDataFromDB(collName, filter)
-	// The key of the map is operator specific data element name and the value is the operator specific data of the UE.
+// QueryOperSpecDataProcedure answers the operator specific data of the UE keyed by data name, the data of
+// fields only when given. Nothing provisioned is answered 404.
+func (p *Processor) QueryOperSpecDataProcedure(c *gin.Context, collName string, ueId string, fields []string) {
+	data, pd := p.GetDataFromDB(c, collName, bson.M{"ueId": ueId})
+	var operSpecData map[string]interface{}
+	var err error
 	if pd != nil {
-		logger.DataRepoLog.Errorf("QueryOperSpecDataProcedure err: %s", pd.Detail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		err = p.provisionedDataFailure(c, ueId, pd)
+	} else {
+		// The map is decoded through its JSON, whatever the type of the nested documents in data
+		var raw []byte
+		raw, err = json.Marshal(data[operSpecDataField])
+		if err == nil {
+			err = json.Unmarshal(raw, &operSpecData)
+		}
+		if err == nil && len(operSpecData) == 0 {
+			err = p.provisionedDataNotFound(c, ueId)
+		}
+	}
+	if err != nil {
+		dataRepoLog(c).Errorf("QueryOperSpecDataProcedure of %s err: %+v", ueId, err)
+		fail(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, data)
+	c.JSON(http.StatusOK, projectFields(operSpecData, fields))
+}
+
+// CreateOperSpecDataProcedure stores the operator specific data of the UE in place of the previous one
+func (p *Processor) CreateOperSpecDataProcedure(c *gin.Context, collName string, ueId string,
+	operSpecData map[string]models.OperatorSpecificDataContainer,
+) {
+	if detail := validateOperSpecData(operSpecData); detail != "" {
+		dataRepoLog(c).Warnf("CreateOperSpecDataProcedure of %s: %s", ueId, detail)
+		fail(c, &Error{Category: ErrorValidation, Message: detail})
+		return
+	}
+
+	putData := map[string]interface{}{"ueId": ueId, operSpecDataField: util.ToBsonM(operSpecData)}
+	existed, _, err := p.ReplaceVersionedDataToDB(c, collName, bson.M{"ueId": ueId}, putData, ifMatchOf(c), nil)
+	if err != nil {
+		dataRepoLog(c).Errorf("CreateOperSpecDataProcedure err: %+v", err)
+		if !abortVersionedWrite(c, err) {
+			fail(c, err)
+		}
+		return
+	}
+	p.NotifySubscribers(c, ueId, c.Request.URL.Path, documentChanges(util.ToBsonM(operSpecData)))
+
+	if existed {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.JSON(http.StatusCreated, operSpecData)
+}
+
+// DeleteOperSpecDataProcedure removes the operator specific data of the UE, none is not a failure
+func (p *Processor) DeleteOperSpecDataProcedure(c *gin.Context, collName string, ueId string) {
+	deleted, _, err := p.DeleteManyDataFromDB(c, []string{collName}, bson.M{"ueId": ueId})
+	if err != nil {
+		dataRepoLog(c).Errorf("DeleteOperSpecDataProcedure err: %+v", err)
+		fail(c, err)
+		return
+	}
+	if deleted[collName] > 0 {
+		p.NotifySubscribers(c, ueId, c.Request.URL.Path, documentChanges(nil))
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// decodeOperSpecData decodes the operator specific data of a patch into data, once it is checked valid
+func decodeOperSpecData(modified []byte, data *map[string]interface{}) error {
+	var operSpecData map[string]models.OperatorSpecificDataContainer
+	if err := json.Unmarshal(modified, &operSpecData); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidOperSpecData, err)
+	}
+	if detail := validateOperSpecData(operSpecData); detail != "" {
+		return fmt.Errorf("%w: %s", errInvalidOperSpecData, detail)
+	}
+	return json.Unmarshal(modified, data)
+}
+
+// validateOperSpecData returns the detail of the first problem found, by data name: the names shall be
+// usable as the fields of a document and the value of each container shall be of its dataType
+func validateOperSpecData(operSpecData map[string]models.OperatorSpecificDataContainer) string {
+	dataNames := make([]string, 0, len(operSpecData))
+	for dataName := range operSpecData {
+		dataNames = append(dataNames, dataName)
+	}
+	sort.Strings(dataNames)
+
+	for _, dataName := range dataNames {
+		if dataName == "" || strings.HasPrefix(dataName, "$") || strings.Contains(dataName, ".") {
+			return fmt.Sprintf("%q is not a valid data name", dataName)
+		}
+		container := operSpecData[dataName]
+		var valid bool
+		switch value := container.Value.(type) {
+		case string:
+			valid = container.DataType == "string"
+		case float64:
+			valid = container.DataType == "number" || container.DataType == "integer" && value == float64(int64(value))
+		case bool:
+			valid = container.DataType == "boolean"
+		case map[string]interface{}:
+			valid = container.DataType == "object"
+		}
+		if !valid {
+			return fmt.Sprintf("%s: value is not of dataType %q", dataName, container.DataType)
+		}
+	}
+	return ""
 }
@@ -10,23 +10,12 @@
 package processor
 
 import (
-	"net/http"
-
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 
-	"github.com/free5gc/udr/internal/logger"
-	"github.com/free5gc/util/metrics/sbi"
+	"github.com/free5gc/openapi/models"
 )
 
+// GetppDataProcedure answers the PpData of the UE, 404 when none was provisioned
 func (p *Processor) GetppDataProcedure(c *gin.Context, collName string, ueId string) {
-	filter := bson.M{"ueId": ueId}
-	data, pd := p.GetDataFromDB(collName, filter)
-	if pd != nil {
-		logger.DataRepoLog.Errorf("GetppDataProcedure err: %s", pd.Detail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
-		return
-	}
-	c.JSON(http.StatusOK, data)
+	p.queryDecodedDataSet(c, "GetppDataProcedure", collName, ueId, &models.PpData{})
 }
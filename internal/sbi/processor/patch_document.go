@@ -0,0 +1,127 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/free5gc/openapi/models"
+)
+
+// PatchDocument is the body of a PATCH request, a JSON Patch (RFC 6902) or a JSON Merge Patch (RFC 7386)
+// as told by its Content-Type
+type PatchDocument struct {
+	// Set for application/json-patch+json
+	PatchItems []models.PatchItem
+	// Set for application/merge-patch+json, a JSON object
+	MergePatch []byte
+}
+
+func (d PatchDocument) IsMergePatch() bool {
+	return d.MergePatch != nil
+}
+
+func (d PatchDocument) String() string {
+	if d.IsMergePatch() {
+		return string(d.MergePatch)
+	}
+	return fmt.Sprintf("%+v", d.PatchItems)
+}
+
+// apply returns the JSON document original modified by the patch
+func (d PatchDocument) apply(original []byte) ([]byte, error) {
+	if d.IsMergePatch() {
+		return jsonpatch.MergePatch(original, d.MergePatch)
+	}
+	patch, err := decodePatchItems(d.PatchItems)
+	if err != nil {
+		return nil, err
+	}
+	return patch.Apply(original)
+}
+
+// changes describes the modification to the subscribers of the resource, a merge patch carries no
+// operation and is notified as the replacement of the document
+func (d PatchDocument) changes(origValue, newValue map[string]interface{}) []models.ChangeItem {
+	if d.IsMergePatch() {
+		return documentChanges(newValue)
+	}
+	return patchChanges(d.PatchItems, origValue, newValue)
+}
+
+func (p *Processor) patchDataToDBAndNotify(ctx context.Context, collName string, ueId string,
+	patch PatchDocument, filter bson.M,
+) (origValue, newValue map[string]interface{}, err error) {
+	if patch.IsMergePatch() {
+		return p.MergePatchDataToDBAndNotify(ctx, collName, ueId, patch.MergePatch, filter)
+	}
+	return p.PatchDataToDBAndNotify(ctx, collName, ueId, patch.PatchItems, filter)
+}
+
+func (p *Processor) patchVersionedDataToDB(ctx context.Context, collName string, filter bson.M,
+	patch PatchDocument, ifMatch string,
+) (origValue, newValue map[string]interface{}, etag string, err error) {
+	if patch.IsMergePatch() {
+		return p.MergePatchVersionedDataToDB(ctx, collName, filter, patch.MergePatch, ifMatch, nil)
+	}
+	return p.PatchVersionedDataToDB(ctx, collName, filter, patch.PatchItems, ifMatch)
+}
+
+// patchFailure is the failure of a JSON Patch not applying to the document, otherwise for the other errors:
+// 409 when a test operation fails on the current document, 422 when an operation refers to a location the
+// document does not have or is not a valid operation
+func patchFailure(err, otherwise error) error {
+	switch {
+	case errors.Is(err, jsonpatch.ErrTestFailed):
+		return &Error{Category: ErrorConflict, Err: err}
+	case errors.Is(err, jsonpatch.ErrMissing), errors.Is(err, jsonpatch.ErrInvalidIndex),
+		errors.Is(err, jsonpatch.ErrUnknownType), errors.Is(err, jsonpatch.ErrInvalid):
+		return &Error{Category: ErrorUnprocessable, Err: err}
+	}
+	return otherwise
+}
+
+// unmodifiableField returns the location of the first modification of the patch outside of the top-level
+// fields of modifiable, the test operations modify nothing. A merge patch that is not an object is let
+// through, applying it fails.
+func unmodifiableField(patch PatchDocument, modifiable []string) (string, bool) {
+	isModifiable := func(path string) bool {
+		for _, field := range modifiable {
+			if isPatchPathUnder(path, field) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if patch.IsMergePatch() {
+		var document map[string]json.RawMessage
+		if err := json.Unmarshal(patch.MergePatch, &document); err != nil {
+			return "", false
+		}
+		for _, field := range slices.Sorted(maps.Keys(document)) {
+			if !isModifiable("/" + field) {
+				return "/" + field, true
+			}
+		}
+		return "", false
+	}
+
+	for _, item := range patch.PatchItems {
+		switch {
+		case item.Op == models.PatchOperation_TEST:
+		case !isModifiable(item.Path):
+			return item.Path, true
+		// Moving a field away removes it
+		case item.Op == models.PatchOperation_MOVE && !isModifiable(item.From):
+			return item.From, true
+		}
+	}
+	return "", false
+}
@@ -1,18 +1,40 @@
 package processor
 
 import (
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
 	"github.com/free5gc/udr/internal/database"
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/util"
 	"github.com/free5gc/udr/pkg/app"
 )
 
 type Processor struct {
 	app.App
 	database.DbConnector
+
+	// audit is nil when the audit trail is disabled
+	audit *auditTrail
+	// authSubsCache is nil when the authentication subscriptions are not cached
+	authSubsCache *authSubsCache
 }
 
 func NewProcessor(udr app.App) *Processor {
-	return &Processor{
+	p := &Processor{
 		App:         udr,
 		DbConnector: database.NewDbConnector(udr.Config().Configuration.DbConnectorType),
 	}
+	if udr.Config().IsAuditEnabled() {
+		p.audit = newAuditTrail(udr.Config().GetAuditQueueSize(), p.writeAuditRecord)
+	}
+	if udr.Config().IsAuthSubsCacheEnabled() {
+		p.authSubsCache = newAuthSubsCache(udr.Config().GetAuthSubsCacheSize(), udr.Config().GetAuthSubsCacheTtl())
+	}
+	return p
+}
+
+// dataRepoLog is logger.DataRepoLog carrying the request ID of c
+func dataRepoLog(c *gin.Context) *logrus.Entry {
+	return util.RequestLog(c, logger.DataRepoLog)
 }
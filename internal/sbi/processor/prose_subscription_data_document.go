@@ -0,0 +1,13 @@
+package processor
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/free5gc/openapi/models"
+)
+
+// QueryProseDataProcedure answers the ProseSubscriptionData of the UE, the same in any serving PLMN. The data set
+// has no supportedFeatures member, the features of the consumer are checked by the handler only.
+func (p *Processor) QueryProseDataProcedure(c *gin.Context, collName string, ueId string) {
+	p.queryDecodedDataSet(c, "QueryProseDataProcedure", collName, ueId, &models.ProseSubscriptionData{})
+}
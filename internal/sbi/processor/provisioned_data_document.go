@@ -10,175 +10,245 @@
 package processor
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"reflect"
+	"slices"
 
 	"github.com/gin-gonic/gin"
 	"github.com/mitchellh/mapstructure"
 	"go.mongodb.org/mongo-driver/bson"
+	"golang.org/x/sync/errgroup"
 
-	"github.com/free5gc/openapi"
 	"github.com/free5gc/openapi/models"
-	"github.com/free5gc/udr/internal/logger"
 	"github.com/free5gc/udr/internal/util"
-	"github.com/free5gc/util/metrics/sbi"
 	"github.com/free5gc/util/mongoapi"
 )
 
-func (p *Processor) QueryProvisionedDataProcedure(c *gin.Context, ueId string, servingPlmnId string,
-	provisionedDataSets models.ProvisionedDataSets,
-) {
-	var collName string
-	var filter bson.M
-
-	collName = "subscriptionData.provisionedData.amData"
-	filter = bson.M{"ueId": ueId, "servingPlmnId": servingPlmnId}
-	accessAndMobilitySubscriptionData, pd := p.GetDataFromDB(collName, filter)
-	if pd != nil && pd.Status == http.StatusInternalServerError {
-		logger.DataRepoLog.Errorf(
-			"QueryProvisionedDataProcedure get accessAndMobilitySubscriptionData err: %s", pd.Detail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
-		return
+// ProvisionedDataSetNames are the data sets of the provisioned data, the dataset-names of
+// QueryProvisionedDataProcedure
+var ProvisionedDataSetNames = []models.DataSetName{
+	models.DataSetName_AM, models.DataSetName_SMF_SEL, models.DataSetName_SMS_SUB, models.DataSetName_SM,
+	models.DataSetName_TRACE, models.DataSetName_SMS_MNG, models.DataSetName_LCS_PRIVACY, models.DataSetName_LCS_MO,
+	models.DataSetName_V2_X, models.DataSetName_PROSE,
+}
+
+// maxConcurrentDataSetReads bounds the reads of the data sets of one request running at once
+const maxConcurrentDataSetReads = 4
+
+// provisionedDataSet is where a data set of the provisioned data is stored and how it is decoded
+type provisionedDataSet struct {
+	name     models.DataSetName
+	collName string
+	// perPlmn tells the data set is stored by serving PLMN, the others are the same in any serving PLMN
+	perPlmn bool
+	// decode sets the data set in dataSets from its documents, a single one but for the SM data
+	decode func(docs []map[string]interface{}, dataSets *models.ProvisionedDataSets) error
+}
+
+var provisionedDataSetStores = []provisionedDataSet{
+	{models.DataSetName_AM, "subscriptionData.provisionedData.amData", true,
+		func(docs []map[string]interface{}, dataSets *models.ProvisionedDataSets) error {
+			dataSets.AmData = &models.AccessAndMobilitySubscriptionData{}
+			return mapstructure.Decode(docs[0], dataSets.AmData)
+		}},
+	{models.DataSetName_SMF_SEL, "subscriptionData.provisionedData.smfSelectionSubscriptionData", true,
+		func(docs []map[string]interface{}, dataSets *models.ProvisionedDataSets) error {
+			dataSets.SmfSelData = &models.SmfSelectionSubscriptionData{}
+			return mapstructure.Decode(docs[0], dataSets.SmfSelData)
+		}},
+	{models.DataSetName_SMS_SUB, "subscriptionData.provisionedData.smsData", true,
+		func(docs []map[string]interface{}, dataSets *models.ProvisionedDataSets) error {
+			dataSets.SmsSubsData = &models.SmsSubscriptionData{}
+			return mapstructure.Decode(docs[0], dataSets.SmsSubsData)
+		}},
+	{models.DataSetName_SM, "subscriptionData.provisionedData.smData", true, decodeSmDataSet},
+	{models.DataSetName_TRACE, "subscriptionData.provisionedData.traceData", true,
+		func(docs []map[string]interface{}, dataSets *models.ProvisionedDataSets) error {
+			dataSets.TraceData = &models.TraceData{}
+			return mapstructure.Decode(docs[0], dataSets.TraceData)
+		}},
+	{models.DataSetName_SMS_MNG, "subscriptionData.provisionedData.smsMngData", true,
+		func(docs []map[string]interface{}, dataSets *models.ProvisionedDataSets) error {
+			dataSets.SmsMngData = &models.SmsManagementSubscriptionData{}
+			return mapstructure.Decode(docs[0], dataSets.SmsMngData)
+		}},
+	{models.DataSetName_LCS_PRIVACY, "subscriptionData.provisionedData.lcsPrivacyData", false,
+		func(docs []map[string]interface{}, dataSets *models.ProvisionedDataSets) error {
+			dataSets.LcsPrivacyData = &models.LcsPrivacyData{}
+			return decodeJSONDataSet(docs[0], dataSets.LcsPrivacyData)
+		}},
+	{models.DataSetName_LCS_MO, "subscriptionData.provisionedData.lcsMoData", false,
+		func(docs []map[string]interface{}, dataSets *models.ProvisionedDataSets) error {
+			dataSets.LcsMoData = &models.LcsMoData{}
+			return decodeJSONDataSet(docs[0], dataSets.LcsMoData)
+		}},
+	{models.DataSetName_V2_X, "subscriptionData.provisionedData.v2xData", false,
+		func(docs []map[string]interface{}, dataSets *models.ProvisionedDataSets) error {
+			dataSets.V2xData = &models.V2xSubscriptionData{}
+			return decodeJSONDataSet(docs[0], dataSets.V2xData)
+		}},
+	{models.DataSetName_PROSE, "subscriptionData.provisionedData.proseData", false,
+		func(docs []map[string]interface{}, dataSets *models.ProvisionedDataSets) error {
+			dataSets.ProseData = &models.ProseSubscriptionData{}
+			return decodeJSONDataSet(docs[0], dataSets.ProseData)
+		}},
+}
+
+// decodeSmDataSet decodes the SM data of each S-NSSAI, with their DNNs unescaped
+func decodeSmDataSet(docs []map[string]interface{}, dataSets *models.ProvisionedDataSets) error {
+	var smData []models.SessionManagementSubscriptionData
+	if err := mapstructure.Decode(docs, &smData); err != nil {
+		return err
 	}
-	if accessAndMobilitySubscriptionData != nil {
-		var tmp models.AccessAndMobilitySubscriptionData
-		if err := mapstructure.Decode(accessAndMobilitySubscriptionData, &tmp); err != nil {
-			logger.DataRepoLog.Errorf(
-				"QueryProvisionedDataProcedure accessAndMobilitySubscriptionData decode err: %+v", err)
-			problemDetails := openapi.ProblemDetailsSystemFailure(err.Error())
-			c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
-			c.JSON(http.StatusInternalServerError, problemDetails)
-			return
+	for i := range smData {
+		dnnConfigurations := make(map[string]models.DnnConfiguration, len(smData[i].DnnConfigurations))
+		for escapedDnn, dnnConf := range smData[i].DnnConfigurations {
+			dnnConfigurations[util.UnescapeDnn(escapedDnn)] = dnnConf
 		}
-		provisionedDataSets.AmData = &tmp
+		smData[i].DnnConfigurations = dnnConfigurations
 	}
+	dataSets.SmData = &models.SmSubsData{IndividualSmSubsData: smData}
+	return nil
+}
 
-	collName = "subscriptionData.provisionedData.smfSelectionSubscriptionData"
-	filter = bson.M{"ueId": ueId, "servingPlmnId": servingPlmnId}
-	smfSelectionSubscriptionData, pd := p.GetDataFromDB(collName, filter)
-	if pd != nil && pd.Status == http.StatusInternalServerError {
-		logger.DataRepoLog.Errorf("QueryProvisionedDataProcedure get smfSelectionSubscriptionData err: %s", pd.Detail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+// IsPerPlmnDataSet tells whether the data set of the provisioned data is stored by serving PLMN
+func IsPerPlmnDataSet(dataSetName models.DataSetName) bool {
+	return slices.ContainsFunc(provisionedDataSetStores, func(dataSet provisionedDataSet) bool {
+		return dataSet.name == dataSetName && dataSet.perPlmn
+	})
+}
+
+// QueryProvisionedDataProcedure answers the data sets of dataSetNames provisioned for the UE, all of them when
+// dataSetNames is empty, the data sets without data being left out. Without servingPlmnId the data sets stored
+// by serving PLMN are left out. Nothing provisioned at all is answered 404. The ETag is the one of the content,
+// the data sets being separate documents.
+func (p *Processor) QueryProvisionedDataProcedure(c *gin.Context, ueId string, servingPlmnId string,
+	provisionedDataSets models.ProvisionedDataSets, dataSetNames []models.DataSetName,
+) {
+	if err := p.readProvisionedDataSets(c, ueId, servingPlmnId, dataSetNames, &provisionedDataSets); err != nil {
+		dataRepoLog(c).Errorf("QueryProvisionedDataProcedure of %s err: %+v", ueId, err)
+		fail(c, err)
 		return
 	}
-	if smfSelectionSubscriptionData != nil {
-		var tmp models.SmfSelectionSubscriptionData
-		if err := mapstructure.Decode(smfSelectionSubscriptionData, &tmp); err != nil {
-			logger.DataRepoLog.Errorf(
-				"QueryProvisionedDataProcedure smfSelectionSubscriptionData decode err: %+v", err)
-			problemDetails := openapi.ProblemDetailsSystemFailure(err.Error())
-			c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
-			c.JSON(http.StatusInternalServerError, problemDetails)
-		}
-		provisionedDataSets.SmfSelData = &tmp
-	}
 
-	collName = "subscriptionData.provisionedData.smsData"
-	filter = bson.M{"ueId": ueId, "servingPlmnId": servingPlmnId}
-	smsSubscriptionData, pd := p.GetDataFromDB(collName, filter)
-	if pd != nil && pd.Status == http.StatusInternalServerError {
-		logger.DataRepoLog.Errorf("QueryProvisionedDataProcedure get smsSubscriptionData err: %s", pd.Detail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+	if reflect.DeepEqual(provisionedDataSets, models.ProvisionedDataSets{}) {
+		fail(c, p.provisionedDataNotFound(c, ueId))
 		return
 	}
-	if smsSubscriptionData != nil {
-		var tmp models.SmsSubscriptionData
-		if err := mapstructure.Decode(smsSubscriptionData, &tmp); err != nil {
-			logger.DataRepoLog.Errorf(
-				"QueryProvisionedDataProcedure smsSubscriptionData decode err: %+v", err)
-			problemDetails := openapi.ProblemDetailsSystemFailure(err.Error())
-			c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
-			c.JSON(http.StatusInternalServerError, problemDetails)
-			return
+	respondHashed(c, provisionedDataSets)
+}
+
+// readProvisionedDataSets sets in dataSets the data sets of dataSetNames provisioned for the UE, as
+// QueryProvisionedDataProcedure answers them. The data sets are read concurrently, at most
+// maxConcurrentDataSetReads at once, the first failure cancels the others.
+func (p *Processor) readProvisionedDataSets(ctx context.Context, ueId string, servingPlmnId string,
+	dataSetNames []models.DataSetName, dataSets *models.ProvisionedDataSets,
+) error {
+	var reads []provisionedDataSet
+	for _, dataSet := range provisionedDataSetStores {
+		if (len(dataSetNames) == 0 || slices.Contains(dataSetNames, dataSet.name)) &&
+			(servingPlmnId != "" || !dataSet.perPlmn) {
+			reads = append(reads, dataSet)
 		}
-		provisionedDataSets.SmsSubsData = &tmp
 	}
 
-	collName = "subscriptionData.provisionedData.smData"
-	filter = bson.M{"ueId": ueId, "servingPlmnId": servingPlmnId}
-	sessionManagementSubscriptionDatas, err := mongoapi.
-		RestfulAPIGetMany(collName, filter, mongoapi.COLLATION_STRENGTH_IGNORE_CASE)
-	if err != nil {
-		logger.DataRepoLog.Errorf("QueryProvisionedDataProcedure get sessionManagementSubscriptionDatas err: %+v", err)
-		problemDetails := openapi.ProblemDetailsSystemFailure(err.Error())
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
-		c.JSON(http.StatusInternalServerError, problemDetails)
-		return
+	// docs are by read, none for the data sets without data
+	docs := make([][]map[string]interface{}, len(reads))
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(maxConcurrentDataSetReads)
+	for i, dataSet := range reads {
+		group.Go(func() error {
+			filter := bson.M{"ueId": ueId}
+			if dataSet.perPlmn {
+				filter["servingPlmnId"] = servingPlmnId
+			}
+			if dataSet.name == models.DataSetName_SM {
+				var err error
+				docs[i], err = p.GetManyDataFromDBWithArg(groupCtx, dataSet.collName, filter,
+					mongoapi.COLLATION_STRENGTH_IGNORE_CASE)
+				return err
+			}
+			data, pd := p.GetDataFromDB(groupCtx, dataSet.collName, filter)
+			switch {
+			case pd == nil:
+				docs[i] = []map[string]interface{}{data}
+			case pd.Status != http.StatusNotFound:
+				return fmt.Errorf("get %s: %s", dataSet.name, pd.Detail)
+			}
+			return nil
+		})
 	}
-	if sessionManagementSubscriptionDatas != nil {
-		var tmp []models.SessionManagementSubscriptionData
-		if err := mapstructure.Decode(sessionManagementSubscriptionDatas, &tmp); err != nil {
-			logger.DataRepoLog.Errorf(
-				"QueryProvisionedDataProcedure sessionManagementSubscriptionDatas decode err: %+v", err)
-			problemDetails := openapi.ProblemDetailsSystemFailure(err.Error())
-			c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
-			c.JSON(http.StatusInternalServerError, problemDetails)
-			return
+	if err := group.Wait(); err != nil {
+		return err
+	}
+
+	for i, dataSet := range reads {
+		if len(docs[i]) == 0 {
+			continue
 		}
-		for _, smData := range tmp {
-			dnnConfigurations := smData.DnnConfigurations
-			tmpDnnConfigurations := make(map[string]models.DnnConfiguration)
-			for escapedDnn, dnnConf := range dnnConfigurations {
-				dnn := util.UnescapeDnn(escapedDnn)
-				tmpDnnConfigurations[dnn] = dnnConf
-			}
-			smData.DnnConfigurations = tmpDnnConfigurations
+		if err := dataSet.decode(docs[i], dataSets); err != nil {
+			return fmt.Errorf("decode %s: %w", dataSet.name, err)
 		}
-		provisionedDataSets.SmData.IndividualSmSubsData = tmp
 	}
+	return nil
+}
 
-	collName = "subscriptionData.provisionedData.traceData"
-	filter = bson.M{"ueId": ueId, "servingPlmnId": servingPlmnId}
-	traceData, pd := p.GetDataFromDB(collName, filter)
-	if pd != nil && pd.Status == http.StatusInternalServerError {
-		logger.DataRepoLog.Errorf("QueryProvisionedDataProcedure get traceData err: %s", pd.Detail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
-		return
-	}
-	if traceData != nil {
-		var tmp models.TraceData
-		if err := mapstructure.Decode(traceData, &tmp); err != nil {
-			logger.DataRepoLog.Errorf("QueryProvisionedDataProcedure traceData decode err: %+v", err)
-			problemDetails := openapi.ProblemDetailsSystemFailure(err.Error())
-			c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
-			c.JSON(http.StatusInternalServerError, problemDetails)
-			return
-		}
-		provisionedDataSets.TraceData = &tmp
+// provisionedDataNotFound is the 404 of the provisioned data missing, USER_NOT_FOUND when the UE has no
+// authentication subscription either
+func (p *Processor) provisionedDataNotFound(c *gin.Context, ueId string) *Error {
+	_, pd := p.GetDataFromDB(c, "subscriptionData.authenticationData.authenticationSubscription",
+		bson.M{"ueId": ueId})
+	switch {
+	case pd == nil:
+		return &Error{Category: ErrorNotFound}
+	case pd.Status == http.StatusNotFound:
+		return &Error{Category: ErrorNotFound, Cause: "USER_NOT_FOUND"}
+	default:
+		dataRepoLog(c).Errorf("provisionedDataNotFound get authenticationSubscription err: %s", pd.Detail)
+		return problemOf(pd)
 	}
+}
 
-	collName = "subscriptionData.provisionedData.smsMngData"
-	filter = bson.M{"ueId": ueId, "servingPlmnId": servingPlmnId}
-	smsManagementSubscriptionData, pd := p.GetDataFromDB(collName, filter)
-	if pd != nil && pd.Status == http.StatusInternalServerError {
-		logger.DataRepoLog.Errorf(
-			"QueryProvisionedDataProcedure get smsManagementSubscriptionData err: %s", pd.Detail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
-		return
+// provisionedDataFailure is the failure of the ProblemDetails a read of a provisioned data set answered, the
+// one of provisionedDataNotFound when the data set is missing
+func (p *Processor) provisionedDataFailure(c *gin.Context, ueId string, pd *models.ProblemDetails) *Error {
+	if pd.Status == http.StatusNotFound {
+		return p.provisionedDataNotFound(c, ueId)
 	}
-	if smsManagementSubscriptionData != nil {
-		var tmp models.SmsManagementSubscriptionData
-		if err := mapstructure.Decode(smsManagementSubscriptionData, &tmp); err != nil {
-			logger.DataRepoLog.Errorf(
-				"QueryProvisionedDataProcedure smsManagementSubscriptionData decode err: %+v", err)
-			problemDetails := openapi.ProblemDetailsSystemFailure(err.Error())
-			c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
-			c.JSON(http.StatusInternalServerError, problemDetails)
-			return
-		}
-		provisionedDataSets.SmsMngData = &tmp
+	return problemOf(pd)
+}
+
+// decodeJSONDataSet decodes the data set through its JSON, so that the times stored as RFC 3339 strings
+// decode, unlike with mapstructure
+func decodeJSONDataSet(data map[string]interface{}, dataSet interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
 	}
+	return json.Unmarshal(raw, dataSet)
+}
 
-	if reflect.DeepEqual(provisionedDataSets, models.ProvisionedDataSets{}) {
-		pd := util.ProblemDetailsNotFound("DATA_NOT_FOUND")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+// queryDecodedDataSet answers the data set of the UE keyed by ueId only, decoded into dataSet first, so that the
+// members provisioned null or not at all are left out of it. A document without any member answers an empty
+// data set, like the AM data does.
+func (p *Processor) queryDecodedDataSet(c *gin.Context, procedure string, collName string, ueId string,
+	dataSet interface{},
+) {
+	filter := bson.M{"ueId": ueId}
+	data, etag, pd := p.GetVersionedDataFromDB(c, collName, filter)
+	var err error
+	if pd != nil {
+		err = p.provisionedDataFailure(c, ueId, pd)
+	} else {
+		err = decodeJSONDataSet(data, dataSet)
+	}
+	if err != nil {
+		dataRepoLog(c).Errorf("%s of %s err: %+v", procedure, ueId, err)
+		fail(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, provisionedDataSets)
+	respondVersioned(c, etag, dataSet)
 }
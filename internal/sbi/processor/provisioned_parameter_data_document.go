@@ -10,28 +10,118 @@
 package processor
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 
 	"github.com/free5gc/openapi/models"
-	"github.com/free5gc/udr/internal/logger"
 	"github.com/free5gc/udr/internal/util"
-	"github.com/free5gc/util/metrics/sbi"
 )
 
-func (p *Processor) ModifyPpDataProcedure(c *gin.Context, collName string, ueId string, patchItem []models.PatchItem) {
-	var err error
+// ModifyPpDataProcedure applies the JSON Patch or the JSON Merge Patch to the PpData of the UE, the first
+// patch creates it as TS 29.505 has no other way to. The patched document is written only when it is still a
+// valid PpData, and the subscribers monitoring it are notified.
+func (p *Processor) ModifyPpDataProcedure(c *gin.Context, collName string, ueId string, patch PatchDocument) {
 	var origValue, newValue map[string]interface{}
-	filter := bson.M{"ueId": ueId}
-	if origValue, newValue, err = p.PatchDataToDBAndNotify(collName, ueId, patchItem, filter); err != nil {
-		logger.DataRepoLog.Errorf("ModifyPpDataProcedure err: %+v", err)
-		pd := util.ProblemDetailsModifyNotAllowed("")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+	// patchErr is the failure of the patch itself, rather than of the datastore
+	var patchErr error
+	putData := make(map[string]interface{})
+	// The current document is patched within validate, so that the latest one is patched again when another
+	// write lands in between
+	_, _, err := p.ReplaceVersionedDataToDB(c, collName, bson.M{"ueId": ueId}, putData, "",
+		func(current map[string]interface{}) error {
+			origValue = current
+			if origValue == nil {
+				origValue = map[string]interface{}{"ueId": ueId}
+			}
+			modified, applyErr := patch.apply(util.MapToByte(origValue))
+			newValue = nil
+			if applyErr == nil {
+				applyErr = json.Unmarshal(modified, &newValue)
+			}
+			if applyErr == nil {
+				applyErr = validatePpData(newValue, ueId)
+			}
+			if applyErr != nil {
+				patchErr = applyErr
+				return applyErr
+			}
+			clear(putData)
+			maps.Copy(putData, newValue)
+			return nil
+		})
+	var invalidErr *invalidDocumentError
+	switch {
+	case errors.As(patchErr, &invalidErr):
+		err = &Error{Category: ErrorUnprocessable, Err: invalidErr}
+	case patchErr != nil:
+		err = patchFailure(patchErr,
+			&Error{Category: ErrorNotAllowed, Message: "Occur error when applying the patch"})
+	}
+	if err != nil {
+		dataRepoLog(c).Errorf("ModifyPpDataProcedure of %s err: %+v", ueId, err)
+		fail(c, err)
 		return
 	}
-	PreHandleOnDataChangeNotify(ueId, CurrentResourceUri, patchItem, origValue, newValue)
+	p.NotifySubscribers(c, ueId, c.Request.URL.Path, patch.changes(origValue, newValue))
 	c.Status(http.StatusNoContent)
 }
+
+// validatePpData strictly decodes the document, without its ueId, into PpData and checks the durations and
+// counts it holds are not negative
+func validatePpData(document map[string]interface{}, ueId string) error {
+	if document["ueId"] != ueId {
+		return &invalidDocumentError{errors.New("ueId cannot be modified")}
+	}
+	ppData := make(map[string]interface{}, len(document))
+	for key, value := range document {
+		if key != "ueId" {
+			ppData[key] = value
+		}
+	}
+	var decoded models.PpData
+	decoder := json.NewDecoder(bytes.NewReader(util.MapToByte(ppData)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&decoded); err != nil {
+		return &invalidDocumentError{fmt.Errorf("invalid PpData: %w", err)}
+	}
+
+	var values map[string]int32
+	if characteristics := decoded.CommunicationCharacteristics; characteristics != nil {
+		values = map[string]int32{"ppDlPacketCount": characteristics.PpDlPacketCount}
+		if characteristics.PpSubsRegTimer != nil {
+			values["ppSubsRegTimer.subsRegTimer"] = characteristics.PpSubsRegTimer.SubsRegTimer
+		}
+		if characteristics.PpActiveTime != nil {
+			values["ppActiveTime.activeTime"] = characteristics.PpActiveTime.ActiveTime
+		}
+		if characteristics.PpMaximumResponseTime != nil {
+			values["ppMaximumResponseTime.maximumResponseTime"] =
+				characteristics.PpMaximumResponseTime.MaximumResponseTime
+		}
+		if characteristics.PpMaximumLatency != nil {
+			values["ppMaximumLatency.maximumLatency"] = characteristics.PpMaximumLatency.MaximumLatency
+		}
+	}
+	for name, value := range values {
+		if value < 0 {
+			return &invalidDocumentError{fmt.Errorf("communicationCharacteristics.%s shall not be negative", name)}
+		}
+	}
+	if behaviour := decoded.ExpectedUeBehaviourParameters; behaviour != nil {
+		switch {
+		case behaviour.CommunicationDurationTime < 0:
+			return &invalidDocumentError{
+				errors.New("expectedUeBehaviourParameters.communicationDurationTime shall not be negative")}
+		case behaviour.PeriodicTime < 0:
+			return &invalidDocumentError{errors.New("expectedUeBehaviourParameters.periodicTime shall not be negative")}
+		}
+	}
+	return nil
+}
@@ -14,41 +14,19 @@ import (
 
 	"github.com/gin-gonic/gin"
 
-	udr_context "github.com/free5gc/udr/internal/context"
-	"github.com/free5gc/udr/internal/logger"
-	"github.com/free5gc/udr/internal/util"
-	"github.com/free5gc/util/metrics/sbi"
+	db "github.com/free5gc/udr/internal/database"
 )
 
 func (p *Processor) GetAmfSubscriptionInfoProcedure(c *gin.Context, subsId string, ueId string) {
-	udrSelf := udr_context.GetSelf()
-
-	value, ok := udrSelf.UESubsCollection.Load(ueId)
-	if !ok {
-		pd := util.ProblemDetailsNotFound("USER_NOT_FOUND")
-		logger.DataRepoLog.Errorf("GetAmfSubscriptionInfoProcedure err: %s", pd.Detail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
-		return
-	}
-
-	UESubsData := value.(*udr_context.UESubsData)
-	_, ok = UESubsData.EeSubscriptionCollection[subsId]
-
-	if !ok {
-		pd := util.ProblemDetailsNotFound("SUBSCRIPTION_NOT_FOUND")
-		logger.DataRepoLog.Errorf("GetAmfSubscriptionInfoProcedure err: %s", pd.Detail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+	if !p.checkEeSubscription(c, "GetAmfSubscriptionInfoProcedure", ueId, subsId) {
 		return
 	}
 
-	if UESubsData.EeSubscriptionCollection[subsId].AmfSubscriptionInfos == nil {
-		pd := util.ProblemDetailsNotFound("AMFSUBSCRIPTION_NOT_FOUND")
-		logger.DataRepoLog.Errorf("GetAmfSubscriptionInfoProcedure err: %s", pd.Detail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+	data, pd := p.GetDataFromDB(c, db.SUBSCDATA_EE_AMF_SUBSC_DB_COLLECTION_NAME, eeSubscriptionFilter(ueId, subsId))
+	if pd != nil {
+		dataRepoLog(c).Errorf("GetAmfSubscriptionInfoProcedure err: %s", pd.Detail)
+		fail(c, problemOf(pd).notFoundCause("AMFSUBSCRIPTION_NOT_FOUND"))
 		return
 	}
-	c.JSON(http.StatusOK, UESubsData.EeSubscriptionCollection[subsId].AmfSubscriptionInfos)
+	c.JSON(http.StatusOK, data[amfSubscriptionInfoListField])
 }
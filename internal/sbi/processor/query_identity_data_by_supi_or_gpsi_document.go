@@ -16,12 +16,10 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 
 	"github.com/free5gc/openapi/models"
-	"github.com/free5gc/udr/internal/logger"
-	"github.com/free5gc/util/metrics/sbi"
 )
 
 func (p *Processor) GetIdentityDataProcedure(c *gin.Context, collName string, ueId string) {
-	logger.DataRepoLog.Debugf("Handle GetIdentityDataProcedure: %+v", ueId)
+	dataRepoLog(c).Debugf("Handle GetIdentityDataProcedure: %+v", ueId)
 	filter := bson.M{
 		"$or": []bson.M{
 			{"gpsi": ueId},
@@ -29,11 +27,10 @@ func (p *Processor) GetIdentityDataProcedure(c *gin.Context, collName string, ue
 		},
 	}
 
-	data, pd := p.GetDataFromDB(collName, filter)
+	data, pd := p.GetDataFromDB(c, collName, filter)
 	if pd != nil {
-		logger.DataRepoLog.Errorf("GetIdentityDataProcedure err: %+v", pd)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		dataRepoLog(c).Errorf("GetIdentityDataProcedure err: %+v", pd)
+		fail(c, problemOf(pd))
 		return
 	}
 
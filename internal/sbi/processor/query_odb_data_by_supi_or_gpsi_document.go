@@ -14,18 +14,14 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
-
-	"github.com/free5gc/udr/internal/logger"
-	"github.com/free5gc/util/metrics/sbi"
 )
 
 func (p *Processor) GetOdbDataProcedure(c *gin.Context, collName string, ueId string) {
 	filter := bson.M{"ueId": ueId}
-	data, pd := p.GetDataFromDB(collName, filter)
+	data, pd := p.GetDataFromDB(c, collName, filter)
 	if pd != nil {
-		logger.DataRepoLog.Errorf("GetOdbDataProcedure err: %s", pd.Detail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		dataRepoLog(c).Errorf("GetOdbDataProcedure err: %s", pd.Detail)
+		fail(c, problemOf(pd))
 		return
 	}
 	c.JSON(http.StatusOK, data)
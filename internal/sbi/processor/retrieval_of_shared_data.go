@@ -15,33 +15,50 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 
-	"github.com/free5gc/udr/internal/logger"
 	"github.com/free5gc/udr/internal/util"
-	"github.com/free5gc/util/metrics/sbi"
 )
 
+// GetSharedDataProcedure answers the shared data of the IDs found, in the order of the IDs and once each, and
+// 404 when none is found
 func (p *Processor) GetSharedDataProcedure(c *gin.Context, collName string, sharedDataIds []string) {
-	var sharedDataArray []map[string]interface{}
-	for _, sharedDataId := range sharedDataIds {
-		filter := bson.M{"sharedDataId": sharedDataId}
-		sharedData, pd := p.GetDataFromDB(collName, filter)
-		if pd != nil && pd.Status == http.StatusInternalServerError {
-			logger.DataRepoLog.Errorf("GetSharedDataProcedure err: %s", pd.Detail)
-			c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-			c.JSON(int(pd.Status), pd)
-			return
+	data, err := p.GetManyDataFromDB(c, collName, bson.M{"sharedDataId": bson.M{"$in": sharedDataIds}})
+	if err != nil {
+		dataRepoLog(c).Errorf("GetSharedDataProcedure err: %+v", err)
+		fail(c, err)
+		return
+	}
+
+	bySharedDataId := make(map[string]map[string]interface{}, len(data))
+	for _, sharedData := range data {
+		if sharedDataId, ok := sharedData["sharedDataId"].(string); ok {
+			delete(sharedData, util.DocumentVersionKey)
+			bySharedDataId[sharedDataId] = sharedData
 		}
-		if sharedData != nil {
+	}
+	sharedDataArray := make([]map[string]interface{}, 0, len(bySharedDataId))
+	for _, sharedDataId := range sharedDataIds {
+		if sharedData, ok := bySharedDataId[sharedDataId]; ok {
 			sharedDataArray = append(sharedDataArray, sharedData)
+			delete(bySharedDataId, sharedDataId)
 		}
 	}
 
-	if sharedDataArray == nil {
-		pd := util.ProblemDetailsNotFound("DATA_NOT_FOUND")
-		logger.DataRepoLog.Errorf("GetSharedDataProcedure err: %s", pd.Detail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+	if len(sharedDataArray) == 0 {
+		dataRepoLog(c).Errorf("GetSharedDataProcedure err: no shared data of %v", sharedDataIds)
+		fail(c, &Error{Category: ErrorNotFound})
 		return
 	}
 	c.JSON(http.StatusOK, sharedDataArray)
 }
+
+// GetIndividualSharedDataProcedure answers the shared data of sharedDataId
+func (p *Processor) GetIndividualSharedDataProcedure(c *gin.Context, collName string, sharedDataId string) {
+	sharedData, pd := p.GetDataFromDB(c, collName, bson.M{"sharedDataId": sharedDataId})
+	if pd != nil {
+		dataRepoLog(c).Errorf("GetIndividualSharedDataProcedure err: %s", pd.Detail)
+		fail(c, problemOf(pd))
+		return
+	}
+	delete(sharedData, util.DocumentVersionKey)
+	c.JSON(http.StatusOK, sharedData)
+}
@@ -10,33 +10,25 @@
 package processor
 
 import (
+	"encoding/json"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/free5gc/openapi/models"
 	udr_context "github.com/free5gc/udr/internal/context"
-	"github.com/free5gc/udr/internal/util"
-	"github.com/free5gc/util/metrics/sbi"
 )
 
 func (p *Processor) RemovesdmSubscriptionsProcedure(c *gin.Context, ueId string, subsId string) {
-	udrSelf := udr_context.GetSelf()
-	value, ok := udrSelf.UESubsCollection.Load(ueId)
+	UESubsData, ok := loadUESubsData(c, ueId)
 	if !ok {
-		pd := util.ProblemDetailsNotFound("USER_NOT_FOUND")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
 		return
 	}
+	UESubsData.Lock()
+	defer UESubsData.Unlock()
 
-	UESubsData := value.(*udr_context.UESubsData)
-	_, ok = UESubsData.SdmSubscriptions[subsId]
-
-	if !ok {
-		pd := util.ProblemDetailsNotFound("SUBSCRIPTION_NOT_FOUND")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+	if _, ok = sdmSubscriptionOf(c, UESubsData, subsId); !ok {
 		return
 	}
 	delete(UESubsData.SdmSubscriptions, subsId)
@@ -44,29 +36,96 @@ func (p *Processor) RemovesdmSubscriptionsProcedure(c *gin.Context, ueId string,
 	c.Status(http.StatusNoContent)
 }
 
+// UpdatesdmsubscriptionsProcedure replaces the SDM subscription, which shall be of the same NF instance
 func (p *Processor) UpdatesdmsubscriptionsProcedure(c *gin.Context, ueId string, subsId string,
 	SdmSubscription models.SdmSubscription,
 ) {
-	udrSelf := udr_context.GetSelf()
-	value, ok := udrSelf.UESubsCollection.Load(ueId)
+	UESubsData, ok := loadUESubsData(c, ueId)
 	if !ok {
-		pd := util.ProblemDetailsNotFound("USER_NOT_FOUND")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
 		return
 	}
+	UESubsData.Lock()
+	defer UESubsData.Unlock()
 
-	UESubsData := value.(*udr_context.UESubsData)
-	_, ok = UESubsData.SdmSubscriptions[subsId]
-
+	subscription, ok := sdmSubscriptionOf(c, UESubsData, subsId)
 	if !ok {
-		pd := util.ProblemDetailsNotFound("SUBSCRIPTION_NOT_FOUND")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		return
+	}
+	if SdmSubscription.NfInstanceId != subscription.NfInstanceId {
+		fail(c, &Error{Category: ErrorNotAllowed, Message: "nfInstanceId of the SDM subscription is not modifiable"})
 		return
 	}
 	SdmSubscription.SubscriptionId = subsId
-	UESubsData.SdmSubscriptions[subsId] = &SdmSubscription
+	if !dryRunSubscription(c, &SdmSubscription) {
+		UESubsData.SdmSubscriptions[subsId] = &SdmSubscription
+	}
 
 	c.Status(http.StatusNoContent)
 }
+
+// ModifysdmsubscriptionProcedure applies the patch to the SDM subscription and answers 200 with the modified
+// subscription, the UDM extends its expiry or changes its monitored resources this way. Modifying the
+// nfInstanceId or the subscriptionId answers 403.
+func (p *Processor) ModifysdmsubscriptionProcedure(c *gin.Context, ueId string, subsId string,
+	patch PatchDocument,
+) {
+	UESubsData, ok := loadUESubsData(c, ueId)
+	if !ok {
+		return
+	}
+	UESubsData.Lock()
+	defer UESubsData.Unlock()
+
+	subscription, ok := sdmSubscriptionOf(c, UESubsData, subsId)
+	if !ok {
+		return
+	}
+
+	modified, err := json.Marshal(subscription)
+	if err == nil {
+		modified, err = patch.apply(modified)
+	}
+	var sdmSubscription models.SdmSubscription
+	if err == nil {
+		err = json.Unmarshal(modified, &sdmSubscription)
+	}
+	if err != nil {
+		dataRepoLog(c).Errorf("ModifysdmsubscriptionProcedure of %s err: %+v", subsId, err)
+		fail(c, patchFailure(err, &Error{Category: ErrorNotAllowed, Message: "Occur error when applying the patch"}))
+		return
+	}
+	if sdmSubscription.NfInstanceId != subscription.NfInstanceId || sdmSubscription.SubscriptionId != subsId {
+		fail(c, &Error{
+			Category: ErrorNotAllowed,
+			Message:  "nfInstanceId and subscriptionId of the SDM subscription are not modifiable",
+		})
+		return
+	}
+	if !dryRunSubscription(c, &sdmSubscription) {
+		UESubsData.SdmSubscriptions[subsId] = &sdmSubscription
+	}
+
+	c.JSON(http.StatusOK, sdmSubscription)
+}
+
+// loadUESubsData answers 404 when no SDM subscription was ever stored for ueId
+func loadUESubsData(c *gin.Context, ueId string) (*udr_context.UESubsData, bool) {
+	value, ok := udr_context.GetSelf().UESubsCollection.Load(ueId)
+	if !ok {
+		fail(c, &Error{Category: ErrorNotFound, Cause: "USER_NOT_FOUND"})
+		return nil, false
+	}
+	return value.(*udr_context.UESubsData), true
+}
+
+// sdmSubscriptionOf answers 404 when there is no SDM subscription subsId or it has expired, the sweeper
+// removing it may not have run yet. UESubsData shall be locked.
+func sdmSubscriptionOf(c *gin.Context, UESubsData *udr_context.UESubsData, subsId string,
+) (*models.SdmSubscription, bool) {
+	subscription, ok := UESubsData.SdmSubscriptions[subsId]
+	if !ok || udr_context.IsExpired(subscription.Expires, time.Now()) {
+		fail(c, &Error{Category: ErrorNotFound, Cause: "SUBSCRIPTION_NOT_FOUND"})
+		return nil, false
+	}
+	return subscription, true
+}
@@ -13,13 +13,12 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/free5gc/openapi/models"
 	udr_context "github.com/free5gc/udr/internal/context"
-	"github.com/free5gc/udr/internal/util"
-	"github.com/free5gc/util/metrics/sbi"
 )
 
 func (p *Processor) CreateSdmSubscriptionsProcedure(c *gin.Context, SdmSubscription models.SdmSubscription,
@@ -27,12 +26,10 @@ func (p *Processor) CreateSdmSubscriptionsProcedure(c *gin.Context, SdmSubscript
 ) {
 	udrSelf := udr_context.GetSelf()
 
-	value, ok := udrSelf.UESubsCollection.Load(ueId)
-	if !ok {
-		udrSelf.UESubsCollection.Store(ueId, new(udr_context.UESubsData))
-		value, _ = udrSelf.UESubsCollection.Load(ueId)
-	}
+	value, _ := udrSelf.UESubsCollection.LoadOrStore(ueId, new(udr_context.UESubsData))
 	UESubsData := value.(*udr_context.UESubsData)
+	UESubsData.Lock()
+	defer UESubsData.Unlock()
 	if UESubsData.SdmSubscriptions == nil {
 		UESubsData.SdmSubscriptions = make(map[string]*models.SdmSubscription)
 	}
@@ -56,23 +53,25 @@ func (p *Processor) QuerysdmsubscriptionsProcedure(c *gin.Context, ueId string)
 
 	value, ok := udrSelf.UESubsCollection.Load(ueId)
 	if !ok {
-		pd := util.ProblemDetailsNotFound("USER_NOT_FOUND")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		fail(c, &Error{Category: ErrorNotFound, Cause: "USER_NOT_FOUND"})
 		return
 	}
 
 	UESubsData := value.(*udr_context.UESubsData)
 	var sdmSubscriptionSlice []models.SdmSubscription
 
+	// The expired subscriptions are left out until the sweeper removes them
+	now := time.Now()
+	UESubsData.Lock()
 	for _, v := range UESubsData.SdmSubscriptions {
-		sdmSubscriptionSlice = append(sdmSubscriptionSlice, *v)
+		if !udr_context.IsExpired(v.Expires, now) {
+			sdmSubscriptionSlice = append(sdmSubscriptionSlice, *v)
+		}
 	}
+	UESubsData.Unlock()
 
 	if len(sdmSubscriptionSlice) == 0 {
-		pd := util.ProblemDetailsNotFound("SDMSUBSCRIPTION_NOT_FOUND")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		fail(c, &Error{Category: ErrorNotFound, Cause: "SDMSUBSCRIPTION_NOT_FOUND"})
 		return
 	}
 
@@ -10,65 +10,496 @@
 package processor
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"reflect"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 
 	"github.com/free5gc/openapi/models"
-	"github.com/free5gc/udr/internal/logger"
+	db "github.com/free5gc/udr/internal/database"
 	"github.com/free5gc/udr/internal/util"
-	"github.com/free5gc/util/metrics/sbi"
 	"github.com/free5gc/util/mongoapi"
 )
 
+// QuerySmDataProcedure answers the SM data of the UE in the serving PLMN, of the S-NSSAI and the DNN when given.
+// The S-NSSAI is matched whole, one without sd only matching the SM data without sd. None matching is answered
+// 404 DATA_NOT_FOUND, or USER_NOT_FOUND for an unknown UE, as the other provisioned data sets are.
+// With fields each of them is projected to the members of fields, along with its mandatory singleNssai. With
+// supportedFeatures the attributes of the features not negotiated are left out and the negotiated ones answered.
+// The ETag is the one of the content, the SM data of each S-NSSAI being a document of its own.
 func (p *Processor) QuerySmDataProcedure(c *gin.Context, collName string, ueId string, servingPlmnId string,
-	singleNssai models.Snssai, dnn string,
+	singleNssai *models.Snssai, dnn string, supportedFeatures string, fields []string,
 ) {
 	filter := bson.M{"ueId": ueId, "servingPlmnId": servingPlmnId}
-
-	if !reflect.DeepEqual(singleNssai, models.Snssai{}) {
-		if singleNssai.Sd == "" {
-			filter["singleNssai.sst"] = singleNssai.Sst
-		} else {
-			filter["singleNssai.sst"] = singleNssai.Sst
-			filter["singleNssai.sd"] = singleNssai.Sd
-		}
+	if singleNssai != nil {
+		filter = smDataFilter(ProvisioningRecord{Supi: ueId, ServingPlmnId: servingPlmnId}, singleNssai)
 	}
-
 	if dnn != "" {
 		dnnKey := util.EscapeDnn(dnn)
 		filter["dnnConfigurations."+dnnKey] = bson.M{"$exists": true}
 	}
-	resp := models.SmSubsData{}
 
-	sessionManagementSubscriptionDatas, err := mongoapi.
-		RestfulAPIGetMany(collName, filter, mongoapi.COLLATION_STRENGTH_IGNORE_CASE)
+	sessionManagementSubscriptionDatas, err := p.GetManyDataFromDBWithArg(c, collName, filter,
+		mongoapi.COLLATION_STRENGTH_IGNORE_CASE)
 	if err != nil {
-		logger.DataRepoLog.Errorf("QuerySmDataProcedure err: %+v", err)
-		pd := util.ProblemDetailsUpspecified("")
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		dataRepoLog(c).Errorf("QuerySmDataProcedure err: %+v", err)
+		fail(c, &Error{Category: ErrorNotAllowed, Cause: "UNSPECIFIED"})
+		return
+	}
+	resp := smSubsDataOf(c, sessionManagementSubscriptionDatas)
+	if len(resp.IndividualSmSubsData) == 0 {
+		err := p.provisionedDataNotFound(c, ueId)
+		dataRepoLog(c).Warnf("QuerySmDataProcedure of %s: %s", ueId, err.ProblemDetails().Title)
+		fail(c, err)
+		return
+	}
+	negotiated, negotiating := p.negotiateFeatures(c, supportedFeatures)
+	if len(fields) == 0 && !negotiating {
+		respondHashed(c, resp)
 		return
 	}
-	for _, smData := range sessionManagementSubscriptionDatas {
+
+	// The nested documents of ToBsonM are of JSON
+	projected := util.ToBsonM(resp)
+	individualSmSubsData, _ := projected["individualSmSubsData"].([]interface{})
+	for i, smData := range individualSmSubsData {
+		smData, isDoc := smData.(map[string]interface{})
+		if !isDoc {
+			continue
+		}
+		if negotiating {
+			stripFeatures(smData, smDataFeatures, negotiated)
+		}
+		if len(fields) > 0 {
+			smData = projectFields(smData, append([]string{"singleNssai"}, fields...))
+		}
+		if negotiating {
+			setSupportedFeatures(smData, negotiated)
+		}
+		individualSmSubsData[i] = smData
+	}
+	respondHashed(c, projected)
+}
+
+// smSubsDataOf is the SmSubsData of the SM data documents, the ones not decoding being skipped
+func smSubsDataOf(c *gin.Context, docs []map[string]interface{}) models.SmSubsData {
+	resp := models.SmSubsData{}
+	for _, smData := range docs {
 		var tmpSmData models.SessionManagementSubscriptionData
 		err := json.Unmarshal(util.MapToByte(smData), &tmpSmData)
 		if err != nil {
-			logger.DataRepoLog.Debug("SmData Unmarshal error")
+			dataRepoLog(c).Debug("SmData Unmarshal error")
 			continue
 		}
+
+		// The DNN configurations are answered keyed by DNN, not by their escaped key
+		if tmpSmData.DnnConfigurations != nil {
+			dnnConfigurations := make(map[string]models.DnnConfiguration, len(tmpSmData.DnnConfigurations))
+			for escapedDnn, dnnConf := range tmpSmData.DnnConfigurations {
+				dnnConfigurations[util.UnescapeDnn(escapedDnn)] = dnnConf
+			}
+			tmpSmData.DnnConfigurations = dnnConfigurations
+		}
 		resp.IndividualSmSubsData = append(resp.IndividualSmSubsData, tmpSmData)
+	}
+	return resp
+}
+
+// smDataETag is the ETag QuerySmDataProcedure answers for all the SM data of the UE in the serving PLMN, read
+// with ctx, and whether there is any
+func (p *Processor) smDataETag(ctx context.Context, c *gin.Context, collName string, ueId string,
+	servingPlmnId string,
+) (string, bool, error) {
+	docs, err := p.GetManyDataFromDBWithArg(ctx, collName, bson.M{"ueId": ueId, "servingPlmnId": servingPlmnId},
+		mongoapi.COLLATION_STRENGTH_IGNORE_CASE)
+	if err != nil {
+		return "", false, err
+	}
+	resp := smSubsDataOf(c, docs)
+	body, err := json.Marshal(resp)
+	if err != nil {
+		return "", false, err
+	}
+	return util.ContentETag(body), len(resp.IndividualSmSubsData) > 0, nil
+}
+
+// smDataDnnField holds the DNN configurations of an S-NSSAI in its document, keyed by escaped DNN
+const smDataDnnField = "dnnConfigurations"
+
+// smDataPatchRoot is the field the DNN configuration of a smDataPatchOp is patched under
+const smDataPatchRoot = "/dnnConfiguration"
+
+// smDataSnssaiPattern is an S-NSSAI in the path of a patch of the SM data, its sst then its sd in hex
+var smDataSnssaiPattern = regexp.MustCompile(`^[0-9A-Fa-f]{2}([0-9A-Fa-f]{6})?$`)
 
-		dnnConfigurations := tmpSmData.DnnConfigurations
-		tmpDnnConfigurations := make(map[string]models.DnnConfiguration)
-		for escapedDnn, dnnConf := range dnnConfigurations {
-			dnn := util.UnescapeDnn(escapedDnn)
-			tmpDnnConfigurations[dnn] = dnnConf
+// smDataPatchOp is an operation of a patch of the SM data, its path and from relative to the DNN
+// configuration it addresses once put under smDataPatchRoot
+type smDataPatchOp struct {
+	index  int
+	snssai string
+	dnn    string
+	// dnnKey is the key of the DNN configuration in its document, set once it is found provisioned
+	dnnKey string
+	item   models.PatchItem
+}
+
+// smDataPatchError is the failure of the operation of a patch of the SM data at index
+type smDataPatchError struct {
+	index int
+	err   error
+}
+
+func (e *smDataPatchError) Error() string {
+	return fmt.Sprintf("operation %d: %s", e.index, e.err.Error())
+}
+
+func (e *smDataPatchError) Unwrap() error {
+	return e.err
+}
+
+// errDnnNotProvisioned is the failure of an operation addressing a DNN the S-NSSAI has no configuration of
+var errDnnNotProvisioned = errors.New("dnn is not provisioned")
+
+// ModifySmDataProcedure applies the JSON Patch to the SM data of the UE in the serving PLMN. The path of each
+// operation is /{singleNssai}/dnnConfigurations/{dnn}, or a location under it, with the S-NSSAI in hex as
+// the query of the Nudm_SDM API: it shall address a provisioned DNN, or else is answered 404 pointing at the
+// operation. Only the fields of the DNN configurations the patch changes are written, within a transaction when
+// the datastore supports them, and the subscribers are notified of each DNN configuration changed.
+// With If-Match the patch only applies to the SM data of the ETag QuerySmDataProcedure answers for all of it,
+// otherwise 412 is answered; the check is only atomic with the writes within a transaction. The new ETag is
+// answered.
+func (p *Processor) ModifySmDataProcedure(c *gin.Context, collName string, ueId string, servingPlmnId string,
+	patchItems []models.PatchItem,
+) {
+	ops, err := parseSmDataPatch(patchItems)
+	if err != nil {
+		dataRepoLog(c).Warnf("ModifySmDataProcedure of %s: %+v", ueId, err)
+		fail(c, err)
+		return
+	}
+
+	docs, err := p.GetManyDataFromDB(c, collName, bson.M{"ueId": ueId, "servingPlmnId": servingPlmnId})
+	if err != nil {
+		dataRepoLog(c).Errorf("ModifySmDataProcedure err: %+v", err)
+		fail(c, err)
+		return
+	}
+	// The S-NSSAIs patched, in the order of their first operation
+	var snssais []string
+	singleNssais := make(map[string]models.Snssai)
+	opsBySnssai := make(map[string][]*smDataPatchOp)
+	for _, op := range ops {
+		singleNssai, dnnKey, found := provisionedDnn(docs, op.snssai, op.dnn)
+		if !found {
+			err := smDataPatchFailure(&smDataPatchError{op.index, errDnnNotProvisioned}, ops)
+			dataRepoLog(c).Warnf("ModifySmDataProcedure of %s: %+v", ueId, err)
+			fail(c, err)
+			return
+		}
+		op.dnnKey = dnnKey
+		if _, ok := opsBySnssai[op.snssai]; !ok {
+			snssais = append(snssais, op.snssai)
+			singleNssais[op.snssai] = singleNssai
+		}
+		opsBySnssai[op.snssai] = append(opsBySnssai[op.snssai], op)
+	}
+
+	// The DNN configurations before and after the patch, by S-NSSAI then by key
+	origConfs := make(map[string]map[string]map[string]interface{})
+	newConfs := make(map[string]map[string]map[string]interface{})
+	var written []string
+	ifMatch := ifMatchOf(c)
+	transactional, err := p.WithTransaction(c, func(ctx context.Context) error {
+		written = nil
+		if ifMatch != "" {
+			etag, exists, etagErr := p.smDataETag(ctx, c, collName, ueId, servingPlmnId)
+			if etagErr != nil {
+				return etagErr
+			}
+			if !util.IfMatch(ifMatch, etag, exists) {
+				return db.ErrVersionMismatch
+			}
+		}
+		for _, snssai := range snssais {
+			singleNssai := singleNssais[snssai]
+			filter := smDataFilter(ProvisioningRecord{Supi: ueId, ServingPlmnId: servingPlmnId}, &singleNssai)
+			doc, _, getErr := p.GetOne(ctx, collName, filter)
+			if getErr != nil {
+				return getErr
+			}
+			value, marshalErr := json.Marshal(doc[smDataDnnField])
+			if marshalErr != nil {
+				return marshalErr
+			}
+			origConf, newConf, applyErr := applySmDataPatch(value, opsBySnssai[snssai])
+			if applyErr != nil {
+				return applyErr
+			}
+			origConfs[snssai], newConfs[snssai] = origConf, newConf
+			if set, unset := dnnConfigurationsUpdate(origConf, newConf); len(set) > 0 || len(unset) > 0 {
+				if _, patchErr := p.PatchOne(ctx, collName, filter, db.Update{Set: set, Unset: unset}); patchErr != nil {
+					return patchErr
+				}
+			}
+			written = append(written, snssai)
+		}
+		return nil
+	})
+	// Without a transaction, the S-NSSAIs written before the failure stay written
+	if err == nil || !transactional {
+		for _, snssai := range written {
+			p.notifySmDataChanges(c, ueId, snssai, opsBySnssai[snssai], origConfs[snssai], newConfs[snssai])
 		}
-		smData["DnnConfigurations"] = tmpDnnConfigurations
 	}
-	c.JSON(http.StatusOK, resp)
+	if err != nil {
+		dataRepoLog(c).Errorf("ModifySmDataProcedure of %s err: %+v", ueId, err)
+		if !abortVersionedWrite(c, err) {
+			fail(c, smDataPatchFailure(err, ops))
+		}
+		return
+	}
+	if etag, _, etagErr := p.smDataETag(c, c, collName, ueId, servingPlmnId); etagErr != nil {
+		dataRepoLog(c).Warnf("ModifySmDataProcedure of %s ETag err: %+v", ueId, etagErr)
+	} else {
+		setETag(c, etag)
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// parseSmDataPatch returns the operations of the patch relative to the DNN configurations they address, or
+// the 400 pointing at the first operation not addressing one. The from of a move or a copy shall address the
+// DNN configuration of its path.
+func parseSmDataPatch(patchItems []models.PatchItem) ([]*smDataPatchOp, error) {
+	invalid := func(param string, reason string) error {
+		return &Error{
+			Category:      ErrorValidation,
+			Cause:         "INVALID_PARAMETER",
+			Message:       param + ": " + reason,
+			InvalidParams: []models.InvalidParam{{Param: param, Reason: reason}},
+		}
+	}
+
+	if len(patchItems) == 0 {
+		return nil, invalid("/", "the patch shall have at least one operation")
+	}
+	ops := make([]*smDataPatchOp, 0, len(patchItems))
+	for i, item := range patchItems {
+		snssai, dnn, rest, ok := splitSmDataPath(item.Path)
+		if !ok {
+			return nil, invalid(fmt.Sprintf("/%d/path", i),
+				"shall be /{singleNssai}/dnnConfigurations/{dnn} or a location under it")
+		}
+		op := &smDataPatchOp{index: i, snssai: snssai, dnn: dnn, item: item}
+		op.item.Path = smDataPatchRoot + rest
+		if item.Op == models.PatchOperation_MOVE || item.Op == models.PatchOperation_COPY {
+			fromSnssai, fromDnn, fromRest, fromOk := splitSmDataPath(item.From)
+			if !fromOk || fromSnssai != snssai || !strings.EqualFold(fromDnn, dnn) {
+				return nil, invalid(fmt.Sprintf("/%d/from", i), "shall address the DNN configuration of path")
+			}
+			op.item.From = smDataPatchRoot + fromRest
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+// splitSmDataPath splits the path of an operation of a patch of the SM data into the S-NSSAI, in lower case,
+// the DNN and the location under its configuration, still a JSON Pointer
+func splitSmDataPath(path string) (snssai string, dnn string, rest string, ok bool) {
+	segments := strings.SplitN(path, "/", 5)
+	if len(segments) < 4 || segments[0] != "" || !smDataSnssaiPattern.MatchString(segments[1]) ||
+		segments[2] != smDataDnnField || segments[3] == "" {
+		return "", "", "", false
+	}
+	if len(segments) == 5 {
+		rest = "/" + segments[4]
+	}
+	dnn = strings.NewReplacer("~1", "/", "~0", "~").Replace(segments[3])
+	return strings.ToLower(segments[1]), dnn, rest, true
+}
+
+// provisionedDnn looks the DNN up among the configurations of the S-NSSAI in the SM data documents, the DNNs
+// being compared case-insensitively as the queries of the SM data do
+func provisionedDnn(docs []map[string]interface{}, snssai string, dnn string) (
+	singleNssai models.Snssai, dnnKey string, found bool,
+) {
+	for _, doc := range docs {
+		var smData models.SessionManagementSubscriptionData
+		if err := json.Unmarshal(util.MapToByte(doc), &smData); err != nil || smData.SingleNssai == nil ||
+			strings.ToLower(util.SnssaiModelsToHex(*smData.SingleNssai)) != snssai {
+			continue
+		}
+		for key := range smData.DnnConfigurations {
+			if strings.EqualFold(key, util.EscapeDnn(dnn)) {
+				return *smData.SingleNssai, key, true
+			}
+		}
+		return *smData.SingleNssai, "", false
+	}
+	return models.Snssai{}, "", false
+}
+
+// applySmDataPatch applies the operations to the DNN configurations of an S-NSSAI and returns the
+// configurations of the DNNs they address before and after by key, nil once removed. Each modified
+// configuration shall still be a valid DnnConfiguration.
+func applySmDataPatch(value []byte, ops []*smDataPatchOp) (
+	origConfs, newConfs map[string]map[string]interface{}, err error,
+) {
+	var dnnConfs map[string]json.RawMessage
+	if err = json.Unmarshal(value, &dnnConfs); err != nil {
+		return nil, nil, err
+	}
+	root := strings.TrimPrefix(smDataPatchRoot, "/")
+	origConfs, newConfs = make(map[string]map[string]interface{}), make(map[string]map[string]interface{})
+	for _, op := range ops {
+		conf, ok := dnnConfs[op.dnnKey]
+		if !ok {
+			return nil, nil, &smDataPatchError{op.index, errDnnNotProvisioned}
+		}
+		if _, seen := origConfs[op.dnnKey]; !seen {
+			var origConf map[string]interface{}
+			if err = json.Unmarshal(conf, &origConf); err != nil {
+				return nil, nil, err
+			}
+			origConfs[op.dnnKey] = origConf
+		}
+
+		patch, decodeErr := decodePatchItems([]models.PatchItem{op.item})
+		if decodeErr != nil {
+			return nil, nil, &smDataPatchError{op.index, decodeErr}
+		}
+		wrapped, marshalErr := json.Marshal(map[string]json.RawMessage{root: conf})
+		if marshalErr != nil {
+			return nil, nil, marshalErr
+		}
+		if wrapped, err = patch.Apply(wrapped); err != nil {
+			return nil, nil, &smDataPatchError{op.index, err}
+		}
+		var patched map[string]json.RawMessage
+		if err = json.Unmarshal(wrapped, &patched); err != nil {
+			return nil, nil, err
+		}
+		if conf, ok = patched[root]; !ok {
+			delete(dnnConfs, op.dnnKey)
+			newConfs[op.dnnKey] = nil
+			continue
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(conf))
+		decoder.DisallowUnknownFields()
+		var newConf map[string]interface{}
+		if err = decoder.Decode(&models.DnnConfiguration{}); err == nil {
+			err = json.Unmarshal(conf, &newConf)
+		}
+		if err != nil || newConf == nil {
+			return nil, nil, &smDataPatchError{op.index, &invalidDocumentError{
+				fmt.Errorf("invalid DnnConfiguration of %s: %v", op.dnn, err),
+			}}
+		}
+		dnnConfs[op.dnnKey] = conf
+		newConfs[op.dnnKey] = newConf
+	}
+	return origConfs, newConfs, nil
+}
+
+// dnnConfigurationsUpdate is the update of the SM data document from the DNN configurations before the patch to
+// the ones after, keyed as applySmDataPatch returns them: the fields of each configuration changed are set and
+// the ones removed unset, a configuration removed is unset whole
+func dnnConfigurationsUpdate(origConfs, newConfs map[string]map[string]interface{}) (set bson.M, unset []string) {
+	set = bson.M{}
+	for dnnKey, newConf := range newConfs {
+		path := smDataDnnField + "." + dnnKey
+		if newConf == nil {
+			unset = append(unset, path)
+			continue
+		}
+		origConf := origConfs[dnnKey]
+		for field, value := range newConf {
+			if origValue, ok := origConf[field]; !ok || !reflect.DeepEqual(origValue, value) {
+				set[path+"."+field] = value
+			}
+		}
+		for field := range origConf {
+			if _, ok := newConf[field]; !ok {
+				unset = append(unset, path+"."+field)
+			}
+		}
+	}
+	sort.Strings(unset)
+	return set, unset
+}
+
+// smDataPatchFailure is the failure of a patch of the SM data, pointing at the operation failing: 404 for a
+// DNN not provisioned, 409 for a failed test and 422 otherwise
+func smDataPatchFailure(err error, ops []*smDataPatchOp) error {
+	var opErr *smDataPatchError
+	if !errors.As(err, &opErr) {
+		if errors.Is(err, db.ErrNoDocument) {
+			return &Error{Category: ErrorNotFound}
+		}
+		return err
+	}
+
+	category := ErrorUnprocessable
+	reason := opErr.err.Error()
+	var invalidErr *invalidDocumentError
+	switch {
+	case errors.Is(opErr.err, errDnnNotProvisioned):
+		op := ops[opErr.index]
+		reason = fmt.Sprintf("dnn %s is not provisioned for S-NSSAI %s", op.dnn, op.snssai)
+		category = ErrorNotFound
+	case errors.As(opErr.err, &invalidErr):
+	default:
+		if failure, ok := patchFailure(opErr.err, nil).(*Error); ok {
+			category = failure.Category
+		}
+	}
+	param := fmt.Sprintf("/%d", opErr.index)
+	return &Error{
+		Category:      category,
+		Message:       param + ": " + reason,
+		InvalidParams: []models.InvalidParam{{Param: param, Reason: reason}},
+	}
+}
+
+// notifySmDataChanges notifies the subscribers of the DNN configurations the operations changed, each at its
+// own resource under the SM data, so that the subscriptions to one DNN are not notified of the others. The
+// test operations change nothing.
+func (p *Processor) notifySmDataChanges(c *gin.Context, ueId string, snssai string, ops []*smDataPatchOp,
+	origConfs, newConfs map[string]map[string]interface{},
+) {
+	var dnnKeys []string
+	changes := make(map[string][]models.ChangeItem)
+	for _, op := range ops {
+		if op.item.Op == models.PatchOperation_TEST {
+			continue
+		}
+		if _, ok := changes[op.dnnKey]; !ok {
+			dnnKeys = append(dnnKeys, op.dnnKey)
+		}
+		change := models.ChangeItem{
+			Op:        models.ChangeType(op.item.Op),
+			Path:      strings.TrimPrefix(op.item.Path, smDataPatchRoot),
+			OrigValue: origConfs[op.dnnKey],
+			NewValue:  newConfs[op.dnnKey],
+		}
+		if op.item.From != "" {
+			change.From = strings.TrimPrefix(op.item.From, smDataPatchRoot)
+		}
+		changes[op.dnnKey] = append(changes[op.dnnKey], change)
+	}
+	for _, dnnKey := range dnnKeys {
+		resourcePath := fmt.Sprintf("%s/%s/%s/%s", c.Request.URL.Path, snssai, smDataDnnField,
+			util.UnescapeDnn(dnnKey))
+		p.NotifySubscribers(c, ueId, resourcePath, changes[dnnKey])
+	}
 }
@@ -0,0 +1,41 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestDnnConfigurationsUpdate(t *testing.T) {
+	origConfs := map[string]map[string]interface{}{
+		"internet": {
+			"sscModes":     map[string]interface{}{"defaultSscMode": "SSC_MODE_1"},
+			"5gQosProfile": map[string]interface{}{"5qi": float64(9)},
+			"sessionAmbr":  map[string]interface{}{"uplink": "1 Gbps", "downlink": "2 Gbps"},
+		},
+		"ims_mnc093": {"sscModes": map[string]interface{}{"defaultSscMode": "SSC_MODE_1"}},
+	}
+	newConfs := map[string]map[string]interface{}{
+		"internet": {
+			"sscModes":     map[string]interface{}{"defaultSscMode": "SSC_MODE_1"},
+			"5gQosProfile": map[string]interface{}{"5qi": float64(6)},
+			"iwkEpsInd":    true,
+		},
+		"ims_mnc093": nil,
+	}
+	set, unset := dnnConfigurationsUpdate(origConfs, newConfs)
+	// Only the fields changed are written, the other writes to the DNN configurations are kept
+	require.Equal(t, bson.M{
+		"dnnConfigurations.internet.5gQosProfile": map[string]interface{}{"5qi": float64(6)},
+		"dnnConfigurations.internet.iwkEpsInd":    true,
+	}, set)
+	require.Equal(t, []string{"dnnConfigurations.ims_mnc093", "dnnConfigurations.internet.sessionAmbr"}, unset)
+
+	// A patch of test operations only writes nothing
+	set, unset = dnnConfigurationsUpdate(origConfs, map[string]map[string]interface{}{
+		"internet": origConfs["internet"],
+	})
+	require.Empty(t, set)
+	require.Empty(t, unset)
+}
@@ -0,0 +1,257 @@
+package processor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/udr/internal/database"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/util/mongoapi"
+)
+
+// usageMonDataCollName is the collection of the usage monitoring data of the SM policy data, a document per
+// limitId of a UE
+const usageMonDataCollName = "policyData.ues.smData.usageMonData"
+
+// smPolicyDataOf returns the SM policy data of the UE, its DNN keys unescaped and its umData gathered from
+// the usage monitoring data by limitId
+func (p *Processor) smPolicyDataOf(c *gin.Context, collName string, ueId string) (
+	*models.SmPolicyData, error,
+) {
+	filter := bson.M{"ueId": ueId}
+	data, pd := p.GetDataFromDBWithArg(c, collName, filter, mongoapi.COLLATION_STRENGTH_IGNORE_CASE)
+	if pd != nil {
+		return nil, problemOf(pd)
+	}
+	usageMonDataMapArray, err := p.GetManyDataFromDB(c, usageMonDataCollName, filter)
+	if err != nil {
+		return nil, err
+	}
+	return decodeSmPolicyData(data, usageMonDataMapArray)
+}
+
+// decodeSmPolicyData is the SM policy data of the document of the UE and of its usage monitoring data
+func decodeSmPolicyData(data map[string]interface{}, usageMonDataMapArray []map[string]interface{}) (
+	*models.SmPolicyData, error,
+) {
+	var smPolicyData models.SmPolicyData
+	if err := json.Unmarshal(util.MapToByte(data), &smPolicyData); err != nil {
+		return nil, err
+	}
+	for snssai, snssaiData := range smPolicyData.SmPolicySnssaiData {
+		smPolicyDnnData := make(map[string]models.SmPolicyDnnData, len(snssaiData.SmPolicyDnnData))
+		for escapedDnn, dnnData := range snssaiData.SmPolicyDnnData {
+			smPolicyDnnData[util.UnescapeDnn(escapedDnn)] = dnnData
+		}
+		snssaiData.SmPolicyDnnData = smPolicyDnnData
+		smPolicyData.SmPolicySnssaiData[snssai] = snssaiData
+	}
+
+	if len(usageMonDataMapArray) > 0 {
+		var usageMonDataArray []models.UsageMonData
+		if err := json.Unmarshal(util.MapArrayToByte(usageMonDataMapArray), &usageMonDataArray); err != nil {
+			return nil, err
+		}
+		smPolicyData.UmData = make(map[string]models.UsageMonData, len(usageMonDataArray))
+		for _, usageMonData := range usageMonDataArray {
+			smPolicyData.UmData[usageMonData.LimitId] = usageMonData
+		}
+	}
+	return &smPolicyData, nil
+}
+
+// filterSmPolicyData leaves in smPolicyData only the S-NSSAI and the DNN asked for, a nil snssai or an empty
+// dnn not filtering. It tells whether any SM policy data is left.
+func filterSmPolicyData(smPolicyData *models.SmPolicyData, snssai *models.Snssai, dnn string) bool {
+	if snssai == nil && dnn == "" {
+		return true
+	}
+	if snssai != nil {
+		hexSnssai := util.SnssaiModelsToHex(*snssai)
+		for key := range smPolicyData.SmPolicySnssaiData {
+			if !strings.EqualFold(key, hexSnssai) {
+				delete(smPolicyData.SmPolicySnssaiData, key)
+			}
+		}
+	}
+	if dnn != "" {
+		for key, snssaiData := range smPolicyData.SmPolicySnssaiData {
+			for cmpDnn := range snssaiData.SmPolicyDnnData {
+				if !strings.EqualFold(cmpDnn, dnn) {
+					delete(snssaiData.SmPolicyDnnData, cmpDnn)
+				}
+			}
+			if len(snssaiData.SmPolicyDnnData) == 0 {
+				delete(smPolicyData.SmPolicySnssaiData, key)
+			}
+		}
+	}
+	return len(smPolicyData.SmPolicySnssaiData) > 0
+}
+
+// smPolicyDataPatch is the merge patch of the SM policy data, the SmPolicyDataPatch of 3GPP 29.519 along with
+// the umDataLimits. Its entries are kept raw to tell the null ones.
+type smPolicyDataPatch struct {
+	UmData             map[string]json.RawMessage `json:"umData"`
+	UmDataLimits       map[string]json.RawMessage `json:"umDataLimits"`
+	SmPolicySnssaiData map[string]json.RawMessage `json:"smPolicySnssaiData"`
+}
+
+// fieldsUpdate is a merge patch as the fields to set and to unset in a document, by their dotted path
+type fieldsUpdate struct {
+	set   bson.M
+	unset []string
+}
+
+// smPolicyDataUpdate is the merge patch of the SM policy data as the update of the document of the UE, and the
+// ones of its usage monitoring data by limitId, nil for the ones removed
+type smPolicyDataUpdate struct {
+	fieldsUpdate
+	umData map[string]*fieldsUpdate
+}
+
+// isFieldName tells whether name can be a field of a dotted path
+func isFieldName(name string) bool {
+	return name != "" && !strings.Contains(name, ".") && !strings.HasPrefix(name, "$")
+}
+
+// add merges the patch of the fields of the object at path into the update, the null ones being unset
+func (u *fieldsUpdate) add(path string, patch map[string]interface{}) error {
+	for name, value := range patch {
+		if !isFieldName(name) {
+			return fmt.Errorf("%q is not a field name", name)
+		}
+		fieldPath := name
+		if path != "" {
+			fieldPath = path + "." + name
+		}
+		switch v := value.(type) {
+		case nil:
+			u.unset = append(u.unset, fieldPath)
+		case map[string]interface{}:
+			if err := u.add(fieldPath, v); err != nil {
+				return err
+			}
+		default:
+			u.set[fieldPath] = v
+		}
+	}
+	return nil
+}
+
+// decodeSmPolicyDataPatch decodes the merge patch of the SM policy data into its update. An entry that is not
+// a patch of its data type, or whose limitId is not its key, fails with an ErrorValidation.
+func decodeSmPolicyDataPatch(mergePatch []byte) (*smPolicyDataUpdate, error) {
+	var patch smPolicyDataPatch
+	decoder := json.NewDecoder(bytes.NewReader(mergePatch))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&patch); err != nil {
+		return nil, &Error{Category: ErrorValidation, Message: "SM policy data patch", Err: err}
+	}
+
+	update := &smPolicyDataUpdate{
+		fieldsUpdate: fieldsUpdate{set: bson.M{}},
+		umData:       make(map[string]*fieldsUpdate, len(patch.UmData)),
+	}
+	for limitId, entry := range patch.UmData {
+		param := "/umData/" + limitId
+		if !isFieldName(limitId) {
+			return nil, invalidSmPolicyDataPatch(param, "not a valid limitId", nil)
+		}
+		if bytes.Equal(entry, []byte("null")) {
+			update.umData[limitId] = nil
+			continue
+		}
+		umDataUpdate := &fieldsUpdate{set: bson.M{}}
+		if err := addEntryPatch(umDataUpdate, "", param, limitId, entry, &models.UsageMonData{}); err != nil {
+			return nil, err
+		}
+		update.umData[limitId] = umDataUpdate
+	}
+	for limitId, entry := range patch.UmDataLimits {
+		param := "/umDataLimits/" + limitId
+		if !isFieldName(limitId) {
+			return nil, invalidSmPolicyDataPatch(param, "not a valid limitId", nil)
+		}
+		if err := addEntryPatch(&update.fieldsUpdate, "umDataLimits."+limitId, param, limitId, entry,
+			&models.UsageMonDataLimit{}); err != nil {
+			return nil, err
+		}
+	}
+	for snssai, entry := range patch.SmPolicySnssaiData {
+		param := "/smPolicySnssaiData/" + snssai
+		if !isFieldName(snssai) {
+			return nil, invalidSmPolicyDataPatch(param, "not a valid S-NSSAI", nil)
+		}
+		if err := addEntryPatch(&update.fieldsUpdate, "smPolicySnssaiData."+snssai, param, "", entry,
+			&models.SmPolicySnssaiDataPatch{}); err != nil {
+			return nil, err
+		}
+	}
+	return update, nil
+}
+
+// invalidSmPolicyDataPatch is the ErrorValidation of the patch at param
+func invalidSmPolicyDataPatch(param string, reason string, err error) error {
+	return &Error{
+		Category:      ErrorValidation,
+		Message:       "SM policy data patch " + param,
+		InvalidParams: []models.InvalidParam{{Param: param, Reason: reason}},
+		Err:           err,
+	}
+}
+
+// addEntryPatch adds to the update the patch entry at entryPath, the whole document when empty, after checking
+// it is a patch of model. The limitId of an entry keyed by limitId has to be its key, the DNN keys of an S-NSSAI
+// entry are escaped as they are stored.
+func addEntryPatch(update *fieldsUpdate, entryPath string, param string, limitId string, entry json.RawMessage,
+	model interface{},
+) error {
+	if bytes.Equal(entry, []byte("null")) {
+		update.unset = append(update.unset, entryPath)
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(entry))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(model); err != nil {
+		return invalidSmPolicyDataPatch(param, "not a patch of its data type", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(entry, &fields); err != nil || fields == nil {
+		return invalidSmPolicyDataPatch(param, "not a JSON object", err)
+	}
+	if entryLimitId, ok := fields["limitId"]; ok && limitId != "" && entryLimitId != limitId {
+		return invalidSmPolicyDataPatch(param, "limitId differs from the key", nil)
+	}
+	if smPolicyDnnData, ok := fields["smPolicyDnnData"].(map[string]interface{}); ok {
+		escaped := make(map[string]interface{}, len(smPolicyDnnData))
+		for dnn, dnnData := range smPolicyDnnData {
+			escaped[util.EscapeDnn(dnn)] = dnnData
+		}
+		fields["smPolicyDnnData"] = escaped
+	}
+	if err := update.add(entryPath, fields); err != nil {
+		return invalidSmPolicyDataPatch(param, err.Error(), nil)
+	}
+	return nil
+}
+
+// smPolicyDataNotFound is the ErrorNotFound of a patch of the SM policy data whose document is not stored
+func smPolicyDataNotFound(err error, cause string, param string) error {
+	if !errors.Is(err, database.ErrNoDocument) {
+		return err
+	}
+	notFound := &Error{Category: ErrorNotFound, Cause: cause, Message: "SM policy data patch", Err: err}
+	if param != "" {
+		notFound.InvalidParams = []models.InvalidParam{{Param: param, Reason: "not stored"}}
+	}
+	return notFound
+}
@@ -0,0 +1,73 @@
+package processor
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/free5gc/openapi/models"
+)
+
+func TestDecodeSmPolicyDataPatch(t *testing.T) {
+	update, err := decodeSmPolicyDataPatch([]byte(`{
+		"umData": {"limit1": {"allowedUsage": {"totalVolume": 400}, "resetTime": null}, "limit2": null},
+		"umDataLimits": {"limit1": {"usageLimit": {"totalVolume": 5000}}, "limit2": null},
+		"smPolicySnssaiData": {"01010203": {"smPolicyDnnData": {"ims.mnc001": {"dnn": "ims.mnc001"}}}}
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, bson.M{
+		"umDataLimits.limit1.usageLimit.totalVolume":                 float64(5000),
+		"smPolicySnssaiData.01010203.smPolicyDnnData.ims_mnc001.dnn": "ims.mnc001",
+	}, update.set)
+	require.Equal(t, []string{"umDataLimits.limit2"}, update.unset)
+	require.Equal(t, map[string]*fieldsUpdate{
+		"limit1": {set: bson.M{"allowedUsage.totalVolume": float64(400)}, unset: []string{"resetTime"}},
+		"limit2": nil,
+	}, update.umData)
+
+	// The update is applied by the dotted paths as MongoDB does
+	document := map[string]interface{}{
+		"umDataLimits": map[string]interface{}{
+			"limit1": map[string]interface{}{"limitId": "limit1"},
+			"limit2": map[string]interface{}{"limitId": "limit2"},
+		},
+	}
+	updateDottedFields(document, update.set, update.unset)
+	require.Equal(t, map[string]interface{}{
+		"umDataLimits": map[string]interface{}{
+			"limit1": map[string]interface{}{
+				"limitId":    "limit1",
+				"usageLimit": map[string]interface{}{"totalVolume": float64(5000)},
+			},
+		},
+		"smPolicySnssaiData": map[string]interface{}{
+			"01010203": map[string]interface{}{
+				"smPolicyDnnData": map[string]interface{}{
+					"ims_mnc001": map[string]interface{}{"dnn": "ims.mnc001"},
+				},
+			},
+		},
+	}, document)
+
+	for _, invalid := range []struct {
+		patch string
+		param string
+	}{
+		{`{"umData": {"limit1": {"limitId": "limit2"}}}`, "/umData/limit1"},
+		{`{"umData": {"limit.1": {}}}`, "/umData/limit.1"},
+		{`{"umDataLimits": {"limit1": {"usageLimit": 5000}}}`, "/umDataLimits/limit1"},
+		{`{"umDataLimits": {"limit1": {"scopes": {"$where": {}}}}}`, "/umDataLimits/limit1"},
+		{`{"smPolicySnssaiData": {"01010203": {"smPolicyDnnData": []}}}`, "/smPolicySnssaiData/01010203"},
+	} {
+		_, err = decodeSmPolicyDataPatch([]byte(invalid.patch))
+		var processorErr *Error
+		require.True(t, errors.As(err, &processorErr), invalid.patch)
+		require.Equal(t, ErrorValidation, processorErr.Category)
+		require.True(t, slices.ContainsFunc(processorErr.InvalidParams, func(param models.InvalidParam) bool {
+			return param.Param == invalid.param
+		}), invalid.patch)
+	}
+}
@@ -11,34 +11,33 @@ package processor
 
 import (
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 
 	"github.com/free5gc/openapi/models"
-	"github.com/free5gc/udr/internal/logger"
 	"github.com/free5gc/udr/internal/util"
-	"github.com/free5gc/util/metrics/sbi"
-	"github.com/free5gc/util/mongoapi"
 )
 
 func (p *Processor) CreateSmfContextNon3gppProcedure(c *gin.Context, SmfRegistration models.SmfRegistration,
-	collName string, ueId string, pduSessionIdInt int64,
+	collName string, ueId string, pduSessionId int32,
 ) {
 	putData := util.ToBsonM(SmfRegistration)
 	putData["ueId"] = ueId
-	putData["pduSessionId"] = int32(pduSessionIdInt)
+	putData["pduSessionId"] = pduSessionId
 
-	filter := bson.M{"ueId": ueId, "pduSessionId": pduSessionIdInt}
-	existed, err := mongoapi.RestfulAPIPutOne(collName, filter, putData)
+	filter := bson.M{"ueId": ueId, "pduSessionId": pduSessionId}
+	existed, etag, err := p.PutVersionedDataToDB(c, collName, filter, putData, ifMatchOf(c))
 	if err != nil {
-		logger.DataRepoLog.Errorf("CreateSmfContextNon3gppProcedure err: %+v", err)
-		problemDetails := util.ProblemDetailsSystemFailure(err.Error())
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
-		c.JSON(http.StatusInternalServerError, problemDetails)
+		dataRepoLog(c).Errorf("CreateSmfContextNon3gppProcedure err: %+v", err)
+		if abortVersionedWrite(c, err) {
+			return
+		}
+		fail(c, err)
 		return
 	}
+	p.NotifySubscribers(c, ueId, c.Request.URL.Path, documentChanges(putData))
+	setETag(c, etag)
 
 	if existed {
 		c.JSON(http.StatusOK, putData)
@@ -47,31 +46,39 @@ func (p *Processor) CreateSmfContextNon3gppProcedure(c *gin.Context, SmfRegistra
 	c.JSON(http.StatusCreated, putData)
 }
 
-func (p *Processor) DeleteSmfContextProcedure(c *gin.Context, collName string, ueId string, pduSessionId string) {
-	pduSessionIdInt, err := strconv.ParseInt(pduSessionId, 10, 32)
+// DeleteSmfContextProcedure removes the SMF registration of the PDU session. The deletion is idempotent, 204 is
+// answered as well when there is no such registration, only its actual removal is notified.
+func (p *Processor) DeleteSmfContextProcedure(c *gin.Context, collName string, ueId string, pduSessionId int32) {
+	filter := bson.M{"ueId": ueId, "pduSessionId": pduSessionId}
+	_, pd := p.GetDataFromDB(c, collName, filter)
+	if pd != nil && pd.Status == http.StatusNotFound {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	var err error
+	if pd != nil {
+		err = problemOf(pd)
+	} else {
+		err = p.DeleteOneDataFromDB(c, collName, filter)
+	}
 	if err != nil {
-		logger.DataRepoLog.Error(err)
+		dataRepoLog(c).Errorf("DeleteSmfContextProcedure err: %+v", err)
+		fail(c, err)
+		return
 	}
-	filter := bson.M{"ueId": ueId, "pduSessionId": pduSessionIdInt}
-	p.DeleteDataFromDB(collName, filter)
+	p.NotifySubscribers(c, ueId, c.Request.URL.Path, documentChanges(nil))
 	c.Status(http.StatusNoContent)
 }
 
 func (p *Processor) QuerySmfRegistrationProcedure(c *gin.Context, collName string, ueId string,
-	pduSessionId string,
+	pduSessionId int32,
 ) {
-	pduSessionIdInt, err := strconv.ParseInt(pduSessionId, 10, 32)
-	if err != nil {
-		logger.DataRepoLog.Error(err)
-	}
-
-	filter := bson.M{"ueId": ueId, "pduSessionId": pduSessionIdInt}
-	data, pd := p.GetDataFromDB(collName, filter)
+	filter := bson.M{"ueId": ueId, "pduSessionId": pduSessionId}
+	data, etag, pd := p.GetVersionedDataFromDB(c, collName, filter)
 	if pd != nil {
-		logger.DataRepoLog.Errorf("QuerySmfRegistrationProcedure err: %s", pd.Detail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		dataRepoLog(c).Errorf("QuerySmfRegistrationProcedure err: %s", pd.Detail)
+		fail(c, problemOf(pd))
 		return
 	}
-	c.JSON(http.StatusOK, data)
+	respondVersioned(c, etag, data)
 }
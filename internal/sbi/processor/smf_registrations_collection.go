@@ -15,17 +15,24 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 
-	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/util"
 	"github.com/free5gc/util/mongoapi"
 )
 
+// QuerySmfRegListProcedure answers the SmfRegList of the UE, an empty one when it has no PDU session
 func (p *Processor) QuerySmfRegListProcedure(c *gin.Context, collName string, ueId string) {
 	filter := bson.M{"ueId": ueId}
-	smfRegList, err := mongoapi.RestfulAPIGetMany(collName, filter, mongoapi.COLLATION_STRENGTH_IGNORE_CASE)
+	smfRegList, err := p.GetManyDataFromDBWithArg(c, collName, filter, mongoapi.COLLATION_STRENGTH_IGNORE_CASE)
 	if err != nil {
-		logger.DataRepoLog.Errorf("QuerySmfRegListProcedure err: %+v", err)
-		c.JSON(http.StatusOK, nil)
+		dataRepoLog(c).Errorf("QuerySmfRegListProcedure err: %+v", err)
+		fail(c, err)
 		return
 	}
+	if smfRegList == nil {
+		smfRegList = make([]map[string]interface{}, 0)
+	}
+	for _, smfReg := range smfRegList {
+		delete(smfReg, util.DocumentVersionKey)
+	}
 	c.JSON(http.StatusOK, smfRegList)
 }
@@ -10,25 +10,29 @@
 package processor
 
 import (
-	"net/http"
-
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
-
-	"github.com/free5gc/udr/internal/logger"
-	"github.com/free5gc/util/metrics/sbi"
 )
 
 func (p *Processor) QuerySmfSelectDataProcedure(c *gin.Context, collName string, ueId string,
-	servingPlmnId string,
+	servingPlmnId string, fields []string,
 ) {
 	filter := bson.M{"ueId": ueId, "servingPlmnId": servingPlmnId}
-	data, pd := p.GetDataFromDB(collName, filter)
+	data, etag, pd := p.GetVersionedDataFromDB(c, collName, filter)
 	if pd != nil {
-		logger.DataRepoLog.Errorf("QuerySmfSelectDataProcedure err: %s", pd.Detail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		dataRepoLog(c).Errorf("QuerySmfSelectDataProcedure err: %s", pd.Detail)
+		fail(c, problemOf(pd))
+		return
+	}
+	projected, err := projectDocument(data, fields)
+	if err != nil {
+		dataRepoLog(c).Errorf("QuerySmfSelectDataProcedure err: %+v", err)
+		fail(c, err)
+		return
+	}
+	if len(fields) > 0 {
+		respondHashed(c, projected)
 		return
 	}
-	c.JSON(http.StatusOK, data)
+	respondVersioned(c, etag, projected)
 }
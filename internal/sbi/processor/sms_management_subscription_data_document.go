@@ -11,24 +11,74 @@ package processor
 
 import (
 	"net/http"
+	"regexp"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 
-	"github.com/free5gc/udr/internal/logger"
-	"github.com/free5gc/util/metrics/sbi"
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/udr/internal/util"
+)
+
+var (
+	// pattern: '^[0-9]{3}[0-9]{2,3}-[A-Fa-f0-9]{6}$' -- the traceRef of the TraceData of 3GPP 29.571 5.6.2.1
+	traceRefRegexp = regexp.MustCompile("^[0-9]{3}[0-9]{2,3}-[A-Fa-f0-9]{6}$")
 )
 
 func (p *Processor) QuerySmsMngDataProcedure(c *gin.Context, collName string, ueId string,
-	servingPlmnId string,
+	servingPlmnId string, supportedFeatures string,
+) {
+	p.querySmsDataSet(c, collName, ueId, servingPlmnId, supportedFeatures)
+}
+
+// CreateSmsMngDataProcedure stores the SMS management subscription data of the UE in the serving PLMN in place
+// of the previous one
+func (p *Processor) CreateSmsMngDataProcedure(c *gin.Context, collName string, ueId string,
+	servingPlmnId string, smsMngData models.SmsManagementSubscriptionData,
 ) {
+	if detail := validateSmsMngData(&smsMngData); detail != "" {
+		dataRepoLog(c).Warnf("CreateSmsMngDataProcedure of %s: %s", ueId, detail)
+		fail(c, &Error{Category: ErrorValidation, Message: detail})
+		return
+	}
+
 	filter := bson.M{"ueId": ueId, "servingPlmnId": servingPlmnId}
-	data, pd := p.GetDataFromDB(collName, filter)
-	if pd != nil {
-		logger.DataRepoLog.Errorf("QuerySmsMngDataProcedure err: %s", pd.Detail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+	putData := util.ToBsonM(smsMngData)
+	putData["ueId"] = ueId
+	putData["servingPlmnId"] = servingPlmnId
+	existed, _, err := p.ReplaceVersionedDataToDB(c, collName, filter, putData, ifMatchOf(c), nil)
+	if err != nil {
+		dataRepoLog(c).Errorf("CreateSmsMngDataProcedure err: %+v", err)
+		if !abortVersionedWrite(c, err) {
+			fail(c, err)
+		}
 		return
 	}
-	c.JSON(http.StatusOK, data)
+
+	if existed {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.JSON(http.StatusCreated, smsMngData)
+}
+
+// validateSmsMngData returns the detail of the first problem found, the types are checked by the decoding
+func validateSmsMngData(smsMngData *models.SmsManagementSubscriptionData) string {
+	if !util.SupportedFeaturesRegexp.MatchString(smsMngData.SupportedFeatures) {
+		return "supportedFeatures shall be hexadecimal"
+	}
+	traceData := smsMngData.TraceData
+	switch {
+	case traceData == nil:
+		return ""
+	case !traceRefRegexp.MatchString(traceData.TraceRef):
+		return "traceData: traceRef shall be the MCC and the MNC followed by a six digit hexadecimal trace ID"
+	case traceData.TraceDepth == "":
+		return "traceData: traceDepth is required"
+	case traceData.NeTypeList == "" || !util.SupportedFeaturesRegexp.MatchString(traceData.NeTypeList):
+		return "traceData: neTypeList shall be hexadecimal"
+	case traceData.EventList == "" || !util.SupportedFeaturesRegexp.MatchString(traceData.EventList):
+		return "traceData: eventList shall be hexadecimal"
+	}
+	return ""
 }
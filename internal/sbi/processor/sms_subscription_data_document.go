@@ -10,25 +10,30 @@
 package processor
 
 import (
-	"net/http"
-
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
-
-	"github.com/free5gc/udr/internal/logger"
-	"github.com/free5gc/util/metrics/sbi"
 )
 
 func (p *Processor) QuerySmsDataProcedure(c *gin.Context, collName string, ueId string,
-	servingPlmnId string,
+	servingPlmnId string, supportedFeatures string,
+) {
+	p.querySmsDataSet(c, collName, ueId, servingPlmnId, supportedFeatures)
+}
+
+// querySmsDataSet answers the SMS data set provisioned for the UE in the serving PLMN, with the features of
+// supportedFeatures negotiated. The data sets have no optional attribute of a feature.
+func (p *Processor) querySmsDataSet(c *gin.Context, collName string, ueId string, servingPlmnId string,
+	supportedFeatures string,
 ) {
 	filter := bson.M{"ueId": ueId, "servingPlmnId": servingPlmnId}
-	data, pd := p.GetDataFromDB(collName, filter)
+	data, etag, pd := p.GetVersionedDataFromDB(c, collName, filter)
 	if pd != nil {
-		logger.DataRepoLog.Errorf("QuerySmsDataProcedure err: %s", pd.Detail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		dataRepoLog(c).Errorf("querySmsDataSet %s err: %s", collName, pd.Detail)
+		fail(c, p.provisionedDataFailure(c, ueId, pd))
 		return
 	}
-	c.JSON(http.StatusOK, data)
+	if negotiated, negotiating := p.negotiateFeatures(c, supportedFeatures); negotiating {
+		setSupportedFeatures(data, negotiated)
+	}
+	respondVersioned(c, etag, data)
 }
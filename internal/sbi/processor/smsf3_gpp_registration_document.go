@@ -10,47 +10,21 @@
 package processor
 
 import (
-	"net/http"
-
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 
 	"github.com/free5gc/openapi/models"
-	"github.com/free5gc/udr/internal/logger"
-	"github.com/free5gc/udr/internal/util"
-	"github.com/free5gc/util/metrics/sbi"
-	"github.com/free5gc/util/mongoapi"
 )
 
 func (p *Processor) CreateSmsfContext3gppProcedure(
 	c *gin.Context, collName string, ueId string, SmsfRegistration models.SmsfRegistration,
 ) {
-	putData := util.ToBsonM(SmsfRegistration)
-	putData["ueId"] = ueId
-	filter := bson.M{"ueId": ueId}
-
-	_, err := mongoapi.RestfulAPIPutOne(collName, filter, putData)
-	if err != nil {
-		logger.DataRepoLog.Errorf("CreateSmsfContext3gppProcedure err: %+v", err)
-	}
-
-	c.Status(http.StatusNoContent)
+	p.putSmsfContext(c, "CreateSmsfContext3gppProcedure", collName, ueId, SmsfRegistration)
 }
 
 func (p *Processor) DeleteSmsfContext3gppProcedure(c *gin.Context, collName string, ueId string) {
-	filter := bson.M{"ueId": ueId}
-	p.DeleteDataFromDB(collName, filter)
-	c.Status(http.StatusNoContent)
+	p.deleteSmsfContext(c, "DeleteSmsfContext3gppProcedure", collName, ueId)
 }
 
 func (p *Processor) QuerySmsfContext3gppProcedure(c *gin.Context, collName string, ueId string) {
-	filter := bson.M{"ueId": ueId}
-	data, pd := p.GetDataFromDB(collName, filter)
-	if pd != nil {
-		logger.DataRepoLog.Errorf("QuerySmsfContext3gppProcedure err: %s", pd.Detail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
-		return
-	}
-	c.JSON(http.StatusOK, data)
+	p.querySmsfContext(c, "QuerySmsfContext3gppProcedure", collName, ueId)
 }
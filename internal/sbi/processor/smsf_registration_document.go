@@ -0,0 +1,110 @@
+/*
+ * Nudr_DataRepository API OpenAPI file
+ *
+ * Unified Data Repository Service
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package processor
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/free5gc/openapi/models"
+	udr_context "github.com/free5gc/udr/internal/context"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/udr/pkg/factory"
+)
+
+// The SMSF registrations of the 3GPP and the non-3GPP access are the same resource kept in a collection per
+// access type, a registration or deregistration for one access leaves the other one as is
+
+// putSmsfContext replaces the SMSF registration of the UE for the access type of collName, 201 is answered
+// with the registration when there was none and 204 otherwise
+func (p *Processor) putSmsfContext(c *gin.Context, procedure string, collName string, ueId string,
+	smsfRegistration models.SmsfRegistration,
+) {
+	if detail := validateSmsfRegistration(smsfRegistration); detail != "" {
+		dataRepoLog(c).Warnf("%s of %s: %s", procedure, ueId, detail)
+		fail(c, &Error{Category: ErrorValidation, Message: detail})
+		return
+	}
+
+	putData := util.ToBsonM(smsfRegistration)
+	putData["ueId"] = ueId
+	filter := bson.M{"ueId": ueId}
+
+	existed, etag, err := p.ReplaceVersionedDataToDB(c, collName, filter, putData, ifMatchOf(c), nil)
+	if err != nil {
+		dataRepoLog(c).Errorf("%s err: %+v", procedure, err)
+		if !abortVersionedWrite(c, err) {
+			fail(c, err)
+		}
+		return
+	}
+	p.NotifySubscribers(c, ueId, c.Request.URL.Path, documentChanges(putData))
+	setETag(c, etag)
+
+	if existed {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.Header("Location", udr_context.GetSelf().GetIPv4GroupUri(udr_context.NUDR_DR)+
+		strings.TrimPrefix(c.Request.URL.Path, factory.UdrDrResUriPrefix))
+	c.JSON(http.StatusCreated, smsfRegistration)
+}
+
+// validateSmsfRegistration returns the detail of the first mandatory attribute missing, the types are checked
+// by the decoding
+func validateSmsfRegistration(smsfRegistration models.SmsfRegistration) string {
+	if smsfRegistration.SmsfInstanceId == "" {
+		return "smsfInstanceId is required"
+	}
+	if smsfRegistration.PlmnId == nil || smsfRegistration.PlmnId.Mcc == "" || smsfRegistration.PlmnId.Mnc == "" {
+		return "plmnId is required"
+	}
+	return ""
+}
+
+// deleteSmsfContext removes the SMSF registration of the UE for the access type of collName. The deletion is
+// idempotent, 204 is answered as well when there is no such registration, only its actual removal is notified.
+func (p *Processor) deleteSmsfContext(c *gin.Context, procedure string, collName string, ueId string) {
+	filter := bson.M{"ueId": ueId}
+	_, pd := p.GetDataFromDB(c, collName, filter)
+	if pd != nil && pd.Status == http.StatusNotFound {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	var err error
+	if pd != nil {
+		err = problemOf(pd)
+	} else {
+		err = p.DeleteOneDataFromDB(c, collName, filter)
+	}
+	if err != nil {
+		dataRepoLog(c).Errorf("%s err: %+v", procedure, err)
+		fail(c, err)
+		return
+	}
+	p.NotifySubscribers(c, ueId, c.Request.URL.Path, documentChanges(nil))
+	c.Status(http.StatusNoContent)
+}
+
+// querySmsfContext answers the SMSF registration of the UE for the access type of collName, 404 when no SMSF
+// is registered for it
+func (p *Processor) querySmsfContext(c *gin.Context, procedure string, collName string, ueId string) {
+	filter := bson.M{"ueId": ueId}
+	data, etag, pd := p.GetVersionedDataFromDB(c, collName, filter)
+	if pd != nil {
+		dataRepoLog(c).Errorf("%s err: %s", procedure, pd.Detail)
+		fail(c, problemOf(pd))
+		return
+	}
+	respondVersioned(c, etag, data)
+}
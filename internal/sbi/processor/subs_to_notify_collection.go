@@ -12,7 +12,6 @@ package processor
 import (
 	"fmt"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 
@@ -25,9 +24,7 @@ func (p *Processor) PostSubscriptionDataSubscriptionsProcedure(
 ) {
 	udrSelf := udr_context.GetSelf()
 
-	newSubscriptionID := strconv.Itoa(udrSelf.SubscriptionDataSubscriptionIDGenerator)
-	udrSelf.SubscriptionDataSubscriptions[newSubscriptionID] = &SubscriptionDataSubscriptions
-	udrSelf.SubscriptionDataSubscriptionIDGenerator++
+	newSubscriptionID := udrSelf.AddSubscriptionDataSubscription(&SubscriptionDataSubscriptions)
 
 	/* Contains the URI of the newly created resource, according
 	   to the structure: {apiRoot}/subscription-data/subs-to-notify/{subsId} */
@@ -15,21 +15,14 @@ import (
 	"github.com/gin-gonic/gin"
 
 	udr_context "github.com/free5gc/udr/internal/context"
-	"github.com/free5gc/udr/internal/logger"
-	"github.com/free5gc/udr/internal/util"
-	"github.com/free5gc/util/metrics/sbi"
 )
 
 func (p *Processor) RemovesubscriptionDataSubscriptionsProcedure(c *gin.Context, subsId string) {
 	udrSelf := udr_context.GetSelf()
-	_, ok := udrSelf.SubscriptionDataSubscriptions[subsId]
-	if !ok {
-		pd := util.ProblemDetailsNotFound("SUBSCRIPTION_NOT_FOUND")
-		logger.DataRepoLog.Errorf("RemovesubscriptionDataSubscriptionsProcedure err: %s", pd.Detail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+	if !udrSelf.RemoveSubscriptionDataSubscription(subsId) {
+		dataRepoLog(c).Errorf("RemovesubscriptionDataSubscriptionsProcedure err: no subscription %s", subsId)
+		fail(c, &Error{Category: ErrorNotFound, Cause: "SUBSCRIPTION_NOT_FOUND"})
 		return
 	}
-	delete(udrSelf.SubscriptionDataSubscriptions, subsId)
 	c.Status(http.StatusNoContent)
 }
@@ -0,0 +1,99 @@
+package processor
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+
+	udr_context "github.com/free5gc/udr/internal/context"
+	db "github.com/free5gc/udr/internal/database"
+)
+
+// subscriberCollections hold the documents of a subscriber, by its ueId. The shared data and the group
+// memberships are not the subscriber's own, the audit trail outlives it.
+var subscriberCollections = []string{
+	db.SUBSCDATA_AUTH_SUBSC_DB_COLLECTION_NAME,
+	db.SUBSCDATA_AUTH_STATUS_DB_COLLECTION_NAME,
+	db.SUBSCDATA_INDIV_AUTH_STATUS_DB_COLLECTION_NAME,
+	db.SUBSCDATA_SOR_DATA_DB_COLLECTION_NAME,
+	db.SUBSCDATA_UPU_DATA_DB_COLLECTION_NAME,
+	db.SUBSCDATA_AM_DATA_DB_COLLECTION_NAME,
+	db.SUBSCDATA_SM_DATA_DB_COLLECTION_NAME,
+	db.SUBSCDATA_SMF_SEL_DB_COLLECTION_NAME,
+	"subscriptionData.provisionedData.smsData",
+	"subscriptionData.provisionedData.smsMngData",
+	"subscriptionData.provisionedData.traceData",
+	"subscriptionData.provisionedData.lcsPrivacyData",
+	"subscriptionData.provisionedData.lcsMoData",
+	"subscriptionData.provisionedData.v2xData",
+	"subscriptionData.provisionedData.proseData",
+	"subscriptionData.contextData.amf3gppAccess",
+	"subscriptionData.contextData.amfNon3gppAccess",
+	"subscriptionData.contextData.smfRegistrations",
+	"subscriptionData.contextData.smsf3gppAccess",
+	"subscriptionData.contextData.smsfNon3gppAccess",
+	db.SUBSCDATA_EE_SUBSC_DB_COLLECTION_NAME,
+	db.SUBSCDATA_EE_AMF_SUBSC_DB_COLLECTION_NAME,
+	"subscriptionData.eeProfileData",
+	"subscriptionData.identityData",
+	"subscriptionData.operatorDeterminedBarringData",
+	"subscriptionData.operatorSpecificData",
+	"subscriptionData.ppData",
+	"policyData.ues.amData",
+	"policyData.ues.smData",
+	"policyData.ues.smData.usageMonData",
+	"policyData.ues.uePolicySet",
+	"policyData.ues.operatorSpecificData",
+}
+
+// SubscriberDeletion is the summary of the removal of a subscriber
+type SubscriberDeletion struct {
+	UeId string `json:"ueId"`
+	// Transactional tells whether the documents were removed all at once, which needs MongoDB to be a replica set
+	Transactional bool `json:"transactional"`
+	// Deleted is the count of the documents removed by collection, the collections with none are left out
+	Deleted map[string]int64 `json:"deleted"`
+}
+
+// DeleteSubscriber removes the documents of the subscriber from all the collections, along with its SDM
+// subscriptions, and answers 200 with what was removed, 404 when there was nothing. Without a transaction a
+// failure is answered 500 with the collections removed from before it, sending the request again completes
+// the removal.
+func (p *Processor) DeleteSubscriber(c *gin.Context, supi string) {
+	deleted, transactional, err := p.DeleteManyDataFromDB(c, subscriberCollections, bson.M{"ueId": supi})
+	removed := SubscriberDeletion{UeId: supi, Transactional: transactional, Deleted: make(map[string]int64)}
+	for collName, count := range deleted {
+		if count > 0 {
+			removed.Deleted[collName] = count
+		}
+	}
+	if len(removed.Deleted) > 0 || err != nil {
+		p.invalidateAuthSubs(supi)
+	}
+
+	if err != nil {
+		if !transactional && len(removed.Deleted) > 0 {
+			collNames := make([]string, 0, len(removed.Deleted))
+			for collName := range removed.Deleted {
+				collNames = append(collNames, collName)
+			}
+			sort.Strings(collNames)
+			err = fmt.Errorf("%w, after removing the documents of %s", err, strings.Join(collNames, ", "))
+		}
+		dataRepoLog(c).Errorf("DeleteSubscriber of %s err: %+v", supi, err)
+		fail(c, err)
+		return
+	}
+
+	_, hadSdmSubscriptions := udr_context.GetSelf().UESubsCollection.LoadAndDelete(supi)
+	if len(removed.Deleted) == 0 && !hadSdmSubscriptions {
+		fail(c, &Error{Category: ErrorNotFound, Cause: "USER_NOT_FOUND"})
+		return
+	}
+	p.NotifySubscribers(c, supi, c.Request.URL.Path, documentChanges(nil))
+	c.JSON(http.StatusOK, removed)
+}
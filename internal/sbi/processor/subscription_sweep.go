@@ -0,0 +1,20 @@
+package processor
+
+import (
+	"time"
+
+	udr_context "github.com/free5gc/udr/internal/context"
+	"github.com/free5gc/udr/internal/logger"
+)
+
+// SweepExpiredSubscriptions removes the SDM subscriptions and the subscriptions to data change notifications
+// whose expiry is before now. Both are held by the context, there is nothing to remove from the datastore.
+func (p *Processor) SweepExpiredSubscriptions(now time.Time) {
+	udrSelf := udr_context.GetSelf()
+	sdmSubscriptions := udrSelf.RemoveExpiredSdmSubscriptions(now)
+	dataSubscriptions := udrSelf.RemoveExpiredSubscriptionDataSubscriptions(now)
+	if sdmSubscriptions > 0 || dataSubscriptions > 0 {
+		logger.DataRepoLog.Infof("Removed %d expired SDM subscriptions and %d expired subscriptions to data changes",
+			sdmSubscriptions, dataSubscriptions)
+	}
+}
@@ -0,0 +1,53 @@
+package processor
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/free5gc/openapi/models"
+	db "github.com/free5gc/udr/internal/database"
+)
+
+// GetSupiList answers the page of SUPIs, ordered by SUPI, every subscriber having authentication data.
+// pageNumber starts at 1, the Link header points to the next page when there is one.
+func (p *Processor) GetSupiList(c *gin.Context, pageSize, pageNumber int) {
+	// One more document than the page tells whether a next page exists
+	skip := int64(pageSize) * int64(pageNumber-1)
+	page, pd := p.GetPageFromDB(c, db.SUBSCDATA_AUTH_SUBSC_DB_COLLECTION_NAME, bson.M{}, []string{"ueId"},
+		skip, int64(pageSize)+1)
+	if pd != nil {
+		dataRepoLog(c).Errorf("GetSupiList err: %s", pd.Detail)
+		fail(c, problemOf(pd))
+		return
+	}
+
+	if len(page) > pageSize {
+		page = page[:pageSize]
+		c.Header("Link", nextPageLink(c.Request.URL, pageSize, pageNumber))
+	}
+
+	res := models.IdentityData{
+		SupiList: make([]string, 0, len(page)),
+	}
+	for _, data := range page {
+		if ueId, ok := data["ueId"].(string); ok {
+			res.SupiList = append(res.SupiList, ueId)
+		}
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// nextPageLink keeps the other query parameters of the request
+func nextPageLink(requestUrl *url.URL, pageSize, pageNumber int) string {
+	next := *requestUrl
+	query := next.Query()
+	query.Set("page-size", strconv.Itoa(pageSize))
+	query.Set("page-number", strconv.Itoa(pageNumber+1))
+	next.RawQuery = query.Encode()
+	return fmt.Sprintf("<%s>; rel=\"next\"", next.RequestURI())
+}
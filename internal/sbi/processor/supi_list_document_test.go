@@ -0,0 +1,17 @@
+package processor
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextPageLink(t *testing.T) {
+	requestUrl, err := url.Parse("/nudr-dr/v2/subscription-data/supis?page-number=2&supported-features=1")
+	require.NoError(t, err)
+
+	require.Equal(t,
+		`</nudr-dr/v2/subscription-data/supis?page-number=3&page-size=100&supported-features=1>; rel="next"`,
+		nextPageLink(requestUrl, 100, 2))
+}
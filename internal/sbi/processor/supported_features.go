@@ -0,0 +1,81 @@
+package processor
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/udr/internal/util"
+)
+
+// sharedDataFeature is the feature of nudr-dr of the IDs of the shared data the data sets refer to
+const sharedDataFeature = 1
+
+// featureAttributes are the optional attributes of a data set answered only to the consumers supporting the
+// feature, numbered from 1 as in a SupportedFeatures
+type featureAttributes struct {
+	feature    int
+	attributes []string
+}
+
+var (
+	amDataFeatures = []featureAttributes{
+		{sharedDataFeature, []string{"sharedAmDataIds", "sharedVnGroupDataIds"}},
+	}
+	smDataFeatures = []featureAttributes{
+		{sharedDataFeature, []string{"sharedVnGroupDataIds", "sharedDnnConfigurationsId", "sharedTraceDataId"}},
+	}
+)
+
+// negotiateFeatures returns the features of nudr-dr both the UDR and the consumer support. ok is false when the
+// consumer gave no supported-features, the data sets are answered whole then as before the negotiation. Of the
+// features the consumer asks for that the UDR does not support, the ones it marks mandatory in the
+// required-features query parameter, as the NRF discovery takes them, are logged at Warn: a consumer relying on
+// them is of another version of the API. The others are optional and logged at Debug.
+func (p *Processor) negotiateFeatures(c *gin.Context, supportedFeatures string) (negotiated string, ok bool) {
+	if supportedFeatures == "" {
+		return "", false
+	}
+	service := string(models.ServiceName_NUDR_DR)
+	supported := p.Config().GetSupportedFeatures(service)
+	if unsupported := util.UnsupportedFeatures(supportedFeatures, supported); unsupported != "" {
+		required := c.Query("required-features")
+		if mandatory := util.CommonFeatures(unsupported, required); mandatory != "" {
+			dataRepoLog(c).Warnf("%s %s: supported-features %s requires features %s not supported by %s %s",
+				c.Request.Method, c.Request.URL.Path, supportedFeatures, mandatory, service, supported)
+		}
+		if optional := util.UnsupportedFeatures(unsupported, required); optional != "" {
+			dataRepoLog(c).Debugf("%s %s: supported-features %s asks for features %s not supported by %s %s",
+				c.Request.Method, c.Request.URL.Path, supportedFeatures, optional, service, supported)
+		}
+	}
+	return util.CommonFeatures(supported, supportedFeatures), true
+}
+
+// stripFeatures leaves out of data the attributes of the features not negotiated
+func stripFeatures(data map[string]interface{}, features []featureAttributes, negotiated string) {
+	for _, feature := range features {
+		if util.HasFeature(negotiated, feature.feature) {
+			continue
+		}
+		for _, attribute := range feature.attributes {
+			delete(data, attribute)
+		}
+	}
+}
+
+// setSupportedFeatures answers the negotiated features in data, none being left out
+func setSupportedFeatures(data map[string]interface{}, negotiated string) {
+	setFeaturesAttribute(data, "supportedFeatures", negotiated)
+}
+
+// setSuppFeat is setSupportedFeatures for the data naming the attribute suppFeat, as the PFDs do
+func setSuppFeat(data map[string]interface{}, negotiated string) {
+	setFeaturesAttribute(data, "suppFeat", negotiated)
+}
+
+func setFeaturesAttribute(data map[string]interface{}, attribute string, negotiated string) {
+	delete(data, attribute)
+	if negotiated != "" {
+		data[attribute] = negotiated
+	}
+}
@@ -10,25 +10,19 @@
 package processor
 
 import (
-	"net/http"
-
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
-
-	"github.com/free5gc/udr/internal/logger"
-	"github.com/free5gc/util/metrics/sbi"
 )
 
 func (p *Processor) QueryTraceDataProcedure(c *gin.Context, collName string, ueId string,
 	servingPlmnId string,
 ) {
 	filter := bson.M{"ueId": ueId, "servingPlmnId": servingPlmnId}
-	data, pd := p.GetDataFromDB(collName, filter)
+	data, etag, pd := p.GetVersionedDataFromDB(c, collName, filter)
 	if pd != nil {
-		logger.DataRepoLog.Errorf("QueryTraceDataProcedure err: %s", pd.Detail)
-		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
-		c.JSON(int(pd.Status), pd)
+		dataRepoLog(c).Errorf("QueryTraceDataProcedure err: %s", pd.Detail)
+		fail(c, p.provisionedDataFailure(c, ueId, pd))
 		return
 	}
-	c.JSON(http.StatusOK, data)
+	respondVersioned(c, etag, data)
 }
@@ -0,0 +1,107 @@
+package processor
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// UeUpdateCounterMax is the largest CounterSoR and CounterUPU, 16 bit counters of 3GPP TS 33.501 Annex C
+const UeUpdateCounterMax = 0xffff
+
+// The SoR and UPU data are what the UE acknowledged of the steering information and of the parameters
+// update sent by the UDM. Their counter only increases: a record taking it back is refused with 409,
+// the acknowledgement of the UE keeps it.
+
+// putUeUpdateConfirmation stores the record of the UE in place of the previous one, unless it takes the
+// counter back
+func (p *Processor) putUeUpdateConfirmation(c *gin.Context, collName string, ueId string, putData bson.M,
+	counterField string,
+) {
+	filter := bson.M{"ueId": ueId}
+	putData["ueId"] = ueId
+
+	validate := func(current map[string]interface{}) error {
+		counter, ok := counterOf(putData, counterField)
+		if !ok {
+			return nil
+		}
+		if stored, ok := counterOf(current, counterField); ok && counter < stored {
+			return &Error{
+				Category: ErrorConflict,
+				Message:  fmt.Sprintf("%s %d is lower than the stored one %d", counterField, counter, stored),
+			}
+		}
+		return nil
+	}
+	_, etag, err := p.ReplaceVersionedDataToDB(c, collName, filter, putData, ifMatchOf(c), validate)
+	if err != nil {
+		dataRepoLog(c).Errorf("putUeUpdateConfirmation %s err: %+v", collName, err)
+		if abortVersionedWrite(c, err) {
+			return
+		}
+		fail(c, err)
+		return
+	}
+
+	setETag(c, etag)
+	c.Status(http.StatusNoContent)
+}
+
+func (p *Processor) queryUeUpdateConfirmation(c *gin.Context, collName string, ueId string) {
+	filter := bson.M{"ueId": ueId}
+	data, etag, pd := p.GetVersionedDataFromDB(c, collName, filter)
+	if pd != nil {
+		dataRepoLog(c).Errorf("queryUeUpdateConfirmation %s err: %s", collName, pd.Detail)
+		fail(c, problemOf(pd))
+		return
+	}
+
+	// ueId is the key of the document, not a field of the record
+	delete(data, "ueId")
+	respondVersioned(c, etag, data)
+}
+
+// QueryUeUpdateConfirmationDataProcedure answers the SoR and the UPU data stored for the UE, so that the
+// operator can inspect them at once
+func (p *Processor) QueryUeUpdateConfirmationDataProcedure(c *gin.Context, ueId string) {
+	filter := bson.M{"ueId": ueId}
+	confirmationData := make(map[string]interface{})
+	for dataSet, collName := range map[string]string{
+		"sorData": "subscriptionData.ueUpdateConfirmationData.sorData",
+		"upuData": "subscriptionData.ueUpdateConfirmationData.upuData",
+	} {
+		data, pd := p.GetDataFromDB(c, collName, filter)
+		if pd != nil && pd.Status != http.StatusNotFound {
+			dataRepoLog(c).Errorf("QueryUeUpdateConfirmationDataProcedure get %s err: %s", dataSet, pd.Detail)
+			fail(c, problemOf(pd))
+			return
+		}
+		if data != nil {
+			delete(data, "ueId")
+			confirmationData[dataSet] = data
+		}
+	}
+
+	if len(confirmationData) == 0 {
+		fail(c, &Error{Category: ErrorNotFound})
+		return
+	}
+	c.JSON(http.StatusOK, confirmationData)
+}
+
+// counterOf returns the counter field of the document, decoded from JSON or from BSON
+func counterOf(document map[string]interface{}, field string) (int64, bool) {
+	switch counter := document[field].(type) {
+	case float64:
+		return int64(counter), true
+	case int32:
+		return int64(counter), true
+	case int64:
+		return counter, true
+	default:
+		return 0, false
+	}
+}
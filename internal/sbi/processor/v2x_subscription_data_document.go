@@ -0,0 +1,12 @@
+package processor
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/free5gc/openapi/models"
+)
+
+// QueryV2xDataProcedure answers the V2xSubscriptionData of the UE, the same in any serving PLMN
+func (p *Processor) QueryV2xDataProcedure(c *gin.Context, collName string, ueId string) {
+	p.queryDecodedDataSet(c, "QueryV2xDataProcedure", collName, ueId, &models.V2xSubscriptionData{})
+}
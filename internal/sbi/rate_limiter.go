@@ -0,0 +1,107 @@
+package sbi
+
+import (
+	"container/list"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/udr/pkg/factory"
+	"github.com/free5gc/util/metrics/sbi"
+)
+
+// rateLimiter keeps a token bucket per consumer, the buckets are evicted least recently seen first
+// once there are maxConsumers of them
+type rateLimiter struct {
+	rate           float64
+	burst          float64
+	maxConsumers   int
+	consumerHeader string
+	now            func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*list.Element
+	lru     *list.List // of *tokenBucket, the most recently seen in front
+}
+
+type tokenBucket struct {
+	consumer string
+	tokens   float64
+	updated  time.Time
+}
+
+func newRateLimiter(cfg *factory.RateLimit) *rateLimiter {
+	return &rateLimiter{
+		rate:           cfg.Rate,
+		burst:          float64(cfg.Burst),
+		maxConsumers:   cfg.MaxConsumers,
+		consumerHeader: cfg.ConsumerHeader,
+		now:            time.Now,
+		buckets:        make(map[string]*list.Element),
+		lru:            list.New(),
+	}
+}
+
+// limit answers 429 to the requests of a consumer that has run out of tokens
+func (r *rateLimiter) limit(c *gin.Context) {
+	consumer := r.consumerOf(c)
+	allowed, retryAfter := r.allow(consumer)
+	if allowed {
+		return
+	}
+
+	pd := util.ProblemDetailsTooManyRequests(fmt.Sprintf("rate limit of %s exceeded", consumer))
+	logger.SBILog.Debugf("Reject %s %s: %s", c.Request.Method, c.Request.URL.Path, pd.Detail)
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+	c.AbortWithStatusJSON(int(pd.Status), pd)
+}
+
+// consumerOf identifies the requester by the NF instance ID of its verified access token,
+// then by the configured header and last by its source IP
+func (r *rateLimiter) consumerOf(c *gin.Context) string {
+	if nfInstanceId := c.GetString(util.RequesterNfInstanceIdCtxKey); nfInstanceId != "" {
+		return "nf:" + nfInstanceId
+	}
+	if r.consumerHeader != "" {
+		if value := c.GetHeader(r.consumerHeader); value != "" {
+			return "header:" + value
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// allow takes a token from the bucket of the consumer, otherwise returns when the next one is available
+func (r *rateLimiter) allow(consumer string) (bool, time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	var bucket *tokenBucket
+	if elem, ok := r.buckets[consumer]; ok {
+		r.lru.MoveToFront(elem)
+		bucket = elem.Value.(*tokenBucket)
+		bucket.tokens = math.Min(r.burst, bucket.tokens+now.Sub(bucket.updated).Seconds()*r.rate)
+		bucket.updated = now
+	} else {
+		if r.lru.Len() >= r.maxConsumers {
+			oldest := r.lru.Back()
+			r.lru.Remove(oldest)
+			delete(r.buckets, oldest.Value.(*tokenBucket).consumer)
+		}
+		bucket = &tokenBucket{consumer: consumer, tokens: r.burst, updated: now}
+		r.buckets[consumer] = r.lru.PushFront(bucket)
+	}
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - bucket.tokens) / r.rate * float64(time.Second))
+}
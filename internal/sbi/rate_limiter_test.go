@@ -0,0 +1,61 @@
+package sbi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	"github.com/free5gc/udr/pkg/factory"
+)
+
+func TestRateLimiter_Limit(t *testing.T) {
+	now := time.Now()
+	limiter := newRateLimiter(&factory.RateLimit{
+		Rate:           2,
+		Burst:          2,
+		MaxConsumers:   2,
+		ConsumerHeader: "X-Consumer",
+	})
+	limiter.now = func() time.Time { return now }
+
+	router := gin.New()
+	router.GET("/data", limiter.limit, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	get := func(consumer string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/data", nil)
+		req.Header.Set("X-Consumer", consumer)
+		rsp := httptest.NewRecorder()
+		router.ServeHTTP(rsp, req)
+		return rsp
+	}
+
+	require.Equal(t, http.StatusOK, get("smf").Code)
+	require.Equal(t, http.StatusOK, get("smf").Code)
+	rsp := get("smf")
+	require.Equal(t, http.StatusTooManyRequests, rsp.Code)
+	require.Equal(t, "1", rsp.Header().Get("Retry-After"))
+	require.Contains(t, rsp.Body.String(), "TOO_MANY_REQUESTS")
+
+	// The other consumers keep their own bucket
+	require.Equal(t, http.StatusOK, get("pcf").Code)
+
+	now = now.Add(500 * time.Millisecond)
+	require.Equal(t, http.StatusOK, get("smf").Code)
+	require.Equal(t, http.StatusTooManyRequests, get("smf").Code)
+
+	// A third consumer evicts the least recently seen one, which is pcf
+	require.Equal(t, http.StatusOK, get("nef").Code)
+	require.Len(t, limiter.buckets, 2)
+	require.NotContains(t, limiter.buckets, "header:pcf")
+	require.Equal(t, http.StatusTooManyRequests, get("smf").Code)
+}
+
+func TestRateLimiter_DisabledWithoutConfig(t *testing.T) {
+	s := newTestServer(t)
+	require.Nil(t, s.rateLimiter)
+}
@@ -0,0 +1,50 @@
+package sbi
+
+import (
+	"errors"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/free5gc/udr/internal/logger"
+	udr_metrics "github.com/free5gc/udr/internal/metrics"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/util/metrics/sbi"
+)
+
+// recoverPanic answers 500 with an incident ID to the request whose handler panicked, the same ID is
+// logged with the stack. accessLog runs before it so that the request is still logged.
+func (s *Server) recoverPanic(c *gin.Context) {
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+		if err, ok := recovered.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+			// net/http aborts the response silently on purpose
+			panic(recovered)
+		}
+
+		incidentId := uuid.New().String()
+		path := c.Request.URL.Path
+		if s.Config().IsLogAnonymized() {
+			path = maskSupis(path)
+		}
+		util.RequestLog(c, logger.SBILog).Errorf("panic [incident %s] on %s %s: %v\n%s",
+			incidentId, c.Request.Method, path, recovered, string(debug.Stack()))
+		udr_metrics.IncrPanicCounter(udr_metrics.PANIC_SOURCE_SBI_HANDLER)
+
+		pd := util.ProblemDetailsIncident(incidentId)
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		if c.Writer.Written() {
+			// Too late for a ProblemDetails, the consumer sees the response cut short
+			c.Abort()
+			return
+		}
+		c.AbortWithStatusJSON(int(pd.Status), pd)
+	}()
+
+	c.Next()
+}
@@ -0,0 +1,44 @@
+package sbi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/free5gc/openapi/models"
+	udr_metrics "github.com/free5gc/udr/internal/metrics"
+)
+
+func TestServer_RecoverPanic(t *testing.T) {
+	udr_metrics.GetUdrMetrics("free5gc")
+	udr_metrics.EnableUdrMetrics()
+
+	s := newTestServer(t)
+	s.router.GET("/panic", func(c *gin.Context) {
+		var subscription *models.SubscriptionDataSubscriptions
+		c.String(http.StatusOK, subscription.UeId)
+	})
+
+	rsp := httptest.NewRecorder()
+	s.router.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	require.Equal(t, http.StatusInternalServerError, rsp.Code)
+
+	var pd models.ProblemDetails
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &pd))
+	require.Equal(t, "SYSTEM_FAILURE", pd.Cause)
+	require.True(t, strings.HasPrefix(pd.Instance, "urn:uuid:"))
+	require.Contains(t, pd.Detail, strings.TrimPrefix(pd.Instance, "urn:uuid:"))
+	require.Equal(t, float64(1), testutil.ToFloat64(
+		udr_metrics.PanicCounter.WithLabelValues(udr_metrics.PANIC_SOURCE_SBI_HANDLER)))
+
+	// The server keeps serving
+	rsp = httptest.NewRecorder()
+	s.router.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, UdrLivenessPath, nil))
+	require.Equal(t, http.StatusOK, rsp.Code)
+}
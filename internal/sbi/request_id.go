@@ -0,0 +1,40 @@
+package sbi
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/free5gc/udr/internal/util"
+)
+
+const (
+	HeaderRequestId = "X-Request-ID"
+
+	// maxRequestIdLength bounds the request ID taken from the consumer, as it is written to every log line
+	maxRequestIdLength = 128
+)
+
+// requestId puts the request ID of the consumer, or a generated one when absent or unusable, on the
+// context and echoes it in the response
+func (s *Server) requestId(c *gin.Context) {
+	requestId := c.GetHeader(HeaderRequestId)
+	if !isValidRequestId(requestId) {
+		requestId = uuid.New().String()
+	}
+	c.Set(util.RequestIdCtxKey, requestId)
+	c.Header(HeaderRequestId, requestId)
+}
+
+// isValidRequestId accepts the printable ASCII IDs of at most maxRequestIdLength characters,
+// keeping the log lines free of injected control characters
+func isValidRequestId(requestId string) bool {
+	if requestId == "" || len(requestId) > maxRequestIdLength {
+		return false
+	}
+	for i := 0; i < len(requestId); i++ {
+		if requestId[i] < 0x21 || requestId[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
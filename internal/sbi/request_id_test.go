@@ -0,0 +1,69 @@
+package sbi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/udr/pkg/factory"
+)
+
+func TestServer_RequestId(t *testing.T) {
+	s := newTestServer(t)
+
+	var logged interface{}
+	s.router.GET("/request-id", func(c *gin.Context) {
+		logged = util.RequestLog(c, logger.ProcLog).Data["requestId"]
+		c.Status(http.StatusOK)
+	})
+
+	testCases := []struct {
+		name      string
+		requestId string
+		echoed    bool
+	}{
+		{"From Consumer", "amf-7f3c9a2e", true},
+		{"Absent", "", false},
+		{"Control Characters", "id\r\nforged", false},
+		{"Too Long", strings.Repeat("a", maxRequestIdLength+1), false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/request-id", nil)
+			if tc.requestId != "" {
+				req.Header.Set(HeaderRequestId, tc.requestId)
+			}
+			rsp := httptest.NewRecorder()
+			s.router.ServeHTTP(rsp, req)
+			require.Equal(t, http.StatusOK, rsp.Code)
+
+			requestId := rsp.Header().Get(HeaderRequestId)
+			if tc.echoed {
+				require.Equal(t, tc.requestId, requestId)
+			} else {
+				_, err := uuid.Parse(requestId)
+				require.NoError(t, err)
+			}
+			require.Equal(t, requestId, logged)
+		})
+	}
+
+	t.Run("Rejected Data Repository Request", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, factory.UdrDrResUriPrefix+
+			"/subscription-data/imsi-208930000000001/context-data/amf-3gpp-access", strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "text/plain")
+		req.Header.Set(HeaderRequestId, "smf-1")
+		rsp := httptest.NewRecorder()
+		s.router.ServeHTTP(rsp, req)
+		require.Equal(t, http.StatusUnsupportedMediaType, rsp.Code)
+		require.Equal(t, "smf-1", rsp.Header().Get(HeaderRequestId))
+	})
+}
@@ -0,0 +1,91 @@
+package sbi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/util/metrics/sbi"
+)
+
+// requestTimeoutWriteMargin is left to write the response of a request once its timeout is exceeded
+const requestTimeoutWriteMargin = time.Second
+
+// limitRequestDuration cancels the request context once the request timeout of its path is exceeded and
+// answers 504 in place of the response the handler had not written by then
+func (s *Server) limitRequestDuration(c *gin.Context) {
+	timeout := s.Config().GetSbiRequestTimeout(c.Request.URL.Path)
+	if _, _, writeTimeout, _ := s.Config().GetSbiTimeouts(); timeout+requestTimeoutWriteMargin > writeTimeout {
+		// The write timeout of the server would cut the response of the longer routes
+		err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(timeout + requestTimeoutWriteMargin))
+		if err != nil && !errors.Is(err, http.ErrNotSupported) {
+			logger.SBILog.Warnf("Extend the write deadline of %s %s failed: %+v", c.Request.Method, c.Request.URL.Path, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	defer cancel()
+	c.Request = c.Request.WithContext(ctx)
+
+	writer := &deadlineResponseWriter{ResponseWriter: c.Writer, ctx: ctx}
+	c.Writer = writer
+	// On a panic recoverPanic answers on the original writer
+	defer func() {
+		c.Writer = writer.ResponseWriter
+	}()
+
+	c.Next()
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) && !writer.ResponseWriter.Written() {
+		c.Writer = writer.ResponseWriter
+		pd := util.ProblemDetailsGatewayTimeout(fmt.Sprintf("request not handled within %s", timeout))
+		util.RequestLog(c, logger.SBILog).Warnf("Timeout of %s %s: %s", c.Request.Method, c.Request.URL.Path, pd.Detail)
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+		c.AbortWithStatusJSON(int(pd.Status), pd)
+	}
+}
+
+// deadlineResponseWriter drops the response the handler writes once the request context deadline is
+// exceeded, the handler then answers the failure of its canceled MongoDB query and not the timeout
+type deadlineResponseWriter struct {
+	gin.ResponseWriter
+	ctx context.Context
+}
+
+func (w *deadlineResponseWriter) timedOut() bool {
+	return !w.ResponseWriter.Written() && errors.Is(w.ctx.Err(), context.DeadlineExceeded)
+}
+
+func (w *deadlineResponseWriter) WriteHeader(code int) {
+	if w.timedOut() {
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *deadlineResponseWriter) WriteHeaderNow() {
+	if w.timedOut() {
+		return
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *deadlineResponseWriter) Write(data []byte) (int, error) {
+	if w.timedOut() {
+		return len(data), nil
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *deadlineResponseWriter) WriteString(data string) (int, error) {
+	if w.timedOut() {
+		return len(data), nil
+	}
+	return w.ResponseWriter.WriteString(data)
+}
@@ -1,7 +1,13 @@
 package sbi
 
 import (
+	"strings"
+
 	"github.com/gin-gonic/gin"
+
+	"github.com/free5gc/udr/internal/sbi/processor"
+	"github.com/free5gc/udr/internal/util"
+	"github.com/free5gc/util/metrics/sbi"
 )
 
 // Route is the information for every URI.
@@ -36,3 +42,66 @@ func AddService(group *gin.RouterGroup, routes []Route) {
 		}
 	}
 }
+
+// answerFailures wraps the handler of every route to answer the failure its procedure recorded on the context,
+// unless the procedure answered already
+func answerFailures(routes []Route) []Route {
+	answering := make([]Route, 0, len(routes))
+	for _, route := range routes {
+		handler := route.HandlerFunc
+		route.HandlerFunc = func(c *gin.Context) {
+			handler(c)
+			if failure := c.Errors.ByType(gin.ErrorTypePrivate).Last(); failure != nil && !c.Writer.Written() {
+				respondError(c, failure.Err)
+			}
+		}
+		answering = append(answering, route)
+	}
+	return answering
+}
+
+// respondError answers the ProblemDetails of err
+func respondError(c *gin.Context, err error) {
+	problemDetails := processor.ProblemDetailsOf(err)
+	c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
+	c.JSON(int(problemDetails.Status), problemDetails)
+}
+
+// dataSetScopes are the additional scopes of the Nudr_DataRepository, by the first segment of the route
+// pattern naming the data set
+var dataSetScopes = []struct {
+	dataSet string
+	scope   string
+}{
+	{"subscription-data", util.ScopeNudrDrSubscriptionData},
+	{"policy-data", util.ScopeNudrDrPolicyData},
+	{"exposure-data", util.ScopeNudrDrExposureData},
+	{"application-data", util.ScopeNudrDrApplicationData},
+}
+
+// scopeRoutes are the routes requiring the same additional scope, none for the routes outside a data set
+type scopeRoutes struct {
+	scope  string
+	routes []Route
+}
+
+// groupRoutesByScope splits routes by the additional scope of their data set, in the order of dataSetScopes
+func groupRoutesByScope(routes []Route) []scopeRoutes {
+	groups := make([]scopeRoutes, len(dataSetScopes)+1)
+	for i, dataSetScope := range dataSetScopes {
+		groups[i+1].scope = dataSetScope.scope
+	}
+
+	for _, route := range routes {
+		dataSet, _, _ := strings.Cut(strings.TrimPrefix(route.Pattern, "/"), "/")
+		group := 0
+		for i, dataSetScope := range dataSetScopes {
+			if dataSetScope.dataSet == dataSet {
+				group = i + 1
+				break
+			}
+		}
+		groups[group].routes = append(groups[group].routes, route)
+	}
+	return groups
+}
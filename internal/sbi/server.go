@@ -1,30 +1,84 @@
 package sbi
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/free5gc/openapi/models"
 	"github.com/free5gc/udr/internal/logger"
+	udr_metrics "github.com/free5gc/udr/internal/metrics"
 	"github.com/free5gc/udr/internal/sbi/processor"
 	"github.com/free5gc/udr/internal/util"
 	"github.com/free5gc/udr/pkg/app"
 	"github.com/free5gc/udr/pkg/factory"
-	"github.com/free5gc/util/httpwrapper"
-	logger_util "github.com/free5gc/util/logger"
 	"github.com/free5gc/util/metrics"
+	"github.com/free5gc/util/metrics/sbi"
 )
 
 type Server struct {
 	UDR
 
-	httpServer *http.Server
-	router     *gin.Engine
+	httpServers []*sbiListener
+	router      *gin.Engine
+	metrics     *handlerMetrics
+	rateLimiter *rateLimiter // nil when the rate limit is not configured
+	debugServer *http.Server // nil when debug.pprof is off
+	adminServer *http.Server // nil when no admin listener is configured
+
+	// resolveNfType looks up the NF type of the consumers for allowedNfTypes, nil when there is none
+	resolveNfType NfTypeResolver
+	// processor overrides the one of the UDR, nil when there is none
+	processor Procedures
+
+	// ready is set by the startup sequence once the datastore is connected and the server started
+	ready atomic.Bool
+	// nrfRegistered follows whether the NF profile is registered to the NRF
+	nrfRegistered atomic.Bool
+	// lastNrfHeartbeat is the unix nanoseconds of the latest heartbeat the NRF accepted, 0 before the first one
+	lastNrfHeartbeat atomic.Int64
+	// pingDataStore is checked by the readiness probe and the datastore watch
+	pingDataStore func(ctx context.Context) error
+	// dataStoreAvailable is the outcome of the latest datastore check, assumed available until checked
+	dataStoreAvailable atomic.Bool
+	stopWatch          context.CancelFunc
+
+	// state is derived from ready, dataStoreAvailable, nrfRegistered and draining by updateState
+	state   atomic.Int32
+	stateMu sync.Mutex
+
+	// draining is set once Shutdown starts; requests arriving afterwards are rejected
+	draining  atomic.Bool
+	serveDone chan struct{}
+
+	// inflight tracks the handlers still running so the datastore is closed only after them
+	inflight       sync.WaitGroup
+	activeRequests atomic.Int64
+}
+
+// sbiListener is one of the http.Server sharing the router, created per configured SBI binding
+type sbiListener struct {
+	*http.Server
+	binding factory.SbiBinding
+
+	// unixSocket is set when the server listens on a unix domain socket instead of TCP
+	unixSocket *factory.UnixSocket
+
+	// certReloader provides the certificate of the https listeners
+	certReloader *certReloader
 }
 
 type UDR interface {
@@ -33,122 +87,546 @@ type UDR interface {
 	Processor() *processor.Processor
 }
 
-func NewServer(udr UDR, tlsKeyLogPath string) *Server {
+// Processor returns the procedures serving the handlers, the ones of WithProcessor if given, nil when the UDR has
+// no processor
+func (s *Server) Processor() Procedures {
+	if s.processor != nil {
+		return s.processor
+	}
+	if p := s.UDR.Processor(); p != nil {
+		return p
+	}
+	return nil
+}
+
+// ServerOption customizes the Server built by NewServer
+type ServerOption func(*serverOptions)
+
+type serverOptions struct {
+	metricsRegistry *prometheus.Registry
+	nfTypeResolver  NfTypeResolver
+	processor       Procedures
+}
+
+// WithMetricsRegistry registers the handler metrics in the given registry instead of a new one
+func WithMetricsRegistry(registry *prometheus.Registry) ServerOption {
+	return func(o *serverOptions) {
+		o.metricsRegistry = registry
+	}
+}
+
+// WithProcessor has the handlers served by p instead of the processor of the UDR, e.g. a processor on a fake
+// datastore or a fake of the procedures for testing the HTTP layer
+func WithProcessor(p Procedures) ServerOption {
+	return func(o *serverOptions) {
+		o.processor = p
+	}
+}
+
+// NewServer builds the SBI server, failing when a listener cannot be set up from its configuration
+func NewServer(udr UDR, tlsKeyLogPath string, opts ...ServerOption) (*Server, error) {
+	options := &serverOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.metricsRegistry == nil {
+		options.metricsRegistry = prometheus.NewRegistry()
+	}
+
 	s := &Server{
-		UDR: udr,
+		UDR:     udr,
+		metrics: newHandlerMetrics(options.metricsRegistry, udr.Config().GetMetricsNamespace()),
+
+		resolveNfType: options.nfTypeResolver,
+		processor:     options.processor,
+	}
+	s.pingDataStore = func(ctx context.Context) error {
+		return s.Processor().Ping(ctx)
+	}
+	s.dataStoreAvailable.Store(true)
+	if rateLimit := udr.Config().GetSbiRateLimit(); rateLimit != nil {
+		s.rateLimiter = newRateLimiter(rateLimit)
 	}
 
 	s.router = newRouter(s)
-	server, err := bindRouter(udr, s.router, tlsKeyLogPath)
-	s.httpServer = server
+	timeouts := newServerTimeouts(udr.Config())
+	for _, binding := range udr.Config().GetSbiBindings() {
+		server, err := bindRouter(binding, s.router, tlsKeyLogPath, timeouts)
+		if err != nil {
+			return nil, fmt.Errorf("bind router on %s: %w", binding.GetBindingAddr(), err)
+		}
+		s.httpServers = append(s.httpServers, &sbiListener{
+			Server:  server,
+			binding: binding,
+		})
+	}
 
-	if err != nil {
-		logger.SBILog.Errorf("bind Router Error: %+v", err)
-		panic("Server initialization failed")
+	if unixSocket := udr.Config().GetSbiUnixSocket(); unixSocket != nil {
+		server, err := bindUnixSocket(unixSocket, s.router, timeouts)
+		if err != nil {
+			return nil, fmt.Errorf("bind router on unix socket %s: %w", unixSocket.Path, err)
+		}
+		s.httpServers = append(s.httpServers, server)
 	}
 
-	return s
+	if udr.Config().IsDebugPprofEnabled() {
+		s.debugServer = newDebugServer(s, udr.Config().GetDebugBindAddr())
+	}
+	if bindAddr := udr.Config().GetAdminBindAddr(); bindAddr != "" {
+		s.adminServer = newAdminServer(s, bindAddr)
+	}
+
+	return s, nil
 }
 
-func (s *Server) Run(wg *sync.WaitGroup) {
+// Run serves the SBI, failing without serving any request when one of the listeners cannot be opened
+func (s *Server) Run(wg *sync.WaitGroup) error {
 	logger.SBILog.Info("Starting server...")
 
-	wg.Add(1)
+	// Open every listener before serving so that a single bind failure aborts the whole startup
+	listeners := make([]net.Listener, 0, len(s.httpServers))
+	for _, server := range s.httpServers {
+		ln, err := server.listen()
+		if err != nil {
+			closeListeners(listeners)
+			return fmt.Errorf("SBI server failed to listen on %s: %w", server.Addr, err)
+		}
+		listeners = append(listeners, ln)
+	}
+	debugListener, err := s.listenDebugServer()
+	if err != nil {
+		closeListeners(listeners)
+		return err
+	}
+	adminListener, err := s.listenAdminServer()
+	if err != nil {
+		if debugListener != nil {
+			listeners = append(listeners, debugListener)
+		}
+		closeListeners(listeners)
+		return err
+	}
+
+	s.serveDone = make(chan struct{})
+	var serving sync.WaitGroup
+	for i, server := range s.httpServers {
+		ln := listeners[i]
+		serving.Add(1)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer serving.Done()
+
+			if serveErr := server.serve(ln); serveErr != http.ErrServerClosed {
+				logger.SBILog.Errorf("SBI server failed on %s: %+v", server.Addr, serveErr)
+				return
+			}
+			logger.SBILog.Infof("SBI server (listen on %s) stopped", server.Addr)
+		}()
+	}
 	go func() {
-		defer wg.Done()
+		serving.Wait()
+		close(s.serveDone)
+	}()
+
+	var watchCtx context.Context
+	watchCtx, s.stopWatch = context.WithCancel(context.Background())
+	s.watchDataStore(watchCtx, wg)
+	s.sweepExpiredSubscriptions(watchCtx, wg)
+
+	if debugListener != nil {
+		s.serveDebugServer(wg, debugListener)
+	}
+	if adminListener != nil {
+		s.serveAdminServer(wg, adminListener)
+	}
+	return nil
+}
 
-		err := s.serve()
-		if err != http.ErrServerClosed {
-			logger.SBILog.Panicf("HTTP server setup failed: %+v", err)
+func closeListeners(listeners []net.Listener) {
+	for _, ln := range listeners {
+		if err := ln.Close(); err != nil {
+			logger.SBILog.Warnf("Close listener on %s failed: %+v", ln.Addr(), err)
 		}
-		logger.SBILog.Infof("SBI server (listen on %s) stopped", s.httpServer.Addr)
+	}
+}
+
+// Shutdown stops accepting new requests and waits for the in-flight ones to finish
+// for at most the configured graceful shutdown timeout (0 waits indefinitely) or
+// until ctx is done, after which the remaining connections are closed forcibly.
+func (s *Server) Shutdown(ctx context.Context) {
+	s.draining.Store(true)
+	s.updateState()
+	if s.stopWatch != nil {
+		s.stopWatch()
+	}
+
+	if shutdownTimeout := s.Config().GetGracefulShutdownTimeout(); shutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, shutdownTimeout)
+		defer cancel()
+	}
+
+	s.shutdownHttpServer(ctx)
+	s.shutdownDebugServer(ctx)
+	s.shutdownAdminServer(ctx)
+	s.removeUnixSockets()
+	s.waitInflightRequests(ctx)
+	s.shutdownDataStore(ctx)
+}
+
+// SetReady flips the readiness reported on the readiness probe
+func (s *Server) SetReady(ready bool) {
+	s.ready.Store(ready)
+	if ready {
+		// The startup sequence has just connected the datastore, no need to wait for the next check of the watch
+		s.checkDataStore(context.Background())
+		return
+	}
+	s.updateState()
+}
+
+// SetNrfRegistered tells the readiness probe and the availability gate whether the NF profile is
+// registered to the NRF
+func (s *Server) SetNrfRegistered(registered bool) {
+	s.nrfRegistered.Store(registered)
+	s.updateState()
+}
+
+// SetNrfHeartbeat records the time of a heartbeat the NRF accepted, reported on /healthz/detail
+func (s *Server) SetNrfHeartbeat(at time.Time) {
+	s.lastNrfHeartbeat.Store(at.UnixNano())
+}
+
+// ActiveRequests returns the number of requests currently being handled
+func (s *Server) ActiveRequests() int64 {
+	return s.activeRequests.Load()
+}
+
+func (s *Server) shutdownHttpServer(ctx context.Context) {
+	for _, server := range s.httpServers {
+		if server.certReloader != nil {
+			server.certReloader.stop()
+		}
+
+		err := server.Shutdown(ctx)
+		if err == nil {
+			logger.SBILog.Infof("SBI server (listen on %s) shutdown completed", server.Addr)
+			continue
+		}
+
+		logger.SBILog.Infof("SBI server (listen on %s) shutdown deadline reached, closing remaining connections: %+v",
+			server.Addr, err)
+		if err = server.Close(); err != nil {
+			logger.SBILog.Errorf("HTTP server close failed: %+v", err)
+		}
+	}
+
+	if s.serveDone == nil {
+		return
+	}
+
+	select {
+	case <-s.serveDone:
+	case <-ctx.Done():
+		logger.SBILog.Warnf("SBI server did not stop before shutdown deadline")
+	}
+}
+
+func (s *Server) waitInflightRequests(ctx context.Context) {
+	drained := make(chan struct{})
+	go func() {
+		s.inflight.Wait()
+		close(drained)
 	}()
+
+	select {
+	case <-drained:
+		logger.SBILog.Infof("All in-flight SBI requests finished")
+	case <-ctx.Done():
+		logger.SBILog.Warnf("Shutdown deadline reached with %d SBI requests still in flight", s.ActiveRequests())
+	}
 }
 
-func (s *Server) Shutdown() {
-	s.shutdownHttpServer()
+func (s *Server) shutdownDataStore(ctx context.Context) {
+	if s.Processor() == nil {
+		return
+	}
+	s.Processor().StopAudit(ctx)
+	if err := s.Processor().Disconnect(ctx); err != nil {
+		logger.SBILog.Errorf("Datastore disconnect failed: %+v", err)
+		return
+	}
+	logger.SBILog.Infof("Datastore disconnected")
 }
 
-func (s *Server) shutdownHttpServer() {
-	const shutdownTimeout time.Duration = 2 * time.Second
+// trackInflight counts the request as active until every following handler has returned
+func (s *Server) trackInflight(c *gin.Context) {
+	s.inflight.Add(1)
+	s.activeRequests.Add(1)
+	defer func() {
+		s.activeRequests.Add(-1)
+		s.inflight.Done()
+	}()
 
-	if s.httpServer == nil {
+	c.Next()
+}
+
+// limitRequestBody buffers the request body up to the limit of its path and answers 413 beyond it,
+// the following handlers read the buffered copy
+func (s *Server) limitRequestBody(c *gin.Context) {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
 		return
 	}
 
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
-	defer cancel()
+	maxBytes := s.Config().GetSbiMaxRequestBodyBytes(c.Request.URL.Path)
+	if c.Request.ContentLength > maxBytes {
+		s.rejectRequestBody(c, maxBytes)
+		return
+	}
 
-	err := s.httpServer.Shutdown(shutdownCtx)
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes))
 	if err != nil {
-		logger.SBILog.Errorf("HTTP server shutdown failed: %+v", err)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			s.rejectRequestBody(c, maxBytes)
+			return
+		}
+		pd := util.ProblemDetailsMalformedReqSyntax(err.Error())
+		c.AbortWithStatusJSON(int(pd.Status), pd)
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+}
+
+func (s *Server) rejectRequestBody(c *gin.Context, maxBytes int64) {
+	pd := util.ProblemDetailsPayloadTooLarge(fmt.Sprintf("request body exceeds the limit of %d bytes", maxBytes))
+	logger.SBILog.Warnf("Reject %s %s: %s", c.Request.Method, c.Request.URL.Path, pd.Detail)
+	c.Header("Connection", "close")
+	c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+	c.AbortWithStatusJSON(int(pd.Status), pd)
+}
+
+// rejectWhileDraining answers 503 to requests received after Shutdown has started
+func (s *Server) rejectWhileDraining(c *gin.Context) {
+	if !s.draining.Load() {
+		return
+	}
+
+	pd := &models.ProblemDetails{
+		Title:  "Service unavailable",
+		Status: http.StatusServiceUnavailable,
+		Detail: "UDR is shutting down",
+		Cause:  "NF_SERVICE_FAILOVER",
 	}
+	c.Header("Connection", "close")
+	c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+	c.AbortWithStatusJSON(int(pd.Status), pd)
 }
 
-func bindRouter(udr app.App, router *gin.Engine, tlsKeyLogPath string) (*http.Server, error) {
-	sbiConfig := udr.Config().Configuration.Sbi
-	bindAddr := fmt.Sprintf("%s:%d", sbiConfig.BindingIPv4, sbiConfig.Port)
+func bindRouter(binding factory.SbiBinding, router *gin.Engine, tlsKeyLogPath string, timeouts serverTimeouts) (
+	*http.Server, error,
+) {
+	bindAddr := binding.GetBindingAddr()
+
+	var tlsConfig *tls.Config
+	if binding.Scheme == "https" {
+		tlsConfig = newTLSConfig(binding.Tls)
+	}
+	server, err := newHttp2Server(bindAddr, tlsKeyLogPath, router, timeouts, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
 
-	return httpwrapper.NewHttp2Server(bindAddr, tlsKeyLogPath, router)
+	if binding.Scheme == "https" && binding.Tls != nil {
+		if clientAuth := binding.Tls.GetClientAuth(); clientAuth != factory.UdrSbiClientAuthNone {
+			if err = configureClientAuth(server, clientAuth, binding.Tls.ClientCAs); err != nil {
+				return nil, err
+			}
+			logger.SBILog.Infof("SBI mutual TLS [%s] enabled on %s, client certificates verified against %s",
+				clientAuth, bindAddr, binding.Tls.ClientCAs)
+		}
+	}
+
+	return server, nil
+}
+
+// configureClientAuth makes the TLS handshake fail for clients presenting a certificate not signed by one
+// of the given CAs, and for clients presenting none at all in require-and-verify mode
+func configureClientAuth(server *http.Server, clientAuth, caPath string) error {
+	caPem, err := os.ReadFile(caPath)
+	if err != nil {
+		return fmt.Errorf("read client CAs [%s] fail: %w", caPath, err)
+	}
+
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(caPem) {
+		return fmt.Errorf("no valid certificate found in client CAs [%s]", caPath)
+	}
+
+	if server.TLSConfig == nil {
+		server.TLSConfig = &tls.Config{}
+	}
+	server.TLSConfig.ClientCAs = clientCAs
+	switch clientAuth {
+	case factory.UdrSbiClientAuthRequest:
+		server.TLSConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	case factory.UdrSbiClientAuthRequireAndVerify:
+		server.TLSConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return fmt.Errorf("invalid TLS client auth: %s", clientAuth)
+	}
+	return nil
+}
+
+// isAuthorizationSkipped tells whether the request comes from the unix socket and the authorization
+// is disabled for it
+func (s *Server) isAuthorizationSkipped(c *gin.Context) bool {
+	unixSocket := s.Config().GetSbiUnixSocket()
+	return unixSocket != nil && unixSocket.SkipAuthorization && isUnixSocketRequest(c.Request)
+}
+
+// authorizationCheck runs the RouterAuthorizationCheck of the service, with the additional scope
+// requiredScope when not empty, unless the request comes from the unix socket and the check is
+// disabled for it
+func (s *Server) authorizationCheck(serviceName models.ServiceName, requiredScope string) gin.HandlerFunc {
+	check := util.NewRouterAuthorizationCheck(serviceName).
+		WithRequiredScope(requiredScope, s.Config().AreOAuth2ScopesStrict())
+	return func(c *gin.Context) {
+		if s.isAuthorizationSkipped(c) {
+			return
+		}
+		check.Check(c, s.Context())
+
+		udrContext := s.Context()
+		if !c.IsAborted() && udrContext.OAuth2Required() && !udrContext.OAuth2SkipVerification {
+			c.Set(util.RequesterNfInstanceIdCtxKey, util.RequesterNfInstanceId(c.GetHeader("Authorization")))
+		}
+	}
 }
 
 func newRouter(s *Server) *gin.Engine {
-	router := logger_util.NewGinWithLogrus(logger.GinLog)
+	// accessLog takes the place of the gin log line of logger_util.NewGinWithLogrus
+	router := gin.New()
+	router.Use(s.requestId, s.accessLog, s.recoverPanic)
+	// Let the handlers use the gin.Context as the request context, bounded by limitRequestDuration, so that
+	// their MongoDB queries are abandoned with the request
+	router.ContextWithFallback = true
+
+	// Probes are registered first so that neither the draining nor the authorization middleware applies
+	AddService(&router.RouterGroup, s.getProbeRoutes())
+
+	// The preflight requests are answered here, ahead of the authorization of the groups
+	if cors := s.Config().GetSbiCors(); cors != nil {
+		router.Use(newCorsHandler(cors))
+	}
+
 	router.Use(metrics.InboundMetrics())
+	router.Use(udr_metrics.RouteMetrics)
+	router.Use(s.trackInflight)
+	router.Use(s.rejectUnavailable)
+	router.Use(s.limitRequestDuration)
+	router.Use(s.compressResponse)
+	router.Use(s.decompressRequest)
+	router.Use(s.limitRequestBody)
+	router.Use(util.ExposeClientCertificate)
+
+	router.GET(UdrSbiMetricsPath, s.metrics.handler())
 
-	dataRepositoryGroup := router.Group(factory.UdrDrResUriPrefix)
-	dataRepositoryGroup.Use(func(c *gin.Context) {
-		util.NewRouterAuthorizationCheck(models.ServiceName_NUDR_DR).Check(c, s.Context())
-	})
 	dataRepositoryRoutes := s.getDataRepositoryRoutes()
-	AddService(dataRepositoryGroup, dataRepositoryRoutes)
+	dataRepositoryRoutes = append(dataRepositoryRoutes, s.getPolicyDataRoutes()...)
+	dataRepositoryRoutes = append(dataRepositoryRoutes, s.getGroupIdentifiersRoutes()...)
+	dataRepositoryRoutes = append(dataRepositoryRoutes, s.getSupiListRoutes()...)
+	dataRepositoryRoutes = append(dataRepositoryRoutes, s.getAmDataQueryRoutes()...)
+	dataRepositoryRoutes = append(dataRepositoryRoutes, s.getBulkProvisioningRoutes()...)
+	dataRepositoryRoutes = append(dataRepositoryRoutes, s.getSubscriberRoutes()...)
+	dataRepositoryRoutes = append(dataRepositoryRoutes, s.getBatchReadRoutes()...)
+	// One group per data set, each one requires the additional scope of its data set
+	for _, dataSet := range groupRoutesByScope(dataRepositoryRoutes) {
+		dataRepositoryGroup := router.Group(factory.UdrDrResUriPrefix)
+		dataRepositoryGroup.Use(s.authorizationCheck(models.ServiceName_NUDR_DR, dataSet.scope))
+		dataRepositoryGroup.Use(s.checkConsumerNfType)
+		dataRepositoryGroup.Use(s.checkContentType)
+		if s.rateLimiter != nil {
+			dataRepositoryGroup.Use(s.rateLimiter.limit)
+		}
+		dataRepositoryGroup.Use(s.dryRunWrites)
+		if auditedScopes[dataSet.scope] && s.Config().IsAuditEnabled() {
+			dataRepositoryGroup.Use(s.auditWrites)
+		}
+		AddService(dataRepositoryGroup, s.metrics.instrumentRoutes(answerFailures(dataSet.routes)))
+	}
 
 	groupIdGroup := router.Group(factory.UdrGroupIdResUriPrefix)
-	groupIdGroup.Use(func(c *gin.Context) {
-		util.NewRouterAuthorizationCheck(models.ServiceName_NUDR_GROUP_ID_MAP).Check(c, s.Context())
-	})
+	groupIdGroup.Use(s.authorizationCheck(models.ServiceName_NUDR_GROUP_ID_MAP, ""))
 	groupIdRoutes := s.getGroupIdMap()
-	AddService(groupIdGroup, groupIdRoutes)
+	AddService(groupIdGroup, answerFailures(groupIdRoutes))
 
 	imsSDM := router.Group(factory.HSSIsmSDMUriPrefix)
-	imsSDM.Use(func(c *gin.Context) {
-		util.NewRouterAuthorizationCheck(models.ServiceName_NHSS_IMS_SDM).Check(c, s.Context())
-	})
+	imsSDM.Use(s.authorizationCheck(models.ServiceName_NHSS_IMS_SDM, ""))
 	imsSDMRoutes := s.getImsSDMRoutes()
-	AddService(imsSDM, imsSDMRoutes)
+	AddService(imsSDM, answerFailures(imsSDMRoutes))
 
 	return router
 }
 
-func (s *Server) unsecureServe() error {
-	return s.httpServer.ListenAndServe()
-}
+func (l *sbiListener) listen() (net.Listener, error) {
+	if l.unixSocket != nil {
+		return listenUnixSocket(l.unixSocket)
+	}
 
-func (s *Server) secureServe() error {
-	sbiConfig := s.UDR.Config()
+	ln, err := net.Listen("tcp", l.Addr)
+	if err != nil || l.binding.Scheme != "https" {
+		return ln, err
+	}
 
-	pemPath := sbiConfig.GetCertPemPath()
-	if pemPath == "" {
-		pemPath = factory.UdrDefaultCertPemPath
+	if err = l.setupCertReloader(); err != nil {
+		if closeErr := ln.Close(); closeErr != nil {
+			logger.SBILog.Warnf("Close listener on %s failed: %+v", l.Addr, closeErr)
+		}
+		return nil, err
+	}
+	return ln, nil
+}
+
+func (l *sbiListener) setupCertReloader() error {
+	pemPath := factory.UdrDefaultCertPemPath
+	keyPath := factory.UdrDefaultPrivateKeyPath
+	if tls := l.binding.Tls; tls != nil {
+		if tls.Pem != "" {
+			pemPath = tls.Pem
+		}
+		if tls.Key != "" {
+			keyPath = tls.Key
+		}
 	}
 
-	keyPath := sbiConfig.GetCertKeyPath()
-	if keyPath == "" {
-		keyPath = factory.UdrDefaultPrivateKeyPath
+	reloader, err := newCertReloader(pemPath, keyPath)
+	if err != nil {
+		return err
 	}
 
-	return s.httpServer.ListenAndServeTLS(pemPath, keyPath)
+	if l.TLSConfig == nil {
+		l.TLSConfig = &tls.Config{}
+	}
+	l.TLSConfig.GetCertificate = reloader.getCertificate
+	l.certReloader = reloader
+	reloader.start(certReloadInterval)
+	return nil
 }
 
-func (s *Server) serve() error {
-	sbiConfig := s.UDR.Config().Configuration.Sbi
+func (l *sbiListener) secureServe(ln net.Listener) error {
+	// The certificate is provided by the reloader through TLSConfig.GetCertificate
+	return l.ServeTLS(ln, "", "")
+}
 
-	switch sbiConfig.Scheme {
+func (l *sbiListener) serve(ln net.Listener) error {
+	switch l.binding.Scheme {
 	case "http":
-		return s.unsecureServe()
+		return l.Serve(ln)
 	case "https":
-		return s.secureServe()
+		return l.secureServe(ln)
 	default:
-		return fmt.Errorf("invalid SBI scheme: %s", sbiConfig.Scheme)
+		return fmt.Errorf("invalid SBI scheme: %s", l.binding.Scheme)
 	}
 }
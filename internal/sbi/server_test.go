@@ -0,0 +1,765 @@
+package sbi
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+
+	"github.com/free5gc/openapi/models"
+	udr_context "github.com/free5gc/udr/internal/context"
+	"github.com/free5gc/udr/internal/database"
+	"github.com/free5gc/udr/internal/sbi/processor"
+	"github.com/free5gc/udr/pkg/factory"
+)
+
+func newTestServer(t *testing.T, opts ...ServerOption) *Server {
+	return newTestServerWithDb(t, nil, opts...)
+}
+
+// newTestServerWithDb is newTestServer with a processor on db, on the MongoDB connector when db is nil
+func newTestServerWithDb(t *testing.T, db database.DbConnector, opts ...ServerOption) *Server {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	udr := NewMockUDR(ctrl)
+	factory.UdrConfig = &factory.Config{
+		Configuration: &factory.Configuration{
+			DbConnectorType: "mongodb",
+			Mongodb:         &factory.Mongodb{},
+			Sbi: &factory.Sbi{
+				BindingIPv4: "127.0.0.1",
+				Port:        8000,
+			},
+		},
+	}
+	udr.EXPECT().Config().Return(factory.UdrConfig).AnyTimes()
+	p := processor.NewProcessor(udr)
+	if db != nil {
+		p.DbConnector = db
+	}
+	udr.EXPECT().Processor().Return(p).AnyTimes()
+	udr.EXPECT().Context().Return(udr_context.GetSelf()).AnyTimes()
+
+	s, err := NewServer(udr, "", opts...)
+	require.NoError(t, err)
+	return setServerReady(s)
+}
+
+// setServerReady lets the requests through the availability gate, as the startup sequence does
+func setServerReady(s *Server) *Server {
+	s.pingDataStore = func(ctx context.Context) error {
+		return nil
+	}
+	s.SetNrfRegistered(true)
+	s.SetReady(true)
+	return s
+}
+
+func TestServer_BindFailures(t *testing.T) {
+	s := newTestServer(t)
+
+	t.Run("Invalid Listener Configuration", func(t *testing.T) {
+		factory.UdrConfig.Configuration.Sbi = &factory.Sbi{
+			Scheme:      "https",
+			BindingIPv4: "127.0.0.1",
+			Port:        8000,
+			Tls: &factory.Tls{
+				Pem:       "cert/udr.pem",
+				Key:       "cert/udr.key",
+				ClientCAs: filepath.Join(t.TempDir(), "missing.pem"),
+			},
+		}
+		_, err := NewServer(s.UDR, "")
+		require.ErrorContains(t, err, "read client CAs")
+	})
+
+	t.Run("Address In Use", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer ln.Close()
+
+		factory.UdrConfig.Configuration.Sbi = &factory.Sbi{
+			Scheme:      "http",
+			BindingIPv4: "127.0.0.1",
+			Port:        ln.Addr().(*net.TCPAddr).Port,
+		}
+		server, err := NewServer(s.UDR, "")
+		require.NoError(t, err)
+		require.ErrorContains(t, server.Run(&sync.WaitGroup{}), "address already in use")
+	})
+
+	t.Run("Debug Address In Use", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer ln.Close()
+
+		factory.UdrConfig.Configuration.Sbi = &factory.Sbi{Scheme: "http", BindingIPv4: "127.0.0.1", Port: freePort(t)}
+		factory.UdrConfig.Configuration.Debug = &factory.Debug{Pprof: true, BindAddr: ln.Addr().String()}
+		defer func() { factory.UdrConfig.Configuration.Debug = nil }()
+		server, err := NewServer(s.UDR, "")
+		require.NoError(t, err)
+		require.ErrorContains(t, server.Run(&sync.WaitGroup{}), "debug server failed to listen")
+
+		// The SBI listener opened before is closed again
+		sbiListener, err := net.Listen("tcp", server.httpServers[0].Addr)
+		require.NoError(t, err)
+		require.NoError(t, sbiListener.Close())
+	})
+
+	t.Run("Admin Address In Use", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer ln.Close()
+
+		factory.UdrConfig.Configuration.Sbi = &factory.Sbi{Scheme: "http", BindingIPv4: "127.0.0.1", Port: freePort(t)}
+		factory.UdrConfig.Configuration.Admin = &factory.Admin{BindAddr: ln.Addr().String()}
+		defer func() { factory.UdrConfig.Configuration.Admin = nil }()
+		server, err := NewServer(s.UDR, "")
+		require.NoError(t, err)
+		require.ErrorContains(t, server.Run(&sync.WaitGroup{}), "admin server failed to listen")
+
+		// Nothing is served, the SBI listener opened before is closed again
+		sbiListener, err := net.Listen("tcp", server.httpServers[0].Addr)
+		require.NoError(t, err)
+		require.NoError(t, sbiListener.Close())
+	})
+}
+
+// freePort returns a TCP port nothing listens on
+func freePort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestServer_ShutdownDrainsInflightRequests(t *testing.T) {
+	s := newTestServer(t)
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	s.router.GET("/slow", func(c *gin.Context) {
+		close(entered)
+		<-release
+		c.Status(http.StatusOK)
+	})
+
+	slowRsp := httptest.NewRecorder()
+	slowDone := make(chan struct{})
+	go func() {
+		defer close(slowDone)
+		req := httptest.NewRequest(http.MethodGet, "/slow", nil)
+		s.router.ServeHTTP(slowRsp, req)
+	}()
+	<-entered
+	require.Equal(t, int64(1), s.ActiveRequests())
+
+	shutdownDone := make(chan struct{})
+	go func() {
+		s.Shutdown(context.Background())
+		close(shutdownDone)
+	}()
+
+	require.Eventually(t, s.draining.Load, time.Second, 10*time.Millisecond)
+
+	// New requests are refused while draining
+	rsp := httptest.NewRecorder()
+	s.router.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rsp.Code)
+
+	select {
+	case <-shutdownDone:
+		t.Fatal("Shutdown returned before the in-flight request finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case <-shutdownDone:
+	case <-time.After(time.Second):
+		t.Fatal("Shutdown did not return after the in-flight request finished")
+	}
+	require.Equal(t, int64(0), s.ActiveRequests())
+
+	<-slowDone
+	require.Equal(t, http.StatusOK, slowRsp.Code)
+}
+
+func TestServer_HandlerMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	s := newTestServer(t, WithMetricsRegistry(registry))
+
+	rsp := httptest.NewRecorder()
+	s.router.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, factory.UdrDrResUriPrefix+"/", nil))
+	require.Equal(t, http.StatusNotImplemented, rsp.Code)
+
+	req := httptest.NewRequest(http.MethodPatch,
+		factory.UdrDrResUriPrefix+"/subscription-data/imsi-208930000000001/context-data/amf-3gpp-access",
+		strings.NewReader("{"))
+	req.Header.Set("Content-Type", "application/json-patch+json")
+	rsp = httptest.NewRecorder()
+	s.router.ServeHTTP(rsp, req)
+	require.Equal(t, http.StatusBadRequest, rsp.Code)
+
+	require.Equal(t, float64(1), testutil.ToFloat64(s.metrics.requests.WithLabelValues("Index")))
+	require.Equal(t, float64(1), testutil.ToFloat64(s.metrics.errors.WithLabelValues("Index", "501")))
+	require.Equal(t, float64(1), testutil.ToFloat64(s.metrics.requests.WithLabelValues("AmfContext3gpp")))
+	require.Equal(t, float64(1), testutil.ToFloat64(s.metrics.errors.WithLabelValues("AmfContext3gpp", "400")))
+	require.Equal(t, 2, testutil.CollectAndCount(s.metrics.latency))
+
+	rsp = httptest.NewRecorder()
+	s.router.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, UdrSbiMetricsPath, nil))
+	require.Equal(t, http.StatusOK, rsp.Code)
+	require.Contains(t, rsp.Body.String(), "free5gc_udr_dr_requests_total")
+}
+
+func TestServer_WithProcessor(t *testing.T) {
+	// The processor of the UDR is on MongoDB, which the tests run without
+	udrServer := newTestServer(t)
+	db := newTestDb()
+	_, err := db.ReplaceDataToDB(context.Background(), "subscriptionData.provisionedData.smsData",
+		map[string]interface{}{"ueId": "imsi-208930000000001", "servingPlmnId": "20893"},
+		map[string]interface{}{"ueId": "imsi-208930000000001", "servingPlmnId": "20893", "smsSubscribed": true})
+	require.NoError(t, err)
+	p := processor.NewProcessor(udrServer.UDR)
+	p.DbConnector = db
+
+	s, err := NewServer(udrServer.UDR, "", WithProcessor(p))
+	require.NoError(t, err)
+	s = setServerReady(s)
+	require.Same(t, p, s.Processor())
+
+	rsp := httptest.NewRecorder()
+	s.router.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet,
+		factory.UdrDrResUriPrefix+"/subscription-data/imsi-208930000000001/20893/provisioned-data/sms-data", nil))
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+	require.Contains(t, rsp.Body.String(), `"smsSubscribed":true`)
+}
+
+// fakeProcedures serves the SMS subscription data query, the other procedures are not expected
+type fakeProcedures struct {
+	Procedures
+	queried []string
+}
+
+func (f *fakeProcedures) QuerySmsDataProcedure(c *gin.Context, collName string, ueId string, servingPlmnId string,
+	supportedFeatures string,
+) {
+	f.queried = append(f.queried, ueId+"/"+servingPlmnId)
+	c.JSON(http.StatusOK, models.SmsSubscriptionData{SmsSubscribed: true})
+}
+
+func TestServer_WithFakeProcedures(t *testing.T) {
+	udrServer := newTestServer(t)
+	fake := &fakeProcedures{}
+	s, err := NewServer(udrServer.UDR, "", WithProcessor(fake))
+	require.NoError(t, err)
+	s = setServerReady(s)
+
+	rsp := httptest.NewRecorder()
+	s.router.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet,
+		factory.UdrDrResUriPrefix+"/subscription-data/imsi-208930000000001/20893/provisioned-data/sms-data", nil))
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+	require.JSONEq(t, `{"smsSubscribed":true}`, rsp.Body.String())
+	require.Equal(t, []string{"imsi-208930000000001/20893"}, fake.queried)
+}
+
+func TestServer_Probes(t *testing.T) {
+	s := newTestServer(t)
+	pingErr := errors.New("server selection timeout")
+	s.pingDataStore = func(ctx context.Context) error {
+		return pingErr
+	}
+
+	probe := func(path string) (int, string) {
+		rsp := httptest.NewRecorder()
+		s.router.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, path, nil))
+		return rsp.Code, rsp.Body.String()
+	}
+
+	s.SetReady(false)
+	s.SetNrfRegistered(false)
+	code, _ := probe(UdrHealthPath)
+	require.Equal(t, http.StatusOK, code)
+	code, _ = probe(UdrLivenessPath)
+	require.Equal(t, http.StatusOK, code)
+	code, body := probe(UdrReadinessPath)
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Contains(t, body, `"server":"starting"`)
+
+	s.SetReady(true)
+	code, body = probe(UdrReadinessPath)
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Contains(t, body, `"mongodb":"server selection timeout"`)
+	require.Contains(t, body, `"nrf":"not registered"`)
+
+	pingErr = nil
+	s.SetNrfRegistered(true)
+	code, body = probe(UdrReadinessPath)
+	require.Equal(t, http.StatusOK, code)
+	require.JSONEq(t, `{"status":"ready","checks":{"server":"ok","mongodb":"ok","nrf":"ok"}}`, body)
+
+	s.SetNrfRegistered(false)
+	factory.UdrConfig.Configuration.Probes = &factory.Probes{SkipNrfRegistrationCheck: true}
+	code, _ = probe(UdrReadinessPath)
+	require.Equal(t, http.StatusOK, code)
+
+	s.Shutdown(context.Background())
+	code, _ = probe(UdrLivenessPath)
+	require.Equal(t, http.StatusOK, code)
+	code, body = probe(UdrReadinessPath)
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Contains(t, body, `"server":"draining"`)
+}
+
+func TestServer_HealthDetail(t *testing.T) {
+	s := newTestServer(t)
+	factory.UdrConfig.Configuration.Probes = &factory.Probes{HealthDetailTimeout: 20}
+
+	detail := func() (int, map[string]interface{}) {
+		rsp := httptest.NewRecorder()
+		s.router.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, UdrHealthDetailPath, nil))
+		var report map[string]interface{}
+		require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &report))
+		return rsp.Code, report
+	}
+
+	code, report := detail()
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "ok", report["status"])
+	require.Equal(t, "ready", report["state"])
+	require.Equal(t, "ok", report["mongodb"].(map[string]interface{})["status"])
+	require.Contains(t, report["mongodb"], "latencyMs")
+	require.Equal(t, map[string]interface{}{"registered": true}, report["nrf"])
+
+	heartbeat := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	s.SetNrfHeartbeat(heartbeat)
+	_, report = detail()
+	require.Equal(t, heartbeat.Format(time.RFC3339Nano), report["nrf"].(map[string]interface{})["lastHeartbeat"])
+
+	// A hanging MongoDB is reported once the timeout expires
+	s.pingDataStore = func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	start := time.Now()
+	code, report = detail()
+	require.Less(t, time.Since(start), time.Second)
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, "not ready", report["status"])
+	mongodb := report["mongodb"].(map[string]interface{})
+	require.Equal(t, "unavailable", mongodb["status"])
+	require.Equal(t, context.DeadlineExceeded.Error(), mongodb["error"])
+	require.GreaterOrEqual(t, mongodb["latencyMs"], float64(20))
+}
+
+func TestServer_SlowClientConnectionsReaped(t *testing.T) {
+	server, err := newHttp2Server("127.0.0.1:0", "", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), serverTimeouts{
+		readHeader: 100 * time.Millisecond,
+		read:       time.Second,
+		write:      time.Second,
+		idle:       200 * time.Millisecond,
+	}, nil)
+	require.NoError(t, err)
+
+	ln, err := net.Listen("tcp", server.Addr)
+	require.NoError(t, err)
+	go func() {
+		_ = server.Serve(ln)
+	}()
+	defer server.Close()
+
+	requireClosedByServer := func(conn net.Conn) {
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+		_, readErr := io.Copy(io.Discard, conn)
+		var netErr net.Error
+		if errors.As(readErr, &netErr) && netErr.Timeout() {
+			t.Fatal("connection was not closed by the server")
+		}
+	}
+
+	t.Run("Slow Headers", func(t *testing.T) {
+		conn, dialErr := net.Dial("tcp", ln.Addr().String())
+		require.NoError(t, dialErr)
+		defer conn.Close()
+
+		_, err = conn.Write([]byte("GET / HTTP/1.1\r\nHost: udr\r\n"))
+		require.NoError(t, err)
+		requireClosedByServer(conn)
+	})
+
+	t.Run("Idle HTTP/1.1", func(t *testing.T) {
+		conn, dialErr := net.Dial("tcp", ln.Addr().String())
+		require.NoError(t, dialErr)
+		defer conn.Close()
+
+		_, err = conn.Write([]byte("GET / HTTP/1.1\r\nHost: udr\r\n\r\n"))
+		require.NoError(t, err)
+		rsp, readErr := http.ReadResponse(bufio.NewReader(conn), nil)
+		require.NoError(t, readErr)
+		require.NoError(t, rsp.Body.Close())
+		require.Equal(t, http.StatusOK, rsp.StatusCode)
+		requireClosedByServer(conn)
+	})
+
+	t.Run("Idle HTTP/2 Cleartext", func(t *testing.T) {
+		conn, dialErr := net.Dial("tcp", ln.Addr().String())
+		require.NoError(t, dialErr)
+		defer conn.Close()
+
+		_, err = conn.Write([]byte(http2.ClientPreface))
+		require.NoError(t, err)
+		require.NoError(t, http2.NewFramer(conn, conn).WriteSettings())
+		requireClosedByServer(conn)
+	})
+}
+
+func TestServer_TLSVersions(t *testing.T) {
+	dir := t.TempDir()
+	pemPath := filepath.Join(dir, "udr.pem")
+	keyPath := filepath.Join(dir, "udr.key")
+	writeTestCert(t, pemPath, keyPath, "udr", time.Now())
+
+	binding := factory.SbiBinding{
+		BindingIP: "127.0.0.1",
+		Scheme:    "https",
+		Tls: &factory.Tls{
+			Pem:        pemPath,
+			Key:        keyPath,
+			MinVersion: factory.UdrSbiTlsVersion13,
+		},
+	}
+	server, err := bindRouter(binding, gin.New(), "", serverTimeouts{})
+	require.NoError(t, err)
+	l := &sbiListener{Server: server, binding: binding}
+	l.Addr = "127.0.0.1:0"
+	ln, err := l.listen()
+	require.NoError(t, err)
+	go func() {
+		_ = l.serve(ln)
+	}()
+	defer l.Close()
+
+	dial := func(maxVersion uint16) (*tls.Conn, error) {
+		return tls.Dial("tcp", ln.Addr().String(), &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			MaxVersion:         maxVersion,
+			NextProtos:         []string{http2.NextProtoTLS},
+			InsecureSkipVerify: true, // #nosec G402 -- self-signed test certificates
+		})
+	}
+
+	_, err = dial(tls.VersionTLS12)
+	require.Error(t, err)
+
+	conn, err := dial(tls.VersionTLS13)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.Equal(t, uint16(tls.VersionTLS13), conn.ConnectionState().Version)
+	require.Equal(t, http2.NextProtoTLS, conn.ConnectionState().NegotiatedProtocol)
+}
+
+func TestServer_WriteTimeoutCancelsRequestContext(t *testing.T) {
+	s := newTestServer(t)
+	factory.UdrConfig.Configuration.Sbi.Timeouts = &factory.SbiTimeouts{Write: 1}
+
+	s.router.GET("/blocking", func(c *gin.Context) {
+		select {
+		case <-c.Done():
+			c.Status(http.StatusGatewayTimeout)
+		case <-time.After(5 * time.Second):
+			c.Status(http.StatusOK)
+		}
+	})
+
+	rsp := httptest.NewRecorder()
+	s.router.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, "/blocking", nil))
+	require.Equal(t, http.StatusGatewayTimeout, rsp.Code)
+}
+
+func TestServer_RequestTimeout(t *testing.T) {
+	s := newTestServer(t)
+	factory.UdrConfig.Configuration.Sbi.RequestTimeout = 1
+	factory.UdrConfig.Configuration.Sbi.RequestTimeouts = []*factory.RequestTimeout{
+		{PathPrefix: "/query/bulk", Timeout: 3},
+	}
+
+	// Answers like the processor does when its MongoDB query is canceled
+	query := func(c *gin.Context) {
+		select {
+		case <-c.Done():
+			c.JSON(http.StatusInternalServerError, &models.ProblemDetails{Status: http.StatusInternalServerError})
+		case <-time.After(1500 * time.Millisecond):
+			c.JSON(http.StatusOK, gin.H{"ueId": "imsi-208930000000001"})
+		}
+	}
+	s.router.GET("/query", query)
+	s.router.GET("/query/bulk", query)
+
+	rsp := httptest.NewRecorder()
+	s.router.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, "/query", nil))
+	require.Equal(t, http.StatusGatewayTimeout, rsp.Code)
+	var pd models.ProblemDetails
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &pd))
+	require.Equal(t, "TIMED_OUT_REQUEST", pd.Cause)
+
+	rsp = httptest.NewRecorder()
+	s.router.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, "/query/bulk", nil))
+	require.Equal(t, http.StatusOK, rsp.Code)
+	require.Contains(t, rsp.Body.String(), "imsi-208930000000001")
+}
+
+func TestServer_RequestBodyLimit(t *testing.T) {
+	s := newTestServer(t)
+	factory.UdrConfig.Configuration.Sbi.MaxRequestBodyBytes = 8
+	factory.UdrConfig.Configuration.Sbi.RequestBodyLimits = []*factory.RequestBodyLimit{
+		{PathPrefix: "/echo/large", MaxBytes: 16},
+	}
+
+	echo := func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		require.NoError(t, err)
+		c.String(http.StatusOK, string(body))
+	}
+	s.router.POST("/echo", echo)
+	s.router.POST("/echo/large", echo)
+
+	testCases := []struct {
+		name         string
+		path         string
+		body         string
+		chunked      bool
+		expectedCode int
+	}{
+		{"At Limit", "/echo", strings.Repeat("a", 8), false, http.StatusOK},
+		{"Over Limit", "/echo", strings.Repeat("a", 9), false, http.StatusRequestEntityTooLarge},
+		{"Over Limit Chunked", "/echo", strings.Repeat("a", 9), true, http.StatusRequestEntityTooLarge},
+		{"Path Override", "/echo/large", strings.Repeat("a", 16), true, http.StatusOK},
+		{"Over Path Override", "/echo/large", strings.Repeat("a", 17), false, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body))
+			if tc.chunked {
+				req.ContentLength = -1
+			}
+			rsp := httptest.NewRecorder()
+			s.router.ServeHTTP(rsp, req)
+			require.Equal(t, tc.expectedCode, rsp.Code)
+			if tc.expectedCode == http.StatusOK {
+				require.Equal(t, tc.body, rsp.Body.String())
+			} else {
+				require.Contains(t, rsp.Body.String(), "PAYLOAD_TOO_LARGE")
+			}
+		})
+	}
+}
+
+func TestServer_GetGroupIdentifiersInvalidQuery(t *testing.T) {
+	s := newTestServer(t)
+
+	for _, query := range []string{
+		"",
+		"?ext-group-id=extgroupid-udr@free5gc.org&internal-group-id=001-01-00000001",
+		"?ext-group-id=udr",
+	} {
+		rsp := httptest.NewRecorder()
+		s.router.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet,
+			factory.UdrDrResUriPrefix+"/subscription-data/group-data/group-identifiers"+query, nil))
+		require.Equal(t, http.StatusBadRequest, rsp.Code, query)
+		require.Contains(t, rsp.Body.String(), "INVALID_QUERY_PARAM")
+	}
+}
+
+func TestServer_GetSupiListInvalidQuery(t *testing.T) {
+	s := newTestServer(t)
+	factory.UdrConfig.Configuration.Pagination = &factory.Pagination{MaxPageSize: 50}
+
+	for _, query := range []string{
+		"?page-size=0",
+		"?page-size=51",
+		"?page-size=ten",
+		"?page-number=0",
+		"?page-size=10&page-number=-1",
+	} {
+		rsp := httptest.NewRecorder()
+		s.router.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet,
+			factory.UdrDrResUriPrefix+"/subscription-data/supis"+query, nil))
+		require.Equal(t, http.StatusBadRequest, rsp.Code, query)
+		require.Contains(t, rsp.Body.String(), "INVALID_QUERY_PARAM")
+	}
+}
+
+// newStrictScopesTestServer is newTestServer authorizing the requests by the scopes of their unverified tokens
+func newStrictScopesTestServer(t *testing.T) *Server {
+	s := newTestServer(t)
+	udrContext := udr_context.GetSelf()
+	udrContext.SetOAuth2Required(true)
+	udrContext.OAuth2SkipVerification = true
+	t.Cleanup(func() {
+		udrContext.SetOAuth2Required(false)
+		udrContext.OAuth2SkipVerification = false
+	})
+	factory.UdrConfig.Configuration.Sbi.OAuth = &factory.OAuth{StrictScopes: true}
+	s.router = newRouter(s)
+	return s
+}
+
+// mintToken returns the Authorization header of an unsigned token granting scope
+func mintToken(t *testing.T, scope string) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodNone, &models.NrfAccessTokenAccessTokenClaims{
+		Sub:   "0c6c1d64-7a36-4d37-8b3e-4cf3c9c17d3b",
+		Scope: scope,
+	}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+	return "Bearer " + token
+}
+
+func TestServer_DataSetScopes(t *testing.T) {
+	s := newStrictScopesTestServer(t)
+
+	testCases := []struct {
+		name         string
+		scope        string
+		method       string
+		path         string
+		expectedCode int
+	}{
+		// The handlers answer without reaching MongoDB once the authorization check passed
+		{"Subscription Data", "nudr-dr nudr-dr:subscription-data", http.MethodGet,
+			"/subscription-data/supis?page-size=ten", http.StatusBadRequest},
+		{"Subscription Data Without Scope", "nudr-dr nudr-dr:policy-data", http.MethodGet,
+			"/subscription-data/supis?page-size=ten", http.StatusForbidden},
+		{"Policy Data", "nudr-dr nudr-dr:policy-data", http.MethodPatch,
+			"/policy-data/ues/imsi-208930000000001/am-data", http.StatusUnsupportedMediaType},
+		{"Policy Data Without Scope", "nudr-dr nudr-dr:subscription-data", http.MethodPatch,
+			"/policy-data/ues/imsi-208930000000001/am-data", http.StatusForbidden},
+		{"UE Policy Set Without Scope", "nudr-dr nudr-dr:subscription-data", http.MethodGet,
+			"/policy-data/ues/imsi-208930000000001/ue-policy-set", http.StatusForbidden},
+		{"SM Policy Data Without Scope", "nudr-dr nudr-dr:subscription-data", http.MethodGet,
+			"/policy-data/ues/imsi-208930000000001/sm-data", http.StatusForbidden},
+		{"Exposure Data Without Scope", "nudr-dr", http.MethodPut,
+			"/exposure-data/subs-to-notify/1", http.StatusForbidden},
+		{"Application Data Without Scope", "nudr-dr nudr-dr:exposure-data", http.MethodPut,
+			"/application-data/pfds/app1", http.StatusForbidden},
+		{"Outside Data Sets", "nudr-dr", http.MethodGet, "/", http.StatusNotImplemented},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, factory.UdrDrResUriPrefix+tc.path, strings.NewReader("{}"))
+			req.Header.Set("Authorization", mintToken(t, tc.scope))
+			req.Header.Set("Content-Type", "text/plain")
+			rsp := httptest.NewRecorder()
+			s.router.ServeHTTP(rsp, req)
+			require.Equal(t, tc.expectedCode, rsp.Code, rsp.Body.String())
+			if tc.expectedCode == http.StatusForbidden {
+				require.Contains(t, rsp.Body.String(), "OAUTH2_REQUIRED_SCOPES_NOT_GRANTED")
+			}
+		})
+	}
+}
+
+func TestServer_AdminScope(t *testing.T) {
+	s := newStrictScopesTestServer(t)
+
+	testCases := []struct {
+		name         string
+		scope        string
+		expectedCode int
+	}{
+		// The body is not a group, which is answered once the authorization check passed
+		{"Admin", "nudr-dr nudr-dr:subscription-data nudr-dr:admin", http.StatusBadRequest},
+		{"Without Admin Scope", "nudr-dr nudr-dr:subscription-data", http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, factory.UdrDrResUriPrefix+UdrGroupIdentifiersPath,
+				strings.NewReader("{}"))
+			req.Header.Set("Authorization", mintToken(t, tc.scope))
+			req.Header.Set("Content-Type", "application/json")
+			rsp := httptest.NewRecorder()
+			s.router.ServeHTTP(rsp, req)
+			require.Equal(t, tc.expectedCode, rsp.Code, rsp.Body.String())
+		})
+	}
+}
+
+func TestServer_BulkProvisioningBatchBounds(t *testing.T) {
+	s := newTestServer(t)
+	factory.UdrConfig.Configuration.Provisioning = &factory.Provisioning{MaxBatchSize: 2}
+
+	testCases := []struct {
+		name          string
+		body          string
+		expectedCode  int
+		expectedCause string
+	}{
+		{"Malformed", `{"supi":"imsi-208930000000001"}`, http.StatusBadRequest, ""},
+		{"Empty", `[]`, http.StatusBadRequest, "INVALID_PARAMETER"},
+		{"Over Batch Limit", `[{"supi":"imsi-1"},{"supi":"imsi-2"},{"supi":"imsi-3"}]`,
+			http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost,
+				factory.UdrDrResUriPrefix+UdrBulkProvisioningPath, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			rsp := httptest.NewRecorder()
+			s.router.ServeHTTP(rsp, req)
+			require.Equal(t, tc.expectedCode, rsp.Code)
+			require.Contains(t, rsp.Body.String(), tc.expectedCause)
+		})
+	}
+}
+
+func TestServer_ModifyAmDataInvalidRequest(t *testing.T) {
+	s := newTestServer(t)
+
+	testCases := []struct {
+		name         string
+		contentType  string
+		body         string
+		expectedCode int
+	}{
+		{"JSON Patch", "application/json-patch+json", `[]`, http.StatusUnsupportedMediaType},
+		{"Not An Object", "application/merge-patch+json", `["gpsis"]`, http.StatusBadRequest},
+		{"Null", "application/merge-patch+json", `null`, http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, factory.UdrDrResUriPrefix+
+				"/subscription-data/imsi-208930000000001/20893/provisioned-data/am-data", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", tc.contentType)
+			rsp := httptest.NewRecorder()
+			s.router.ServeHTTP(rsp, req)
+			require.Equal(t, tc.expectedCode, rsp.Code)
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package sbi
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepExpiredSubscriptions removes the expired subscriptions on the interval of the configuration until ctx
+// is done
+func (s *Server) sweepExpiredSubscriptions(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(s.Config().GetSubscriptionSweepInterval())
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.Processor().SweepExpiredSubscriptions(now)
+			}
+		}
+	}()
+}
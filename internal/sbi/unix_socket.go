@@ -0,0 +1,88 @@
+package sbi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/pkg/factory"
+)
+
+type unixSocketConnKey struct{}
+
+func bindUnixSocket(unixSocket *factory.UnixSocket, router *gin.Engine, timeouts serverTimeouts) (
+	*sbiListener, error,
+) {
+	server, err := newHttp2Server(unixSocket.Path, "", router, timeouts, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Mark the connections so the router can tell the requests received on the socket
+	server.ConnContext = func(ctx context.Context, c net.Conn) context.Context {
+		return context.WithValue(ctx, unixSocketConnKey{}, true)
+	}
+
+	return &sbiListener{
+		Server: server,
+		binding: factory.SbiBinding{
+			Scheme: "http",
+		},
+		unixSocket: unixSocket,
+	}, nil
+}
+
+func listenUnixSocket(unixSocket *factory.UnixSocket) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(unixSocket.Path), 0o750); err != nil {
+		return nil, fmt.Errorf("create unix socket directory fail: %w", err)
+	}
+
+	// A socket file left by a previous run makes the bind fail, anything else is not ours to remove
+	if info, err := os.Lstat(unixSocket.Path); err == nil {
+		if info.Mode()&fs.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a unix socket", unixSocket.Path)
+		}
+		if err = os.Remove(unixSocket.Path); err != nil {
+			return nil, fmt.Errorf("remove stale unix socket fail: %w", err)
+		}
+	}
+
+	ln, err := net.Listen("unix", unixSocket.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = os.Chmod(unixSocket.Path, unixSocket.GetMode()); err != nil {
+		if closeErr := ln.Close(); closeErr != nil {
+			logger.SBILog.Warnf("Close unix socket listener failed: %+v", closeErr)
+		}
+		return nil, fmt.Errorf("set unix socket mode fail: %w", err)
+	}
+
+	return ln, nil
+}
+
+func (s *Server) removeUnixSockets() {
+	for _, server := range s.httpServers {
+		if server.unixSocket == nil {
+			continue
+		}
+		err := os.Remove(server.unixSocket.Path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			logger.SBILog.Warnf("Remove unix socket %s failed: %+v", server.unixSocket.Path, err)
+		}
+	}
+}
+
+func isUnixSocketRequest(req *http.Request) bool {
+	fromUnixSocket, _ := req.Context().Value(unixSocketConnKey{}).(bool)
+	return fromUnixSocket
+}
@@ -0,0 +1,85 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/free5gc/openapi/models"
+)
+
+const (
+	// Keys of the verified client certificate identity set on the gin.Context
+	ClientCertSubjectCtxKey = "udr.clientCertSubject"
+	ClientCertSANsCtxKey    = "udr.clientCertSANs"
+	// Key of the NF instance ID of a verified access token set on the gin.Context
+	RequesterNfInstanceIdCtxKey = "udr.requesterNfInstanceId"
+
+	nfInstanceIdUriPrefix = "urn:uuid:"
+)
+
+// ExposeClientCertificate puts the subject and SANs of the verified client certificate on the context
+func ExposeClientCertificate(c *gin.Context) {
+	tlsState := c.Request.TLS
+	if tlsState == nil || len(tlsState.VerifiedChains) == 0 || len(tlsState.VerifiedChains[0]) == 0 {
+		return
+	}
+
+	leaf := tlsState.VerifiedChains[0][0]
+	sans := make([]string, 0, len(leaf.DNSNames)+len(leaf.IPAddresses)+len(leaf.URIs))
+	sans = append(sans, leaf.DNSNames...)
+	for _, ip := range leaf.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	for _, uri := range leaf.URIs {
+		sans = append(sans, uri.String())
+	}
+
+	c.Set(ClientCertSubjectCtxKey, leaf.Subject.String())
+	c.Set(ClientCertSANsCtxKey, sans)
+}
+
+// checkRequesterCertificate compares the NF instance ID carried by the client certificate, as a urn:uuid URI SAN,
+// with the subject of the access token. Nothing is checked when either of them is missing.
+func checkRequesterCertificate(c *gin.Context, authorization string) error {
+	sans := c.GetStringSlice(ClientCertSANsCtxKey)
+	if len(sans) == 0 {
+		return nil
+	}
+
+	requester := RequesterNfInstanceId(authorization)
+	if requester == "" {
+		return nil
+	}
+
+	var certNfInstanceIds []string
+	for _, san := range sans {
+		if nfInstanceId, ok := strings.CutPrefix(san, nfInstanceIdUriPrefix); ok {
+			if strings.EqualFold(nfInstanceId, requester) {
+				return nil
+			}
+			certNfInstanceIds = append(certNfInstanceIds, nfInstanceId)
+		}
+	}
+	if len(certNfInstanceIds) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("requester NF instance %s does not match client certificate %v", requester, certNfInstanceIds)
+}
+
+// RequesterNfInstanceId returns the subject of the bearer access token, empty when there is none.
+// The signature is not verified here, only use it on requests that passed the authorization check.
+func RequesterNfInstanceId(authorization string) string {
+	authFields := strings.Fields(authorization)
+	if len(authFields) < 2 {
+		return ""
+	}
+	claims := &models.NrfAccessTokenAccessTokenClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(authFields[1], claims); err != nil {
+		return ""
+	}
+	return claims.Sub
+}
@@ -0,0 +1,99 @@
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DocumentVersionKey is the field of the stored documents holding their version, incremented on every
+// write so that a conditional write applies to the document it checked. The documents written before it existed
+// are at version 0.
+const DocumentVersionKey = "_version"
+
+// DocumentETag is the strong entity tag of a stored document, the ContentETag of its JSON without its _id and its
+// version. It changes with any write of the document, the ones of the writers that do not increment the version
+// as well, and a document deleted and created again has the tag of its new content.
+func DocumentETag(document map[string]interface{}) string {
+	content := make(map[string]interface{}, len(document))
+	for field, value := range document {
+		if field != "_id" && field != DocumentVersionKey {
+			content[field] = value
+		}
+	}
+	canonicalJSON, err := json.Marshal(content)
+	if err != nil {
+		canonicalJSON = []byte(fmt.Sprint(content))
+	}
+	return ContentETag(canonicalJSON)
+}
+
+// ContentETag is the strong entity tag of a content, the hash of its canonical JSON, where the members of the
+// objects are sorted
+func ContentETag(canonicalJSON []byte) string {
+	sum := sha256.Sum256(canonicalJSON)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// NoneMatchAny is the precondition of a write with If-None-Match: *, in place of an If-Match, which only accepts
+// that there is no document
+const NoneMatchAny = "If-None-Match: *"
+
+// IfMatch reports whether the If-Match header value accepts the document of etag, exists tells whether there is
+// one. An empty header accepts anything, "*" any existing document, NoneMatchAny only a missing one, otherwise
+// one of the listed tags must be etag; weak tags never match as If-Match uses the strong comparison.
+func IfMatch(ifMatch string, etag string, exists bool) bool {
+	ifMatch = strings.TrimSpace(ifMatch)
+	if ifMatch == "" {
+		return true
+	}
+	if ifMatch == NoneMatchAny {
+		return !exists
+	}
+	if !exists {
+		return false
+	}
+	if ifMatch == "*" {
+		return true
+	}
+
+	for _, tag := range strings.Split(ifMatch, ",") {
+		if strings.TrimSpace(tag) == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// IfNoneMatch reports whether the If-None-Match header value matches the existing representation of etag.
+// "*" matches any, otherwise one of the listed tags must be etag; weak tags match too as If-None-Match uses the
+// weak comparison.
+func IfNoneMatch(ifNoneMatch string, etag string) bool {
+	ifNoneMatch = strings.TrimSpace(ifNoneMatch)
+	if ifNoneMatch == "*" {
+		return true
+	}
+
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// DocumentVersion returns the version of a stored document, 0 when it has none
+func DocumentVersion(document map[string]interface{}) int64 {
+	switch version := document[DocumentVersionKey].(type) {
+	case int64:
+		return version
+	case int32:
+		return int64(version)
+	case float64:
+		return int64(version)
+	default:
+		return 0
+	}
+}
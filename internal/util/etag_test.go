@@ -0,0 +1,59 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIfMatch(t *testing.T) {
+	etag := DocumentETag(map[string]interface{}{"ueId": "imsi-208930000000001"})
+	testCases := []struct {
+		name     string
+		ifMatch  string
+		exists   bool
+		expected bool
+	}{
+		{"No Header", "", true, true},
+		{"No Header Nor Document", "", false, true},
+		{"Current Tag", etag, true, true},
+		{"Stale Tag", `"2"`, true, false},
+		{"One Of The List", `"1", ` + etag, true, true},
+		{"Weak Tag", `W/` + etag, true, false},
+		{"Any Existing", "*", true, true},
+		{"Any Missing", "*", false, false},
+		{"Tag Of Missing Document", etag, false, false},
+		{"None Match Existing", NoneMatchAny, true, false},
+		{"None Match Missing", NoneMatchAny, false, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, IfMatch(tc.ifMatch, etag, tc.exists))
+		})
+	}
+}
+
+func TestDocumentETag(t *testing.T) {
+	document := map[string]interface{}{"ueId": "imsi-208930000000001", "gpsis": []interface{}{"msisdn-0900000000"}}
+	etag := DocumentETag(document)
+	// The _id and the version are not of the content
+	require.Equal(t, etag, DocumentETag(map[string]interface{}{
+		"_id": "65a0c0ffee", DocumentVersionKey: int64(7), "gpsis": []interface{}{"msisdn-0900000000"},
+		"ueId": "imsi-208930000000001",
+	}))
+	require.NotEqual(t, etag, DocumentETag(map[string]interface{}{"ueId": "imsi-208930000000001"}))
+}
+
+func TestContentETag(t *testing.T) {
+	etag := ContentETag([]byte(`{"a":1}`))
+	require.Regexp(t, `^"[0-9a-f]{32}"$`, etag)
+	require.Equal(t, etag, ContentETag([]byte(`{"a":1}`)))
+	require.NotEqual(t, etag, ContentETag([]byte(`{"a":2}`)))
+
+	require.True(t, IfNoneMatch(etag, etag))
+	require.True(t, IfNoneMatch(`"1", W/`+etag, etag))
+	require.True(t, IfNoneMatch("*", etag))
+	require.False(t, IfNoneMatch(`"1"`, etag))
+	require.False(t, IfNoneMatch("", etag))
+}
@@ -0,0 +1,74 @@
+package util
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync/atomic"
+
+	"golang.org/x/net/http2"
+
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/udr/pkg/factory"
+)
+
+// sbiClient is the HTTP client of the outbound SBI requests, to the NRF and to the notification
+// callbacks. It stays nil, leaving the openapi default clients in use, unless sbi.tls sets
+// minVersion or cipherSuites.
+var sbiClient atomic.Pointer[http.Client]
+
+// InitSbiClient applies the TLS settings of sbi.tls to the outbound SBI requests
+func InitSbiClient(cfg *factory.Config) {
+	minVersion, cipherSuites, ok := cfg.GetSbiClientTls()
+	if !ok {
+		sbiClient.Store(nil)
+		return
+	}
+	sbiClient.Store(newSbiClient(minVersion, cipherSuites))
+}
+
+// SbiClient returns the client to set on the openapi configurations, nil for the openapi defaults
+func SbiClient() *http.Client {
+	return sbiClient.Load()
+}
+
+// newSbiClient mirrors the openapi default clients, HTTP/2 over TLS or cleartext by the scheme of
+// the request, with the given TLS versions and cipher suites
+func newSbiClient(minVersion uint16, cipherSuites []uint16) *http.Client {
+	return &http.Client{
+		Transport: &sbiTransport{
+			tls: &http2.Transport{
+				TLSClientConfig: &tls.Config{
+					MinVersion:         minVersion,
+					CipherSuites:       cipherSuites,
+					InsecureSkipVerify: true, // nolint:gosec // as the openapi default client
+				},
+				ReadIdleTimeout: openapi.ReadIdleTimeoutPeriod,
+				PingTimeout:     openapi.PingTimeoutPeriod,
+			},
+			cleartext: &http2.Transport{
+				AllowHTTP: true,
+				DialTLSContext: func(ctx context.Context, network, addr string, cfg *tls.Config) (net.Conn, error) {
+					dialer := &net.Dialer{}
+					return dialer.DialContext(ctx, network, addr)
+				},
+				ReadIdleTimeout: openapi.ReadIdleTimeoutPeriod,
+				PingTimeout:     openapi.PingTimeoutPeriod,
+			},
+		},
+		Timeout: openapi.TimeoutPeriod,
+	}
+}
+
+type sbiTransport struct {
+	tls       http.RoundTripper
+	cleartext http.RoundTripper
+}
+
+func (t *sbiTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme == "http" {
+		return t.cleartext.RoundTrip(req)
+	}
+	return t.tls.RoundTrip(req)
+}
@@ -0,0 +1,312 @@
+package util
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/util/metrics/sbi"
+)
+
+// AnyUe is the ueId of the operations applying to any UE, e.g. the EE subscriptions to all the UEs
+const AnyUe = "anyUE"
+
+var (
+	// pattern: '^(imsi-[0-9]{5,15}|nai-.+|gci-.+|gli-.+|.+)$' -- 3GPP 29.571 5.3.2, without the catch-all
+	supiRegexp = regexp.MustCompile("^(imsi-[0-9]{5,15}|nai-.+|gci-.+|gli-.+)$")
+	// pattern: '^(msisdn-[0-9]{5,15}|extid-[^@]+@[^@]+|.+)$' -- 3GPP 29.571 5.3.2, without the catch-all
+	gpsiRegexp = regexp.MustCompile("^(msisdn-[0-9]{5,15}|extid-[^@]+@[^@]+)$")
+	// pattern: '^extgroupid-[^@]+@[^@]+$' -- 3GPP 29.571 5.3.2
+	extGroupIdRegexp = regexp.MustCompile("^extgroupid-[^@]+@[^@]+$")
+	// pattern: '^[A-Fa-f0-9]{8}-[0-9]{3}-[0-9]{2,3}-([A-Fa-f0-9][A-Fa-f0-9]){1,10}$' -- the GroupId of 3GPP 29.571 5.3.2
+	intGroupIdRegexp = regexp.MustCompile("^[A-Fa-f0-9]{8}-[0-9]{3}-[0-9]{2,3}-([A-Fa-f0-9][A-Fa-f0-9]){1,10}$")
+	// pattern: '^[0-9]{5,6}$' -- MCC followed by MNC, the VarPlmnId of 3GPP 29.505 6.1.6.3.2
+	servingPlmnIdRegexp = regexp.MustCompile("^[0-9]{5,6}$")
+	// pattern: '^[A-Fa-f0-9]{6}$' -- the Sd of 3GPP 29.571 5.4.2
+	sdRegexp = regexp.MustCompile("^[A-Fa-f0-9]{6}$")
+)
+
+// SupportedFeaturesRegexp is the pattern of a SupportedFeatures of 3GPP 29.571 5.2.2, '^[A-Fa-f0-9]*$', and of the
+// other hexadecimal bit masks
+var SupportedFeaturesRegexp = regexp.MustCompile("^[A-Fa-f0-9]*$")
+
+// IsValidSupi reports whether supi is an IMSI, a NAI, a GCI or a GLI based SUPI
+func IsValidSupi(supi string) bool {
+	return supiRegexp.MatchString(supi)
+}
+
+// IsValidUeId reports whether ueId is a SUPI or a GPSI, an MSISDN or an external identifier
+func IsValidUeId(ueId string) bool {
+	return supiRegexp.MatchString(ueId) || gpsiRegexp.MatchString(ueId)
+}
+
+// IsValidExtGroupId reports whether extGroupId is an external group identifier
+func IsValidExtGroupId(extGroupId string) bool {
+	return extGroupIdRegexp.MatchString(extGroupId)
+}
+
+// IsValidIntGroupId reports whether intGroupId is an internal group identifier
+func IsValidIntGroupId(intGroupId string) bool {
+	return intGroupIdRegexp.MatchString(intGroupId)
+}
+
+func IsValidServingPlmnId(servingPlmnId string) bool {
+	return servingPlmnIdRegexp.MatchString(servingPlmnId)
+}
+
+// CheckUeIdParam answers 400 when the ueId path parameter is not a SUPI or a GPSI, and tells whether the handling
+// goes on
+func CheckUeIdParam(c *gin.Context, ueId string) bool {
+	if IsValidUeId(ueId) {
+		return true
+	}
+	invalidParam(c, "ueId", ueId, "shall be an imsi-, nai-, gci-, gli-, msisdn- or extid- identifier")
+	return false
+}
+
+// CheckSupiParam answers 400 when the ueId path parameter is not a SUPI, and tells whether the handling goes on
+func CheckSupiParam(c *gin.Context, ueId string) bool {
+	if IsValidSupi(ueId) {
+		return true
+	}
+	invalidParam(c, "ueId", ueId, "shall be an imsi-, nai-, gci- or gli- identifier")
+	return false
+}
+
+// CheckEeUeIdParam answers 400 when the ueId path parameter of an EE subscription is not a SUPI or a GPSI, an
+// external group identifier nor anyUE, and tells whether the handling goes on
+func CheckEeUeIdParam(c *gin.Context, ueId string) bool {
+	if ueId == AnyUe || IsValidUeId(ueId) || IsValidExtGroupId(ueId) {
+		return true
+	}
+	invalidParam(c, "ueId", ueId,
+		"shall be an imsi-, nai-, gci-, gli-, msisdn-, extid- or extgroupid- identifier, or anyUE")
+	return false
+}
+
+// CheckServingPlmnIdParam answers 400 when the servingPlmnId path parameter is not a PLMN ID
+func CheckServingPlmnIdParam(c *gin.Context, servingPlmnId string) bool {
+	if IsValidServingPlmnId(servingPlmnId) {
+		return true
+	}
+	invalidParam(c, "servingPlmnId", servingPlmnId, "shall be the MCC followed by the MNC")
+	return false
+}
+
+// CheckPduSessionIdParam answers 400 when the pduSessionId path parameter is not an integer within [0, 255], the
+// PduSessionId of 3GPP 29.571 5.4.2, and returns it otherwise
+func CheckPduSessionIdParam(c *gin.Context, pduSessionId string) (int32, bool) {
+	id, err := strconv.ParseUint(pduSessionId, 10, 8)
+	if err != nil {
+		invalidParam(c, "pduSessionId", pduSessionId, "shall be an integer within [0, 255]")
+		return 0, false
+	}
+	return int32(id), true
+}
+
+// SupportedFeaturesQuery returns the supported-features query parameter, or the first of the aliases the
+// operation accepts it by, empty when absent. It answers 400 when the parameter is not a hexadecimal string,
+// and tells whether the handling goes on.
+func SupportedFeaturesQuery(c *gin.Context, aliases ...string) (string, bool) {
+	param := "supported-features"
+	supportedFeatures, ok := c.GetQuery(param)
+	for _, alias := range aliases {
+		if ok {
+			break
+		}
+		param = alias
+		supportedFeatures, ok = c.GetQuery(param)
+	}
+	if !ok || (supportedFeatures != "" && SupportedFeaturesRegexp.MatchString(supportedFeatures)) {
+		return supportedFeatures, true
+	}
+	invalidParam(c, param, supportedFeatures, "shall be hexadecimal, a bit per feature")
+	return "", false
+}
+
+// SingleNssaiQuery returns the S-NSSAI of the single-nssai query parameter, see SnssaiQuery
+func SingleNssaiQuery(c *gin.Context) (*models.Snssai, bool) {
+	return SnssaiQuery(c, "single-nssai")
+}
+
+// SnssaiQuery returns the S-NSSAI of the query parameter param, the JSON of a Snssai of 3GPP 29.571 5.4.4.2, nil
+// when absent. It answers 400 when the parameter is not an S-NSSAI, with an sst within [0, 255] and an sd of
+// 6 hexadecimal digits when present, and tells whether the handling goes on.
+func SnssaiQuery(c *gin.Context, param string) (*models.Snssai, bool) {
+	query, ok := c.GetQuery(param)
+	if !ok {
+		return nil, true
+	}
+
+	var singleNssai models.Snssai
+	var raw map[string]json.RawMessage
+	reason := "shall be the JSON of an S-NSSAI"
+	if err := json.Unmarshal([]byte(query), &raw); err == nil && raw["sst"] != nil {
+		if err = json.Unmarshal([]byte(query), &singleNssai); err == nil {
+			switch {
+			case singleNssai.Sst < 0 || singleNssai.Sst > 255:
+				reason = "sst shall be within [0, 255]"
+			case singleNssai.Sd != "" && !sdRegexp.MatchString(singleNssai.Sd):
+				reason = "sd shall be 6 hexadecimal digits"
+			default:
+				return &singleNssai, true
+			}
+		}
+	}
+	invalidParam(c, param, query, reason)
+	return nil, false
+}
+
+// HasFeature reports whether the feature numbered n, from 1, is set in the SupportedFeatures of 3GPP 29.571
+// 5.2.2. The last hexadecimal digit holds the features 1 to 4, from its least significant bit.
+func HasFeature(supportedFeatures string, n int) bool {
+	i := len(supportedFeatures) - 1 - (n-1)/4
+	if n < 1 || i < 0 {
+		return false
+	}
+	digit, err := strconv.ParseUint(supportedFeatures[i:i+1], 16, 8)
+	return err == nil && digit&(1<<((n-1)%4)) != 0
+}
+
+// CommonFeatures returns the features set in both the SupportedFeatures a and b, without its leading zeros
+func CommonFeatures(a, b string) string {
+	return combineFeatures(a, b, min(len(a), len(b)), func(digitA, digitB uint64) uint64 {
+		return digitA & digitB
+	})
+}
+
+// UnsupportedFeatures returns the features set in the SupportedFeatures requested but not in supported, without
+// its leading zeros
+func UnsupportedFeatures(requested, supported string) string {
+	return combineFeatures(requested, supported, len(requested), func(digitRequested, digitSupported uint64) uint64 {
+		return digitRequested &^ digitSupported
+	})
+}
+
+// combineFeatures combines the n last digits of the SupportedFeatures a and b, aligned on their last digit, a
+// missing digit being 0
+func combineFeatures(a, b string, n int, combine func(digitA, digitB uint64) uint64) string {
+	digitAt := func(features string, i int) uint64 {
+		if i > len(features) {
+			return 0
+		}
+		// A digit that is not hexadecimal parses as 0, none of its features
+		digit, _ := strconv.ParseUint(features[len(features)-i:len(features)-i+1], 16, 8)
+		return digit
+	}
+	combined := make([]byte, 0, n)
+	for i := n; i > 0; i-- {
+		if digit := combine(digitAt(a, i), digitAt(b, i)); digit != 0 || len(combined) > 0 {
+			combined = strconv.AppendUint(combined, digit, 16)
+		}
+	}
+	return string(combined)
+}
+
+// SharedDataIdsQuery returns the IDs of the shared-data-ids query parameter, comma separated and possibly
+// repeated. It answers 400 when there is no ID or one is empty, and tells whether the handling goes on.
+func SharedDataIdsQuery(c *gin.Context) ([]string, bool) {
+	var sharedDataIds []string
+	for _, ids := range c.QueryArray("shared-data-ids") {
+		sharedDataIds = append(sharedDataIds, strings.Split(ids, ",")...)
+	}
+	if len(sharedDataIds) != 0 && !slices.Contains(sharedDataIds, "") {
+		return sharedDataIds, true
+	}
+	invalidParam(c, "shared-data-ids", strings.Join(sharedDataIds, ","), "shall be a list of shared data IDs")
+	return nil, false
+}
+
+// FieldsQuery returns the attribute paths of the fields query parameter, comma separated and possibly repeated,
+// none when absent. A path names a member of dataSet by its JSON name, the members of an array element or of a
+// map value after a dot, e.g. nssai.defaultSingleNssais. It answers 400 with the unknown paths, and tells
+// whether the handling goes on.
+func FieldsQuery(c *gin.Context, dataSet interface{}) ([]string, bool) {
+	var fields []string
+	var unknown []models.InvalidParam
+	for _, param := range c.QueryArray("fields") {
+		for _, field := range strings.Split(param, ",") {
+			if field == "" {
+				continue
+			}
+			if !isFieldPath(reflect.TypeOf(dataSet), field) {
+				unknown = append(unknown, models.InvalidParam{Param: "fields", Reason: field + " is not an attribute"})
+				continue
+			}
+			fields = append(fields, field)
+		}
+	}
+	if len(unknown) == 0 {
+		return fields, true
+	}
+	pd := &models.ProblemDetails{
+		Title:         "Invalid parameter",
+		Status:        http.StatusBadRequest,
+		Detail:        "Invalid fields",
+		Cause:         "INVALID_QUERY_PARAM",
+		InvalidParams: unknown,
+	}
+	c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+	GinProblemJson(c, pd)
+	return nil, false
+}
+
+// isFieldPath reports whether the dotted path names a member of the model, any key of its maps
+func isFieldPath(model reflect.Type, path string) bool {
+	for _, name := range strings.Split(path, ".") {
+		for model.Kind() == reflect.Pointer || model.Kind() == reflect.Slice || model.Kind() == reflect.Array {
+			model = model.Elem()
+		}
+		switch {
+		case name == "":
+			return false
+		case model.Kind() == reflect.Map:
+			model = model.Elem()
+		case model.Kind() == reflect.Struct:
+			field, ok := jsonField(model, name)
+			if !ok {
+				return false
+			}
+			model = field.Type
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// jsonField returns the field of the struct whose JSON name is name
+func jsonField(model reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < model.NumField(); i++ {
+		field := model.Field(i)
+		if jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ","); jsonName == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func invalidParam(c *gin.Context, param, value, reason string) {
+	if value == "" {
+		reason = "is required"
+	}
+	pd := &models.ProblemDetails{
+		Title:  "Invalid parameter",
+		Status: http.StatusBadRequest,
+		Detail: "Invalid " + param,
+		Cause:  "MANDATORY_IE_INCORRECT",
+		InvalidParams: []models.InvalidParam{{
+			Param:  param,
+			Reason: reason,
+		}},
+	}
+	c.Set(sbi.IN_PB_DETAILS_CTX_STR, pd.Cause)
+	GinProblemJson(c, pd)
+}
@@ -0,0 +1,208 @@
+package util
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	"github.com/free5gc/openapi/models"
+)
+
+func TestIsValidUeId(t *testing.T) {
+	testCases := []struct {
+		name  string
+		ueId  string
+		supi  bool
+		valid bool
+	}{
+		{"IMSI", "imsi-208930000000001", true, true},
+		{"Shortest IMSI", "imsi-20893", true, true},
+		{"IMSI Too Short", "imsi-2089", false, false},
+		{"IMSI Too Long", "imsi-2089300000000012", false, false},
+		{"IMSI With Letters", "imsi-abc", false, false},
+		{"NAI", "nai-user@free5gc.org", true, true},
+		{"Empty NAI", "nai-", false, false},
+		{"GCI", "gci-000001@free5gc.org", true, true},
+		{"GLI", "gli-000001@free5gc.org", true, true},
+		{"MSISDN", "msisdn-0900000000", false, true},
+		{"MSISDN With Letters", "msisdn-09000abc", false, false},
+		{"External ID", "extid-user@free5gc.org", false, true},
+		{"External ID Without Domain", "extid-user", false, false},
+		{"External ID With Two Domains", "extid-user@free5gc@org", false, false},
+		{"No Prefix", "208930000000001", false, false},
+		{"Upper Case Prefix", "IMSI-208930000000001", false, false},
+		{"Empty", "", false, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.supi, IsValidSupi(tc.ueId))
+			require.Equal(t, tc.valid, IsValidUeId(tc.ueId))
+		})
+	}
+}
+
+func TestIsValidServingPlmnId(t *testing.T) {
+	testCases := []struct {
+		name          string
+		servingPlmnId string
+		valid         bool
+	}{
+		{"Two Digit MNC", "20893", true},
+		{"Three Digit MNC", "310410", true},
+		{"Too Short", "2089", false},
+		{"Too Long", "2089300", false},
+		{"Letters", "208ab", false},
+		{"Empty", "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.valid, IsValidServingPlmnId(tc.servingPlmnId))
+		})
+	}
+}
+
+func TestCheckUeIdParam(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	testCases := []struct {
+		name   string
+		ueId   string
+		valid  bool
+		reason string
+	}{
+		{"Valid", "imsi-208930000000001", true, ""},
+		{"Invalid", "imsi-abc", false, "shall be an imsi-, nai-, gci-, gli-, msisdn- or extid- identifier"},
+		{"Empty", "", false, "is required"},
+		{"Wildcard Not Accepted", AnyUe, false, "shall be an imsi-, nai-, gci-, gli-, msisdn- or extid- identifier"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rsp := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rsp)
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+
+			require.Equal(t, tc.valid, CheckUeIdParam(c, tc.ueId))
+			if tc.valid {
+				require.False(t, c.Writer.Written())
+				return
+			}
+			require.Equal(t, http.StatusBadRequest, rsp.Code)
+			var pd models.ProblemDetails
+			require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &pd))
+			require.Equal(t, []models.InvalidParam{{Param: "ueId", Reason: tc.reason}}, pd.InvalidParams)
+		})
+	}
+}
+
+func TestCheckPduSessionIdParam(t *testing.T) {
+	testCases := []struct {
+		name         string
+		pduSessionId string
+		id           int32
+		valid        bool
+	}{
+		{"Lowest", "0", 0, true},
+		{"Highest", "255", 255, true},
+		{"Too High", "256", 0, false},
+		{"Negative", "-1", 0, false},
+		{"Letters", "5a", 0, false},
+		{"Empty", "", 0, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rsp := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rsp)
+			id, ok := CheckPduSessionIdParam(c, tc.pduSessionId)
+			require.Equal(t, tc.valid, ok)
+			require.Equal(t, tc.id, id)
+			if !tc.valid {
+				require.Equal(t, http.StatusBadRequest, rsp.Code)
+			}
+		})
+	}
+}
+
+func TestSingleNssaiQuery(t *testing.T) {
+	testCases := []struct {
+		name        string
+		query       string
+		singleNssai *models.Snssai
+		reason      string
+	}{
+		{"Absent", "", nil, ""},
+		{"Without Sd", "single-nssai=%7B%22sst%22%3A1%7D", &models.Snssai{Sst: 1}, ""},
+		{"With Sd", `single-nssai={"sst":1,"sd":"0A0B0C"}`, &models.Snssai{Sst: 1, Sd: "0A0B0C"}, ""},
+		{"Malformed JSON", `single-nssai={"sst":1`, nil, "shall be the JSON of an S-NSSAI"},
+		{"Without Sst", `single-nssai={"sd":"010203"}`, nil, "shall be the JSON of an S-NSSAI"},
+		{"Sst Out Of Range", `single-nssai={"sst":256}`, nil, "sst shall be within [0, 255]"},
+		{"Invalid Sd", `single-nssai={"sst":1,"sd":"xyz"}`, nil, "sd shall be 6 hexadecimal digits"},
+		{"Empty", "single-nssai=", nil, "is required"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rsp := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rsp)
+			c.Request = httptest.NewRequest(http.MethodGet, "/sm-data", nil)
+			c.Request.URL.RawQuery = tc.query
+			singleNssai, ok := SingleNssaiQuery(c)
+			require.Equal(t, tc.reason == "", ok)
+			require.Equal(t, tc.singleNssai, singleNssai)
+			if tc.reason != "" {
+				require.Equal(t, http.StatusBadRequest, rsp.Code)
+				var pd models.ProblemDetails
+				require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &pd))
+				require.Equal(t, []models.InvalidParam{{Param: "single-nssai", Reason: tc.reason}}, pd.InvalidParams)
+			}
+		})
+	}
+}
+
+func TestCommonFeatures(t *testing.T) {
+	testCases := []struct {
+		name     string
+		a, b     string
+		expected string
+	}{
+		{"Same", "3", "3", "3"},
+		{"Common Bits", "5", "3", "1"},
+		{"Shorter Consumer", "1F0", "13", "10"},
+		{"Leading Zeros", "0A", "0F", "a"},
+		{"None", "1", "2", ""},
+		{"Empty", "", "F", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, CommonFeatures(tc.a, tc.b))
+		})
+	}
+}
+
+func TestUnsupportedFeatures(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		requested, supported string
+		expected             string
+	}{
+		{"All Supported", "1", "3", ""},
+		{"Other Bits", "7", "1", "6"},
+		{"Longer Consumer", "1F0", "13", "1e0"},
+		{"Leading Zeros", "0A", "2", "8"},
+		{"Nothing Supported", "5", "", "5"},
+		{"None Requested", "", "F", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, UnsupportedFeatures(tc.requested, tc.supported))
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package util
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// Key of the request ID set on the gin.Context, the one of the consumer or a generated one
+const RequestIdCtxKey = "udr.requestId"
+
+// RequestLog returns entry with the request ID of c as the requestId field, so that the log lines
+// of a request can be correlated with those of the consumer
+func RequestLog(c *gin.Context, entry *logrus.Entry) *logrus.Entry {
+	if c == nil {
+		return entry
+	}
+	if requestId := c.GetString(RequestIdCtxKey); requestId != "" {
+		return entry.WithField("requestId", requestId)
+	}
+	return entry
+}
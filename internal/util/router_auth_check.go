@@ -1,17 +1,38 @@
 package util
 
 import (
+	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 
 	"github.com/free5gc/openapi/models"
 	udr_context "github.com/free5gc/udr/internal/context"
 	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/util/metrics/sbi"
 )
 
+// Additional scopes of the Nudr_DataRepository service, TS 29.504 clause 8.3
+const (
+	ScopeNudrDrSubscriptionData = "nudr-dr:subscription-data"
+	ScopeNudrDrPolicyData       = "nudr-dr:policy-data"
+	ScopeNudrDrExposureData     = "nudr-dr:exposure-data"
+	ScopeNudrDrApplicationData  = "nudr-dr:application-data"
+)
+
+// ScopeNudrDrAdmin is the scope of the provisioning of the operator, it is not of TS 29.504 and the NRF shall
+// be configured to grant it
+const ScopeNudrDrAdmin = "nudr-dr:admin"
+
 type RouterAuthorizationCheck struct {
 	serviceName models.ServiceName
+	// requiredScope is the additional scope of the resources behind the check, empty when the
+	// service scope is enough
+	requiredScope string
+	// strictScopes refuses the tokens granting the service scope only
+	strictScopes bool
 }
 
 func NewRouterAuthorizationCheck(serviceName models.ServiceName) *RouterAuthorizationCheck {
@@ -20,15 +41,89 @@ func NewRouterAuthorizationCheck(serviceName models.ServiceName) *RouterAuthoriz
 	}
 }
 
+// WithRequiredScope makes the check require the additional scope in the token. Without strict, a token
+// granting the service scope and no additional scope at all is still accepted.
+func (rac *RouterAuthorizationCheck) WithRequiredScope(scope string, strict bool) *RouterAuthorizationCheck {
+	rac.requiredScope = scope
+	rac.strictScopes = strict
+	return rac
+}
+
 func (rac *RouterAuthorizationCheck) Check(c *gin.Context, udrContext udr_context.NFContext) {
 	token := c.Request.Header.Get("Authorization")
 	err := udrContext.AuthorizationCheck(token, rac.serviceName)
 	if err != nil {
 		logger.UtilLog.Debugf("RouterAuthorizationCheck: Check Unauthorized: %s", err.Error())
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-		c.Abort()
+		problemDetails := &models.ProblemDetails{
+			Title:  "Unauthorized",
+			Status: http.StatusUnauthorized,
+			Detail: err.Error(),
+			Cause:  "UNAUTHORIZED",
+		}
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, problemDetails)
+		return
+	}
+
+	if err = checkRequesterCertificate(c, token); err != nil {
+		logger.UtilLog.Debugf("RouterAuthorizationCheck: Check Forbidden: %s", err.Error())
+		problemDetails := &models.ProblemDetails{
+			Title:  "Forbidden",
+			Status: http.StatusForbidden,
+			Detail: err.Error(),
+			Cause:  "CLIENT_CERTIFICATE_MISMATCH",
+		}
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
+		c.AbortWithStatusJSON(http.StatusForbidden, problemDetails)
+		return
+	}
+
+	if err = rac.checkRequiredScope(token); err != nil {
+		logger.UtilLog.Debugf("RouterAuthorizationCheck: Check Forbidden: %s", err.Error())
+		problemDetails := &models.ProblemDetails{
+			Title:  "Forbidden",
+			Status: http.StatusForbidden,
+			Detail: err.Error(),
+			Cause:  "OAUTH2_REQUIRED_SCOPES_NOT_GRANTED",
+		}
+		c.Header("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope="%s"`, rac.requiredScope))
+		c.Set(sbi.IN_PB_DETAILS_CTX_STR, problemDetails.Cause)
+		c.AbortWithStatusJSON(http.StatusForbidden, problemDetails)
 		return
 	}
 
 	logger.UtilLog.Debugf("RouterAuthorizationCheck: Check Authorized")
 }
+
+// checkRequiredScope matches the scope claim of the bearer token against the required scope. A request
+// without a readable token passed the authorization check as OAuth2 is not in use, there is no scope
+// to match then.
+func (rac *RouterAuthorizationCheck) checkRequiredScope(authorization string) error {
+	if rac.requiredScope == "" {
+		return nil
+	}
+	authFields := strings.Fields(authorization)
+	if len(authFields) < 2 {
+		return nil
+	}
+
+	claims := &models.NrfAccessTokenAccessTokenClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(authFields[1], claims); err != nil {
+		return nil
+	}
+
+	additionalScopes := 0
+	for _, scope := range strings.Fields(claims.Scope) {
+		if scope == rac.requiredScope {
+			return nil
+		}
+		if strings.HasPrefix(scope, string(rac.serviceName)+":") {
+			additionalScopes++
+		}
+	}
+	if additionalScopes == 0 && !rac.strictScopes {
+		return nil
+	}
+	return fmt.Errorf("access token scope %q does not grant %s", claims.Scope, rac.requiredScope)
+}
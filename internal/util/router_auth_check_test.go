@@ -1,11 +1,14 @@
 package util
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/pkg/errors"
 
 	"github.com/free5gc/openapi/models"
@@ -91,3 +94,163 @@ func TestRouterAuthorizationCheck_Check(t *testing.T) {
 		})
 	}
 }
+
+type allowAllUDRContext struct{}
+
+func (m *allowAllUDRContext) AuthorizationCheck(token string, serviceName models.ServiceName) error {
+	return nil
+}
+
+func TestRouterAuthorizationCheck_ClientCertificate(t *testing.T) {
+	const requesterNfInstanceId = "0c6c1d64-7a36-4d37-8b3e-4cf3c9c17d3b"
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodNone, &models.NrfAccessTokenAccessTokenClaims{
+		Sub:   requesterNfInstanceId,
+		Scope: "nudr-dr",
+	}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("error on token signing: %+v", err)
+	}
+
+	tests := []struct {
+		name       string
+		sans       []string
+		statusCode int
+	}{
+		{
+			name:       "No Client Certificate",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Matching NF Instance",
+			sans:       []string{"udm.5gc.mnc093.mcc208.3gppnetwork.org", "urn:uuid:" + requesterNfInstanceId},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Certificate Without NF Instance",
+			sans:       []string{"udm.5gc.mnc093.mcc208.3gppnetwork.org"},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Mismatching NF Instance",
+			sans:       []string{"urn:uuid:9db8a4d0-4d0c-4c0e-9efa-3b1e7d3a8e21"},
+			statusCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, err = http.NewRequest("GET", "/", nil)
+			if err != nil {
+				t.Errorf("error on http request: %+v", err)
+			}
+			c.Request.Header.Set("Authorization", "Bearer "+token)
+			if tt.sans != nil {
+				c.Set(ClientCertSANsCtxKey, tt.sans)
+			}
+
+			rac := NewRouterAuthorizationCheck(models.ServiceName_NUDR_DR)
+			rac.Check(c, &allowAllUDRContext{})
+			if w.Code != tt.statusCode {
+				t.Errorf("StatusCode should be %d, but got %d", tt.statusCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestRouterAuthorizationCheck_RequiredScope(t *testing.T) {
+	mintToken := func(scope string) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodNone, &models.NrfAccessTokenAccessTokenClaims{
+			Sub:   "0c6c1d64-7a36-4d37-8b3e-4cf3c9c17d3b",
+			Scope: scope,
+		}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+		if err != nil {
+			t.Fatalf("error on token signing: %+v", err)
+		}
+		return "Bearer " + token
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+		statusCode    int
+		strictCode    int
+	}{
+		{
+			name:          "Service Scope Only",
+			authorization: mintToken("nudr-dr"),
+			statusCode:    http.StatusOK,
+			strictCode:    http.StatusForbidden,
+		},
+		{
+			name:          "Required Scope",
+			authorization: mintToken("nudr-dr nudr-dr:policy-data"),
+			statusCode:    http.StatusOK,
+			strictCode:    http.StatusOK,
+		},
+		{
+			name:          "Required Among Additional Scopes",
+			authorization: mintToken("nudr-dr nudr-dr:subscription-data nudr-dr:policy-data"),
+			statusCode:    http.StatusOK,
+			strictCode:    http.StatusOK,
+		},
+		{
+			name:          "Other Additional Scope",
+			authorization: mintToken("nudr-dr nudr-dr:subscription-data"),
+			statusCode:    http.StatusForbidden,
+			strictCode:    http.StatusForbidden,
+		},
+		{
+			name:          "Other Additional Scopes",
+			authorization: mintToken("nudr-dr nudr-dr:exposure-data nudr-dr:application-data"),
+			statusCode:    http.StatusForbidden,
+			strictCode:    http.StatusForbidden,
+		},
+		{
+			name:          "Additional Scope Of Another Service",
+			authorization: mintToken("nudr-dr nudr-group-id-map:policy-data"),
+			statusCode:    http.StatusOK,
+			strictCode:    http.StatusForbidden,
+		},
+		{
+			name:       "No Token",
+			statusCode: http.StatusOK,
+			strictCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		for _, strict := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s Strict %v", tt.name, strict), func(t *testing.T) {
+				w := httptest.NewRecorder()
+				c, _ := gin.CreateTestContext(w)
+				var err error
+				c.Request, err = http.NewRequest("GET", "/", nil)
+				if err != nil {
+					t.Errorf("error on http request: %+v", err)
+				}
+				if tt.authorization != "" {
+					c.Request.Header.Set("Authorization", tt.authorization)
+				}
+
+				rac := NewRouterAuthorizationCheck(models.ServiceName_NUDR_DR).
+					WithRequiredScope(ScopeNudrDrPolicyData, strict)
+				rac.Check(c, &allowAllUDRContext{})
+
+				statusCode := tt.statusCode
+				if strict {
+					statusCode = tt.strictCode
+				}
+				if w.Code != statusCode {
+					t.Errorf("StatusCode should be %d, but got %d", statusCode, w.Code)
+				}
+				if w.Code == http.StatusForbidden &&
+					!strings.Contains(w.Body.String(), "OAUTH2_REQUIRED_SCOPES_NOT_GRANTED") {
+					t.Errorf("Cause should be OAUTH2_REQUIRED_SCOPES_NOT_GRANTED, but got %s", w.Body.String())
+				}
+			})
+		}
+	}
+}
@@ -23,6 +23,18 @@ func ProblemDetailsSystemFailure(detail string) *models.ProblemDetails {
 	}
 }
 
+// ProblemDetailsIncident hides the cause of an unexpected failure from the consumer,
+// the operators find it in the log by the incident ID
+func ProblemDetailsIncident(incidentId string) *models.ProblemDetails {
+	return &models.ProblemDetails{
+		Title:    "Internal server error",
+		Status:   http.StatusInternalServerError,
+		Detail:   "Unexpected error, incident " + incidentId,
+		Cause:    "SYSTEM_FAILURE",
+		Instance: "urn:uuid:" + incidentId,
+	}
+}
+
 func ProblemDetailsMalformedReqSyntax(detail string) *models.ProblemDetails {
 	return &models.ProblemDetails{
 		Title:  "Malformed request syntax",
@@ -31,6 +43,87 @@ func ProblemDetailsMalformedReqSyntax(detail string) *models.ProblemDetails {
 	}
 }
 
+func ProblemDetailsPayloadTooLarge(detail string) *models.ProblemDetails {
+	return &models.ProblemDetails{
+		Title:  "Payload too large",
+		Status: http.StatusRequestEntityTooLarge,
+		Detail: detail,
+		Cause:  "PAYLOAD_TOO_LARGE",
+	}
+}
+
+func ProblemDetailsTooManyRequests(detail string) *models.ProblemDetails {
+	return &models.ProblemDetails{
+		Title:  "Too many requests",
+		Status: http.StatusTooManyRequests,
+		Detail: detail,
+		Cause:  "TOO_MANY_REQUESTS",
+	}
+}
+
+func ProblemDetailsGatewayTimeout(detail string) *models.ProblemDetails {
+	return &models.ProblemDetails{
+		Title:  "Gateway timeout",
+		Status: http.StatusGatewayTimeout,
+		Detail: detail,
+		Cause:  "TIMED_OUT_REQUEST",
+	}
+}
+
+func ProblemDetailsServiceUnavailable(detail string) *models.ProblemDetails {
+	return &models.ProblemDetails{
+		Title:  "Service unavailable",
+		Status: http.StatusServiceUnavailable,
+		Detail: detail,
+		Cause:  "SYSTEM_FAILURE",
+	}
+}
+
+func ProblemDetailsNfCongestion(detail string) *models.ProblemDetails {
+	return &models.ProblemDetails{
+		Title:  "NF congestion",
+		Status: http.StatusServiceUnavailable,
+		Detail: detail,
+		Cause:  "NF_CONGESTION",
+	}
+}
+
+func ProblemDetailsPreconditionFailed(detail string) *models.ProblemDetails {
+	return &models.ProblemDetails{
+		Title:  "Precondition failed",
+		Status: http.StatusPreconditionFailed,
+		Detail: detail,
+		Cause:  "PRECONDITION_FAILED",
+	}
+}
+
+func ProblemDetailsConflict(detail string) *models.ProblemDetails {
+	return &models.ProblemDetails{
+		Title:  "Conflict",
+		Status: http.StatusConflict,
+		Detail: detail,
+		Cause:  "CONFLICT",
+	}
+}
+
+func ProblemDetailsUnprocessableEntity(detail string) *models.ProblemDetails {
+	return &models.ProblemDetails{
+		Title:  "Unprocessable entity",
+		Status: http.StatusUnprocessableEntity,
+		Detail: detail,
+		Cause:  "UNPROCESSABLE_ENTITY",
+	}
+}
+
+func ProblemDetailsUnsupportedMediaType(detail string) *models.ProblemDetails {
+	return &models.ProblemDetails{
+		Title:  "Unsupported media type",
+		Status: http.StatusUnsupportedMediaType,
+		Detail: detail,
+		Cause:  "UNSUPPORTED_MEDIA_TYPE",
+	}
+}
+
 func ProblemDetailsNotFound(cause string) *models.ProblemDetails {
 	title := ""
 	switch cause {
@@ -5,32 +5,84 @@
 package factory
 
 import (
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"maps"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/asaskevich/govalidator"
+	"github.com/sirupsen/logrus"
 
 	"github.com/free5gc/udr/internal/logger"
 )
 
 const (
-	UdrDefaultTLSKeyLogPath    = "./log/udrsslkey.log"
-	UdrDefaultCertPemPath      = "./cert/udr.pem"
-	UdrDefaultPrivateKeyPath   = "./cert/udr.key"
-	UdrDefaultConfigPath       = "./config/udrcfg.yaml"
-	UdrSbiDefaultIPv4          = "127.0.0.9"
-	UdrSbiDefaultPort          = 8000
-	UdrSbiDefaultScheme        = "https"
-	UdrMetricsDefaultEnabled   = false
-	UdrMetricsDefaultPort      = 9091
-	UdrMetricsDefaultScheme    = "https"
-	UdrMetricsDefaultNamespace = "free5gc"
-	UdrDefaultNrfUri           = "https://127.0.0.10:8000"
-	UdrDrResUriPrefix          = "/nudr-dr/v2"
-	UdrGroupIdResUriPrefix     = "/nudr-group-id-map/v1"
-	HSSIsmSDMUriPrefix         = "/nhss-ims-sdm/v1"
+	UdrDefaultTLSKeyLogPath             = "./log/udrsslkey.log"
+	UdrDefaultCertPemPath               = "./cert/udr.pem"
+	UdrDefaultPrivateKeyPath            = "./cert/udr.key"
+	UdrDefaultConfigPath                = "./config/udrcfg.yaml"
+	UdrSbiDefaultIPv4                   = "127.0.0.9"
+	UdrSbiDefaultPort                   = 8000
+	UdrSbiDefaultScheme                 = "https"
+	UdrSbiDefaultUnixSocketMode         = 0o660
+	UdrSbiClientAuthNone                = "none"
+	UdrSbiClientAuthRequest             = "request"
+	UdrSbiClientAuthRequireAndVerify    = "require-and-verify"
+	UdrSbiTlsVersion12                  = "1.2"
+	UdrSbiTlsVersion13                  = "1.3"
+	UdrSbiDefaultTlsMinVersion          = UdrSbiTlsVersion12
+	UdrDefaultShutdownTimeout           = 2    // seconds
+	UdrDefaultHeartbeatInterval         = 10   // seconds
+	UdrDefaultNrfDeregisterTimeout      = 3    // seconds
+	UdrDefaultNrfRegisterRetryDelay     = 2000 // milliseconds
+	UdrNrfMaxRegisterRetryDelay         = 60   // seconds
+	UdrDefaultProbeMongoPingTimeout     = 1000 // milliseconds
+	UdrDefaultProbeHealthDetailTimeout  = 500  // milliseconds
+	UdrDefaultRetryAfter                = 5    // seconds
+	UdrDefaultDataStoreCheckInterval    = 5    // seconds
+	UdrSbiDefaultReadHeaderTimeout      = 10   // seconds
+	UdrSbiDefaultReadTimeout            = 30   // seconds
+	UdrSbiDefaultWriteTimeout           = 30   // seconds
+	UdrSbiDefaultIdleTimeout            = 120  // seconds
+	UdrSbiDefaultMaxRequestBodyBytes    = 8 << 20
+	UdrSbiDefaultCompressionMinSize     = 1024
+	UdrSbiDefaultRateLimitMaxConsumers  = 10000
+	UdrMongodbDefaultConnectMaxAttempts = 10
+	UdrMongodbDefaultConnectRetryDelay  = 500 // milliseconds
+	UdrMongodbMaxConnectRetryDelay      = 30  // seconds
+	UdrMongodbDefaultMaxPoolSize        = 100 // the driver default
+	UdrDefaultPageSize                  = 100
+	UdrDefaultMaxPageSize               = 1000
+	UdrDefaultProvisioningMaxBatchSize  = 1000
+	UdrDefaultBatchReadMaxSupis         = 1000
+	UdrDefaultAuditRetention            = 90 // days
+	UdrDefaultAuditQueueSize            = 1000
+	UdrDefaultAuthSubsCacheSize         = 10000
+	UdrDefaultAuthSubsCacheTtl          = 30 // seconds
+	UdrDefaultSubscriptionSweepInterval = 60 // seconds
+	UdrAccessLogFormatText              = "text"
+	UdrAccessLogFormatJSON              = "json"
+	UdrDefaultAccessLogFormat           = UdrAccessLogFormatText
+	UdrMetricsDefaultEnabled            = false
+	UdrMetricsDefaultPort               = 9091
+	UdrMetricsDefaultScheme             = "https"
+	UdrMetricsDefaultNamespace          = "free5gc"
+	UdrDefaultNrfUri                    = "https://127.0.0.10:8000"
+	UdrDrResUriPrefix                   = "/nudr-dr/v2"
+	UdrDrDefaultSupportedFeatures       = "1" // the shared data IDs of the data sets
+	UdrGroupIdResUriPrefix              = "/nudr-group-id-map/v1"
+	HSSIsmSDMUriPrefix                  = "/nhss-ims-sdm/v1"
 )
 
 type DbType string
@@ -40,17 +92,34 @@ type Config struct {
 	Configuration *Configuration `yaml:"configuration" valid:"required"`
 	Logger        *Logger        `yaml:"logger" valid:"required"`
 	sync.RWMutex
+
+	// path of the file read, the one read again by Reload
+	path string
 }
 
+// Validate checks the whole config, the error lists every problem found rather than the first one only
 func (c *Config) Validate() (bool, error) {
+	var errs govalidator.Errors
 	if configuration := c.Configuration; configuration != nil {
-		if result, err := configuration.validate(); err != nil {
-			return result, err
+		if _, err := configuration.validate(); err != nil {
+			errs = appendErrors(errs, err)
 		}
 	}
 
-	result, err := govalidator.ValidateStruct(c)
-	return result, appendInvalid(err)
+	if c.Logger != nil {
+		if _, err := c.Logger.validate(); err != nil {
+			errs = appendErrors(errs, err)
+		}
+	}
+
+	if _, err := govalidator.ValidateStruct(c); err != nil {
+		errs = appendErrors(errs, appendInvalid(err))
+	}
+
+	if len(errs) > 0 {
+		return false, error(errs)
+	}
+	return true, nil
 }
 
 type Info struct {
@@ -67,82 +136,191 @@ const (
 type Configuration struct {
 	Sbi             *Sbi     `yaml:"sbi" valid:"required"`
 	Metrics         *Metrics `yaml:"metrics,omitempty" valid:"optional"`
-	DbConnectorType DbType   `yaml:"dbConnectorType" valid:"required,in(mongodb)"`
+	DbConnectorType DbType   `yaml:"dbConnectorType" valid:"required,in(mongodb|memory)"`
 	Mongodb         *Mongodb `yaml:"mongodb" valid:"optional"`
 	NrfUri          string   `yaml:"nrfUri" valid:"url,required"`
 	NrfCertPem      string   `yaml:"nrfCertPem,omitempty" valid:"optional"`
+	// Time (in seconds) to wait for in-flight requests to drain on shutdown, 0 means wait indefinitely.
+	GracefulShutdownTimeout *int `yaml:"gracefulShutdownTimeout,omitempty" valid:"optional"`
+	// Heartbeat interval (in seconds) used when the NRF does not provide a heartBeatTimer.
+	NrfHeartbeatInterval int `yaml:"nrfHeartbeatInterval,omitempty" valid:"optional"`
+	// Registration attempts to the NRF on startup, 0 retries until registered. The delay between two of them
+	// doubles from nrfRegisterRetryDelay.
+	NrfRegisterMaxAttempts int `yaml:"nrfRegisterMaxAttempts,omitempty" valid:"optional"`
+	NrfRegisterRetryDelay  int `yaml:"nrfRegisterRetryDelay,omitempty" valid:"optional"` // milliseconds
+	// Keep the NF profile in the NRF on shutdown, meant for debugging.
+	SkipNrfDeregistration bool          `yaml:"skipNrfDeregistration,omitempty" valid:"type(bool)"`
+	Probes                *Probes       `yaml:"probes,omitempty" valid:"optional"`
+	Debug                 *Debug        `yaml:"debug,omitempty" valid:"optional"`
+	Pagination            *Pagination   `yaml:"pagination,omitempty" valid:"optional"`
+	Provisioning          *Provisioning `yaml:"provisioning,omitempty" valid:"optional"`
+	BatchRead             *BatchRead    `yaml:"batchRead,omitempty" valid:"optional"`
+	Availability          *Availability `yaml:"availability,omitempty" valid:"optional"`
+	// NF types, e.g. UDM, allowed to access each resource group, the groups not listed are open to any NF.
+	AllowedNfTypes map[string][]string `yaml:"allowedNfTypes,omitempty" valid:"-"`
+	Audit          *Audit              `yaml:"audit,omitempty" valid:"optional"`
+	Admin          *Admin              `yaml:"admin,omitempty" valid:"optional"`
+	AuthSubsCache  *AuthSubsCache      `yaml:"authSubsCache,omitempty" valid:"optional"`
+	NfProfile      *NfProfile          `yaml:"nfProfile,omitempty" valid:"optional"`
+	Subscriptions  *Subscriptions      `yaml:"subscriptions,omitempty" valid:"optional"`
+	// Features supported by service name, e.g. nudr-dr, as the hexadecimal SupportedFeatures of TS 29.571.
+	SupportedFeatures map[string]string `yaml:"supportedFeatures,omitempty" valid:"-"`
 }
 
-type Logger struct {
-	Enable       bool   `yaml:"enable" valid:"type(bool)"`
-	Level        string `yaml:"level" valid:"required,in(trace|debug|info|warn|error|fatal|panic)"`
-	ReportCaller bool   `yaml:"reportCaller" valid:"type(bool)"`
-}
+// Resource groups of allowedNfTypes, authentication-data is the part of subscription-data holding the
+// authentication subscription and status, along with the bulk provisioning and the offboarding of subscribers
+// that write and remove it
+const (
+	UdrResourceGroupSubscriptionData   = "subscription-data"
+	UdrResourceGroupAuthenticationData = "authentication-data"
+	UdrResourceGroupPolicyData         = "policy-data"
+	UdrResourceGroupExposureData       = "exposure-data"
+	UdrResourceGroupApplicationData    = "application-data"
+)
 
-func (c *Configuration) validate() (bool, error) {
-	govalidator.TagMap["scheme"] = govalidator.Validator(func(str string) bool {
-		return str == "https" || str == "http"
-	})
+// supportedFeaturesServices are the services of supportedFeatures
+var supportedFeaturesServices = []string{"nudr-dr", "nudr-group-id-map"}
 
-	if c.Metrics != nil {
-		if _, err := c.Metrics.validate(); err != nil {
-			return false, err
+func validateSupportedFeatures(supportedFeatures map[string]string) (bool, error) {
+	var errs govalidator.Errors
+	for service, features := range supportedFeatures {
+		if !slices.Contains(supportedFeaturesServices, service) {
+			errs = append(errs, fmt.Errorf("supportedFeatures: unknown service %q", service))
+			continue
 		}
+		// A SupportedFeatures of TS 29.571 5.2.2 is hexadecimal, empty when there is none
+		if features != "" && !govalidator.IsHexadecimal(features) {
+			errs = append(errs, fmt.Errorf("supportedFeatures %s: %q should be hexadecimal", service, features))
+		}
+	}
+	if len(errs) > 0 {
+		return false, error(errs)
+	}
+	return true, nil
+}
 
-		if c.Sbi != nil && c.Metrics.Port == c.Sbi.Port && c.Sbi.BindingIPv4 == c.Metrics.BindingIPv4 {
-			var errs govalidator.Errors
-			err := fmt.Errorf("sbi and metrics bindings IPv4: %s and port: %d cannot be the same, "+
-				"please provide at least another port for the metrics", c.Sbi.BindingIPv4, c.Sbi.Port)
-			errs = append(errs, err)
-			return false, error(errs)
+func validateAllowedNfTypes(allowedNfTypes map[string][]string) (bool, error) {
+	var errs govalidator.Errors
+	for resourceGroup, nfTypes := range allowedNfTypes {
+		switch resourceGroup {
+		case UdrResourceGroupSubscriptionData, UdrResourceGroupAuthenticationData, UdrResourceGroupPolicyData,
+			UdrResourceGroupExposureData, UdrResourceGroupApplicationData:
+		default:
+			errs = append(errs, fmt.Errorf("allowedNfTypes: unknown resource group %q", resourceGroup))
+			continue
+		}
+		if len(nfTypes) == 0 {
+			errs = append(errs, fmt.Errorf("allowedNfTypes %s: at least one NF type should be provided",
+				resourceGroup))
+		}
+		for _, nfType := range nfTypes {
+			if nfType == "" || strings.ToUpper(nfType) != nfType {
+				errs = append(errs, fmt.Errorf("allowedNfTypes %s: NF type %q should be in upper case, e.g. UDM",
+					resourceGroup, nfType))
+			}
 		}
 	}
+	if len(errs) > 0 {
+		return false, error(errs)
+	}
+	return true, nil
+}
 
-	result, err := govalidator.ValidateStruct(c)
-	return result, appendInvalid(err)
+// Availability tunes the 503 answered while the UDR is starting, overloaded or its datastore is down
+type Availability struct {
+	// In-flight requests beyond which the new ones are rejected, 0 means no limit.
+	MaxInflightRequests    int `yaml:"maxInflightRequests,omitempty" valid:"optional"`
+	RetryAfter             int `yaml:"retryAfter,omitempty" valid:"optional"`             // seconds
+	DataStoreCheckInterval int `yaml:"dataStoreCheckInterval,omitempty" valid:"optional"` // seconds
 }
 
-type Sbi struct {
-	Scheme       string `yaml:"scheme" valid:"scheme,required"`
-	RegisterIPv4 string `yaml:"registerIPv4,omitempty" valid:"host,optional"` // IP that is registered at NRF.
-	// IPv6Addr string `yaml:"ipv6Addr,omitempty"`
-	BindingIPv4 string `yaml:"bindingIPv4,omitempty" valid:"host,optional"` // IP used to run the server in the node.
-	Port        int    `yaml:"port" valid:"port,required"`
-	Tls         *Tls   `yaml:"tls,omitempty" valid:"optional"`
+func (a *Availability) validate() (bool, error) {
+	var errs govalidator.Errors
+	if a.MaxInflightRequests < 0 {
+		errs = append(errs, fmt.Errorf("availability maxInflightRequests: %d should not be negative",
+			a.MaxInflightRequests))
+	}
+	if a.RetryAfter < 0 {
+		errs = append(errs, fmt.Errorf("availability retryAfter: %d should not be negative", a.RetryAfter))
+	}
+	if a.DataStoreCheckInterval < 0 {
+		errs = append(errs, fmt.Errorf("availability dataStoreCheckInterval: %d should not be negative",
+			a.DataStoreCheckInterval))
+	}
+
+	if len(errs) > 0 {
+		return false, error(errs)
+	}
+	return true, nil
 }
 
-type Tls struct {
-	Pem string `yaml:"pem,omitempty" valid:"type(string),minstringlength(1),required"`
-	Key string `yaml:"key,omitempty" valid:"type(string),minstringlength(1),required"`
+// Provisioning bounds the bulk provisioning requests
+type Provisioning struct {
+	MaxBatchSize int `yaml:"maxBatchSize,omitempty" valid:"optional"` // Records accepted in one request.
 }
 
-func (t *Tls) validate() (bool, error) {
-	result, err := govalidator.ValidateStruct(t)
-	return result, appendInvalid(err)
+// BatchRead bounds the reads of the data of many subscribers in one request
+type BatchRead struct {
+	MaxSupis int `yaml:"maxSupis,omitempty" valid:"optional"` // SUPIs accepted in one request.
 }
 
-type Metrics struct {
-	Enable      bool   `yaml:"enable" valid:"optional"`
-	Scheme      string `yaml:"scheme" valid:"required,scheme"`
-	BindingIPv4 string `yaml:"bindingIPv4,omitempty" valid:"required,host"` // IP used to run the server in the node.
-	Port        int    `yaml:"port,omitempty" valid:"optional,port"`
-	Tls         *Tls   `yaml:"tls,omitempty" valid:"optional"`
-	Namespace   string `yaml:"namespace" valid:"optional"`
+// Pagination bounds the page-size of the listing endpoints
+type Pagination struct {
+	DefaultPageSize int `yaml:"defaultPageSize,omitempty" valid:"optional"` // Used when page-size is not given.
+	MaxPageSize     int `yaml:"maxPageSize,omitempty" valid:"optional"`
 }
 
-// This function is the mirror of the SBI one, I decided not to factor the code as it could in the future diverge.
-// And it will reduce the cognitive overload when reading the function by not hiding the logic elsewhere.
-func (m *Metrics) validate() (bool, error) {
+func (p *Pagination) validate() (bool, error) {
 	var errs govalidator.Errors
+	if p.DefaultPageSize < 0 {
+		errs = append(errs, fmt.Errorf("pagination defaultPageSize: %d should not be negative", p.DefaultPageSize))
+	}
+	if p.MaxPageSize < 0 {
+		errs = append(errs, fmt.Errorf("pagination maxPageSize: %d should not be negative", p.MaxPageSize))
+	}
+	if p.MaxPageSize > 0 && p.DefaultPageSize > p.MaxPageSize {
+		errs = append(errs, fmt.Errorf("pagination defaultPageSize: %d should not exceed maxPageSize: %d",
+			p.DefaultPageSize, p.MaxPageSize))
+	}
 
-	if tls := m.Tls; tls != nil {
-		if _, err := tls.validate(); err != nil {
-			errs = append(errs, err)
-		}
+	if len(errs) > 0 {
+		return false, error(errs)
 	}
+	return true, nil
+}
 
-	if _, err := govalidator.ValidateStruct(m); err != nil {
-		errs = append(errs, err)
+// Probes tunes the checks of the readiness probe
+type Probes struct {
+	MongoPingTimeout int `yaml:"mongoPingTimeout,omitempty" valid:"optional"` // milliseconds
+	// Bounds the MongoDB ping of /healthz/detail, kept short so that the probe never hangs.
+	HealthDetailTimeout int `yaml:"healthDetailTimeout,omitempty" valid:"optional"` // milliseconds
+	// Report ready even when the UDR is not registered to the NRF.
+	SkipNrfRegistrationCheck bool `yaml:"skipNrfRegistrationCheck,omitempty" valid:"type(bool)"`
+}
+
+// Debug serves the pprof and expvar handlers on a listener of its own, meant for troubleshooting only
+type Debug struct {
+	Pprof    bool   `yaml:"pprof,omitempty" valid:"type(bool)"`
+	BindAddr string `yaml:"bindAddr,omitempty" valid:"optional"` // host:port, distinct from every SBI address
+}
+
+// validate refuses a debug listener that could be mistaken for, or collide with, an SBI one
+func (d *Debug) validate(sbi *Sbi) (bool, error) {
+	var errs govalidator.Errors
+
+	host, port, err := net.SplitHostPort(d.BindAddr)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("debug bindAddr: %q should be host:port: %w", d.BindAddr, err))
+		return false, error(errs)
+	}
+	if sbi != nil {
+		for _, binding := range sbi.getBindings() {
+			if port == strconv.Itoa(binding.Port) && (host == binding.BindingIP ||
+				isUnspecifiedHost(host) || isUnspecifiedHost(binding.BindingIP)) {
+				errs = append(errs, fmt.Errorf("debug bindAddr: %s cannot be the same as the sbi binding %s",
+					d.BindAddr, binding.GetBindingAddr()))
+			}
+		}
 	}
 
 	if len(errs) > 0 {
@@ -151,120 +329,1533 @@ func (m *Metrics) validate() (bool, error) {
 	return true, nil
 }
 
-type Mongodb struct {
-	Name string `yaml:"name" valid:"type(string),required"`
-	Url  string `yaml:"url" valid:"requrl,required"`
+// Audit keeps a trail of the provisioning writes on subscription-data and policy-data
+type Audit struct {
+	Enable bool `yaml:"enable" valid:"type(bool)"`
+	// Days the records are kept for, by a TTL index on their timestamp.
+	Retention int `yaml:"retention,omitempty" valid:"optional"`
+	// Records waiting to be written, the records beyond are dropped rather than slowing the writes down.
+	QueueSize int `yaml:"queueSize,omitempty" valid:"optional"`
 }
 
-func appendInvalid(err error) error {
+func (a *Audit) validate() (bool, error) {
 	var errs govalidator.Errors
-
-	if err == nil {
-		return nil
+	if a.Retention < 0 {
+		errs = append(errs, fmt.Errorf("audit retention: %d should not be negative", a.Retention))
+	}
+	if a.QueueSize < 0 {
+		errs = append(errs, fmt.Errorf("audit queueSize: %d should not be negative", a.QueueSize))
 	}
 
-	es := err.(govalidator.Errors).Errors()
-	for _, e := range es {
-		errs = append(errs, fmt.Errorf("invalid %w", e))
+	if len(errs) > 0 {
+		return false, error(errs)
 	}
+	return true, nil
+}
 
-	return error(errs)
+// AuthSubsCache caches the authentication subscriptions read, by SUPI. The writes through this UDR drop the
+// cached subscription, the ones through another UDR or straight to MongoDB show once the TTL has elapsed.
+type AuthSubsCache struct {
+	Enable bool `yaml:"enable" valid:"type(bool)"`
+	// Subscriptions cached, the least recently read ones are evicted beyond.
+	Size int `yaml:"size,omitempty" valid:"optional"`
+	Ttl  int `yaml:"ttl,omitempty" valid:"optional"` // seconds
 }
 
-func (c *Config) GetVersion() string {
-	c.RWMutex.RLock()
-	defer c.RWMutex.RUnlock()
+func (a *AuthSubsCache) validate() (bool, error) {
+	var errs govalidator.Errors
+	if a.Size < 0 {
+		errs = append(errs, fmt.Errorf("authSubsCache size: %d should not be negative", a.Size))
+	}
+	if a.Ttl < 0 {
+		errs = append(errs, fmt.Errorf("authSubsCache ttl: %d should not be negative", a.Ttl))
+	}
 
-	if c.Info.Version != "" {
-		return c.Info.Version
+	if len(errs) > 0 {
+		return false, error(errs)
 	}
-	return ""
+	return true, nil
 }
 
-func (c *Config) SetLogEnable(enable bool) {
-	c.RWMutex.Lock()
-	defer c.RWMutex.Unlock()
+// Subscriptions tunes the removal of the expired SDM subscriptions and subscriptions to data change notifications
+type Subscriptions struct {
+	ExpirySweepInterval int `yaml:"expirySweepInterval,omitempty" valid:"optional"` // seconds
+}
 
-	if c.Logger == nil {
-		logger.CfgLog.Warnf("Logger should not be nil")
-		c.Logger = &Logger{
-			Enable: enable,
-			Level:  "info",
-		}
-	} else {
-		c.Logger.Enable = enable
+func (s *Subscriptions) validate() (bool, error) {
+	if s.ExpirySweepInterval < 0 {
+		return false, fmt.Errorf("subscriptions expirySweepInterval: %d should not be negative", s.ExpirySweepInterval)
 	}
+	return true, nil
 }
 
-func (c *Config) SetLogLevel(level string) {
-	c.RWMutex.Lock()
-	defer c.RWMutex.Unlock()
+// NfProfile sets fields of the NF profile registered to the NRF, e.g. for the NRF to distribute the load
+// between several UDRs. The fields left out are not sent.
+type NfProfile struct {
+	PlmnList []PlmnId `yaml:"plmnList,omitempty" valid:"-"`
+	// 1 to 65535, the lower the more preferred (TS 29.510), 0 leaves it out
+	Priority int    `yaml:"priority,omitempty" valid:"optional"`
+	Capacity int    `yaml:"capacity,omitempty" valid:"optional"` // 1 to 65535, 0 leaves it out
+	Locality string `yaml:"locality,omitempty" valid:"optional"`
+}
 
-	if c.Logger == nil {
-		logger.CfgLog.Warnf("Logger should not be nil")
-		c.Logger = &Logger{
-			Level: level,
+type PlmnId struct {
+	Mcc string `yaml:"mcc"`
+	Mnc string `yaml:"mnc"`
+}
+
+var (
+	mccRegexp = regexp.MustCompile("^[0-9]{3}$")
+	mncRegexp = regexp.MustCompile("^[0-9]{2,3}$")
+)
+
+func (n *NfProfile) validate() (bool, error) {
+	var errs govalidator.Errors
+	for i, plmnId := range n.PlmnList {
+		if !mccRegexp.MatchString(plmnId.Mcc) {
+			errs = append(errs, fmt.Errorf("nfProfile plmnList[%d]: mcc %q should be 3 digits", i, plmnId.Mcc))
 		}
-	} else {
-		c.Logger.Level = level
+		if !mncRegexp.MatchString(plmnId.Mnc) {
+			errs = append(errs, fmt.Errorf("nfProfile plmnList[%d]: mnc %q should be 2 or 3 digits", i, plmnId.Mnc))
+		}
+	}
+	if n.Priority < 0 || n.Priority > math.MaxUint16 {
+		errs = append(errs, fmt.Errorf("nfProfile priority: %d should be between 0 and %d", n.Priority, math.MaxUint16))
+	}
+	if n.Capacity < 0 || n.Capacity > math.MaxUint16 {
+		errs = append(errs, fmt.Errorf("nfProfile capacity: %d should be between 0 and %d", n.Capacity, math.MaxUint16))
+	}
+
+	if len(errs) > 0 {
+		return false, error(errs)
 	}
+	return true, nil
 }
 
-func (c *Config) SetLogReportCaller(reportCaller bool) {
-	c.RWMutex.Lock()
-	defer c.RWMutex.Unlock()
+// Admin is the listener of the operator endpoints, e.g. the audit trail, never served on the SBI
+type Admin struct {
+	BindAddr string `yaml:"bindAddr" valid:"required"` // host:port, distinct from every SBI address
+}
 
-	if c.Logger == nil {
-		logger.CfgLog.Warnf("Logger should not be nil")
-		c.Logger = &Logger{
-			Level:        "info",
-			ReportCaller: reportCaller,
+// validate refuses an admin listener colliding with an SBI or the debug one
+func (a *Admin) validate(sbi *Sbi, debug *Debug) (bool, error) {
+	var errs govalidator.Errors
+
+	host, port, err := net.SplitHostPort(a.BindAddr)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("admin bindAddr: %q should be host:port: %w", a.BindAddr, err))
+		return false, error(errs)
+	}
+	if sbi != nil {
+		for _, binding := range sbi.getBindings() {
+			if port == strconv.Itoa(binding.Port) && (host == binding.BindingIP ||
+				isUnspecifiedHost(host) || isUnspecifiedHost(binding.BindingIP)) {
+				errs = append(errs, fmt.Errorf("admin bindAddr: %s cannot be the same as the sbi binding %s",
+					a.BindAddr, binding.GetBindingAddr()))
+			}
 		}
-	} else {
-		c.Logger.ReportCaller = reportCaller
 	}
-}
+	if debug != nil && debug.Pprof && debug.BindAddr == a.BindAddr {
+		errs = append(errs, fmt.Errorf("admin bindAddr: %s cannot be the same as the debug one", a.BindAddr))
+	}
 
-func (c *Config) GetLogEnable() bool {
-	c.RWMutex.RLock()
-	defer c.RWMutex.RUnlock()
-	if c.Logger == nil {
-		logger.CfgLog.Warnf("Logger should not be nil")
-		return false
+	if len(errs) > 0 {
+		return false, error(errs)
 	}
-	return c.Logger.Enable
+	return true, nil
 }
 
-func (c *Config) GetLogLevel() string {
-	c.RWMutex.RLock()
-	defer c.RWMutex.RUnlock()
-	if c.Logger == nil {
-		logger.CfgLog.Warnf("Logger should not be nil")
-		return "info"
+func isUnspecifiedHost(host string) bool {
+	if host == "" {
+		return true
 	}
-	return c.Logger.Level
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsUnspecified()
 }
 
-func (c *Config) GetLogReportCaller() bool {
-	c.RWMutex.RLock()
-	defer c.RWMutex.RUnlock()
-	if c.Logger == nil {
-		logger.CfgLog.Warnf("Logger should not be nil")
-		return false
-	}
-	return c.Logger.ReportCaller
+type Logger struct {
+	Enable       bool   `yaml:"enable" valid:"type(bool)"`
+	Level        string `yaml:"level" valid:"required,in(trace|debug|info|warn|error|fatal|panic)"`
+	ReportCaller bool   `yaml:"reportCaller" valid:"type(bool)"`
+	// Mask the SUPIs in the access log.
+	Anonymize       bool   `yaml:"anonymize,omitempty" valid:"type(bool)"`
+	AccessLogFormat string `yaml:"accessLogFormat,omitempty" valid:"optional,in(text|json)"` // Defaults to text.
+	// Levels of the SBI and GIN categories overriding level, by category.
+	Levels map[string]string `yaml:"levels,omitempty" valid:"optional"`
 }
 
-func (c *Config) GetCertPemPath() string {
-	c.RLock()
-	defer c.RUnlock()
-	return c.Configuration.Sbi.Tls.Pem
+func (l *Logger) validate() (bool, error) {
+	var errs govalidator.Errors
+	for _, category := range slices.Sorted(maps.Keys(l.Levels)) {
+		if !slices.Contains(logger.LevelCategories, category) {
+			errs = append(errs, fmt.Errorf("logger levels: %s should be one of %s", category,
+				strings.Join(logger.LevelCategories, ", ")))
+		}
+		if _, err := logrus.ParseLevel(l.Levels[category]); err != nil {
+			errs = append(errs, fmt.Errorf("logger levels %s: %w", category, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return false, error(errs)
+	}
+	return true, nil
 }
 
-func (c *Config) GetCertKeyPath() string {
-	c.RLock()
-	defer c.RUnlock()
-	return c.Configuration.Sbi.Tls.Key
+func (c *Configuration) validate() (bool, error) {
+	govalidator.TagMap["scheme"] = govalidator.Validator(func(str string) bool {
+		return str == "https" || str == "http"
+	})
+
+	var errs govalidator.Errors
+
+	if c.GracefulShutdownTimeout != nil && *c.GracefulShutdownTimeout < 0 {
+		errs = append(errs, fmt.Errorf("gracefulShutdownTimeout: %d should not be negative", *c.GracefulShutdownTimeout))
+	}
+
+	if c.Sbi != nil && len(c.Sbi.Bindings) == 0 && c.Sbi.BindingAddr == "" && c.Sbi.BindingIPv4 == "" &&
+		c.Sbi.BindingIPv6 == nil {
+		errs = append(errs, fmt.Errorf("sbi: at least one of bindingAddr, bindingIPv4 or bindingIPv6 should be provided"))
+	}
+
+	if c.Sbi != nil && c.Sbi.ShutdownTimeout != 0 {
+		logger.CfgLog.Warnf("sbi shutdownTimeout is deprecated, use gracefulShutdownTimeout")
+	}
+
+	if c.Sbi != nil {
+		if _, err := c.Sbi.validateBindingAddrs(); err != nil {
+			errs = appendErrors(errs, err)
+		}
+		if _, err := c.Sbi.validateSchemes(); err != nil {
+			errs = appendErrors(errs, err)
+		}
+	}
+
+	if c.NrfHeartbeatInterval < 0 {
+		errs = append(errs, fmt.Errorf("nrfHeartbeatInterval: %d should not be negative", c.NrfHeartbeatInterval))
+	}
+	if c.NrfRegisterMaxAttempts < 0 {
+		errs = append(errs, fmt.Errorf("nrfRegisterMaxAttempts: %d should not be negative", c.NrfRegisterMaxAttempts))
+	}
+	if c.NrfRegisterRetryDelay < 0 {
+		errs = append(errs, fmt.Errorf("nrfRegisterRetryDelay: %d should not be negative", c.NrfRegisterRetryDelay))
+	}
+
+	if c.Probes != nil && c.Probes.MongoPingTimeout < 0 {
+		errs = append(errs, fmt.Errorf("probes mongoPingTimeout: %d should not be negative", c.Probes.MongoPingTimeout))
+	}
+
+	if c.Probes != nil && c.Probes.HealthDetailTimeout < 0 {
+		errs = append(errs, fmt.Errorf("probes healthDetailTimeout: %d should not be negative", c.Probes.HealthDetailTimeout))
+	}
+
+	if c.Provisioning != nil && c.Provisioning.MaxBatchSize < 0 {
+		errs = append(errs, fmt.Errorf("provisioning maxBatchSize: %d should not be negative", c.Provisioning.MaxBatchSize))
+	}
+
+	if c.BatchRead != nil && c.BatchRead.MaxSupis < 0 {
+		errs = append(errs, fmt.Errorf("batchRead maxSupis: %d should not be negative", c.BatchRead.MaxSupis))
+	}
+
+	if c.Mongodb != nil {
+		if _, err := c.Mongodb.validate(); err != nil {
+			errs = appendErrors(errs, err)
+		}
+	} else if c.DbConnectorType == "mongodb" {
+		errs = append(errs, fmt.Errorf("mongodb: should be provided for dbConnectorType %s", c.DbConnectorType))
+	}
+
+	if c.Availability != nil {
+		if _, err := c.Availability.validate(); err != nil {
+			errs = appendErrors(errs, err)
+		}
+	}
+
+	if c.Subscriptions != nil {
+		if _, err := c.Subscriptions.validate(); err != nil {
+			errs = appendErrors(errs, err)
+		}
+	}
+
+	if _, err := validateAllowedNfTypes(c.AllowedNfTypes); err != nil {
+		errs = appendErrors(errs, err)
+	}
+
+	if _, err := validateSupportedFeatures(c.SupportedFeatures); err != nil {
+		errs = appendErrors(errs, err)
+	}
+
+	if c.Pagination != nil {
+		if _, err := c.Pagination.validate(); err != nil {
+			errs = appendErrors(errs, err)
+		}
+	}
+
+	if c.Debug != nil && c.Debug.Pprof {
+		if _, err := c.Debug.validate(c.Sbi); err != nil {
+			errs = appendErrors(errs, err)
+		}
+	}
+
+	if c.Audit != nil {
+		if _, err := c.Audit.validate(); err != nil {
+			errs = appendErrors(errs, err)
+		}
+	}
+
+	if c.Admin != nil {
+		if _, err := c.Admin.validate(c.Sbi, c.Debug); err != nil {
+			errs = appendErrors(errs, err)
+		}
+	}
+
+	if c.AuthSubsCache != nil {
+		if _, err := c.AuthSubsCache.validate(); err != nil {
+			errs = appendErrors(errs, err)
+		}
+	}
+
+	if c.NfProfile != nil {
+		if _, err := c.NfProfile.validate(); err != nil {
+			errs = appendErrors(errs, err)
+		}
+	}
+
+	if c.Sbi != nil {
+		if c.Sbi.Tls != nil {
+			if err := c.Sbi.Tls.validateClientAuth(); err != nil {
+				errs = appendErrors(errs, err)
+			}
+			if _, err := c.Sbi.Tls.validateVersions(); err != nil {
+				errs = appendErrors(errs, err)
+			}
+		}
+		for _, binding := range c.Sbi.Bindings {
+			if binding.Tls == nil {
+				continue
+			}
+			if err := binding.Tls.validateClientAuth(); err != nil {
+				errs = appendErrors(errs, err)
+			}
+			if _, err := binding.Tls.validateVersions(); err != nil {
+				errs = appendErrors(errs, err)
+			}
+		}
+	}
+
+	if c.Sbi != nil {
+		if _, err := c.Sbi.validateRequestBodyLimits(); err != nil {
+			errs = appendErrors(errs, err)
+		}
+	}
+
+	if c.Sbi != nil {
+		if _, err := c.Sbi.validateRequestTimeouts(); err != nil {
+			errs = appendErrors(errs, err)
+		}
+	}
+
+	if c.Sbi != nil && c.Sbi.Timeouts != nil {
+		if _, err := c.Sbi.Timeouts.validate(); err != nil {
+			errs = appendErrors(errs, err)
+		}
+	}
+
+	if c.Sbi != nil && c.Sbi.RateLimit != nil {
+		if _, err := c.Sbi.RateLimit.validate(); err != nil {
+			errs = appendErrors(errs, err)
+		}
+	}
+
+	if c.Sbi != nil && c.Sbi.Compression != nil && c.Sbi.Compression.MinSize < 0 {
+		errs = append(errs, fmt.Errorf("sbi compression minSize: %d should not be negative", c.Sbi.Compression.MinSize))
+	}
+
+	if c.Sbi != nil && c.Sbi.Cors != nil {
+		if _, err := c.Sbi.Cors.validate(); err != nil {
+			errs = appendErrors(errs, err)
+		}
+	}
+
+	if c.Sbi != nil && c.Sbi.UnixSocket != nil {
+		if _, err := c.Sbi.UnixSocket.validate(); err != nil {
+			errs = appendErrors(errs, err)
+		}
+	}
+
+	if c.Sbi != nil && c.Sbi.OAuth != nil && c.Sbi.OAuth.Enable && !c.Sbi.OAuth.SkipVerification &&
+		c.NrfCertPem == "" {
+		errs = append(errs, fmt.Errorf("sbi oauth: nrfCertPem should be provided to verify the access tokens"))
+	}
+
+	if c.Metrics != nil {
+		if _, err := c.Metrics.validate(); err != nil {
+			errs = appendErrors(errs, err)
+		}
+
+		if c.Sbi != nil && c.Metrics.Port == c.Sbi.Port && c.Sbi.BindingIPv4 == c.Metrics.BindingIPv4 {
+			errs = append(errs, fmt.Errorf("sbi and metrics bindings IPv4: %s and port: %d cannot be the same, "+
+				"please provide at least another port for the metrics", c.Sbi.BindingIPv4, c.Sbi.Port))
+		}
+	}
+
+	if len(errs) > 0 {
+		return false, error(errs)
+	}
+	return true, nil
+}
+
+type Sbi struct {
+	Scheme       string `yaml:"scheme" valid:"scheme,required"`
+	RegisterIPv4 string `yaml:"registerIPv4,omitempty" valid:"host,optional"` // IP that is registered at NRF.
+	RegisterIPv6 string `yaml:"registerIPv6,omitempty" valid:"ipv6,optional"` // IPv6 that is registered at NRF.
+	BindingIPv4  string `yaml:"bindingIPv4,omitempty" valid:"host,optional"`  // IP used to run the server in the node.
+	// IPv6 used to run the server in the node, takes precedence over bindingIPv4 when present.
+	// An empty value or "::" listens on the dual-stack wildcard address.
+	BindingIPv6 *string `yaml:"bindingIPv6,omitempty" valid:"ipv6,optional"`
+	// Host used to run the server in the node, an IPv4 or IPv6 literal or a hostname, takes precedence over
+	// bindingIPv4 and bindingIPv6 when present. "0.0.0.0" and "::" listen on all the addresses.
+	BindingAddr string `yaml:"bindingAddr,omitempty" valid:"optional"`
+	Port        int    `yaml:"port" valid:"port,required"`
+	Tls         *Tls   `yaml:"tls,omitempty" valid:"optional"`
+	// Additional listeners, the SBI is served on all of them instead of the single binding above when present.
+	Bindings   []*SbiBinding `yaml:"bindings,omitempty" valid:"optional"`
+	UnixSocket *UnixSocket   `yaml:"unixSocket,omitempty" valid:"optional"`
+	OAuth      *OAuth        `yaml:"oauth,omitempty" valid:"optional"`
+	Timeouts   *SbiTimeouts  `yaml:"timeouts,omitempty" valid:"optional"`
+	// Longest handling of a request in seconds, its context is canceled and it is answered 504 beyond it.
+	// Defaults to the write timeout.
+	RequestTimeout int `yaml:"requestTimeout,omitempty" valid:"optional"`
+	// Overrides of requestTimeout for the routes under a path, the longest matching prefix applies.
+	RequestTimeouts []*RequestTimeout `yaml:"requestTimeouts,omitempty" valid:"optional"`
+	// Largest request body accepted, in bytes.
+	MaxRequestBodyBytes int64 `yaml:"maxRequestBodyBytes,omitempty" valid:"optional"`
+	// Overrides of maxRequestBodyBytes for the routes under a path, the longest matching prefix applies.
+	RequestBodyLimits []*RequestBodyLimit `yaml:"requestBodyLimits,omitempty" valid:"optional"`
+	// Per consumer rate limit of the data repository routes, disabled when absent.
+	RateLimit *RateLimit `yaml:"rateLimit,omitempty" valid:"optional"`
+	// Gzip content-encoding of the requests and responses, disabled when absent.
+	Compression *Compression `yaml:"compression,omitempty" valid:"optional"`
+	// CORS headers for the browsers calling the SBI, e.g. a provisioning web UI, disabled when absent.
+	Cors *Cors `yaml:"cors,omitempty" valid:"optional"`
+	// Deprecated: use gracefulShutdownTimeout, which takes precedence. Time (in seconds) to wait for in-flight
+	// requests to drain on shutdown, the default one when 0.
+	ShutdownTimeout int `yaml:"shutdownTimeout,omitempty" valid:"optional,range(0|3600)"`
+}
+
+// Cors lets the pages of the allowed origins call the SBI from a browser. The preflight requests are answered
+// before the authorization check, the actual requests still need their access token.
+type Cors struct {
+	Enable bool `yaml:"enable" valid:"type(bool)"`
+	// e.g. https://webui.example.org, "*" allows any origin.
+	AllowedOrigins []string `yaml:"allowedOrigins,omitempty" valid:"optional"`
+	// Defaults to GET, PUT, POST, PATCH and DELETE.
+	AllowedMethods []string `yaml:"allowedMethods,omitempty" valid:"optional"`
+	// Defaults to Authorization, Content-Type, If-Match and If-None-Match.
+	AllowedHeaders []string `yaml:"allowedHeaders,omitempty" valid:"optional"`
+	// Seconds the browsers may cache a preflight answer, left to the browser when 0.
+	MaxAge int `yaml:"maxAge,omitempty" valid:"optional"`
+}
+
+func (c *Cors) validate() (bool, error) {
+	var errs govalidator.Errors
+	if c.Enable && len(c.AllowedOrigins) == 0 {
+		errs = append(errs, fmt.Errorf("sbi cors allowedOrigins: at least one origin should be given"))
+	}
+	for _, origin := range c.AllowedOrigins {
+		if origin == "" {
+			errs = append(errs, fmt.Errorf("sbi cors allowedOrigins: an origin should not be empty"))
+		}
+	}
+	if c.MaxAge < 0 {
+		errs = append(errs, fmt.Errorf("sbi cors maxAge: %d should not be negative", c.MaxAge))
+	}
+
+	if len(errs) > 0 {
+		return false, error(errs)
+	}
+	return true, nil
+}
+
+// Compression gzips the responses of the consumers accepting it, and accepts gzipped request bodies
+type Compression struct {
+	Enable bool `yaml:"enable" valid:"type(bool)"`
+	// Smallest response body compressed, in bytes.
+	MinSize int `yaml:"minSize,omitempty" valid:"optional"`
+}
+
+type RequestBodyLimit struct {
+	PathPrefix string `yaml:"pathPrefix" valid:"type(string),minstringlength(1),required"` // e.g. /nudr-dr/v2/application-data
+	MaxBytes   int64  `yaml:"maxBytes" valid:"required"`
+}
+
+type RequestTimeout struct {
+	// e.g. /nudr-dr/v2/subscription-data/bulk-provisioning
+	PathPrefix string `yaml:"pathPrefix" valid:"type(string),minstringlength(1),required"`
+	Timeout    int    `yaml:"timeout" valid:"required"` // seconds
+}
+
+// RateLimit is a token bucket per consumer, identified by the NF instance ID of its access token,
+// then by consumerHeader and last by its source IP
+type RateLimit struct {
+	Rate  float64 `yaml:"rate" valid:"required"`            // Requests per second.
+	Burst int     `yaml:"burst,omitempty" valid:"optional"` // Defaults to one second worth of requests.
+	// Number of consumers whose bucket is kept, the least recently seen are evicted beyond it.
+	MaxConsumers   int    `yaml:"maxConsumers,omitempty" valid:"optional"`
+	ConsumerHeader string `yaml:"consumerHeader,omitempty" valid:"optional"`
+}
+
+func (r *RateLimit) validate() (bool, error) {
+	var errs govalidator.Errors
+	if r.Rate <= 0 {
+		errs = append(errs, fmt.Errorf("sbi rateLimit rate: %v should be positive", r.Rate))
+	}
+	if r.Burst < 0 {
+		errs = append(errs, fmt.Errorf("sbi rateLimit burst: %d should not be negative", r.Burst))
+	}
+	if r.MaxConsumers < 0 {
+		errs = append(errs, fmt.Errorf("sbi rateLimit maxConsumers: %d should not be negative", r.MaxConsumers))
+	}
+
+	if len(errs) > 0 {
+		return false, error(errs)
+	}
+	return true, nil
+}
+
+// SbiTimeouts of the SBI http.Server in seconds, the defaults are used for the ones left to 0
+type SbiTimeouts struct {
+	ReadHeader int `yaml:"readHeader,omitempty" valid:"optional"`
+	Read       int `yaml:"read,omitempty" valid:"optional"`
+	// Also the default requestTimeout of the SBI.
+	Write int `yaml:"write,omitempty" valid:"optional"`
+	Idle  int `yaml:"idle,omitempty" valid:"optional"` // Applies to HTTP/1.1 keep-alive and HTTP/2 connections.
+}
+
+func (t *SbiTimeouts) validate() (bool, error) {
+	var errs govalidator.Errors
+	for name, timeout := range map[string]int{
+		"readHeader": t.ReadHeader,
+		"read":       t.Read,
+		"write":      t.Write,
+		"idle":       t.Idle,
+	} {
+		if timeout < 0 {
+			errs = append(errs, fmt.Errorf("sbi timeouts %s: %d should not be negative", name, timeout))
+		}
+	}
+
+	if len(errs) > 0 {
+		return false, error(errs)
+	}
+	return true, nil
+}
+
+// OAuth requires an NRF issued access token on every SBI request, even when the NRF does not ask for it
+type OAuth struct {
+	Enable bool `yaml:"enable" valid:"type(bool)"`
+	// Accept the requests without verifying their access token, only meant for test environments.
+	SkipVerification bool `yaml:"skipVerification,omitempty" valid:"type(bool)"`
+	// Require the additional scope of the data set, e.g. nudr-dr:policy-data, in every access token.
+	// Otherwise a token granting nudr-dr only is accepted on all the data sets.
+	StrictScopes bool `yaml:"strictScopes,omitempty" valid:"type(bool)"`
+}
+
+// UnixSocket serves the SBI in plain HTTP over a unix domain socket for co-located consumers
+type UnixSocket struct {
+	Path string `yaml:"path" valid:"type(string),minstringlength(1),required"`
+	Mode string `yaml:"mode,omitempty" valid:"optional"` // Permission bits of the socket file in octal, e.g. "0660".
+	// There is no TLS peer identity on the socket, so the authorization check can be turned off for it.
+	SkipAuthorization bool `yaml:"skipAuthorization,omitempty" valid:"type(bool)"`
+	// Only listen on the socket, the TCP bindings are not started.
+	DisableTcp bool `yaml:"disableTcp,omitempty" valid:"type(bool)"`
+}
+
+func (u *UnixSocket) validate() (bool, error) {
+	if u.Mode != "" {
+		if _, err := strconv.ParseUint(u.Mode, 8, 32); err != nil {
+			var errs govalidator.Errors
+			errs = append(errs, fmt.Errorf("unixSocket mode: %s should be an octal permission", u.Mode))
+			return false, error(errs)
+		}
+	}
+	return true, nil
+}
+
+func (u *UnixSocket) GetMode() os.FileMode {
+	if u.Mode == "" {
+		return UdrSbiDefaultUnixSocketMode
+	}
+	mode, err := strconv.ParseUint(u.Mode, 8, 32)
+	if err != nil {
+		return UdrSbiDefaultUnixSocketMode
+	}
+	return os.FileMode(mode)
+}
+
+type SbiBinding struct {
+	BindingIP  string `yaml:"bindingIP" valid:"host,required"`
+	RegisterIP string `yaml:"registerIP,omitempty" valid:"host,optional"` // IP registered at NRF, defaults to bindingIP.
+	Port       int    `yaml:"port" valid:"port,required"`
+	Scheme     string `yaml:"scheme" valid:"scheme,required"`
+	Tls        *Tls   `yaml:"tls,omitempty" valid:"optional"`
+	Advertised bool   `yaml:"advertised,omitempty" valid:"type(bool)"` // Whether the endpoint is put in the NF profile.
+}
+
+func (b *SbiBinding) GetBindingAddr() string {
+	return net.JoinHostPort(b.BindingIP, strconv.Itoa(b.Port))
+}
+
+func (b *SbiBinding) GetRegisterIP() string {
+	if b.RegisterIP != "" {
+		return b.RegisterIP
+	}
+	return b.BindingIP
+}
+
+type Tls struct {
+	Pem string `yaml:"pem,omitempty" valid:"type(string),minstringlength(1),required"`
+	Key string `yaml:"key,omitempty" valid:"type(string),minstringlength(1),required"`
+	// CA bundle used to verify client certificates, mutual TLS is enforced on the SBI when present.
+	ClientCAs string `yaml:"clientCAs,omitempty" valid:"type(string),optional"`
+	// One of none, request or require-and-verify, defaults to require-and-verify when clientCAs is given.
+	ClientAuth string `yaml:"clientAuth,omitempty" valid:"in(none|request|require-and-verify),optional"`
+	// Lowest TLS version accepted, 1.2 or 1.3, defaults to 1.2.
+	MinVersion string `yaml:"minVersion,omitempty" valid:"in(1.2|1.3),optional"`
+	// TLS 1.2 cipher suites by their IANA name, the Go defaults when empty. TLS 1.3 suites are not configurable.
+	CipherSuites []string `yaml:"cipherSuites,omitempty" valid:"optional"`
+}
+
+func (t *Tls) GetClientAuth() string {
+	if t.ClientAuth != "" {
+		return t.ClientAuth
+	}
+	if t.ClientCAs != "" {
+		return UdrSbiClientAuthRequireAndVerify
+	}
+	return UdrSbiClientAuthNone
+}
+
+func (t *Tls) validateClientAuth() error {
+	if t.GetClientAuth() != UdrSbiClientAuthNone && t.ClientCAs == "" {
+		var errs govalidator.Errors
+		errs = append(errs, fmt.Errorf("tls clientAuth: %s requires clientCAs to be provided", t.ClientAuth))
+		return error(errs)
+	}
+	return nil
+}
+
+// GetMinVersion returns the crypto/tls value of minVersion
+func (t *Tls) GetMinVersion() uint16 {
+	if t.MinVersion == UdrSbiTlsVersion13 {
+		return tls.VersionTLS13
+	}
+	return tls.VersionTLS12
+}
+
+// GetCipherSuites returns the crypto/tls IDs of cipherSuites, nil for the Go defaults
+func (t *Tls) GetCipherSuites() []uint16 {
+	if len(t.CipherSuites) == 0 {
+		return nil
+	}
+	ids := make([]uint16, 0, len(t.CipherSuites))
+	for _, name := range t.CipherSuites {
+		if suite, ok := tlsCipherSuites()[name]; ok {
+			ids = append(ids, suite.ID)
+		}
+	}
+	return ids
+}
+
+// tlsCipherSuites are the TLS 1.2 cipher suites implemented by crypto/tls and considered secure, by their name
+func tlsCipherSuites() map[string]*tls.CipherSuite {
+	suites := make(map[string]*tls.CipherSuite)
+	for _, suite := range tls.CipherSuites() {
+		for _, version := range suite.SupportedVersions {
+			if version == tls.VersionTLS12 {
+				suites[suite.Name] = suite
+				break
+			}
+		}
+	}
+	return suites
+}
+
+// validateVersions rejects the cipher suites unknown to crypto/tls, and the lists HTTP/2 cannot be served
+// with (RFC 7540 section 9.2.2) or which have no effect with a TLS 1.3 minimum version
+func (t *Tls) validateVersions() (bool, error) {
+	if len(t.CipherSuites) == 0 {
+		return true, nil
+	}
+
+	var errs govalidator.Errors
+	if t.GetMinVersion() == tls.VersionTLS13 {
+		errs = append(errs, fmt.Errorf("tls cipherSuites: not configurable with minVersion %s", t.MinVersion))
+		return false, error(errs)
+	}
+
+	suites := tlsCipherSuites()
+	http2Capable := false
+	for _, name := range t.CipherSuites {
+		if _, ok := suites[name]; !ok {
+			errs = append(errs, fmt.Errorf("tls cipherSuites: unknown cipher suite %s, valid: %s",
+				name, strings.Join(slices.Sorted(maps.Keys(suites)), ", ")))
+			continue
+		}
+		if name == "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256" || name == "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256" {
+			http2Capable = true
+		}
+	}
+	if len(errs) == 0 && !http2Capable {
+		errs = append(errs, fmt.Errorf("tls cipherSuites: HTTP/2 requires TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 "+
+			"or TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"))
+	}
+
+	if len(errs) > 0 {
+		return false, error(errs)
+	}
+	return true, nil
+}
+
+func (t *Tls) validate() (bool, error) {
+	result, err := govalidator.ValidateStruct(t)
+	return result, appendInvalid(err)
+}
+
+type Metrics struct {
+	Enable      bool   `yaml:"enable" valid:"optional"`
+	Scheme      string `yaml:"scheme" valid:"required,scheme"`
+	BindingIPv4 string `yaml:"bindingIPv4,omitempty" valid:"required,host"` // IP used to run the server in the node.
+	Port        int    `yaml:"port,omitempty" valid:"optional,port"`
+	Tls         *Tls   `yaml:"tls,omitempty" valid:"optional"`
+	Namespace   string `yaml:"namespace" valid:"optional"`
+}
+
+// This function is the mirror of the SBI one, I decided not to factor the code as it could in the future diverge.
+// And it will reduce the cognitive overload when reading the function by not hiding the logic elsewhere.
+func (m *Metrics) validate() (bool, error) {
+	var errs govalidator.Errors
+
+	if tls := m.Tls; tls != nil {
+		if _, err := tls.validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if _, err := govalidator.ValidateStruct(m); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 0 {
+		return false, error(errs)
+	}
+	return true, nil
+}
+
+type Mongodb struct {
+	Name string `yaml:"name" valid:"type(string),required"`
+	Url  string `yaml:"url" valid:"requrl,required"`
+	// Connection pool of the driver, 0 keeps the driver default. The options given here override the ones of url.
+	MaxPoolSize     int `yaml:"maxPoolSize,omitempty" valid:"optional"`
+	MinPoolSize     int `yaml:"minPoolSize,omitempty" valid:"optional"`
+	MaxConnIdleTime int `yaml:"maxConnIdleTime,omitempty" valid:"optional"` // seconds
+	// Connection attempts on startup, the delay between two of them doubles from connectRetryDelay.
+	ConnectMaxAttempts int `yaml:"connectMaxAttempts,omitempty" valid:"optional"`
+	ConnectRetryDelay  int `yaml:"connectRetryDelay,omitempty" valid:"optional"` // milliseconds
+	// Leave the indexes to the operator instead of creating them on startup.
+	SkipIndexCreation bool `yaml:"skipIndexCreation,omitempty" valid:"type(bool)"`
+}
+
+func (m *Mongodb) validate() (bool, error) {
+	var errs govalidator.Errors
+	if m.MaxPoolSize < 0 {
+		errs = append(errs, fmt.Errorf("mongodb maxPoolSize: %d should not be negative", m.MaxPoolSize))
+	}
+	if m.MinPoolSize < 0 {
+		errs = append(errs, fmt.Errorf("mongodb minPoolSize: %d should not be negative", m.MinPoolSize))
+	}
+	if m.MaxConnIdleTime < 0 {
+		errs = append(errs, fmt.Errorf("mongodb maxConnIdleTime: %d should not be negative", m.MaxConnIdleTime))
+	}
+	if m.ConnectMaxAttempts < 0 {
+		errs = append(errs, fmt.Errorf("mongodb connectMaxAttempts: %d should not be negative", m.ConnectMaxAttempts))
+	}
+	if m.ConnectRetryDelay < 0 {
+		errs = append(errs, fmt.Errorf("mongodb connectRetryDelay: %d should not be negative", m.ConnectRetryDelay))
+	}
+	maxPoolSize := m.MaxPoolSize
+	if maxPoolSize == 0 {
+		maxPoolSize = UdrMongodbDefaultMaxPoolSize
+	}
+	if m.MinPoolSize > maxPoolSize {
+		errs = append(errs, fmt.Errorf("mongodb minPoolSize: %d should not exceed maxPoolSize: %d",
+			m.MinPoolSize, maxPoolSize))
+	}
+
+	if len(errs) > 0 {
+		return false, error(errs)
+	}
+	return true, nil
+}
+
+// appendErrors appends the errors listed by err, one by one when it is a govalidator.Errors
+func appendErrors(errs govalidator.Errors, err error) govalidator.Errors {
+	var validErrs govalidator.Errors
+	if errors.As(err, &validErrs) {
+		return append(errs, validErrs.Errors()...)
+	}
+	return append(errs, err)
+}
+
+func appendInvalid(err error) error {
+	var errs govalidator.Errors
+
+	if err == nil {
+		return nil
+	}
+
+	es := err.(govalidator.Errors).Errors()
+	for _, e := range es {
+		errs = append(errs, fmt.Errorf("invalid %w", e))
+	}
+
+	return error(errs)
+}
+
+func (c *Config) GetVersion() string {
+	c.RWMutex.RLock()
+	defer c.RWMutex.RUnlock()
+
+	if c.Info.Version != "" {
+		return c.Info.Version
+	}
+	return ""
+}
+
+func (c *Config) SetLogEnable(enable bool) {
+	c.RWMutex.Lock()
+	defer c.RWMutex.Unlock()
+
+	if c.Logger == nil {
+		logger.CfgLog.Warnf("Logger should not be nil")
+		c.Logger = &Logger{
+			Enable: enable,
+			Level:  "info",
+		}
+	} else {
+		c.Logger.Enable = enable
+	}
+}
+
+func (c *Config) SetLogLevel(level string) {
+	c.RWMutex.Lock()
+	defer c.RWMutex.Unlock()
+
+	if c.Logger == nil {
+		logger.CfgLog.Warnf("Logger should not be nil")
+		c.Logger = &Logger{
+			Level: level,
+		}
+	} else {
+		c.Logger.Level = level
+	}
+}
+
+func (c *Config) SetLogReportCaller(reportCaller bool) {
+	c.RWMutex.Lock()
+	defer c.RWMutex.Unlock()
+
+	if c.Logger == nil {
+		logger.CfgLog.Warnf("Logger should not be nil")
+		c.Logger = &Logger{
+			Level:        "info",
+			ReportCaller: reportCaller,
+		}
+	} else {
+		c.Logger.ReportCaller = reportCaller
+	}
+}
+
+func (c *Config) GetLogEnable() bool {
+	c.RWMutex.RLock()
+	defer c.RWMutex.RUnlock()
+	if c.Logger == nil {
+		logger.CfgLog.Warnf("Logger should not be nil")
+		return false
+	}
+	return c.Logger.Enable
+}
+
+func (c *Config) GetLogLevel() string {
+	c.RWMutex.RLock()
+	defer c.RWMutex.RUnlock()
+	if c.Logger == nil {
+		logger.CfgLog.Warnf("Logger should not be nil")
+		return "info"
+	}
+	return c.Logger.Level
+}
+
+func (c *Config) IsLogAnonymized() bool {
+	c.RWMutex.RLock()
+	defer c.RWMutex.RUnlock()
+	return c.Logger != nil && c.Logger.Anonymize
+}
+
+func (c *Config) GetAccessLogFormat() string {
+	c.RWMutex.RLock()
+	defer c.RWMutex.RUnlock()
+	if c.Logger == nil || c.Logger.AccessLogFormat == "" {
+		return UdrDefaultAccessLogFormat
+	}
+	return c.Logger.AccessLogFormat
+}
+
+// GetLogCategoryLevels returns the levels of the log categories set apart from the level of the logger
+func (c *Config) GetLogCategoryLevels() map[string]string {
+	c.RWMutex.RLock()
+	defer c.RWMutex.RUnlock()
+	if c.Logger == nil {
+		return nil
+	}
+	return maps.Clone(c.Logger.Levels)
+}
+
+func (c *Config) GetLogReportCaller() bool {
+	c.RWMutex.RLock()
+	defer c.RWMutex.RUnlock()
+	if c.Logger == nil {
+		logger.CfgLog.Warnf("Logger should not be nil")
+		return false
+	}
+	return c.Logger.ReportCaller
+}
+
+func (c *Config) GetCertPemPath() string {
+	c.RLock()
+	defer c.RUnlock()
+	return c.Configuration.Sbi.Tls.Pem
+}
+
+func (c *Config) GetCertKeyPath() string {
+	c.RLock()
+	defer c.RUnlock()
+	return c.Configuration.Sbi.Tls.Key
+}
+
+// GetSbiClientTls returns the TLS settings of the outbound SBI requests, those of sbi.tls,
+// and false when neither minVersion nor cipherSuites is configured
+func (c *Config) GetSbiClientTls() (minVersion uint16, cipherSuites []uint16, ok bool) {
+	c.RLock()
+	defer c.RUnlock()
+
+	tlsCfg := c.Configuration.Sbi.Tls
+	if tlsCfg == nil || (tlsCfg.MinVersion == "" && len(tlsCfg.CipherSuites) == 0) {
+		return 0, nil, false
+	}
+	return tlsCfg.GetMinVersion(), tlsCfg.GetCipherSuites(), true
+}
+
+// GetSbiBindings returns every TCP listener of the SBI server, falling back to the single top-level binding
+// which is always advertised when no bindings list is configured, and none when only the unix socket is used
+func (c *Config) GetSbiBindings() []SbiBinding {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.Configuration.Sbi.getBindings()
+}
+
+func (s *Sbi) getBindings() []SbiBinding {
+	if s.UnixSocket != nil && s.UnixSocket.DisableTcp {
+		return nil
+	}
+	if len(s.Bindings) == 0 {
+		return []SbiBinding{
+			{
+				BindingIP:  s.getBindingIP(),
+				Port:       s.Port,
+				Scheme:     s.Scheme,
+				Tls:        s.Tls,
+				Advertised: true,
+			},
+		}
+	}
+
+	bindings := make([]SbiBinding, 0, len(s.Bindings))
+	for _, binding := range s.Bindings {
+		bindings = append(bindings, *binding)
+	}
+	return bindings
+}
+
+func (s *Sbi) validateRequestBodyLimits() (bool, error) {
+	var errs govalidator.Errors
+
+	if s.MaxRequestBodyBytes < 0 {
+		errs = append(errs, fmt.Errorf("sbi maxRequestBodyBytes: %d should not be negative", s.MaxRequestBodyBytes))
+	}
+	for _, limit := range s.RequestBodyLimits {
+		if !strings.HasPrefix(limit.PathPrefix, "/") {
+			errs = append(errs, fmt.Errorf("sbi requestBodyLimits pathPrefix: %s should start with /", limit.PathPrefix))
+		}
+		if limit.MaxBytes <= 0 {
+			errs = append(errs, fmt.Errorf("sbi requestBodyLimits maxBytes: %d should be positive", limit.MaxBytes))
+		}
+	}
+
+	if len(errs) > 0 {
+		return false, error(errs)
+	}
+	return true, nil
+}
+
+func (s *Sbi) validateRequestTimeouts() (bool, error) {
+	var errs govalidator.Errors
+
+	if s.RequestTimeout < 0 {
+		errs = append(errs, fmt.Errorf("sbi requestTimeout: %d should not be negative", s.RequestTimeout))
+	}
+	for _, timeout := range s.RequestTimeouts {
+		if !strings.HasPrefix(timeout.PathPrefix, "/") {
+			errs = append(errs, fmt.Errorf("sbi requestTimeouts pathPrefix: %s should start with /", timeout.PathPrefix))
+		}
+		if timeout.Timeout <= 0 {
+			errs = append(errs, fmt.Errorf("sbi requestTimeouts timeout: %d should be positive", timeout.Timeout))
+		}
+	}
+
+	if len(errs) > 0 {
+		return false, error(errs)
+	}
+	return true, nil
+}
+
+// validateBindingAddrs refuses the listen addresses that net.Listen could not resolve to a host
+func (s *Sbi) validateBindingAddrs() (bool, error) {
+	var errs govalidator.Errors
+	for i, binding := range s.getBindings() {
+		field := "sbi bindingAddr"
+		if len(s.Bindings) > 0 {
+			field = fmt.Sprintf("sbi bindings[%d] bindingIP", i)
+		}
+		host, _, err := net.SplitHostPort(binding.GetBindingAddr())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s is not a valid listen address: %w", field,
+				binding.GetBindingAddr(), err))
+			continue
+		}
+		if net.ParseIP(host) == nil && !govalidator.IsDNSName(host) {
+			errs = append(errs, fmt.Errorf("%s: %q should be an IPv4 or IPv6 address or a hostname", field, host))
+		}
+	}
+
+	if len(errs) > 0 {
+		return false, error(errs)
+	}
+	return true, nil
+}
+
+// validateSchemes checks that the https listeners have their certificate and key
+func (s *Sbi) validateSchemes() (bool, error) {
+	var errs govalidator.Errors
+	for i, binding := range s.getBindings() {
+		field := "sbi tls"
+		if len(s.Bindings) > 0 {
+			field = fmt.Sprintf("sbi bindings[%d] tls", i)
+		}
+		if binding.Scheme == "https" && binding.Tls == nil {
+			errs = append(errs, fmt.Errorf("%s: pem and key should be provided for scheme https", field))
+		}
+	}
+
+	if len(errs) > 0 {
+		return false, error(errs)
+	}
+	return true, nil
+}
+
+func (s *Sbi) getBindingIP() string {
+	if s.BindingAddr != "" {
+		// An IPv6 literal is accepted with the brackets of a URL too
+		return strings.TrimSuffix(strings.TrimPrefix(s.BindingAddr, "["), "]")
+	}
+	host := s.BindingIPv4
+	if s.BindingIPv6 != nil {
+		host = *s.BindingIPv6
+		if host == "" {
+			host = "::"
+		}
+	}
+	return host
+}
+
+// GetSbiTimeouts returns the timeouts of the SBI http.Server with the defaults applied
+func (c *Config) GetSbiTimeouts() (readHeader, read, write, idle time.Duration) {
+	c.RLock()
+	defer c.RUnlock()
+
+	timeouts := SbiTimeouts{}
+	if c.Configuration != nil && c.Configuration.Sbi != nil && c.Configuration.Sbi.Timeouts != nil {
+		timeouts = *c.Configuration.Sbi.Timeouts
+	}
+
+	withDefault := func(timeout, defaultTimeout int) time.Duration {
+		if timeout > 0 {
+			return time.Duration(timeout) * time.Second
+		}
+		return time.Duration(defaultTimeout) * time.Second
+	}
+	return withDefault(timeouts.ReadHeader, UdrSbiDefaultReadHeaderTimeout),
+		withDefault(timeouts.Read, UdrSbiDefaultReadTimeout),
+		withDefault(timeouts.Write, UdrSbiDefaultWriteTimeout),
+		withDefault(timeouts.Idle, UdrSbiDefaultIdleTimeout)
+}
+
+// GetSbiRequestTimeout returns the longest handling of a request to the path
+func (c *Config) GetSbiRequestTimeout(path string) time.Duration {
+	_, _, timeout, _ := c.GetSbiTimeouts()
+
+	c.RLock()
+	defer c.RUnlock()
+	if c.Configuration == nil || c.Configuration.Sbi == nil {
+		return timeout
+	}
+
+	sbi := c.Configuration.Sbi
+	if sbi.RequestTimeout > 0 {
+		timeout = time.Duration(sbi.RequestTimeout) * time.Second
+	}
+	matched := ""
+	for _, override := range sbi.RequestTimeouts {
+		if strings.HasPrefix(path, override.PathPrefix) && len(override.PathPrefix) > len(matched) {
+			matched = override.PathPrefix
+			timeout = time.Duration(override.Timeout) * time.Second
+		}
+	}
+	return timeout
+}
+
+// GetSbiMaxRequestBodyBytes returns the body size limit of the request path
+func (c *Config) GetSbiMaxRequestBodyBytes(path string) int64 {
+	c.RLock()
+	defer c.RUnlock()
+
+	maxBytes := int64(UdrSbiDefaultMaxRequestBodyBytes)
+	if c.Configuration == nil || c.Configuration.Sbi == nil {
+		return maxBytes
+	}
+
+	sbi := c.Configuration.Sbi
+	if sbi.MaxRequestBodyBytes > 0 {
+		maxBytes = sbi.MaxRequestBodyBytes
+	}
+	matched := ""
+	for _, limit := range sbi.RequestBodyLimits {
+		if strings.HasPrefix(path, limit.PathPrefix) && len(limit.PathPrefix) > len(matched) {
+			matched = limit.PathPrefix
+			maxBytes = limit.MaxBytes
+		}
+	}
+	return maxBytes
+}
+
+// GetSbiCompression returns whether gzip is enabled and the smallest response body compressed
+func (c *Config) GetSbiCompression() (enabled bool, minSize int) {
+	c.RLock()
+	defer c.RUnlock()
+	if c.Configuration == nil || c.Configuration.Sbi == nil || c.Configuration.Sbi.Compression == nil ||
+		!c.Configuration.Sbi.Compression.Enable {
+		return false, 0
+	}
+	if minSize = c.Configuration.Sbi.Compression.MinSize; minSize == 0 {
+		minSize = UdrSbiDefaultCompressionMinSize
+	}
+	return true, minSize
+}
+
+// GetSbiCors returns nil when CORS is disabled, otherwise a copy with the defaults applied
+func (c *Config) GetSbiCors() *Cors {
+	c.RLock()
+	defer c.RUnlock()
+
+	if c.Configuration == nil || c.Configuration.Sbi == nil || c.Configuration.Sbi.Cors == nil ||
+		!c.Configuration.Sbi.Cors.Enable {
+		return nil
+	}
+
+	cors := *c.Configuration.Sbi.Cors
+	cors.AllowedOrigins = slices.Clone(cors.AllowedOrigins)
+	if cors.AllowedMethods = slices.Clone(cors.AllowedMethods); len(cors.AllowedMethods) == 0 {
+		cors.AllowedMethods = []string{
+			http.MethodGet, http.MethodPut, http.MethodPost, http.MethodPatch, http.MethodDelete,
+		}
+	}
+	if cors.AllowedHeaders = slices.Clone(cors.AllowedHeaders); len(cors.AllowedHeaders) == 0 {
+		cors.AllowedHeaders = []string{"Authorization", "Content-Type", "If-Match", "If-None-Match"}
+	}
+	return &cors
+}
+
+// GetSbiRateLimit returns nil when the rate limit is disabled, otherwise a copy with the defaults applied
+func (c *Config) GetSbiRateLimit() *RateLimit {
+	c.RLock()
+	defer c.RUnlock()
+
+	if c.Configuration == nil || c.Configuration.Sbi == nil || c.Configuration.Sbi.RateLimit == nil {
+		return nil
+	}
+
+	rateLimit := *c.Configuration.Sbi.RateLimit
+	if rateLimit.Burst == 0 {
+		rateLimit.Burst = int(math.Max(1, math.Ceil(rateLimit.Rate)))
+	}
+	if rateLimit.MaxConsumers == 0 {
+		rateLimit.MaxConsumers = UdrSbiDefaultRateLimitMaxConsumers
+	}
+	return &rateLimit
+}
+
+// GetAllowedNfTypes returns the NF types allowed to access the resource group, ok is false when the
+// group is open to any NF. The authentication-data falls back on the subscription-data it is part of.
+func (c *Config) GetAllowedNfTypes(resourceGroup string) (nfTypes []string, ok bool) {
+	c.RLock()
+	defer c.RUnlock()
+	if c.Configuration == nil {
+		return nil, false
+	}
+	if nfTypes, ok = c.Configuration.AllowedNfTypes[resourceGroup]; !ok &&
+		resourceGroup == UdrResourceGroupAuthenticationData {
+		nfTypes, ok = c.Configuration.AllowedNfTypes[UdrResourceGroupSubscriptionData]
+	}
+	return nfTypes, ok
+}
+
+// AreOAuth2ScopesStrict tells whether the access tokens should grant the additional scope of the data set
+func (c *Config) AreOAuth2ScopesStrict() bool {
+	c.RLock()
+	defer c.RUnlock()
+	return c.Configuration != nil && c.Configuration.Sbi != nil && c.Configuration.Sbi.OAuth != nil &&
+		c.Configuration.Sbi.OAuth.StrictScopes
+}
+
+func (c *Config) GetSbiUnixSocket() *UnixSocket {
+	c.RLock()
+	defer c.RUnlock()
+	return c.Configuration.Sbi.UnixSocket
+}
+
+// GetGracefulShutdownTimeout returns 0 when the server should wait indefinitely. The deprecated
+// sbi.shutdownTimeout applies when gracefulShutdownTimeout is not set.
+func (c *Config) GetGracefulShutdownTimeout() time.Duration {
+	c.RLock()
+	defer c.RUnlock()
+	if c.Configuration != nil && c.Configuration.GracefulShutdownTimeout != nil {
+		return time.Duration(*c.Configuration.GracefulShutdownTimeout) * time.Second
+	}
+	if c.Configuration != nil && c.Configuration.Sbi != nil && c.Configuration.Sbi.ShutdownTimeout > 0 {
+		return time.Duration(c.Configuration.Sbi.ShutdownTimeout) * time.Second
+	}
+	return UdrDefaultShutdownTimeout * time.Second
+}
+
+func (c *Config) GetNrfHeartbeatInterval() time.Duration {
+	c.RLock()
+	defer c.RUnlock()
+	if c.Configuration != nil && c.Configuration.NrfHeartbeatInterval > 0 {
+		return time.Duration(c.Configuration.NrfHeartbeatInterval) * time.Second
+	}
+	return UdrDefaultHeartbeatInterval * time.Second
+}
+
+// GetNrfRegisterRetry returns the registration attempts to the NRF on startup, 0 for no limit, and the delay
+// before the first retry
+func (c *Config) GetNrfRegisterRetry() (maxAttempts int, retryDelay time.Duration) {
+	c.RLock()
+	defer c.RUnlock()
+
+	retryDelay = UdrDefaultNrfRegisterRetryDelay * time.Millisecond
+	if c.Configuration == nil {
+		return 0, retryDelay
+	}
+	if c.Configuration.NrfRegisterRetryDelay > 0 {
+		retryDelay = time.Duration(c.Configuration.NrfRegisterRetryDelay) * time.Millisecond
+	}
+	return c.Configuration.NrfRegisterMaxAttempts, retryDelay
+}
+
+func (c *Config) IsNrfDeregistrationSkipped() bool {
+	c.RLock()
+	defer c.RUnlock()
+	return c.Configuration != nil && c.Configuration.SkipNrfDeregistration
+}
+
+func (c *Config) GetProbeMongoPingTimeout() time.Duration {
+	c.RLock()
+	defer c.RUnlock()
+	if c.Configuration != nil && c.Configuration.Probes != nil && c.Configuration.Probes.MongoPingTimeout > 0 {
+		return time.Duration(c.Configuration.Probes.MongoPingTimeout) * time.Millisecond
+	}
+	return UdrDefaultProbeMongoPingTimeout * time.Millisecond
+}
+
+func (c *Config) GetProbeHealthDetailTimeout() time.Duration {
+	c.RLock()
+	defer c.RUnlock()
+	if c.Configuration != nil && c.Configuration.Probes != nil && c.Configuration.Probes.HealthDetailTimeout > 0 {
+		return time.Duration(c.Configuration.Probes.HealthDetailTimeout) * time.Millisecond
+	}
+	return UdrDefaultProbeHealthDetailTimeout * time.Millisecond
+}
+
+func (c *Config) IsProbeNrfRegistrationCheckSkipped() bool {
+	c.RLock()
+	defer c.RUnlock()
+	return c.Configuration != nil && c.Configuration.Probes != nil && c.Configuration.Probes.SkipNrfRegistrationCheck
+}
+
+// GetMaxInflightRequests returns the in-flight requests beyond which the UDR is overloaded, 0 when unlimited
+func (c *Config) GetMaxInflightRequests() int {
+	c.RLock()
+	defer c.RUnlock()
+	if c.Configuration != nil && c.Configuration.Availability != nil {
+		return c.Configuration.Availability.MaxInflightRequests
+	}
+	return 0
+}
+
+func (c *Config) GetAvailabilityRetryAfter() time.Duration {
+	c.RLock()
+	defer c.RUnlock()
+	if c.Configuration != nil && c.Configuration.Availability != nil && c.Configuration.Availability.RetryAfter > 0 {
+		return time.Duration(c.Configuration.Availability.RetryAfter) * time.Second
+	}
+	return UdrDefaultRetryAfter * time.Second
+}
+
+func (c *Config) GetDataStoreCheckInterval() time.Duration {
+	c.RLock()
+	defer c.RUnlock()
+	if c.Configuration != nil && c.Configuration.Availability != nil &&
+		c.Configuration.Availability.DataStoreCheckInterval > 0 {
+		return time.Duration(c.Configuration.Availability.DataStoreCheckInterval) * time.Second
+	}
+	return UdrDefaultDataStoreCheckInterval * time.Second
+}
+
+// GetSupportedFeatures returns the features the service supports, the ones of the data sets by default for nudr-dr
+// and none for the other services
+func (c *Config) GetSupportedFeatures(service string) string {
+	c.RLock()
+	defer c.RUnlock()
+	if c.Configuration != nil {
+		if features, ok := c.Configuration.SupportedFeatures[service]; ok {
+			return features
+		}
+	}
+	if service == "nudr-dr" {
+		return UdrDrDefaultSupportedFeatures
+	}
+	return ""
+}
+
+// GetPageSizes returns the page-size used when the request gives none and the largest one accepted
+func (c *Config) GetPageSizes() (defaultPageSize, maxPageSize int) {
+	c.RLock()
+	defer c.RUnlock()
+
+	defaultPageSize, maxPageSize = UdrDefaultPageSize, UdrDefaultMaxPageSize
+	if c.Configuration == nil || c.Configuration.Pagination == nil {
+		return defaultPageSize, maxPageSize
+	}
+	pagination := c.Configuration.Pagination
+	if pagination.MaxPageSize > 0 {
+		maxPageSize = pagination.MaxPageSize
+	}
+	if pagination.DefaultPageSize > 0 {
+		defaultPageSize = pagination.DefaultPageSize
+	}
+	return min(defaultPageSize, maxPageSize), maxPageSize
+}
+
+func (c *Config) GetProvisioningMaxBatchSize() int {
+	c.RLock()
+	defer c.RUnlock()
+	if c.Configuration != nil && c.Configuration.Provisioning != nil && c.Configuration.Provisioning.MaxBatchSize > 0 {
+		return c.Configuration.Provisioning.MaxBatchSize
+	}
+	return UdrDefaultProvisioningMaxBatchSize
+}
+
+// GetBatchReadMaxSupis returns the most SUPIs a batch read may ask for
+func (c *Config) GetBatchReadMaxSupis() int {
+	c.RLock()
+	defer c.RUnlock()
+	if c.Configuration != nil && c.Configuration.BatchRead != nil && c.Configuration.BatchRead.MaxSupis > 0 {
+		return c.Configuration.BatchRead.MaxSupis
+	}
+	return UdrDefaultBatchReadMaxSupis
+}
+
+// GetMongodbUrl returns the url of the MongoDB with the connection pool options of the config
+func (c *Config) GetMongodbUrl() string {
+	c.RLock()
+	defer c.RUnlock()
+	if c.Configuration == nil || c.Configuration.Mongodb == nil {
+		return ""
+	}
+	mongodb := c.Configuration.Mongodb
+	if mongodb.MaxPoolSize == 0 && mongodb.MinPoolSize == 0 && mongodb.MaxConnIdleTime == 0 {
+		return mongodb.Url
+	}
+
+	mongoUrl, err := url.Parse(mongodb.Url)
+	if err != nil {
+		logger.CfgLog.Warnf("MongoDB url is invalid, connection pool options ignored: %+v", err)
+		return mongodb.Url
+	}
+	query := mongoUrl.Query()
+	if mongodb.MaxPoolSize > 0 {
+		query.Set("maxPoolSize", strconv.Itoa(mongodb.MaxPoolSize))
+	}
+	if mongodb.MinPoolSize > 0 {
+		query.Set("minPoolSize", strconv.Itoa(mongodb.MinPoolSize))
+	}
+	if mongodb.MaxConnIdleTime > 0 {
+		query.Set("maxIdleTimeMS", strconv.Itoa(mongodb.MaxConnIdleTime*1000))
+	}
+	// The driver requires the slash between the hosts and the options
+	if mongoUrl.Path == "" {
+		mongoUrl.Path = "/"
+	}
+	mongoUrl.RawQuery = query.Encode()
+	return mongoUrl.String()
+}
+
+// GetMongodbConnectRetry returns the connection attempts on startup and the delay before the first retry
+func (c *Config) GetMongodbConnectRetry() (maxAttempts int, retryDelay time.Duration) {
+	c.RLock()
+	defer c.RUnlock()
+
+	maxAttempts, retryDelay = UdrMongodbDefaultConnectMaxAttempts, UdrMongodbDefaultConnectRetryDelay*time.Millisecond
+	if c.Configuration == nil || c.Configuration.Mongodb == nil {
+		return maxAttempts, retryDelay
+	}
+	mongodb := c.Configuration.Mongodb
+	if mongodb.ConnectMaxAttempts > 0 {
+		maxAttempts = mongodb.ConnectMaxAttempts
+	}
+	if mongodb.ConnectRetryDelay > 0 {
+		retryDelay = time.Duration(mongodb.ConnectRetryDelay) * time.Millisecond
+	}
+	return maxAttempts, retryDelay
+}
+
+func (c *Config) IsMongodbIndexCreationSkipped() bool {
+	c.RLock()
+	defer c.RUnlock()
+	return c.Configuration != nil && c.Configuration.Mongodb != nil && c.Configuration.Mongodb.SkipIndexCreation
+}
+
+func (c *Config) IsDebugPprofEnabled() bool {
+	c.RLock()
+	defer c.RUnlock()
+	return c.Configuration != nil && c.Configuration.Debug != nil && c.Configuration.Debug.Pprof
+}
+
+func (c *Config) GetDebugBindAddr() string {
+	c.RLock()
+	defer c.RUnlock()
+	if c.Configuration != nil && c.Configuration.Debug != nil {
+		return c.Configuration.Debug.BindAddr
+	}
+	return ""
+}
+
+func (c *Config) IsAuditEnabled() bool {
+	c.RLock()
+	defer c.RUnlock()
+	return c.Configuration != nil && c.Configuration.Audit != nil && c.Configuration.Audit.Enable
+}
+
+// GetAuditRetention returns how long the audit records are kept for
+func (c *Config) GetAuditRetention() time.Duration {
+	c.RLock()
+	defer c.RUnlock()
+	retention := UdrDefaultAuditRetention
+	if c.Configuration != nil && c.Configuration.Audit != nil && c.Configuration.Audit.Retention > 0 {
+		retention = c.Configuration.Audit.Retention
+	}
+	return time.Duration(retention) * 24 * time.Hour
+}
+
+func (c *Config) GetAuditQueueSize() int {
+	c.RLock()
+	defer c.RUnlock()
+	if c.Configuration != nil && c.Configuration.Audit != nil && c.Configuration.Audit.QueueSize > 0 {
+		return c.Configuration.Audit.QueueSize
+	}
+	return UdrDefaultAuditQueueSize
+}
+
+// IsAuthSubsCacheEnabled tells whether the authentication subscriptions read are cached, they are not by default
+func (c *Config) IsAuthSubsCacheEnabled() bool {
+	c.RLock()
+	defer c.RUnlock()
+	return c.Configuration != nil && c.Configuration.AuthSubsCache != nil && c.Configuration.AuthSubsCache.Enable
+}
+
+func (c *Config) GetAuthSubsCacheSize() int {
+	c.RLock()
+	defer c.RUnlock()
+	if c.Configuration != nil && c.Configuration.AuthSubsCache != nil && c.Configuration.AuthSubsCache.Size > 0 {
+		return c.Configuration.AuthSubsCache.Size
+	}
+	return UdrDefaultAuthSubsCacheSize
+}
+
+// GetAuthSubsCacheTtl returns how long an authentication subscription stays cached
+func (c *Config) GetAuthSubsCacheTtl() time.Duration {
+	c.RLock()
+	defer c.RUnlock()
+	ttl := UdrDefaultAuthSubsCacheTtl
+	if c.Configuration != nil && c.Configuration.AuthSubsCache != nil && c.Configuration.AuthSubsCache.Ttl > 0 {
+		ttl = c.Configuration.AuthSubsCache.Ttl
+	}
+	return time.Duration(ttl) * time.Second
+}
+
+// GetSubscriptionSweepInterval returns how often the expired subscriptions are removed
+func (c *Config) GetSubscriptionSweepInterval() time.Duration {
+	c.RLock()
+	defer c.RUnlock()
+	interval := UdrDefaultSubscriptionSweepInterval
+	if c.Configuration != nil && c.Configuration.Subscriptions != nil &&
+		c.Configuration.Subscriptions.ExpirySweepInterval > 0 {
+		interval = c.Configuration.Subscriptions.ExpirySweepInterval
+	}
+	return time.Duration(interval) * time.Second
+}
+
+// GetNfProfile returns the fields of the NF profile set by the config, none are by default
+func (c *Config) GetNfProfile() NfProfile {
+	c.RLock()
+	defer c.RUnlock()
+	if c.Configuration == nil || c.Configuration.NfProfile == nil {
+		return NfProfile{}
+	}
+	nfProfile := *c.Configuration.NfProfile
+	nfProfile.PlmnList = slices.Clone(nfProfile.PlmnList)
+	return nfProfile
+}
+
+// GetAdminBindAddr returns the address of the admin listener, empty when there is none
+func (c *Config) GetAdminBindAddr() string {
+	c.RLock()
+	defer c.RUnlock()
+	if c.Configuration != nil && c.Configuration.Admin != nil {
+		return c.Configuration.Admin.BindAddr
+	}
+	return ""
 }
 
 func (c *Config) AreMetricsEnabled() bool {
@@ -0,0 +1,629 @@
+package factory
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_MongodbPoolSizes(t *testing.T) {
+	testCases := []struct {
+		name    string
+		mongodb Mongodb
+		valid   bool
+	}{
+		{"Default", Mongodb{}, true},
+		{"Min Below Max", Mongodb{MaxPoolSize: 50, MinPoolSize: 10, MaxConnIdleTime: 60}, true},
+		{"Min Above Max", Mongodb{MaxPoolSize: 10, MinPoolSize: 50}, false},
+		{"Min Above Default Max", Mongodb{MinPoolSize: UdrMongodbDefaultMaxPoolSize + 1}, false},
+		{"Negative Idle Time", Mongodb{MaxConnIdleTime: -1}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mongodb.Name, tc.mongodb.Url = "free5gc", "mongodb://127.0.0.1:27017"
+			cfg := &Config{
+				Info: &Info{Version: "1.1.0"},
+				Configuration: &Configuration{
+					Sbi:             &Sbi{Scheme: "http", BindingIPv4: "127.0.0.1", Port: 8000},
+					DbConnectorType: "mongodb",
+					Mongodb:         &tc.mongodb,
+					NrfUri:          "http://127.0.0.10:8000",
+				},
+				Logger: &Logger{Level: "info"},
+			}
+			valid, err := cfg.Validate()
+			require.Equal(t, tc.valid, valid, err)
+		})
+	}
+}
+
+func TestConfig_DbConnectorType(t *testing.T) {
+	testCases := []struct {
+		name            string
+		dbConnectorType DbType
+		mongodb         *Mongodb
+		valid           bool
+	}{
+		{"MongoDB", "mongodb", &Mongodb{Name: "free5gc", Url: "mongodb://127.0.0.1:27017"}, true},
+		{"MongoDB Not Provided", "mongodb", nil, false},
+		{"Memory", "memory", nil, true},
+		{"Unknown", "sql", nil, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{
+				Info: &Info{Version: "1.1.0"},
+				Configuration: &Configuration{
+					Sbi:             &Sbi{Scheme: "http", BindingIPv4: "127.0.0.1", Port: 8000},
+					DbConnectorType: tc.dbConnectorType,
+					Mongodb:         tc.mongodb,
+					NrfUri:          "http://127.0.0.10:8000",
+				},
+				Logger: &Logger{Level: "info"},
+			}
+			valid, err := cfg.Validate()
+			require.Equal(t, tc.valid, valid, err)
+		})
+	}
+}
+
+func TestConfig_GetMongodbUrl(t *testing.T) {
+	testCases := []struct {
+		name     string
+		mongodb  Mongodb
+		expected string
+	}{
+		{
+			name:     "No Pool Options",
+			mongodb:  Mongodb{Url: "mongodb://127.0.0.1:27017"},
+			expected: "mongodb://127.0.0.1:27017",
+		},
+		{
+			name:     "Pool Options",
+			mongodb:  Mongodb{Url: "mongodb://127.0.0.1:27017", MaxPoolSize: 200, MinPoolSize: 10, MaxConnIdleTime: 60},
+			expected: "mongodb://127.0.0.1:27017/?maxIdleTimeMS=60000&maxPoolSize=200&minPoolSize=10",
+		},
+		{
+			name:     "Override Url Options",
+			mongodb:  Mongodb{Url: "mongodb://127.0.0.1:27017/?maxPoolSize=5&replicaSet=rs0", MaxPoolSize: 200},
+			expected: "mongodb://127.0.0.1:27017/?maxPoolSize=200&replicaSet=rs0",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{Configuration: &Configuration{Mongodb: &tc.mongodb}}
+			require.Equal(t, tc.expected, cfg.GetMongodbUrl())
+		})
+	}
+}
+
+func TestConfig_AllowedNfTypes(t *testing.T) {
+	testCases := []struct {
+		name           string
+		allowedNfTypes map[string][]string
+		valid          bool
+	}{
+		{"Absent", nil, true},
+		{"Restricted Groups", map[string][]string{"authentication-data": {"UDM"}, "policy-data": {"PCF"}}, true},
+		{"Unknown Group", map[string][]string{"identity-data": {"UDM"}}, false},
+		{"No NF Type", map[string][]string{"policy-data": {}}, false},
+		{"Lower Case NF Type", map[string][]string{"policy-data": {"pcf"}}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{
+				Info: &Info{Version: "1.1.0"},
+				Configuration: &Configuration{
+					Sbi:             &Sbi{Scheme: "http", BindingIPv4: "127.0.0.1", Port: 8000},
+					DbConnectorType: "mongodb",
+					Mongodb:         &Mongodb{Name: "free5gc", Url: "mongodb://127.0.0.1:27017"},
+					NrfUri:          "http://127.0.0.10:8000",
+					AllowedNfTypes:  tc.allowedNfTypes,
+				},
+				Logger: &Logger{Level: "info"},
+			}
+			valid, err := cfg.Validate()
+			require.Equal(t, tc.valid, valid, err)
+		})
+	}
+}
+
+func TestConfig_SupportedFeatures(t *testing.T) {
+	testCases := []struct {
+		name              string
+		supportedFeatures map[string]string
+		valid             bool
+		drFeatures        string
+	}{
+		{"Absent", nil, true, UdrDrDefaultSupportedFeatures},
+		{"None", map[string]string{"nudr-dr": ""}, true, ""},
+		{"Configured", map[string]string{"nudr-dr": "0F", "nudr-group-id-map": "1"}, true, "0F"},
+		{"Unknown Service", map[string]string{"nudm-sdm": "1"}, false, UdrDrDefaultSupportedFeatures},
+		{"Not Hexadecimal", map[string]string{"nudr-dr": "1G"}, false, "1G"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{
+				Info: &Info{Version: "1.1.0"},
+				Configuration: &Configuration{
+					Sbi:               &Sbi{Scheme: "http", BindingIPv4: "127.0.0.1", Port: 8000},
+					DbConnectorType:   "mongodb",
+					Mongodb:           &Mongodb{Name: "free5gc", Url: "mongodb://127.0.0.1:27017"},
+					NrfUri:            "http://127.0.0.10:8000",
+					SupportedFeatures: tc.supportedFeatures,
+				},
+				Logger: &Logger{Level: "info"},
+			}
+			valid, err := cfg.Validate()
+			require.Equal(t, tc.valid, valid, err)
+			require.Equal(t, tc.drFeatures, cfg.GetSupportedFeatures("nudr-dr"))
+		})
+	}
+}
+
+func TestConfig_SbiBindingAddr(t *testing.T) {
+	ipv6 := "2001:db8::9"
+	testCases := []struct {
+		name     string
+		sbi      Sbi
+		expected string
+		valid    bool
+	}{
+		{"IPv4", Sbi{BindingIPv4: "127.0.0.9"}, "127.0.0.9:8000", true},
+		{"IPv6", Sbi{BindingIPv6: &ipv6}, "[2001:db8::9]:8000", true},
+		{"IPv4 Wildcard", Sbi{BindingAddr: "0.0.0.0"}, "0.0.0.0:8000", true},
+		{"IPv6 Wildcard", Sbi{BindingAddr: "::"}, "[::]:8000", true},
+		{"IPv6 Literal", Sbi{BindingAddr: "::1", BindingIPv4: "127.0.0.9"}, "[::1]:8000", true},
+		{"Bracketed IPv6 Literal", Sbi{BindingAddr: "[::1]"}, "[::1]:8000", true},
+		{"Hostname", Sbi{BindingAddr: "udr.free5gc.org"}, "udr.free5gc.org:8000", true},
+		{"Invalid Hostname", Sbi{BindingAddr: "udr free5gc"}, "", false},
+		{"Address With Port", Sbi{BindingAddr: "127.0.0.9:8000"}, "", false},
+		{"Invalid Additional Binding", Sbi{Bindings: []*SbiBinding{
+			{BindingIP: "127.0.0.9", Port: 8000, Scheme: "http"},
+			{BindingIP: "[::1]", Port: 8001, Scheme: "http"},
+		}}, "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.sbi.Scheme, tc.sbi.Port = "http", 8000
+			cfg := &Config{
+				Info: &Info{Version: "1.1.0"},
+				Configuration: &Configuration{
+					Sbi:             &tc.sbi,
+					DbConnectorType: "mongodb",
+					Mongodb:         &Mongodb{Name: "free5gc", Url: "mongodb://127.0.0.1:27017"},
+					NrfUri:          "http://127.0.0.10:8000",
+				},
+				Logger: &Logger{Level: "info"},
+			}
+			valid, err := cfg.Validate()
+			require.Equal(t, tc.valid, valid, err)
+			if tc.valid {
+				bindings := cfg.GetSbiBindings()
+				require.Len(t, bindings, 1)
+				require.Equal(t, tc.expected, bindings[0].GetBindingAddr())
+			}
+		})
+	}
+}
+
+func TestConfig_SbiTls(t *testing.T) {
+	testCases := []struct {
+		name        string
+		tls         Tls
+		errContains string
+	}{
+		{"Default", Tls{}, ""},
+		{"TLS 1.3", Tls{MinVersion: "1.3"}, ""},
+		{"Cipher Suites", Tls{MinVersion: "1.2", CipherSuites: []string{
+			"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+		}}, ""},
+		{"Unknown Version", Tls{MinVersion: "1.1"}, "MinVersion"},
+		{"Unknown Cipher Suite", Tls{CipherSuites: []string{
+			"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "TLS_RSA_WITH_RC4_128_SHA",
+		}}, "unknown cipher suite TLS_RSA_WITH_RC4_128_SHA, valid: "},
+		{"Cipher Suites With TLS 1.3", Tls{MinVersion: "1.3", CipherSuites: []string{
+			"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+		}}, "not configurable with minVersion 1.3"},
+		{"Without HTTP/2 Cipher Suite", Tls{CipherSuites: []string{
+			"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+		}}, "HTTP/2 requires"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.tls.Pem, tc.tls.Key = "cert/udr.pem", "cert/udr.key"
+			cfg := &Config{
+				Info: &Info{Version: "1.1.0"},
+				Configuration: &Configuration{
+					Sbi:             &Sbi{Scheme: "https", BindingIPv4: "127.0.0.9", Port: 8000, Tls: &tc.tls},
+					DbConnectorType: "mongodb",
+					Mongodb:         &Mongodb{Name: "free5gc", Url: "mongodb://127.0.0.1:27017"},
+					NrfUri:          "http://127.0.0.10:8000",
+				},
+				Logger: &Logger{Level: "info"},
+			}
+			valid, err := cfg.Validate()
+			if tc.errContains == "" {
+				require.True(t, valid, err)
+				minVersion, cipherSuites, ok := cfg.GetSbiClientTls()
+				require.Equal(t, tc.tls.MinVersion != "" || len(tc.tls.CipherSuites) > 0, ok)
+				if ok {
+					require.Equal(t, tc.tls.GetMinVersion(), minVersion)
+					require.Len(t, cipherSuites, len(tc.tls.CipherSuites))
+				}
+				return
+			}
+			require.False(t, valid)
+			require.ErrorContains(t, err, tc.errContains)
+		})
+	}
+}
+
+func TestConfig_SbiRequestTimeouts(t *testing.T) {
+	testCases := []struct {
+		name     string
+		sbi      Sbi
+		expected map[string]time.Duration
+		valid    bool
+	}{
+		{"Default", Sbi{}, map[string]time.Duration{
+			"/nudr-dr/v2/subscription-data/bulk-provisioning": UdrSbiDefaultWriteTimeout * time.Second,
+		}, true},
+		{"Default Write Timeout", Sbi{Timeouts: &SbiTimeouts{Write: 10}}, map[string]time.Duration{
+			"/nudr-dr/v2/subscription-data/bulk-provisioning": 10 * time.Second,
+		}, true},
+		{"Path Override", Sbi{RequestTimeout: 5, RequestTimeouts: []*RequestTimeout{
+			{PathPrefix: "/nudr-dr/v2/subscription-data", Timeout: 10},
+			{PathPrefix: "/nudr-dr/v2/subscription-data/bulk-provisioning", Timeout: 120},
+		}}, map[string]time.Duration{
+			"/nudr-dr/v2/policy-data/ues/imsi-208930000000001/am-data": 5 * time.Second,
+			"/nudr-dr/v2/subscription-data/imsi-208930000000001":       10 * time.Second,
+			"/nudr-dr/v2/subscription-data/bulk-provisioning":          120 * time.Second,
+		}, true},
+		{"Negative Timeout", Sbi{RequestTimeout: -1}, nil, false},
+		{"Relative Path", Sbi{RequestTimeouts: []*RequestTimeout{
+			{PathPrefix: "nudr-dr/v2/subscription-data", Timeout: 10},
+		}}, nil, false},
+		{"Zero Path Timeout", Sbi{RequestTimeouts: []*RequestTimeout{
+			{PathPrefix: "/nudr-dr/v2/subscription-data", Timeout: 0},
+		}}, nil, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.sbi.Scheme, tc.sbi.BindingIPv4, tc.sbi.Port = "http", "127.0.0.9", 8000
+			cfg := &Config{
+				Info: &Info{Version: "1.1.0"},
+				Configuration: &Configuration{
+					Sbi:             &tc.sbi,
+					DbConnectorType: "mongodb",
+					Mongodb:         &Mongodb{Name: "free5gc", Url: "mongodb://127.0.0.1:27017"},
+					NrfUri:          "http://127.0.0.10:8000",
+				},
+				Logger: &Logger{Level: "info"},
+			}
+			valid, err := cfg.Validate()
+			require.Equal(t, tc.valid, valid, err)
+			for path, timeout := range tc.expected {
+				require.Equal(t, timeout, cfg.GetSbiRequestTimeout(path), path)
+			}
+		})
+	}
+}
+
+func TestConfig_SbiCors(t *testing.T) {
+	testCases := []struct {
+		name  string
+		cors  *Cors
+		valid bool
+	}{
+		{"Default", &Cors{Enable: true, AllowedOrigins: []string{"https://webui.example.org"}}, true},
+		{"Disabled Without Origins", &Cors{}, true},
+		{"Without Origins", &Cors{Enable: true}, false},
+		{"Empty Origin", &Cors{Enable: true, AllowedOrigins: []string{""}}, false},
+		{"Negative Max Age", &Cors{Enable: true, AllowedOrigins: []string{"*"}, MaxAge: -1}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{
+				Info: &Info{Version: "1.1.0"},
+				Configuration: &Configuration{
+					Sbi:             &Sbi{Scheme: "http", BindingIPv4: "127.0.0.9", Port: 8000, Cors: tc.cors},
+					DbConnectorType: "mongodb",
+					Mongodb:         &Mongodb{Name: "free5gc", Url: "mongodb://127.0.0.1:27017"},
+					NrfUri:          "http://127.0.0.10:8000",
+				},
+				Logger: &Logger{Level: "info"},
+			}
+			valid, err := cfg.Validate()
+			require.Equal(t, tc.valid, valid, err)
+		})
+	}
+
+	cfg := &Config{Configuration: &Configuration{Sbi: &Sbi{Cors: &Cors{AllowedOrigins: []string{"*"}}}}}
+	require.Nil(t, cfg.GetSbiCors())
+	cfg.Configuration.Sbi.Cors.Enable = true
+	cors := cfg.GetSbiCors()
+	require.Equal(t, []string{"*"}, cors.AllowedOrigins)
+	require.Contains(t, cors.AllowedMethods, "PATCH")
+	require.Contains(t, cors.AllowedHeaders, "Authorization")
+	require.Nil(t, cfg.Configuration.Sbi.Cors.AllowedMethods, "the defaults are not written back to the config")
+}
+
+func TestConfig_NrfRegisterRetry(t *testing.T) {
+	testCases := []struct {
+		name                string
+		maxAttempts         int
+		retryDelay          int
+		expectedMaxAttempts int
+		expectedRetryDelay  time.Duration
+		valid               bool
+	}{
+		{"Default", 0, 0, 0, UdrDefaultNrfRegisterRetryDelay * time.Millisecond, true},
+		{"Limited", 5, 100, 5, 100 * time.Millisecond, true},
+		{"Negative Attempts", -1, 0, 0, 0, false},
+		{"Negative Delay", 0, -1, 0, 0, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{
+				Info: &Info{Version: "1.1.0"},
+				Configuration: &Configuration{
+					Sbi:                    &Sbi{Scheme: "http", BindingIPv4: "127.0.0.9", Port: 8000},
+					DbConnectorType:        "mongodb",
+					Mongodb:                &Mongodb{Name: "free5gc", Url: "mongodb://127.0.0.1:27017"},
+					NrfUri:                 "http://127.0.0.10:8000",
+					NrfRegisterMaxAttempts: tc.maxAttempts,
+					NrfRegisterRetryDelay:  tc.retryDelay,
+				},
+				Logger: &Logger{Level: "info"},
+			}
+			valid, err := cfg.Validate()
+			require.Equal(t, tc.valid, valid, err)
+			if !tc.valid {
+				return
+			}
+			maxAttempts, retryDelay := cfg.GetNrfRegisterRetry()
+			require.Equal(t, tc.expectedMaxAttempts, maxAttempts)
+			require.Equal(t, tc.expectedRetryDelay, retryDelay)
+		})
+	}
+}
+
+func TestConfig_GracefulShutdownTimeout(t *testing.T) {
+	seconds := func(value int) *int {
+		return &value
+	}
+	testCases := []struct {
+		name                    string
+		gracefulShutdownTimeout *int
+		shutdownTimeout         int
+		expected                time.Duration
+		valid                   bool
+	}{
+		{"Default", nil, 0, UdrDefaultShutdownTimeout * time.Second, true},
+		{"Graceful", seconds(10), 0, 10 * time.Second, true},
+		{"Wait Indefinitely", seconds(0), 0, 0, true},
+		{"Deprecated Alias", nil, 5, 5 * time.Second, true},
+		{"Graceful Over Alias", seconds(10), 5, 10 * time.Second, true},
+		{"Negative", seconds(-1), 0, 0, false},
+		{"Alias Out Of Range", nil, 3601, 0, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{
+				Info: &Info{Version: "1.1.0"},
+				Configuration: &Configuration{
+					Sbi: &Sbi{
+						Scheme: "http", BindingIPv4: "127.0.0.9", Port: 8000, ShutdownTimeout: tc.shutdownTimeout,
+					},
+					DbConnectorType:         "mongodb",
+					Mongodb:                 &Mongodb{Name: "free5gc", Url: "mongodb://127.0.0.1:27017"},
+					NrfUri:                  "http://127.0.0.10:8000",
+					GracefulShutdownTimeout: tc.gracefulShutdownTimeout,
+				},
+				Logger: &Logger{Level: "info"},
+			}
+			valid, err := cfg.Validate()
+			require.Equal(t, tc.valid, valid, err)
+			if !tc.valid {
+				return
+			}
+			require.Equal(t, tc.expected, cfg.GetGracefulShutdownTimeout())
+		})
+	}
+}
+
+func TestConfig_AuditAndAdmin(t *testing.T) {
+	testCases := []struct {
+		name  string
+		audit *Audit
+		admin *Admin
+		debug *Debug
+		valid bool
+	}{
+		{"Default", &Audit{Enable: true}, &Admin{BindAddr: "127.0.0.1:8010"}, nil, true},
+		{"Negative Retention", &Audit{Enable: true, Retention: -1}, nil, nil, false},
+		{"Negative Queue Size", &Audit{Enable: true, QueueSize: -1}, nil, nil, false},
+		{"Admin Without Port", nil, &Admin{BindAddr: "127.0.0.1"}, nil, false},
+		{"Admin On SBI", nil, &Admin{BindAddr: "0.0.0.0:8000"}, nil, false},
+		{"Admin On Debug", nil, &Admin{BindAddr: "127.0.0.1:8010"},
+			&Debug{Pprof: true, BindAddr: "127.0.0.1:8010"}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{
+				Info: &Info{Version: "1.1.0"},
+				Configuration: &Configuration{
+					Sbi:             &Sbi{Scheme: "http", BindingIPv4: "127.0.0.9", Port: 8000},
+					DbConnectorType: "mongodb",
+					Mongodb:         &Mongodb{Name: "free5gc", Url: "mongodb://127.0.0.1:27017"},
+					NrfUri:          "http://127.0.0.10:8000",
+					Audit:           tc.audit,
+					Admin:           tc.admin,
+					Debug:           tc.debug,
+				},
+				Logger: &Logger{Level: "info"},
+			}
+			valid, err := cfg.Validate()
+			require.Equal(t, tc.valid, valid, err)
+			if tc.valid {
+				require.True(t, cfg.IsAuditEnabled())
+				require.Equal(t, UdrDefaultAuditRetention*24*time.Hour, cfg.GetAuditRetention())
+				require.Equal(t, UdrDefaultAuditQueueSize, cfg.GetAuditQueueSize())
+				require.Equal(t, "127.0.0.1:8010", cfg.GetAdminBindAddr())
+			}
+		})
+	}
+}
+
+func TestConfig_NfProfile(t *testing.T) {
+	testCases := []struct {
+		name      string
+		nfProfile *NfProfile
+		valid     bool
+	}{
+		{"Absent", nil, true},
+		{"Load Distribution", &NfProfile{
+			PlmnList: []PlmnId{{Mcc: "208", Mnc: "93"}, {Mcc: "466", Mnc: "092"}},
+			Priority: 10, Capacity: 100, Locality: "site-a",
+		}, true},
+		{"Short MCC", &NfProfile{PlmnList: []PlmnId{{Mcc: "20", Mnc: "93"}}}, false},
+		{"Long MNC", &NfProfile{PlmnList: []PlmnId{{Mcc: "208", Mnc: "9301"}}}, false},
+		{"Non Digit MNC", &NfProfile{PlmnList: []PlmnId{{Mcc: "208", Mnc: "9a"}}}, false},
+		{"Negative Priority", &NfProfile{Priority: -1}, false},
+		{"Capacity Above Range", &NfProfile{Capacity: 65536}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{
+				Info: &Info{Version: "1.1.0"},
+				Configuration: &Configuration{
+					Sbi:             &Sbi{Scheme: "http", BindingIPv4: "127.0.0.1", Port: 8000},
+					DbConnectorType: "mongodb",
+					Mongodb:         &Mongodb{Name: "free5gc", Url: "mongodb://127.0.0.1:27017"},
+					NrfUri:          "http://127.0.0.10:8000",
+					NfProfile:       tc.nfProfile,
+				},
+				Logger: &Logger{Level: "info"},
+			}
+			valid, err := cfg.Validate()
+			require.Equal(t, tc.valid, valid, err)
+			if tc.valid && tc.nfProfile != nil {
+				require.Equal(t, *tc.nfProfile, cfg.GetNfProfile())
+			}
+		})
+	}
+}
+
+func TestConfig_ValidateListsEveryProblem(t *testing.T) {
+	testCases := []struct {
+		name     string
+		sbi      Sbi
+		mongodb  *Mongodb
+		problems []string
+	}{
+		{
+			"Valid",
+			Sbi{Scheme: "https", BindingIPv4: "127.0.0.1", Port: 8000, Tls: &Tls{Pem: "cert/udr.pem", Key: "cert/udr.key"}},
+			&Mongodb{Name: "free5gc", Url: "mongodb://127.0.0.1:27017"},
+			nil,
+		},
+		{
+			"Unknown Scheme And Port Out Of Range",
+			Sbi{Scheme: "ftp", BindingIPv4: "127.0.0.1", Port: 70000},
+			&Mongodb{Name: "free5gc"},
+			[]string{"does not validate as scheme", "does not validate as port", "Url: non zero value required"},
+		},
+		{
+			"HTTPS Without TLS",
+			Sbi{Scheme: "https", BindingIPv4: "127.0.0.1", Port: 8000},
+			nil,
+			[]string{"sbi tls: pem and key should be provided", "mongodb: should be provided"},
+		},
+		{
+			"HTTPS Binding Without TLS",
+			Sbi{Scheme: "http", Port: 8000, Bindings: []*SbiBinding{
+				{BindingIP: "127.0.0.1", Port: 8000, Scheme: "http"},
+				{BindingIP: "127.0.0.2", Port: 8443, Scheme: "https"},
+			}},
+			&Mongodb{Name: "free5gc", Url: "mongodb://127.0.0.1:27017", MaxPoolSize: -1},
+			[]string{"sbi bindings[1] tls: pem and key should be provided", "maxPoolSize: -1 should not be negative"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{
+				Info: &Info{Version: "1.1.0"},
+				Configuration: &Configuration{
+					Sbi:             &tc.sbi,
+					DbConnectorType: "mongodb",
+					Mongodb:         tc.mongodb,
+					NrfUri:          "http://127.0.0.10:8000",
+				},
+				Logger: &Logger{Level: "info"},
+			}
+			valid, err := cfg.Validate()
+			require.Equal(t, len(tc.problems) == 0, valid, err)
+			for _, problem := range tc.problems {
+				require.ErrorContains(t, err, problem)
+			}
+		})
+	}
+}
+
+func TestConfig_Reload(t *testing.T) {
+	const content = `
+info:
+  version: 1.1.0
+configuration:
+  sbi:
+    scheme: http
+    bindingIPv4: %s
+    port: 8000
+  dbConnectorType: mongodb
+  mongodb:
+    name: free5gc
+    url: mongodb://127.0.0.1:27017
+  nrfUri: http://127.0.0.10:8000
+logger:
+  enable: true
+  level: %s
+  levels:
+    SBI: %s
+`
+	cfgPath := filepath.Join(t.TempDir(), "udrcfg.yaml")
+	require.NoError(t, os.WriteFile(cfgPath, fmt.Appendf(nil, content, "127.0.0.1", "info", "info"), 0o600))
+	cfg, err := ReadConfig(cfgPath)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(cfgPath, fmt.Appendf(nil, content, "127.0.0.2", "warn", "debug"), 0o600))
+	ignored, err := cfg.Reload()
+	require.NoError(t, err)
+	require.Equal(t, []string{"configuration.sbi"}, ignored)
+	require.Equal(t, "warn", cfg.GetLogLevel())
+	require.Equal(t, map[string]string{"SBI": "debug"}, cfg.GetLogCategoryLevels())
+	require.Equal(t, "127.0.0.1:8000", cfg.GetSbiBindings()[0].GetBindingAddr())
+
+	require.NoError(t, os.WriteFile(cfgPath, fmt.Appendf(nil, content, "127.0.0.1", "warn", "verbose"), 0o600))
+	_, err = cfg.Reload()
+	require.Error(t, err)
+	require.Equal(t, map[string]string{"SBI": "debug"}, cfg.GetLogCategoryLevels())
+}
@@ -7,6 +7,8 @@ package factory
 import (
 	"fmt"
 	"os"
+	"reflect"
+	"strings"
 
 	"github.com/asaskevich/govalidator"
 	"gopkg.in/yaml.v2"
@@ -35,7 +37,7 @@ func InitConfigFactory(f string, cfg *Config) error {
 }
 
 func ReadConfig(cfgPath string) (*Config, error) {
-	cfg := &Config{}
+	cfg := &Config{path: cfgPath}
 	if err := InitConfigFactory(cfgPath, cfg); err != nil {
 		return nil, fmt.Errorf("ReadConfig [%s] Error: %+v", cfgPath, err)
 	}
@@ -50,3 +52,57 @@ func ReadConfig(cfgPath string) (*Config, error) {
 
 	return cfg, nil
 }
+
+// Reload reads the config file again and takes the fields below from it, the other fields are only read on
+// startup and their changes are returned, to be reported as ignored until the UDR restarts:
+//   - logger.level
+//   - logger.levels, the levels of the SBI and GIN categories
+//   - logger.reportCaller
+//
+// The SBI bindings and TLS settings in particular are never reloaded, the certificate is reloaded on its own.
+func (c *Config) Reload() (ignored []string, err error) {
+	newCfg, err := ReadConfig(c.path)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	ignored = changedFields("info", c.Info, newCfg.Info)
+	ignored = append(ignored, changedFields("configuration", c.Configuration, newCfg.Configuration)...)
+	startupLogger := *newCfg.Logger
+	startupLogger.Level, startupLogger.Levels, startupLogger.ReportCaller =
+		c.Logger.Level, c.Logger.Levels, c.Logger.ReportCaller
+	ignored = append(ignored, changedFields("logger", c.Logger, &startupLogger)...)
+
+	c.Logger.Level = newCfg.Logger.Level
+	c.Logger.Levels = newCfg.Logger.Levels
+	c.Logger.ReportCaller = newCfg.Logger.ReportCaller
+	return ignored, nil
+}
+
+// changedFields returns the fields of the structs pointed to by cur and next which differ, by their yaml path
+func changedFields(path string, cur, next any) []string {
+	curValue, nextValue := reflect.ValueOf(cur), reflect.ValueOf(next)
+	if curValue.IsNil() || nextValue.IsNil() {
+		if curValue.IsNil() != nextValue.IsNil() {
+			return []string{path}
+		}
+		return nil
+	}
+
+	var fields []string
+	curValue, nextValue = curValue.Elem(), nextValue.Elem()
+	for i := 0; i < curValue.NumField(); i++ {
+		field := curValue.Type().Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "" {
+			continue
+		}
+		if !reflect.DeepEqual(curValue.Field(i).Interface(), nextValue.Field(i).Interface()) {
+			fields = append(fields, path+"."+name)
+		}
+	}
+	return fields
+}
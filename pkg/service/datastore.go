@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	db "github.com/free5gc/udr/internal/database"
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/pkg/factory"
+	"github.com/free5gc/util/mongoapi"
+)
+
+// connectDataStore connects to MongoDB and waits for it to answer, retrying with an exponential backoff
+// as MongoDB may start along with the UDR. The memory datastore has nothing to connect to.
+func (a *UdrApp) connectDataStore(ctx context.Context) error {
+	if a.cfg.Configuration.DbConnectorType == db.DBCONNECTOR_TYPE_MEMORY {
+		logger.InitLog.Warnf("The documents are kept in memory, they are lost when the UDR stops")
+		return nil
+	}
+	mongodb := a.cfg.Configuration.Mongodb
+	maxAttempts, retryDelay := a.cfg.GetMongodbConnectRetry()
+	maxRetryDelay := factory.UdrMongodbMaxConnectRetryDelay * time.Second
+
+	return retryWithBackoff(ctx, maxAttempts, retryDelay, maxRetryDelay, func(attempt int) error {
+		if err := mongoapi.SetMongoDB(mongodb.Name, a.cfg.GetMongodbUrl()); err != nil {
+			logger.InitLog.Warnf("Connect to MongoDB attempt %d/%d failed: %+v", attempt, maxAttempts, err)
+			return err
+		}
+
+		pingCtx, cancel := context.WithTimeout(ctx, a.cfg.GetProbeMongoPingTimeout())
+		defer cancel()
+		if err := a.processor.Ping(pingCtx); err != nil {
+			logger.InitLog.Warnf("Connect to MongoDB attempt %d/%d failed: %+v", attempt, maxAttempts, err)
+			return err
+		}
+		logger.InitLog.Infof("Connected to MongoDB on attempt %d/%d", attempt, maxAttempts)
+		return nil
+	})
+}
+
+// ensureIndexes creates the indexes of the UDR queries, it is idempotent so that it runs on every startup.
+// A failure only slows the queries down and is not fatal.
+func (a *UdrApp) ensureIndexes(ctx context.Context) {
+	if a.cfg.IsMongodbIndexCreationSkipped() {
+		logger.InitLog.Infof("MongoDB index creation skipped by config")
+		return
+	}
+	indexes := db.UdrIndexes
+	if a.cfg.IsAuditEnabled() {
+		indexes = append(append([]db.Index{}, indexes...), db.AuditIndexes(a.cfg.GetAuditRetention())...)
+	}
+	if err := a.processor.DropIndexes(ctx, db.StaleIndexes); err != nil {
+		logger.InitLog.Warnf("Drop stale MongoDB indexes failed: %+v", err)
+	}
+	if err := a.processor.EnsureIndexes(ctx, indexes); err != nil {
+		logger.InitLog.Warnf("Create MongoDB indexes failed: %+v", err)
+		return
+	}
+	logger.InitLog.Infof("MongoDB indexes ensured")
+}
+
+// migrateDataStore rewrites the documents a former release stored in another layout. It runs before the indexes
+// are ensured, whose unique keys the former documents lack. A failure leaves those documents unread.
+func (a *UdrApp) migrateDataStore(ctx context.Context) {
+	migrated, err := a.processor.MigrateGroupMembership(ctx)
+	if err != nil {
+		logger.InitLog.Warnf("Migrate the MongoDB group memberships failed after %d of them: %+v", migrated, err)
+		return
+	}
+	if migrated > 0 {
+		logger.InitLog.Infof("Migrated %d MongoDB group memberships", migrated)
+	}
+}
+
+// detectTransactions tells whether the multi-document writes, e.g. the removal of a subscriber, are atomic.
+// They are on a replica set or a sharded cluster, on a standalone server they are applied one by one.
+func (a *UdrApp) detectTransactions(ctx context.Context) {
+	supported, err := a.processor.DetectTransactions(ctx)
+	switch {
+	case err != nil:
+		logger.InitLog.Warnf("Detect MongoDB transaction support failed, transactions will be attempted: %+v", err)
+	case supported:
+		logger.InitLog.Infof("MongoDB supports transactions")
+	default:
+		logger.InitLog.Warnf("MongoDB is a standalone server, the multi-document writes are not atomic")
+	}
+}
+
+// retryWithBackoff calls try until it succeeds, at most maxAttempts times or with no limit when maxAttempts
+// is 0. The delay before the second attempt is retryDelay, doubled for each of the next ones up to
+// maxRetryDelay.
+func retryWithBackoff(ctx context.Context, maxAttempts int, retryDelay, maxRetryDelay time.Duration,
+	try func(attempt int) error,
+) error {
+	var err error
+	for attempt := 1; maxAttempts == 0 || attempt <= maxAttempts; attempt++ {
+		if err = try(attempt); err == nil {
+			return nil
+		}
+		if attempt == maxAttempts {
+			break
+		}
+
+		timer := time.NewTimer(retryDelay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		retryDelay = min(2*retryDelay, maxRetryDelay)
+	}
+	return fmt.Errorf("gave up after %d attempts: %w", maxAttempts, err)
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryWithBackoff(t *testing.T) {
+	errNotReady := errors.New("server selection timeout")
+
+	var attempts []time.Time
+	err := retryWithBackoff(context.Background(), 4, 10*time.Millisecond, time.Second, func(attempt int) error {
+		attempts = append(attempts, time.Now())
+		if attempt < 3 {
+			return errNotReady
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, attempts, 3)
+	require.GreaterOrEqual(t, attempts[1].Sub(attempts[0]), 10*time.Millisecond)
+	require.GreaterOrEqual(t, attempts[2].Sub(attempts[1]), 20*time.Millisecond)
+
+	calls := 0
+	err = retryWithBackoff(context.Background(), 3, time.Millisecond, time.Second, func(attempt int) error {
+		calls++
+		return errNotReady
+	})
+	require.ErrorIs(t, err, errNotReady)
+	require.Equal(t, 3, calls)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = retryWithBackoff(ctx, 3, time.Hour, time.Hour, func(attempt int) error {
+		return errNotReady
+	})
+	require.ErrorIs(t, err, context.Canceled)
+
+	// No limit on the attempts
+	attempts = nil
+	err = retryWithBackoff(context.Background(), 0, 5*time.Millisecond, 10*time.Millisecond, func(attempt int) error {
+		attempts = append(attempts, time.Now())
+		if attempt < 5 {
+			return errNotReady
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, attempts, 5)
+	require.GreaterOrEqual(t, attempts[4].Sub(attempts[3]), 10*time.Millisecond)
+}
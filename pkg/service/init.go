@@ -5,8 +5,11 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"runtime/debug"
 	"sync"
+	"syscall"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
@@ -15,14 +18,15 @@ import (
 	"github.com/free5gc/openapi/nrf/NFManagement"
 	udr_context "github.com/free5gc/udr/internal/context"
 	"github.com/free5gc/udr/internal/logger"
+	udr_metrics "github.com/free5gc/udr/internal/metrics"
 	"github.com/free5gc/udr/internal/sbi"
 	"github.com/free5gc/udr/internal/sbi/consumer"
 	"github.com/free5gc/udr/internal/sbi/processor"
+	"github.com/free5gc/udr/internal/util"
 	"github.com/free5gc/udr/pkg/app"
 	"github.com/free5gc/udr/pkg/factory"
 	"github.com/free5gc/util/metrics"
 	"github.com/free5gc/util/metrics/utils"
-	"github.com/free5gc/util/mongoapi"
 )
 
 type UdrApp struct {
@@ -42,6 +46,11 @@ type UdrApp struct {
 var _ app.App = &UdrApp{}
 
 func NewApp(ctx context.Context, cfg *factory.Config, tlsKeyLogPath string) (*UdrApp, error) {
+	// Refuse to start rather than failing once serving
+	if _, err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
 	udr_context.Init()
 
 	udr := &UdrApp{
@@ -53,7 +62,10 @@ func NewApp(ctx context.Context, cfg *factory.Config, tlsKeyLogPath string) (*Ud
 
 	udr.SetLogEnable(cfg.GetLogEnable())
 	udr.SetLogLevel(cfg.GetLogLevel())
+	udr.SetLogCategoryLevels(cfg.GetLogCategoryLevels())
 	udr.SetReportCaller(cfg.GetLogReportCaller())
+	logger.SetAccessLogJSON(cfg.GetAccessLogFormat() == factory.UdrAccessLogFormatJSON)
+	util.InitSbiClient(cfg)
 
 	processor := processor.NewProcessor(udr)
 	udr.processor = processor
@@ -61,16 +73,20 @@ func NewApp(ctx context.Context, cfg *factory.Config, tlsKeyLogPath string) (*Ud
 	consumer := consumer.NewConsumer(udr)
 	udr.consumer = consumer
 
-	udr.sbiServer = sbi.NewServer(udr, tlsKeyLogPath)
+	var err error
+	if udr.sbiServer, err = sbi.NewServer(udr, tlsKeyLogPath, sbi.WithNfTypeResolver(consumer.GetNfType)); err != nil {
+		return nil, err
+	}
 
 	features := map[utils.MetricTypeEnabled]bool{utils.SBI: true}
 	customMetrics := make(map[utils.MetricTypeEnabled][]prometheus.Collector)
 	if cfg.AreMetricsEnabled() {
-		var err error
+		customMetrics[utils.SBI] = udr_metrics.GetUdrMetrics(cfg.GetMetricsNamespace())
 		if udr.metricsServer, err = metrics.NewServer(
 			getInitMetrics(cfg, features, customMetrics), tlsKeyLogPath, logger.InitLog); err != nil {
 			return nil, err
 		}
+		udr_metrics.EnableUdrMetrics()
 	}
 
 	return udr, nil
@@ -120,9 +136,9 @@ func (a *UdrApp) SetLogEnable(enable bool) {
 	}
 	a.cfg.SetLogEnable(enable)
 	if enable {
-		logger.Log.SetOutput(os.Stderr)
+		logger.SetOutput(os.Stderr)
 	} else {
-		logger.Log.SetOutput(io.Discard)
+		logger.SetOutput(io.Discard)
 	}
 }
 
@@ -137,7 +153,7 @@ func (a *UdrApp) SetLogLevel(level string) {
 		return
 	}
 	a.cfg.SetLogLevel(level)
-	logger.Log.SetLevel(lvl)
+	logger.SetLevel(lvl)
 }
 
 func (a *UdrApp) SetReportCaller(reportCaller bool) {
@@ -146,7 +162,56 @@ func (a *UdrApp) SetReportCaller(reportCaller bool) {
 		return
 	}
 	a.cfg.SetLogReportCaller(reportCaller)
-	logger.Log.SetReportCaller(reportCaller)
+	logger.SetReportCaller(reportCaller)
+}
+
+// SetLogCategoryLevels sets the levels of the log categories set apart from the log level
+func (a *UdrApp) SetLogCategoryLevels(levels map[string]string) {
+	lvls := make(map[string]logrus.Level, len(levels))
+	for category, level := range levels {
+		lvl, err := logrus.ParseLevel(level)
+		if err != nil {
+			logger.MainLog.Warnf("Log level [%s] of %s is invalid", level, category)
+			continue
+		}
+		logger.MainLog.Infof("Log level of %s is set to [%s]", category, level)
+		lvls[category] = lvl
+	}
+	logger.SetCategoryLevels(lvls)
+}
+
+// listenReload reloads the config on SIGHUP
+func (a *UdrApp) listenReload(ctx context.Context) {
+	defer a.wg.Done()
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			a.reloadConfig()
+		}
+	}
+}
+
+// reloadConfig applies the fields of the config file changeable live, see factory.Config.Reload
+func (a *UdrApp) reloadConfig() {
+	ignored, err := a.cfg.Reload()
+	if err != nil {
+		logger.CfgLog.Errorf("Reload config failed, the current one is kept: %+v", err)
+		return
+	}
+	for _, field := range ignored {
+		logger.CfgLog.Warnf("Change of %s ignored on reload, it takes a restart", field)
+	}
+
+	a.SetLogLevel(a.cfg.GetLogLevel())
+	a.SetLogCategoryLevels(a.cfg.GetLogCategoryLevels())
+	a.SetReportCaller(a.cfg.GetLogReportCaller())
+	logger.CfgLog.Infof("Config reloaded")
 }
 
 func (u *UdrApp) registerToNrf(ctx context.Context) error {
@@ -154,7 +219,7 @@ func (u *UdrApp) registerToNrf(ctx context.Context) error {
 
 	nrfUri, nfId, err := u.consumer.SendRegisterNFInstance(ctx, udrContext.NrfUri)
 	if err != nil {
-		return fmt.Errorf("send register NFInstance error[%s]", err.Error())
+		return fmt.Errorf("send register NFInstance error: %w", err)
 	}
 	udrContext.NrfUri = nrfUri
 	udrContext.NfId = nfId
@@ -163,47 +228,46 @@ func (u *UdrApp) registerToNrf(ctx context.Context) error {
 }
 
 func (a *UdrApp) deregisterFromNrf() {
-	err := a.consumer.SendDeregisterNFInstance()
+	if a.cfg.IsNrfDeregistrationSkipped() {
+		logger.InitLog.Infof("Deregister from NRF skipped by config")
+		return
+	}
+
+	// A slow NRF must not hold the shutdown
+	ctx, cancel := context.WithTimeout(context.Background(), factory.UdrDefaultNrfDeregisterTimeout*time.Second)
+	defer cancel()
+
+	err := a.consumer.SendDeregisterNFInstance(ctx)
 	if err != nil {
 		switch apiErr := err.(type) {
 		case openapi.GenericOpenAPIError:
 			switch errModel := apiErr.Model().(type) {
 			case NFManagement.DeregisterNFInstanceError:
 				pd := &errModel.ProblemDetails
-				logger.InitLog.Errorf("Deregister NF instance Failed Problem[%+v]", pd)
-			case error:
-				logger.InitLog.Errorf("Deregister NF instance Error[%+v]", err)
+				logger.InitLog.Warnf("Deregister NF instance Failed Problem[%+v]", pd)
+			default:
+				logger.InitLog.Warnf("Deregister NF instance Error[%+v]", err)
 			}
-		case error:
-			logger.InitLog.Errorf("Deregister NF instance Error[%+v]", err)
+		default:
+			logger.InitLog.Warnf("Deregister NF instance Error[%+v]", err)
 		}
-		logger.InitLog.Errorf("Deregister NF instance Error[%+v]", err)
+		return
 	}
 
 	logger.InitLog.Infof("Deregister from NRF successfully")
 }
 
 func (a *UdrApp) Start() {
-	err := a.registerToNrf(a.ctx)
-	if err != nil {
-		logger.InitLog.Errorf("register to NRF failed: %v", err)
-	} else {
-		logger.InitLog.Infof("register to NRF successfully")
-	}
+	// Listen first, SIGHUP terminates the process otherwise
+	a.wg.Add(1)
+	go a.listenReload(a.ctx)
 
 	// get config file info
 	logger.InitLog.Infoln("Server started")
 	config := factory.UdrConfig
-	mongodb := config.Configuration.Mongodb
 
 	logger.InitLog.Infof("UDR Config Info: Version[%s] Description[%s]", config.Info.Version, config.Info.Description)
 
-	// Connect to MongoDB
-	if err := mongoapi.SetMongoDB(mongodb.Name, mongodb.Url); err != nil {
-		logger.InitLog.Errorf("UDR start set MongoDB error: %+v", err)
-		return
-	}
-
 	// Graceful deregister when panic
 	defer func() {
 		if p := recover(); p != nil {
@@ -212,7 +276,27 @@ func (a *UdrApp) Start() {
 		}
 	}()
 
-	a.sbiServer.Run(&a.wg)
+	// The SBI server starts before MongoDB is connected and the NF is registered to the NRF, so that the
+	// readiness probe answers 503 meanwhile
+	if err := a.sbiServer.Run(&a.wg); err != nil {
+		logger.InitLog.Errorf("UDR start SBI server error: %+v", err)
+		a.abortStart()
+		return
+	}
+
+	// The registration retries while MongoDB is connected, the heartbeat follows it
+	a.wg.Add(1)
+	go a.runNrfHeartbeat(a.ctx)
+
+	if err := a.connectDataStore(a.ctx); err != nil {
+		logger.InitLog.Errorf("UDR start connect MongoDB error: %+v", err)
+		a.abortStart()
+		return
+	}
+	a.detectTransactions(a.ctx)
+	a.migrateDataStore(a.ctx)
+	a.ensureIndexes(a.ctx)
+	a.sbiServer.SetReady(true)
 	if a.cfg.AreMetricsEnabled() && a.metricsServer != nil {
 		go func() {
 			a.metricsServer.Run(&a.wg)
@@ -225,6 +309,14 @@ func (a *UdrApp) Start() {
 	a.WaitRoutineStopped()
 }
 
+// abortStart terminates the UDR failing to start: the routines Start launched are cancelled first, so that the
+// retrying NRF registration gives up rather than land after the deregistration, then waited for
+func (a *UdrApp) abortStart() {
+	a.cancel()
+	a.terminateProcedure()
+	a.WaitRoutineStopped()
+}
+
 func (a *UdrApp) listenShutdown(ctx context.Context) {
 	defer a.wg.Done()
 
@@ -238,13 +330,14 @@ func (a *UdrApp) Terminate() {
 
 func (a *UdrApp) terminateProcedure() {
 	logger.MainLog.Infof("Terminating UDR...")
-	a.CallServerStop()
+	// Deregister first so that consumers stop being routed here while the SBI server drains
 	a.deregisterFromNrf()
+	a.CallServerStop()
 }
 
 func (a *UdrApp) CallServerStop() {
 	if a.sbiServer != nil {
-		a.sbiServer.Shutdown()
+		a.sbiServer.Shutdown(context.Background())
 	}
 
 	if a.metricsServer != nil {
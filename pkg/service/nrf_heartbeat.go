@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/udr/internal/logger"
+	"github.com/free5gc/udr/pkg/factory"
+)
+
+// heartbeatInterval returns the heartBeatTimer given by the NRF, or the configured default
+func (a *UdrApp) heartbeatInterval() time.Duration {
+	if heartBeatTimer := a.udrCtx.NrfHeartBeatTimer(); heartBeatTimer > 0 {
+		return time.Duration(heartBeatTimer) * time.Second
+	}
+	return a.cfg.GetNrfHeartbeatInterval()
+}
+
+// registerToNrfWithRetry registers the NF profile to the NRF, retrying with an exponential backoff as the NRF
+// may start along with the UDR. The readiness probe reports not ready until the registration succeeds.
+func (a *UdrApp) registerToNrfWithRetry(ctx context.Context) error {
+	maxAttempts, retryDelay := a.cfg.GetNrfRegisterRetry()
+	maxRetryDelay := factory.UdrNrfMaxRegisterRetryDelay * time.Second
+	attemptOf := func(attempt int) string {
+		if maxAttempts == 0 {
+			return strconv.Itoa(attempt)
+		}
+		return fmt.Sprintf("%d/%d", attempt, maxAttempts)
+	}
+
+	err := retryWithBackoff(ctx, maxAttempts, retryDelay, maxRetryDelay, func(attempt int) error {
+		if err := a.registerToNrf(ctx); err != nil {
+			logger.InitLog.Warnf("Register to NRF attempt %s failed: %+v", attemptOf(attempt), err)
+			return err
+		}
+		logger.InitLog.Infof("Register to NRF successfully on attempt %s", attemptOf(attempt))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	a.sbiServer.SetNrfRegistered(true)
+	return nil
+}
+
+// runNrfHeartbeat registers the NF profile to the NRF, then keeps it alive and registers it again once the
+// NRF lost it. The heartbeat starts as well when the registration gave up: the NRF answers it 404 until
+// the NF profile is registered.
+func (a *UdrApp) runNrfHeartbeat(ctx context.Context) {
+	defer a.wg.Done()
+
+	if err := a.registerToNrfWithRetry(ctx); err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		logger.InitLog.Errorf("Register to NRF failed: %+v", err)
+		if !a.cfg.IsProbeNrfRegistrationCheckSkipped() {
+			logger.InitLog.Warnf("UDR is not registered to NRF, the readiness probe reports not ready until it is")
+		}
+	}
+
+	interval := a.heartbeatInterval()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	logger.InitLog.Infof("NRF heartbeat started, interval %s", interval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.InitLog.Infof("NRF heartbeat stopped")
+			return
+		case <-ticker.C:
+		}
+
+		heartbeatCtx, cancel := context.WithTimeout(ctx, interval)
+		err := a.consumer.SendHeartbeat(heartbeatCtx)
+		cancel()
+		if err == nil {
+			a.sbiServer.SetNrfHeartbeat(time.Now())
+			continue
+		}
+
+		var apiErr openapi.GenericOpenAPIError
+		if !errors.As(err, &apiErr) || apiErr.ErrorStatus != http.StatusNotFound {
+			logger.InitLog.Warnf("NRF heartbeat failed: %+v", err)
+			continue
+		}
+
+		logger.InitLog.Warnf("Re-register to NRF: NF profile not found on heartbeat (%+v)", err)
+		a.sbiServer.SetNrfRegistered(false)
+		if err = a.registerToNrf(ctx); err != nil {
+			logger.InitLog.Errorf("Re-register to NRF failed: %+v", err)
+			continue
+		}
+		a.sbiServer.SetNrfRegistered(true)
+		logger.InitLog.Infof("Re-register to NRF successfully")
+
+		if newInterval := a.heartbeatInterval(); newInterval != interval {
+			interval = newInterval
+			ticker.Reset(interval)
+			logger.InitLog.Infof("NRF heartbeat interval changed to %s", interval)
+		}
+	}
+}